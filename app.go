@@ -3,13 +3,25 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"smartcalc/internal/calc"
+	"smartcalc/internal/data"
+	"smartcalc/internal/datetime"
 	"smartcalc/internal/eval"
+	"smartcalc/internal/export"
+	"smartcalc/internal/filesearch"
+	"smartcalc/internal/finance"
+	"smartcalc/internal/fraction"
+	"smartcalc/internal/percentage"
+	"smartcalc/internal/programmer"
 	"smartcalc/internal/updater"
+	"smartcalc/internal/userfuncs"
+	"smartcalc/internal/utils"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -22,24 +34,90 @@ type App struct {
 	recentFiles []string
 	hasUnsaved  bool
 	currentFile string
+	settings    Settings
+	deferred    *calc.DeferredScheduler
+	lookups     *calc.AsyncLookups
+	search      *filesearch.Searcher
+	recovery    *recoveryBuffer
+}
+
+// Settings holds user preferences persisted in the config directory
+type Settings struct {
+	// AmbiguousTimezones is "all" to list every candidate for IST/CST/BST,
+	// "default" to assume the most common one, or "strict" to require a
+	// region such as IST(India)
+	AmbiguousTimezones string `json:"ambiguousTimezones"`
+	// DefaultTaxRate is the sales tax rate in percent used by "$45.99 + tax";
+	// 0 means not set
+	DefaultTaxRate float64 `json:"defaultTaxRate"`
+	// DisabledEvaluators names the evaluators that are turned off, such as
+	// "whois" where network lookups are not allowed
+	DisabledEvaluators []string `json:"disabledEvaluators"`
+	// Fractions shows integer-ratio arithmetic as exact fractions: "1/2 (0.5)"
+	Fractions bool `json:"fractions"`
+	// DecimalMark is "comma" for numbers written 1.234,56, "period" for
+	// 1,234.56, or "auto" to follow the OS locale
+	DecimalMark string `json:"decimalMark"`
+	// Precision is the most decimal places results are shown with, 0 to 10.
+	// Currency amounts keep showing cents.
+	Precision int `json:"precision"`
+	// Language is the ISO 639-1 code of the language durations and date
+	// words are written in, or "auto" to follow the OS locale
+	Language string `json:"language"`
+	// NotesDir is a directory whose documents are searched along with the
+	// recent files; empty searches the recent files only
+	NotesDir string `json:"notesDir"`
+}
+
+// Decimal mark settings
+const (
+	DecimalMarkAuto   = "auto"
+	DecimalMarkPeriod = "period"
+	DecimalMarkComma  = "comma"
+)
+
+// LanguageAuto follows the language of the OS locale
+const LanguageAuto = "auto"
+
+// osLocale returns the locale of a POSIX category such as "LC_NUMERIC", by
+// the precedence of LC_ALL, the category and LANG
+func osLocale(category string) string {
+	for _, name := range []string{"LC_ALL", category, "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
-	app := &App{}
+	app := &App{
+		deferred: calc.NewDeferredScheduler(calc.DeferredDelay, calc.EvalLinesDeferred),
+		lookups:  calc.NewAsyncLookups(nil),
+		search:   filesearch.NewSearcher(),
+		recovery: newRecoveryBuffer(filepath.Join(getConfigPath(), recoveryFileName)),
+	}
 	app.loadRecentFiles()
+	app.loadSettings()
+	app.loadUserFunctions()
 	return app
 }
 
 // startup is called when the app starts
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	programmer.SetHashProgress(func(p programmer.HashProgress) {
+		runtime.EventsEmit(a.ctx, "hash:progress", p)
+	})
+	go a.recovery.run(ctx, recoveryInterval)
 }
 
 // beforeClose is called when the app is about to close
 // Returns true to prevent closing (if user cancels), false to allow closing
 func (a *App) beforeClose(ctx context.Context) (prevent bool) {
 	if !a.hasUnsaved {
+		a.recovery.clear()
 		return false // No unsaved changes, allow close
 	}
 
@@ -74,6 +152,7 @@ func (a *App) beforeClose(ctx context.Context) (prevent bool) {
 		runtime.EventsEmit(a.ctx, "app:saveAndQuit")
 		return true // Prevent close - frontend will call Quit after saving
 	case "Don't Save", "No":
+		a.recovery.clear()
 		return false // Allow close without saving
 	case "Cancel":
 		return true // Prevent close
@@ -122,6 +201,173 @@ func (a *App) saveRecentFiles() {
 	os.WriteFile(configPath, data, 0644)
 }
 
+// loadSettings loads user settings from config and applies them
+func (a *App) loadSettings() {
+	a.settings = Settings{AmbiguousTimezones: string(datetime.AmbiguityShowAll), DecimalMark: DecimalMarkAuto, Precision: utils.MaxDecimals, Language: LanguageAuto}
+	configPath := filepath.Join(getConfigPath(), "settings.json")
+	if data, err := os.ReadFile(configPath); err == nil {
+		json.Unmarshal(data, &a.settings)
+	}
+	a.applySettings()
+}
+
+// saveSettings saves user settings to config
+func (a *App) saveSettings() {
+	configDir := getConfigPath()
+	os.MkdirAll(configDir, 0755)
+	configPath := filepath.Join(configDir, "settings.json")
+	data, _ := json.Marshal(a.settings)
+	os.WriteFile(configPath, data, 0644)
+}
+
+// applySettings pushes settings into the evaluator packages
+func (a *App) applySettings() {
+	datetime.SetAmbiguityMode(datetime.AmbiguityMode(a.settings.AmbiguousTimezones))
+	a.settings.AmbiguousTimezones = string(datetime.GetAmbiguityMode())
+	percentage.SetDefaultTaxRate(a.settings.DefaultTaxRate)
+	a.settings.DefaultTaxRate = percentage.GetDefaultTaxRate()
+	calc.SetDisabledEvaluators(a.settings.DisabledEvaluators)
+	a.settings.DisabledEvaluators = calc.DisabledEvaluators()
+	fraction.SetEnabled(a.settings.Fractions)
+	utils.SetPrecision(a.settings.Precision)
+	a.settings.Precision = utils.Precision()
+	switch a.settings.DecimalMark {
+	case DecimalMarkPeriod, DecimalMarkComma:
+		utils.SetDecimalComma(a.settings.DecimalMark == DecimalMarkComma)
+	default:
+		a.settings.DecimalMark = DecimalMarkAuto
+		utils.SetDecimalComma(utils.DetectDecimalComma(osLocale("LC_NUMERIC")))
+	}
+	if a.settings.Language == "" || a.settings.Language == LanguageAuto {
+		a.settings.Language = LanguageAuto
+		utils.SetLanguage(utils.DetectLanguage(osLocale("LC_MESSAGES")))
+	} else {
+		utils.SetLanguage(a.settings.Language)
+		a.settings.Language = utils.Language()
+	}
+}
+
+// GetSettings returns the current user settings
+func (a *App) GetSettings() Settings {
+	return a.settings
+}
+
+// SetAmbiguousTimezoneMode sets how ambiguous time zone abbreviations are
+// handled ("all", "default" or "strict") and persists the choice
+func (a *App) SetAmbiguousTimezoneMode(mode string) {
+	a.settings.AmbiguousTimezones = mode
+	a.applySettings()
+	a.saveSettings()
+}
+
+// SetDefaultTaxRate sets the sales tax rate (in percent) used by "+ tax"
+// expressions and persists it
+func (a *App) SetDefaultTaxRate(rate float64) {
+	a.settings.DefaultTaxRate = rate
+	a.applySettings()
+	a.saveSettings()
+}
+
+// SetFractionMode turns fractions mode on or off and persists the choice
+func (a *App) SetFractionMode(enabled bool) {
+	a.settings.Fractions = enabled
+	a.applySettings()
+	a.saveSettings()
+}
+
+// SetDecimalMark sets how numbers are written ("auto", "period" or "comma")
+// and persists the choice
+func (a *App) SetDecimalMark(mark string) {
+	a.settings.DecimalMark = mark
+	a.applySettings()
+	a.saveSettings()
+}
+
+// SetLanguage sets the language durations and date words are written in (an
+// ISO 639-1 code such as "de", or "auto") and persists the choice
+func (a *App) SetLanguage(lang string) {
+	a.settings.Language = lang
+	a.applySettings()
+	a.saveSettings()
+}
+
+// SetPrecision sets the most decimal places results are shown with and
+// persists it. A line's own hint ("1/3 = :6") still overrides it.
+func (a *App) SetPrecision(decimals int) {
+	a.settings.Precision = decimals
+	a.applySettings()
+	a.saveSettings()
+}
+
+// SetNotesDir sets the directory searched along with the recent files and
+// persists it; empty searches the recent files only
+func (a *App) SetNotesDir(dir string) {
+	a.settings.NotesDir = dir
+	a.saveSettings()
+}
+
+// ChooseNotesDir asks for the directory searched along with the recent files
+// and persists it. Returns the directory, or empty string if the dialog was
+// cancelled.
+func (a *App) ChooseNotesDir() (string, error) {
+	dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:            "Choose Notes Folder",
+		DefaultDirectory: a.settings.NotesDir,
+	})
+	if err != nil || dir == "" {
+		return "", err
+	}
+	a.SetNotesDir(dir)
+	return dir, nil
+}
+
+// GetEvaluatorNames returns the names of the evaluators that can be turned off
+func (a *App) GetEvaluatorNames() []string {
+	return calc.EvaluatorNames()
+}
+
+// SetEvaluatorEnabled turns an evaluator on or off and persists the choice
+func (a *App) SetEvaluatorEnabled(name string, enabled bool) {
+	disabled := slices.DeleteFunc(slices.Clone(a.settings.DisabledEvaluators), func(n string) bool {
+		return n == name
+	})
+	if !enabled {
+		disabled = append(disabled, name)
+	}
+	a.settings.DisabledEvaluators = disabled
+	a.applySettings()
+	a.saveSettings()
+}
+
+// loadUserFunctions loads the functions defined in the config directory and
+// returns the problems found in the definitions file
+func (a *App) loadUserFunctions() []string {
+	_, errs := userfuncs.Load(a.GetUserFunctionsPath())
+	problems := make([]string, len(errs))
+	for i, err := range errs {
+		problems[i] = err.Error()
+	}
+	return problems
+}
+
+// ReloadUserFunctions reloads the functions defined in the config directory
+// without restarting, rebuilds the Snippets menu and returns the problems
+// found in the definitions file
+func (a *App) ReloadUserFunctions() []string {
+	problems := a.loadUserFunctions()
+	calc.ResetCache()
+	if a.ctx != nil {
+		runtime.MenuSetApplicationMenu(a.ctx, createAppMenu(a))
+		runtime.MenuUpdateApplicationMenu(a.ctx)
+	}
+	return problems
+}
+
+// GetUserFunctionsPath returns the path of the user functions definitions file
+func (a *App) GetUserFunctionsPath() string {
+	return filepath.Join(getConfigPath(), userfuncs.FileName)
+}
+
 // GetRecentFiles returns the list of recent files
 func (a *App) GetRecentFiles() []string {
 	return a.recentFiles
@@ -147,6 +393,34 @@ func (a *App) AddRecentFile(path string) {
 	a.saveLastFile(path)
 }
 
+// SearchRecentFiles finds the lines of the recent files, and of the
+// documents in the notes directory, that contain query, for the quick-open
+// palette. Lines whose expression is the query come first. Files are kept in
+// memory until they change, so this can run on every keystroke.
+func (a *App) SearchRecentFiles(query string) []filesearch.Match {
+	files := append(slices.Clone(a.recentFiles), filesearch.NotesFiles(a.settings.NotesDir)...)
+	return a.search.Search(files, query)
+}
+
+// FileAtLine is a file to show in the editor, scrolled to a line. It is
+// emitted as "file:openAtLine".
+type FileAtLine struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+	Line    int    `json:"line"` // 1-based
+}
+
+// OpenFileAtLine loads a file and tells the editor to show it scrolled to
+// line, 1-based
+func (a *App) OpenFileAtLine(path string, line int) error {
+	content, err := a.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	runtime.EventsEmit(a.ctx, "file:openAtLine", FileAtLine{Path: path, Content: content, Line: max(line, 1)})
+	return nil
+}
+
 // GetLastFile returns the last opened file path
 func (a *App) GetLastFile() string {
 	configPath := filepath.Join(getConfigPath(), "lastfile.txt")
@@ -175,7 +449,11 @@ func (a *App) AutoSave(path, content string) error {
 	if path == "" {
 		return nil
 	}
-	return os.WriteFile(path, []byte(content), 0644)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return err
+	}
+	a.recovery.clear()
+	return nil
 }
 
 // AdjustReferences adjusts line references when lines are added or removed
@@ -183,6 +461,12 @@ func (a *App) AdjustReferences(oldText, newText string) string {
 	return eval.AdjustReferences(oldText, newText)
 }
 
+// MoveLines moves lines start..end (1-based) of the document by delta lines,
+// negative for up, and renumbers the references to the lines that moved
+func (a *App) MoveLines(text string, start, end, delta int) string {
+	return eval.MoveLines(text, start, end, delta)
+}
+
 // EvalResult represents a single line evaluation result
 type EvalResult struct {
 	LineNum int    `json:"lineNum"`
@@ -190,13 +474,95 @@ type EvalResult struct {
 	Output  string `json:"output"`
 }
 
+// DeferredEvalResult carries the results of a deferred evaluation pass.
+// Source is the document text the fast pass produced; the frontend only
+// applies Results if the editor still shows exactly that text.
+type DeferredEvalResult struct {
+	Source  string       `json:"source"`
+	Results []EvalResult `json:"results"`
+}
+
+// AsyncEvalResult is the result of one network line, emitted as
+// "calc:asyncResult" when its lookup lands. The frontend replaces line
+// LineNum with Output only if it still shows Placeholder.
+type AsyncEvalResult struct {
+	LineNum     int    `json:"lineNum"`
+	Token       uint64 `json:"token"`
+	Placeholder string `json:"placeholder"`
+	Output      string `json:"output"`
+}
+
 // Evaluate evaluates all lines and returns results
 // activeLineNum is 1-based line number of the line currently being edited (skip formatting for this line)
 // Pass 0 or negative to format all lines
+// Only local evaluators run here; lines that need the network show a pending
+// marker. Certificate, DNS, WHOIS, GeoIP and "my ip" lines are looked up in
+// the background, each emitted as "calc:asyncResult" as soon as it lands; the
+// other pending lines are filled in by a debounced deferred pass, emitted as
+// "eval:deferred".
 func (a *App) Evaluate(text string, activeLineNum int) []EvalResult {
 	lines := strings.Split(text, "\n")
-	results := calc.EvalLines(lines, activeLineNum)
+	results := calc.EvalLinesFast(lines, activeLineNum)
+	evalResults := toEvalResults(lines, results)
+
+	a.lookups.Start(lines, activeLineNum, results, func(r calc.AsyncResult) {
+		runtime.EventsEmit(a.ctx, "calc:asyncResult", AsyncEvalResult{
+			LineNum:     r.LineNum,
+			Token:       r.Token,
+			Placeholder: r.Placeholder,
+			Output:      r.Output,
+		})
+	})
+	if !calc.NeedsDeferredPass(lines, results) {
+		a.deferred.Cancel()
+		return evalResults
+	}
+
+	outputs := make([]string, len(results))
+	for i, r := range results {
+		outputs[i] = r.Output
+	}
+	source := strings.Join(outputs, "\n")
+	a.deferred.Schedule(lines, activeLineNum, func(deferred []calc.LineResult) {
+		runtime.EventsEmit(a.ctx, "eval:deferred", DeferredEvalResult{
+			Source:  source,
+			Results: toEvalResults(lines, deferred),
+		})
+	})
+	return evalResults
+}
+
+// RefreshDocument re-evaluates the whole document so volatile lines (now,
+// today, random, roll, uuid, my ip) and the lines depending on them are updated
+func (a *App) RefreshDocument(text string) []EvalResult {
+	a.deferred.Cancel()
+	a.lookups.Cancel()
+	lines := strings.Split(text, "\n")
+	return toEvalResults(lines, calc.RefreshLines(lines))
+}
+
+// RefreshProgress reports how far a network refresh has got, emitted as
+// "refresh:progress" after each lookup
+type RefreshProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// RefreshNetworkLines looks up the certificate, DNS, WHOIS, GeoIP and "my ip"
+// lines of the document again. Evaluate keeps their results once shown, so
+// this is how stale lookups are updated.
+func (a *App) RefreshNetworkLines(text string) []EvalResult {
+	a.deferred.Cancel()
+	a.lookups.Cancel()
+	lines := strings.Split(text, "\n")
+	results := calc.RefreshNetworkLines(lines, calc.NetworkRefreshWorkers, func(done, total int) {
+		runtime.EventsEmit(a.ctx, "refresh:progress", RefreshProgress{Done: done, Total: total})
+	})
+	return toEvalResults(lines, results)
+}
 
+// toEvalResults converts line results for the frontend
+func toEvalResults(lines []string, results []calc.LineResult) []EvalResult {
 	evalResults := make([]EvalResult, len(results))
 	for i, r := range results {
 		evalResults[i] = EvalResult{
@@ -208,6 +574,24 @@ func (a *App) Evaluate(text string, activeLineNum int) []EvalResult {
 	return evalResults
 }
 
+// GetAmortization returns the payment schedule of a mortgage or loan line,
+// for the frontend to show as a table
+func (a *App) GetAmortization(expr string) (finance.Amortization, error) {
+	return finance.ScheduleFor(expr)
+}
+
+// RenderTemplate fills in the placeholders of a template snippet with the
+// values the user entered, for the frontend to insert
+func (a *App) RenderTemplate(content string, values map[string]string) (string, error) {
+	return data.RenderTemplate(content, values)
+}
+
+// GetDocumentStats returns result, error and assertion counts for the document
+func (a *App) GetDocumentStats(text string) calc.DocumentStats {
+	lines := strings.Split(text, "\n")
+	return calc.GetDocumentStats(lines)
+}
+
 // GetVersion returns the app version
 func (a *App) GetVersion() string {
 	return version
@@ -237,6 +621,39 @@ func (a *App) SaveFileDialog() (string, error) {
 	})
 }
 
+// ExportDocument renders text as Markdown ("markdown") or HTML ("html") and
+// writes it to a path chosen in a save dialog. Returns the path, or empty
+// string if the dialog was cancelled.
+func (a *App) ExportDocument(text, format string) (string, error) {
+	var content, ext string
+	var filter runtime.FileFilter
+	switch format {
+	case "markdown":
+		content, ext = export.ExportMarkdown(text), ".md"
+		filter = runtime.FileFilter{DisplayName: "Markdown Files", Pattern: "*.md"}
+	case "html":
+		content, ext = export.ExportHTML(text), ".html"
+		filter = runtime.FileFilter{DisplayName: "HTML Files", Pattern: "*.html"}
+	default:
+		return "", fmt.Errorf("unknown export format: %s", format)
+	}
+
+	name := "untitled"
+	if a.currentFile != "" {
+		base := filepath.Base(a.currentFile)
+		name = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export",
+		DefaultFilename: name + ext,
+		Filters:         []runtime.FileFilter{filter},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	return path, os.WriteFile(path, []byte(content), 0644)
+}
+
 // ReadFile reads a file and returns its contents
 func (a *App) ReadFile(path string) (string, error) {
 	data, err := os.ReadFile(path)
@@ -251,11 +668,31 @@ func (a *App) WriteFile(path, content string) error {
 	return os.WriteFile(path, []byte(content), 0644)
 }
 
+// SaveDocument saves a document, first adding today's result of each
+// "!track" line to its history. Returns the content as written, which the
+// editor shows from then on.
+func (a *App) SaveDocument(path, content string) (string, error) {
+	lines := calc.RecordHistory(strings.Split(content, "\n"), datetime.Now())
+	content = strings.Join(lines, "\n")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", err
+	}
+	a.recovery.clear()
+	return content, nil
+}
+
 // CopyWithResolvedRefs copies text with references replaced by values
 func (a *App) CopyWithResolvedRefs(text string) string {
 	return calc.ReplaceRefsWithValues(text)
 }
 
+// CopyFormatted copies text with references replaced by values, laid out in
+// a style: "plain", "aligned" ('=' signs lined up and results right-aligned)
+// or "results-only" (one result per line, for pasting into a spreadsheet)
+func (a *App) CopyFormatted(text, style string) (string, error) {
+	return export.CopyFormatted(text, style)
+}
+
 // ShowInfoDialog shows an information dialog with the given title and message
 func (a *App) ShowInfoDialog(title, message string) {
 	runtime.MessageDialog(a.ctx, runtime.MessageDialogOptions{
@@ -265,6 +702,19 @@ func (a *App) ShowInfoDialog(title, message string) {
 	})
 }
 
+// ShowQuestionDialog asks a yes/no question and reports if the answer was yes
+func (a *App) ShowQuestionDialog(title, message string) bool {
+	result, err := runtime.MessageDialog(a.ctx, runtime.MessageDialogOptions{
+		Type:    runtime.QuestionDialog,
+		Title:   title,
+		Message: message,
+	})
+	if err != nil {
+		return false
+	}
+	return result == "Yes" || result == "OK"
+}
+
 // StripLineResult removes the result from a line, keeping expression, '=' and inline comment
 func (a *App) StripLineResult(line string) string {
 	return calc.StripResult(line)
@@ -275,6 +725,27 @@ func (a *App) HasLineResult(line string) bool {
 	return calc.HasResult(line)
 }
 
+// GetDependencyGraph returns which lines each line reads from and is read by,
+// and the groups of lines that form reference cycles
+func (a *App) GetDependencyGraph(text string) calc.DependencyInfo {
+	lines := strings.Split(text, "\n")
+	return calc.GetDependencyInfo(lines)
+}
+
+// GetOutline returns the headings, named variables and labeled lines of the
+// document, for the outline sidebar
+func (a *App) GetOutline(text string) []calc.OutlineEntry {
+	lines := strings.Split(text, "\n")
+	return calc.GetOutline(lines)
+}
+
+// GetPinnedResults returns the lines ending with "pin", with their labels and
+// results, for the pinned results panel. The text is the document as the
+// editor shows it; nothing is evaluated again.
+func (a *App) GetPinnedResults(text string) []calc.PinnedResult {
+	return calc.PinnedResults(strings.Split(text, "\n"))
+}
+
 // FindDependentLines returns line numbers (1-based) that depend on the given line
 func (a *App) FindDependentLines(text string, changedLine int) []int {
 	lines := strings.Split(text, "\n")
@@ -287,16 +758,7 @@ func (a *App) FindDependentLines(text string, changedLine int) []int {
 func (a *App) EvaluateLines(text string, changedLine int) []EvalResult {
 	lines := strings.Split(text, "\n")
 	results := calc.EvalLines(lines, 0)
-
-	evalResults := make([]EvalResult, len(results))
-	for i, r := range results {
-		evalResults[i] = EvalResult{
-			LineNum: i + 1,
-			Input:   lines[i],
-			Output:  r.Output,
-		}
-	}
-	return evalResults
+	return toEvalResults(lines, results)
 }
 
 // StripAndEvalReferencingLines strips results from lines with references and re-evaluates them
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"smartcalc/internal/calc"
+	"smartcalc/internal/currency"
+)
+
+// runCLI handles the command-line subcommands. It returns false when args
+// name none, so the app starts normally.
+func runCLI(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	if args[0] == "outline" {
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, "usage: smartcalc outline FILE")
+			os.Exit(2)
+		}
+		data, err := os.ReadFile(args[1])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "smartcalc:", err)
+			os.Exit(1)
+		}
+		fmt.Print(calc.FormatOutline(calc.GetOutline(strings.Split(string(data), "\n"))))
+		return true
+	}
+	if isEvalCommand(args) {
+		if code := runEval(args, os.Stdin, os.Stdout, os.Stderr); code != 0 {
+			os.Exit(code)
+		}
+		return true
+	}
+	return false
+}
+
+// isEvalCommand checks if args ask for a headless evaluation with --eval
+func isEvalCommand(args []string) bool {
+	for _, arg := range args {
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if strings.HasPrefix(arg, "-") && name == "eval" {
+			return true
+		}
+	}
+	return false
+}
+
+// runEval evaluates a document without the GUI and prints it to stdout:
+// "smartcalc --eval notes.txt", or "--eval -" for stdin. --no-network turns
+// the lines that reach other hosts into "ERR: network disabled" and keeps
+// exchange rates to the cached ones; --strict fails when a line is an error.
+// It returns the exit status: 0, 1 when --strict finds an error or the input
+// can't be read, and 2 for bad usage.
+func runEval(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("smartcalc", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	path := fs.String("eval", "", "evaluate `FILE` and print it, - for standard input")
+	noNetwork := fs.Bool("no-network", false, "don't reach other hosts; their lines show ERR: network disabled")
+	strict := fs.Bool("strict", false, "exit with status 1 if any line evaluates to ERR")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: smartcalc --eval FILE [--no-network] [--strict]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *path == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var data []byte
+	var err error
+	if *path == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(*path)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "smartcalc:", err)
+		return 1
+	}
+
+	if *noNetwork {
+		calc.SetNetworkDisabled(true)
+		currency.SetRateSource(func() (*currency.Rates, error) {
+			return nil, calc.ErrNetworkDisabled
+		})
+		defer func() {
+			calc.SetNetworkDisabled(false)
+			currency.SetRateSource(nil)
+		}()
+	}
+
+	text, results := calc.EvalText(string(data))
+	fmt.Fprint(stdout, text)
+	if *strict {
+		for _, r := range results {
+			if r.HasError() {
+				return 1
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsEvalCommand(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected bool
+	}{
+		{[]string{"--eval", "notes.txt"}, true},
+		{[]string{"--strict", "-eval=-"}, true},
+		{[]string{"outline", "notes.txt"}, false},
+		{[]string{"eval", "notes.txt"}, false},
+		{[]string{"--evaluate"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := isEvalCommand(tt.args); got != tt.expected {
+			t.Errorf("isEvalCommand(%q) = %v, want %v", tt.args, got, tt.expected)
+		}
+	}
+}
+
+func TestRunEval(t *testing.T) {
+	input := "2+2 =\ndns example.com =\n"
+	var stdout, stderr bytes.Buffer
+	code := runEval([]string{"--no-network", "--eval", "-"}, strings.NewReader(input), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit status = %d, stderr %q", code, stderr.String())
+	}
+	want := "2 + 2 = 4\ndns example.com = ERR: network disabled\n"
+	if stdout.String() != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+
+	// Only --strict fails on an error line
+	stdout.Reset()
+	code = runEval([]string{"--eval", "-", "--strict", "--no-network"}, strings.NewReader(input), &stdout, &stderr)
+	if code != 1 {
+		t.Errorf("--strict exit status = %d, want 1", code)
+	}
+	code = runEval([]string{"--eval", "-", "--strict"}, strings.NewReader("10 / 4 =\n"), &stdout, &stderr)
+	if code != 0 {
+		t.Errorf("--strict exit status without errors = %d, want 0", code)
+	}
+}
+
+func TestRunEvalFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("rent = $1800 =\nrent * 12 =\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := runEval([]string{"--eval", path}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit status = %d, stderr %q", code, stderr.String())
+	}
+	if want := "rent = $1800 = $1,800.00\nrent * 12 = $21,600.00\n"; stdout.String() != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"--eval", filepath.Join(t.TempDir(), "missing.txt")}, 1},
+		{[]string{"--eval"}, 2},
+		{[]string{"--eval", path, "extra"}, 2},
+		{[]string{"--eval", path, "--bogus"}, 2},
+	}
+	for _, tt := range tests {
+		if code := runEval(tt.args, nil, &stdout, &stderr); code != tt.code {
+			t.Errorf("runEval(%q) = %d, want %d", tt.args, code, tt.code)
+		}
+	}
+}
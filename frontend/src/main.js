@@ -4,7 +4,7 @@ import { EditorState, RangeSetBuilder } from '@codemirror/state';
 import { keymap, Decoration, ViewPlugin } from '@codemirror/view';
 import { defaultKeymap, history, historyKeymap } from '@codemirror/commands';
 import { lineNumbers, highlightActiveLineGutter, highlightActiveLine } from '@codemirror/view';
-import { Evaluate, GetVersion, OpenFileDialog, SaveFileDialog, ReadFile, WriteFile, AddRecentFile, GetLastFile, AutoSave, AdjustReferences, CopyWithResolvedRefs, SetUnsavedState, Quit, StripLineResult, HasLineResult, EvaluateLines, StripAndEvalReferencingLines, GetGitHubRepoURL, CheckForUpdates, OpenURL } from '../wailsjs/go/main/App';
+import { Evaluate, GetVersion, OpenFileDialog, SaveFileDialog, ReadFile, SaveDocument, AddRecentFile, GetLastFile, AutoSave, AdjustReferences, CopyWithResolvedRefs, CopyFormatted, SetUnsavedState, Quit, StripLineResult, HasLineResult, EvaluateLines, StripAndEvalReferencingLines, RefreshDocument, RefreshNetworkLines, ExportDocument, GetGitHubRepoURL, CheckForUpdates, OpenURL, MoveLines, SearchRecentFiles, OpenFileAtLine, MarkDirty, CheckRecovery, DiscardRecovery, ShowQuestionDialog, GetPinnedResults, RenderTemplate } from '../wailsjs/go/main/App';
 import { EventsOn, ClipboardGetText, ClipboardSetText } from '../wailsjs/runtime/runtime';
 
 let editor;
@@ -18,6 +18,7 @@ let isUpdatingEditor = false; // Flag to prevent re-entry during programmatic up
 let savedContent = ''; // Content at last save, to detect unsaved changes
 let lastActiveLine = 1; // Track the last active line number (1-based)
 let pendingEvaluation = false; // Track if we need to evaluate when leaving line
+let queuedAsyncResults = []; // Network results that landed while the editor was being updated
 const AUTOSAVE_DELAY = 2000; // 2 seconds after last change
 
 // Dark theme (Tokyo Night inspired)
@@ -164,6 +165,13 @@ function buildDecorations(view) {
             builder.add(from, line.to, commentMark);
             continue;
         }
+
+        // Sheet directives like "@precision 4"
+        const directive = text.match(/^\s*@\w*/);
+        if (directive) {
+            builder.add(from, from + directive[0].length, keywordMark);
+            continue;
+        }
         
         // Check for inline comment (# after =)
         const eqIndex = text.indexOf('=');
@@ -196,8 +204,8 @@ function buildDecorations(view) {
             const closeMarker = text.lastIndexOf('»', pos);
             const inRegexMatch = openMarker >= 0 && (closeMarker < openMarker);
             
-            // Line references \1, \2, etc.
-            const refMatch = remaining.match(/^\\[0-9]+/);
+            // Line references \1, \2, etc., and ranges \1..\5
+            const refMatch = remaining.match(/^\\[0-9]+(?:\.\.\\[0-9]+)?/);
             if (refMatch) {
                 builder.add(from + pos, from + pos + refMatch[0].length, referenceMark);
                 pos += refMatch[0].length;
@@ -206,7 +214,7 @@ function buildDecorations(view) {
             }
             
             // Currency with amount $1,234.56
-            const currencyMatch = remaining.match(/^\$[\d,]+\.?\d*/);
+            const currencyMatch = remaining.match(/^[$€£¥₹₴₽₩][\d.,]*\d/);
             if (currencyMatch) {
                 builder.add(from + pos, from + pos + currencyMatch[0].length, currencyMark);
                 pos += currencyMatch[0].length;
@@ -258,7 +266,7 @@ function buildDecorations(view) {
             }
             
             // Functions
-            const funcMatch = remaining.match(/^(sin|cos|tan|sqrt|abs|log|ln|exp|floor|ceil|round|min|max|avg|average|mean|median|sum|stddev|stdev|variance|var|count|range)\s*\(/i);
+            const funcMatch = remaining.match(/^(sin|cos|tan|sqrt|abs|log|ln|exp|floor|ceil|round|min|max|avg|average|mean|median|sum|stddev|stdev|variance|var|count|range|percentile|mode|weightedavg)\s*\(/i);
             if (funcMatch) {
                 builder.add(from + pos, from + pos + funcMatch[1].length, functionMark);
                 pos += funcMatch[1].length;
@@ -267,7 +275,7 @@ function buildDecorations(view) {
             }
             
             // Keywords
-            const kwMatch = remaining.match(/^(jwt|cert|ssl|chmod|umask|permissions?|symbolic|octal|setuid|setgid|sticky|regex|test|match|against|now|today|yesterday|tomorrow|in|to|till|from|split|subnets?|networks?|hosts?|mask|wildcard|how\s+many|is|Range|Broadcast|what|percent|percentage|increase|decrease|tip|loan|mortgage|compound|simple|interest|invest|avg|average|mean|median|sum|stddev|stdev|variance|count|range|ascii|char|uuid|md5|sha1|sha256|base64|encode|decode|random|and|or|xor|not|speed\s+of\s+light|gravity|pi|avogadro|planck|golden\s+ratio|value\s+of|dig|nslookup|dns|lookup|resolve|whois)\b/i);
+            const kwMatch = remaining.match(/^(bmi|bmr|target\s+heart\s+rate|bpm|pace|half\s+marathon|marathon|how\s+far|crosswind|density\s+altitude|fuel|endurance|rwy|calories|kcal|concrete|paint|mulch|dim\s+weight|dimensional\s+weight|volumetric\s+weight|actual|fit|gravel|topsoil|coats?|deep|thick|events|trend|measured|expected|error\s+of|within|cron|every|next|last|beginning\s+of|start\s+of|end\s+of|net|preset|verify|jwks|bits|(?:set|clear|toggle|test)\s+bit|cost\s+of|kwh|compare|upper|lower|title|camel|snake|kebab|reverse|length|count\s+(?:words|chars)|wordcount|word\s+count|reading\s+time|describe|pods?|cores?|cpu|storage|runway|how\s+long|how\s+much|gpa|letter|grade|credits?|odds|probability|decimal|fractional|american|totp|digits|fraction|mixed\s+number|jwt|cert|ssl|headers|http|status|chmod|umask|permissions?|symbolic|octal|setuid|setgid|sticky|regex|test|match|against|now|today|yesterday|tomorrow|week\s+number|day\s+of\s+year|leap\s+year|in|to|till|until|since|from|split|subnets?|networks?|hosts?|mask|wildcard|how\s+many|is|Range|Broadcast|what|percent|percentage|increase|decrease|tip|loan|mortgage|balance\s+start|data|col(?:umn)?|compound|simple|interest|invest|avg|average|mean|median|sum|stddev|stdev|variance|count|range|percentile|mode|weighted|ascii|char|uuid|md5|sha1|sha256|verify|base64|encode|decode|random|roll|drop|lowest|highest|sample|and|or|xor|not|molar\s+mass|moles?|mass\s+of|speed\s+of\s+light|gravity|pi|avogadro|planck|golden\s+ratio|value\s+of|resistors?|parallel|series|divider|led|voltage\s+drop|wire\s+gauge|gauge|awg|add|combine|difference|lighten|darken|mix|contrast|dig|nslookup|dns|lookup|resolve|whois|ping|port|open\s+on|country\s+code|(?:calling|dialing|dial|phone)\s+code|currency|time\s*zones?)\b/i);
             if (kwMatch) {
                 builder.add(from + pos, from + pos + kwMatch[0].length, keywordMark);
                 pos += kwMatch[0].length;
@@ -338,10 +346,17 @@ function handleEnterKey(view) {
     const lineNumber = line.number;
     const cursorColumn = pos - line.from;
     
-    // Skip empty lines and comments - just insert newline
+    // A comment with calc(...) spans is evaluated when Enter ends the line
+    if (/^\s*#.*\bcalc\(/.test(lineText) && cursorColumn === lineText.length) {
+        handleEnterKeyAsync(view, lineNumber, false);
+        return true;
+    }
+
+    // Skip empty lines, comments and directives - just insert newline
     if (lineText.trim().length === 0 || 
         lineText.trim().startsWith('#') || 
-        lineText.trim().startsWith('//')) {
+        lineText.trim().startsWith('//') ||
+        lineText.trim().startsWith('@')) {
         return false; // Let default handler insert newline
     }
     
@@ -421,12 +436,65 @@ async function evaluateAndUpdate(lineNumber) {
     }
 }
 
-// Custom keymap for Enter key
+// Move the selected lines up (-1) or down (1). The backend renumbers the
+// references to every line that changes place, and the result is applied as
+// one change so a single undo restores the document.
+function handleMoveLines(view, delta) {
+    const { from, to } = view.state.selection.main;
+    const start = view.state.doc.lineAt(from).number;
+    const end = view.state.doc.lineAt(to).number;
+    if (start + delta < 1 || end + delta > view.state.doc.lines) {
+        return true;
+    }
+    moveLinesAsync(view, start, end, delta);
+    return true;
+}
+
+async function moveLinesAsync(view, start, end, delta) {
+    const text = view.state.doc.toString();
+    try {
+        const moved = await MoveLines(text, start, end, delta);
+        if (moved === text || view.state.doc.toString() !== text) {
+            return;
+        }
+        isUpdatingEditor = true;
+        const doc = view.state.doc;
+        const cursorLine = doc.lineAt(view.state.selection.main.head);
+        const column = view.state.selection.main.head - cursorLine.from;
+        view.dispatch({
+            changes: { from: 0, to: doc.length, insert: moved },
+        });
+        const newLine = view.state.doc.line(cursorLine.number + delta);
+        view.dispatch({
+            selection: { anchor: newLine.from + Math.min(column, newLine.length) },
+            scrollIntoView: true,
+        });
+        previousText = moved;
+        previousLineCount = moved.split('\n').length;
+        updateUnsavedState();
+        scheduleAutosave();
+    } catch (err) {
+        console.error('Move lines error:', err);
+    } finally {
+        isUpdatingEditor = false;
+    }
+    evaluateContent();
+}
+
+// Custom keymap for Enter and for moving lines
 const customKeymap = keymap.of([
     {
         key: 'Enter',
         run: handleEnterKey,
     },
+    {
+        key: 'Alt-ArrowUp',
+        run: (view) => handleMoveLines(view, -1),
+    },
+    {
+        key: 'Alt-ArrowDown',
+        run: (view) => handleMoveLines(view, 1),
+    },
 ]);
 
 // Initialize editor
@@ -630,11 +698,12 @@ async function stripCurrentLineResult() {
     const cursorColumn = cursorPos - line.from;
     const eqPos = findResultEqualsPos(lineText);
     
-    // If cursor was in the result area, move it to after '='
+    // If cursor was after the '=', shift it left by the removed result, but
+    // not past the '=' (prose lines keep their text after the fragment)
     let newCursorColumn = cursorColumn;
     if (eqPos >= 0 && cursorColumn > eqPos + 1) {
-        // Cursor was after the result, put it right after '='
-        newCursorColumn = eqPos + 1;
+        const removed = lineText.length - strippedLine.length;
+        newCursorColumn = Math.max(eqPos + 1, cursorColumn - removed);
     }
     
     // Find all dependent lines and strip their results too
@@ -723,6 +792,20 @@ async function evaluateLineAndDependents(lineNumber) {
     }
 }
 
+// Count the lines that differ between two texts with the same line count.
+// Typing changes one line; a move changes at least two.
+function changedLineCount(oldText, newText) {
+    const oldLines = oldText.split('\n');
+    const newLines = newText.split('\n');
+    let changed = 0;
+    for (let i = 0; i < Math.min(oldLines.length, newLines.length); i++) {
+        if (oldLines[i] !== newLines[i]) {
+            changed++;
+        }
+    }
+    return changed;
+}
+
 // Check if line count changed and adjust references
 // currentText/currentLineCount are the current state
 // snapshotPreviousText is the state BEFORE the change (captured at moment of change)
@@ -753,8 +836,8 @@ async function checkAndAdjustReferences(currentText, currentLineCount, snapshotP
     const prevLineCount = oldText.split('\n').length;
     const currLineCount = currentText.split('\n').length;
     
-    // If line count changed, adjust references
-    if (prevLineCount !== currLineCount) {
+    // If line count changed, or lines were moved (dragged), adjust references
+    if (prevLineCount !== currLineCount || changedLineCount(oldText, currentText) > 1) {
         try {
             const adjusted = await AdjustReferences(oldText, currentText);
             if (adjusted !== currentText) {
@@ -811,6 +894,10 @@ function updateUnsavedState() {
     const currentContent = editor.state.doc.toString();
     const hasUnsaved = currentContent !== savedContent;
     SetUnsavedState(hasUnsaved, currentFile);
+    if (hasUnsaved) {
+        // Kept in the recovery file in case the app crashes before a save
+        MarkDirty(currentContent);
+    }
 }
 
 // Schedule autosave after delay
@@ -842,6 +929,7 @@ async function evaluateContent() {
     } finally {
         isUpdatingEditor = false;
     }
+    flushAsyncResults();
 }
 
 // Internal evaluate function (does not manage flag)
@@ -853,69 +941,214 @@ async function evaluateContentInternal() {
         const activeLineNum = editor.state.doc.lineAt(cursorPos).number;
         
         const results = await Evaluate(text, activeLineNum);
+        applyResults(text, results);
+    } catch (err) {
+        console.error('Evaluation error:', err);
+    }
+}
+
+// Re-evaluate the whole document so volatile lines (now, random, roll, uuid, my ip)
+// and the lines that reference them are brought up to date together
+async function refreshDocument() {
+    isUpdatingEditor = true;
+    try {
+        const text = editor.state.doc.toString();
+        const results = await RefreshDocument(text);
+        applyResults(text, results);
+    } catch (err) {
+        console.error('Refresh error:', err);
+    } finally {
+        isUpdatingEditor = false;
+    }
+}
+
+// Look up every certificate, DNS, WHOIS, ping and GeoIP line again. The results are
+// dropped if the document was edited while the lookups ran.
+async function refreshNetworkResults() {
+    const progress = document.getElementById('refresh-progress');
+    progress.textContent = 'Refreshing network results…';
+    progress.classList.remove('hidden');
+    try {
+        const text = editor.state.doc.toString();
+        const results = await RefreshNetworkLines(text);
+        if (editor.state.doc.toString() !== text) {
+            return;
+        }
+        isUpdatingEditor = true;
+        try {
+            applyResults(text, results);
+        } finally {
+            isUpdatingEditor = false;
+        }
+    } catch (err) {
+        console.error('Network refresh error:', err);
+    } finally {
+        progress.classList.add('hidden');
+    }
+}
+
+// Show how many network lines have been looked up so far
+function showRefreshProgress(p) {
+    document.getElementById('refresh-progress').textContent = `Refreshing network results… ${p.done}/${p.total}`;
+}
+
+// Show how much of a large file has been hashed, hiding the status once done
+function showHashProgress(p) {
+    const progress = document.getElementById('refresh-progress');
+    const name = p.path.split(/[\\/]/).pop();
+    progress.textContent = `Hashing ${name}… ${Math.floor(100 * p.done / p.total)}%`;
+    progress.classList.toggle('hidden', p.done >= p.total);
+}
+
+// Patch in the results of a deferred (network) evaluation pass, but only if
+// the document hasn't changed since the fast pass that scheduled it
+function applyDeferredResults(deferred) {
+    if (editor.state.doc.toString() !== deferred.source) {
+        return;
+    }
+    isUpdatingEditor = true;
+    try {
+        applyResults(deferred.source, deferred.results);
+    } finally {
+        isUpdatingEditor = false;
+    }
+    flushAsyncResults();
+}
+
+// Fill in a certificate, DNS, WHOIS, ping, GeoIP or "my ip" line whose lookup ran in
+// the background. The result is dropped if the line no longer shows the
+// placeholder it was fetched for, as it has been edited since.
+function applyAsyncResult(result) {
+    if (isUpdatingEditor) {
+        queuedAsyncResults.push(result);
+        return;
+    }
+    const doc = editor.state.doc;
+    if (result.lineNum > doc.lines || doc.line(result.lineNum).text !== result.placeholder) {
+        return;
+    }
+    const line = doc.line(result.lineNum);
+    // Keep the expression as typed so the cursor on it doesn't move
+    let from = 0;
+    while (from < line.text.length && line.text[from] === result.output[from]) {
+        from++;
+    }
+    isUpdatingEditor = true;
+    try {
+        editor.dispatch({
+            changes: { from: line.from + from, to: line.to, insert: result.output.slice(from) },
+        });
+    } finally {
+        isUpdatingEditor = false;
+    }
+    updatePinnedPanel();
+}
+
+// Apply the network results that landed while the editor was being updated
+function flushAsyncResults() {
+    const queued = queuedAsyncResults;
+    queuedAsyncResults = [];
+    queued.forEach(applyAsyncResult);
+}
+
+// Replace the editor content with evaluation results, keeping cursor and scroll
+function applyResults(text, results) {
+    // Build new content from results
+    const newLines = results.map(r => r.output);
+    const newText = newLines.join('\n');
+    
+    // Only update if different (to avoid cursor jump)
+    if (newText !== text) {
+        // Save scroll position and cursor line/column
+        const scrollTop = editor.scrollDOM.scrollTop;
+        const scrollLeft = editor.scrollDOM.scrollLeft;
+        const cursorPos = editor.state.selection.main.head;
+        const cursorLine = editor.state.doc.lineAt(cursorPos);
+        const lineNumber = cursorLine.number;
+        const columnOffset = cursorPos - cursorLine.from;
         
-        // Build new content from results
-        const newLines = results.map(r => r.output);
-        const newText = newLines.join('\n');
+        // Detect if multi-line output was added (new lines starting with ">")
+        const oldOutputLines = text.split('\n').filter(l => l.startsWith('>')).length;
+        const newOutputLines = newText.split('\n').filter(l => l.startsWith('>')).length;
+        const hasNewMultiLineOutput = newOutputLines > oldOutputLines && newOutputLines > 1;
         
-        // Only update if different (to avoid cursor jump)
-        if (newText !== text) {
-            // Save scroll position and cursor line/column
-            const scrollTop = editor.scrollDOM.scrollTop;
-            const scrollLeft = editor.scrollDOM.scrollLeft;
-            const cursorPos = editor.state.selection.main.head;
-            const cursorLine = editor.state.doc.lineAt(cursorPos);
-            const lineNumber = cursorLine.number;
-            const columnOffset = cursorPos - cursorLine.from;
-            
-            // Detect if multi-line output was added (new lines starting with ">")
-            const oldOutputLines = text.split('\n').filter(l => l.startsWith('>')).length;
-            const newOutputLines = newText.split('\n').filter(l => l.startsWith('>')).length;
-            const hasNewMultiLineOutput = newOutputLines > oldOutputLines && newOutputLines > 1;
-            
+        editor.dispatch({
+            changes: { from: 0, to: editor.state.doc.length, insert: newText },
+        });
+        
+        // Restore cursor position based on line number
+        const newDoc = editor.state.doc;
+        if (lineNumber <= newDoc.lines) {
+            const newLine = newDoc.line(lineNumber);
+            const newPos = newLine.from + Math.min(columnOffset, newLine.length);
             editor.dispatch({
-                changes: { from: 0, to: editor.state.doc.length, insert: newText },
+                selection: { anchor: newPos },
             });
-            
-            // Restore cursor position based on line number
-            const newDoc = editor.state.doc;
-            if (lineNumber <= newDoc.lines) {
-                const newLine = newDoc.line(lineNumber);
-                const newPos = newLine.from + Math.min(columnOffset, newLine.length);
+        } else {
+            // If line doesn't exist, go to end
+            editor.dispatch({
+                selection: { anchor: newText.length },
+            });
+        }
+        
+        // Scroll handling after update
+        requestAnimationFrame(() => {
+            if (hasNewMultiLineOutput) {
+                // Scroll to show the last output line
+                const lastLineNum = newDoc.lines;
+                const lastLine = newDoc.line(lastLineNum);
+                // Use scrollIntoView effect to scroll the last line into view
                 editor.dispatch({
-                    selection: { anchor: newPos },
+                    effects: EditorView.scrollIntoView(lastLine.from, { y: 'end' })
                 });
             } else {
-                // If line doesn't exist, go to end
-                editor.dispatch({
-                    selection: { anchor: newText.length },
-                });
+                // Restore previous scroll position
+                editor.scrollDOM.scrollTop = scrollTop;
+                editor.scrollDOM.scrollLeft = scrollLeft;
             }
-            
-            // Scroll handling after update
-            requestAnimationFrame(() => {
-                if (hasNewMultiLineOutput) {
-                    // Scroll to show the last output line
-                    const lastLineNum = newDoc.lines;
-                    const lastLine = newDoc.line(lastLineNum);
-                    // Use scrollIntoView effect to scroll the last line into view
-                    editor.dispatch({
-                        effects: EditorView.scrollIntoView(lastLine.from, { y: 'end' })
-                    });
-                } else {
-                    // Restore previous scroll position
-                    editor.scrollDOM.scrollTop = scrollTop;
-                    editor.scrollDOM.scrollLeft = scrollLeft;
-                }
-            });
-            
-            // Update previous text to the evaluated result
-            previousText = newText;
-            previousLineCount = newText.split('\n').length;
-        }
+        });
+        
+        // Update previous text to the evaluated result
+        previousText = newText;
+        previousLineCount = newText.split('\n').length;
+    }
+    updatePinnedPanel();
+}
+
+// Show the lines ending with "pin" in the pinned results panel, which stays
+// in view while the document scrolls and is hidden while there are none
+async function updatePinnedPanel() {
+    const panel = document.getElementById('pinned-panel');
+    const list = document.getElementById('pinned-list');
+    let pinned = [];
+    try {
+        pinned = (await GetPinnedResults(editor.state.doc.toString())) || [];
     } catch (err) {
-        console.error('Evaluation error:', err);
+        console.error('Pinned results error:', err);
     }
+    list.innerHTML = '';
+    pinned.forEach(p => {
+        const item = document.createElement('li');
+        item.className = 'pinned-item';
+        item.title = `Line ${p.line}`;
+        const label = document.createElement('span');
+        label.className = 'pinned-label';
+        label.textContent = p.label;
+        const result = document.createElement('span');
+        result.className = 'pinned-result';
+        result.textContent = p.result;
+        item.append(label, result);
+        // Jump to the pinned line
+        item.addEventListener('click', () => {
+            const doc = editor.state.doc;
+            if (p.line <= doc.lines) {
+                editor.dispatch({ selection: { anchor: doc.line(p.line).from }, scrollIntoView: true });
+                editor.focus();
+            }
+        });
+        list.appendChild(item);
+    });
+    panel.classList.toggle('hidden', pinned.length === 0);
 }
 
 // Keyboard shortcuts
@@ -935,6 +1168,11 @@ function handleKeyboard(e) {
         e.preventDefault();
         saveFileAs();
     }
+    // Ctrl+P - Find in recent files
+    if (e.ctrlKey && e.key === 'p') {
+        e.preventDefault();
+        showQuickOpen();
+    }
     // Ctrl+N - New
     if (e.ctrlKey && e.key === 'n') {
         e.preventDefault();
@@ -967,26 +1205,189 @@ async function openFile() {
 async function openFilePath(path) {
     try {
         const content = await ReadFile(path);
-        editor.dispatch({
-            changes: { from: 0, to: editor.state.doc.length, insert: content },
+        await loadDocument(path, content);
+    } catch (err) {
+        console.error('Open error:', err);
+    }
+}
+
+// Show a file's content in the editor as the saved current file
+async function loadDocument(path, content) {
+    editor.dispatch({
+        changes: { from: 0, to: editor.state.doc.length, insert: content },
+    });
+    currentFile = path;
+    savedContent = content; // Mark as saved
+    updateFileName();
+    evaluateContent();
+    await AddRecentFile(path);
+    SetUnsavedState(false, currentFile);
+}
+
+// Show a file sent by OpenFileAtLine with the cursor on its line
+async function openFileAtLine(file) {
+    try {
+        await loadDocument(file.path, file.content);
+        const doc = editor.state.doc;
+        const line = doc.line(Math.min(Math.max(file.line, 1), doc.lines));
+        editor.dispatch({ selection: { anchor: line.from }, scrollIntoView: true });
+        editor.focus();
+    } catch (err) {
+        console.error('Open error:', err);
+    }
+}
+
+// Quick open: search the lines of the recent files as you type and open
+// the file of the chosen match at its line
+const QUICK_OPEN_DELAY = 80; // ms to wait for more typing before searching
+let quickOpenMatches = [];
+let quickOpenSelected = 0;
+let quickOpenTimer = null;
+
+function showQuickOpen() {
+    const overlay = document.getElementById('quick-open');
+    const input = document.getElementById('quick-open-input');
+    overlay.classList.remove('hidden');
+    input.select();
+    input.focus();
+    runQuickOpenSearch();
+}
+
+function hideQuickOpen() {
+    clearTimeout(quickOpenTimer);
+    document.getElementById('quick-open').classList.add('hidden');
+    editor.focus();
+}
+
+async function runQuickOpenSearch() {
+    const input = document.getElementById('quick-open-input');
+    const query = input.value;
+    try {
+        const matches = await SearchRecentFiles(query);
+        if (input.value !== query) {
+            return; // a newer search is on its way
+        }
+        quickOpenMatches = matches || [];
+        quickOpenSelected = 0;
+        renderQuickOpen();
+    } catch (err) {
+        console.error('Search error:', err);
+    }
+}
+
+function renderQuickOpen() {
+    const list = document.getElementById('quick-open-results');
+    list.innerHTML = '';
+    quickOpenMatches.forEach((match, i) => {
+        const item = document.createElement('li');
+        item.className = 'quick-open-item' + (match.exact ? ' exact' : '') + (i === quickOpenSelected ? ' selected' : '');
+        const snippet = document.createElement('span');
+        snippet.className = 'quick-open-snippet';
+        snippet.textContent = match.snippet;
+        const location = document.createElement('span');
+        location.className = 'quick-open-location';
+        location.textContent = `${match.path}:${match.line}`;
+        item.append(snippet, location);
+        item.addEventListener('mousedown', (e) => {
+            e.preventDefault();
+            openQuickOpenMatch(i);
         });
-        currentFile = path;
-        savedContent = content; // Mark as saved
-        updateFileName();
-        evaluateContent();
-        await AddRecentFile(path);
-        SetUnsavedState(false, currentFile);
+        list.appendChild(item);
+    });
+    list.children[quickOpenSelected]?.scrollIntoView({ block: 'nearest' });
+}
+
+async function openQuickOpenMatch(i) {
+    const match = quickOpenMatches[i];
+    if (!match) {
+        return;
+    }
+    hideQuickOpen();
+    try {
+        await OpenFileAtLine(match.path, match.line);
     } catch (err) {
         console.error('Open error:', err);
     }
 }
 
+function initQuickOpen() {
+    const overlay = document.getElementById('quick-open');
+    const input = document.getElementById('quick-open-input');
+    input.addEventListener('input', () => {
+        clearTimeout(quickOpenTimer);
+        quickOpenTimer = setTimeout(runQuickOpenSearch, QUICK_OPEN_DELAY);
+    });
+    input.addEventListener('keydown', (e) => {
+        switch (e.key) {
+            case 'ArrowDown':
+            case 'ArrowUp':
+                e.preventDefault();
+                if (quickOpenMatches.length > 0) {
+                    const step = e.key === 'ArrowDown' ? 1 : -1;
+                    quickOpenSelected = (quickOpenSelected + step + quickOpenMatches.length) % quickOpenMatches.length;
+                    renderQuickOpen();
+                }
+                break;
+            case 'Enter':
+                e.preventDefault();
+                openQuickOpenMatch(quickOpenSelected);
+                break;
+            case 'Escape':
+                e.preventDefault();
+                hideQuickOpen();
+                break;
+        }
+    });
+    overlay.addEventListener('mousedown', (e) => {
+        if (e.target === overlay) {
+            hideQuickOpen();
+        }
+    });
+}
+
+// Save the document to path and show it as saved. Saving adds today's
+// result of each "!track" line to its history, so the editor takes the
+// content that was written.
+async function saveDocument(path) {
+    const content = await SaveDocument(path, editor.state.doc.toString());
+    if (content !== editor.state.doc.toString()) {
+        isUpdatingEditor = true;
+        try {
+            // Only "> " history lines change, so the cursor stays on the same
+            // expression line, counted without the "> " lines
+            const doc = editor.state.doc;
+            const cursorLine = doc.lineAt(editor.state.selection.main.head);
+            const column = editor.state.selection.main.head - cursorLine.from;
+            let exprIndex = -1;
+            for (let n = 1; n <= cursorLine.number; n++) {
+                if (!doc.line(n).text.startsWith('>')) {
+                    exprIndex++;
+                }
+            }
+            editor.dispatch({
+                changes: { from: 0, to: doc.length, insert: content },
+            });
+            const newDoc = editor.state.doc;
+            let lineNum = 1;
+            for (let n = 1, seen = -1; n <= newDoc.lines && seen < exprIndex; n++) {
+                if (!newDoc.line(n).text.startsWith('>')) {
+                    seen++;
+                    lineNum = n;
+                }
+            }
+            const line = newDoc.line(lineNum);
+            editor.dispatch({ selection: { anchor: line.from + Math.min(column, line.length) } });
+        } finally {
+            isUpdatingEditor = false;
+        }
+    }
+    savedContent = content; // Mark as saved
+}
+
 async function saveFile() {
     if (currentFile) {
         try {
-            const content = editor.state.doc.toString();
-            await WriteFile(currentFile, content);
-            savedContent = content; // Mark as saved
+            await saveDocument(currentFile);
             await AddRecentFile(currentFile);
             SetUnsavedState(false, currentFile);
         } catch (err) {
@@ -1001,10 +1402,8 @@ async function saveFileAs() {
     try {
         const path = await SaveFileDialog();
         if (path) {
-            const content = editor.state.doc.toString();
-            await WriteFile(path, content);
+            await saveDocument(path);
             currentFile = path;
-            savedContent = content; // Mark as saved
             updateFileName();
             await AddRecentFile(path);
             SetUnsavedState(false, currentFile);
@@ -1014,6 +1413,15 @@ async function saveFileAs() {
     }
 }
 
+// Export the document as Markdown or HTML to a file chosen by the user
+async function exportDocument(format) {
+    try {
+        await ExportDocument(editor.state.doc.toString(), format);
+    } catch (err) {
+        console.error('Export error:', err);
+    }
+}
+
 // Welcome message for new documents
 const WELCOME_MESSAGE = `# Welcome to SmartCalc!
 # Check out the Snippets menu to explore features.
@@ -1184,15 +1592,20 @@ to build on previous calculations. Add comments with #.
 10 + 20 * 3 = 70
 $1,500.00 + $250.50 = $1,750.50
 $1,000 x 12 - 15% + $500 = $10,700.00
+£45 * 3 = £135.00
+¥1000 + ¥500 = ¥1,500
 sin(45) + cos(30) = 1.57
 sqrt(144) = 12
 abs(-50) = 50
 25 > 2.5 = true
 100 >= 100 = true
+0.1 + 0.2 == 0.3 = true
+0.30000001 ~= 0.3 within 1e-6 = true
 
 ## Line References
 100 = 100
 \\1 * 2 = 200
+2 cups flour to grams ?explain = (shows which evaluator matched, and the ones checked before it)
 
 ## Base Conversion
 255 in hex = 0xFF
@@ -1204,6 +1617,13 @@ pi = 3.14159265359
 e = 2.71828182846
 speed of light = 299,792,458 m/s
 gravity = 9.80665 m/s²
+planck * 5e14 = 3.313035075e-19 J·s
+2 * pi * 6371 km = 40,030.17 km
+
+## Chemistry
+molar mass H2O = 18.015 g/mol (with each element below)
+moles of 36 g H2O = 1.9983347211 mol
+mass of 2 mol NaCl = 116.88 g
 
 ## Date & Time
 now = (current time)
@@ -1211,6 +1631,12 @@ today = (current date)
 now in Seattle = (Seattle time)
 today + 30 days = (future date)
 6:00 am Seattle in Kiev = (converted time)
+3pm UTC+5:30 in Seattle = (converted time)
+timezones for CST = US: America/Chicago (UTC-05:00), ...
+every 2 weeks from 2024-06-03, next 6 = (next paydays)
+week number of 2024-06-10 = 24
+what day is 2025-01-01 = Wednesday
+is 2100 a leap year = no (365 days)
 
 ## Network/IP
 10.100.0.0/24 = 254 hosts
@@ -1218,7 +1644,11 @@ mask for /24 = 255.255.255.0
 wildcard for /24 = 0.0.0.255
 broadcast for 10.100.0.0/24 = 10.100.0.255
 is 10.100.0.50 in 10.100.0.0/24 = yes
+ping example.com = reachable, 3/3 replies, min/avg/max 11.2/12.5/14.1 ms
+port 8080 open on 192.168.1.10 ? = closed
 10.100.0.0/16 / 4 subnets = (subnet list)
+country code UA = Ukraine (UA, UKR, 804), ...
+calling code +49 = Germany (DE)
 
 ## Unit Conversions
 5 miles in km = 8.05 km
@@ -1226,7 +1656,13 @@ is 10.100.0.50 in 10.100.0.0/24 = yes
 10 kg in lbs = 22.05 lbs
 5 gallons in liters = 18.93 L
 60 mph to kph = 96.56 kph
+120 km in 1.5 hours = 80 km/h (49.71 mph)
+300 km at 100 km/h = 3 hours
+120 kts to mph = 138.09 mph
+density altitude 5000 ft 30 C = 8,000 ft
+fuel 2.5 hours at 8.5 gph = 21.25 gal (80.44 L)
 1 acre to sqft = 43,560 sqft
+2 TB over 1 Gbps = 4.44 hours at 125 MB/s
 
 ## Percentage
 $100 - 20% = $80.00
@@ -1244,6 +1680,8 @@ mortgage $350000 at 7% for 30 years = $2,328.56/month
 $10000 at 5% for 10 years compounded monthly = $16,470.09
 simple interest $5000 at 3% for 2 years = $300.00
 invest $1000 at 7% for 20 years = $3,869.68
+balance start $2,400 = $2,400.00
+- $120 groceries = -$120.00 [bal $2,280.00]
 
 ## Statistics
 avg(10, 20, 30, 40) = 25
@@ -1254,6 +1692,15 @@ min(10, 5, 20, 3) = 3
 max(10, 5, 20, 3) = 20
 stddev(2, 4, 4, 4, 5, 5, 7, 9) = 2
 range(1, 5, 10, 3) = 9
+percentile(90, 12, 45, 67, 89, 23) = 80.2
+mode(1, 1, 2, 2, 3) = 1, 2
+weightedavg((80, 0.3), (90, 0.7)) = 87
+data: = 3 rows, 2 columns (CSV or TSV rows below, up to a blank line)
+col 2 sum = (column 2 of the data block above)
+col revenue max = (by header name; also avg, median, min, count, ...)
+4.7k ohm ±5% = 4.465k to 4.935k ohm (±0.235k ohm)
+measured 9.73 expected 9.81 = absolute error -0.08, percent error 0.82%
+(12.3 ± 0.2) * (4.56 ± 0.05) = 56.1 ± 1.1
 
 ## Programmer
 0xFF AND 0x0F = 15 (0xF)
@@ -1261,6 +1708,8 @@ range(1, 5, 10, 3) = 9
 0xFF XOR 0x0F = 240 (0xF0)
 1 << 8 = 256 (0x100)
 256 >> 4 = 16 (0x10)
+set bit 3 of 0x40 = 72 (0x48)
+snake SomeVariableName = some_variable_name
 ascii A = 65
 char 65 = A
 md5 hello = 5d41402abc4b2a76...
@@ -1268,6 +1717,9 @@ base64 encode hello = aGVsbG8=
 base64 decode aGVsbG8= = hello
 uuid = (random UUID)
 random 1 to 100 = (random number)
+roll 2d20 + 5 = (total, with the dice below)
+roll 4d6 drop lowest = (the lowest die left out)
+sample 5 from 1..50 = (5 distinct numbers)
 
 # Check the Snippets menu for more examples!`;
     showModal("SmartCalc Manual", manual);
@@ -1285,7 +1737,7 @@ A powerful multi-purpose calculator with support for:
 • Mathematical expressions & functions
 • Unit conversions (length, weight, temperature, etc.)
 • Percentage & financial calculations
-• Statistics (avg, median, stddev, etc.)
+• Statistics (avg, median, stddev, percentile, mode, etc.)
 • Date/Time calculations & time zones
 • Network/IP subnet calculations
 • Programmer utilities (bitwise, ASCII, hashing)
@@ -1306,26 +1758,179 @@ Enter     Auto-append = and calculate`;
     });
 }
 
-// Smart copy - replace line references with actual values
-async function smartCopy() {
+// The text a copy takes: the selection, or the entire document without one
+function textToCopy() {
     const selection = editor.state.selection.main;
-    let textToCopy;
-    
     if (selection.empty) {
-        // No selection - copy entire document
-        textToCopy = editor.state.doc.toString();
-    } else {
-        // Copy selected text
-        textToCopy = editor.state.sliceDoc(selection.from, selection.to);
+        return editor.state.doc.toString();
     }
-    
+    return editor.state.sliceDoc(selection.from, selection.to);
+}
+
+// Smart copy - replace line references with actual values
+async function smartCopy() {
     // Replace line references with actual values
-    const resolvedText = await CopyWithResolvedRefs(textToCopy);
+    const resolvedText = await CopyWithResolvedRefs(textToCopy());
     
     // Copy to clipboard using Wails runtime
     ClipboardSetText(resolvedText);
 }
 
+// Copy as: choose how the copy is laid out, with references resolved like
+// smart copy
+const COPY_STYLES = [
+    { style: 'aligned', label: 'Aligned', hint: '= signs lined up, results right-aligned' },
+    { style: 'plain', label: 'Plain', hint: 'as in the editor' },
+    { style: 'results-only', label: 'Results only', hint: 'one value per line, for spreadsheets' },
+];
+let copyAsSelected = 0;
+
+function showCopyAs() {
+    copyAsSelected = 0;
+    renderCopyAs();
+    document.getElementById('copy-as').classList.remove('hidden');
+    document.getElementById('copy-as-styles').focus();
+}
+
+function hideCopyAs() {
+    document.getElementById('copy-as').classList.add('hidden');
+    editor.focus();
+}
+
+function renderCopyAs() {
+    const list = document.getElementById('copy-as-styles');
+    list.innerHTML = '';
+    COPY_STYLES.forEach((s, i) => {
+        const item = document.createElement('li');
+        item.className = 'quick-open-item' + (i === copyAsSelected ? ' selected' : '');
+        const label = document.createElement('span');
+        label.className = 'quick-open-snippet';
+        label.textContent = s.label;
+        const hint = document.createElement('span');
+        hint.className = 'quick-open-location';
+        hint.textContent = s.hint;
+        item.append(label, hint);
+        item.addEventListener('mousedown', (e) => {
+            e.preventDefault();
+            copyAs(i);
+        });
+        list.appendChild(item);
+    });
+}
+
+async function copyAs(i) {
+    hideCopyAs();
+    try {
+        ClipboardSetText(await CopyFormatted(textToCopy(), COPY_STYLES[i].style));
+    } catch (err) {
+        console.error('Copy error:', err);
+    }
+}
+
+function initCopyAs() {
+    const overlay = document.getElementById('copy-as');
+    const list = document.getElementById('copy-as-styles');
+    list.addEventListener('keydown', (e) => {
+        switch (e.key) {
+            case 'ArrowDown':
+            case 'ArrowUp':
+                e.preventDefault();
+                copyAsSelected = (copyAsSelected + (e.key === 'ArrowDown' ? 1 : -1) + COPY_STYLES.length) % COPY_STYLES.length;
+                renderCopyAs();
+                break;
+            case 'Enter':
+                e.preventDefault();
+                copyAs(copyAsSelected);
+                break;
+            case 'Escape':
+                e.preventDefault();
+                hideCopyAs();
+                break;
+        }
+    });
+    overlay.addEventListener('mousedown', (e) => {
+        if (e.target === overlay) {
+            hideCopyAs();
+        }
+    });
+}
+
+// Template snippets: ask for the values of the placeholders, then insert
+// the filled-in snippet. A field left empty takes its default.
+let pendingTemplate = null;
+
+function showTemplateForm(template) {
+    pendingTemplate = template;
+    document.getElementById('template-title').textContent = template.name;
+    document.getElementById('template-error').textContent = '';
+    const fields = document.getElementById('template-fields');
+    fields.innerHTML = '';
+    template.placeholders.forEach((p) => {
+        const label = document.createElement('label');
+        label.className = 'template-field';
+        const name = document.createElement('span');
+        name.textContent = p.name;
+        const input = document.createElement('input');
+        input.type = 'text';
+        input.name = p.name;
+        input.placeholder = p.default;
+        input.spellcheck = false;
+        input.autocomplete = 'off';
+        if (p.numeric) {
+            input.inputMode = 'decimal';
+        }
+        label.append(name, input);
+        fields.appendChild(label);
+    });
+    document.getElementById('template-form').classList.remove('hidden');
+    fields.querySelector('input')?.focus();
+}
+
+function hideTemplateForm() {
+    pendingTemplate = null;
+    document.getElementById('template-form').classList.add('hidden');
+    editor.focus();
+}
+
+async function submitTemplateForm() {
+    if (!pendingTemplate) {
+        return;
+    }
+    const values = {};
+    document.querySelectorAll('#template-fields input').forEach((input) => {
+        values[input.name] = input.value;
+    });
+    try {
+        const snippet = await RenderTemplate(pendingTemplate.content, values);
+        hideTemplateForm();
+        await insertSnippet(snippet);
+    } catch (err) {
+        document.getElementById('template-error').textContent = String(err);
+    }
+}
+
+function initTemplateForm() {
+    const overlay = document.getElementById('template-form');
+    const dialog = document.getElementById('template-dialog');
+    dialog.addEventListener('keydown', (e) => {
+        switch (e.key) {
+            case 'Enter':
+                e.preventDefault();
+                submitTemplateForm();
+                break;
+            case 'Escape':
+                e.preventDefault();
+                hideTemplateForm();
+                break;
+        }
+    });
+    overlay.addEventListener('mousedown', (e) => {
+        if (e.target === overlay) {
+            hideTemplateForm();
+        }
+    });
+}
+
 // Paste from clipboard using Wails runtime
 async function smartPaste() {
     try {
@@ -1349,13 +1954,56 @@ function setupMenuEvents() {
     EventsOn('menu:save', saveFile);
     EventsOn('menu:saveAs', saveFileAs);
     EventsOn('menu:openRecent', openFilePath);
+    EventsOn('menu:quickOpen', showQuickOpen);
+    EventsOn('file:openAtLine', openFileAtLine);
+    EventsOn('menu:export', exportDocument);
     EventsOn('menu:cut', () => document.execCommand('cut'));
     EventsOn('menu:copy', smartCopy);
+    EventsOn('menu:copyAs', showCopyAs);
     EventsOn('menu:paste', smartPaste);
+    EventsOn('menu:refresh', refreshDocument);
+    EventsOn('menu:refreshNetwork', refreshNetworkResults);
+    EventsOn('refresh:progress', showRefreshProgress);
+    EventsOn('hash:progress', showHashProgress);
     EventsOn('menu:snippet', insertSnippet);
+    EventsOn('menu:template', showTemplateForm);
     EventsOn('menu:manual', showManual);
     EventsOn('menu:about', showAbout);
     EventsOn('app:saveAndQuit', saveAndQuit);
+    EventsOn('settings:changed', evaluateContent);
+    EventsOn('eval:deferred', applyDeferredResults);
+    EventsOn('calc:asyncResult', applyAsyncResult);
+    EventsOn('recovery:available', offerRecovery);
+}
+
+// Offer back the text a crash left unsaved. Restoring opens the document it
+// was typed into and shows the text as unsaved changes to it, or as an
+// untitled document when it was untitled or can no longer be read.
+async function offerRecovery(recovered) {
+    const name = recovered.path || 'an untitled document';
+    const restore = await ShowQuestionDialog('Recover Unsaved Changes',
+        `SmartCalc quit without saving changes to ${name} (last written ${recovered.modified}). Do you want to restore them?`);
+    if (!restore) {
+        DiscardRecovery();
+        return;
+    }
+    if (recovered.path !== currentFile) {
+        try {
+            if (!recovered.path) {
+                throw new Error('untitled');
+            }
+            await loadDocument(recovered.path, await ReadFile(recovered.path));
+        } catch (err) {
+            currentFile = '';
+            savedContent = '';
+            updateFileName();
+        }
+    }
+    editor.dispatch({
+        changes: { from: 0, to: editor.state.doc.length, insert: recovered.content },
+    });
+    evaluateContent();
+    updateUnsavedState();
 }
 
 // Save file and quit - called when user clicks Save on unsaved unnamed file close
@@ -1363,10 +2011,8 @@ async function saveAndQuit() {
     try {
         const path = await SaveFileDialog();
         if (path) {
-            const content = editor.state.doc.toString();
-            await WriteFile(path, content);
+            await saveDocument(path);
             currentFile = path;
-            savedContent = content;
             await AddRecentFile(path);
             // Clear unsaved state BEFORE quitting to prevent loop
             await SetUnsavedState(false, path);
@@ -1394,6 +2040,7 @@ async function loadLastFile() {
         // On error, show welcome message
         newFile();
     }
+    CheckRecovery();
 }
 
 // Context menu functionality
@@ -1502,5 +2149,8 @@ document.addEventListener('DOMContentLoaded', () => {
     initEditor();
     setupMenuEvents();
     setupContextMenu();
+    initQuickOpen();
+    initCopyAs();
+    initTemplateForm();
     loadLastFile();
 });
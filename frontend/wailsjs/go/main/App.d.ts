@@ -2,6 +2,9 @@
 // This file is automatically generated. DO NOT EDIT
 import {updater} from '../models';
 import {main} from '../models';
+import {calc} from '../models';
+import {finance} from '../models';
+import {filesearch} from '../models';
 
 export function AddRecentFile(arg1:string):Promise<void>;
 
@@ -11,24 +14,56 @@ export function AutoSave(arg1:string,arg2:string):Promise<void>;
 
 export function CheckForUpdates():Promise<updater.ReleaseInfo>;
 
+export function CheckRecovery():Promise<void>;
+
+export function ChooseNotesDir():Promise<string>;
+
+export function CopyFormatted(arg1:string,arg2:string):Promise<string>;
+
 export function CopyWithResolvedRefs(arg1:string):Promise<string>;
 
+export function DiscardRecovery():Promise<void>;
+
 export function Evaluate(arg1:string,arg2:number):Promise<Array<main.EvalResult>>;
 
 export function EvaluateLines(arg1:string,arg2:number):Promise<Array<main.EvalResult>>;
 
+export function ExportDocument(arg1:string,arg2:string):Promise<string>;
+
 export function FindDependentLines(arg1:string,arg2:number):Promise<Array<number>>;
 
+export function GetAmortization(arg1:string):Promise<finance.Amortization>;
+
+export function GetDependencyGraph(arg1:string):Promise<calc.DependencyInfo>;
+
+export function GetDocumentStats(arg1:string):Promise<calc.DocumentStats>;
+
+export function GetEvaluatorNames():Promise<Array<string>>;
+
 export function GetGitHubRepoURL():Promise<string>;
 
 export function GetLastFile():Promise<string>;
 
+export function GetOutline(arg1:string):Promise<Array<calc.OutlineEntry>>;
+
+export function GetPinnedResults(arg1:string):Promise<Array<calc.PinnedResult>>;
+
 export function GetRecentFiles():Promise<Array<string>>;
 
+export function GetSettings():Promise<main.Settings>;
+
+export function GetUserFunctionsPath():Promise<string>;
+
 export function GetVersion():Promise<string>;
 
 export function HasLineResult(arg1:string):Promise<boolean>;
 
+export function MarkDirty(arg1:string):Promise<void>;
+
+export function MoveLines(arg1:string,arg2:number,arg3:number,arg4:number):Promise<string>;
+
+export function OpenFileAtLine(arg1:string,arg2:number):Promise<void>;
+
 export function OpenFileDialog():Promise<string>;
 
 export function OpenURL(arg1:string):Promise<void>;
@@ -37,12 +72,42 @@ export function Quit():Promise<void>;
 
 export function ReadFile(arg1:string):Promise<string>;
 
+export function RefreshDocument(arg1:string):Promise<Array<main.EvalResult>>;
+
+export function RefreshNetworkLines(arg1:string):Promise<Array<main.EvalResult>>;
+
+export function ReloadUserFunctions():Promise<Array<string>>;
+
+export function RenderTemplate(arg1:string,arg2:{[key: string]: string}):Promise<string>;
+
+export function SaveDocument(arg1:string,arg2:string):Promise<string>;
+
 export function SaveFileDialog():Promise<string>;
 
+export function SearchRecentFiles(arg1:string):Promise<Array<filesearch.Match>>;
+
+export function SetAmbiguousTimezoneMode(arg1:string):Promise<void>;
+
+export function SetDecimalMark(arg1:string):Promise<void>;
+
+export function SetDefaultTaxRate(arg1:number):Promise<void>;
+
+export function SetEvaluatorEnabled(arg1:string,arg2:boolean):Promise<void>;
+
+export function SetFractionMode(arg1:boolean):Promise<void>;
+
+export function SetLanguage(arg1:string):Promise<void>;
+
+export function SetNotesDir(arg1:string):Promise<void>;
+
+export function SetPrecision(arg1:number):Promise<void>;
+
 export function SetUnsavedState(arg1:boolean,arg2:string):Promise<void>;
 
 export function ShowInfoDialog(arg1:string,arg2:string):Promise<void>;
 
+export function ShowQuestionDialog(arg1:string,arg2:string):Promise<boolean>;
+
 export function StripAndEvalReferencingLines(arg1:string):Promise<string>;
 
 export function StripLineResult(arg1:string):Promise<string>;
@@ -18,10 +18,26 @@ export function CheckForUpdates() {
   return window['go']['main']['App']['CheckForUpdates']();
 }
 
+export function CheckRecovery() {
+  return window['go']['main']['App']['CheckRecovery']();
+}
+
+export function ChooseNotesDir() {
+  return window['go']['main']['App']['ChooseNotesDir']();
+}
+
+export function CopyFormatted(arg1, arg2) {
+  return window['go']['main']['App']['CopyFormatted'](arg1, arg2);
+}
+
 export function CopyWithResolvedRefs(arg1) {
   return window['go']['main']['App']['CopyWithResolvedRefs'](arg1);
 }
 
+export function DiscardRecovery() {
+  return window['go']['main']['App']['DiscardRecovery']();
+}
+
 export function Evaluate(arg1, arg2) {
   return window['go']['main']['App']['Evaluate'](arg1, arg2);
 }
@@ -30,10 +46,30 @@ export function EvaluateLines(arg1, arg2) {
   return window['go']['main']['App']['EvaluateLines'](arg1, arg2);
 }
 
+export function ExportDocument(arg1, arg2) {
+  return window['go']['main']['App']['ExportDocument'](arg1, arg2);
+}
+
 export function FindDependentLines(arg1, arg2) {
   return window['go']['main']['App']['FindDependentLines'](arg1, arg2);
 }
 
+export function GetAmortization(arg1) {
+  return window['go']['main']['App']['GetAmortization'](arg1);
+}
+
+export function GetDependencyGraph(arg1) {
+  return window['go']['main']['App']['GetDependencyGraph'](arg1);
+}
+
+export function GetDocumentStats(arg1) {
+  return window['go']['main']['App']['GetDocumentStats'](arg1);
+}
+
+export function GetEvaluatorNames() {
+  return window['go']['main']['App']['GetEvaluatorNames']();
+}
+
 export function GetGitHubRepoURL() {
   return window['go']['main']['App']['GetGitHubRepoURL']();
 }
@@ -42,10 +78,26 @@ export function GetLastFile() {
   return window['go']['main']['App']['GetLastFile']();
 }
 
+export function GetOutline(arg1) {
+  return window['go']['main']['App']['GetOutline'](arg1);
+}
+
+export function GetPinnedResults(arg1) {
+  return window['go']['main']['App']['GetPinnedResults'](arg1);
+}
+
 export function GetRecentFiles() {
   return window['go']['main']['App']['GetRecentFiles']();
 }
 
+export function GetSettings() {
+  return window['go']['main']['App']['GetSettings']();
+}
+
+export function GetUserFunctionsPath() {
+  return window['go']['main']['App']['GetUserFunctionsPath']();
+}
+
 export function GetVersion() {
   return window['go']['main']['App']['GetVersion']();
 }
@@ -54,6 +106,18 @@ export function HasLineResult(arg1) {
   return window['go']['main']['App']['HasLineResult'](arg1);
 }
 
+export function MarkDirty(arg1) {
+  return window['go']['main']['App']['MarkDirty'](arg1);
+}
+
+export function MoveLines(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['MoveLines'](arg1, arg2, arg3, arg4);
+}
+
+export function OpenFileAtLine(arg1, arg2) {
+  return window['go']['main']['App']['OpenFileAtLine'](arg1, arg2);
+}
+
 export function OpenFileDialog() {
   return window['go']['main']['App']['OpenFileDialog']();
 }
@@ -70,10 +134,66 @@ export function ReadFile(arg1) {
   return window['go']['main']['App']['ReadFile'](arg1);
 }
 
+export function RefreshDocument(arg1) {
+  return window['go']['main']['App']['RefreshDocument'](arg1);
+}
+
+export function RefreshNetworkLines(arg1) {
+  return window['go']['main']['App']['RefreshNetworkLines'](arg1);
+}
+
+export function ReloadUserFunctions() {
+  return window['go']['main']['App']['ReloadUserFunctions']();
+}
+
+export function RenderTemplate(arg1, arg2) {
+  return window['go']['main']['App']['RenderTemplate'](arg1, arg2);
+}
+
+export function SaveDocument(arg1, arg2) {
+  return window['go']['main']['App']['SaveDocument'](arg1, arg2);
+}
+
 export function SaveFileDialog() {
   return window['go']['main']['App']['SaveFileDialog']();
 }
 
+export function SearchRecentFiles(arg1) {
+  return window['go']['main']['App']['SearchRecentFiles'](arg1);
+}
+
+export function SetAmbiguousTimezoneMode(arg1) {
+  return window['go']['main']['App']['SetAmbiguousTimezoneMode'](arg1);
+}
+
+export function SetDecimalMark(arg1) {
+  return window['go']['main']['App']['SetDecimalMark'](arg1);
+}
+
+export function SetDefaultTaxRate(arg1) {
+  return window['go']['main']['App']['SetDefaultTaxRate'](arg1);
+}
+
+export function SetEvaluatorEnabled(arg1, arg2) {
+  return window['go']['main']['App']['SetEvaluatorEnabled'](arg1, arg2);
+}
+
+export function SetFractionMode(arg1) {
+  return window['go']['main']['App']['SetFractionMode'](arg1);
+}
+
+export function SetLanguage(arg1) {
+  return window['go']['main']['App']['SetLanguage'](arg1);
+}
+
+export function SetNotesDir(arg1) {
+  return window['go']['main']['App']['SetNotesDir'](arg1);
+}
+
+export function SetPrecision(arg1) {
+  return window['go']['main']['App']['SetPrecision'](arg1);
+}
+
 export function SetUnsavedState(arg1, arg2) {
   return window['go']['main']['App']['SetUnsavedState'](arg1, arg2);
 }
@@ -82,6 +202,10 @@ export function ShowInfoDialog(arg1, arg2) {
   return window['go']['main']['App']['ShowInfoDialog'](arg1, arg2);
 }
 
+export function ShowQuestionDialog(arg1, arg2) {
+  return window['go']['main']['App']['ShowQuestionDialog'](arg1, arg2);
+}
+
 export function StripAndEvalReferencingLines(arg1) {
   return window['go']['main']['App']['StripAndEvalReferencingLines'](arg1);
 }
@@ -1,3 +1,226 @@
+export namespace calc {
+	
+	export class DependencyInfo {
+	    dependsOn: Record<number, Array<number>>;
+	    usedBy: Record<number, Array<number>>;
+	    cycles: number[][];
+	
+	    static createFrom(source: any = {}) {
+	        return new DependencyInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.dependsOn = source["dependsOn"];
+	        this.usedBy = source["usedBy"];
+	        this.cycles = source["cycles"];
+	    }
+	}
+	export class DocumentStats {
+	    lines: number;
+	    results: number;
+	    errors: number;
+	    assertions: number;
+	    failedAssertions: number;
+	    expectations: number;
+	    failedExpectations: number;
+	    slowLines: SlowLine[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DocumentStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.lines = source["lines"];
+	        this.results = source["results"];
+	        this.errors = source["errors"];
+	        this.assertions = source["assertions"];
+	        this.failedAssertions = source["failedAssertions"];
+	        this.expectations = source["expectations"];
+	        this.failedExpectations = source["failedExpectations"];
+	        this.slowLines = this.convertValues(source["slowLines"], SlowLine);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OutlineEntry {
+	    id: string;
+	    kind: string;
+	    title: string;
+	    line: number;
+	    level: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OutlineEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.kind = source["kind"];
+	        this.title = source["title"];
+	        this.line = source["line"];
+	        this.level = source["level"];
+	    }
+	}
+	export class PinnedResult {
+	    line: number;
+	    label: string;
+	    result: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PinnedResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.line = source["line"];
+	        this.label = source["label"];
+	        this.result = source["result"];
+	    }
+	}
+	export class SlowLine {
+	    line: number;
+	    evaluator: string;
+	    durationMs: number;
+	    queuedMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SlowLine(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.line = source["line"];
+	        this.evaluator = source["evaluator"];
+	        this.durationMs = source["durationMs"];
+	        this.queuedMs = source["queuedMs"];
+	    }
+	}
+
+}
+
+export namespace filesearch {
+	
+	export class Match {
+	    path: string;
+	    line: number;
+	    snippet: string;
+	    exact: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Match(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.line = source["line"];
+	        this.snippet = source["snippet"];
+	        this.exact = source["exact"];
+	    }
+	}
+
+}
+
+export namespace finance {
+	
+	export class PaymentRow {
+	    month: number;
+	    date: string;
+	    payment: number;
+	    principal: number;
+	    interest: number;
+	    balance: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PaymentRow(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.month = source["month"];
+	        this.date = source["date"];
+	        this.payment = source["payment"];
+	        this.principal = source["principal"];
+	        this.interest = source["interest"];
+	        this.balance = source["balance"];
+	    }
+	}
+	export class Summary {
+	    monthlyPayment: number;
+	    extra: number;
+	    payments: number;
+	    totalPaid: number;
+	    totalInterest: number;
+	    payoff: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Summary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.monthlyPayment = source["monthlyPayment"];
+	        this.extra = source["extra"];
+	        this.payments = source["payments"];
+	        this.totalPaid = source["totalPaid"];
+	        this.totalInterest = source["totalInterest"];
+	        this.payoff = source["payoff"];
+	    }
+	}
+	export class Amortization {
+	    rows: PaymentRow[];
+	    summary: Summary;
+	
+	    static createFrom(source: any = {}) {
+	        return new Amortization(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.rows = this.convertValues(source["rows"], PaymentRow);
+	        this.summary = this.convertValues(source["summary"], Summary);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
 export namespace main {
 	
 	export class EvalResult {
@@ -16,6 +239,32 @@ export namespace main {
 	        this.output = source["output"];
 	    }
 	}
+	export class Settings {
+	    ambiguousTimezones: string;
+	    defaultTaxRate: number;
+	    disabledEvaluators: string[];
+	    fractions: boolean;
+	    decimalMark: string;
+	    precision: number;
+	    language: string;
+	    notesDir: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ambiguousTimezones = source["ambiguousTimezones"];
+	        this.defaultTaxRate = source["defaultTaxRate"];
+	        this.disabledEvaluators = source["disabledEvaluators"];
+	        this.fractions = source["fractions"];
+	        this.decimalMark = source["decimalMark"];
+	        this.precision = source["precision"];
+	        this.language = source["language"];
+	        this.notesDir = source["notesDir"];
+	    }
+	}
 
 }
 
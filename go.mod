@@ -7,6 +7,7 @@ toolchain go1.24.11
 require (
 	github.com/google/uuid v1.6.0
 	github.com/miekg/dns v1.1.69
+	github.com/rivo/uniseg v0.4.7
 	github.com/wailsapp/wails/v2 v2.11.0
 )
 
@@ -26,7 +27,6 @@ require (
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/pkg/browser v0.0.0-20240102092130-5ac0b6a4141c // indirect
 	github.com/pkg/errors v0.9.1 // indirect
-	github.com/rivo/uniseg v0.4.7 // indirect
 	github.com/samber/lo v1.49.1 // indirect
 	github.com/tkrajina/go-reflector v0.5.8 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
@@ -0,0 +1,349 @@
+// Package aviation answers flight-planning questions with the approximations
+// of an E6B flight computer: wind components for a runway, density altitude
+// and fuel planning
+package aviation
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/utils"
+)
+
+// Conversion factors
+const (
+	litersPerGallon = 3.785411784 // US gallon
+	kgPerLb         = 0.45359237
+	feetPerMeter    = 1 / 0.3048
+	// An altimeter setting 1 inHg below standard raises the pressure
+	// altitude by 1000 ft; 1 inHg is 33.8639 hPa
+	feetPerInHg = 1000.0
+	hPaPerInHg  = 33.8639
+)
+
+// Standard atmosphere and the E6B rules of thumb for density altitude: the
+// temperature falls 2 °C per 1000 ft, and every degree above standard adds
+// 120 ft
+const (
+	standardInHg     = 29.92
+	seaLevelISA      = 15.0
+	lapsePer1000Ft   = 2.0
+	feetPerDegreeISA = 120.0
+	maxRunwayNumber  = 36
+)
+
+// Handler evaluates one kind of aviation expression. ok is false when the
+// expression is not its kind; err reports one that is, but can't be worked
+// out.
+type Handler interface {
+	Handle(expr string) (result utils.Result, ok bool, err error)
+}
+
+// HandlerFunc is an adapter to allow ordinary functions to be used as Handlers.
+type HandlerFunc func(expr string) (utils.Result, bool, error)
+
+// Handle calls the underlying function.
+func (f HandlerFunc) Handle(expr string) (utils.Result, bool, error) {
+	return f(expr)
+}
+
+// handlerChain is the ordered list of handlers for aviation expressions.
+var handlerChain = []Handler{
+	HandlerFunc(handleCrosswind),
+	HandlerFunc(handleDensityAltitude),
+	HandlerFunc(handleFuel),
+	HandlerFunc(handleEndurance),
+}
+
+// Parts of the expressions: a number with optional thousands separators, a
+// flight time of "2.5 hours", "1 h 30 min" or "1:30", and a fuel flow
+const (
+	numberPart   = `(-?\d{1,3}(?:,\d{3})+(?:\.\d+)?|-?\d+(?:\.\d+)?)`
+	durationPart = `(\d+:\d{2}|(?:\d+(?:\.\d+)?\s*(?:hours?|hrs?|h|minutes?|mins?)\b\s*(?:and\s+)?)+)`
+	flowPart     = `(\d+(?:\.\d+)?)\s*(gph|gal/hr?|gallons?\s+per\s+hour|lph|l/hr?|lit(?:er|re)s?\s+per\s+hour|pph|lbs?/hr?|pounds?\s+per\s+hour|kg/hr?)`
+)
+
+// crosswindPattern matches "crosswind runway 27 wind 300 at 15 kt", with the
+// wind also written "300/15", "300@15" and with gusts, "300/15g25" or "300 at
+// 15 gusting 25"
+var crosswindPattern = regexp.MustCompile(`^(?:cross\s*wind|wind\s+components?)\s+(?:for\s+|on\s+)?(?:runway|rwy)\s+(\d{1,3})[lrc]?\s*,?\s+(?:wind\s+)?` +
+	`(\d{1,3})\s*°?\s*(?:/|@|\s+at\s+|\s)\s*(\d{1,3})(?:\s*g\s*(\d{1,3})|\s+gust(?:ing|s)?\s+(?:to\s+)?(\d{1,3}))?\s*(?:kts?|knots?|kn)?$`)
+
+// densityAltitudePattern matches "density altitude 5000 ft 30 C", with an
+// optional altimeter setting: "altimeter 29.72" or "qnh 1005 hpa"
+var densityAltitudePattern = regexp.MustCompile(`^density\s+altitude\s+(?:at\s+|for\s+)?` + numberPart + `\s*(ft|feet|m|meters?|metres?)?\s*,?\s+(?:at\s+|oat\s+|temp(?:erature)?\s+)?` +
+	`(-?\d+(?:\.\d+)?)\s*°?\s*(c|f|celsius|fahrenheit)(?:\s*,?\s+(?:altimeter|qnh|baro)\s+(\d+(?:\.\d+)?)\s*(inhg|in|hpa|mb)?)?$`)
+
+// fuelPattern matches the fuel a flight burns: "fuel 2.5 hours at 8.5 gph"
+var fuelPattern = regexp.MustCompile(`^fuel\s+(?:burn\s+|needed\s+)?(?:for\s+)?` + durationPart + `\s+at\s+` + flowPart + `$`)
+
+// endurancePattern matches how long the fuel on board lasts: "endurance 40
+// gal at 8.5 gph"
+var endurancePattern = regexp.MustCompile(`^endurance\s+(?:with\s+|of\s+|for\s+)?(\d+(?:\.\d+)?)\s*(gal|gallons?|l|lit(?:er|re)s?|lbs?|pounds?|kg)\s+at\s+` + flowPart + `$`)
+
+// durationUnitPattern matches one part of a duration such as "1 h 30 min"
+var durationUnitPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*([a-z]+)`)
+
+// patterns are the expressions the handlers answer, for detection
+var patterns = []*regexp.Regexp{crosswindPattern, densityAltitudePattern, fuelPattern, endurancePattern}
+
+// IsAviationExpression checks if an expression asks for wind components,
+// density altitude, fuel or endurance. The whole line must read as one, so
+// the runway and wind numbers are never left to arithmetic.
+func IsAviationExpression(expr string) bool {
+	expr = utils.NormalizeExpr(expr)
+	for _, p := range patterns {
+		if p.MatchString(expr) {
+			return true
+		}
+	}
+	return false
+}
+
+// EvalAviation evaluates an aviation expression. The crosswind breakdown is a
+// block of "> " lines; the other results are a single line.
+func EvalAviation(expr string) (utils.Result, error) {
+	expr = utils.NormalizeExpr(expr)
+	for _, h := range handlerChain {
+		if result, ok, err := h.Handle(expr); ok || err != nil {
+			return result, err
+		}
+	}
+	return utils.Result{}, fmt.Errorf("unable to evaluate aviation expression: %s", expr)
+}
+
+// handleCrosswind splits a wind into its headwind and crosswind components
+// for a runway. Runway 27 points 270°; a number above 36 is taken as the
+// runway's heading.
+// Example: "crosswind runway 27 wind 300 at 15 kt"
+func handleCrosswind(expr string) (utils.Result, bool, error) {
+	m := crosswindPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return utils.Result{}, false, nil
+	}
+	runway, _ := strconv.Atoi(m[1])
+	heading := runway
+	if runway <= maxRunwayNumber {
+		heading = runway * 10
+	}
+	if runway == 0 || heading > 360 {
+		return utils.Result{}, true, fmt.Errorf("invalid runway %s", m[1])
+	}
+	direction, _ := strconv.Atoi(m[2])
+	if direction > 360 {
+		return utils.Result{}, true, fmt.Errorf("wind direction must be 0 to 360°")
+	}
+	speed, _ := strconv.ParseFloat(m[3], 64)
+	gust := m[4] + m[5]
+
+	// The angle between the wind and the runway, positive when the wind
+	// comes from the right of the runway heading
+	angle := math.Mod(float64(direction-heading)+540, 360) - 180
+	head, cross := components(speed, angle)
+
+	var sb strings.Builder
+	if head < 0 {
+		fmt.Fprintf(&sb, "\n> Tailwind: %s kt", formatKnots(-head))
+	} else {
+		fmt.Fprintf(&sb, "\n> Headwind: %s kt", formatKnots(head))
+	}
+	fmt.Fprintf(&sb, "\n> Crosswind: %s kt%s", formatKnots(math.Abs(cross)), side(cross))
+	if gust != "" {
+		g, _ := strconv.ParseFloat(gust, 64)
+		_, gustCross := components(g, angle)
+		fmt.Fprintf(&sb, "\n> Gust crosswind: %s kt%s", formatKnots(math.Abs(gustCross)), side(gustCross))
+	}
+	fmt.Fprintf(&sb, "\n> Wind %03d° at %s kt, %d° off runway %s (%03d°)", direction, m[3], int(math.Abs(angle)), m[1], heading)
+	return utils.ValueResult(sb.String(), math.Abs(cross), false), true, nil
+}
+
+// components returns the headwind and crosswind components of a wind at an
+// angle to the runway
+func components(speed, angle float64) (head, cross float64) {
+	rad := angle * math.Pi / 180
+	return speed * math.Cos(rad), speed * math.Sin(rad)
+}
+
+// side names the side a crosswind comes from
+func side(cross float64) string {
+	switch {
+	case math.Round(cross*10) > 0:
+		return " from the right"
+	case math.Round(cross*10) < 0:
+		return " from the left"
+	}
+	return ""
+}
+
+// formatKnots rounds a wind component to a tenth of a knot
+func formatKnots(v float64) string {
+	return utils.FormatResult(false, math.Round(v*10)/10)
+}
+
+// handleDensityAltitude estimates density altitude from the field elevation
+// and the outside air temperature. Without an altimeter setting the
+// elevation is taken as the pressure altitude.
+// Example: "density altitude 5000 ft 30 C" is 8,000 ft
+func handleDensityAltitude(expr string) (utils.Result, bool, error) {
+	m := densityAltitudePattern.FindStringSubmatch(expr)
+	if m == nil {
+		return utils.Result{}, false, nil
+	}
+	elevation, _ := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+	if strings.HasPrefix(m[2], "m") {
+		elevation *= feetPerMeter
+	}
+	temp, _ := strconv.ParseFloat(m[3], 64)
+	if strings.HasPrefix(m[4], "f") {
+		temp = (temp - 32) * 5 / 9
+	}
+
+	pressureAltitude := elevation
+	if m[5] != "" {
+		setting, _ := strconv.ParseFloat(m[5], 64)
+		inHg := setting
+		// Settings in the hundreds are hectopascals
+		if m[6] == "hpa" || m[6] == "mb" || m[6] == "" && setting > 100 {
+			inHg = setting / hPaPerInHg
+		}
+		if inHg < 25 || inHg > 35 {
+			return utils.Result{}, true, fmt.Errorf("invalid altimeter setting %s", m[5])
+		}
+		pressureAltitude += (standardInHg - inHg) * feetPerInHg
+	}
+
+	isa := seaLevelISA - lapsePer1000Ft*pressureAltitude/1000
+	densityAltitude := pressureAltitude + feetPerDegreeISA*(temp-isa)
+	text := fmt.Sprintf("%s ft (pressure altitude %s ft, ISA %s °C)",
+		formatFeet(densityAltitude), formatFeet(pressureAltitude), utils.FormatResult(false, math.Round(isa*10)/10))
+	return utils.ValueResult(text, math.Round(densityAltitude), false), true, nil
+}
+
+// formatFeet rounds an altitude to the foot
+func formatFeet(v float64) string {
+	return utils.FormatResult(false, math.Round(v))
+}
+
+// handleFuel computes the fuel burned in a flight time at a fuel flow.
+// Example: "fuel 2.5 hours at 8.5 gph" is 21.25 gal (80.44 L)
+func handleFuel(expr string) (utils.Result, bool, error) {
+	m := fuelPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return utils.Result{}, false, nil
+	}
+	hours := parseDuration(m[1])
+	flow, _ := strconv.ParseFloat(m[2], 64)
+	unit := flowUnit(m[3])
+	amount := hours * flow
+	return fuelResult(amount, unit), true, nil
+}
+
+// handleEndurance computes how long the fuel on board lasts at a fuel flow,
+// as hours and minutes.
+// Example: "endurance 40 gal at 8.5 gph" is 4:42 (4.71 hours)
+func handleEndurance(expr string) (utils.Result, bool, error) {
+	m := endurancePattern.FindStringSubmatch(expr)
+	if m == nil {
+		return utils.Result{}, false, nil
+	}
+	fuel, _ := strconv.ParseFloat(m[1], 64)
+	fuelUnit := amountUnit(m[2])
+	flow, _ := strconv.ParseFloat(m[3], 64)
+	unit := flowUnit(m[4])
+	if flow <= 0 {
+		return utils.Result{}, true, fmt.Errorf("fuel flow must be more than zero")
+	}
+	if isWeight(fuelUnit) != isWeight(unit) {
+		return utils.Result{}, true, fmt.Errorf("fuel and fuel flow must both be volumes or both be weights")
+	}
+	hours := convertFuel(fuel, fuelUnit, unit) / flow
+	text := fmt.Sprintf("%s (%s %s)", formatHours(hours), utils.FormatResult(false, math.Round(hours*100)/100), utils.Plural(hours, "hour", "hours"))
+	return utils.ValueResult(text, hours, false), true, nil
+}
+
+// flowUnit returns the fuel unit of a fuel flow: gal, L, lb or kg
+func flowUnit(flow string) string {
+	switch {
+	case strings.HasPrefix(flow, "g"):
+		return "gal"
+	case strings.HasPrefix(flow, "l"):
+		return "L"
+	case strings.HasPrefix(flow, "kg"):
+		return "kg"
+	}
+	return "lb"
+}
+
+// amountUnit returns the fuel unit of an amount of fuel: gal, L, lb or kg
+func amountUnit(unit string) string {
+	switch {
+	case strings.HasPrefix(unit, "g"):
+		return "gal"
+	case unit == "l" || strings.HasPrefix(unit, "lit"):
+		return "L"
+	case unit == "kg":
+		return "kg"
+	}
+	return "lb"
+}
+
+// isWeight checks if a fuel unit is a weight rather than a volume
+func isWeight(unit string) bool {
+	return unit == "lb" || unit == "kg"
+}
+
+// convertFuel converts an amount of fuel between gal and L, or lb and kg
+func convertFuel(v float64, from, to string) float64 {
+	switch {
+	case from == to:
+		return v
+	case from == "gal" && to == "L":
+		return v * litersPerGallon
+	case from == "L" && to == "gal":
+		return v / litersPerGallon
+	case from == "lb" && to == "kg":
+		return v * kgPerLb
+	}
+	return v / kgPerLb
+}
+
+// fuelResult shows an amount of fuel in its unit and the other unit of the
+// same kind: "21.25 gal (80.44 L)"
+func fuelResult(amount float64, unit string) utils.Result {
+	other := map[string]string{"gal": "L", "L": "gal", "lb": "kg", "kg": "lb"}[unit]
+	text := fmt.Sprintf("%s %s (%s %s)", formatAmount(amount), unit, formatAmount(convertFuel(amount, unit, other)), other)
+	return utils.ValueResult(text, amount, false)
+}
+
+// formatAmount rounds an amount of fuel to two decimals
+func formatAmount(v float64) string {
+	return utils.FormatResult(false, math.Round(v*100)/100)
+}
+
+// parseDuration converts "2.5 hours", "1 h 30 min" or "1:30" to hours
+func parseDuration(s string) float64 {
+	if h, m, ok := strings.Cut(s, ":"); ok {
+		hours, _ := strconv.ParseFloat(h, 64)
+		mins, _ := strconv.ParseFloat(m, 64)
+		return hours + mins/60
+	}
+	total := 0.0
+	for _, m := range durationUnitPattern.FindAllStringSubmatch(s, -1) {
+		value, _ := strconv.ParseFloat(m[1], 64)
+		if strings.HasPrefix(m[2], "h") {
+			total += value
+		} else {
+			total += value / 60
+		}
+	}
+	return total
+}
+
+// formatHours writes a time in hours as "4:42", rounded to the minute
+func formatHours(hours float64) string {
+	mins := int64(math.Round(hours * 60))
+	return fmt.Sprintf("%d:%02d", mins/60, mins%60)
+}
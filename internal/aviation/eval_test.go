@@ -0,0 +1,110 @@
+package aviation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIsAviationExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"crosswind runway 27 wind 300 at 15 kt", true},
+		{"Crosswind RWY 09L wind 040/20G30", true},
+		{"wind components runway 36 wind 200 at 10 knots", true},
+		{"density altitude 5000 ft 30 C", true},
+		{"density altitude 1500 m 25 c qnh 1005 hpa", true},
+		{"fuel 2.5 hours at 8.5 gph", true},
+		{"fuel for 1:30 at 35 lph", true},
+		{"endurance 40 gal at 8.5 gph", true},
+
+		// The numbers alone are arithmetic, and a plain speed is a unit
+		{"27 + 300", false},
+		{"120 kts to mph", false},
+		{"crosswind", false},
+		{"density altitude", false},
+		{"fuel = 40", false},
+		{"fuel * 2", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsAviationExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsAviationExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalAviation(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+		value    float64
+	}{
+		// 30° off the runway: cos 30° = 0.87, sin 30° = 0.5
+		{"crosswind runway 27 wind 300 at 15 kt",
+			"\n> Headwind: 13 kt\n> Crosswind: 7.5 kt from the right\n> Wind 300° at 15 kt, 30° off runway 27 (270°)", 7.5},
+		{"crosswind rwy 09 wind 040/20g30",
+			"\n> Headwind: 12.9 kt\n> Crosswind: 15.3 kt from the left\n> Gust crosswind: 23 kt from the left\n> Wind 040° at 20 kt, 50° off runway 09 (090°)", 15.32},
+		{"crosswind runway 36 wind 200 at 10",
+			"\n> Tailwind: 9.4 kt\n> Crosswind: 3.4 kt from the left\n> Wind 200° at 10 kt, 160° off runway 36 (360°)", 3.42},
+		{"crosswind runway 27 wind 270 at 10 kt",
+			"\n> Headwind: 10 kt\n> Crosswind: 0 kt\n> Wind 270° at 10 kt, 0° off runway 27 (270°)", 0},
+		// Straight across the runway: all crosswind
+		{"crosswind runway 18 wind 270 at 12 kt",
+			"\n> Headwind: 0 kt\n> Crosswind: 12 kt from the right\n> Wind 270° at 12 kt, 90° off runway 18 (180°)", 12},
+
+		// ISA at 5000 ft is 5 °C; 25 °C above it adds 25 × 120 ft
+		{"density altitude 5000 ft 30 C", "8,000 ft (pressure altitude 5,000 ft, ISA 5 °C)", 8000},
+		{"density altitude 0 ft 15 c", "0 ft (pressure altitude 0 ft, ISA 15 °C)", 0},
+		// 0.2 inHg below standard raises the pressure altitude by 200 ft
+		{"density altitude 5,000 ft 86 F altimeter 29.72", "8,248 ft (pressure altitude 5,200 ft, ISA 4.6 °C)", 8248},
+		{"density altitude 1500 m 25 c qnh 1005 hpa", "7,603 ft (pressure altitude 5,164 ft, ISA 4.7 °C)", 7603},
+
+		{"fuel 2.5 hours at 8.5 gph", "21.25 gal (80.44 L)", 21.25},
+		{"fuel for 1:30 at 35 lph", "52.5 L (13.87 gal)", 52.5},
+		{"fuel 1 h 45 min at 1200 pph", "2,100 lb (952.54 kg)", 2100},
+		{"endurance 40 gal at 8.5 gph", "4:42 (4.71 hours)", 4.71},
+		{"endurance 150 l at 8.5 gph", "4:40 (4.66 hours)", 4.66},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalAviation(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalAviation(%q) error: %v", tt.expr, err)
+			}
+			if result.Text != tt.expected {
+				t.Errorf("EvalAviation(%q) = %q, want %q", tt.expr, result.Text, tt.expected)
+			}
+			if math.Abs(result.Value-tt.value) > 0.01 {
+				t.Errorf("EvalAviation(%q) value = %v, want %v", tt.expr, result.Value, tt.value)
+			}
+		})
+	}
+}
+
+func TestEvalAviationErrors(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"crosswind runway 0 wind 300 at 15", "invalid runway 0"},
+		{"crosswind runway 400 wind 300 at 15", "invalid runway 400"},
+		{"crosswind runway 27 wind 400 at 15", "wind direction must be 0 to 360°"},
+		{"density altitude 5000 ft 30 c altimeter 2.5", "invalid altimeter setting 2.5"},
+		{"endurance 40 gal at 0 gph", "fuel flow must be more than zero"},
+		{"endurance 40 gal at 600 pph", "fuel and fuel flow must both be volumes or both be weights"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := EvalAviation(tt.expr)
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("EvalAviation(%q) error = %v, want %q", tt.expr, err, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package aviation
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// A runway or wind that doesn't add up is reported rather than left to
+	// arithmetic on its numbers
+	registry.Register(registry.Evaluator{
+		Name:     "aviation",
+		Priority: registry.PriorityAviation,
+		Traits:   registry.MultiLine | registry.ReportsErrors,
+		Detect:   IsAviationExpression,
+		Eval:     EvalAviation,
+	})
+}
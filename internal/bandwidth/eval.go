@@ -0,0 +1,212 @@
+package bandwidth
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"smartcalc/internal/datetime"
+	"smartcalc/internal/units"
+	"smartcalc/internal/utils"
+)
+
+// Parts of the patterns: an amount of data such as "500 GB", a rate such as
+// "120 MB/s" or "1 Gbps", and a duration such as "3 hours". The units keep
+// their case, which tells bits (b) from bytes (B).
+const (
+	amountPart   = `(\d[\d,]*(?:\.\d+)?)\s*([a-z]+)`
+	ratePart     = `(\d[\d,]*(?:\.\d+)?)\s*(([kmgtpe]?i?(?:bits?|bytes?|b))(?:ps|/s|/sec|\s+per\s+second))`
+	durationPart = `(\d+(?:\.\d+)?|an?)\s*(seconds?|secs?|s|minutes?|mins?|hours?|hrs?|h|days?|d|weeks?|w)`
+)
+
+// transferPattern matches "500 GB at 120 MB/s", "2 TB over 1 Gbps" and "how
+// long to copy 40 GiB at 300 Mbit/s"
+var transferPattern = regexp.MustCompile(`(?i)^(?:how\s+long\s+to\s+(?:copy|transfer|send|move|download|upload)\s+)?` +
+	amountPart + `\s+(?:at|over)\s+` + ratePart + `$`)
+
+// volumePattern matches "how much data in 3 hours at 50 Mbps"
+var volumePattern = regexp.MustCompile(`(?i)^how\s+much\s+(?:data\s+)?(?:in|over)\s+` + durationPart + `\s+at\s+` + ratePart + `$`)
+
+// rateForPattern matches "50 Mbps for 3 hours"
+var rateForPattern = regexp.MustCompile(`(?i)^` + ratePart + `\s+for\s+` + durationPart + `$`)
+
+// dataUnitPattern reads a unit by its case: a prefix, "i" for the IEC
+// (powers of 1024) scale, and "b" or "bit" for bits or "B" or "byte" for
+// bytes, as in "Gb", "MiB" or "kbit"
+var dataUnitPattern = regexp.MustCompile(`^([kKmMgGtTpPeE]?)(i?)(bits?|bytes?|b|B)$`)
+
+// prefixes are the scale prefixes in order of size
+const prefixes = "kmgtpe"
+
+// dataUnit is a unit of data as written
+type dataUnit struct {
+	bytes float64 // bytes in one of the unit
+	bits  bool
+	name  string // the unit written properly: "Mbit", "MiB"
+	// sloppy is set for a lowercase prefix that stands for a larger one,
+	// "mbps" for Mbit/s, so the reading is worth a note
+	sloppy bool
+}
+
+// parseDataUnit reads a unit such as "GB", "Gb", "MiB" or "Mbit" by its case
+func parseDataUnit(unit string) (dataUnit, bool) {
+	m := dataUnitPattern.FindStringSubmatch(unit)
+	if m == nil {
+		return dataUnit{}, false
+	}
+	prefix, iec, symbol := m[1], m[2], m[3]
+	base := 1000.0
+	if iec != "" {
+		base = 1024
+	}
+	if iec != "" && prefix == "" {
+		return dataUnit{}, false
+	}
+	u := dataUnit{bytes: 1}
+	if prefix != "" {
+		power := strings.IndexByte(prefixes, strings.ToLower(prefix)[0]) + 1
+		for range power {
+			u.bytes *= base
+		}
+		u.sloppy = prefix != "k" && prefix == strings.ToLower(prefix)
+		// SI writes kilo lowercase; the rest are uppercase, and IEC are all
+		// uppercase
+		prefix = strings.ToUpper(prefix)
+		if prefix == "K" && iec == "" {
+			prefix = "k"
+		}
+	}
+	u.bits = symbol == "b" || strings.HasPrefix(symbol, "bit")
+	if u.bits {
+		u.bytes /= 8
+		u.name = prefix + iec + "bit"
+	} else {
+		u.name = prefix + iec + "B"
+	}
+	return u, true
+}
+
+// unitBytes returns the bytes in one of a unit of data such as "GB" or "Gb".
+// A unit written all in lowercase, "gb", is read in bytes the way unit
+// conversions read it, since amounts of data are rarely counted in bits.
+func unitBytes(unit string) (float64, bool) {
+	if unit == strings.ToLower(unit) {
+		if b, ok := units.DataInBytes(1, unit); ok {
+			return b, true
+		}
+	}
+	u, ok := parseDataUnit(unit)
+	return u.bytes, ok
+}
+
+// amountBytes reads an amount of data such as "500 GB" or "4 Gb" in bytes
+func amountBytes(amount, unit string) (float64, error) {
+	v, err := utils.ParseNumber(amount, false)
+	if err != nil {
+		return 0, err
+	}
+	b, ok := unitBytes(unit)
+	if !ok {
+		return 0, fmt.Errorf("unknown data unit %q", unit)
+	}
+	return v * b, nil
+}
+
+// rate is a transfer rate in bytes per second, with how it was written
+type rate struct {
+	bytesPerSecond float64
+	unit           dataUnit
+	written        string
+}
+
+// parseRate reads a rate such as "120 MB/s" or "1 Gbps", written as the
+// whole rate unit and unit as its unit of data; a lowercase "b" is a bit and
+// an uppercase "B" a byte
+func parseRate(amount, written, unit string) (rate, error) {
+	u, ok := parseDataUnit(unit)
+	if !ok {
+		return rate{}, fmt.Errorf("unknown rate unit %q", written)
+	}
+	v, err := utils.ParseNumber(amount, false)
+	if err != nil {
+		return rate{}, err
+	}
+	return rate{bytesPerSecond: v * u.bytes, unit: u, written: written}, nil
+}
+
+// note points out how a sloppy rate was read: "(mbps read as Mbit/s)"
+func (r rate) note() string {
+	if !r.unit.sloppy {
+		return ""
+	}
+	return fmt.Sprintf(" (%s read as %s/s)", r.written, r.unit.name)
+}
+
+// IsBandwidthExpression checks if an expression asks how long a transfer
+// takes or how much data a rate moves in a time
+func IsBandwidthExpression(expr string) bool {
+	expr = strings.TrimSpace(expr)
+	if m := transferPattern.FindStringSubmatch(expr); m != nil {
+		_, sizeOK := unitBytes(m[2])
+		_, rateOK := parseDataUnit(m[5])
+		return sizeOK && rateOK
+	}
+	for _, p := range []*regexp.Regexp{volumePattern, rateForPattern} {
+		if p.MatchString(expr) {
+			return true
+		}
+	}
+	return false
+}
+
+// EvalBandwidth works out how long an amount of data takes at a rate, "500
+// GB at 120 MB/s = 1.16 hours", or how much data a rate moves in a time,
+// "how much data in 3 hours at 50 Mbps = 67.5 GB / 62.86 GiB". A rate in
+// bits also shows in bytes per second. The value of a transfer time is in
+// seconds and that of an amount in bytes.
+func EvalBandwidth(expr string) (utils.Result, error) {
+	expr = strings.TrimSpace(expr)
+	if m := transferPattern.FindStringSubmatch(expr); m != nil {
+		size, err := amountBytes(m[1], m[2])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		r, err := parseRate(m[3], m[4], m[5])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		if r.bytesPerSecond == 0 {
+			return utils.Result{}, fmt.Errorf("a rate of zero never finishes")
+		}
+		seconds := size / r.bytesPerSecond
+		text := datetime.FormatDuration(time.Duration(seconds * float64(time.Second)))
+		if r.unit.bits {
+			text += " at " + units.FormatBytesSI(r.bytesPerSecond) + "/s"
+		}
+		return utils.ValueResult(text+r.note(), seconds, false), nil
+	}
+
+	if m := volumePattern.FindStringSubmatch(expr); m != nil {
+		return volume(m[3], m[4], m[5], m[1], m[2])
+	}
+	if m := rateForPattern.FindStringSubmatch(expr); m != nil {
+		return volume(m[1], m[2], m[3], m[4], m[5])
+	}
+	return utils.Result{}, fmt.Errorf("invalid bandwidth expression")
+}
+
+// volume works out how much data a rate such as "50 Mbps" moves in a time
+// such as "3 hours"
+func volume(amount, written, unit, timeAmount, timeUnit string) (utils.Result, error) {
+	r, err := parseRate(amount, written, unit)
+	if err != nil {
+		return utils.Result{}, err
+	}
+	d, err := datetime.ParseAmountDuration(timeAmount, timeUnit)
+	if err != nil {
+		return utils.Result{}, err
+	}
+	total := r.bytesPerSecond * d.Seconds()
+	return utils.ValueResult(units.FormatBytes(total)+r.note(), total, false), nil
+}
@@ -0,0 +1,119 @@
+package bandwidth
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestIsBandwidthExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"500 GB at 120 MB/s", true},
+		{"2 TB over 1 Gbps", true},
+		{"how long to copy 40 GiB at 300 Mbit/s", true},
+		{"how much data in 3 hours at 50 Mbps", true},
+		{"50 Mbps for 3 hours", true},
+		{"1 gigabyte at 10 MB per second", true},
+
+		// Conversions, prices and capacity plans are left alone
+		{"10 GB to MiB", false},
+		{"5 apples at 2 MB/s", false},
+		{"data at 50 MB/s for 1 day", false},
+		{"how long until 10 TB at 50 GB/day", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsBandwidthExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsBandwidthExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalBandwidth(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+		value    float64
+	}{
+		{"500 GB at 120 MB/s", "1.16 hours", 500e9 / 120e6},
+		// A rate in bits is an eighth of the same rate in bytes
+		{"2 TB over 1 Gbps", "4.44 hours at 125 MB/s", 16000},
+		{"4 Gb at 1 Gbps", "4.00 seconds at 125 MB/s", 4},
+		{"how long to copy 40 GiB at 300 Mbit/s", "19.09 minutes at 37.5 MB/s", 40 * (1 << 30) / 37.5e6},
+		// IEC prefixes are powers of 1024 on either side
+		{"1 TiB at 100 MiB/s", "2.91 hours", 10485.76},
+		{"1 TB at 100 MiB/s", "2.65 hours", 1e12 / (100 << 20)},
+		{"1 TiB at 100 MB/s", "3.05 hours", (1 << 40) / 100e6},
+		{"1,000 GB over 10 Gbps", "13.33 minutes at 1.25 GB/s", 800},
+		{"1 gigabyte at 10 MB per second", "1.67 minutes", 100},
+		{"how much data in 3 hours at 50 Mbps", "67.5 GB / 62.86 GiB", 67.5e9},
+		{"50 MiB/s for a day", "4.53 TB / 4.12 TiB", 50 * (1 << 20) * 86400},
+		{"50 Mbps for 3 hours", "67.5 GB / 62.86 GiB", 67.5e9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			r, err := EvalBandwidth(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalBandwidth(%q) error: %v", tt.expr, err)
+			}
+			if r.Text != tt.expected {
+				t.Errorf("EvalBandwidth(%q) = %q, want %q", tt.expr, r.Text, tt.expected)
+			}
+			if math.Abs(r.Value-tt.value) > 1e-6*tt.value {
+				t.Errorf("EvalBandwidth(%q) value = %v, want %v", tt.expr, r.Value, tt.value)
+			}
+		})
+	}
+}
+
+func TestEvalBandwidthLowercaseRates(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		// A lowercase "mbps" is read in bits, as line speeds are, with a note
+		{"1 GB at 10 mbps", "13.33 minutes at 1.25 MB/s (mbps read as Mbit/s)"},
+		{"how much data in 1 hour at 8 gb/s", "3.6 TB / 3.27 TiB (gb/s read as Gbit/s)"},
+		// Kilo is written lowercase, so "kbps" is no slip
+		{"1 GB at 100 kbps", "22.22 hours at 12.5 KB/s"},
+		// Amounts written in lowercase are bytes, as in unit conversions
+		{"500 gb at 120 MB/s", "1.16 hours"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			r, err := EvalBandwidth(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalBandwidth(%q) error: %v", tt.expr, err)
+			}
+			if r.Text != tt.expected {
+				t.Errorf("EvalBandwidth(%q) = %q, want %q", tt.expr, r.Text, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalBandwidthInvalidNumber(t *testing.T) {
+	huge := "1" + strings.Repeat("0", 400)
+	for _, expr := range []string{huge + " GB at 1 MB/s", "how much data in 1 hour at " + huge + " Mbps"} {
+		if !IsBandwidthExpression(expr) {
+			t.Errorf("IsBandwidthExpression(%q) = false", expr)
+		}
+		if _, err := EvalBandwidth(expr); err == nil || !strings.HasPrefix(err.Error(), "invalid number") {
+			t.Errorf("EvalBandwidth(%q) error = %v, want invalid number", expr, err)
+		}
+	}
+}
+
+func TestEvalBandwidthZeroRate(t *testing.T) {
+	_, err := EvalBandwidth("500 GB at 0 MB/s")
+	if err == nil || err.Error() != "a rate of zero never finishes" {
+		t.Errorf("EvalBandwidth error = %v, want a rate of zero never finishes", err)
+	}
+}
@@ -0,0 +1,14 @@
+package bandwidth
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// A zero rate is reported instead of left to the evaluators after
+	registry.Register(registry.Evaluator{
+		Name:     "bandwidth",
+		Priority: registry.PriorityBandwidth,
+		Traits:   registry.ReportsErrors,
+		Detect:   IsBandwidthExpression,
+		Eval:     EvalBandwidth,
+	})
+}
@@ -0,0 +1,182 @@
+package calc
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"smartcalc/internal/registry"
+	"smartcalc/internal/utils"
+)
+
+// AsyncResult is the result of a network line, delivered when its lookup lands
+type AsyncResult struct {
+	LineNum     int    // 1-based line number in the fast pass output, counting "> " lines
+	Token       uint64 // request the result answers
+	Placeholder string // the line as the fast pass showed it while the lookup ran
+	Output      string // the line with its result and any "> " output lines
+}
+
+// LookupFunc runs a network evaluator on an expression
+type LookupFunc func(ev registry.Evaluator, expr string) (utils.Result, error)
+
+// AsyncLookups fetches the certificate, DNS, WHOIS, GeoIP and "my ip" lines a
+// fast pass left pending, each on its own goroutine, so a slow host holds up
+// only its own line. Every request gets a token. When a line's expression
+// changes or it is no longer pending, its request is cancelled, and a result
+// that still arrives for the old token is discarded.
+type AsyncLookups struct {
+	mu       sync.Mutex
+	lookup   LookupFunc
+	tokens   uint64
+	requests map[int]*asyncRequest // by 0-based index among the expression lines
+}
+
+// asyncRequest is a lookup in flight
+type asyncRequest struct {
+	expr   string
+	token  uint64
+	cancel context.CancelFunc
+}
+
+// NewAsyncLookups creates a tracker that fetches lines with lookup. A nil
+// lookup runs the evaluator's Eval.
+func NewAsyncLookups(lookup LookupFunc) *AsyncLookups {
+	if lookup == nil {
+		lookup = func(ev registry.Evaluator, expr string) (utils.Result, error) {
+			return ev.Eval(expr)
+		}
+	}
+	return &AsyncLookups{lookup: lookup, requests: make(map[int]*asyncRequest)}
+}
+
+// Start looks up the network lines results, the fast pass over lines, left
+// pending. A line whose lookup of the same expression is already in flight
+// keeps it; the requests of the other lines are cancelled. deliver runs on
+// the lookup's goroutine once the line's result is in.
+func (a *AsyncLookups) Start(lines []string, activeLineNum int, results []LineResult, deliver func(AsyncResult)) {
+	cleaned := cleanOutputLines(lines)
+	lineNums := outputLineNumbers(results)
+	disabled := documentDisabled(cleaned)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	wanted := make(map[int]bool)
+	for idx, r := range results {
+		if !r.Pending || idx >= len(cleaned) {
+			continue
+		}
+		expr := lineExpression(cleaned[idx])
+		if utils.DecimalComma() {
+			expr = utils.CanonicalNumbers(expr) // as evalRegistered offers it
+		}
+		ev, ok := networkEvaluator(expr, disabled)
+		if !ok {
+			continue
+		}
+		wanted[idx] = true
+		if req, ok := a.requests[idx]; ok {
+			if req.expr == expr {
+				continue
+			}
+			req.cancel()
+		}
+
+		a.tokens++
+		ctx, cancel := context.WithCancel(context.Background())
+		req := &asyncRequest{expr: expr, token: a.tokens, cancel: cancel}
+		a.requests[idx] = req
+		placeholder := r.Output
+		go a.run(ctx, idx, req.token, ev, expr, func(p prefetched) {
+			output := evalLines(lines, activeLineNum, fastPass, map[int]prefetched{idx: p})[idx].Output
+			deliver(AsyncResult{LineNum: lineNums[idx], Token: req.token, Placeholder: placeholder, Output: output})
+		})
+	}
+
+	for idx, req := range a.requests {
+		if !wanted[idx] {
+			req.cancel()
+			delete(a.requests, idx)
+		}
+	}
+}
+
+// Cancel drops every lookup in flight
+func (a *AsyncLookups) Cancel() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for idx, req := range a.requests {
+		req.cancel()
+		delete(a.requests, idx)
+	}
+}
+
+// run looks a line up and passes the result to deliver if the request is
+// still current. The evaluators take no context, so a cancelled lookup runs
+// on until its own timeout; only its result is dropped.
+func (a *AsyncLookups) run(ctx context.Context, idx int, token uint64, ev registry.Evaluator, expr string, deliver func(prefetched)) {
+	done := make(chan prefetched, 1)
+	go func() {
+		r, err := a.lookup(ev, expr)
+		done <- prefetched{evaluator: ev.Name, expr: expr, result: r, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case p := <-done:
+		if a.finish(idx, token) {
+			deliver(p)
+		}
+	}
+}
+
+// finish retires the request of line idx if token is still current
+func (a *AsyncLookups) finish(idx int, token uint64) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	req, ok := a.requests[idx]
+	if !ok || req.token != token {
+		return false
+	}
+	delete(a.requests, idx)
+	return true
+}
+
+// NeedsDeferredPass reports whether results, the fast pass over lines, left
+// lines pending that AsyncLookups does not fetch, such as exchange rates
+func NeedsDeferredPass(lines []string, results []LineResult) bool {
+	cleaned := cleanOutputLines(lines)
+	for idx, r := range results {
+		if r.Pending && (idx >= len(cleaned) || !isNetworkLookup(lineExpression(cleaned[idx]))) {
+			return true
+		}
+	}
+	return false
+}
+
+// networkEvaluator finds the evaluator the dispatch table gives a network
+// lookup line to. ok is false when an evaluator that looks nothing up, or a
+// built-in one, claims the line first.
+func networkEvaluator(expr string, disabled map[string]bool) (registry.Evaluator, bool) {
+	if !isNetworkLookup(expr) {
+		return registry.Evaluator{}, false
+	}
+	ev, ok := registeredEvaluator(expr, disabled)
+	return ev, ok && ev.Traits.Has(registry.Expensive)
+}
+
+// outputLineNumbers maps the index of each result to the 1-based line it
+// starts on once the results are shown, "> " output lines included
+func outputLineNumbers(results []LineResult) []int {
+	nums := make([]int, len(results))
+	num := 1
+	for i, r := range results {
+		nums[i] = num
+		num += 1 + strings.Count(r.Output, "\n")
+	}
+	return nums
+}
@@ -0,0 +1,198 @@
+package calc
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"smartcalc/internal/registry"
+	"smartcalc/internal/utils"
+)
+
+// slowLookup is a fake network evaluator that answers each expression only
+// once it is released, and records the expressions it was asked for
+type slowLookup struct {
+	mu      sync.Mutex
+	calls   []string
+	release map[string]chan struct{}
+}
+
+func newSlowLookup() *slowLookup {
+	return &slowLookup{release: make(map[string]chan struct{})}
+}
+
+// gate returns the channel that releases the answer for expr
+func (l *slowLookup) gate(expr string) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.release[expr] == nil {
+		l.release[expr] = make(chan struct{})
+	}
+	return l.release[expr]
+}
+
+func (l *slowLookup) lookup(ev registry.Evaluator, expr string) (utils.Result, error) {
+	l.mu.Lock()
+	l.calls = append(l.calls, expr)
+	l.mu.Unlock()
+	<-l.gate(expr)
+	return utils.TextResult("answer for " + expr), nil
+}
+
+func (l *slowLookup) called() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.calls, ",")
+}
+
+// startAsync runs the fast pass over lines and starts their lookups
+func startAsync(a *AsyncLookups, lines []string, deliver func(AsyncResult)) []LineResult {
+	results := EvalLinesFast(lines, 0)
+	a.Start(lines, 0, results, deliver)
+	return results
+}
+
+// waitResult waits for the next delivered result
+func waitResult(t *testing.T, delivered chan AsyncResult) AsyncResult {
+	t.Helper()
+	select {
+	case r := <-delivered:
+		return r
+	case <-time.After(time.Second):
+		t.Fatal("no result was delivered")
+	}
+	return AsyncResult{}
+}
+
+// expectNoResult checks that nothing more is delivered
+func expectNoResult(t *testing.T, delivered chan AsyncResult) {
+	t.Helper()
+	select {
+	case r := <-delivered:
+		t.Errorf("unexpected delivery %+v", r)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestAsyncLookupsDeliversLine(t *testing.T) {
+	fake := newSlowLookup()
+	a := NewAsyncLookups(fake.lookup)
+	delivered := make(chan AsyncResult, 4)
+
+	// The stale output line is dropped, so the lookups land on lines 2 and 3
+	lines := []string{"2 + 3 =", "> stale output", "dig example.com =", "geoip 8.8.8.8 ="}
+	results := startAsync(a, lines, func(r AsyncResult) { delivered <- r })
+	if results[1].Output != "dig example.com = "+PendingResult {
+		t.Fatalf("fast pass shows %q, want the placeholder", results[1].Output)
+	}
+
+	close(fake.gate("geoip 8.8.8.8"))
+	got := waitResult(t, delivered)
+	if got.LineNum != 3 || got.Placeholder != "geoip 8.8.8.8 = "+PendingResult || got.Output != "geoip 8.8.8.8 = answer for geoip 8.8.8.8" {
+		t.Errorf("delivered %+v, want line 3 with its answer", got)
+	}
+
+	close(fake.gate("dig example.com"))
+	got = waitResult(t, delivered)
+	if got.LineNum != 2 || got.Output != "dig example.com = answer for dig example.com" {
+		t.Errorf("delivered %+v, want line 2 with its answer", got)
+	}
+	expectNoResult(t, delivered)
+}
+
+func TestAsyncLookupsDiscardsEditedLine(t *testing.T) {
+	fake := newSlowLookup()
+	a := NewAsyncLookups(fake.lookup)
+	delivered := make(chan AsyncResult, 4)
+	deliver := func(r AsyncResult) { delivered <- r }
+
+	startAsync(a, []string{"dig example.co ="}, deliver)
+	startAsync(a, []string{"dig example.com ="}, deliver) // the line was edited
+
+	close(fake.gate("dig example.co")) // the stale answer lands first
+	expectNoResult(t, delivered)
+
+	close(fake.gate("dig example.com"))
+	got := waitResult(t, delivered)
+	if got.Output != "dig example.com = answer for dig example.com" || got.Token != 2 {
+		t.Errorf("delivered %+v, want the edited line's answer for token 2", got)
+	}
+	expectNoResult(t, delivered)
+}
+
+func TestAsyncLookupsKeepsRequestInFlight(t *testing.T) {
+	fake := newSlowLookup()
+	a := NewAsyncLookups(fake.lookup)
+	delivered := make(chan AsyncResult, 4)
+	deliver := func(r AsyncResult) { delivered <- r }
+
+	// Typing on another line re-evaluates the document; the unchanged line
+	// keeps its lookup instead of starting another one
+	startAsync(a, []string{"dig example.com =", "1 + 1 ="}, deliver)
+	startAsync(a, []string{"dig example.com =", "1 + 1 + 1 ="}, deliver)
+
+	close(fake.gate("dig example.com"))
+	got := waitResult(t, delivered)
+	if got.Token != 1 {
+		t.Errorf("delivered token %d, want the first request's", got.Token)
+	}
+	expectNoResult(t, delivered)
+	if calls := fake.called(); calls != "dig example.com" {
+		t.Errorf("looked up %q, want a single lookup", calls)
+	}
+}
+
+func TestAsyncLookupsCancel(t *testing.T) {
+	fake := newSlowLookup()
+	a := NewAsyncLookups(fake.lookup)
+	delivered := make(chan AsyncResult, 4)
+	deliver := func(r AsyncResult) { delivered <- r }
+
+	// A line that is deleted is cancelled by the next Start
+	startAsync(a, []string{"dig example.com =", "whois example.com ="}, deliver)
+	startAsync(a, []string{"dig example.com ="}, deliver)
+	close(fake.gate("whois example.com"))
+	expectNoResult(t, delivered)
+
+	a.Cancel()
+	close(fake.gate("dig example.com"))
+	expectNoResult(t, delivered)
+}
+
+func TestNetworkEvaluator(t *testing.T) {
+	tests := []struct {
+		expr     string
+		disabled map[string]bool
+		want     string
+	}{
+		{"dig example.com", nil, "dns"},
+		{"whois example.com", nil, "whois"}, // before datetime, as in dispatch
+		{"dig example.com", map[string]bool{"dns": true}, ""},
+		{"2 + 3", nil, ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if ev, ok := networkEvaluator(tt.expr, tt.disabled); ok {
+			got = ev.Name
+		}
+		if got != tt.want {
+			t.Errorf("networkEvaluator(%q, %v) = %q, want %q", tt.expr, tt.disabled, got, tt.want)
+		}
+	}
+
+	// A line a built-in evaluator claims first is not the registry's
+	if ev, ok := registeredEvaluator("today + 3 days", nil); ok {
+		t.Errorf("registeredEvaluator() = %q, want the built-in datetime", ev.Name)
+	}
+}
+
+func TestNeedsDeferredPass(t *testing.T) {
+	lines := []string{"dig example.com =", "2 + 3 ="}
+	if NeedsDeferredPass(lines, EvalLinesFast(lines, 0)) {
+		t.Error("NeedsDeferredPass = true, want the DNS line left to AsyncLookups")
+	}
+	if results := EvalLinesDeferred(lines, 0); !results[0].Pending {
+		t.Errorf("deferred pass shows %q, want the DNS line still pending", results[0].Output)
+	}
+}
@@ -0,0 +1,114 @@
+package calc
+
+import (
+	"crypto/sha256"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"smartcalc/internal/datetime"
+	"smartcalc/internal/eval"
+	"smartcalc/internal/fraction"
+	"smartcalc/internal/percentage"
+	"smartcalc/internal/utils"
+)
+
+// maxCachedResults bounds the result cache; it is cleared when full
+const maxCachedResults = 10000
+
+// cachedResult is the memoized outcome of the handler chain for one line
+type cachedResult struct {
+	output      string // line output without its inline comment
+	hasResult   bool
+	hasValue    bool // value is referenceable as \N
+	value       float64
+	isCurrency  bool
+	isDateTime  bool
+	dateTimeStr string
+	evaluator   string
+	duration    time.Duration // time the evaluation took
+}
+
+var (
+	cacheMu     sync.Mutex
+	resultCache = make(map[[sha256.Size]byte]cachedResult)
+)
+
+// ResetCache drops all memoized line results
+func ResetCache() {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	clear(resultCache)
+}
+
+func lookupResult(key [sha256.Size]byte) (cachedResult, bool) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	c, ok := resultCache[key]
+	return c, ok
+}
+
+func storeResult(key [sha256.Size]byte, c cachedResult) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if len(resultCache) >= maxCachedResults {
+		clear(resultCache)
+	}
+	resultCache[key] = c
+}
+
+// uncachedPattern matches expressions whose result depends on the clock or on
+// chance without being volatile: generated passwords, loan payoff dates and
+// JWT expiry checks. Text statistics of referenced lines depend on their
+// text, which the cache key doesn't hold.
+var uncachedPattern = regexp.MustCompile(`(?i)^pwgen\b|\b(?:loan|mortgage|jwt)\b|\beyJ|^(?:(?:text\s+)?stats\s+of|word\s*count(?:\s+of)?|reading\s+time\s+of)\s+\\`)
+
+// isCacheable checks if the result of an expression depends only on its text
+// and the values it reads. Volatile lines are excluded by the caller, and
+// network lookups are not stored. A ledger transaction depends on the
+// balance above it and a data block on its rows, which the cache key doesn't
+// hold either.
+func isCacheable(expr string) bool {
+	return !uncachedPattern.MatchString(expr) && !isLedgerEntry(expr) && !isDataExpr(expr)
+}
+
+// resultCacheKey hashes an expression together with everything its result
+// depends on: whether it is shown formatted, the document's directives, the
+// values of the lines and variables it reads, and the settings evaluators use.
+// A line whose references changed value hashes to a new key, so stale results
+// are never reused.
+func resultCacheKey(expr string, formatted bool, directives string, results []LineResult, values []float64,
+	haveRes, currencyByLine []bool, vars map[string]float64, currencyByVar map[string]bool) [sha256.Size]byte {
+	var sb strings.Builder
+	sb.WriteString(expr)
+	sb.WriteString("\x00" + strconv.FormatBool(formatted))
+	sb.WriteString("\x00" + directives)
+	sb.WriteString("\x00" + strconv.FormatFloat(percentage.GetDefaultTaxRate(), 'g', -1, 64))
+	sb.WriteString("\x00" + string(datetime.GetAmbiguityMode()))
+	sb.WriteString("\x00" + strconv.FormatBool(fraction.Enabled()))
+	sb.WriteString("\x00" + strconv.FormatBool(utils.DecimalComma()))
+	sb.WriteString("\x00" + strconv.Itoa(utils.Precision()))
+	sb.WriteString("\x00" + utils.Language())
+
+	for _, n := range eval.ReferencedLines(expr) {
+		sb.WriteString("\x00\\" + strconv.Itoa(n))
+		if n < 1 || n > len(values) {
+			continue
+		}
+		if haveRes[n-1] {
+			sb.WriteString("=" + strconv.FormatFloat(values[n-1], 'g', -1, 64) + strconv.FormatBool(currencyByLine[n-1]))
+		}
+		if results[n-1].IsDateTime {
+			sb.WriteString("@" + results[n-1].DateTimeStr)
+		}
+	}
+	for _, name := range eval.ExprVariables(expr) {
+		sb.WriteString("\x00" + name)
+		if v, ok := vars[name]; ok {
+			sb.WriteString("=" + strconv.FormatFloat(v, 'g', -1, 64) + strconv.FormatBool(currencyByVar[name]))
+		}
+	}
+	return sha256.Sum256([]byte(sb.String()))
+}
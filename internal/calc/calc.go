@@ -1,29 +1,18 @@
 package calc
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
-	"smartcalc/internal/cert"
-	"smartcalc/internal/color"
 	"smartcalc/internal/constants"
-	"smartcalc/internal/cooking"
 	"smartcalc/internal/datetime"
 	"smartcalc/internal/eval"
-	"smartcalc/internal/finance"
-	"smartcalc/internal/hourlycost"
-	"smartcalc/internal/jwt"
-	"smartcalc/internal/manhour"
-	"smartcalc/internal/network"
-	"smartcalc/internal/percentage"
-	"smartcalc/internal/permissions"
-	"smartcalc/internal/programmer"
-	"smartcalc/internal/radio"
-	"smartcalc/internal/regex"
-	"smartcalc/internal/stats"
-	"smartcalc/internal/units"
 	"smartcalc/internal/utils"
 )
 
@@ -96,7 +85,7 @@ func isBaseConversionExpr(expr string) bool {
 }
 
 // findResultEquals finds the position of the trailing '=' that marks the result,
-// skipping '=' characters that are part of comparison operators (>=, <=, ==, !=)
+// skipping '=' characters that are part of comparison operators (>=, <=, ==, !=, ~=)
 // or base64 padding (trailing = or == without space before).
 // Returns -1 if no result '=' is found.
 func findResultEquals(s string) int {
@@ -104,10 +93,10 @@ func findResultEquals(s string) int {
 	// and is not part of a comparison operator
 	for i := len(s) - 1; i >= 0; i-- {
 		if s[i] == '=' {
-			// Check if this '=' is part of >=, <=, ==, or !=
+			// Check if this '=' is part of >=, <=, ==, !=, or ~=
 			if i > 0 {
 				prev := s[i-1]
-				if prev == '>' || prev == '<' || prev == '=' || prev == '!' {
+				if prev == '>' || prev == '<' || prev == '=' || prev == '!' || prev == '~' {
 					continue // Skip this '=', it's part of a comparison operator
 				}
 				// Result delimiter should have a space before it (e.g., "2 + 2 =")
@@ -127,21 +116,153 @@ func findResultEquals(s string) int {
 // The comment must appear after the result '=' to be preserved.
 func extractInlineComment(line string, eqPos int) string {
 	// Look for # after the = sign
-	afterEq := line[eqPos+1:]
-	hashIdx := strings.Index(afterEq, "#")
-	if hashIdx >= 0 {
+	if hashIdx := commentIndexFrom(line, eqPos+1); hashIdx >= 0 {
 		// Preserve the comment exactly as typed, no trimming
-		comment := afterEq[hashIdx:]
-		return " " + comment
+		return " " + line[hashIdx:]
 	}
 	return ""
 }
 
+// hexColorExprPattern matches hex color expressions like "#FF5733 to rgb" or
+// "#3498db lighten 20%", which start with '#' but are not comments
+var hexColorExprPattern = regexp.MustCompile(`(?i)^#[0-9a-f]{3,6}\s+(?:to|in|lighten|darken)\s+`)
+
+// hexDigitsPattern matches the digits of a hex color following a '#'
+var hexDigitsPattern = regexp.MustCompile(`^[0-9a-fA-F]{3,6}(?:\s|$)`)
+
+// IsCommentLine checks if a line is a comment (starting with #, allowing
+// leading whitespace). Hex color expressions like "#FF5733 to rgb" are not comments.
+func IsCommentLine(line string) bool {
+	trimmed := strings.TrimLeft(line, " \t")
+	return strings.HasPrefix(trimmed, "#") && !hexColorExprPattern.MatchString(trimmed)
+}
+
+// urlTextPattern matches "url encode"/"url decode" lines, whose argument is
+// free text that may itself contain '#'
+var urlTextPattern = regexp.MustCompile(`(?i)^\s*url\s+(?:encode|decode)\s+`)
+
+// commentIndex returns the index of the '#' that starts an inline comment, or
+// -1. A '#' starting a hex color (#FF5733), inside a double-quoted string
+// (JSON, regex test input) or inside a backticked prose fragment is not a
+// comment. Quotes and backticks are ignored when they don't pair up, so an
+// inch mark like 5" doesn't hide a comment. On a url encode/decode line
+// see urlCommentIndex.
+func commentIndex(s string) int {
+	if urlTextPattern.MatchString(s) {
+		return urlCommentIndex(s)
+	}
+	idx, inQuote, inCode := -1, false, false
+	for i := 0; i < len(s) && idx < 0; i++ {
+		switch s[i] {
+		case '\\':
+			if inQuote {
+				i++ // escaped character inside a string
+			}
+		case '"':
+			if !inCode {
+				inQuote = !inQuote
+			}
+		case '`':
+			if !inQuote {
+				inCode = !inCode
+			}
+		case '#':
+			if !inQuote && !inCode && !hexDigitsPattern.MatchString(s[i+1:]) {
+				idx = i
+			}
+		}
+	}
+	if idx < 0 && (inQuote || inCode) {
+		for i := 0; i < len(s); i++ {
+			if s[i] == '#' && !hexDigitsPattern.MatchString(s[i+1:]) {
+				return i
+			}
+		}
+	}
+	return idx
+}
+
+// urlCommentIndex returns the index of the comment on a url encode/decode
+// line, or -1. The argument is never cut: a comment may only follow the
+// result '=' and must be separated from the result by a space, so both
+// "url encode a#b =" and the decoded result "a#b" keep their '#'.
+func urlCommentIndex(line string) int {
+	eq := findResultEquals(line)
+	if eq < 0 {
+		return -1
+	}
+	for i := eq + 1; i < len(line); i++ {
+		if line[i] == '#' && (line[i-1] == ' ' || line[i-1] == '\t') {
+			return i
+		}
+	}
+	return -1
+}
+
+// commentIndexFrom returns the index in line of the inline comment starting
+// at or after from, or -1. The whole line is consulted so a url encode/decode
+// result isn't read out of context.
+func commentIndexFrom(line string, from int) int {
+	if urlTextPattern.MatchString(line) {
+		if hashIdx := urlCommentIndex(line); hashIdx >= from {
+			return hashIdx
+		}
+		return -1
+	}
+	if hashIdx := commentIndex(line[from:]); hashIdx >= 0 {
+		return from + hashIdx
+	}
+	return -1
+}
+
+// stripInlineComment removes the inline comment from a line, if any
+func stripInlineComment(line string) string {
+	if hashIdx := commentIndex(line); hashIdx >= 0 {
+		return line[:hashIdx]
+	}
+	return line
+}
+
+// SplitResult splits an evaluated line into its expression, result and inline
+// comment. ok is false when the line has no result '=', or is a prose line
+// with backticked fragments. Hex colors in the
+// result are not mistaken for a comment.
+// Example: "2 + 3 = 5 # my note" -> "2 + 3", "5", "# my note"
+func SplitResult(line string) (expr, result, comment string, ok bool) {
+	if isProseLine(line) {
+		return "", "", "", false // results live inside the fragments
+	}
+	eq := findResultEquals(stripInlineComment(line))
+	if eq < 0 {
+		return "", "", "", false
+	}
+	expr = strings.TrimSpace(line[:eq])
+	start := eq + 1 + precisionMarkerEnd(line[eq+1:])
+	result = line[start:]
+	if hashIdx := commentIndexFrom(line, start); hashIdx >= 0 {
+		comment = strings.TrimSpace(line[hashIdx:])
+		result = line[start:hashIdx]
+	}
+	return expr, strings.TrimSpace(result), comment, true
+}
+
+// withinPattern matches the tolerance comparison form "a within t of b"
+var withinPattern = regexp.MustCompile(`(?i)\bwithin\b.+\bof\b`)
+
+// betweenPattern matches the range membership form "a between lo and hi"
+var betweenPattern = regexp.MustCompile(`(?i)\bbetween\b.+\band\b`)
+
 // isComparisonExpr checks if an expression contains comparison operators
 func isComparisonExpr(expr string) bool {
-	// Check for comparison operators: >, <, >=, <=, ==, !=
+	// Check for comparison operators: >, <, >=, <=, ==, !=, ~=
 	if strings.Contains(expr, ">=") || strings.Contains(expr, "<=") ||
-		strings.Contains(expr, "==") || strings.Contains(expr, "!=") {
+		strings.Contains(expr, "==") || strings.Contains(expr, "!=") ||
+		strings.Contains(expr, "~=") {
+		return true
+	}
+	// Check for tolerance comparison: "a within t of b" and range membership:
+	// "a between lo and hi"
+	if withinPattern.MatchString(expr) || betweenPattern.MatchString(expr) {
 		return true
 	}
 	// Check for single > or < (but not part of >= or <=)
@@ -168,7 +289,7 @@ func formatExpression(expr string) string {
 	// But be careful not to affect:
 	// - Negative numbers at start or after operator
 	// - Currency symbols like $
-	// - Decimal points
+	// - Decimal points and commas, and thousands separators (1.234,56)
 	// - CIDR notation like /24
 	// - Time notation like 6:00
 
@@ -185,15 +306,15 @@ func formatExpression(expr string) string {
 		// Multiplication variants
 		{`(\S)\s*×\s*(\S)`, `$1 × $2`},
 		{`(\S)\s*÷\s*(\S)`, `$1 ÷ $2`},
-		{`([^0])\s*x\s*(\d)`, `$1 x $2`}, // x as multiplication, but not after 0 (hex notation 0x)
-		{`(\d)\s*\*\s*(\d)`, `$1 * $2`},  // * between digits
-		{`(\d)\s*\^\s*(\d)`, `$1 ^ $2`},  // ^ between digits
+		{`([^0a-zA-Z])\s*x\s*(\d)`, `$1 x $2`}, // x as multiplication, but not after 0 (hex notation 0x) or inside a word (tax 8%)
+		{`(\d)\s*\*\s*(\d)`, `$1 * $2`},        // * between digits
+		{`(\d)\s*\^\s*(\d)`, `$1 ^ $2`},        // ^ between digits
 		// Addition - digit/paren/percent followed by +
 		{`([\d\)%])\s*\+\s*(\S)`, `$1 + $2`},
 		// Subtraction - digit/paren/percent followed by -
 		{`([\d\)%])\s*-\s*(\S)`, `$1 - $2`},
 		// Division - but not CIDR notation (/24)
-		{`(\d)\s*/\s*(\d{3,})`, `$1 / $2`}, // Only if divisor is 3+ digits (not CIDR)
+		{`(\d)\s*/\s*(\d{3,}|\d+[.,]\d)`, `$1 / $2`}, // Only if divisor is 3+ digits or has a decimal mark or separator (not CIDR)
 	}
 
 	for _, op := range operators {
@@ -206,14 +327,147 @@ func formatExpression(expr string) string {
 
 // LineResult holds the result of evaluating a single line.
 type LineResult struct {
-	Output      string
-	Value       float64
-	HasResult   bool
-	IsCurrency  bool
-	IsDateTime  bool
-	DateTimeStr string // raw datetime result for reference
+	Output       string
+	Value        float64
+	HasResult    bool
+	IsCurrency   bool
+	IsDateTime   bool
+	DateTimeStr  string        // raw datetime result for reference
+	IsAssertion  bool          // line is an "assert <condition>" check
+	AssertFailed bool          // assertion condition evaluated to false
+	Pending      bool          // expensive evaluation was left to the deferred pass
+	Volatile     bool          // result changes over time (now, random, ...) or depends on such a line
+	Pinned       bool          // line is frozen with "!pin" or "=*" and keeps its stored result
+	PanelPin     bool          // line ends with "pin" and is shown in the pinned results panel
+	Label        string        // name of a PanelPin line in the panel
+	Expectation  bool          // line has an "# expect <value>" annotation
+	ExpectFailed bool          // result differs from the expected value
+	Evaluator    string        // evaluator that claimed the line ("units", "dns", ...)
+	Duration     time.Duration // time spent evaluating the line
+	Queued       time.Duration // time a deferred line waited for the deferred pass
+
+	hasValue bool // primary value is referenceable as \N
+	fetched  bool // result was looked up over the network in this pass
+}
+
+// PendingResult is shown for an expensive line until its lookup lands
+const PendingResult = "⏳ fetching..."
+
+// passMode decides which lookups an evaluation pass runs
+type passMode int
+
+const (
+	fullPass     passMode = iota // every lookup runs
+	fastPass                     // expensive lines are left pending
+	deferredPass                 // network lookup lines are left pending for AsyncLookups
+	freshPass                    // every lookup runs, even for lines already showing a result
+	refreshPass                  // like fullPass, but random lines are drawn again
+)
+
+// assertPattern matches assertion lines like "assert \5 <= 10000"
+var assertPattern = regexp.MustCompile(`(?i)^assert\s+(.+)$`)
+
+// aggregatePattern matches lines that aggregate the block above them:
+// "sum above", "total", "avg above" / "average above"
+var aggregatePattern = regexp.MustCompile(`(?i)^(sum\s+above|total|avg\s+above|average\s+above)$`)
+
+// aggregateBlockStart returns the 0-based index of the first line in the
+// block that ends just above line idx. Blocks are separated by blank lines.
+func aggregateBlockStart(lines []string, idx int) int {
+	start := idx
+	for start > 0 && strings.TrimSpace(lines[start-1]) != "" {
+		start--
+	}
+	return start
+}
+
+// isAggregateLine checks if a line is a "total" / "sum above" / "avg above" line
+func isAggregateLine(line string) bool {
+	return aggregatePattern.MatchString(lineExpression(line))
+}
+
+// pinMarkerPattern matches the "!pin" marker after a line's result
+var pinMarkerPattern = regexp.MustCompile(`(?:^|\s)!pin\s*$`)
+
+// pinnedResult checks whether a line (without its inline comment) is pinned,
+// by a "!pin" marker after the result or by a "=*" result separator, and
+// returns the stored result text. starForm reports the "=*" spelling.
+func pinnedResult(workingLine string, eq int) (stored string, starForm, pinned bool) {
+	rest := workingLine[eq+1:]
+	if strings.HasPrefix(rest, "*") {
+		rest, starForm, pinned = rest[1:], true, true
+	}
+	rest = rest[precisionMarkerEnd(rest):] // "= :6 0.333333 !pin"
+	if loc := pinMarkerPattern.FindStringIndex(rest); loc != nil {
+		rest, pinned = rest[:loc[0]], true
+	}
+	return strings.TrimSpace(rest), starForm, pinned
+}
+
+// linePin checks if a line is pinned and returns its stored result, if any
+func linePin(line string) (stored string, pinned bool) {
+	if hashIdx := strings.Index(line, "#"); hashIdx >= 0 {
+		line = line[:hashIdx]
+	}
+	eq := findResultEquals(line)
+	if eq < 0 {
+		return "", false
+	}
+	stored, _, pinned = pinnedResult(line, eq)
+	return stored, pinned
 }
 
+// isPinnedLine checks if a line is pinned and already holds a stored result
+func isPinnedLine(line string) bool {
+	stored, pinned := linePin(line)
+	return pinned && stored != ""
+}
+
+// notationPattern matches a trailing notation override: "... in sci", "... in eng"
+var notationPattern = regexp.MustCompile(`(?i)^(.+?)\s+in\s+(sci|scientific|eng|engineering)$`)
+
+// volatilePattern matches expressions whose result changes between evaluations
+var volatilePattern = regexp.MustCompile(`(?i)\b(now|today|random|uuid|totp)\b|\broll\s+\d*d\d|\bsample\s+\d+\s+from\b|\bmy\s+ip\b|\btime\s+(?:until|till|since)\b|\bcron\b.*\bnext\b|\bevery\s+(?:\d+\s+|other\s+)?(?:day|week|fortnight|month|quarter|year|sun|mon|tue|wed|thu|fri|sat)`)
+
+// VolatileLines returns the line numbers (1-based) of lines whose results
+// change over time, such as "now" or "random 1 to 10", together with every
+// line that depends on them.
+func VolatileLines(lines []string) []int {
+	volatile := make(map[int]bool)
+	for i, line := range lines {
+		if volatile[i+1] || isPinnedLine(line) || !volatilePattern.MatchString(strings.Join(lineExpressions(line), " ")) {
+			continue
+		}
+		volatile[i+1] = true
+		for _, dep := range FindDependentLines(lines, i+1) {
+			volatile[dep] = true
+		}
+	}
+
+	result := make([]int, 0, len(volatile))
+	for lineNum := range volatile {
+		result = append(result, lineNum)
+	}
+	sort.Ints(result)
+	return result
+}
+
+// RefreshLines re-evaluates the document on an explicit refresh request.
+// Everything is evaluated in a single pass with the clock frozen, so volatile
+// lines and their dependents update together and every "now" agrees. The
+// result cache is dropped, so every line is recomputed, and dice are rolled
+// again.
+func RefreshLines(lines []string) []LineResult {
+	ResetCache()
+	frozen := time.Now()
+	datetime.SetClock(func() time.Time { return frozen })
+	defer datetime.SetClock(nil)
+	return evalLines(lines, 0, refreshPass, nil)
+}
+
+// comparisonSplitPattern splits a condition at its comparison operator
+var comparisonSplitPattern = regexp.MustCompile(`^(.+?)\s*(<=|>=|==|!=|~=|<|>)\s*(.+)$`)
+
 // cleanOutputLines removes stale output lines ("> " prefixed) that follow expression lines.
 // This ensures old multi-line output is cleared before new evaluation.
 func cleanOutputLines(lines []string) []string {
@@ -233,6 +487,35 @@ func cleanOutputLines(lines []string) []string {
 // When activeLineNum > 0, only that line and its dependents are re-evaluated.
 // Pass 0 or negative to evaluate all lines (used for initial load).
 func EvalLines(lines []string, activeLineNum int) []LineResult {
+	return evalLines(lines, activeLineNum, fullPass, nil)
+}
+
+// EvalLinesFast is the fast pass of EvalLines: only local evaluators run.
+// Lines needing network lookups (DNS, WHOIS, certificates, GeoIP, exchange
+// rates) are marked Pending and left for a deferred pass.
+func EvalLinesFast(lines []string, activeLineNum int) []LineResult {
+	return evalLines(lines, activeLineNum, fastPass, nil)
+}
+
+// EvalLinesDeferred is the deferred pass that follows EvalLinesFast. The
+// expensive lines are evaluated, except the certificate, DNS, WHOIS, GeoIP
+// and "my ip" lookups, which stay pending: AsyncLookups fetches those line
+// by line so a slow host only holds up its own line.
+func EvalLinesDeferred(lines []string, activeLineNum int) []LineResult {
+	return evalLines(lines, activeLineNum, deferredPass, nil)
+}
+
+// HasPending reports whether any line was left for the deferred pass
+func HasPending(results []LineResult) bool {
+	for _, r := range results {
+		if r.Pending {
+			return true
+		}
+	}
+	return false
+}
+
+func evalLines(lines []string, activeLineNum int, mode passMode, lookups map[int]prefetched) []LineResult {
 	// Build a map of expression lines that have multi-line output (lines starting with ">")
 	// This is used to preserve existing multi-line output for lines that aren't re-evaluated
 	hasMultiLineOutput := make(map[int][]string) // maps cleaned line index to its output lines
@@ -264,60 +547,204 @@ func EvalLines(lines []string, activeLineNum int) []LineResult {
 		for _, dep := range dependents {
 			linesToEvaluate[dep] = true
 		}
+		// A directive changes how every line below it evaluates
+		if activeLineNum <= len(cleanedLines) && isDirectiveLine(cleanedLines[activeLineNum-1]) {
+			for lineNum := activeLineNum + 1; lineNum <= len(cleanedLines); lineNum++ {
+				linesToEvaluate[lineNum] = true
+			}
+		}
+	}
+
+	d := newDocument(len(cleanedLines), activeLineNum, mode, hasMultiLineOutput, documentDisabled(cleanedLines))
+	d.lookups = lookups
+	d.lines = cleanedLines
+	d.setGradeScale(cleanedLines)
+	d.setPresets(cleanedLines)
+	d.dataRows = dataRowLines(cleanedLines)
+	results, values, haveRes, currencyByLine := d.results, d.values, d.haveRes, d.currencyByLine
+	vars, currencyByVar := d.vars, d.currencyByVar
+	refResolver, varResolver := d.refResolver, d.varResolver
+	maybeFormat, recordValue := d.maybeFormat, d.recordValue
+
+	for _, lineNum := range VolatileLines(cleanedLines) {
+		results[lineNum-1].Volatile = true
+	}
+
+	// usePinned keeps a pinned line as typed and makes its stored result
+	// available to references as a constant
+	usePinned := func(lineIdx int, line, stored string) {
+		results[lineIdx].Output = line
+		if outputLines, ok := hasMultiLineOutput[lineIdx]; ok {
+			results[lineIdx].Output = line + "\n" + strings.Join(outputLines, "\n")
+		}
+		results[lineIdx].HasResult = true
+		results[lineIdx].Pinned = true
+
+		if datetime.IsDateTimeExpression(stored) {
+			if dt, err := datetime.EvalDateTime(stored); err == nil && !strings.HasPrefix(dt, "\n") {
+				results[lineIdx].IsDateTime = true
+				results[lineIdx].DateTimeStr = stored
+				return
+			}
+		}
+		stored = d.sheet.currencyInput(stored)
+		if val, err := eval.EvalExprWithOptions(stored, nil, nil, d.sheet.evalOptions()); err == nil {
+			recordValue(lineIdx, utils.ValueResult(stored, val, strings.Contains(stored, "$")))
+		}
 	}
 
-	results := make([]LineResult, len(cleanedLines))
-	values := make([]float64, len(cleanedLines))
-	haveRes := make([]bool, len(cleanedLines))
-	currencyByLine := make([]bool, len(cleanedLines))
+	// evalFragment evaluates an expression embedded in a line (a what-if table
+	// row, a prose fragment) with the variables and values known so far
+	evalFragment := func(expr string) LineResult {
+		return d.evalInContext(expr)
+	}
+
+	// Results depend on which evaluators are turned off, on the grade scale
+	// and on the presets
+	directivesKey := disableDirective(d.disabled) + "\n" + d.gradeScaleLine + "\n" + strings.Join(d.presetLines, "\n")
+
+	// missed remembers lines that went through the handler chain without a
+	// cached result, to be memoized once the loop is done
+	type cacheMiss struct {
+		key           [sha256.Size]byte
+		expr, comment string
+	}
+	missed := make(map[int]cacheMiss)
+
+	// pinMarks remembers pinned lines evaluated for the first time, whose
+	// marker is put back once the result is known
+	pinMarks := make(map[int]bool) // line index -> uses "=*"
+
+	// panelPins remembers the lines in the pinned results panel ("pin"),
+	// whose keyword is put back once the result is known
+	panelPins := make(map[int]string) // line index -> label
+
+	// tracks remembers the "!track" lines being evaluated, whose marker and
+	// history lines are put back once the result is known
+	tracks := make(map[int]trackedLine)
+
+	// hints remembers lines with a precision hint ("1/3 = :6"), which are
+	// shown with their own number of decimal places and get the hint back
+	// once the result is known
+	hints := make(map[int]precisionHint)
+
+	// explains remembers the "?explain" lines, which get the suffix back and
+	// the trace of the evaluators they were offered to once the result is
+	// known
+	explains := make(map[int]*explainTrace)
+
+	// A line with a precision hint, or with amounts in another currency than
+	// the sheet's, is evaluated with settings of its own
+	outerSheet, ownSheet := d.sheet, false
+	useLineSheet := func(s sheetSettings) {
+		if !ownSheet {
+			outerSheet, ownSheet = d.sheet, true
+		}
+		d.sheet = s
+	}
+	endLineSheet := func() {
+		if ownSheet {
+			d.sheet, ownSheet = outerSheet, false
+		}
+	}
 
-	// Helper to conditionally format expression (skip formatting for active line)
-	maybeFormat := func(lineIdx int, expr string) string {
-		// lineIdx is 0-based, activeLineNum is 1-based
-		if activeLineNum > 0 && lineIdx+1 == activeLineNum {
-			return expr // Skip formatting for active line
+	// Each line is timed from the start of its iteration to the start of the
+	// next one, so every early "continue" is covered
+	timed, started := -1, time.Time{}
+	stopClock := func() {
+		if timed >= 0 {
+			results[timed].Duration = time.Since(started)
+			timed = -1
 		}
-		return formatExpression(expr)
 	}
 
 	for i, line := range cleanedLines {
+		stopClock()
+		endLineSheet()
+		d.trace = nil
+		timed, started = i, time.Now()
 		results[i].Output = line
 		lineNum := i + 1 // 1-based line number
 
 		// Skip empty lines
-		if line == "" {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		// The rows of a data block are data, even where they look like a
+		// comment or an expression
+		if d.dataRows[i] {
+			results[i].Evaluator = "data"
+			continue
+		}
+		// Skip comment lines, but not hex color expressions like "#FF5733 to rgb"
+		// or comments with calc(...) spans, which are evaluated like prose
+		if IsCommentLine(line) && !isCommentCalcLine(line) {
+			continue
+		}
+
+		// Directive lines ("@precision 4") change how the lines below them are
+		// evaluated and shown. A bad directive marks its own line and changes
+		// nothing.
+		if isDirectiveLine(line) {
+			results[i].Evaluator = "directive"
+			directive := stripInlineComment(line)
+			if eq := findResultEquals(directive); eq >= 0 {
+				// Drop a stale " = ERR: ..." result
+				directive = strings.TrimRight(directive[:eq], " \t")
+				results[i].Output = directive
+			}
+			sheet, err := d.sheet.apply(directive)
+			if err != nil {
+				// Not while it is being typed: "@prec" is not an error yet
+				if !d.isActive(i) {
+					results[i].Output = strings.TrimRight(directive, " \t") + " = ERR: " + err.Error()
+				}
+				continue
+			}
+			d.sheet = sheet
 			continue
 		}
-		// Skip comment lines (starting with #, allowing leading whitespace)
-		// But don't skip hex color expressions like "#FF5733 to rgb"
-		trimmedLine := strings.TrimLeft(line, " \t")
-		if strings.HasPrefix(trimmedLine, "#") {
-			// Check if this looks like a hex color expression
-			hexColorPattern := regexp.MustCompile(`^#[0-9a-fA-F]{3,6}\s+(?:to|in)\s+`)
-			if !hexColorPattern.MatchString(trimmedLine) {
+		d.symbolByLine[i] = d.sheet.format.Symbol
+
+		// Prose lines: "The deposit is `$4500 * 0.1 =` due Friday" evaluates each
+		// backticked fragment in place, and "# budget: calc(3*450 + 120)" each
+		// calc(...) span of a comment. The line takes the value of its last
+		// fragment with a numeric result; a comment stays out of references and
+		// totals.
+		if isProseLine(line) {
+			if activeLineNum > 0 && !linesToEvaluate[lineNum] {
 				continue
 			}
+			comment := IsCommentLine(line)
+			results[i].Evaluator = "prose"
+			if comment {
+				results[i].Evaluator = "comment"
+			}
+			results[i].Output = replaceFragments(line, func(expr, _ string) string {
+				r := evalFragment(expr)
+				result := primaryResult(r)
+				// A failed calc(...) span doesn't spell out its error in the comment
+				if comment && strings.HasPrefix(result, "ERR") {
+					result = "ERR"
+				}
+				results[i].Pending = results[i].Pending || r.Pending
+				if r.hasValue && !comment {
+					values[i] = r.Value
+					haveRes[i] = true
+					currencyByLine[i] = r.IsCurrency
+					results[i].Value = r.Value
+					results[i].IsCurrency = r.IsCurrency
+				}
+				return expr + " = " + result
+			})
+			results[i].HasResult = true
+			continue
 		}
 
 		// Handle inline comments - strip everything after #
 		// But don't treat hex colors (#FF5733) as comments
-		workingLine := line
+		workingLine := stripInlineComment(line)
 		inlineComment := ""
-		if hashIdx := strings.Index(line, "#"); hashIdx >= 0 {
-			// Check if this looks like a hex color (# followed by hex digits)
-			isHexColor := false
-			if hashIdx < len(line)-1 {
-				rest := line[hashIdx+1:]
-				// Check if it starts with hex digits (color code)
-				hexPattern := regexp.MustCompile(`^[0-9a-fA-F]{3,6}(?:\s|$)`)
-				if hexPattern.MatchString(rest) {
-					isHexColor = true
-				}
-			}
-			if !isHexColor {
-				workingLine = line[:hashIdx]
-			}
-		}
 
 		eq := findResultEquals(workingLine)
 		if eq < 0 {
@@ -327,6 +754,44 @@ func EvalLines(lines []string, activeLineNum int) []LineResult {
 		if expr == "" {
 			continue
 		}
+		hint, hasHint := linePrecision(workingLine, eq)
+		expr, explain := stripExplain(expr)
+
+		// Amounts in a currency other than the sheet's, "£45 * 3" or "\2 * 2"
+		// of a line in euros, are shown in that currency. Amounts in two
+		// currencies are reported by the arithmetic that would add them up.
+		currencies := d.lineCurrencies(currencyOperands(expr))
+		d.currencyMix = mixedCurrencies(currencies)
+		if len(currencies) == 1 && currencies[0] != d.sheet.format.Symbol {
+			useLineSheet(d.sheet.withCurrency(currencies[0]))
+			d.symbolByLine[i] = currencies[0]
+		}
+
+		// A line in the pinned results panel ("pin") is evaluated without its
+		// keyword, so the keyword never becomes part of the result
+		if stripped, ok := linePanelPin(workingLine, eq); ok {
+			line = stripped + line[len(workingLine):]
+			workingLine = stripped
+			panelPins[i] = panelLabel(line)
+		}
+
+		// Pinned lines ("!pin" marker or "=*") are not recomputed. Without a
+		// stored result yet they are evaluated once and the marker is restored.
+		if stored, starForm, pinned := pinnedResult(workingLine, eq); pinned {
+			if stored != "" {
+				results[i].Evaluator = "pinned"
+				usePinned(i, line, stored)
+				if name, _, ok := eval.ParseAssignment(expr); ok && haveRes[i] {
+					vars[name] = values[i]
+					currencyByVar[name] = currencyByLine[i]
+					d.symbolByVar[name] = d.symbolByLine[i]
+				}
+				continue
+			}
+			pinMarks[i] = starForm
+			line = workingLine[:eq+1] + line[len(workingLine):]
+			workingLine = workingLine[:eq+1]
+		}
 
 		// Skip evaluation for lines that don't need it (not active line or dependent)
 		// Preserve existing results for these lines
@@ -339,360 +804,419 @@ func EvalLines(lines []string, activeLineNum int) []LineResult {
 			continue
 		}
 
-		// Extract inline comment from original line (after the = sign)
-		inlineComment = extractInlineComment(line, eq)
-
-		// Try base conversion first (24 in hex, 0xFF in dec, etc.)
-		if isBaseConversionExpr(expr) {
-			if baseResult, ok := tryBaseConversion(expr); ok {
-				results[i].Output = expr + " = " + baseResult + inlineComment
-				results[i].HasResult = true
-				continue
-			}
-		}
-
-		// Try physical constants
-		if constants.IsConstantExpression(expr) {
-			constResult, err := constants.EvalConstants(expr)
-			if err == nil {
-				results[i].Output = maybeFormat(i, expr) + " = " + constResult + inlineComment
-				results[i].HasResult = true
-				continue
+		// A tracked line ("!track") is evaluated like any other; its history
+		// lines, which only a save adds to, stay below the fresh result
+		if marker, stripped, ok := lineTrack(workingLine, eq); ok {
+			t := trackedLine{marker: marker}
+			if outputLines, ok := hasMultiLineOutput[i]; ok {
+				var rest []string
+				t.history, rest = splitHistory(outputLines)
+				if len(rest) > 0 {
+					hasMultiLineOutput[i] = rest
+				} else {
+					delete(hasMultiLineOutput, i)
+				}
 			}
+			tracks[i] = t
+			line = stripped + line[len(workingLine):]
+			workingLine = stripped
 		}
 
-		// Try unit conversions
-		if units.IsUnitExpression(expr) {
-			unitResult, err := units.EvalUnits(expr)
-			if err == nil {
-				results[i].Output = maybeFormat(i, expr) + " = " + unitResult + inlineComment
-				results[i].HasResult = true
-				continue
-			}
-		}
+		// Extract inline comment from original line (after the = sign)
+		inlineComment = extractInlineComment(line, eq)
 
-		// Try radio/electrical calculations
-		if radio.IsRadioExpression(expr) {
-			radioResult, err := radio.EvalRadio(expr)
-			if err == nil {
-				// Multi-line results start with \n>, single-line results don't
-				if strings.HasPrefix(radioResult, "\n>") {
-					results[i].Output = maybeFormat(i, expr) + " =" + radioResult + inlineComment
-				} else {
-					results[i].Output = maybeFormat(i, expr) + " = " + radioResult + inlineComment
+		// An explained line goes through the evaluators again, with its
+		// previous trace left out of the output it may keep
+		if explain {
+			d.trace = &explainTrace{}
+			explains[i] = d.trace
+			if outputLines, ok := hasMultiLineOutput[i]; ok {
+				hasMultiLineOutput[i] = withoutTrace(outputLines)
+				if len(hasMultiLineOutput[i]) == 0 {
+					delete(hasMultiLineOutput, i)
 				}
-				results[i].HasResult = true
-				continue
 			}
 		}
 
-		// Try percentage calculations
-		if percentage.IsPercentageExpression(expr) {
-			pctResult, err := percentage.EvalPercentage(expr)
-			if err == nil {
-				results[i].Output = maybeFormat(i, expr) + " = " + pctResult + inlineComment
-				results[i].HasResult = true
+		// A precision hint ("1/3 = :6", "1/3 to 6 dp =") is taken off while the
+		// line evaluates with its own number of decimal places
+		if hasHint {
+			hints[i] = hint
+			var afterEq string
+			expr, afterEq = hint.strip(expr, workingLine[eq+1:])
+			workingLine = workingLine[:eq+1] + afterEq
+			if hint.decimals > utils.MaxDecimals {
+				results[i].Output = maybeFormat(i, expr) + " = ERR: " + errPrecisionRange.Error() + inlineComment
 				continue
 			}
+			useLineSheet(d.sheet.withPrecision(hint.decimals))
 		}
 
-		// Try financial calculations
-		if finance.IsFinanceExpression(expr) {
-			finResult, err := finance.EvalFinance(expr)
-			if err == nil {
-				results[i].Output = maybeFormat(i, expr) + " = " + finResult + inlineComment
-				results[i].HasResult = true
+		// Assertion: "assert \5 <= 10000 =" shows ✓ or a failure with resolved values
+		if m := assertPattern.FindStringSubmatch(expr); m != nil {
+			cond := d.sheet.currencyInput(strings.TrimSpace(m[1]))
+			val, err := eval.EvalExprWithOptions(cond, refResolver, varResolver, d.sheet.evalOptions())
+			results[i].IsAssertion = true
+			results[i].Evaluator = "assert"
+			if d.currencyMix != nil {
+				results[i].Output = maybeFormat(i, expr) + " = ERR: " + d.currencyMix.Error() + inlineComment
 				continue
 			}
-		}
-
-		// Try statistics functions
-		if stats.IsStatsExpression(expr) {
-			statsResult, err := stats.EvalStats(expr)
-			if err == nil {
-				results[i].Output = maybeFormat(i, expr) + " = " + statsResult + inlineComment
-				results[i].HasResult = true
+			if err != nil {
+				results[i].Output = maybeFormat(i, expr) + " = ERR" + inlineComment
 				continue
 			}
-		}
-
-		// Try programmer utilities
-		if programmer.IsProgrammerExpression(expr) {
-			progResult, err := programmer.EvalProgrammer(expr)
-			if err == nil {
-				results[i].Output = maybeFormat(i, expr) + " = " + progResult + inlineComment
-				results[i].HasResult = true
-				continue
+			values[i] = val
+			haveRes[i] = true
+			resultStr := "✓"
+			if val != 1 {
+				isCurrency := strings.Contains(cond, "$") ||
+					eval.ExprReferencesCurrency(cond, currencyByLine) ||
+					eval.ExprReferencesCurrencyVar(cond, currencyByVar)
+				resultStr = "✗ FAILED: " + describeAssertion(cond, isCurrency, refResolver, varResolver)
+				results[i].AssertFailed = true
 			}
+			results[i].Output = maybeFormat(i, expr) + " = " + resultStr + inlineComment
+			results[i].Value = val
+			results[i].HasResult = true
+			continue
 		}
 
-		// Try regex testing
-		if regex.IsRegexExpression(expr) {
-			regexResult, err := regex.EvalRegex(expr)
-			if err == nil {
-				results[i].Output = maybeFormat(i, expr) + " =" + regexResult + inlineComment
+		// Aggregates: "total =", "sum above =" and "avg above =" combine the values
+		// of the block above, back to the previous blank line. A variable that is
+		// actually named "total" takes precedence.
+		if m := aggregatePattern.FindStringSubmatch(expr); m != nil {
+			if _, isVar := vars[expr]; !isVar {
+				results[i].Evaluator = "aggregate"
+				sum, count, isCurrency := 0.0, 0, false
+				var symbols []string
+				for j := aggregateBlockStart(cleanedLines, i); j < i; j++ {
+					if !haveRes[j] || results[j].IsAssertion || results[j].Evaluator == "aggregate" {
+						continue // comments, text, checks and other aggregates don't contribute
+					}
+					sum += values[j]
+					count++
+					isCurrency = isCurrency || currencyByLine[j]
+					if s := d.lineSymbol(j); s != "" && !slices.Contains(symbols, s) {
+						symbols = append(symbols, s)
+					}
+				}
+				if err := mixedCurrencies(symbols); err != nil {
+					results[i].Output = maybeFormat(i, expr) + " = ERR: " + err.Error() + inlineComment
+					continue
+				}
+				if len(symbols) == 1 && symbols[0] != d.sheet.format.Symbol {
+					useLineSheet(d.sheet.withCurrency(symbols[0]))
+					d.symbolByLine[i] = symbols[0]
+				}
+				val := sum
+				if strings.HasPrefix(strings.ToLower(m[1]), "av") {
+					if count == 0 {
+						results[i].Output = maybeFormat(i, expr) + " = ERR: no values above to average" + inlineComment
+						continue
+					}
+					val = sum / float64(count)
+				}
+				values[i] = val
+				haveRes[i] = true
+				currencyByLine[i] = isCurrency
+				results[i].Output = maybeFormat(i, expr) + " = " + d.sheet.format.Result(isCurrency, val) + inlineComment
+				results[i].Value = val
 				results[i].HasResult = true
+				results[i].IsCurrency = isCurrency
 				continue
 			}
 		}
 
-		// Try Unix permissions evaluation
-		if permissions.IsPermissionsExpression(expr) {
-			permResult, err := permissions.EvalPermissions(expr)
-			if err == nil {
-				results[i].Output = maybeFormat(i, expr) + " = " + permResult + inlineComment
-				results[i].HasResult = true
+		// What-if tables: "table rate from 5% to 8% step 0.5%: loan $300000 at rate
+		// for 30 years =" evaluates the expression once per value of rate
+		if sw, ok, err := parseSweep(expr); ok {
+			results[i].Evaluator = "what-if table"
+			if err != nil {
+				results[i].Output = expr + " = ERR: " + err.Error() + inlineComment
 				continue
 			}
+			results[i].Output = expr + " =" + evalSweep(sw, func(rowExpr string) string {
+				return primaryResult(evalFragment(rowExpr))
+			}) + inlineComment
+			results[i].HasResult = true
+			continue
 		}
 
-		// Try cooking conversions
-		if cooking.IsCookingExpression(expr) {
-			cookResult, err := cooking.EvalCooking(expr)
-			if err == nil {
-				results[i].Output = maybeFormat(i, expr) + " = " + cookResult + inlineComment
-				results[i].HasResult = true
+		// Pasted tables: "table sum col 3 =", "table avg price =" and "table count ="
+		// query the aligned text rows right above them
+		if val, isCurrency, ok, err := evalTableQuery(cleanedLines, i, expr); ok {
+			results[i].Evaluator = "table"
+			if err != nil {
+				results[i].Output = maybeFormat(i, expr) + " = ERR: " + err.Error() + inlineComment
 				continue
 			}
+			values[i] = val
+			haveRes[i] = true
+			currencyByLine[i] = isCurrency
+			results[i].Output = maybeFormat(i, expr) + " = " + d.sheet.format.Result(isCurrency, val) + inlineComment
+			results[i].Value = val
+			results[i].HasResult = true
+			results[i].IsCurrency = isCurrency
+			continue
 		}
 
-		// Try man-hour calculations
-		if manhour.IsManHourExpression(expr) {
-			mhResult, err := manhour.EvalManHour(expr)
-			if err == nil {
-				results[i].Output = maybeFormat(i, expr) + " = " + mhResult + inlineComment
-				results[i].HasResult = true
+		// Variable assignment: "rent = $1800 =" defines rent for later lines
+		if name, rhs, ok := eval.ParseAssignment(expr); ok {
+			results[i].Evaluator = "variable"
+			if d.currencyMix != nil {
+				results[i].Output = maybeFormat(i, expr) + " = ERR: " + d.currencyMix.Error() + inlineComment
 				continue
 			}
-		}
-
-		// Try hourly cost calculations
-		if hourlycost.IsHourlyCostExpression(expr) {
-			hcResult, err := hourlycost.EvalHourlyCost(expr)
-			if err == nil {
-				results[i].Output = maybeFormat(i, expr) + " = " + hcResult + inlineComment
-				results[i].HasResult = true
+			rhs = constants.ReplaceNames(d.sheet.currencyInput(rhs))
+			isCurrency := strings.Contains(rhs, "$") ||
+				eval.ExprReferencesCurrency(rhs, currencyByLine) ||
+				eval.ExprReferencesCurrencyVar(rhs, currencyByVar)
+			val, err := eval.EvalExprWithOptions(rhs, refResolver, varResolver, d.sheet.evalOptions())
+			if err != nil {
+				results[i].Output = maybeFormat(i, expr) + " = ERR" + inlineComment
 				continue
 			}
+			vars[name] = val
+			currencyByVar[name] = isCurrency
+			d.symbolByVar[name] = d.sheet.format.Symbol
+			values[i] = val
+			haveRes[i] = true
+			currencyByLine[i] = isCurrency
+			results[i].Output = maybeFormat(i, expr) + " = " + d.sheet.format.Result(isCurrency, val) + inlineComment
+			results[i].Value = val
+			results[i].HasResult = true
+			results[i].IsCurrency = isCurrency
+			continue
 		}
 
-		// Try JWT decoding
-		// Note: Don't use maybeFormat for JWT expressions as it corrupts base64url tokens
-		if jwt.IsJWTExpression(expr) {
-			jwtResult, err := jwt.EvalJWT(expr)
-			if err == nil {
-				results[i].Output = expr + " =\n> " + jwtResult + inlineComment
-				results[i].HasResult = true
+		// Unchanged lines reuse their memoized result and skip handler detection
+		if !results[i].Volatile && !explain && isCacheable(expr) {
+			formatted := activeLineNum <= 0 || lineNum != activeLineNum
+			sheetKey := strings.Join(d.sheet.directives(), "\n")
+			if hasHint {
+				sheetKey += "\n:" + strconv.Itoa(hint.decimals)
+			}
+			key := resultCacheKey(expr, formatted, directivesKey+"\n"+sheetKey, results, values, haveRes, currencyByLine, vars, currencyByVar)
+			if c, ok := lookupResult(key); ok {
+				results[i].Output = c.output + inlineComment
+				results[i].HasResult = c.hasResult
+				results[i].IsDateTime = c.isDateTime
+				results[i].DateTimeStr = c.dateTimeStr
+				if c.hasValue {
+					recordValue(i, utils.ValueResult("", c.value, c.isCurrency))
+				}
+				// Report what the line costs to evaluate, not the lookup
+				results[i].Evaluator = c.evaluator
+				results[i].Duration = c.duration
+				timed = -1
 				continue
 			}
+			missed[i] = cacheMiss{key: key, expr: expr, comment: inlineComment}
 		}
 
-		// Try SSL certificate decoding
-		// Note: Don't use maybeFormat for cert expressions as URLs should not be modified
-		// Skip re-evaluation if line already has a result and is not the active line (expensive network operation)
-		if cert.IsCertExpression(expr) {
-			isActiveLine := activeLineNum > 0 && i+1 == activeLineNum
-
-			// Check if line already has an inline result (like "ERR: ..." after =)
-			existingResult := strings.TrimSpace(workingLine[eq+1:])
-			if existingResult != "" && !isActiveLine {
-				// Line already has an inline result, keep it as-is
-				results[i].Output = line
-				results[i].HasResult = true
-				continue
-			}
+		results[i].Evaluator = d.dispatch(lineInput{
+			idx:           i,
+			line:          line,
+			workingLine:   workingLine,
+			eq:            eq,
+			expr:          expr,
+			inlineComment: inlineComment,
+		})
+	}
+	stopClock()
+	endLineSheet()
+
+	// Memoize the new results. Network lookups keep their previous output
+	// instead, and clock-dependent dates are always recomputed.
+	for i, m := range missed {
+		r := results[i]
+		if r.Pending || r.IsDateTime || isNetworkEvaluator(r.Evaluator) || datetime.IsDateTimeExpression(m.expr) {
+			continue
+		}
+		storeResult(m.key, cachedResult{
+			output:      strings.TrimSuffix(r.Output, m.comment),
+			hasResult:   r.HasResult,
+			hasValue:    haveRes[i],
+			value:       values[i],
+			isCurrency:  currencyByLine[i],
+			isDateTime:  r.IsDateTime,
+			dateTimeStr: r.DateTimeStr,
+			evaluator:   r.Evaluator,
+			duration:    r.Duration,
+		})
+	}
 
-			// Check if line had multi-line output (successful cert decode)
-			if outputLines, ok := hasMultiLineOutput[i]; ok && !isActiveLine {
-				// Reconstruct the output with the existing multi-line result
-				results[i].Output = line + "\n" + strings.Join(outputLines, "\n")
-				results[i].HasResult = true
-				continue
-			}
+	for i, hint := range hints {
+		results[i].Output = hint.restore(results[i].Output)
+	}
+	for i, trace := range explains {
+		results[i].Output = trace.restore(results[i].Output, results[i].Evaluator)
+	}
 
-			certResult, err := cert.EvalCert(expr)
-			if err == nil {
-				results[i].Output = expr + " =\n> " + certResult + inlineComment
-				results[i].HasResult = true
-				continue
-			} else {
-				// Show the error message for cert decode failures
-				results[i].Output = expr + " = ERR: " + err.Error() + inlineComment
-				results[i].HasResult = true
-				continue
-			}
+	// Expected-value annotations ("2 + 2 = # expect 4") flag lines whose result
+	// differs. Pinned lines are left alone so the flag never becomes part of
+	// their stored text, and pending lines are checked by the deferred pass.
+	for i, line := range cleanedLines {
+		if activeLineNum > 0 && !linesToEvaluate[i+1] {
+			continue
+		}
+		expected, tolerance, ok := lineExpectation(line)
+		if _, isPinMark := pinMarks[i]; !ok || isPinMark || results[i].Pinned || results[i].Pending {
+			continue
 		}
+		results[i].Expectation = true
+		if !expectationMet(results[i], haveRes[i], expected, tolerance) {
+			results[i].ExpectFailed = true
+			results[i].Output = flagExpectation(results[i].Output, expected)
+		}
+	}
 
-		// Try DNS lookup
-		// Note: Don't use maybeFormat for DNS expressions as domain names should not be modified
-		// Skip re-evaluation if line already has a result and is not the active line (expensive network operation)
-		if network.IsDNSExpression(expr) {
-			isActiveLine := activeLineNum > 0 && i+1 == activeLineNum
+	// "#profile" notes the evaluation time of slow lines
+	annotateProfile(results, func(i int) bool {
+		_, isPinMark := pinMarks[i]
+		return !isPinMark && (activeLineNum <= 0 || linesToEvaluate[i+1])
+	})
 
-			// Check if line already has an inline result
-			existingResult := strings.TrimSpace(workingLine[eq+1:])
-			if existingResult != "" && !isActiveLine {
-				results[i].Output = line
-				results[i].HasResult = true
-				continue
-			}
+	for i, starForm := range pinMarks {
+		if results[i].HasResult {
+			results[i].Output = restorePinMarker(results[i].Output, starForm)
+			results[i].Pinned = true
+		}
+	}
 
-			// Check if line had multi-line output
-			if outputLines, ok := hasMultiLineOutput[i]; ok && !isActiveLine {
-				results[i].Output = line + "\n" + strings.Join(outputLines, "\n")
-				results[i].HasResult = true
-				continue
-			}
+	for i, t := range tracks {
+		results[i].Output = t.restore(results[i].Output)
+	}
 
-			dnsResult, err := network.EvalDNS(expr)
-			if err == nil {
-				results[i].Output = expr + " =\n" + dnsResult + inlineComment
-				results[i].HasResult = true
-				continue
-			} else {
-				results[i].Output = expr + " = ERR: " + err.Error() + inlineComment
-				results[i].HasResult = true
-				continue
-			}
+	for i, label := range panelPins {
+		first, _, _ := strings.Cut(results[i].Output, "\n")
+		if _, ok := withoutPanelPin(first); !ok {
+			results[i].Output = insertResultMarker(results[i].Output, panelMarker)
 		}
+		results[i].PanelPin = true
+		results[i].Label = label
+	}
 
-		// Try WHOIS lookup
-		// Note: Don't use maybeFormat for WHOIS expressions as domain names should not be modified
-		// Skip re-evaluation if line already has a result and is not the active line (expensive network operation)
-		if network.IsWhoisExpression(expr) {
-			isActiveLine := activeLineNum > 0 && i+1 == activeLineNum
+	for i := range results {
+		results[i].hasValue = haveRes[i]
+	}
+	return results
+}
 
-			// Check if line already has an inline result
-			existingResult := strings.TrimSpace(workingLine[eq+1:])
-			if existingResult != "" && !isActiveLine {
-				results[i].Output = line
-				results[i].HasResult = true
-				continue
-			}
+// restorePinMarker puts the pin marker back into a freshly evaluated line:
+// "now = 2025-07-15 09:30 UTC" becomes "now =* 2025-07-15 09:30 UTC" or
+// "now = 2025-07-15 09:30 UTC !pin" (ahead of any inline comment).
+func restorePinMarker(output string, starForm bool) string {
+	if !starForm {
+		return insertResultMarker(output, "!pin")
+	}
+	first, rest, multiLine := strings.Cut(output, "\n")
+	eq := findResultEquals(first)
+	if eq < 0 {
+		return output
+	}
+	first = first[:eq+1] + "*" + first[eq+1:]
+	if multiLine {
+		return first + "\n" + rest
+	}
+	return first
+}
 
-			// Check if line had multi-line output
-			if outputLines, ok := hasMultiLineOutput[i]; ok && !isActiveLine {
-				results[i].Output = line + "\n" + strings.Join(outputLines, "\n")
-				results[i].HasResult = true
-				continue
-			}
+// insertResultMarker puts a marker ("!pin", "!track", "pin") after the result of
+// the first line of an output, ahead of any inline comment
+func insertResultMarker(output, marker string) string {
+	first, rest, multiLine := strings.Cut(output, "\n")
+	eq := findResultEquals(first)
+	if eq < 0 {
+		return output
+	}
+	if hashIdx := strings.Index(first[eq:], " #"); hashIdx >= 0 {
+		first = first[:eq+hashIdx] + " " + marker + first[eq+hashIdx:]
+	} else {
+		first = strings.TrimRight(first, " ") + " " + marker
+	}
+	if multiLine {
+		return first + "\n" + rest
+	}
+	return first
+}
 
-			whoisResult, err := network.EvalWhois(expr)
-			if err == nil {
-				results[i].Output = expr + " =\n" + whoisResult + inlineComment
-				results[i].HasResult = true
-				continue
-			} else {
-				results[i].Output = expr + " = ERR: " + err.Error() + inlineComment
-				results[i].HasResult = true
-				continue
+// describeAssertion renders a failed assertion condition with both sides
+// resolved to values, e.g. "12500 <= 10000" for "\5 <= 10000".
+func describeAssertion(cond string, isCurrency bool, refs func(int) (float64, error), vars func(string) (float64, error)) string {
+	m := comparisonSplitPattern.FindStringSubmatch(cond)
+	if m == nil {
+		// No simple comparison to split; just resolve line references in place
+		refPattern := regexp.MustCompile(`\\(\d+)`)
+		return refPattern.ReplaceAllStringFunc(cond, func(match string) string {
+			n, _ := strconv.Atoi(match[1:])
+			if v, err := refs(n); err == nil {
+				return utils.FormatResult(isCurrency, v)
 			}
-		}
+			return match
+		})
+	}
+	opts := eval.Options{DecimalComma: utils.DecimalComma()}
+	left, errL := eval.EvalExprWithOptions(m[1], refs, vars, opts)
+	right, errR := eval.EvalExprWithOptions(m[3], refs, vars, opts)
+	if errL != nil || errR != nil {
+		return cond
+	}
+	return utils.FormatResult(isCurrency, left) + " " + m[2] + " " + utils.FormatResult(isCurrency, right)
+}
 
-		// Try network/IP evaluation
-		if network.IsNetworkExpression(expr) {
-			netResult, err := network.EvalNetwork(expr)
-			if err == nil {
-				results[i].Output = maybeFormat(i, expr) + " = " + netResult + inlineComment
-				results[i].HasResult = true
-				continue
-			}
-			// Fall through if network eval fails
+// substituteRefs replaces resolvable \N references with the plain numeric
+// value of the referenced line, leaving unresolved references untouched.
+func substituteRefs(expr string, refs func(int) (float64, error)) string {
+	return lineRefPattern.ReplaceAllStringFunc(expr, func(match string) string {
+		n, _ := strconv.Atoi(match[1:])
+		if v, err := refs(n); err == nil {
+			return strconv.FormatFloat(v, 'f', -1, 64)
 		}
+		return match
+	})
+}
 
-		// Try GeoIP lookup
-		if network.IsGeoIPExpression(expr) {
-			geoResult, err := network.EvalGeoIP(expr)
-			if err == nil {
-				results[i].Output = maybeFormat(i, expr) + " = " + geoResult + inlineComment
-				results[i].HasResult = true
-				continue
-			}
-			// Fall through if geoip eval fails
-		}
+// DocumentStats summarizes evaluation results for a whole document.
+type DocumentStats struct {
+	Lines              int `json:"lines"`
+	Results            int `json:"results"`
+	Errors             int `json:"errors"`
+	Assertions         int `json:"assertions"`
+	FailedAssertions   int `json:"failedAssertions"`
+	Expectations       int `json:"expectations"`
+	FailedExpectations int `json:"failedExpectations"`
+
+	SlowLines []SlowLine `json:"slowLines"` // slowest lines, slowest first
+}
 
-		// Try "what is my ip" lookup
-		if network.IsMyIPExpression(expr) {
-			myIPResult, err := network.EvalMyIP()
-			if err == nil {
-				results[i].Output = maybeFormat(i, expr) + " =" + myIPResult + inlineComment
-				results[i].HasResult = true
-				continue
-			}
-			// Fall through if my ip eval fails
+// GetDocumentStats evaluates all lines and counts results, errors, assertions
+// and expected-value annotations, and reports the slowest lines.
+func GetDocumentStats(lines []string) DocumentStats {
+	results := EvalLines(lines, 0)
+	stats := DocumentStats{Lines: len(results), SlowLines: slowestLines(results, maxSlowLines)}
+	for _, r := range results {
+		if r.HasResult {
+			stats.Results++
 		}
-
-		// Try color conversion
-		if color.IsColorExpression(expr) {
-			colorResult, err := color.EvalColor(expr)
-			if err == nil {
-				results[i].Output = maybeFormat(i, expr) + " = " + colorResult + inlineComment
-				results[i].HasResult = true
-				continue
-			}
-			// Fall through if color eval fails
+		if strings.Contains(r.Output, "= ERR") {
+			stats.Errors++
 		}
-
-		// Try date/time evaluation (with reference support)
-		if datetime.IsDateTimeExpression(expr) || strings.Contains(expr, "\\") {
-			// Create resolver for line references
-			resolver := func(n int) (string, bool) {
-				idx := n - 1
-				if idx < 0 || idx >= len(results) {
-					return "", false
-				}
-				if results[idx].IsDateTime && results[idx].DateTimeStr != "" {
-					return results[idx].DateTimeStr, true
-				}
-				return "", false
-			}
-
-			dtResult, err := datetime.EvalDateTimeWithRefs(expr, resolver)
-			if err == nil {
-				results[i].Output = maybeFormat(i, expr) + " = " + dtResult + inlineComment
-				results[i].HasResult = true
-				results[i].IsDateTime = true
-				results[i].DateTimeStr = dtResult
-				continue
-			}
-			// Fall through to numeric evaluation if datetime fails
+		if r.IsAssertion {
+			stats.Assertions++
 		}
-
-		isCurrency := strings.Contains(expr, "$") || eval.ExprReferencesCurrency(expr, currencyByLine)
-		isComparison := isComparisonExpr(expr)
-
-		val, err := eval.EvalExpr(expr, func(n int) (float64, error) {
-			idx := n - 1
-			if idx < 0 || idx >= len(values) {
-				return 0, fmt.Errorf("bad reference \\\\%d", n)
-			}
-			if !haveRes[idx] {
-				return 0, fmt.Errorf("unresolved reference \\\\%d", n)
-			}
-			return values[idx], nil
-		})
-		if err != nil {
-			results[i].Output = maybeFormat(i, expr) + " = ERR" + inlineComment
-			continue
+		if r.AssertFailed {
+			stats.FailedAssertions++
 		}
-
-		values[i] = val
-		haveRes[i] = true
-		currencyByLine[i] = isCurrency
-
-		var resultStr string
-		if isComparison {
-			resultStr = utils.FormatBoolResult(val)
-		} else {
-			resultStr = utils.FormatResult(isCurrency, val)
+		if r.Expectation {
+			stats.Expectations++
+		}
+		if r.ExpectFailed {
+			stats.FailedExpectations++
 		}
-		results[i].Output = maybeFormat(i, expr) + " = " + resultStr + inlineComment
-		results[i].Value = val
-		results[i].HasResult = true
-		results[i].IsCurrency = isCurrency
 	}
-
-	return results
+	return stats
 }
 
 // BuildLineNumbers generates line number text for n lines.
@@ -711,7 +1235,7 @@ func GetLineValues(lines []string) map[int]string {
 	values := make(map[int]string)
 	for i, r := range results {
 		if r.HasResult {
-			values[i+1] = utils.FormatResult(r.IsCurrency, r.Value)
+			values[i+1] = utils.FormatExact(r.IsCurrency, r.Value)
 		}
 	}
 	return values
@@ -727,64 +1251,100 @@ func ReplaceRefsWithValues(text string) string {
 // FindDependentLines returns a list of line numbers (1-based) that reference the given line.
 // It recursively finds all lines that depend on the changed line.
 func FindDependentLines(lines []string, changedLine int) []int {
+	usedBy := ReverseDependencies(DependencyGraph(lines))
+
 	dependents := make(map[int]bool)
-	findDependentsRecursive(lines, changedLine, dependents)
+	queue := []int{changedLine}
+	for len(queue) > 0 {
+		lineNum := queue[0]
+		queue = queue[1:]
+		for _, dep := range usedBy[lineNum] {
+			if !dependents[dep] {
+				dependents[dep] = true
+				queue = append(queue, dep)
+			}
+		}
+	}
 
-	// Convert map to sorted slice
 	result := make([]int, 0, len(dependents))
 	for lineNum := range dependents {
 		result = append(result, lineNum)
 	}
-	// Sort to ensure consistent order
-	for i := 0; i < len(result)-1; i++ {
-		for j := i + 1; j < len(result); j++ {
-			if result[i] > result[j] {
-				result[i], result[j] = result[j], result[i]
-			}
-		}
-	}
+	sort.Ints(result)
 	return result
 }
 
-// findDependentsRecursive finds all lines that reference targetLine and adds them to dependents map
-func findDependentsRecursive(lines []string, targetLine int, dependents map[int]bool) {
-	refPattern := regexp.MustCompile(`\\(\d+)`)
+// lineExpression returns the expression part of a line (before the result '='),
+// with any inline comment removed.
+func lineExpression(line string) string {
+	if isProseLine(line) {
+		return ""
+	}
+	line = stripInlineComment(line)
+	eq := findResultEquals(line)
+	if eq < 0 {
+		return ""
+	}
+	expr, _ := stripExplain(strings.TrimSpace(line[:eq]))
+	return expr
+}
 
-	for i, line := range lines {
-		lineNum := i + 1 // 1-based
-		if dependents[lineNum] {
-			continue // Already processed
-		}
-
-		// Find all references in this line
-		matches := refPattern.FindAllStringSubmatch(line, -1)
-		for _, match := range matches {
-			refNum, _ := strconv.Atoi(match[1])
-			if refNum == targetLine {
-				// This line references the target line
-				dependents[lineNum] = true
-				// Recursively find lines that depend on this line
-				findDependentsRecursive(lines, lineNum, dependents)
-				break
-			}
+// lineExpressions returns the expressions a line evaluates: the fragments of
+// a prose line, or the line's own expression
+func lineExpressions(line string) []string {
+	if isProseLine(line) {
+		return proseExpressions(line)
+	}
+	return []string{lineExpression(line)}
+}
+
+// lineAssignedVariable returns the variable name defined by a line like
+// "rent = $1800 =", or empty string if the line is not an assignment.
+func lineAssignedVariable(line string) string {
+	name, _, ok := eval.ParseAssignment(lineExpression(line))
+	if !ok {
+		return ""
+	}
+	return name
+}
+
+// lineVariables returns the variables used by the expressions of a line.
+// For an assignment only the right-hand side counts.
+func lineVariables(line string) []string {
+	var names []string
+	for _, expr := range lineExpressions(line) {
+		if assigned, rhs, ok := eval.ParseAssignment(expr); ok && assigned != "" {
+			expr = rhs
 		}
+		names = append(names, eval.ExprVariables(expr)...)
 	}
+	return names
 }
 
 // StripResult removes the result from a line, keeping the expression, '=' sign, and any inline comment.
 // Example: "2 + 3 = 5 # my note" -> "2 + 3 = # my note"
 // Example: "2 + 3 = 5" -> "2 + 3 ="
+// Example: "1/3 = :4 0.3333" -> "1/3 = :4"
 func StripResult(line string) string {
+	if stripped, ok := withoutPanelPin(line); ok {
+		return insertResultMarker(StripResult(stripped), panelMarker) // "pin" stays on the line
+	}
+	if _, pinned := linePin(line); pinned {
+		return line // Pinned results and markers are part of the document
+	}
+	if isProseLine(line) {
+		return replaceFragments(line, func(expr, _ string) string { return expr + " =" })
+	}
 	eq := findResultEquals(line)
 	if eq < 0 {
 		return line // No '=' found, return as-is
 	}
 
-	// Get the part before and including '='
-	beforeEq := line[:eq+1]
+	// Get the part before and including '=', and a precision hint after it
+	beforeEq := line[:eq+1+precisionMarkerEnd(line[eq+1:])]
 
 	// Check for inline comment after '='
-	afterEq := line[eq+1:]
+	afterEq := line[len(beforeEq):]
 	hashIdx := strings.Index(afterEq, "#")
 	if hashIdx >= 0 {
 		// Has inline comment - keep it
@@ -797,12 +1357,26 @@ func StripResult(line string) string {
 
 // HasResult checks if a line has a result (something after '=' that's not just whitespace or comment)
 func HasResult(line string) bool {
+	if stripped, ok := withoutPanelPin(line); ok {
+		return HasResult(stripped)
+	}
+	if stored, pinned := linePin(line); pinned {
+		return stored != ""
+	}
+	if isProseLine(line) {
+		for _, span := range proseFragments(line) {
+			if _, result := fragmentExpression(line[span[0]:span[1]]); result != "" {
+				return true
+			}
+		}
+		return false
+	}
 	eq := findResultEquals(line)
 	if eq < 0 {
 		return false
 	}
 
-	afterEq := strings.TrimSpace(line[eq+1:])
+	afterEq := strings.TrimSpace(line[eq+1+precisionMarkerEnd(line[eq+1:]):])
 	// If it starts with # or is empty, no result
 	if afterEq == "" || strings.HasPrefix(afterEq, "#") {
 		return false
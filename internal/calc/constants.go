@@ -0,0 +1,83 @@
+package calc
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+
+	"smartcalc/internal/constants"
+	"smartcalc/internal/eval"
+	"smartcalc/internal/units"
+)
+
+// trailingUnitPattern splits a unit off the end of arithmetic: "2 * pi *
+// 6371 km"
+var trailingUnitPattern = regexp.MustCompile(`^(.*[\d)])\s*([a-zA-Z][a-zA-Z/²]*)$`)
+
+// evalWithConstants evaluates arithmetic that may use named constants,
+// "planck * 5e14" or "speed of light * 2", and returns the unit of the
+// result. A result proportional to a single constant with a unit has that
+// unit: "boltzmann * 300 = 4.141947e-21 J/K", but "1 / c" and "sqrt(g)" have
+// none. Arithmetic on dimensionless constants may end in a unit, which the
+// result keeps: "2 * pi * 6371 km = 40,030.17 km".
+func (d *document) evalWithConstants(expr string) (float64, string, error) {
+	expr = constants.ReplaceNames(expr)
+
+	// The constants the expression uses; scale multiplies the value of one
+	// of them, to tell whether the result is proportional to it
+	var used []string
+	scaled, scale := "", 1.0
+	resolver := func(name string) (float64, error) {
+		if _, ok := d.vars[name]; !ok {
+			if v, _, ok := constants.Lookup(name); ok {
+				used = append(used, name)
+				if name == scaled {
+					return v * scale, nil
+				}
+			}
+		}
+		return d.varResolver(name)
+	}
+	evalExpr := func(expr string) (float64, error) {
+		used = nil
+		return eval.EvalExprWithOptions(expr, d.refResolver, resolver, d.sheet.evalOptions())
+	}
+
+	val, err := evalExpr(expr)
+	if err == nil {
+		var withUnits []string
+		for _, name := range used {
+			if _, unit, _ := constants.Lookup(name); unit != "" {
+				withUnits = append(withUnits, name)
+			}
+		}
+		if len(withUnits) != 1 || val == 0 {
+			return val, "", nil
+		}
+		scaled, scale = withUnits[0], 2
+		doubled, err := evalExpr(expr)
+		if err != nil || math.Abs(doubled-2*val) > 1e-9*math.Abs(val) {
+			return val, "", nil
+		}
+		_, unit, _ := constants.Lookup(scaled)
+		return val, unit, nil
+	}
+
+	m := trailingUnitPattern.FindStringSubmatch(expr)
+	if m == nil || !units.IsUnit(m[2]) {
+		return 0, "", err
+	}
+	val, err = evalExpr(m[1])
+	if err != nil {
+		return 0, "", err
+	}
+	if len(used) == 0 {
+		return 0, "", fmt.Errorf("unexpected unit %s", m[2])
+	}
+	for _, name := range used {
+		if _, unit, _ := constants.Lookup(name); unit != "" {
+			return 0, "", fmt.Errorf("cannot combine %s with %s", unit, m[2])
+		}
+	}
+	return val, m[2], nil
+}
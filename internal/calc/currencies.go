@@ -0,0 +1,70 @@
+package calc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"smartcalc/internal/currency"
+	"smartcalc/internal/eval"
+)
+
+// lineCurrencies returns the currency symbols of the amounts in an
+// expression: those it writes, "£45 * 3", and those of the currency lines and
+// variables it refers to, each once
+func (d *document) lineCurrencies(expr string) []string {
+	symbols := currency.SymbolsIn(expr)
+	add := func(symbol string) {
+		if symbol != "" && !slices.Contains(symbols, symbol) {
+			symbols = append(symbols, symbol)
+		}
+	}
+	// A sheet currency without a symbol of its own is written "CHF 250"
+	if strings.Contains(expr, d.sheet.format.Symbol) {
+		add(d.sheet.format.Symbol)
+	}
+	for _, n := range eval.ReferencedLines(expr) {
+		add(d.lineSymbol(n - 1))
+	}
+	for _, name := range eval.ExprVariables(expr) {
+		add(d.varSymbol(name))
+	}
+	return symbols
+}
+
+// currencyOperands returns the part of an expression whose amounts are
+// added up: the right side of an assignment, whose name may be redefined in
+// another currency, or else the whole expression
+func currencyOperands(expr string) string {
+	if _, rhs, ok := eval.ParseAssignment(expr); ok {
+		return rhs
+	}
+	return expr
+}
+
+// lineSymbol returns the currency symbol of a line (0-based) with a currency
+// value, or "" for other lines
+func (d *document) lineSymbol(lineIdx int) string {
+	if lineIdx < 0 || lineIdx >= len(d.currencyByLine) || !d.currencyByLine[lineIdx] {
+		return ""
+	}
+	return d.symbolByLine[lineIdx]
+}
+
+// varSymbol returns the currency symbol of a variable with a currency value,
+// or "" for other variables
+func (d *document) varSymbol(name string) string {
+	if !d.currencyByVar[name] {
+		return ""
+	}
+	return d.symbolByVar[name]
+}
+
+// mixedCurrencies reports amounts in more than one currency, which don't
+// add up without an exchange rate
+func mixedCurrencies(symbols []string) error {
+	if len(symbols) < 2 {
+		return nil
+	}
+	return fmt.Errorf("can't mix %s and %s amounts", strings.TrimSpace(symbols[0]), strings.TrimSpace(symbols[1]))
+}
@@ -0,0 +1,173 @@
+package calc
+
+import (
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"smartcalc/internal/stats"
+)
+
+// A data block holds rows pasted from a spreadsheet, as CSV or TSV. Its
+// "data: =" line opens it, the raw rows below it follow, and a blank line
+// closes it. Column queries anywhere below read the closest block above:
+//
+//	data: = 2 rows, 2 columns
+//	month,revenue
+//	jan,1200
+//	feb,1350
+//
+//	col revenue sum = 2,550
+var (
+	dataStartPattern   = regexp.MustCompile(`(?i)^data:$`)
+	columnQueryPattern = regexp.MustCompile(`(?i)^col(?:umn)?\s+(?:(\d+)|(.+?))\s+(sum|total|avg|average|mean|median|min|max|stddev|stdev|variance|count|range)$`)
+)
+
+// isDataStart checks if an expression opens a data block
+func isDataStart(expr string) bool {
+	return dataStartPattern.MatchString(expr)
+}
+
+// isColumnQuery checks if an expression queries a column of a data block
+func isColumnQuery(expr string) bool {
+	return columnQueryPattern.MatchString(expr)
+}
+
+// isColumnQueryLine checks if a line queries a column of a data block
+func isColumnQueryLine(line string) bool {
+	return isColumnQuery(lineExpression(line))
+}
+
+// isDataExpr checks if an expression opens a data block or queries one
+func isDataExpr(expr string) bool {
+	return isDataStart(expr) || isColumnQuery(expr)
+}
+
+// dataRowLines returns the 0-based indexes of the raw rows of every data
+// block, which are data rather than expressions or comments
+func dataRowLines(lines []string) map[int]bool {
+	rows := make(map[int]bool)
+	for i := 0; i < len(lines); i++ {
+		if !isDataStart(lineExpression(lines[i])) {
+			continue
+		}
+		for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			i++
+			rows[i] = true
+		}
+	}
+	return rows
+}
+
+// dataRow splits a CSV or TSV row into cells; a row with a tab is TSV
+func dataRow(line string) []string {
+	r := csv.NewReader(strings.NewReader(strings.TrimSpace(line)))
+	if strings.Contains(line, "\t") {
+		r.Comma = '\t'
+	}
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+	cells, err := r.Read()
+	if err != nil {
+		return strings.Split(line, string(r.Comma))
+	}
+	for i, c := range cells {
+		cells[i] = strings.TrimSpace(c)
+	}
+	return cells
+}
+
+// dataBlockAt reads the data block opened on line start (0-based). As in a
+// pasted table, a first row without any numbers is the header.
+func dataBlockAt(lines []string, start int) pastedTable {
+	var t pastedTable
+	for j := start + 1; j < len(lines) && strings.TrimSpace(lines[j]) != ""; j++ {
+		t.rows = append(t.rows, dataRow(lines[j]))
+	}
+	if len(t.rows) > 1 && !rowHasNumber(t.rows[0]) {
+		t.header, t.rows = t.rows[0], t.rows[1:]
+	}
+	return t
+}
+
+// dataBlockAbove returns the line (0-based) opening the closest data block
+// above line idx
+func dataBlockAbove(lines []string, idx int) (int, bool) {
+	for j := idx - 1; j >= 0; j-- {
+		if isDataStart(lineExpression(lines[j])) {
+			return j, true
+		}
+	}
+	return 0, false
+}
+
+// dataSummary describes a data block on its "data:" line: "3 rows, 2 columns"
+func dataSummary(t pastedTable) string {
+	columns := len(t.header)
+	for _, row := range t.rows {
+		columns = max(columns, len(row))
+	}
+	return plural(len(t.rows), "row") + ", " + plural(columns, "column")
+}
+
+// plural writes a count with its noun: "1 row", "3 rows"
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// evalColumnQuery computes a statistic of a column of the data block above
+// line idx: "col 2 sum", "col revenue max". Cells that are not numbers are
+// left out and counted in ignored; empty cells are left out silently. ok is
+// false when expr is not a column query.
+func evalColumnQuery(lines []string, idx int, expr string) (val float64, isCurrency bool, ignored int, ok bool, err error) {
+	m := columnQueryPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return 0, false, 0, false, nil
+	}
+	start, found := dataBlockAbove(lines, idx)
+	if !found {
+		return 0, false, 0, true, fmt.Errorf("no data block above")
+	}
+	t := dataBlockAt(lines, start)
+	if len(t.rows) == 0 {
+		return 0, false, 0, true, fmt.Errorf("data block has no rows")
+	}
+	col, err := t.column(m[1], m[2])
+	if err != nil {
+		return 0, false, 0, true, err
+	}
+
+	var numbers []float64
+	for _, row := range t.rows {
+		if col >= len(row) || row[col] == "" {
+			continue
+		}
+		v, cur, isNum := tableCell(row[col])
+		if !isNum {
+			ignored++
+			continue
+		}
+		numbers = append(numbers, v)
+		isCurrency = isCurrency || cur
+	}
+	if len(numbers) == 0 {
+		return 0, false, ignored, true, fmt.Errorf("column %d has no numbers", col+1)
+	}
+	val, _ = stats.Aggregate(m[3], numbers)
+	if strings.EqualFold(m[3], "count") {
+		isCurrency = false
+	}
+	return val, isCurrency, ignored, true, nil
+}
+
+// ignoredNote notes the cells a column query left out: " (1 cell ignored)"
+func ignoredNote(ignored int) string {
+	if ignored == 0 {
+		return ""
+	}
+	return " (" + plural(ignored, "cell") + " ignored)"
+}
@@ -0,0 +1,113 @@
+package calc
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// disableDirectivePattern matches a "#disable cooking, whois" comment line that
+// turns evaluators off for the document
+var disableDirectivePattern = regexp.MustCompile(`(?i)^\s*#\s*disable\s+(.+)$`)
+
+var (
+	disabledMu sync.RWMutex
+	disabledBy = make(map[string]bool) // evaluators turned off in the settings
+)
+
+// EvaluatorNames returns the names of the evaluators that can be turned off,
+// in the order they are tried. Plain arithmetic is always on.
+func EvaluatorNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, ev := range dispatchTable() {
+		if !seen[ev.name] {
+			seen[ev.name] = true
+			names = append(names, ev.name)
+		}
+	}
+	return names
+}
+
+// isEvaluatorName checks if name is one of EvaluatorNames
+func isEvaluatorName(name string) bool {
+	for _, ev := range dispatchTable() {
+		if ev.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SetDisabledEvaluators turns the named evaluators off for every document.
+// Unknown names are ignored.
+func SetDisabledEvaluators(names []string) {
+	disabled := make(map[string]bool)
+	for _, name := range names {
+		if name = strings.ToLower(strings.TrimSpace(name)); isEvaluatorName(name) {
+			disabled[name] = true
+		}
+	}
+	disabledMu.Lock()
+	defer disabledMu.Unlock()
+	disabledBy = disabled
+}
+
+// DisabledEvaluators returns the evaluators turned off in the settings, sorted
+func DisabledEvaluators() []string {
+	disabledMu.RLock()
+	defer disabledMu.RUnlock()
+	names := make([]string, 0, len(disabledBy))
+	for name := range disabledBy {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// documentDisabled returns the evaluators turned off for a document: those
+// disabled in the settings and those named by its "#disable" lines
+func documentDisabled(lines []string) map[string]bool {
+	disabled := make(map[string]bool)
+	for _, name := range DisabledEvaluators() {
+		disabled[name] = true
+	}
+	for _, line := range lines {
+		m := disableDirectivePattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		for _, name := range strings.FieldsFunc(m[1], func(r rune) bool { return r == ',' || r == ' ' }) {
+			if name = strings.ToLower(name); isEvaluatorName(name) {
+				disabled[name] = true
+			}
+		}
+	}
+	return disabled
+}
+
+// disableDirective returns a "#disable" line for a set of evaluators, or ""
+// when none are off
+func disableDirective(disabled map[string]bool) string {
+	if len(disabled) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(disabled))
+	for name := range disabled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "#disable " + strings.Join(names, ", ")
+}
+
+// disabledMatch returns the first turned-off evaluator that recognizes expr,
+// to explain why a line only it would have handled fails
+func (d *document) disabledMatch(expr string) string {
+	for _, ev := range dispatchTable() {
+		if d.disabled[ev.name] && ev.detect(expr) {
+			return ev.name
+		}
+	}
+	return ""
+}
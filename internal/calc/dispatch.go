@@ -0,0 +1,681 @@
+package calc
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"smartcalc/internal/capacity"
+	"smartcalc/internal/constants"
+	"smartcalc/internal/datetime"
+	"smartcalc/internal/eval"
+	"smartcalc/internal/percentage"
+	"smartcalc/internal/programmer"
+	"smartcalc/internal/registry"
+	"smartcalc/internal/stats"
+	"smartcalc/internal/utils"
+
+	// Evaluators that register themselves
+	_ "smartcalc/internal/aviation"
+	_ "smartcalc/internal/bandwidth"
+	_ "smartcalc/internal/cert"
+	_ "smartcalc/internal/chem"
+	_ "smartcalc/internal/color"
+	_ "smartcalc/internal/constants"
+	_ "smartcalc/internal/cooking"
+	_ "smartcalc/internal/currency"
+	_ "smartcalc/internal/diy"
+	_ "smartcalc/internal/energy"
+	_ "smartcalc/internal/finance"
+	_ "smartcalc/internal/fitness"
+	_ "smartcalc/internal/fraction"
+	_ "smartcalc/internal/health"
+	_ "smartcalc/internal/hourlycost"
+	_ "smartcalc/internal/httpcheck"
+	_ "smartcalc/internal/jwt"
+	_ "smartcalc/internal/lookup"
+	_ "smartcalc/internal/manhour"
+	_ "smartcalc/internal/network"
+	_ "smartcalc/internal/otp"
+	_ "smartcalc/internal/permissions"
+	_ "smartcalc/internal/radio"
+	_ "smartcalc/internal/regex"
+	_ "smartcalc/internal/shipping"
+	_ "smartcalc/internal/travel"
+	_ "smartcalc/internal/uncertainty"
+	_ "smartcalc/internal/units"
+)
+
+// document is the state of one evaluation pass, shared by the evaluators of
+// the dispatch table
+type document struct {
+	activeLineNum int      // 1-based line being edited; 0 evaluates everything
+	mode          passMode // which lookups are left pending
+
+	results        []LineResult
+	values         []float64 // primary value of each line, referenceable as \N
+	haveRes        []bool    // line has a referenceable value
+	currencyByLine []bool
+	symbolByLine   []string // currency symbol each line is shown with
+
+	// Variable table built as lines are evaluated; later definitions shadow earlier ones
+	vars          map[string]float64
+	currencyByVar map[string]bool
+	symbolByVar   map[string]string
+
+	// currencyMix reports amounts in two currencies on the line being
+	// evaluated, which arithmetic doesn't add up
+	currencyMix error
+
+	hasMultiLineOutput map[int][]string   // line index -> its existing "> " output lines
+	disabled           map[string]bool    // evaluators turned off by the settings or "#disable"
+	gradeScaleLine     string             // "#grade scale" line in effect, if any
+	gradeScale         stats.GradeScale   // percentage bands of the "#grade scale" line, or the default
+	gradeScaleErr      error              // malformed "#grade scale" line
+	presetLines        []string           // "#preset" lines, in order
+	presets            datetime.Presets   // date offsets of the "#preset" lines, by name
+	sheet              sheetSettings      // "@" directives above the line being evaluated
+	lines              []string           // the document without its "> " output lines
+	lookups            map[int]prefetched // network results looked up ahead of the pass, by line index
+	dataRows           map[int]bool       // raw rows of the data blocks, by line index
+	trace              *explainTrace      // evaluators offered a "?explain" line, nil for other lines
+}
+
+func newDocument(n, activeLineNum int, mode passMode, hasMultiLineOutput map[int][]string, disabled map[string]bool) *document {
+	sheet := defaultSheetSettings
+	sheet.format.Decimals = utils.Precision()
+	sheet.format.DecimalComma = utils.DecimalComma()
+	return &document{
+		activeLineNum:      activeLineNum,
+		mode:               mode,
+		results:            make([]LineResult, n),
+		values:             make([]float64, n),
+		haveRes:            make([]bool, n),
+		currencyByLine:     make([]bool, n),
+		symbolByLine:       make([]string, n),
+		vars:               make(map[string]float64),
+		currencyByVar:      make(map[string]bool),
+		symbolByVar:        make(map[string]string),
+		hasMultiLineOutput: hasMultiLineOutput,
+		disabled:           disabled,
+		gradeScale:         stats.DefaultGradeScale,
+		sheet:              sheet,
+	}
+}
+
+// refResolver resolves a line reference \n to its value
+func (d *document) refResolver(n int) (float64, error) {
+	idx := n - 1
+	if idx < 0 || idx >= len(d.values) {
+		return 0, fmt.Errorf("bad reference \\\\%d", n)
+	}
+	if !d.haveRes[idx] {
+		return 0, fmt.Errorf("unresolved reference \\\\%d", n)
+	}
+	return d.values[idx], nil
+}
+
+// varResolver resolves a variable defined on an earlier line, or else a
+// named constant such as pi or planck. A variable hides the constant of its
+// name, so "c = 3 =" makes c 3.
+func (d *document) varResolver(name string) (float64, error) {
+	if v, ok := d.vars[name]; ok {
+		return v, nil
+	}
+	if v, _, ok := constants.Lookup(name); ok {
+		return v, nil
+	}
+	return 0, fmt.Errorf("undefined variable %s", name)
+}
+
+// isActive checks if lineIdx (0-based) is the line being edited
+func (d *document) isActive(lineIdx int) bool {
+	return d.activeLineNum > 0 && lineIdx+1 == d.activeLineNum
+}
+
+// maybeFormat formats an expression, except on the line being edited
+func (d *document) maybeFormat(lineIdx int, expr string) string {
+	if d.isActive(lineIdx) {
+		return expr // Skip formatting for active line
+	}
+	return formatExpression(expr)
+}
+
+// recordValue makes an evaluator's primary value referenceable as \N
+func (d *document) recordValue(lineIdx int, r utils.Result) {
+	if !r.HasValue {
+		return
+	}
+	d.values[lineIdx] = r.Value
+	d.haveRes[lineIdx] = true
+	d.currencyByLine[lineIdx] = r.IsCurrency
+	d.results[lineIdx].Value = r.Value
+	d.results[lineIdx].IsCurrency = r.IsCurrency
+}
+
+// deferLine leaves an expensive line to the deferred pass. Inactive lines
+// keep the result they already show; others display PendingResult.
+func (d *document) deferLine(lineIdx int, line, existingResult, expr, inlineComment string) {
+	d.results[lineIdx].Pending = true
+	if !d.isActive(lineIdx) {
+		if outputLines, ok := d.hasMultiLineOutput[lineIdx]; ok {
+			d.results[lineIdx].Output = line + "\n" + strings.Join(outputLines, "\n")
+			d.results[lineIdx].HasResult = true
+			return
+		}
+		if strings.TrimSpace(existingResult) != "" {
+			d.results[lineIdx].Output = line
+			d.results[lineIdx].HasResult = true
+			return
+		}
+	}
+	d.results[lineIdx].Output = expr + " = " + PendingResult + inlineComment
+}
+
+// lineInput is the line an evaluator of the dispatch table is offered
+type lineInput struct {
+	idx           int    // 0-based line index
+	line          string // line as typed, with its current result
+	workingLine   string // line without its inline comment
+	eq            int    // position of the result '=' in workingLine
+	expr          string // expression before the '='
+	inlineComment string // " # comment" after the result, if any
+}
+
+// existingResult returns the result the line currently shows
+func (in lineInput) existingResult() string {
+	return in.workingLine[in.eq+1:]
+}
+
+// show sets the output of a claimed line: the expression as shown, the result
+// with its separator, and the inline comment
+func (d *document) show(in lineInput, shown, result string) bool {
+	d.results[in.idx].Output = shown + result + in.inlineComment
+	d.results[in.idx].HasResult = true
+	return true
+}
+
+// keepPrevious keeps the result an inactive network or random line already
+// shows, so lookups are not repeated and dice not rolled again on every
+// keystroke
+func (d *document) keepPrevious(in lineInput, multiLine bool) bool {
+	if d.isActive(in.idx) {
+		return false
+	}
+	if strings.TrimSpace(in.existingResult()) != "" {
+		d.results[in.idx].Output = in.line
+		// A result may have "> " lines below it, like the dice of a roll
+		if outputLines, ok := d.hasMultiLineOutput[in.idx]; ok && multiLine {
+			d.results[in.idx].Output += "\n" + strings.Join(outputLines, "\n")
+		}
+		d.results[in.idx].HasResult = true
+		return true
+	}
+	if outputLines, ok := d.hasMultiLineOutput[in.idx]; ok && multiLine {
+		d.results[in.idx].Output = in.line + "\n" + strings.Join(outputLines, "\n")
+		d.results[in.idx].HasResult = true
+		return true
+	}
+	return false
+}
+
+// lineEvaluator is an entry of the dispatch table. eval claims a line by
+// setting its result and returning true; returning false offers the line to
+// the next entry.
+type lineEvaluator struct {
+	name     string
+	priority int
+	traits   registry.Trait
+	detect   func(expr string) bool // recognizes the expressions eval may claim
+	eval     func(d *document, in lineInput) bool
+	builtin  bool // not in the registry
+}
+
+// builtinEvaluators read the document being evaluated, such as the values of
+// referenced lines, so they are not in the registry. They are ordered with the
+// registered evaluators by priority.
+var builtinEvaluators = []lineEvaluator{
+	{name: "ledger", priority: registry.PriorityLedger, detect: isLedgerExpr, eval: evalLedger},
+	{name: "data", priority: registry.PriorityData, detect: isDataExpr, eval: evalData},
+	{name: "base", priority: registry.PriorityBase, detect: isBaseConversionExpr, eval: evalBase},
+	{name: "textstats", priority: registry.PriorityTextStats, detect: programmer.IsTextStatsExpression, eval: evalTextStats},
+	{name: "resources", priority: registry.PriorityResources, detect: capacity.IsResourceExpression, eval: evalResources},
+	{name: "percentage", priority: registry.PriorityPercentage, detect: percentage.IsPercentageExpression, eval: evalPercentage},
+	{name: "trend", priority: registry.PriorityTrend, detect: stats.IsTrendExpression, eval: evalTrend},
+	{name: "stats", priority: registry.PriorityStats, detect: stats.IsStatsExpression, eval: evalStats},
+	{name: "grades", priority: registry.PriorityGrades, detect: stats.IsGradeExpression, eval: evalGrades},
+	{name: "datetime", priority: registry.PriorityDateTime, detect: datetime.IsDateTimeExpression, eval: evalDateTime},
+}
+
+var (
+	dispatchOnce   sync.Once
+	lineEvaluators []lineEvaluator
+)
+
+// dispatchTable returns the built-in and registered evaluators in priority
+// order. It is built on first use, after every package has registered.
+func dispatchTable() []lineEvaluator {
+	dispatchOnce.Do(func() {
+		table := make([]lineEvaluator, 0, len(builtinEvaluators))
+		for _, ev := range builtinEvaluators {
+			ev.builtin = true
+			table = append(table, ev)
+		}
+		for _, ev := range registry.Evaluators() {
+			table = append(table, lineEvaluator{name: ev.Name, priority: ev.Priority, traits: ev.Traits, detect: ev.Detect, eval: evalRegistered(ev)})
+		}
+		sort.SliceStable(table, func(i, j int) bool {
+			return table[i].priority < table[j].priority
+		})
+		lineEvaluators = table
+	})
+	return lineEvaluators
+}
+
+// dispatch offers a line to the dispatch table and returns the name of the
+// evaluator that claimed it. Turned-off evaluators are skipped; lines no
+// evaluator claims are plain arithmetic.
+func (d *document) dispatch(in lineInput) string {
+	for _, ev := range dispatchTable() {
+		if d.disabled[ev.name] {
+			continue
+		}
+		start := time.Now()
+		claimed := ev.eval(d, in)
+		d.trace.check(ev.name, claimed, time.Since(start))
+		if claimed {
+			return ev.name
+		}
+	}
+	start := time.Now()
+	evalNumeric(d, in)
+	d.trace.check("numeric", true, time.Since(start))
+	return "numeric"
+}
+
+// registeredEvaluator returns the registered evaluator the dispatch table
+// offers expr to: the first evaluator not turned off that recognizes it, as
+// in dispatch. ok is false when that evaluator is built in or none is.
+func registeredEvaluator(expr string, disabled map[string]bool) (registry.Evaluator, bool) {
+	for _, ev := range dispatchTable() {
+		if disabled[ev.name] || !ev.detect(expr) {
+			continue
+		}
+		if ev.builtin {
+			break
+		}
+		for _, r := range registry.Evaluators() {
+			if r.Name == ev.name {
+				return r, true
+			}
+		}
+		break
+	}
+	return registry.Evaluator{}, false
+}
+
+// isNetworkEvaluator checks if the named evaluator looks results up over the network
+func isNetworkEvaluator(name string) bool {
+	for _, ev := range dispatchTable() {
+		if ev.name == name {
+			return ev.traits.Has(registry.Expensive)
+		}
+	}
+	return false
+}
+
+// evalRegistered adapts a registered evaluator to the dispatch table, applying
+// the shared behavior its traits ask for
+func evalRegistered(ev registry.Evaluator) func(d *document, in lineInput) bool {
+	return func(d *document, in lineInput) bool {
+		expr := d.sheet.numberInput(in.expr)
+		if !ev.Detect(expr) {
+			return false
+		}
+		expensive := ev.Traits.Has(registry.Expensive)
+		var r utils.Result
+		var err error
+		if p, ok := d.lookups[in.idx]; ok && expensive && p.evaluator == ev.Name && p.expr == expr {
+			d.results[in.idx].fetched = true
+			r, err = p.result, p.err
+		} else {
+			if expensive && NetworkDisabled() && usesNetwork(expr) {
+				return d.show(in, in.expr, " = ERR: "+ErrNetworkDisabled.Error())
+			}
+			if expensive {
+				if !ev.Traits.Has(registry.Volatile) && d.mode != freshPass && d.keepPrevious(in, ev.Traits.Has(registry.MultiLine)) {
+					return true
+				}
+				if d.mode == fastPass || d.mode == deferredPass && isNetworkLookup(expr) {
+					d.deferLine(in.idx, in.line, in.existingResult(), in.expr, in.inlineComment)
+					return true
+				}
+				d.results[in.idx].fetched = true
+			}
+			if ev.Traits.Has(registry.Random) && d.mode != freshPass && d.mode != refreshPass && d.keepPrevious(in, ev.Traits.Has(registry.MultiLine)) {
+				// The number kept is still there for later lines to reference
+				if v, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(in.existingResult()), ",", ""), 64); err == nil {
+					d.recordValue(in.idx, utils.ValueResult("", v, false))
+				}
+				return true
+			}
+			r, err = ev.Eval(expr)
+		}
+		shown := in.expr
+		if !ev.Traits.Has(registry.NoFormat) {
+			shown = d.maybeFormat(in.idx, in.expr)
+		}
+		if err != nil {
+			if !ev.Traits.Has(registry.ReportsErrors) {
+				return false
+			}
+			if expensive {
+				// A failed lookup is a result like any other, shown as typed
+				return d.show(in, in.expr, " = ERR: "+err.Error())
+			}
+			d.results[in.idx].Output = shown + " = ERR: " + err.Error() + in.inlineComment
+			return true
+		}
+		d.recordValue(in.idx, r)
+
+		// Multi-line results start with \n>, single-line results don't
+		if ev.Traits.Has(registry.MultiLine) && strings.HasPrefix(r.Text, "\n") {
+			return d.show(in, shown, " ="+r.Text)
+		}
+		return d.show(in, shown, " = "+r.Text)
+	}
+}
+
+// evalBase handles base conversions (24 in hex, 0xFF in dec, etc.)
+func evalBase(d *document, in lineInput) bool {
+	if !isBaseConversionExpr(in.expr) {
+		return false
+	}
+	baseResult, ok := tryBaseConversion(in.expr)
+	if !ok {
+		return false
+	}
+	return d.show(in, in.expr, " = "+baseResult)
+}
+
+// evalLedger shows the amount of a ledger line and, for a transaction, the
+// balance after it. Its memo is kept as typed.
+func evalLedger(d *document, in lineInput) bool {
+	amount, balance, ok := d.ledgerLine(in.idx, in.expr)
+	if !ok {
+		return false
+	}
+	d.recordValue(in.idx, utils.ValueResult("", amount, true))
+	return d.show(in, in.expr, " = "+d.ledgerResult(in.expr, amount, balance))
+}
+
+// evalData describes a data block on its "data:" line, and computes
+// "col 2 sum" or "col revenue max" over a column of the closest block above
+func evalData(d *document, in lineInput) bool {
+	if isDataStart(in.expr) {
+		return d.show(in, in.expr, " = "+dataSummary(dataBlockAt(d.lines, in.idx)))
+	}
+	val, isCurrency, ignored, ok, err := evalColumnQuery(d.lines, in.idx, in.expr)
+	if !ok {
+		return false
+	}
+	if err != nil {
+		d.results[in.idx].Output = d.maybeFormat(in.idx, in.expr) + " = ERR: " + err.Error() + in.inlineComment
+		return true
+	}
+	d.recordValue(in.idx, utils.ValueResult("", val, isCurrency))
+	return d.show(in, d.maybeFormat(in.idx, in.expr), " = "+d.sheet.format.Result(isCurrency, val)+ignoredNote(ignored))
+}
+
+// evalPercentage handles percentage calculations. Line references are resolved
+// first so "15% of \3" is recognized, and the result keeps the referenced currency.
+func evalPercentage(d *document, in lineInput) bool {
+	pctExpr := d.sheet.numberInput(d.sheet.currencyInput(in.expr))
+	if strings.Contains(pctExpr, "\\") {
+		pctExpr = substituteRefs(pctExpr, d.refResolver)
+	}
+	if !percentage.IsPercentageExpression(pctExpr) {
+		return false
+	}
+	if val, err := percentage.EvalPercentageValue(pctExpr); err == nil {
+		isCurrency := strings.Contains(pctExpr, "$") ||
+			eval.ExprReferencesCurrency(in.expr, d.currencyByLine)
+		d.recordValue(in.idx, utils.ValueResult("", val, isCurrency))
+		return d.show(in, d.maybeFormat(in.idx, in.expr), " = "+d.sheet.format.Result(isCurrency, val))
+	}
+	pctResult, err := percentage.EvalPercentage(pctExpr)
+	if err != nil {
+		return false
+	}
+	return d.show(in, d.maybeFormat(in.idx, in.expr), " = "+pctResult)
+}
+
+// evalResources handles CPU, memory and cost math of resource requests, with
+// line references resolved to their values: "\1 cores at $0.031/core-hour for 30 days"
+func evalResources(d *document, in lineInput) bool {
+	resExpr := d.sheet.numberInput(in.expr)
+	if !capacity.IsResourceExpression(resExpr) {
+		return false
+	}
+	if strings.Contains(resExpr, "\\") {
+		resExpr = substituteRefs(resExpr, d.refResolver)
+	}
+	r, err := capacity.EvalResources(resExpr)
+	if err != nil {
+		d.results[in.idx].Output = in.expr + " = ERR: " + err.Error() + in.inlineComment
+		return true
+	}
+	d.recordValue(in.idx, r)
+	return d.show(in, in.expr, " = "+r.Text)
+}
+
+// evalTextStats counts the words of quoted text or of the text of referenced
+// lines: "wordcount \\3", "stats of \\2..\\6". A line with a result counts
+// its expression and a comment line the text after its '#'.
+func evalTextStats(d *document, in lineInput) bool {
+	if !programmer.IsTextStatsExpression(in.expr) {
+		return false
+	}
+	subject, quoted := programmer.TextStatsSubject(in.expr)
+	if !quoted {
+		var texts []string
+		for _, n := range eval.ReferencedLines(subject) {
+			if idx := n - 1; idx >= 0 && idx < len(d.lines) && idx != in.idx {
+				texts = append(texts, lineText(d.lines[idx]))
+			}
+		}
+		if len(texts) == 0 {
+			d.results[in.idx].Output = in.expr + " = ERR: no line " + subject + in.inlineComment
+			return true
+		}
+		subject = strings.Join(texts, "\n")
+	}
+	r := programmer.EvalTextStats(subject)
+	d.recordValue(in.idx, r)
+	return d.show(in, in.expr, " = "+r.Text)
+}
+
+// lineText returns the text of a line: the expression of a line with a
+// result, the text of a comment, or the line as typed
+func lineText(line string) string {
+	if IsCommentLine(line) {
+		return strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+	}
+	if expr := lineExpression(line); expr != "" {
+		return expr
+	}
+	return strings.TrimSpace(line)
+}
+
+// evalTrend summarizes the values of a range of lines: "trend \\1..\\10".
+// Lines of the range without a value, such as comments and text, are skipped.
+func evalTrend(d *document, in lineInput) bool {
+	if !stats.IsTrendExpression(in.expr) {
+		return false
+	}
+	var values []float64
+	for _, n := range eval.ReferencedLines(in.expr) {
+		if idx := n - 1; idx >= 0 && idx < len(d.values) && idx != in.idx && d.haveRes[idx] {
+			values = append(values, d.values[idx])
+		}
+	}
+	r, err := stats.Trend(values, eval.ExprReferencesCurrency(in.expr, d.currencyByLine))
+	if err != nil {
+		d.results[in.idx].Output = in.expr + " = ERR: " + err.Error() + in.inlineComment
+		return true
+	}
+	d.recordValue(in.idx, r)
+	return d.show(in, in.expr, " = "+r.Text)
+}
+
+// evalStats handles statistics functions, with line references resolved to
+// their values: "percentile(95, \1, \2, \3)"
+func evalStats(d *document, in lineInput) bool {
+	statsExpr := d.sheet.numberInput(in.expr)
+	if !stats.IsStatsExpression(statsExpr) {
+		return false
+	}
+	if strings.Contains(statsExpr, "\\") {
+		statsExpr = substituteRefs(statsExpr, d.refResolver)
+	}
+	statsResult, err := stats.EvalStatsResult(statsExpr)
+	if err != nil {
+		return false
+	}
+	d.recordValue(in.idx, statsResult)
+	return d.show(in, d.maybeFormat(in.idx, in.expr), " = "+statsResult.Text)
+}
+
+// evalGrades handles GPA and letter grade conversions with the document's
+// grade scale. A malformed "#grade scale" line is reported on grade lines.
+func evalGrades(d *document, in lineInput) bool {
+	if !stats.IsGradeExpression(in.expr) {
+		return false
+	}
+	r, err := stats.EvalGrade(in.expr, d.gradeScale)
+	if err == nil {
+		err = d.gradeScaleErr
+	}
+	if err != nil {
+		d.results[in.idx].Output = d.maybeFormat(in.idx, in.expr) + " = ERR: " + err.Error() + in.inlineComment
+		return true
+	}
+	d.recordValue(in.idx, r)
+	return d.show(in, d.maybeFormat(in.idx, in.expr), " = "+r.Text)
+}
+
+// evalDateTime handles date/time expressions, with references to earlier
+// date/time lines
+func evalDateTime(d *document, in lineInput) bool {
+	date, preset, isPreset := d.presets.Match(in.expr)
+	if !isPreset && !datetime.IsDateTimeExpression(in.expr) && !strings.Contains(in.expr, "\\") {
+		return false
+	}
+	resolver := func(n int) (string, bool) {
+		idx := n - 1
+		if idx < 0 || idx >= len(d.results) {
+			return "", false
+		}
+		if d.results[idx].IsDateTime && d.results[idx].DateTimeStr != "" {
+			return d.results[idx].DateTimeStr, true
+		}
+		return "", false
+	}
+
+	// "2025-03-01 + net 30" adds a named offset; "2/10 net 30" lists the
+	// discount deadline and the due date
+	if isPreset {
+		due, out, err := datetime.EvalPreset(date, preset, resolver)
+		if err != nil {
+			return false
+		}
+		d.results[in.idx].IsDateTime = true
+		d.results[in.idx].DateTimeStr = due
+		if strings.HasPrefix(out, "\n>") {
+			return d.show(in, in.expr, " ="+out)
+		}
+		return d.show(in, d.maybeFormat(in.idx, in.expr), " = "+out)
+	}
+
+	// "week number of 2024-06-10" and "day of year \3" have a number as
+	// their value; the dates they ask about are kept as typed
+	if datetime.IsDateQuery(in.expr) {
+		r, err := datetime.EvalDateQuery(in.expr, resolver)
+		if err != nil {
+			return d.show(in, in.expr, " = ERR: "+err.Error())
+		}
+		d.recordValue(in.idx, r)
+		return d.show(in, in.expr, " = "+r.Text)
+	}
+
+	dtResult, err := datetime.EvalDateTimeWithRefs(in.expr, resolver)
+	if err != nil {
+		return false // fall through to numeric evaluation
+	}
+	// A recurrence keeps its start date as typed: "from 2024-06-03" is not
+	// a subtraction
+	expr := d.maybeFormat(in.idx, in.expr)
+	if datetime.IsRecurrenceExpression(in.expr) {
+		expr = in.expr
+	}
+	// Ambiguous time zones list one candidate per "> Region: ..." line, and
+	// recurrences one date per line
+	if strings.HasPrefix(dtResult, "\n>") {
+		return d.show(in, expr, " ="+dtResult)
+	}
+	d.results[in.idx].IsDateTime = true
+	d.results[in.idx].DateTimeStr = dtResult
+	return d.show(in, expr, " = "+dtResult)
+}
+
+// evalNumeric evaluates plain arithmetic with references and variables. An
+// expression it cannot evaluate shows ERR.
+func evalNumeric(d *document, in lineInput) {
+	// A trailing "in sci" / "in eng" picks the notation of the result
+	numExpr := d.sheet.currencyInput(in.expr)
+	notation := ""
+	if m := notationPattern.FindStringSubmatch(numExpr); m != nil {
+		numExpr, notation = strings.TrimSpace(m[1]), strings.ToLower(m[2][:3])
+	}
+
+	isCurrency := strings.Contains(numExpr, "$") ||
+		eval.ExprReferencesCurrency(numExpr, d.currencyByLine) ||
+		eval.ExprReferencesCurrencyVar(numExpr, d.currencyByVar)
+	isComparison := isComparisonExpr(numExpr)
+	if d.currencyMix != nil {
+		d.results[in.idx].Output = d.maybeFormat(in.idx, in.expr) + " = ERR: " + d.currencyMix.Error() + in.inlineComment
+		return
+	}
+
+	val, unit, err := d.evalWithConstants(numExpr)
+	if err != nil {
+		result := " = ERR"
+		if name := d.disabledMatch(in.expr); name != "" {
+			result += ": matched disabled evaluator: " + name
+		}
+		d.results[in.idx].Output = d.maybeFormat(in.idx, in.expr) + result + in.inlineComment
+		return
+	}
+	d.recordValue(in.idx, utils.ValueResult("", val, isCurrency))
+
+	var resultStr string
+	if isComparison {
+		resultStr = utils.FormatBoolResult(val)
+	} else if notation == "sci" && !isCurrency {
+		resultStr = utils.FormatScientific(val)
+	} else if notation == "eng" && !isCurrency {
+		resultStr = utils.FormatEngineering(val)
+	} else if baseStr, ok := utils.FormatInBase(val, eval.LeadingBase(numExpr)); ok && !isCurrency {
+		// Mixed-base arithmetic is shown in the base of the first operand (0xFF + 1 = 0x100)
+		resultStr = baseStr
+	} else {
+		resultStr = d.sheet.format.Result(isCurrency, val)
+	}
+	if unit != "" && !isComparison {
+		resultStr += " " + unit
+	}
+	d.show(in, d.maybeFormat(in.idx, in.expr), " = "+resultStr)
+}
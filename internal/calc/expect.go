@@ -0,0 +1,77 @@
+package calc
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"smartcalc/internal/eval"
+	"smartcalc/internal/utils"
+)
+
+// expectPattern matches an expected-value annotation inside an inline comment:
+// "# expect 4", "#expect $1,610.46", "# expect 3.14 ±0.01", "# expect 100 +/- 1%"
+var expectPattern = regexp.MustCompile(`(?i)(?:^|[\s#,;])expect\s+(.+?)(?:\s*(?:±|\+/-)\s*(\d*[.,]?\d+%?))?\s*$`)
+
+// lineExpectation returns the expected value and optional tolerance annotated
+// in a line's inline comment
+func lineExpectation(line string) (expected, tolerance string, ok bool) {
+	_, _, comment, hasEq := SplitResult(line)
+	if !hasEq || comment == "" {
+		return "", "", false
+	}
+	m := expectPattern.FindStringSubmatch(comment)
+	if m == nil {
+		return "", "", false
+	}
+	return strings.TrimSpace(m[1]), m[2], true
+}
+
+// expectationMet checks a line result against its annotation. The result text
+// (or the formatted primary value) matching exactly always passes; otherwise
+// numeric lines are compared by value, within the tolerance if one is given.
+func expectationMet(r LineResult, numeric bool, expected, tolerance string) bool {
+	first, _, _ := strings.Cut(r.Output, "\n")
+	if _, result, _, ok := SplitResult(first); ok && result != "" && result == expected {
+		return true
+	}
+	if !numeric {
+		return false
+	}
+	if utils.FormatResult(r.IsCurrency, r.Value) == expected {
+		return true // e.g. the monthly payment of a multi-line loan result
+	}
+	want, err := eval.EvalExprWithOptions(expected, nil, nil, eval.Options{DecimalComma: utils.DecimalComma()})
+	if err != nil {
+		return false
+	}
+
+	tol := 1e-9 * math.Max(1, math.Abs(want))
+	if pct, isPct := strings.CutSuffix(tolerance, "%"); isPct {
+		if p, err := utils.ParseNumber(pct, utils.DecimalComma()); err == nil {
+			tol = math.Abs(want) * p / 100
+		}
+	} else if tolerance != "" {
+		if t, err := utils.ParseNumber(tolerance, utils.DecimalComma()); err == nil {
+			tol = t
+		}
+	}
+	return math.Abs(r.Value-want) <= tol
+}
+
+// flagExpectation marks a line whose result differs from its annotation,
+// ahead of the inline comment: "2 + 2 = 5 ✗ FAILED: expected 4 # expect 4"
+func flagExpectation(output, expected string) string {
+	flag := " ✗ FAILED: expected " + expected
+	first, rest, multiLine := strings.Cut(output, "\n")
+	if _, _, comment, ok := SplitResult(first); ok && comment != "" {
+		idx := strings.LastIndex(first, comment)
+		first = strings.TrimRight(first[:idx], " ") + flag + " " + first[idx:]
+	} else {
+		first = strings.TrimRight(first, " ") + flag
+	}
+	if multiLine {
+		return first + "\n" + rest
+	}
+	return first
+}
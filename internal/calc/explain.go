@@ -0,0 +1,112 @@
+package calc
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// explainSuffixPattern matches the "?explain" suffix that traces how a line
+// was routed: "2 cups flour to grams ?explain ="
+var explainSuffixPattern = regexp.MustCompile(`(?i)\s*\?explain$`)
+
+// explainSuffix is written back into an explained line
+const explainSuffix = " ?explain"
+
+// Trace lines start with these, so a new trace replaces the old one
+const (
+	explainMatched = "> matched: "
+	explainChecked = "> checked: "
+)
+
+// stripExplain takes the "?explain" suffix off an expression
+func stripExplain(expr string) (string, bool) {
+	loc := explainSuffixPattern.FindStringIndex(expr)
+	if loc == nil {
+		return expr, false
+	}
+	return strings.TrimSpace(expr[:loc[0]]), true
+}
+
+// gateCheck is one evaluator of the dispatch table offered an explained line
+type gateCheck struct {
+	name    string
+	claimed bool
+	took    time.Duration
+}
+
+// explainTrace records the evaluators an explained line was offered to, in
+// order, up to the one that claimed it
+type explainTrace struct {
+	checks []gateCheck
+}
+
+// check records an evaluator offered the line. A nil trace records nothing,
+// so lines that are not explained pay for no bookkeeping.
+func (t *explainTrace) check(name string, claimed bool, took time.Duration) {
+	if t != nil {
+		t.checks = append(t.checks, gateCheck{name, claimed, took})
+	}
+}
+
+// lines writes the trace as "> " lines: the evaluator that claimed the line
+// and every evaluator it was offered to, with the time each took. A line
+// claimed before the dispatch table, such as an assignment, names only its
+// evaluator.
+func (t *explainTrace) lines(evaluator string) string {
+	if len(t.checks) == 0 {
+		if evaluator == "" {
+			evaluator = "none"
+		}
+		return "\n" + explainMatched + evaluator
+	}
+	last := t.checks[len(t.checks)-1]
+	matched := evaluator
+	if last.claimed {
+		matched = fmt.Sprintf("%s (%s)", last.name, micros(last.took))
+	}
+	checked := make([]string, len(t.checks))
+	for i, c := range t.checks {
+		mark := "✗"
+		if c.claimed {
+			mark = "✓"
+		}
+		checked[i] = fmt.Sprintf("%s %s %s", c.name, mark, micros(c.took))
+	}
+	return "\n" + explainMatched + matched + "\n" + explainChecked + strings.Join(checked, ", ")
+}
+
+// micros writes a duration in whole microseconds: "12 µs"
+func micros(d time.Duration) string {
+	return fmt.Sprintf("%d µs", d.Microseconds())
+}
+
+// withoutTrace drops the trace lines of an earlier explain from a line's
+// "> " output lines
+func withoutTrace(outputLines []string) []string {
+	var kept []string
+	for _, l := range outputLines {
+		if !strings.HasPrefix(l, explainMatched) && !strings.HasPrefix(l, explainChecked) {
+			kept = append(kept, l)
+		}
+	}
+	return kept
+}
+
+// restore writes the "?explain" suffix back into an evaluated line, ahead of
+// its '=', and appends the trace below its output
+func (t *explainTrace) restore(output, evaluator string) string {
+	first, rest, multiLine := strings.Cut(output, "\n")
+	if eq := findResultEquals(stripInlineComment(first)); eq >= 0 {
+		if expr := strings.TrimRight(first[:eq], " \t"); !explainSuffixPattern.MatchString(expr) {
+			first = expr + explainSuffix + " " + first[eq:]
+		}
+	}
+	if multiLine {
+		if kept := withoutTrace(strings.Split(rest, "\n")); len(kept) > 0 {
+			first += "\n" + strings.Join(kept, "\n")
+		}
+	}
+	return first + t.lines(evaluator)
+}
@@ -0,0 +1,23 @@
+package calc
+
+import (
+	"strings"
+
+	"smartcalc/internal/stats"
+)
+
+// setGradeScale applies the document's "#grade scale A 90, B 80, ..." line.
+// The last one wins; without one the default scale applies.
+func (d *document) setGradeScale(lines []string) {
+	for _, line := range lines {
+		scale, ok, err := stats.ParseGradeScale(line)
+		if !ok {
+			continue
+		}
+		d.gradeScaleLine = strings.TrimSpace(line)
+		d.gradeScale, d.gradeScaleErr = scale, err
+		if err != nil {
+			d.gradeScale = stats.DefaultGradeScale
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package calc
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"smartcalc/internal/eval"
+)
+
+// lineRefPattern matches line references like \3
+var lineRefPattern = regexp.MustCompile(`\\(\d+)`)
+
+// DependencyInfo is the dependency graph of a document, as drawn by the editor
+type DependencyInfo struct {
+	DependsOn map[int][]int `json:"dependsOn"` // line -> lines it reads from
+	UsedBy    map[int][]int `json:"usedBy"`    // line -> lines that read from it
+	Cycles    [][]int       `json:"cycles"`    // groups of lines that depend on each other
+}
+
+// GetDependencyInfo builds the dependency graph of a document with its reverse
+// adjacency and cycles
+func GetDependencyInfo(lines []string) DependencyInfo {
+	graph := DependencyGraph(lines)
+	return DependencyInfo{
+		DependsOn: graph,
+		UsedBy:    ReverseDependencies(graph),
+		Cycles:    DependencyCycles(graph),
+	}
+}
+
+// DependencyGraph returns, for each line (1-based), the lines it reads from:
+// the lines it references (\3), the latest assignment of each variable it
+// uses, the block above an aggregate ("total ="), the rows above a table
+// query, the ledger line above a transaction and the rows of a data block
+// for its "data:" line and the column queries below it. Lines without
+// dependencies are left out.
+func DependencyGraph(lines []string) map[int][]int {
+	graph := make(map[int][]int)
+	assignedAt := make(map[string]int) // variable -> line of its latest assignment
+	ledgerAbove := ledgerDependencies(lines)
+	dataRows := dataRowLines(lines)
+
+	for i, line := range lines {
+		lineNum := i + 1
+		if strings.TrimSpace(line) == "" || IsCommentLine(line) && !isCommentCalcLine(line) || dataRows[i] {
+			continue
+		}
+		deps := make(map[int]bool)
+
+		for _, n := range eval.ReferencedLines(line) {
+			if n >= 1 && n <= len(lines) {
+				deps[n] = true
+			}
+		}
+		for _, name := range lineVariables(line) {
+			if at, ok := assignedAt[name]; ok {
+				deps[at] = true
+			}
+		}
+		if isAggregateLine(line) {
+			for j := aggregateBlockStart(lines, i); j < i; j++ {
+				deps[j+1] = true
+			}
+		}
+		if isTableQueryLine(line) {
+			if _, start, ok := tableAbove(lines, i); ok {
+				for j := start; j < i; j++ {
+					deps[j+1] = true
+				}
+			}
+		}
+		if above, ok := ledgerAbove[lineNum]; ok {
+			deps[above] = true
+		}
+		if isDataStart(lineExpression(line)) {
+			for j := i + 1; dataRows[j]; j++ {
+				deps[j+1] = true
+			}
+		}
+		if isColumnQueryLine(line) {
+			if start, ok := dataBlockAbove(lines, i); ok {
+				deps[start+1] = true
+			}
+		}
+
+		// A redefinition like "x = x + 1 =" reads the previous x, so the
+		// assignment is recorded after its own variables are resolved
+		if name := lineAssignedVariable(line); name != "" {
+			assignedAt[name] = lineNum
+		}
+
+		if len(deps) > 0 {
+			graph[lineNum] = sortedLines(deps)
+		}
+	}
+	return graph
+}
+
+// ReverseDependencies inverts a dependency graph: for each line, the lines
+// that read from it
+func ReverseDependencies(graph map[int][]int) map[int][]int {
+	usedBy := make(map[int][]int)
+	for line, deps := range graph {
+		for _, dep := range deps {
+			usedBy[dep] = append(usedBy[dep], line)
+		}
+	}
+	for _, users := range usedBy {
+		sort.Ints(users)
+	}
+	return usedBy
+}
+
+// DependencyCycles returns the groups of lines that depend on each other,
+// directly or through other lines, each sorted and ordered by first line. A
+// line that references itself is a group of one. The evaluator reports these
+// lines as unresolved references.
+func DependencyCycles(graph map[int][]int) [][]int {
+	// Tarjan's strongly connected components
+	index := make(map[int]int)
+	low := make(map[int]int)
+	onStack := make(map[int]bool)
+	var stack []int
+	var cycles [][]int
+	next := 0
+
+	var visit func(v int)
+	visit = func(v int) {
+		index[v], low[v] = next, next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range graph[v] {
+			if _, seen := index[w]; !seen {
+				visit(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+
+		if low[v] != index[v] {
+			return
+		}
+		var group []int
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			group = append(group, w)
+			if w == v {
+				break
+			}
+		}
+		if len(group) > 1 || dependsOn(graph, v, v) {
+			sort.Ints(group)
+			cycles = append(cycles, group)
+		}
+	}
+
+	starts := make([]int, 0, len(graph))
+	for line := range graph {
+		starts = append(starts, line)
+	}
+	sort.Ints(starts)
+	for _, line := range starts {
+		if _, seen := index[line]; !seen {
+			visit(line)
+		}
+	}
+
+	sort.Slice(cycles, func(a, b int) bool { return cycles[a][0] < cycles[b][0] })
+	return cycles
+}
+
+// dependsOn checks for a direct edge from line to dep
+func dependsOn(graph map[int][]int, line, dep int) bool {
+	for _, d := range graph[line] {
+		if d == dep {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedLines returns the line numbers of a set in ascending order
+func sortedLines(set map[int]bool) []int {
+	result := make([]int, 0, len(set))
+	for line := range set {
+		result = append(result, line)
+	}
+	sort.Ints(result)
+	return result
+}
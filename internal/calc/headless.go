@@ -0,0 +1,64 @@
+package calc
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	"smartcalc/internal/httpcheck"
+)
+
+// ErrNetworkDisabled is the result of a line that needs the network while
+// it is turned off
+var ErrNetworkDisabled = errors.New("network disabled")
+
+// networkOff turns off the lines that reach other hosts
+var networkOff atomic.Bool
+
+// SetNetworkDisabled turns the certificate, HTTP, DNS, WHOIS, GeoIP and
+// "my ip" lines off, so they show ErrNetworkDisabled instead of reaching
+// another host, or back on
+func SetNetworkDisabled(off bool) {
+	networkOff.Store(off)
+}
+
+// NetworkDisabled reports whether SetNetworkDisabled turned the network off
+func NetworkDisabled() bool {
+	return networkOff.Load()
+}
+
+// usesNetwork checks if an expression reaches another host: a network lookup
+// or an HTTP check
+func usesNetwork(expr string) bool {
+	return isNetworkLookup(expr) || httpcheck.IsHTTPExpression(expr)
+}
+
+// EvalText evaluates a whole document outside the editor, as the command line
+// does. Unlike EvalLines, lookups run again instead of keeping the results
+// their lines show. Windows line endings are read as plain ones. It returns
+// the evaluated text and the result of each line.
+func EvalText(text string) (string, []LineResult) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	results := evalLines(lines, 0, freshPass, nil)
+	outputs := make([]string, len(results))
+	for i, r := range results {
+		outputs[i] = r.Output
+	}
+	return strings.Join(outputs, "\n"), results
+}
+
+// HasError reports whether a line evaluated to an error, "2 +* 3 = ERR", or
+// shows one in a prose fragment or calc(...) span
+func (r LineResult) HasError() bool {
+	first, _, _ := strings.Cut(r.Output, "\n")
+	if isProseLine(first) {
+		for _, span := range proseFragments(first) {
+			if _, result := fragmentExpression(first[span[0]:span[1]]); strings.HasPrefix(result, "ERR") {
+				return true
+			}
+		}
+		return false
+	}
+	_, result, _, ok := SplitResult(first)
+	return ok && strings.HasPrefix(strings.TrimSpace(result), "ERR")
+}
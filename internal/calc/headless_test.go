@@ -0,0 +1,79 @@
+package calc
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"smartcalc/internal/httpcheck"
+)
+
+func TestEvalTextNetworkDisabled(t *testing.T) {
+	SetNetworkDisabled(true)
+	defer SetNetworkDisabled(false)
+
+	text := strings.Join([]string{
+		"2 + 2 =",
+		"dns example.com =",
+		"whois example.com =",
+		"cert decode example.com =",
+		"http status example.com =",
+		"geoip 8.8.8.8 = Mountain View, US",
+		"> Country: US",
+		"3 * 3 =",
+	}, "\n")
+	want := strings.Join([]string{
+		"2 + 2 = 4",
+		"dns example.com = ERR: network disabled",
+		"whois example.com = ERR: network disabled",
+		"cert decode example.com = ERR: network disabled",
+		"http status example.com = ERR: network disabled",
+		"geoip 8.8.8.8 = ERR: network disabled",
+		"3 * 3 = 9",
+	}, "\n")
+	got, results := EvalText(text)
+	if got != want {
+		t.Errorf("EvalText = %q, want %q", got, want)
+	}
+	for i, r := range results {
+		if wantErr := i >= 1 && i <= 5; r.HasError() != wantErr {
+			t.Errorf("line %d HasError = %v, want %v", i+1, r.HasError(), wantErr)
+		}
+	}
+}
+
+func TestEvalTextLooksUpAgain(t *testing.T) {
+	requests := 0
+	httpcheck.SetTransport(handlerTransport{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, "ok")
+	})})
+	defer httpcheck.SetTransport(nil)
+
+	text := "http status https://example.com = 500 Internal Server Error\n"
+	got, _ := EvalText(text)
+	if requests != 1 || strings.Contains(got, "500") {
+		t.Errorf("EvalText made %d requests and shows %q, want a fresh lookup", requests, got)
+	}
+	if !strings.HasSuffix(got, "\n") {
+		t.Errorf("EvalText dropped the final newline: %q", got)
+	}
+}
+
+func TestLineResultHasError(t *testing.T) {
+	results := EvalLines([]string{
+		"1 +* 2 =",
+		"10 / 4 =",
+		"Pay `1 +* 2 =` today",
+		"# budget calc(2 +* 3)",
+		"# budget calc(2 * 3)",
+		"no result here",
+	}, 0)
+	want := []bool{true, false, true, true, false, false}
+	for i, w := range want {
+		if got := results[i].HasError(); got != w {
+			t.Errorf("line %d %q HasError = %v, want %v", i+1, results[i].Output, got, w)
+		}
+	}
+}
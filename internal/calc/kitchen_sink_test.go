@@ -0,0 +1,244 @@
+package calc
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"smartcalc/internal/currency"
+	"smartcalc/internal/datetime"
+	"smartcalc/internal/httpcheck"
+	"smartcalc/internal/jwt"
+	"smartcalc/internal/network"
+	"smartcalc/internal/programmer"
+)
+
+// kitchenSinkNow is the time the kitchen sink document is evaluated at
+var kitchenSinkNow = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+// kitchenSinkWhois is the whois server's answer for every domain
+const kitchenSinkWhois = `   Domain Name: EXAMPLE.COM
+   Registrar: RESERVED-Internet Assigned Numbers Authority
+   Updated Date: 2025-08-14T07:01:39Z
+   Creation Date: 1995-08-14T04:00:00Z
+   Registry Expiry Date: 2027-08-13T04:00:00Z
+   Domain Status: clientDeleteProhibited https://icann.org/epp#clientDeleteProhibited
+   Name Server: A.IANA-SERVERS.NET
+   Name Server: B.IANA-SERVERS.NET
+`
+
+// handlerTransport answers HTTP requests with a handler instead of the network
+type handlerTransport struct{ h http.Handler }
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.h.ServeHTTP(rec, req)
+	return rec.Result(), nil
+}
+
+// kitchenSinkCertificate is a self-signed certificate for example.com. The
+// key comes from a fixed seed and Ed25519 signatures are deterministic, so
+// the certificate is the same on every run.
+func kitchenSinkCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(20261016),
+		Subject:      pkix.Name{CommonName: "example.com", Organization: []string{"Example Org"}, Country: []string{"US"}},
+		DNSNames:     []string{"example.com", "www.example.com"},
+		NotBefore:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(nil, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// countingReader yields the bytes 0, 1, 2, ... in place of random bytes
+type countingReader struct{ next byte }
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = r.next
+		r.next++
+	}
+	return len(p), nil
+}
+
+// stubKitchenSink replaces the network, the clock and the random source with
+// canned answers for the duration of the test
+func stubKitchenSink(t *testing.T) {
+	t.Helper()
+
+	local := time.Local
+	time.Local = time.UTC
+	datetime.SetClock(func() time.Time { return kitchenSinkNow })
+	programmer.SetRandSource(&countingReader{})
+
+	network.SetResolver(network.ResolverFunc(func(name string, qtype uint16) (*dns.Msg, error) {
+		records := map[string][]string{
+			"A example.com.":    {"example.com. 300 IN A 93.184.216.34"},
+			"AAAA example.com.": {"example.com. 300 IN AAAA 2606:2800:220:1:248:1893:25c8:1946"},
+			"MX example.com.":   {"example.com. 300 IN MX 10 mail.example.com."},
+			"NS example.com.":   {"example.com. 300 IN NS a.iana-servers.net."},
+			"TXT example.com.":  {`example.com. 300 IN TXT "v=spf1 -all"`},
+		}
+		m := new(dns.Msg)
+		for _, rr := range records[dns.TypeToString[qtype]+" "+dns.Fqdn(name)] {
+			parsed, err := dns.NewRR(rr)
+			if err != nil {
+				return nil, err
+			}
+			m.Answer = append(m.Answer, parsed)
+		}
+		return m, nil
+	}))
+
+	certificate := kitchenSinkCertificate(t)
+	network.SetDialer(func(kind, address string, _ time.Duration) (net.Conn, error) {
+		// Pings go over TCP, to a host that never answers, so that their
+		// round trips don't vary
+		if kind == "ip4:icmp" {
+			return nil, &net.OpError{Op: "dial", Net: kind, Err: os.NewSyscallError("socket", syscall.EPERM)}
+		}
+		if address == "10.0.0.1:443" {
+			return nil, &net.OpError{Op: "dial", Net: kind, Err: os.ErrDeadlineExceeded}
+		}
+		client, server := net.Pipe()
+		// Whois servers answer a query on port 43; other servers present
+		// the certificate to whoever starts a TLS handshake
+		if strings.HasSuffix(address, ":43") {
+			go func() {
+				defer server.Close()
+				if _, err := bufio.NewReader(server).ReadString('\n'); err == nil {
+					server.Write([]byte(kitchenSinkWhois))
+				}
+			}()
+			return client, nil
+		}
+		go func() {
+			conn := tls.Server(server, &tls.Config{Certificates: []tls.Certificate{certificate}})
+			defer conn.Close()
+			conn.Handshake()
+		}()
+		return client, nil
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("auth.example.com/.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"keys":[{"kty":"EC","kid":"kitchen-sink","use":"sig","crv":"P-256",`+
+			`"x":"PMyF6k0rRJrc7uEMQMgmHml9Zr8n35OnQS6ldlBDLGI","y":"rhJsRYv2Ys898cv3NVv0z-eU63HK_gebukzWRdTIoiQ"}]}`)
+	})
+	mux.HandleFunc("ip-api.com/json/", func(w http.ResponseWriter, r *http.Request) {
+		ip := strings.TrimPrefix(r.URL.Path, "/json/")
+		if ip == "" {
+			ip = "203.0.113.7"
+		}
+		fmt.Fprintf(w, `{"status":"success","country":"United States","countryCode":"US","region":"CA","regionName":"California",`+
+			`"city":"Mountain View","zip":"94043","lat":37.422,"lon":-122.084,"timezone":"America/Los_Angeles",`+
+			`"isp":"Google LLC","org":"Google Public DNS","as":"AS15169 Google LLC","query":%q}`, ip)
+	})
+	network.SetTransport(handlerTransport{mux})
+	jwt.SetTransport(handlerTransport{mux})
+	network.SetGeoIPProvider(nil) // forgets the locations looked up so far
+
+	httpcheck.SetTransport(handlerTransport{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Scheme == "http" {
+			http.Redirect(w, r, "https://"+r.URL.Host+"/", http.StatusMovedPermanently)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+		w.Header().Set("Cache-Control", "max-age=3600")
+		w.Header().Set("Date", kitchenSinkNow.Format(http.TimeFormat))
+		fmt.Fprint(w, "<html></html>")
+	})})
+
+	currency.SetCachePath(filepath.Join(t.TempDir(), "rates.json"))
+	currency.SetRateSource(func() (*currency.Rates, error) {
+		return &currency.Rates{Base: "USD", FetchedAt: kitchenSinkNow, Rates: map[string]float64{"USD": 1, "EUR": 0.92, "GBP": 0.79}}, nil
+	})
+
+	ResetCache()
+	t.Cleanup(func() {
+		time.Local = local
+		datetime.SetClock(nil)
+		programmer.SetRandSource(nil)
+		network.SetResolver(nil)
+		network.SetDialer(nil)
+		network.SetTransport(nil)
+		jwt.SetTransport(nil)
+		network.SetGeoIPProvider(nil)
+		httpcheck.SetTransport(nil)
+		currency.SetRateSource(nil)
+		currency.SetCachePath("")
+		ResetCache()
+	})
+}
+
+// TestEvalLinesKitchenSink evaluates testdata/kitchen_sink.scalc, a document
+// that exercises every evaluator, with the network, the clock and the random
+// source stubbed, and compares it with testdata/kitchen_sink.golden. A change
+// to any evaluator's output shows up here. Run with -update to rewrite it.
+func TestEvalLinesKitchenSink(t *testing.T) {
+	stubKitchenSink(t)
+
+	data, err := os.ReadFile(filepath.Join("testdata", "kitchen_sink.scalc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+
+	var sb strings.Builder
+	claimed := make(map[string]bool)
+	for _, r := range EvalLines(lines, 0) {
+		sb.WriteString(r.Output + "\n")
+		claimed[r.Evaluator] = true
+	}
+	got := sb.String()
+
+	// A new evaluator needs a line in the document
+	for _, ev := range dispatchTable() {
+		if !claimed[ev.name] {
+			t.Errorf("no line of kitchen_sink.scalc is evaluated by %q", ev.name)
+		}
+	}
+
+	golden := filepath.Join("testdata", "kitchen_sink.golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		gotLines, wantLines := strings.Split(got, "\n"), strings.Split(string(want), "\n")
+		for i := 0; i < len(gotLines) && i < len(wantLines); i++ {
+			if gotLines[i] != wantLines[i] {
+				t.Fatalf("output line %d = %q, want %q", i+1, gotLines[i], wantLines[i])
+			}
+		}
+		t.Fatalf("output has %d lines, want %d", len(gotLines), len(wantLines))
+	}
+}
@@ -0,0 +1,125 @@
+package calc
+
+import (
+	"regexp"
+	"strings"
+
+	"smartcalc/internal/eval"
+	"smartcalc/internal/utils"
+)
+
+// A ledger keeps a running balance over a block of transactions. Its
+// "balance start $2,400" line opens it, each line below that starts with a
+// sign and an amount is a transaction, and a blank line closes it:
+//
+//	balance start $2,400 = $2,400.00
+//	- $120 groceries = -$120.00 [bal $2,280.00]
+//	+ $50 refund = $50.00 [bal $2,330.00]
+var (
+	ledgerStartPattern = regexp.MustCompile(`(?i)^balance\s+start\s+(-?\p{Sc}\s?[\d.,]*\d)$`)
+	ledgerEntryPattern = regexp.MustCompile(`^([+-])\s*(\p{Sc}\s?[\d.,]*\d)(?:\s+(.*))?$`)
+)
+
+// isLedgerStart checks if an expression opens a ledger
+func isLedgerStart(expr string) bool {
+	return ledgerStartPattern.MatchString(expr)
+}
+
+// isLedgerEntry checks if an expression is a transaction, when a ledger is open
+func isLedgerEntry(expr string) bool {
+	return ledgerEntryPattern.MatchString(expr)
+}
+
+// isLedgerExpr checks if an expression is a ledger line, a start line or a
+// transaction
+func isLedgerExpr(expr string) bool {
+	return isLedgerStart(expr) || isLedgerEntry(expr)
+}
+
+// ledgerAmount reads the amount of a ledger line, "$2,400" or "-$120", in
+// the currency and decimal mark of the sheet
+func (d *document) ledgerAmount(amount string) (float64, bool) {
+	v, err := eval.EvalExprWithOptions(d.sheet.currencyInput(strings.ReplaceAll(amount, " ", "")), nil, nil, d.sheet.evalOptions())
+	return v, err == nil
+}
+
+// ledgerEntryAmount reads the signed amount of a transaction
+func (d *document) ledgerEntryAmount(expr string) (float64, bool) {
+	m := ledgerEntryPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return 0, false
+	}
+	v, ok := d.ledgerAmount(m[2])
+	if ok && m[1] == "-" {
+		v = -v
+	}
+	return v, ok
+}
+
+// ledgerLine reads line idx of a ledger: its start line, or a transaction
+// below one. The balance is added up from the start line down, so it holds
+// whichever lines above were evaluated again. ok is false for other lines;
+// the amount is that of the line, signed, and the balance the one after it.
+func (d *document) ledgerLine(idx int, expr string) (amount, balance float64, ok bool) {
+	if m := ledgerStartPattern.FindStringSubmatch(expr); m != nil {
+		amount, ok = d.ledgerAmount(m[1])
+		return amount, amount, ok
+	}
+	if amount, ok = d.ledgerEntryAmount(expr); !ok {
+		return 0, 0, false
+	}
+	balance = amount
+	for j := idx - 1; j >= 0 && strings.TrimSpace(d.lines[j]) != ""; j-- {
+		if d.dataRows[j] {
+			continue
+		}
+		above := lineExpression(d.lines[j])
+		if m := ledgerStartPattern.FindStringSubmatch(above); m != nil {
+			if start, ok := d.ledgerAmount(m[1]); ok {
+				return amount, start + balance, true
+			}
+		} else if v, ok := d.ledgerEntryAmount(above); ok {
+			balance += v
+		}
+	}
+	return 0, 0, false // no ledger open above
+}
+
+// ledgerResult shows the amount of a ledger line and, for a transaction, the
+// balance after it. Amounts read sign first, like a statement: -$120.00.
+func (d *document) ledgerResult(expr string, amount, balance float64) string {
+	if isLedgerStart(expr) {
+		return signedCurrency(d.sheet.format, amount)
+	}
+	return signedCurrency(d.sheet.format, amount) + " [bal " + signedCurrency(d.sheet.format, balance) + "]"
+}
+
+// signedCurrency writes a currency amount with its sign ahead of the symbol
+func signedCurrency(f utils.NumberFormat, v float64) string {
+	if v < 0 {
+		return "-" + f.Currency(-v)
+	}
+	return f.Currency(v)
+}
+
+// ledgerDependencies returns, for each transaction line (1-based), the
+// ledger line above it, whose balance it carries on
+func ledgerDependencies(lines []string) map[int]int {
+	deps := make(map[int]int)
+	prev := 0 // ledger line above, 0 outside a ledger
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			prev = 0
+			continue
+		}
+		expr := lineExpression(line)
+		switch {
+		case isLedgerStart(expr):
+			prev = i + 1
+		case prev > 0 && isLedgerEntry(expr):
+			deps[i+1] = prev
+			prev = i + 1
+		}
+	}
+	return deps
+}
@@ -0,0 +1,108 @@
+package calc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Outline entry kinds
+const (
+	OutlineHeading  = "heading"  // "## Budget" comment line
+	OutlineVariable = "variable" // "rent = $1800 =" assignment
+	OutlineLabel    = "label"    // line with a "#label: Annual rent" comment
+)
+
+// labelCommentPattern matches a "#label: Annual rent" comment
+var labelCommentPattern = regexp.MustCompile(`(?i)^#\s*label:\s*(.*?)\s*$`)
+
+// OutlineEntry is one item of a document's outline
+type OutlineEntry struct {
+	// ID identifies the entry across edits: it depends on the kind and title
+	// only, so inserting lines above an entry keeps its ID
+	ID    string `json:"id"`
+	Kind  string `json:"kind"`
+	Title string `json:"title"`
+	Line  int    `json:"line"`  // 1-based
+	Level int    `json:"level"` // 1 for "##", 2 for "###"; 0 for variables and labels
+}
+
+// GetOutline returns the headings, named variables and labeled lines of a
+// document in line order
+func GetOutline(lines []string) []OutlineEntry {
+	var entries []OutlineEntry
+	seen := make(map[string]int) // kind and title -> occurrences so far
+
+	add := func(kind, title string, lineNum, level int) {
+		key := kind + "\x00" + title
+		sum := sha256.Sum256([]byte(key))
+		id := hex.EncodeToString(sum[:6])
+		// Repeated titles are numbered in order of appearance
+		if n := seen[key]; n > 0 {
+			id = fmt.Sprintf("%s-%d", id, n+1)
+		}
+		seen[key]++
+		entries = append(entries, OutlineEntry{ID: id, Kind: kind, Title: title, Line: lineNum, Level: level})
+	}
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, ">") {
+			continue // output lines of a multi-line result
+		}
+		if IsCommentLine(line) {
+			if m := labelCommentPattern.FindStringSubmatch(trimmed); m != nil && m[1] != "" {
+				add(OutlineLabel, m[1], i+1, 0)
+			} else if title, level := headingTitle(trimmed); title != "" {
+				add(OutlineHeading, title, i+1, level)
+			}
+			continue
+		}
+		if name := lineAssignedVariable(line); name != "" {
+			add(OutlineVariable, name, i+1, 0)
+		}
+		if hashIdx := commentIndex(line); hashIdx >= 0 {
+			if m := labelCommentPattern.FindStringSubmatch(strings.TrimSpace(line[hashIdx:])); m != nil && m[1] != "" {
+				add(OutlineLabel, m[1], i+1, 0)
+			}
+		}
+	}
+	return entries
+}
+
+// headingTitle returns the title and level of a "## Budget" heading line, or
+// "" for other comment lines. "#" alone starts a plain comment.
+func headingTitle(trimmed string) (string, int) {
+	hashes := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+	if hashes < 2 {
+		return "", 0
+	}
+	return strings.TrimSpace(trimmed[hashes:]), hashes - 1
+}
+
+// FormatOutline renders an outline as text, one entry per line with its line
+// number. Entries are indented under the heading they follow.
+func FormatOutline(entries []OutlineEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	width := len(fmt.Sprint(entries[len(entries)-1].Line))
+	var sb strings.Builder
+	depth := 0
+	for _, e := range entries {
+		var text string
+		switch e.Kind {
+		case OutlineHeading:
+			depth = e.Level
+			text = strings.Repeat("  ", e.Level-1) + e.Title
+		case OutlineVariable:
+			text = strings.Repeat("  ", depth) + e.Title + " ="
+		default:
+			text = strings.Repeat("  ", depth) + "[" + e.Title + "]"
+		}
+		fmt.Fprintf(&sb, "%*d  %s\n", width, e.Line, text)
+	}
+	return sb.String()
+}
@@ -0,0 +1,107 @@
+package calc
+
+import "testing"
+
+func TestGetOutline(t *testing.T) {
+	lines := []string{
+		"# Household budget",
+		"## Income",
+		"salary = $5,000 = $5,000.00",
+		"bonus = $500 =",
+		"",
+		"## Expenses",
+		"### Housing",
+		"rent = $1800 =",
+		"rent * 12 = $21,600.00 #label: Annual rent",
+		"#label: Utilities",
+		"#FF5733 to rgb = rgb(255, 87, 51)",
+		"####",
+		"salary - rent * 12 =",
+		"> not a heading ## here",
+	}
+
+	got := GetOutline(lines)
+	want := []struct {
+		kind, title string
+		line, level int
+	}{
+		{OutlineHeading, "Income", 2, 1},
+		{OutlineVariable, "salary", 3, 0},
+		{OutlineVariable, "bonus", 4, 0},
+		{OutlineHeading, "Expenses", 6, 1},
+		{OutlineHeading, "Housing", 7, 2},
+		{OutlineVariable, "rent", 8, 0},
+		{OutlineLabel, "Annual rent", 9, 0},
+		{OutlineLabel, "Utilities", 10, 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("GetOutline returned %d entries, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		e := got[i]
+		if e.Kind != w.kind || e.Title != w.title || e.Line != w.line || e.Level != w.level {
+			t.Errorf("entry %d = %+v, want %+v", i, e, w)
+		}
+		if e.ID == "" {
+			t.Errorf("entry %d has no ID", i)
+		}
+	}
+}
+
+func TestGetOutlineStableIDs(t *testing.T) {
+	before := GetOutline([]string{"## Income", "salary = $5,000 =", "## Income"})
+	after := GetOutline([]string{"# notes", "", "## Income", "tax = 20% =", "salary = $5,000 =", "## Income"})
+
+	ids := func(entries []OutlineEntry) map[string]int {
+		m := make(map[string]int)
+		for _, e := range entries {
+			m[e.Kind+" "+e.Title+" "+e.ID] = e.Line
+		}
+		return m
+	}
+	beforeIDs, afterIDs := ids(before), ids(after)
+	for key := range beforeIDs {
+		if _, ok := afterIDs[key]; !ok {
+			t.Errorf("entry %q lost its ID after lines were inserted", key)
+		}
+	}
+	// Repeated headings get distinct IDs
+	if before[0].ID == before[2].ID {
+		t.Errorf("repeated headings share the ID %q", before[0].ID)
+	}
+}
+
+func TestGetOutlineWithoutStructure(t *testing.T) {
+	for _, lines := range [][]string{
+		nil,
+		{""},
+		{"2 + 2 = 4", "# just a comment", "\\1 * 3 = 12", "> output line"},
+	} {
+		if got := GetOutline(lines); len(got) != 0 {
+			t.Errorf("GetOutline(%q) = %+v, want no entries", lines, got)
+		}
+		if got := FormatOutline(GetOutline(lines)); got != "" {
+			t.Errorf("FormatOutline for %q = %q, want empty", lines, got)
+		}
+	}
+}
+
+func TestFormatOutline(t *testing.T) {
+	lines := make([]string, 12)
+	lines[0] = "## Income"
+	lines[1] = "salary = $5,000 ="
+	lines[3] = "### Bonus"
+	lines[4] = "bonus = $500 = # label: Q4"
+	lines[11] = "## Notes"
+
+	got := FormatOutline(GetOutline(lines))
+	want := " 1  Income\n" +
+		" 2    salary =\n" +
+		" 4    Bonus\n" +
+		" 5      bonus =\n" +
+		" 5      [Q4]\n" +
+		"12  Notes\n"
+	if got != want {
+		t.Errorf("FormatOutline =\n%s\nwant\n%s", got, want)
+	}
+}
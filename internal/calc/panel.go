@@ -0,0 +1,113 @@
+package calc
+
+import (
+	"regexp"
+	"strings"
+)
+
+// A "pin" keyword after a line's result puts the result in the pinned results
+// panel, which stays in view while the document scrolls:
+//
+//	monthly payment: loan $250000 at 6.5% for 30 years = pin
+//
+// Deleting the keyword unpins the line. Unlike "!pin", which freezes a
+// result, a line in the panel is evaluated like any other.
+const panelMarker = "pin"
+
+// panelMarkerPattern matches the "pin" keyword after a line's result
+var panelMarkerPattern = regexp.MustCompile(`(?:^|\s)pin\s*$`)
+
+// labelPrefixPattern matches the leading text that names a line:
+// "monthly payment: loan ..."
+var labelPrefixPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9 _'-]*?)\s*:\s`)
+
+// PinnedResult is a line shown in the pinned results panel
+type PinnedResult struct {
+	Line   int    `json:"line"` // 1-based
+	Label  string `json:"label"`
+	Result string `json:"result"`
+}
+
+// linePanelPin finds the "pin" keyword of a line (without its inline comment)
+// whose result '=' is at eq, and returns the line with it taken out
+func linePanelPin(workingLine string, eq int) (stripped string, ok bool) {
+	afterEq := workingLine[eq+1:]
+	loc := panelMarkerPattern.FindStringIndex(afterEq)
+	if loc == nil {
+		return workingLine, false
+	}
+	return workingLine[:eq+1] + afterEq[:loc[0]], true
+}
+
+// withoutPanelPin takes the "pin" keyword out of a line, keeping any inline
+// comment. ok is false when the line has no keyword.
+func withoutPanelPin(line string) (string, bool) {
+	workingLine := stripInlineComment(line)
+	eq := findResultEquals(workingLine)
+	if eq < 0 || isProseLine(line) {
+		return line, false
+	}
+	stripped, ok := linePanelPin(workingLine, eq)
+	if !ok {
+		return line, false
+	}
+	return stripped + line[len(workingLine):], true
+}
+
+// panelLabel names a pinned line in the panel: by its "#label:" or other
+// inline comment, the variable it assigns, the leading text before a colon,
+// or else its expression
+func panelLabel(line string) string {
+	if _, _, comment, ok := SplitResult(line); ok && comment != "" {
+		if m := labelCommentPattern.FindStringSubmatch(comment); m != nil && m[1] != "" {
+			return m[1]
+		}
+		if text := strings.TrimSpace(strings.TrimLeft(comment, "#")); text != "" {
+			return text
+		}
+	}
+	if name := lineAssignedVariable(line); name != "" {
+		return name
+	}
+	expr := lineExpression(line)
+	if m := labelPrefixPattern.FindStringSubmatch(expr); m != nil {
+		return m[1]
+	}
+	return expr
+}
+
+// panelResult returns the result of a line as the panel shows it, without
+// the "!pin" or "!track" markers after it
+func panelResult(line string) string {
+	workingLine := stripInlineComment(line)
+	eq := findResultEquals(workingLine)
+	if eq < 0 {
+		return ""
+	}
+	_, workingLine, _ = lineTrack(workingLine, eq)
+	result, _, _ := pinnedResult(workingLine, eq)
+	return result
+}
+
+// PinnedResults returns the lines of an evaluated document that end with the
+// "pin" keyword, with their labels and results, in line order. A line whose
+// result is shown on "> " lines below it is summed up by the first of them.
+// The document is read as it is; nothing is evaluated.
+func PinnedResults(lines []string) []PinnedResult {
+	var pinned []PinnedResult
+	for i, line := range lines {
+		if strings.HasPrefix(line, ">") {
+			continue
+		}
+		stripped, ok := withoutPanelPin(line)
+		if !ok {
+			continue
+		}
+		result := panelResult(stripped)
+		if result == "" && i+1 < len(lines) && strings.HasPrefix(lines[i+1], ">") {
+			result = strings.TrimSpace(strings.TrimPrefix(lines[i+1], ">"))
+		}
+		pinned = append(pinned, PinnedResult{Line: i + 1, Label: panelLabel(stripped), Result: result})
+	}
+	return pinned
+}
@@ -0,0 +1,98 @@
+package calc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// shownLines evaluates a document and returns it as the editor shows it,
+// with the "> " output lines of multi-line results
+func shownLines(doc []string) []string {
+	var shown []string
+	for _, r := range EvalLines(doc, 0) {
+		shown = append(shown, r.Output)
+	}
+	return strings.Split(strings.Join(shown, "\n"), "\n")
+}
+
+func TestEvalLinesPanelPin(t *testing.T) {
+	lines := []string{
+		"monthly payment: loan $250000 at 6.5% for 30 years = pin",
+		"rent = $1800 = pin # label: Rent",
+		"rent * 12 = pin",
+		"2 + 2 = # pin is part of the comment",
+		"x = 2 + 2 = !track pin",
+	}
+	results := EvalLines(lines, 0)
+
+	tests := []struct {
+		output string
+		pinned bool
+		label  string
+	}{
+		{"monthly payment: loan $250000 at 6.5% for 30 years = pin\n> Monthly: $1,580.17\n> Total: $568,861.22\n> Interest: $318,861.22", true, "monthly payment"},
+		{"rent = $1800 = $1,800.00 pin # label: Rent", true, "Rent"},
+		{"rent * 12 = $21,600.00 pin", true, "rent * 12"},
+		{"2 + 2 = 4 # pin is part of the comment", false, ""},
+		{"x = 2 + 2 = 4 !track pin", true, "x"},
+	}
+	for i, tt := range tests {
+		r := results[i]
+		if r.Output != tt.output || r.PanelPin != tt.pinned || r.Label != tt.label {
+			t.Errorf("line %d = %q (pinned %v, label %q), want %q (pinned %v, label %q)",
+				i+1, r.Output, r.PanelPin, r.Label, tt.output, tt.pinned, tt.label)
+		}
+	}
+
+	// Evaluating the shown document again keeps the keyword in one place
+	again := shownLines(shownLines(lines))
+	if got, want := strings.Join(again, "\n"), strings.Join(shownLines(lines), "\n"); got != want {
+		t.Errorf("second evaluation = %q, want %q", got, want)
+	}
+}
+
+func TestStripResultKeepsPanelPin(t *testing.T) {
+	tests := []struct {
+		line      string
+		stripped  string
+		hasResult bool
+	}{
+		{"rent * 12 = $21,600.00 pin", "rent * 12 = pin", true},
+		{"rent = $1800 = $1,800.00 pin # label: Rent", "rent = $1800 = pin # label: Rent", true},
+		{"rent * 12 = pin", "rent * 12 = pin", false},
+		{"now = 2025-07-15 09:30 UTC !pin pin", "now = 2025-07-15 09:30 UTC !pin pin", true},
+	}
+	for _, tt := range tests {
+		if got := StripResult(tt.line); got != tt.stripped {
+			t.Errorf("StripResult(%q) = %q, want %q", tt.line, got, tt.stripped)
+		}
+		if got := HasResult(tt.line); got != tt.hasResult {
+			t.Errorf("HasResult(%q) = %v, want %v", tt.line, got, tt.hasResult)
+		}
+		if _, ok := withoutPanelPin(StripResult(tt.line)); !ok {
+			t.Errorf("StripResult(%q) lost the pin keyword", tt.line)
+		}
+	}
+}
+
+func TestPinnedResults(t *testing.T) {
+	doc := shownLines([]string{
+		"monthly payment: loan $250000 at 6.5% for 30 years = pin",
+		"rent = $1800 = pin # label: Rent",
+		"",
+		"12v 2a = pin",
+		"2 + 2 =",
+		"now = 5 !pin pin",
+	})
+	got := PinnedResults(doc)
+	want := []PinnedResult{
+		{Line: 1, Label: "monthly payment", Result: "Monthly: $1,580.17"},
+		{Line: 5, Label: "Rent", Result: "$1,800.00"},
+		{Line: 7, Label: "12v 2a", Result: "Voltage: 12.000 V"},
+		{Line: 13, Label: "now", Result: "5"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PinnedResults = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,23 @@
+package calc
+
+import (
+	"strings"
+
+	"smartcalc/internal/datetime"
+)
+
+// setPresets collects the document's "#preset netting 45 days" lines. A
+// preset applies to the whole document; a later line of the same name wins.
+func (d *document) setPresets(lines []string) {
+	for _, line := range lines {
+		name, preset, ok := datetime.ParsePresetLine(line)
+		if !ok {
+			continue
+		}
+		if d.presets == nil {
+			d.presets = make(datetime.Presets)
+		}
+		d.presets[name] = preset
+		d.presetLines = append(d.presetLines, strings.TrimSpace(line))
+	}
+}
@@ -0,0 +1,135 @@
+package calc
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// slowLineThreshold is how long a line must take to be noted by "#profile"
+var slowLineThreshold = 10 * time.Millisecond
+
+// maxSlowLines is how many of the slowest lines the document stats report
+const maxSlowLines = 5
+
+// profileDirectivePattern matches the "#profile" comment line that turns on
+// timing notes for slow lines
+var profileDirectivePattern = regexp.MustCompile(`(?i)^\s*#\s*profile\s*$`)
+
+// tookPattern matches a timing note left by an earlier pass, at the end of a
+// result or as the last output line of a multi-line result
+var tookPattern = regexp.MustCompile(`(?:\n>)? \(took [^()]+\)$`)
+
+// SlowLine is one of the slowest lines of a document
+type SlowLine struct {
+	Line       int     `json:"line"`      // 1-based line number
+	Evaluator  string  `json:"evaluator"` // evaluator that claimed the line
+	DurationMs float64 `json:"durationMs"`
+	QueuedMs   float64 `json:"queuedMs"` // time waited for the deferred pass
+}
+
+// slowestLines returns the slowest evaluated lines, slowest first
+func slowestLines(results []LineResult, n int) []SlowLine {
+	var slow []SlowLine
+	for i, r := range results {
+		if r.Evaluator == "" {
+			continue
+		}
+		slow = append(slow, SlowLine{
+			Line:       i + 1,
+			Evaluator:  r.Evaluator,
+			DurationMs: float64(r.Duration) / float64(time.Millisecond),
+			QueuedMs:   float64(r.Queued) / float64(time.Millisecond),
+		})
+	}
+	sort.SliceStable(slow, func(a, b int) bool { return slow[a].DurationMs > slow[b].DurationMs })
+	if len(slow) > n {
+		slow = slow[:n]
+	}
+	return slow
+}
+
+// formatTook formats a duration for a timing note: "850µs", "250ms", "1.2s"
+func formatTook(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return fmt.Sprintf("%dµs", d.Microseconds())
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	default:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+}
+
+// annotateProfile notes "(took 1.2s)" on the lines of a "#profile" document
+// that took at least slowLineThreshold, ahead of any inline comment; deferred
+// network lines also note their time in the queue. Notes from an earlier pass
+// are replaced on every line that include accepts.
+func annotateProfile(results []LineResult, include func(i int) bool) {
+	profiling := false
+	for _, r := range results {
+		if profileDirectivePattern.MatchString(r.Output) {
+			profiling = true
+			break
+		}
+	}
+
+	for i := range results {
+		r := &results[i]
+		if r.Evaluator == "" || r.Pinned || !include(i) {
+			continue
+		}
+		r.Output = stripTook(r.Output)
+		if !profiling || r.Pending || r.Duration < slowLineThreshold {
+			continue
+		}
+
+		note := "(took " + formatTook(r.Duration)
+		if r.Queued > 0 {
+			note += ", queued " + formatTook(r.Queued)
+		}
+		note += ")"
+
+		if strings.Contains(r.Output, "\n") {
+			// Multi-line results note the time on a last output line, so the
+			// expression line stays free for keeping the previous output
+			r.Output += "\n> " + note
+			continue
+		}
+		if _, _, comment, ok := SplitResult(r.Output); ok && comment != "" {
+			idx := strings.LastIndex(r.Output, comment)
+			r.Output = strings.TrimRight(r.Output[:idx], " ") + " " + note + " " + r.Output[idx:]
+			continue
+		}
+		r.Output = strings.TrimRight(r.Output, " ") + " " + note
+	}
+}
+
+// stripTook removes a timing note left by an earlier pass
+func stripTook(output string) string {
+	if strings.Contains(output, "\n") {
+		return tookPattern.ReplaceAllString(output, "")
+	}
+	if _, _, comment, ok := SplitResult(output); ok && comment != "" {
+		idx := strings.LastIndex(output, comment)
+		head := strings.TrimRight(output[:idx], " ")
+		if !tookPattern.MatchString(head) {
+			return output
+		}
+		return tookPattern.ReplaceAllString(head, "") + " " + output[idx:]
+	}
+	return tookPattern.ReplaceAllString(output, "")
+}
+
+// noteQueueTime records how long the network lines looked up by a deferred
+// pass waited for it, and adds the wait to their timing notes
+func noteQueueTime(results []LineResult, queued time.Duration) {
+	for i := range results {
+		if results[i].fetched {
+			results[i].Queued = queued
+		}
+	}
+	annotateProfile(results, func(i int) bool { return results[i].fetched })
+}
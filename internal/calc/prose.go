@@ -0,0 +1,112 @@
+package calc
+
+import (
+	"regexp"
+	"strings"
+)
+
+// proseFragmentPattern matches a backticked fragment inside a prose line
+var proseFragmentPattern = regexp.MustCompile("`([^`]*)`")
+
+// proseFragments returns the spans (start, end of the text between the
+// backticks) of the fragments of a prose line that ask for a result, like
+// "The deposit is `$4500 * 0.1 =` due Friday". Other backticked text is left
+// alone. A '#' inside a fragment is never a comment. The fragments of a
+// comment line are its calc(...) spans, if it has any.
+func proseFragments(line string) [][2]int {
+	if IsCommentLine(line) {
+		if spans := commentCalcSpans(line); len(spans) > 0 {
+			return spans
+		}
+	}
+	var spans [][2]int
+	for _, m := range proseFragmentPattern.FindAllStringSubmatchIndex(line, -1) {
+		fragment := line[m[2]:m[3]]
+		if eq := findResultEquals(fragment); eq >= 0 && strings.TrimSpace(fragment[:eq]) != "" {
+			spans = append(spans, [2]int{m[2], m[3]})
+		}
+	}
+	return spans
+}
+
+// isProseLine checks if a line is prose with backticked fragments to evaluate
+func isProseLine(line string) bool {
+	return len(proseFragments(line)) > 0
+}
+
+// fragmentExpression splits a fragment like "$4500 * 0.1 = $450.00" into its
+// expression and current result. A calc(...) span not evaluated yet is all
+// expression.
+func fragmentExpression(fragment string) (expr, result string) {
+	eq := findResultEquals(fragment)
+	if eq < 0 {
+		return strings.TrimSpace(fragment), ""
+	}
+	return strings.TrimSpace(fragment[:eq]), strings.TrimSpace(fragment[eq+1:])
+}
+
+// proseExpressions returns the expressions of the fragments of a prose line
+func proseExpressions(line string) []string {
+	var exprs []string
+	for _, span := range proseFragments(line) {
+		expr, _ := fragmentExpression(line[span[0]:span[1]])
+		exprs = append(exprs, expr)
+	}
+	return exprs
+}
+
+// replaceFragments rewrites each fragment of a prose line with fn, leaving the
+// prose around the backticks untouched
+func replaceFragments(line string, fn func(expr, result string) string) string {
+	var sb strings.Builder
+	last := 0
+	for _, span := range proseFragments(line) {
+		expr, result := fragmentExpression(line[span[0]:span[1]])
+		sb.WriteString(line[last:span[0]])
+		sb.WriteString(fn(expr, result))
+		last = span[1]
+	}
+	sb.WriteString(line[last:])
+	return sb.String()
+}
+
+// commentCalcPattern matches the start of a calc(...) span in a comment line
+var commentCalcPattern = regexp.MustCompile(`(?:^|[^\w.])calc\(`)
+
+// commentCalcSpans returns the spans (start, end of the text between the
+// parentheses) of the calc(...) spans of a comment line, like
+// "# budget: calc(3*450 + 120)". Parentheses inside a span nest; a span that
+// is never closed is left alone.
+func commentCalcSpans(line string) [][2]int {
+	var spans [][2]int
+	for from := 0; from < len(line); {
+		loc := commentCalcPattern.FindStringIndex(line[from:])
+		if loc == nil {
+			break
+		}
+		start := from + loc[1]
+		end, depth := start, 1
+		for ; end < len(line); end++ {
+			if line[end] == '(' {
+				depth++
+			} else if line[end] == ')' {
+				if depth--; depth == 0 {
+					break
+				}
+			}
+		}
+		if depth > 0 {
+			break
+		}
+		if strings.TrimSpace(line[start:end]) != "" {
+			spans = append(spans, [2]int{start, end})
+		}
+		from = end + 1
+	}
+	return spans
+}
+
+// isCommentCalcLine checks if a comment line has calc(...) spans to evaluate
+func isCommentCalcLine(line string) bool {
+	return IsCommentLine(line) && len(commentCalcSpans(line)) > 0
+}
@@ -0,0 +1,119 @@
+package calc
+
+import (
+	"strings"
+	"sync"
+
+	"smartcalc/internal/cert"
+	"smartcalc/internal/network"
+	"smartcalc/internal/registry"
+	"smartcalc/internal/utils"
+)
+
+// NetworkRefreshWorkers bounds how many lookups RefreshNetworkLines runs at once
+const NetworkRefreshWorkers = 4
+
+// prefetched is the result of a network lookup made ahead of an evaluation
+// pass, used by the pass instead of looking the line up again
+type prefetched struct {
+	evaluator string
+	expr      string
+	result    utils.Result
+	err       error
+}
+
+// isNetworkLookup checks if an expression looks something up on another
+// host: a certificate, DNS records, a WHOIS entry, the location of an IP or
+// the public IP itself, or checks that a host or one of its ports answers
+func isNetworkLookup(expr string) bool {
+	return cert.IsCertExpression(expr) ||
+		network.IsDNSExpression(expr) ||
+		network.IsPingExpression(expr) ||
+		network.IsPortExpression(expr) ||
+		network.IsWhoisExpression(expr) ||
+		network.IsGeoIPExpression(expr) ||
+		network.IsMyIPExpression(expr)
+}
+
+// StripNetworkResults removes the results of network lookup lines, with their
+// "> " output lines, so that they are looked up again instead of kept. It
+// returns the stripped document and the 0-based indexes of the lookup lines
+// among its expression lines, as EvalLines numbers them. Pinned lines keep
+// their results.
+func StripNetworkResults(lines []string) ([]string, []int) {
+	var stripped []string
+	var lookups []int
+	skipOutput := false
+	exprIdx := 0 // index of the line among the expression lines
+	for _, line := range lines {
+		if strings.HasPrefix(line, ">") {
+			if !skipOutput {
+				stripped = append(stripped, line)
+			}
+			continue
+		}
+		skipOutput = false
+		if !isPinnedLine(line) && isNetworkLookup(lineExpression(line)) {
+			line = StripResult(line)
+			lookups = append(lookups, exprIdx)
+			skipOutput = true
+		}
+		stripped = append(stripped, line)
+		exprIdx++
+	}
+	return stripped, lookups
+}
+
+// RefreshNetworkLines looks up every network line of a document again, as
+// EvalLines keeps their previous results. The lookups run concurrently on at
+// most workers goroutines, and progress, if set, is called after each one
+// with the number done so far. The document is then evaluated in one pass
+// with the fresh results; a failed lookup shows its error on its own line.
+func RefreshNetworkLines(lines []string, workers int, progress func(done, total int)) []LineResult {
+	stripped, lookups := StripNetworkResults(lines)
+	cleaned := cleanOutputLines(stripped)
+	disabled := documentDisabled(cleaned)
+
+	type job struct {
+		idx  int
+		ev   registry.Evaluator
+		expr string
+	}
+	var jobs []job
+	for _, idx := range lookups {
+		expr := lineExpression(cleaned[idx])
+		if utils.DecimalComma() {
+			expr = utils.CanonicalNumbers(expr) // as evalRegistered offers it
+		}
+		if ev, ok := networkEvaluator(expr, disabled); ok {
+			jobs = append(jobs, job{idx, ev, expr})
+		}
+	}
+
+	found := make(map[int]prefetched, len(jobs))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan job)
+	for range min(max(workers, 1), len(jobs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				r, err := j.ev.Eval(j.expr)
+				mu.Lock()
+				found[j.idx] = prefetched{evaluator: j.ev.Name, expr: j.expr, result: r, err: err}
+				if progress != nil {
+					progress(len(found), len(jobs))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, j := range jobs {
+		queue <- j
+	}
+	close(queue)
+	wg.Wait()
+
+	return evalLines(stripped, 0, fullPass, found)
+}
@@ -0,0 +1,67 @@
+package calc
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestStripNetworkResults(t *testing.T) {
+	lines := []string{
+		"dig example.com =",
+		"> A: 93.184.216.34",
+		"2 + 2 = 4",
+		"range 1 to 3 =",
+		"> kept",
+		"whois example.com = old # registrar",
+		"geoip 8.8.8.8 =* Mountain View",
+	}
+	stripped, lookups := StripNetworkResults(lines)
+	want := []string{
+		"dig example.com =",
+		"2 + 2 = 4",
+		"range 1 to 3 =",
+		"> kept",
+		"whois example.com = # registrar",
+		"geoip 8.8.8.8 =* Mountain View",
+	}
+	if !reflect.DeepEqual(stripped, want) {
+		t.Errorf("StripNetworkResults() lines = %q, want %q", stripped, want)
+	}
+	// Indexes count expression lines only; the pinned lookup is left alone
+	if !reflect.DeepEqual(lookups, []int{0, 3}) {
+		t.Errorf("StripNetworkResults() lookups = %v, want [0 3]", lookups)
+	}
+}
+
+func TestRefreshNetworkLines(t *testing.T) {
+	// Private addresses fail without reaching the network
+	lines := []string{
+		"geoip 192.168.1.1 = Somewhere",
+		"2 + 2 = 4",
+		"geoip 10.0.0.1 = Elsewhere",
+		"\\2 * 3 =",
+	}
+	var mu sync.Mutex
+	var calls [][2]int
+	results := RefreshNetworkLines(lines, 2, func(done, total int) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, [2]int{done, total})
+	})
+
+	expected := []string{
+		"geoip 192.168.1.1 = ERR",
+		"2 + 2 = 4",
+		"geoip 10.0.0.1 = ERR",
+		"\\2 * 3 = 12",
+	}
+	for i, want := range expected {
+		if results[i].Output != want {
+			t.Errorf("line %d output = %q, want %q", i+1, results[i].Output, want)
+		}
+	}
+	if !reflect.DeepEqual(calls, [][2]int{{1, 2}, {2, 2}}) {
+		t.Errorf("progress calls = %v, want [[1 2] [2 2]]", calls)
+	}
+}
@@ -0,0 +1,75 @@
+package calc
+
+import (
+	"sync"
+	"time"
+)
+
+// DeferredDelay is how long the document must be idle before the deferred
+// (network) evaluation pass runs
+const DeferredDelay = 400 * time.Millisecond
+
+// EvalFunc evaluates document lines; EvalLines is the deferred pass
+type EvalFunc func(lines []string, activeLineNum int) []LineResult
+
+// DeferredScheduler debounces the deferred evaluation pass. Every Schedule
+// supersedes the previous one: a pending pass is cancelled and the results of
+// a pass that is already running are discarded instead of delivered.
+type DeferredScheduler struct {
+	mu         sync.Mutex
+	delay      time.Duration
+	eval       EvalFunc
+	timer      *time.Timer
+	generation uint64
+}
+
+// NewDeferredScheduler creates a scheduler that runs eval after delay.
+// A nil eval uses EvalLines.
+func NewDeferredScheduler(delay time.Duration, eval EvalFunc) *DeferredScheduler {
+	if eval == nil {
+		eval = EvalLines
+	}
+	return &DeferredScheduler{delay: delay, eval: eval}
+}
+
+// Schedule evaluates lines once the scheduler has been idle for its delay and
+// passes the results to deliver, unless a later Schedule or Cancel supersedes it.
+// deliver runs on the scheduler's goroutine.
+func (s *DeferredScheduler) Schedule(lines []string, activeLineNum int, deliver func([]LineResult)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.generation++
+	gen := s.generation
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	scheduled := time.Now()
+	s.timer = time.AfterFunc(s.delay, func() {
+		queued := time.Since(scheduled)
+		results := s.eval(lines, activeLineNum)
+		noteQueueTime(results, queued)
+		if s.current(gen) {
+			deliver(results)
+		}
+	})
+}
+
+// Cancel drops any scheduled or running deferred pass
+func (s *DeferredScheduler) Cancel() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.generation++
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
+
+// current reports whether gen is still the latest scheduled pass
+func (s *DeferredScheduler) current(gen uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return gen == s.generation
+}
@@ -0,0 +1,140 @@
+package calc
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowEval is a fake deferred pass that takes a while and records call order
+type slowEval struct {
+	mu    sync.Mutex
+	delay time.Duration
+	calls []string
+}
+
+func (e *slowEval) eval(lines []string, activeLineNum int) []LineResult {
+	e.mu.Lock()
+	e.calls = append(e.calls, lines[0])
+	e.mu.Unlock()
+	time.Sleep(e.delay)
+	return []LineResult{{Output: lines[0] + " = done"}}
+}
+
+func TestDeferredSchedulerDebounces(t *testing.T) {
+	fake := &slowEval{delay: 10 * time.Millisecond}
+	s := NewDeferredScheduler(30*time.Millisecond, fake.eval)
+
+	delivered := make(chan string, 3)
+	deliver := func(r []LineResult) { delivered <- r[0].Output }
+
+	// Rapid keystrokes: only the last one should be evaluated
+	s.Schedule([]string{"a"}, 1, deliver)
+	s.Schedule([]string{"ab"}, 1, deliver)
+	s.Schedule([]string{"abc"}, 1, deliver)
+
+	select {
+	case got := <-delivered:
+		if got != "abc = done" {
+			t.Errorf("delivered %q, want %q", got, "abc = done")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("deferred pass was never delivered")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if len(delivered) != 0 {
+		t.Errorf("unexpected extra delivery %q", <-delivered)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if strings.Join(fake.calls, ",") != "abc" {
+		t.Errorf("evaluated %v, want only [abc]", fake.calls)
+	}
+}
+
+func TestDeferredSchedulerDiscardsSuperseded(t *testing.T) {
+	fake := &slowEval{delay: 80 * time.Millisecond}
+	s := NewDeferredScheduler(10*time.Millisecond, fake.eval)
+
+	var mu sync.Mutex
+	var delivered []string
+	done := make(chan struct{})
+	deliver := func(r []LineResult) {
+		mu.Lock()
+		delivered = append(delivered, r[0].Output)
+		mu.Unlock()
+		close(done)
+	}
+
+	s.Schedule([]string{"old"}, 1, deliver)
+	time.Sleep(30 * time.Millisecond) // "old" is now running
+	s.Schedule([]string{"new"}, 1, deliver)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("deferred pass was never delivered")
+	}
+	time.Sleep(100 * time.Millisecond) // let "old" finish
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(delivered) != 1 || delivered[0] != "new = done" {
+		t.Errorf("delivered %v, want only [new = done]", delivered)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if strings.Join(fake.calls, ",") != "old,new" {
+		t.Errorf("evaluated %v, want [old new] in order", fake.calls)
+	}
+}
+
+func TestDeferredSchedulerCancel(t *testing.T) {
+	fake := &slowEval{}
+	s := NewDeferredScheduler(20*time.Millisecond, fake.eval)
+
+	delivered := make(chan string, 1)
+	s.Schedule([]string{"a"}, 1, func(r []LineResult) { delivered <- r[0].Output })
+	s.Cancel()
+
+	time.Sleep(60 * time.Millisecond)
+	if len(delivered) != 0 {
+		t.Errorf("cancelled pass delivered %q", <-delivered)
+	}
+}
+
+func TestEvalLinesFastDefersNetworkLines(t *testing.T) {
+	lines := []string{
+		"2 + 3 =",
+		"dig example.com =",
+		"whois example.com = Registrar: Example",
+		"geoip 8.8.8.8 =",
+	}
+
+	start := time.Now()
+	results := EvalLinesFast(lines, 0)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("fast pass took %v, expected no network access", elapsed)
+	}
+
+	if results[0].Output != "2 + 3 = 5" || results[0].Pending {
+		t.Errorf("line 1 = %q (pending %v), want local result", results[0].Output, results[0].Pending)
+	}
+	if results[1].Output != "dig example.com = "+PendingResult || !results[1].Pending {
+		t.Errorf("line 2 = %q (pending %v), want pending marker", results[1].Output, results[1].Pending)
+	}
+	if results[2].Output != lines[2] || results[2].Pending {
+		t.Errorf("line 3 = %q (pending %v), want existing result kept", results[2].Output, results[2].Pending)
+	}
+	if results[3].Output != "geoip 8.8.8.8 = "+PendingResult || !results[3].Pending {
+		t.Errorf("line 4 = %q (pending %v), want pending marker", results[3].Output, results[3].Pending)
+	}
+	if !HasPending(results) {
+		t.Error("HasPending = false, want true")
+	}
+	if HasPending(EvalLinesFast([]string{"2 + 3 ="}, 1)) {
+		t.Error("HasPending = true for a document without network lines")
+	}
+}
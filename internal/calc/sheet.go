@@ -0,0 +1,211 @@
+package calc
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/currency"
+	"smartcalc/internal/eval"
+	"smartcalc/internal/utils"
+)
+
+// sheetSettings are what "@" directive lines set: "@precision 4",
+// "@currency EUR" and "@angle degrees". A directive applies to the lines
+// below it.
+type sheetSettings struct {
+	format  utils.NumberFormat
+	degrees bool // trig functions work in degrees
+}
+
+// defaultSheetSettings apply above the first directive
+var defaultSheetSettings = sheetSettings{format: utils.DefaultNumberFormat}
+
+// errPrecisionRange rejects a precision directive or hint out of range
+var errPrecisionRange = fmt.Errorf("precision is 0 to %d decimal places", utils.MaxDecimals)
+
+// isDirectiveLine checks if a line is a sheet directive such as "@precision 4"
+func isDirectiveLine(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "@")
+}
+
+// apply returns the settings with a directive applied. A bad directive leaves
+// them unchanged.
+func (s sheetSettings) apply(directive string) (sheetSettings, error) {
+	name, value, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(directive), "@"), " ")
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(name) {
+	case "precision":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || n > utils.MaxDecimals {
+			return s, errPrecisionRange
+		}
+		s.format.Decimals = n
+	case "currency":
+		symbol, ok := currency.SymbolFor(value)
+		if !ok {
+			return s, fmt.Errorf("unknown currency %q", value)
+		}
+		s = s.withCurrency(symbol)
+	case "angle":
+		switch strings.ToLower(value) {
+		case "degrees", "degree", "deg":
+			s.degrees = true
+		case "radians", "radian", "rad":
+			s.degrees = false
+		default:
+			return s, fmt.Errorf("angle is degrees or radians")
+		}
+	default:
+		return s, fmt.Errorf("unknown directive @%s", name)
+	}
+	return s, nil
+}
+
+// directives returns the directive lines that reproduce s, or none for the
+// defaults
+func (s sheetSettings) directives() []string {
+	var lines []string
+	if s.format.Decimals != defaultSheetSettings.format.Decimals {
+		lines = append(lines, fmt.Sprintf("@precision %d", s.format.Decimals))
+	}
+	if s.format.Symbol != defaultSheetSettings.format.Symbol {
+		lines = append(lines, "@currency "+strings.TrimSpace(s.format.Symbol))
+	}
+	if s.degrees {
+		lines = append(lines, "@angle degrees")
+	}
+	return lines
+}
+
+// decimalCommaAmountPattern matches an amount written with a decimal comma
+// right after its currency symbol: "€1.200,50", "€99,95"
+var decimalCommaAmountPattern = regexp.MustCompile(`\p{Sc}(?:\d{1,3}(?:\.\d{3})+,\d+|\d+,\d{1,2})\b`)
+
+// withCurrency returns the settings amounts written with symbol are shown
+// with: "€" amounts in euros with cents, "¥" amounts in whole yen
+func (s sheetSettings) withCurrency(symbol string) sheetSettings {
+	s.format.Symbol = symbol
+	s.format.WholeCurrency = !currency.HasCents(symbol)
+	return s
+}
+
+// currencyInput writes amounts in the sheet's currency ("€250") as the "$"
+// amounts arithmetic understands. An amount written the European way,
+// "€1.200,50" or "€99,95", is read with its decimal comma.
+func (s sheetSettings) currencyInput(expr string) string {
+	if s.format.Symbol == "$" {
+		return expr
+	}
+	if !s.format.DecimalComma {
+		expr = decimalCommaAmountPattern.ReplaceAllStringFunc(expr, func(amount string) string {
+			if !strings.HasPrefix(amount, s.format.Symbol) {
+				return amount
+			}
+			return utils.CanonicalNumbers(amount)
+		})
+	}
+	return strings.ReplaceAll(expr, s.format.Symbol, "$")
+}
+
+// numberInput writes numbers typed with decimal commas ("1.234,56",
+// "avg(1,5; 2,5)") the way evaluators other than arithmetic read them
+func (s sheetSettings) numberInput(expr string) string {
+	if !s.format.DecimalComma {
+		return expr
+	}
+	return utils.CanonicalNumbers(expr)
+}
+
+// evalOptions returns the options arithmetic is evaluated with
+func (s sheetSettings) evalOptions() eval.Options {
+	return eval.Options{Degrees: s.degrees, DecimalComma: s.format.DecimalComma}
+}
+
+// withPrecision returns the settings a line with a precision hint is shown
+// with. The hint applies to currency amounts too.
+func (s sheetSettings) withPrecision(decimals int) sheetSettings {
+	s.format.Decimals = decimals
+	s.format.ExactCurrency = true
+	return s
+}
+
+// precisionMarkerPattern matches a precision hint at the start of the text
+// after a line's result '=': "1/3 = :6" shows 6 decimal places
+var precisionMarkerPattern = regexp.MustCompile(`^\s*(:(\d+))(?:\s|$)`)
+
+// precisionSuffixPattern matches a precision hint ending an expression:
+// "1/3 to 6 dp", "pi to 4 decimal places"
+var precisionSuffixPattern = regexp.MustCompile(`(?i)\s+to\s+(\d+)\s*(?:dp|decimals?|decimal\s+places)$`)
+
+// precisionHint is a line's own number of decimal places, as written on it
+type precisionHint struct {
+	decimals int
+	marker   string // ":6" after the result '=', or empty
+	suffix   string // " to 6 dp" ending the expression, or empty
+}
+
+// precisionMarkerEnd returns where a ":6" precision hint at the start of the
+// text after a line's result '=' (and the "*" of a pinned line) ends, or 0
+// if there is none
+func precisionMarkerEnd(afterEq string) int {
+	start := 0
+	if strings.HasPrefix(afterEq, "*") {
+		start = 1
+	}
+	if m := precisionMarkerPattern.FindStringSubmatchIndex(afterEq[start:]); m != nil {
+		return start + m[3]
+	}
+	return 0
+}
+
+// linePrecision reads the precision hint of a line (without its inline
+// comment) whose result '=' is at eq
+func linePrecision(workingLine string, eq int) (precisionHint, bool) {
+	afterEq := workingLine[eq+1:]
+	if end := precisionMarkerEnd(afterEq); end > 0 {
+		m := precisionMarkerPattern.FindStringSubmatch(strings.TrimPrefix(afterEq, "*"))
+		n, _ := strconv.Atoi(m[2])
+		return precisionHint{decimals: n, marker: m[1]}, true
+	}
+	if loc := precisionSuffixPattern.FindStringSubmatchIndex(strings.TrimRight(workingLine[:eq], " \t")); loc != nil {
+		expr := strings.TrimRight(workingLine[:eq], " \t")
+		n, _ := strconv.Atoi(expr[loc[2]:loc[3]])
+		return precisionHint{decimals: n, suffix: expr[loc[0]:]}, true
+	}
+	return precisionHint{}, false
+}
+
+// strip takes the hint off a line's expression and the text after its '='
+func (h precisionHint) strip(expr, afterEq string) (string, string) {
+	if h.suffix != "" {
+		return strings.TrimSpace(strings.TrimSuffix(expr, h.suffix)), afterEq
+	}
+	return expr, strings.Replace(afterEq, h.marker, "", 1)
+}
+
+// restore writes the hint back into an evaluated line, unless the line kept
+// it as typed
+func (h precisionHint) restore(output string) string {
+	first, rest, multiLine := strings.Cut(output, "\n")
+	eq := findResultEquals(stripInlineComment(first))
+	if eq < 0 {
+		return output
+	}
+	if h.suffix != "" {
+		if expr := strings.TrimRight(first[:eq], " \t"); !strings.HasSuffix(expr, h.suffix) {
+			first = expr + h.suffix + " " + first[eq:]
+		}
+	} else if precisionMarkerEnd(first[eq+1:]) == 0 {
+		at := eq + 1
+		if strings.HasPrefix(first[at:], "*") {
+			at++
+		}
+		first = first[:at] + " " + h.marker + first[at:]
+	}
+	if multiLine {
+		return first + "\n" + rest
+	}
+	return first
+}
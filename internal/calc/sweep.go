@@ -0,0 +1,163 @@
+package calc
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/utils"
+)
+
+// maxSweepSteps caps the number of rows a what-if table evaluates
+const maxSweepSteps = 50
+
+// sweepPattern matches what-if tables like
+// "table rate from 5% to 8% step 0.5%: loan $300000 at rate for 30 years"
+var sweepPattern = regexp.MustCompile(`(?i)^table\s+([a-z_][a-z0-9_]*)\s+from\s+(\S+)\s+to\s+(\S+)\s+step\s+([^:\s]+)\s*:\s*(.+)$`)
+
+// sweep is a parsed what-if table
+type sweep struct {
+	name     string   // placeholder substituted into the template
+	values   []string // placeholder values, written like the "from" bound
+	template string   // expression evaluated once per value
+}
+
+// sweepNumber parses a table bound like "5%", "$1,000" or "0.5" and returns
+// its value with the "$" prefix or "%" suffix it was written with
+func sweepNumber(s string) (v float64, prefix, suffix string, err error) {
+	if strings.HasPrefix(s, "$") {
+		prefix, s = "$", s[1:]
+	}
+	if strings.HasSuffix(s, "%") {
+		suffix, s = "%", s[:len(s)-1]
+	}
+	v, err = utils.ParseNumber(s, utils.DecimalComma())
+	if err != nil {
+		return 0, "", "", fmt.Errorf("invalid table bound %q", prefix+s+suffix)
+	}
+	return v, prefix, suffix, nil
+}
+
+// parseSweep parses a what-if table expression. ok is false when expr is not a
+// table at all; err reports a malformed range or template.
+func parseSweep(expr string) (sw sweep, ok bool, err error) {
+	m := sweepPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return sweep{}, false, nil
+	}
+	sw.name, sw.template = m[1], strings.TrimSpace(m[5])
+
+	from, prefix, suffix, err := sweepNumber(m[2])
+	if err != nil {
+		return sw, true, err
+	}
+	to, _, _, err := sweepNumber(m[3])
+	if err != nil {
+		return sw, true, err
+	}
+	step, _, _, err := sweepNumber(m[4])
+	if err != nil {
+		return sw, true, err
+	}
+	if step == 0 || (to-from)/step < 0 {
+		return sw, true, fmt.Errorf("step must move from %s to %s", m[2], m[3])
+	}
+	steps := int(math.Floor((to-from)/step+1e-9)) + 1
+	if steps > maxSweepSteps {
+		return sw, true, fmt.Errorf("too many steps (%d), at most %d", steps, maxSweepSteps)
+	}
+	if !sweepPlaceholder(sw.name).MatchString(sw.template) {
+		return sw, true, fmt.Errorf("%s is not used in the expression", sw.name)
+	}
+
+	for k := 0; k < steps; k++ {
+		// Round away float drift so 5 + 0.1*3 prints as 5.3
+		v := math.Round((from+float64(k)*step)*1e9) / 1e9
+		sw.values = append(sw.values, prefix+utils.LocalizeNumber(strconv.FormatFloat(v, 'f', -1, 64))+suffix)
+	}
+	return sw, true, nil
+}
+
+// sweepPlaceholder matches the placeholder name as a whole word
+func sweepPlaceholder(name string) *regexp.Regexp {
+	return regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+}
+
+// evalSweep evaluates the template once per value with evalOne and returns the
+// "> " table of value vs result
+func evalSweep(sw sweep, evalOne func(expr string) string) string {
+	placeholder := sweepPlaceholder(sw.name)
+	width := utils.DisplayWidth(sw.name)
+	for _, v := range sw.values {
+		width = max(width, utils.DisplayWidth(v))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n> %s | result", utils.PadRight(sw.name, width))
+	for _, v := range sw.values {
+		expr := placeholder.ReplaceAllLiteralString(sw.template, v)
+		fmt.Fprintf(&sb, "\n> %s | %s", utils.PadRight(v, width), evalOne(expr))
+	}
+	return sb.String()
+}
+
+// evalInContext evaluates expr as a line of its own through the normal
+// evaluator chain. Variables and line references of the document are carried
+// over as literal values, and its directives still apply.
+func (d *document) evalInContext(expr string) LineResult {
+	expr = lineRefPattern.ReplaceAllStringFunc(expr, func(match string) string {
+		n, _ := strconv.Atoi(match[1:])
+		if n < 1 || n > len(d.values) || !d.haveRes[n-1] {
+			return match
+		}
+		return sweepLiteral(d.lineSymbol(n-1), d.values[n-1])
+	})
+
+	names := make([]string, 0, len(d.vars))
+	for name := range d.vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names)+2)
+	for _, directive := range []string{disableDirective(d.disabled), d.gradeScaleLine} {
+		if directive != "" {
+			lines = append(lines, directive)
+		}
+	}
+	lines = append(lines, d.presetLines...)
+	lines = append(lines, d.sheet.directives()...)
+	for _, name := range names {
+		lines = append(lines, name+" = "+sweepLiteral(d.varSymbol(name), d.vars[name])+" =")
+	}
+	lines = append(lines, expr+" =")
+
+	return evalLines(lines, 0, d.mode, nil)[len(lines)-1]
+}
+
+// primaryResult returns a line result as a single value: the result of a
+// one-line output, or the primary value of a multi-line one
+func primaryResult(r LineResult) string {
+	first, output, multiLine := strings.Cut(r.Output, "\n")
+	if _, result, _, ok := SplitResult(first); ok && result != "" {
+		return result
+	}
+	if !multiLine || !r.HasResult {
+		return "ERR"
+	}
+	if r.Value != 0 {
+		return utils.FormatResult(r.IsCurrency, r.Value)
+	}
+	// No primary value: show the first output line instead
+	first, _, _ = strings.Cut(output, "\n")
+	return strings.TrimSpace(strings.TrimPrefix(first, ">"))
+}
+
+// sweepLiteral writes a value so every evaluator can parse it back: plain
+// digits with the current decimal mark, after the currency symbol of an
+// amount ("" for other values)
+func sweepLiteral(symbol string, v float64) string {
+	return symbol + utils.LocalizeNumber(strconv.FormatFloat(v, 'f', -1, 64))
+}
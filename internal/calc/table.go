@@ -0,0 +1,144 @@
+package calc
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// tableQueryPattern matches queries on the pasted table right above them:
+// "table count", "table sum col 3", "table avg column 2", "table total price"
+var tableQueryPattern = regexp.MustCompile(`(?i)^table\s+(?:(count)|(sum|total|avg|average)\s+(?:col(?:umn)?\s+(\d+)|(.+)))$`)
+
+// tableCellSeparator splits a pasted table row into cells: a gap of two or
+// more spaces, or a tab
+var tableCellSeparator = regexp.MustCompile(`\s{2,}|\t`)
+
+// pastedTable is a block of aligned text rows, like a table copied from a
+// spreadsheet or a web page
+type pastedTable struct {
+	header []string // column names; nil when the first row holds data
+	rows   [][]string
+}
+
+// tableRow splits a line into cells. ok is false for lines that are not plain
+// table rows: blank lines, comments, output and lines with a result '='.
+func tableRow(line string) (cells []string, ok bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || IsCommentLine(line) || strings.HasPrefix(trimmed, ">") || findResultEquals(trimmed) >= 0 {
+		return nil, false
+	}
+	cells = tableCellSeparator.Split(trimmed, -1)
+	return cells, len(cells) >= 2
+}
+
+// isTableQueryLine checks if a line queries the pasted table above it
+func isTableQueryLine(line string) bool {
+	return tableQueryPattern.MatchString(lineExpression(line))
+}
+
+// tableAbove returns the pasted table that ends right above line idx (0-based),
+// skipping other table queries stacked below it, and the index of its first row.
+// All rows must have the same number of columns.
+func tableAbove(lines []string, idx int) (t pastedTable, start int, ok bool) {
+	end := idx
+	for end > 0 && isTableQueryLine(lines[end-1]) {
+		end--
+	}
+
+	start, columns := end, 0
+	for start > 0 {
+		cells, isRow := tableRow(lines[start-1])
+		if !isRow || (columns > 0 && len(cells) != columns) {
+			break
+		}
+		columns = len(cells)
+		start--
+		t.rows = append([][]string{cells}, t.rows...)
+	}
+	if len(t.rows) == 0 {
+		return pastedTable{}, 0, false
+	}
+
+	// A first row without any numbers is the header
+	if len(t.rows) > 1 && !rowHasNumber(t.rows[0]) {
+		t.header, t.rows = t.rows[0], t.rows[1:]
+	}
+	return t, start, true
+}
+
+// tableCell parses a plain-number or currency cell like "3", "1,250" or "$1.20"
+func tableCell(cell string) (v float64, isCurrency, ok bool) {
+	s := strings.TrimSpace(cell)
+	if strings.HasPrefix(s, "$") {
+		s, isCurrency = s[1:], true
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	return v, isCurrency, err == nil
+}
+
+// rowHasNumber checks if any cell of a row is a number
+func rowHasNumber(cells []string) bool {
+	for _, c := range cells {
+		if _, _, ok := tableCell(c); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// column resolves a 1-based column number or a header name to a cell index
+func (t pastedTable) column(num, name string) (int, error) {
+	if num != "" {
+		n, _ := strconv.Atoi(num)
+		if n < 1 || n > len(t.rows[0]) {
+			return 0, fmt.Errorf("no column %d", n)
+		}
+		return n - 1, nil
+	}
+	for i, h := range t.header {
+		if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(name)) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no column %s", name)
+}
+
+// evalTableQuery evaluates a table query on line idx. ok is false when expr is
+// not a table query; err reports a missing table, column or numbers.
+func evalTableQuery(lines []string, idx int, expr string) (val float64, isCurrency, ok bool, err error) {
+	m := tableQueryPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return 0, false, false, nil
+	}
+	t, _, found := tableAbove(lines, idx)
+	if !found {
+		return 0, false, true, fmt.Errorf("no table above")
+	}
+	if m[1] != "" {
+		return float64(len(t.rows)), false, true, nil
+	}
+
+	col, err := t.column(m[3], m[4])
+	if err != nil {
+		return 0, false, true, err
+	}
+	sum, count := 0.0, 0
+	for _, row := range t.rows {
+		v, cur, isNum := tableCell(row[col])
+		if !isNum {
+			continue // text cells (labels, notes) don't count
+		}
+		sum += v
+		count++
+		isCurrency = isCurrency || cur
+	}
+	if count == 0 {
+		return 0, false, true, fmt.Errorf("column %d has no numbers", col+1)
+	}
+	if strings.HasPrefix(strings.ToLower(m[2]), "av") {
+		return sum / float64(count), isCurrency, true, nil
+	}
+	return sum, isCurrency, true, nil
+}
@@ -0,0 +1,210 @@
+package calc
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"smartcalc/internal/utils"
+)
+
+// TrackHistoryLimit is how many history entries a "!track" line keeps unless
+// its marker says otherwise ("!track 5")
+const TrackHistoryLimit = 12
+
+// trackMarkerPattern matches the "!track" or "!track 5" marker after a
+// line's result
+var trackMarkerPattern = regexp.MustCompile(`(?:^|\s)!track(?:\s+(\d+))?\s*$`)
+
+// historyLinePattern matches a history line of a tracked line,
+// "> 2025-03-01: $1,234", with the sparkline the first one ends with
+var historyLinePattern = regexp.MustCompile(`^>\s*(\d{4}-\d{2}-\d{2}):\s*(.*?)(?:\s{2,}[▁▂▃▄▅▆▇█ ]+)?$`)
+
+// historyNumberPattern matches the number a history entry is charted by
+var historyNumberPattern = regexp.MustCompile(`-?\d+(?:\.\d+)?`)
+
+// sparkBars are the levels of a sparkline, lowest first
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// trackedLine is a "!track" line being evaluated: its marker, put back once
+// the result is known, and its history lines, kept below the fresh output
+type trackedLine struct {
+	marker  string
+	history []string
+}
+
+// lineTrack finds the "!track" marker of a line (without its inline comment)
+// whose result '=' is at eq. It returns the marker as typed and the line
+// with it taken out.
+func lineTrack(workingLine string, eq int) (marker, stripped string, ok bool) {
+	afterEq := workingLine[eq+1:]
+	loc := trackMarkerPattern.FindStringIndex(afterEq)
+	if loc == nil {
+		return "", workingLine, false
+	}
+	return strings.TrimSpace(afterEq[loc[0]:]), workingLine[:eq+1] + afterEq[:loc[0]], true
+}
+
+// isHistoryLine checks if an output line is a "> 2025-03-01: value" entry
+func isHistoryLine(line string) bool {
+	return historyLinePattern.MatchString(line)
+}
+
+// splitHistory separates the history lines of a tracked line's output lines
+// from the rest
+func splitHistory(outputLines []string) (history, rest []string) {
+	for _, line := range outputLines {
+		if isHistoryLine(line) {
+			history = append(history, line)
+		} else {
+			rest = append(rest, line)
+		}
+	}
+	return history, rest
+}
+
+// restore puts the marker back into a freshly evaluated line, ahead of any
+// inline comment, and its history lines below the output
+func (t trackedLine) restore(output string) string {
+	output = insertResultMarker(output, t.marker)
+	if len(t.history) > 0 {
+		output += "\n" + strings.Join(t.history, "\n")
+	}
+	return output
+}
+
+// historyEntry is one dated value of a tracked line
+type historyEntry struct {
+	date  string
+	value string
+}
+
+// RecordHistory adds the result of every "!track" line to its history, dated
+// today: "> 2025-03-01: $1,234". A second save on the same day updates the
+// day's entry instead of adding another. Only the last TrackHistoryLimit
+// entries are kept, or as many as the marker asks for ("!track 5"), and the
+// first line ends with a sparkline of the values. Lines without a result, or
+// with an error, are left alone. lines is the document as shown, with its
+// "> " output lines.
+func RecordHistory(lines []string, today time.Time) []string {
+	date := today.Format("2006-01-02")
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		out = append(out, line)
+		if strings.HasPrefix(line, ">") {
+			continue
+		}
+		j := i + 1
+		for j < len(lines) && strings.HasPrefix(lines[j], ">") {
+			j++
+		}
+		outputLines := lines[i+1 : j]
+		limit, value, ok := trackedValue(line)
+		if !ok {
+			continue
+		}
+		i = j - 1
+
+		history, rest := splitHistory(outputLines)
+		entries := parseHistory(history)
+		if n := len(entries); n > 0 && entries[n-1].date == date {
+			entries[n-1].value = value
+		} else {
+			entries = append(entries, historyEntry{date, value})
+		}
+		if len(entries) > limit {
+			entries = entries[len(entries)-limit:]
+		}
+		out = append(out, rest...)
+		out = append(out, formatHistory(entries)...)
+	}
+	return out
+}
+
+// trackedValue returns the history limit and the result of a tracked line
+// that has a result worth recording
+func trackedValue(line string) (limit int, value string, ok bool) {
+	workingLine := stripInlineComment(line)
+	eq := findResultEquals(workingLine)
+	if eq < 0 || isProseLine(line) {
+		return 0, "", false
+	}
+	marker, stripped, tracked := lineTrack(workingLine, eq)
+	if !tracked {
+		return 0, "", false
+	}
+	limit = TrackHistoryLimit
+	if m := trackMarkerPattern.FindStringSubmatch(" " + marker); m[1] != "" {
+		if n, err := strconv.Atoi(m[1]); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	_, value, _, _ = SplitResult(stripped)
+	if value == "" || value == PendingResult || strings.HasPrefix(value, "ERR") {
+		return 0, "", false
+	}
+	return limit, value, true
+}
+
+// parseHistory reads the entries of history lines
+func parseHistory(history []string) []historyEntry {
+	var entries []historyEntry
+	for _, line := range history {
+		m := historyLinePattern.FindStringSubmatch(line)
+		entries = append(entries, historyEntry{date: m[1], value: m[2]})
+	}
+	return entries
+}
+
+// formatHistory renders history entries as "> " lines, the first with a
+// sparkline of all of them once there are two
+func formatHistory(entries []historyEntry) []string {
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = "> " + e.date + ": " + e.value
+	}
+	if len(entries) > 1 {
+		if spark := sparkline(entries); spark != "" {
+			lines[0] += "  " + spark
+		}
+	}
+	return lines
+}
+
+// sparkline draws the values of history entries as bars from ▁ (lowest) to
+// █ (highest). An entry without a number leaves a gap; a flat series is all ▁.
+func sparkline(entries []historyEntry) string {
+	values := make([]float64, len(entries))
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i, e := range entries {
+		values[i] = math.NaN()
+		text := e.value
+		if utils.DecimalComma() {
+			text = utils.CanonicalNumbers(text)
+		}
+		if m := historyNumberPattern.FindString(strings.ReplaceAll(text, ",", "")); m != "" {
+			values[i], _ = strconv.ParseFloat(m, 64)
+			lo, hi = math.Min(lo, values[i]), math.Max(hi, values[i])
+		}
+	}
+	if math.IsInf(lo, 1) {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		switch {
+		case math.IsNaN(v):
+			sb.WriteRune(' ')
+		case hi == lo:
+			sb.WriteRune(sparkBars[0])
+		default:
+			level := int(math.Round((v - lo) / (hi - lo) * float64(len(sparkBars)-1)))
+			sb.WriteRune(sparkBars[level])
+		}
+	}
+	return strings.TrimRight(sb.String(), " ")
+}
@@ -0,0 +1,111 @@
+package calc
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// saveCycle evaluates a document as the editor shows it and saves it on day,
+// returning the document as written
+func saveCycle(doc []string, day time.Time) []string {
+	var shown []string
+	for _, r := range EvalLines(doc, 0) {
+		shown = append(shown, r.Output)
+	}
+	return RecordHistory(strings.Split(strings.Join(shown, "\n"), "\n"), day)
+}
+
+func TestTrackHistorySaveCycle(t *testing.T) {
+	day := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	doc := []string{"balance = 1000 + 234 = !track 3 # checking", "\\1 * 2 ="}
+
+	doc = saveCycle(doc, day)
+	want := []string{
+		"balance = 1000 + 234 = 1,234 !track 3 # checking",
+		"> 2025-03-01: 1,234",
+		"\\1 * 2 = 2,468",
+	}
+	if strings.Join(doc, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("first save = %q, want %q", doc, want)
+	}
+
+	// A later save the same day updates the day's entry
+	doc[0] = "balance = 1000 + 300 = 1,234 !track 3 # checking"
+	doc = saveCycle(doc, day.Add(8*time.Hour))
+	if doc[1] != "> 2025-03-01: 1,300" || len(doc) != 3 {
+		t.Fatalf("same-day save = %q, want the entry updated", doc)
+	}
+
+	// Weekly saves add entries, oldest dropped past the limit of 3
+	for week, expr := range []string{"1000 + 500", "1000 + 100", "1000 + 900"} {
+		doc[0] = "balance = " + expr + " = 0 !track 3 # checking"
+		doc = saveCycle(doc, day.AddDate(0, 0, 7*(week+1)))
+	}
+	want = []string{
+		"balance = 1000 + 900 = 1,900 !track 3 # checking",
+		"> 2025-03-08: 1,500  ▅▁█",
+		"> 2025-03-15: 1,100",
+		"> 2025-03-22: 1,900",
+		"\\1 * 2 = 3,800",
+	}
+	if strings.Join(doc, "\n") != strings.Join(want, "\n") {
+		t.Errorf("after weekly saves = %q, want %q", doc, want)
+	}
+}
+
+func TestEvalLinesKeepsTrackHistory(t *testing.T) {
+	lines := []string{
+		"rent = 1800 + 50 = 1,800 !track",
+		"> 2025-03-01: 1,800  ▁█",
+		"> 2025-04-01: 1,850",
+		"rent * 12 =",
+	}
+
+	// Evaluating the line keeps its history and marker; the reference skips
+	// the history lines
+	results := EvalLines(lines, 0)
+	want := "rent = 1800 + 50 = 1,850 !track\n> 2025-03-01: 1,800  ▁█\n> 2025-04-01: 1,850"
+	if results[0].Output != want {
+		t.Errorf("tracked line = %q, want %q", results[0].Output, want)
+	}
+	if results[1].Output != "rent * 12 = 22,200" {
+		t.Errorf("line 2 = %q, want the tracked value used", results[1].Output)
+	}
+
+	// So does typing on another line
+	if got := EvalLines(lines, 2)[0].Output; got != strings.Join(lines[:3], "\n") {
+		t.Errorf("inactive tracked line = %q, want it kept as typed", got)
+	}
+}
+
+func TestRecordHistorySkipsErrors(t *testing.T) {
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	lines := []string{"1 / 0 = ERR !track", "2 + 2 = 4", "> 2025-02-01: 4"}
+	if got := RecordHistory(lines, day); strings.Join(got, "\n") != strings.Join(lines, "\n") {
+		t.Errorf("RecordHistory = %q, want untracked and failing lines left alone", got)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   string
+	}{
+		{[]string{"1", "2", "3", "4", "5", "6", "7", "8"}, "▁▂▃▄▅▆▇█"},
+		{[]string{"$1,200.50", "$900", "$1,500"}, "▅▁█"},
+		{[]string{"45 days", "38 days", "31 days"}, "█▅▁"},
+		{[]string{"5", "5"}, "▁▁"},
+		{[]string{"3", "pending", "1"}, "█ ▁"},
+		{[]string{"n/a", "none"}, ""},
+	}
+	for _, tt := range tests {
+		var entries []historyEntry
+		for _, v := range tt.values {
+			entries = append(entries, historyEntry{date: "2025-03-01", value: v})
+		}
+		if got := sparkline(entries); got != tt.want {
+			t.Errorf("sparkline(%v) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
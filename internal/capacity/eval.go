@@ -0,0 +1,221 @@
+package capacity
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/datetime"
+	"smartcalc/internal/units"
+	"smartcalc/internal/utils"
+)
+
+// Parts of the patterns: a rate such as "2500/s" or "2.5k per minute", a
+// duration such as "30 days" or "a week", and an amount of data such as "5 KB"
+const (
+	timeUnit     = `(s|secs?|seconds?|mins?|minutes?|h|hrs?|hours?|d|days?|w|weeks?|months?|y|yrs?|years?)`
+	countRate    = `(\d+(?:\.\d+)?)\s*(k|m)?\s*(?:/\s*|per\s+)` + timeUnit
+	durationPart = `(\d+(?:\.\d+)?|an?)\s*` + timeUnit
+	dataAmount   = `(\d+(?:\.\d+)?)\s*([a-z]+)`
+	dataNouns    = `(?:storage|data|disk|disk\s+space|space|traffic|transfer|bandwidth)`
+)
+
+// countPattern matches "events at 2500/s for 1 day"
+var countPattern = regexp.MustCompile(`^(?:([a-z][a-z ]*?)\s+)?at\s+` + countRate + `\s+for\s+` + durationPart + `$`)
+
+// storagePattern matches "storage for 5 KB per event at 2000/s for 30 days"
+var storagePattern = regexp.MustCompile(`^` + dataNouns + `\s+(?:for|of)\s+` + dataAmount + `(?:\s*/\s*|\s+(?:per|an?|each)\s+)([a-z]+)\s+at\s+` + countRate + `\s+for\s+` + durationPart + `$`)
+
+// dataRatePattern matches "data at 50 MB/s for 1 day"
+var dataRatePattern = regexp.MustCompile(`^` + dataNouns + `\s+(?:for|at|of)\s+` + dataAmount + `\s*(?:/\s*|per\s+)` + timeUnit + `\s+for\s+` + durationPart + `$`)
+
+// runwayPattern matches "how long until 10 TB at 50 GB/day" and "runway for
+// 10 TB at 50 GB/day"
+var runwayPattern = regexp.MustCompile(`^(?:how\s+long\s+(?:until|till|to\s+fill)|runway\s+(?:for|of))\s+` + dataAmount + `(?:\s+(?:is\s+)?full)?\s+at\s+` + dataAmount + `\s*(?:/\s*|per\s+)` + timeUnit + `$`)
+
+// dutyCyclePattern matches the duty cycle of "data at 50 MB/s for 8
+// hours/day for 30 days"
+var dutyCyclePattern = regexp.MustCompile(`\s+for\s+(` + datetime.DutyCyclePattern + `)\s+for\s+`)
+
+// countSuffixes scale a rate written "2.5k/s" or "1m per day"
+var countSuffixes = map[string]float64{"": 1, "k": 1e3, "m": 1e6}
+
+// IsCapacityExpression checks if an expression multiplies a rate by a
+// duration, or divides a capacity by a rate
+func IsCapacityExpression(expr string) bool {
+	expr, _ = splitDutyCycle(utils.NormalizeExpr(expr))
+	return countPattern.MatchString(expr) || storagePattern.MatchString(expr) ||
+		dataRatePattern.MatchString(expr) || runwayPattern.MatchString(expr)
+}
+
+// EvalCapacity evaluates a back-of-envelope capacity plan: the events a rate
+// adds up to over a duration, the storage they take, or how long a capacity
+// lasts at a rate. Storage is shown in both SI (TB) and IEC (TiB) units. A
+// rate that only runs part of the time, "for 8 hours/day for 30 days", adds
+// up over that share of the duration.
+func EvalCapacity(expr string) (utils.Result, error) {
+	expr, cycle := splitDutyCycle(utils.NormalizeExpr(expr))
+	share, err := dutyShare(cycle)
+	if err != nil {
+		return utils.Result{}, err
+	}
+	if m := storagePattern.FindStringSubmatch(expr); m != nil {
+		size, err := dataBytes(m[1], m[2])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		count, err := countOver(m[4], m[5], m[6], m[7], m[8])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		count *= share
+		total := size * count
+		text := fmt.Sprintf("%s (%s)", units.FormatBytes(total), countText(count, pluralNoun(m[3])))
+		return utils.ValueResult(text, total, false), nil
+	}
+	if m := dataRatePattern.FindStringSubmatch(expr); m != nil {
+		rate, err := dataBytes(m[1], m[2])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		per, err := datetime.ParseAmountDuration("1", m[3])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		d, err := datetime.ParseAmountDuration(m[4], m[5])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		total := rate * d.Seconds() / per.Seconds() * share
+		return utils.ValueResult(units.FormatBytes(total), total, false), nil
+	}
+	if m := countPattern.FindStringSubmatch(expr); m != nil {
+		count, err := countOver(m[2], m[3], m[4], m[5], m[6])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		count *= share
+		text := countText(count, m[1])
+		if count >= 1000 {
+			text += " (" + utils.FormatResult(false, math.Round(count)) + ")"
+		}
+		return utils.ValueResult(text, count, false), nil
+	}
+	if m := runwayPattern.FindStringSubmatch(expr); m != nil {
+		capacity, err := dataBytes(m[1], m[2])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		rate, err := dataBytes(m[3], m[4])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		per, err := datetime.ParseAmountDuration("1", m[5])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		if rate == 0 {
+			return utils.Result{}, fmt.Errorf("a rate of zero never fills %s %s", m[1], m[2])
+		}
+		days := capacity / rate * per.Hours() / 24
+		return utils.ValueResult(runwayText(days), days, false), nil
+	}
+	return utils.Result{}, fmt.Errorf("invalid capacity expression")
+}
+
+// splitDutyCycle takes the duty cycle out of "... for 8 hours/day for 30
+// days", leaving "... for 30 days" and "8 hours/day", or "" without one
+func splitDutyCycle(expr string) (rest, cycle string) {
+	m := dutyCyclePattern.FindStringSubmatchIndex(expr)
+	if m == nil {
+		return expr, ""
+	}
+	return expr[:m[0]] + " for " + expr[m[1]:], expr[m[2]:m[3]]
+}
+
+// dutyShare reads the share of the time a duty cycle runs, all of it
+// without one
+func dutyShare(cycle string) (float64, error) {
+	if cycle == "" {
+		return 1, nil
+	}
+	return datetime.ParseDutyCycle(cycle)
+}
+
+// countOver multiplies a rate such as "2.5k/s" by a duration such as "30 days"
+func countOver(rate, suffix, rateUnit, amount, unit string) (float64, error) {
+	n, _ := strconv.ParseFloat(rate, 64)
+	per, err := datetime.ParseAmountDuration("1", rateUnit)
+	if err != nil {
+		return 0, err
+	}
+	d, err := datetime.ParseAmountDuration(amount, unit)
+	if err != nil {
+		return 0, err
+	}
+	return n * countSuffixes[suffix] * d.Seconds() / per.Seconds(), nil
+}
+
+// dataBytes converts an amount of data such as "5 KB" to bytes
+func dataBytes(amount, unit string) (float64, error) {
+	v, _ := strconv.ParseFloat(amount, 64)
+	b, ok := units.DataInBytes(v, unit)
+	if !ok {
+		return 0, fmt.Errorf("unknown data unit %q", unit)
+	}
+	return b, nil
+}
+
+// countScales are the short scale suffixes of large counts
+var countScales = []struct {
+	factor float64
+	suffix string
+}{
+	{1e12, "T"}, {1e9, "B"}, {1e6, "M"}, {1e3, "K"},
+}
+
+// countText shows a count in human form, "216 M events"
+func countText(count float64, noun string) string {
+	text := utils.FormatResult(false, math.Round(count))
+	for _, s := range countScales {
+		if count >= s.factor {
+			text = utils.FormatResult(false, roundTo(count/s.factor, 2)) + " " + s.suffix
+			break
+		}
+	}
+	if noun != "" {
+		text += " " + noun
+	}
+	return text
+}
+
+// pluralNoun writes the thing stored per unit in the plural: "event" -> "events"
+func pluralNoun(noun string) string {
+	if strings.HasSuffix(noun, "s") {
+		return noun
+	}
+	return noun + "s"
+}
+
+// runwayText shows how long a capacity lasts: "200 days", or "12 hours" for
+// less than a day, with years added past a year
+func runwayText(days float64) string {
+	if days < 1 {
+		hours := roundTo(days*24, 1)
+		return utils.FormatResult(false, hours) + " " + utils.Plural(hours, "hour", "hours")
+	}
+	d := roundTo(days, 1)
+	text := utils.FormatResult(false, d) + " " + utils.Plural(d, "day", "days")
+	if days >= 365.25 {
+		text += fmt.Sprintf(" (%s years)", utils.FormatResult(false, roundTo(days/365.25, 1)))
+	}
+	return text
+}
+
+// roundTo rounds v to places decimals
+func roundTo(v float64, places int) float64 {
+	scale := math.Pow10(places)
+	return math.Round(v*scale) / scale
+}
@@ -0,0 +1,90 @@
+package capacity
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestIsCapacityExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"events at 2500/s for 1 day", true},
+		{"Storage for 5 KB per event at 2000/s for 30 days", true},
+		{"how long until 10 TB at 50 GB/day", true},
+		{"data at 50 MB/s for 1 day", true},
+		{"runway for 1 PB at 1 TB per week", true},
+
+		// Dates and conversions are left alone
+		{"how long until christmas", false},
+		{"10 GB to MiB", false},
+		{"meeting at 10:30 for 1 hour", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsCapacityExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsCapacityExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalCapacity(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+		value    float64
+	}{
+		{"events at 2500/s for 1 day", "216 M events (216,000,000)", 216e6},
+		{"requests at 2.5k per minute for a week", "25.2 M requests (25,200,000)", 25.2e6},
+		{"logs at 3/s for 1 min", "180 logs", 180},
+		{"storage for 5 KB per event at 2000/s for 30 days", "25.92 TB / 23.57 TiB (5.18 B events)", 25.92e12},
+		{"disk space for 1 MiB/upload at 10/h for 1 year", "91.92 GB / 85.61 GiB (87.66 K uploads)", 87660 * 1048576},
+		{"data at 50 MB/s for 1 day", "4.32 TB / 3.93 TiB", 4.32e12},
+		{"how long until 10 TB at 50 GB/day", "200 days", 200},
+		{"how long until 10 TB is full at 50 GB/day", "200 days", 200},
+		{"runway for 1 PB at 1 TB/week", "7,000 days (19.2 years)", 7000},
+		{"how long to fill 100 GB at 10 GB/h", "10 hours", 10.0 / 24},
+		// A duty cycle counts only the hours the rate runs
+		{"events at 2500/s for 8 hours/day for 1 day", "72 M events (72,000,000)", 72e6},
+		{"data at 50 MB/s for 24/7 for 1 day", "4.32 TB / 3.93 TiB", 4.32e12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			r, err := EvalCapacity(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalCapacity(%q) error: %v", tt.expr, err)
+			}
+			if r.Text != tt.expected {
+				t.Errorf("EvalCapacity(%q) = %q, want %q", tt.expr, r.Text, tt.expected)
+			}
+			if !r.HasValue || math.Abs(r.Value-tt.value) > 1e-6*tt.value {
+				t.Errorf("EvalCapacity(%q) value = %v, want %v", tt.expr, r.Value, tt.value)
+			}
+		})
+	}
+}
+
+func TestEvalCapacityErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"storage for 5 zz per event at 2000/s for 30 days", `unknown data unit "zz"`},
+		{"how long until 10 TB at 0 GB/day", "a rate of zero never fills 10 tb"},
+		{"events at 2500/s for 30 hours/day for 1 day", "a day has 24 hours"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := EvalCapacity(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("EvalCapacity(%q) error = %v, want %q", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package capacity
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// Unknown data units are reported instead of left to unit conversions
+	// and dates, which would claim "for 30 days" and "how long until".
+	registry.Register(registry.Evaluator{
+		Name:     "capacity",
+		Priority: registry.PriorityCapacity,
+		Traits:   registry.ReportsErrors,
+		Detect:   IsCapacityExpression,
+		Eval:     EvalCapacity,
+	})
+}
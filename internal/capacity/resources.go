@@ -0,0 +1,141 @@
+package capacity
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/datetime"
+	"smartcalc/internal/utils"
+)
+
+// Parts of the resource patterns: a count of replicas such as "3 pods x", an
+// amount that may be a line reference such as "\1", and the CPU and memory
+// units of Kubernetes resource requests. A CPU amount always names cpu or
+// cores, as "250m" alone would be meters.
+const (
+	resourceAmount = `(\\\d+|\d+(?:\.\d+)?)`
+	replicas       = `(?:` + resourceAmount + `\s*(?:pods?|replicas?|containers?|instances?|nodes?|vms?)?\s*(?:x|×|\*)\s*)?`
+	cpuNoun        = `(?:v?cpus?|cores?)`
+	memorySuffix   = `(ki|mi|gi|ti)(?:b)?`
+	memoryNoun     = `(?:\s*(?:memory|mem|ram))?`
+	costUnit       = `(v?cpu|core|gib|gi)`
+	costPeriod     = `(h|hr|hours?|d|days?|months?)`
+)
+
+// cpuPattern matches "3 pods x 250m cpu" and "1500m cores"
+var cpuPattern = regexp.MustCompile(`^` + replicas + resourceAmount + `\s*(m)?\s*` + cpuNoun + `$`)
+
+// memoryPattern matches "12 pods x 512 MiB" and "512Mi memory"
+var memoryPattern = regexp.MustCompile(`^` + replicas + resourceAmount + `\s*` + memorySuffix + memoryNoun + `$`)
+
+// resourceCostPattern matches "0.75 cores at $0.031/core-hour for 30 days" and
+// "6 GiB at $0.004 per GiB-hour for a month"
+var resourceCostPattern = regexp.MustCompile(`^` + resourceAmount + `\s*(m)?\s*(` + cpuNoun + `|` + memorySuffix + memoryNoun + `)\s+at\s+\$\s*(\d+(?:\.\d+)?)\s*(?:/\s*|per\s+)` + costUnit + `[- ]?` + costPeriod + `\s+for\s+` + durationPart + `$`)
+
+// memoryFactors are the binary (IEC) multiples of memory requests in GiB
+var memoryFactors = map[string]float64{
+	"ki": 1.0 / (1 << 20), "mi": 1.0 / (1 << 10), "gi": 1, "ti": 1 << 10,
+}
+
+// IsResourceExpression checks if an expression adds up the CPU or memory
+// requests of replicas, or prices a resource by the hour
+func IsResourceExpression(expr string) bool {
+	expr, _ = splitDutyCycle(utils.NormalizeExpr(expr))
+	return cpuPattern.MatchString(expr) || memoryPattern.MatchString(expr) || resourceCostPattern.MatchString(expr)
+}
+
+// EvalResources evaluates Kubernetes-style resource math: the cores of CPU
+// requests in millicores ("3 pods x 250m cpu" is 0.75 cores), the GiB of
+// memory requests with binary suffixes ("12 pods x 512 MiB" is 6 GiB), and
+// the cost of a resource at an hourly rate over a duration, or over part of
+// it: "for 8 hours/day for 30 days". Line references must be resolved to
+// numbers first.
+func EvalResources(expr string) (utils.Result, error) {
+	expr, cycle := splitDutyCycle(utils.NormalizeExpr(expr))
+	share, err := dutyShare(cycle)
+	if err != nil {
+		return utils.Result{}, err
+	}
+	if m := resourceCostPattern.FindStringSubmatch(expr); m != nil {
+		amount, err := resourceNumber(m[1])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		isCPU := m[4] == ""
+		if isCPU {
+			if m[2] == "m" {
+				amount /= 1000
+			}
+		} else {
+			if m[2] == "m" {
+				return utils.Result{}, fmt.Errorf("millicores are a CPU amount, not memory")
+			}
+			amount *= memoryFactors[m[4]]
+		}
+		if rateCPU := !strings.HasPrefix(m[6], "gi"); rateCPU && !isCPU {
+			return utils.Result{}, fmt.Errorf("a CPU rate does not price memory")
+		} else if !rateCPU && isCPU {
+			return utils.Result{}, fmt.Errorf("a memory rate does not price CPU")
+		}
+		rate, _ := strconv.ParseFloat(m[5], 64)
+		per, err := datetime.ParseAmountDuration("1", m[7])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		d, err := datetime.ParseAmountDuration(m[8], m[9])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		cost := amount * rate * d.Hours() / per.Hours() * share
+		return utils.ValueResult(utils.FormatResult(true, cost), cost, true), nil
+	}
+	if m := cpuPattern.FindStringSubmatch(expr); m != nil {
+		count, amount, err := replicaAmounts(m[1], m[2])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		if m[3] == "m" {
+			amount /= 1000
+		}
+		cores := count * amount
+		text := utils.FormatResult(false, roundTo(cores, 3)) + " " + utils.Plural(cores, "core", "cores")
+		text += " (" + utils.FormatResult(false, math.Round(cores*1000)) + "m)"
+		return utils.ValueResult(text, cores, false), nil
+	}
+	if m := memoryPattern.FindStringSubmatch(expr); m != nil {
+		count, amount, err := replicaAmounts(m[1], m[2])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		gib := count * amount * memoryFactors[m[3]]
+		text := fmt.Sprintf("%s GiB (%s MiB)", utils.FormatResult(false, roundTo(gib, 3)),
+			utils.FormatResult(false, roundTo(gib*1024, 2)))
+		return utils.ValueResult(text, gib, false), nil
+	}
+	return utils.Result{}, fmt.Errorf("invalid resource expression")
+}
+
+// replicaAmounts reads the number of replicas, one if none is given, and the
+// amount each requests
+func replicaAmounts(count, amount string) (float64, float64, error) {
+	n := 1.0
+	if count != "" {
+		var err error
+		if n, err = resourceNumber(count); err != nil {
+			return 0, 0, err
+		}
+	}
+	a, err := resourceNumber(amount)
+	return n, a, err
+}
+
+// resourceNumber reads a number, failing on a line reference left unresolved
+func resourceNumber(s string) (float64, error) {
+	if strings.HasPrefix(s, `\`) {
+		return 0, fmt.Errorf("line %s has no value", s)
+	}
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,98 @@
+package capacity
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestIsResourceExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"3 pods x 250m cpu", true},
+		{"250m CPU", true},
+		{"12 pods x 512 MiB", true},
+		{"512Mi memory", true},
+		{"0.75 cores at $0.031/core-hour for 30 days", true},
+		{`\1 cores at $0.031/core-hour for 30 days`, true},
+
+		// Without cpu or cores, "m" is meters, and SI data units are conversions
+		{"250m", false},
+		{"3 x 250m", false},
+		{"512 MB", false},
+		{"512 MiB to GB", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsResourceExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsResourceExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalResources(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+		value    float64
+	}{
+		// Millicores
+		{"3 pods x 250m cpu", "0.75 cores (750m)", 0.75},
+		{"1500m cores", "1.5 cores (1,500m)", 1.5},
+		{"4 replicas × 1000m vcpu", "4 cores (4,000m)", 4},
+		{"2 x 0.5 cores", "1 core (1,000m)", 1},
+
+		// Binary memory suffixes
+		{"12 pods x 512 MiB", "6 GiB (6,144 MiB)", 6},
+		{"512Mi memory", "0.5 GiB (512 MiB)", 0.5},
+		{"3 pods * 2Gi", "6 GiB (6,144 MiB)", 6},
+		{"2 nodes x 1 TiB ram", "2,048 GiB (2,097,152 MiB)", 2048},
+		{"4 x 256 KiB", "0.001 GiB (1 MiB)", 1.0 / 1024},
+
+		// Cost of a rate over a duration
+		{"0.75 cores at $0.031/core-hour for 30 days", "$16.74", 16.74},
+		{"0.75 cores at $0.031/core-hour for 8 hours/day for 30 days", "$5.58", 0.75 * 0.031 * 240},
+		{"750m cpu at $0.031 per cpu-hour for 30 days", "$16.74", 16.74},
+		{"6 GiB at $0.004/GiB-hour for 1 day", "$0.58", 0.576},
+		{"2 cores at $10/core-month for 3 months", "$60.00", 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			r, err := EvalResources(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalResources(%q) error: %v", tt.expr, err)
+			}
+			if r.Text != tt.expected {
+				t.Errorf("EvalResources(%q) = %q, want %q", tt.expr, r.Text, tt.expected)
+			}
+			if math.Abs(r.Value-tt.value) > 1e-9*math.Max(1, tt.value) {
+				t.Errorf("EvalResources(%q) value = %v, want %v", tt.expr, r.Value, tt.value)
+			}
+		})
+	}
+}
+
+func TestEvalResourcesErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"2 cores at $0.004/GiB-hour for 1 day", "a memory rate does not price CPU"},
+		{"6 GiB at $0.031/core-hour for 1 day", "a CPU rate does not price memory"},
+		{`\4 cores at $0.031/core-hour for 30 days`, `line \4 has no value`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := EvalResources(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("EvalResources(%q) error = %v, want %q", tt.expr, err, tt.want)
+			}
+		})
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
-	"net"
 	"net/url"
 	"regexp"
 	"strings"
 	"time"
+
+	"smartcalc/internal/datetime"
+	"smartcalc/internal/network"
+	"smartcalc/internal/utils"
 )
 
 // IsCertExpression checks if an expression is a certificate decode expression
@@ -103,18 +106,20 @@ func decodeCertificate(urlStr string) (string, error) {
 	}
 
 	// Connect with TLS, skipping verification to handle expired/untrusted certs
-	conn, err := tls.DialWithDialer(
-		&net.Dialer{Timeout: 10 * time.Second},
-		"tcp",
-		host,
-		&tls.Config{
-			InsecureSkipVerify: true, // Allow expired/untrusted certificates
-		},
-	)
+	// Connected through the network package's dialer, which tests replace
+	raw, err := network.Dial("tcp", host, 10*time.Second)
 	if err != nil {
 		return "", fmt.Errorf("failed to connect: %v", err)
 	}
-	defer conn.Close()
+	defer raw.Close()
+	raw.SetDeadline(time.Now().Add(10 * time.Second))
+	conn := tls.Client(raw, &tls.Config{
+		ServerName:         parsedURL.Hostname(),
+		InsecureSkipVerify: true, // Allow expired/untrusted certificates
+	})
+	if err := conn.Handshake(); err != nil {
+		return "", fmt.Errorf("failed to connect: %v", err)
+	}
 
 	// Get the certificate chain
 	certs := conn.ConnectionState().PeerCertificates
@@ -172,15 +177,16 @@ func formatCertificates(certs []*x509.Certificate, host string) (string, error)
 	result.WriteString(fmt.Sprintf(">   Not After: %s\n", cert.NotAfter.Format("2006-01-02 15:04:05 MST")))
 
 	// Status
-	now := time.Now()
+	now := datetime.Now()
 	result.WriteString("> Status: ")
 	if now.Before(cert.NotBefore) {
 		result.WriteString("⚠ NOT YET VALID\n")
 	} else if now.After(cert.NotAfter) {
 		result.WriteString("⚠ EXPIRED\n")
 	} else {
-		remaining := time.Until(cert.NotAfter)
-		result.WriteString(fmt.Sprintf("✓ Valid (expires in %s)\n", formatDuration(remaining)))
+		remaining := cert.NotAfter.Sub(now)
+		expires := fmt.Sprintf(utils.Message(utils.MsgExpiresIn), formatDuration(remaining))
+		result.WriteString(fmt.Sprintf("✓ Valid (%s)\n", expires))
 	}
 
 	// Serial Number
@@ -224,41 +230,61 @@ func formatCertificates(certs []*x509.Certificate, host string) (string, error)
 	// Certificate chain info as ASCII tree
 	if len(certs) > 1 {
 		result.WriteString(fmt.Sprintf("> Certificate Chain: %d certificates\n", len(certs)))
-		// Display chain in reverse order (root at top, leaf at bottom)
-		for i := len(certs) - 1; i >= 0; i-- {
-			c := certs[i]
-			depth := len(certs) - 1 - i
-
-			// Determine certificate type label
-			var label string
-			if i == 0 {
-				label = "(leaf)"
-			} else if c.IsCA {
-				if i == len(certs)-1 {
-					label = "(root)"
-				} else {
-					label = "(intermediate)"
-				}
-			}
+		result.WriteString(formatChain(certs))
+	}
 
-			name := c.Subject.CommonName
-			if name == "" && len(c.Subject.Organization) > 0 {
-				name = c.Subject.Organization[0]
-			}
+	return result.String(), nil
+}
 
-			// Build tree line with simple indentation
-			if depth == 0 {
-				// Root certificate
-				result.WriteString(fmt.Sprintf("> 🔐 %s %s\n", name, label))
+// formatChain draws the certificate chain as a tree, root at the top and leaf
+// at the bottom, with the (root)/(intermediate)/(leaf) labels in a column
+func formatChain(certs []*x509.Certificate) string {
+	var branches, labels []string
+	// Display chain in reverse order (root at top, leaf at bottom)
+	for i := len(certs) - 1; i >= 0; i-- {
+		c := certs[i]
+		depth := len(certs) - 1 - i
+
+		// Determine certificate type label
+		var label string
+		if i == 0 {
+			label = "(leaf)"
+		} else if c.IsCA {
+			if i == len(certs)-1 {
+				label = "(root)"
 			} else {
-				// Simple indentation: spaces for each level, then └──
-				indent := strings.Repeat("    ", depth-1)
-				result.WriteString(fmt.Sprintf("> %s└── %s %s\n", indent, name, label))
+				label = "(intermediate)"
 			}
 		}
+
+		name := c.Subject.CommonName
+		if name == "" && len(c.Subject.Organization) > 0 {
+			name = c.Subject.Organization[0]
+		}
+
+		// Build tree line with simple indentation
+		if depth == 0 {
+			// Root certificate
+			branches = append(branches, "🔐 "+name)
+		} else {
+			// Simple indentation: spaces for each level, then └──
+			indent := strings.Repeat("    ", depth-1)
+			branches = append(branches, indent+"└── "+name)
+		}
+		labels = append(labels, label)
 	}
 
-	return result.String(), nil
+	// Names can hold wide characters (CJK, emoji), so pad by display width
+	width := 0
+	for _, branch := range branches {
+		width = max(width, utils.DisplayWidth(branch))
+	}
+	var sb strings.Builder
+	for i, branch := range branches {
+		line := strings.TrimRight(utils.PadRight(branch, width)+" "+labels[i], " ")
+		sb.WriteString("> " + line + "\n")
+	}
+	return sb.String()
 }
 
 // formatSerialNumber formats a serial number as hex
@@ -348,10 +374,14 @@ func formatExtKeyUsage(eku []x509.ExtKeyUsage) string {
 	return strings.Join(usages, ", ")
 }
 
-// formatDuration formats a duration in a human-readable way
+// formatDuration formats a duration in a human-readable way, with the short
+// units of the output language
 func formatDuration(d time.Duration) string {
 	if d < 0 {
-		return "expired"
+		return utils.Message(utils.MsgExpired)
+	}
+	short := func(id utils.MessageID, n int) string {
+		return fmt.Sprintf(utils.Message(id), n)
 	}
 
 	days := int(d.Hours() / 24)
@@ -361,21 +391,21 @@ func formatDuration(d time.Duration) string {
 	if days > 365 {
 		years := days / 365
 		remainingDays := days % 365
-		return fmt.Sprintf("%dy %dd", years, remainingDays)
+		return short(utils.MsgYearsShort, years) + " " + short(utils.MsgDaysShort, remainingDays)
 	}
 	if days > 30 {
 		months := days / 30
 		remainingDays := days % 30
-		return fmt.Sprintf("%dmo %dd", months, remainingDays)
+		return short(utils.MsgMonthsShort, months) + " " + short(utils.MsgDaysShort, remainingDays)
 	}
 	if days > 0 {
-		return fmt.Sprintf("%dd %dh", days, hours)
+		return short(utils.MsgDaysShort, days) + " " + short(utils.MsgHoursShort, hours)
 	}
 	if hours > 0 {
-		return fmt.Sprintf("%dh %dm", hours, minutes)
+		return short(utils.MsgHoursShort, hours) + " " + short(utils.MsgMinutesShort, minutes)
 	}
 	if minutes > 0 {
-		return fmt.Sprintf("%dm", minutes)
+		return short(utils.MsgMinutesShort, minutes)
 	}
-	return fmt.Sprintf("%ds", int(d.Seconds()))
+	return short(utils.MsgSecondsShort, int(d.Seconds()))
 }
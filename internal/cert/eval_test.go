@@ -2,11 +2,19 @@ package cert
 
 import (
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"flag"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"smartcalc/internal/utils"
 )
 
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
 func TestIsCertExpression(t *testing.T) {
 	tests := []struct {
 		expr     string
@@ -176,6 +184,29 @@ func TestFormatDuration(t *testing.T) {
 	}
 }
 
+func TestFormatDurationLanguages(t *testing.T) {
+	defer utils.SetLanguage("en")
+	d := 45*24*time.Hour + 3*time.Hour
+	tests := []struct {
+		lang     string
+		expected string
+	}{
+		{"en", "1mo 15d"},
+		{"de", "1 Mon. 15 T."},
+		{"uk", "1 міс. 15 д."},
+	}
+	for _, tt := range tests {
+		utils.SetLanguage(tt.lang)
+		if got := formatDuration(d); got != tt.expected {
+			t.Errorf("formatDuration() in %s = %q, want %q", tt.lang, got, tt.expected)
+		}
+	}
+	utils.SetLanguage("fr")
+	if got := formatDuration(-time.Hour); got != "expiré" {
+		t.Errorf("formatDuration(-1h) in fr = %q, want expiré", got)
+	}
+}
+
 func TestFormatKeyUsage(t *testing.T) {
 	tests := []struct {
 		usage    x509.KeyUsage
@@ -277,3 +308,38 @@ func TestEvalCert_BadSSL(t *testing.T) {
 		})
 	}
 }
+
+// TestFormatChainGolden locks in the chain tree with wide characters in the
+// names: the labels stay in one column
+func TestFormatChainGolden(t *testing.T) {
+	certs := []*x509.Certificate{
+		{Subject: pkix.Name{CommonName: "例え.jp"}},
+		{Subject: pkix.Name{CommonName: "Zertifizierungsstelle Ω"}, IsCA: true},
+		{Subject: pkix.Name{CommonName: "中国金融认证中心 🔒"}, IsCA: true},
+		{Subject: pkix.Name{Organization: []string{"Root° Trust"}}, IsCA: true},
+	}
+	result := formatChain(certs)
+
+	golden := filepath.Join("testdata", "chain.golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(result), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if result != string(want) {
+		t.Errorf("chain differs from %s (run with -update to accept):\n%s", golden, result)
+	}
+
+	column := -1
+	for _, line := range strings.Split(strings.TrimSuffix(result, "\n"), "\n") {
+		at := utils.DisplayWidth(line[:strings.LastIndex(line, " (")])
+		if column >= 0 && at != column {
+			t.Errorf("label of %q at column %d, want %d", line, at, column)
+		}
+		column = at
+	}
+}
@@ -0,0 +1,16 @@
+package cert
+
+import "smartcalc/internal/registry"
+
+func init() {
+	registry.Register(registry.Evaluator{
+		Name:     "cert",
+		Priority: registry.PriorityCert,
+		Traits:   registry.Expensive | registry.MultiLine | registry.NoFormat | registry.ReportsErrors,
+		Detect:   IsCertExpression,
+		Eval: registry.TextEval(func(expr string) (string, error) {
+			result, err := EvalCert(expr)
+			return "\n> " + result, err
+		}),
+	})
+}
@@ -0,0 +1,111 @@
+package chem
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"smartcalc/internal/utils"
+)
+
+// formulaPart matches a chemical formula as typed: it starts with an
+// uppercase letter or a bracket, so words and variables are not formulas
+const formulaPart = `([A-Z(\[][A-Za-z0-9()\[\]·•*.]*)`
+
+// amountPart matches a number such as "36", "0.5" or "1,000"
+const amountPart = `(\d[\d,]*(?:\.\d+)?)`
+
+// molarMassPattern matches "molar mass H2O" and "molar mass of C6H12O6"
+var molarMassPattern = regexp.MustCompile(`(?i:^molar\s+mass\s+(?:of\s+)?)` + formulaPart + `$`)
+
+// molesPattern matches "moles of 36 g H2O" and "moles in 1 kg of NaCl"
+var molesPattern = regexp.MustCompile(`(?i:^moles?\s+(?:of|in)\s+)` + amountPart + `\s*(?i:(mg|g|kg|grams?|milligrams?|kilograms?))\s+(?i:of\s+)?` + formulaPart + `$`)
+
+// massPattern matches "mass of 2 mol NaCl" and "mass of 250 mmol of H2SO4"
+var massPattern = regexp.MustCompile(`(?i:^mass\s+of\s+)` + amountPart + `\s*(?i:(mmol|mol|kmol|moles?|millimoles?))\s+(?i:of\s+)?` + formulaPart + `$`)
+
+// gramsPer are grams in one of each mass unit
+var gramsPer = map[string]float64{
+	"mg": 1e-3, "milligram": 1e-3, "milligrams": 1e-3,
+	"g": 1, "gram": 1, "grams": 1,
+	"kg": 1e3, "kilogram": 1e3, "kilograms": 1e3,
+}
+
+// molesPer are moles in one of each amount unit
+var molesPer = map[string]float64{
+	"mmol": 1e-3, "millimole": 1e-3, "millimoles": 1e-3,
+	"mol": 1, "mole": 1, "moles": 1,
+	"kmol": 1e3,
+}
+
+// IsChemistryExpression checks if an expression asks for the molar mass of
+// a formula or converts between its mass and amount. An unknown element is
+// left to EvalChemistry to report.
+func IsChemistryExpression(expr string) bool {
+	expr = strings.TrimSpace(expr)
+	return molarMassPattern.MatchString(expr) || molesPattern.MatchString(expr) || massPattern.MatchString(expr)
+}
+
+// EvalChemistry works out the molar mass of a formula, "molar mass H2O =
+// 18.015 g/mol" with what each element adds as "> " lines, the moles in a
+// mass, "moles of 36 g H2O = 1.998 mol", or the mass of an amount, "mass of
+// 2 mol NaCl = 116.88 g". The value is in g/mol, moles or grams.
+func EvalChemistry(expr string) (utils.Result, error) {
+	expr = strings.TrimSpace(expr)
+	if m := molarMassPattern.FindStringSubmatch(expr); m != nil {
+		c, err := parseFormula(m[1])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		return utils.ValueResult(utils.FormatResult(false, c.molarMass())+" g/mol"+c.breakdown(), c.molarMass(), false), nil
+	}
+	if m := molesPattern.FindStringSubmatch(expr); m != nil {
+		c, err := parseFormula(m[3])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		amount, err := utils.ParseNumber(m[1], false)
+		if err != nil {
+			return utils.Result{}, err
+		}
+		grams := amount * gramsPer[strings.ToLower(m[2])]
+		moles := grams / c.molarMass()
+		return utils.ValueResult(utils.FormatResult(false, moles)+" mol", moles, false), nil
+	}
+	if m := massPattern.FindStringSubmatch(expr); m != nil {
+		c, err := parseFormula(m[3])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		amount, err := utils.ParseNumber(m[1], false)
+		if err != nil {
+			return utils.Result{}, err
+		}
+		grams := amount * molesPer[strings.ToLower(m[2])] * c.molarMass()
+		return utils.ValueResult(utils.FormatResult(false, grams)+" g", grams, false), nil
+	}
+	return utils.Result{}, fmt.Errorf("invalid chemistry expression")
+}
+
+// molarMass adds up the atomic weights of a composition in g/mol
+func (c composition) molarMass() float64 {
+	total := 0.0
+	for _, el := range c.order {
+		total += float64(c.counts[el]) * atomicWeights[el]
+	}
+	return total
+}
+
+// breakdown shows what each element adds to the molar mass, as "> " lines:
+// "> O: 1 × 15.999 = 15.999 g/mol (88.81%)"
+func (c composition) breakdown() string {
+	total := c.molarMass()
+	var sb strings.Builder
+	for _, el := range c.order {
+		n, weight := c.counts[el], atomicWeights[el]
+		part := float64(n) * weight
+		fmt.Fprintf(&sb, "\n> %s: %d × %s = %s g/mol (%.2f%%)", el, n,
+			utils.FormatResult(false, weight), utils.FormatResult(false, part), part/total*100)
+	}
+	return sb.String()
+}
@@ -0,0 +1,122 @@
+package chem
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestIsChemistryExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"molar mass H2O", true},
+		{"molar mass of C6H12O6", true},
+		{"moles of 36 g H2O", true},
+		{"moles in 1 kg of NaCl", true},
+		{"mass of 2 mol NaCl", true},
+		{"mass of 250 mmol of H2SO4", true},
+		// Unknown elements are reported, not left to other evaluators
+		{"molar mass Xy2", true},
+
+		{"molar mass", false},
+		{"molar mass water", false},
+		{"mass of 2 kg", false},
+		{"H2O", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsChemistryExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsChemistryExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalChemistry(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+		value    float64
+	}{
+		{"molar mass H2O", "18.015 g/mol\n> H: 2 × 1.008 = 2.016 g/mol (11.19%)\n> O: 1 × 15.999 = 15.999 g/mol (88.81%)", 18.015},
+		{"moles of 36 g H2O", "1.9983347211 mol", 36 / 18.015},
+		{"moles in 1 kg of NaCl", "17.1115674196 mol", 1000 / 58.44},
+		{"mass of 2 mol NaCl", "116.88 g", 116.88},
+		{"mass of 250 mmol of H2SO4", "24.518 g", 24.518},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			r, err := EvalChemistry(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalChemistry(%q) error: %v", tt.expr, err)
+			}
+			if r.Text != tt.expected {
+				t.Errorf("EvalChemistry(%q) = %q, want %q", tt.expr, r.Text, tt.expected)
+			}
+			if math.Abs(r.Value-tt.value) > 1e-9*tt.value {
+				t.Errorf("EvalChemistry(%q) value = %v, want %v", tt.expr, r.Value, tt.value)
+			}
+		})
+	}
+}
+
+func TestParseFormula(t *testing.T) {
+	tests := []struct {
+		formula string
+		mass    float64
+	}{
+		{"C6H12O6", 180.156},
+		{"Cu(NO3)2", 63.546 + 2*14.007 + 6*15.999},
+		{"K4[Fe(CN)6]", 4*39.098 + 55.845 + 6*12.011 + 6*14.007},
+		// A hydrate counts its water
+		{"CuSO4·5H2O", 63.546 + 32.06 + 9*15.999 + 10*1.008},
+		{"CuSO4.5H2O", 63.546 + 32.06 + 9*15.999 + 10*1.008},
+		{"Ca(OH)2", 40.078 + 2*15.999 + 2*1.008},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.formula, func(t *testing.T) {
+			c, err := parseFormula(tt.formula)
+			if err != nil {
+				t.Fatalf("parseFormula(%q) error: %v", tt.formula, err)
+			}
+			if got := c.molarMass(); math.Abs(got-tt.mass) > 1e-9 {
+				t.Errorf("molar mass of %s = %v, want %v", tt.formula, got, tt.mass)
+			}
+		})
+	}
+}
+
+func TestEvalChemistryInvalidNumber(t *testing.T) {
+	huge := "1" + strings.Repeat("0", 400)
+	for _, expr := range []string{"moles in " + huge + " g of NaCl", "mass of " + huge + " mol of H2O"} {
+		if _, err := EvalChemistry(expr); err == nil || !strings.HasPrefix(err.Error(), "invalid number") {
+			t.Errorf("EvalChemistry(%q) error = %v, want invalid number", expr, err)
+		}
+	}
+}
+
+func TestParseFormulaErrors(t *testing.T) {
+	tests := []struct {
+		formula string
+		err     string
+	}{
+		{"Xy2O", `unknown element "Xy" in Xy2O`},
+		{"NaCL", `unknown element "L" in NaCL`},
+		{"H2(O", `missing ')' in H2(O`},
+		{"H2)O", `unbalanced ')' in H2)O`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.formula, func(t *testing.T) {
+			_, err := parseFormula(tt.formula)
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("parseFormula(%q) error = %v, want %s", tt.formula, err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package chem
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// composition counts the atoms of each element in a formula, with the
+// elements in the order they first appear
+type composition struct {
+	counts map[string]int
+	order  []string
+}
+
+func newComposition() composition {
+	return composition{counts: make(map[string]int)}
+}
+
+// add counts n atoms of an element
+func (c *composition) add(element string, n int) {
+	if _, seen := c.counts[element]; !seen {
+		c.order = append(c.order, element)
+	}
+	c.counts[element] += n
+}
+
+// addAll counts the atoms of a group times over
+func (c *composition) addAll(group composition, times int) {
+	for _, el := range group.order {
+		c.add(el, group.counts[el]*times)
+	}
+}
+
+// hydrateSeparators join the parts of a hydrate or adduct: CuSO4·5H2O
+const hydrateSeparators = "·•*."
+
+// parseFormula reads a chemical formula such as "H2O", "Cu(NO3)2" or the
+// hydrate "CuSO4·5H2O", whose parts after the dot may start with a count.
+// An unknown element is reported by name.
+func parseFormula(formula string) (composition, error) {
+	total := newComposition()
+	parts := strings.FieldsFunc(formula, func(r rune) bool {
+		return strings.ContainsRune(hydrateSeparators, r)
+	})
+	if len(parts) == 0 {
+		return total, fmt.Errorf("empty formula")
+	}
+	for _, part := range parts {
+		times, rest := leadingCount(part)
+		group, end, err := parseGroup(formula, rest, 0, 0)
+		if err != nil {
+			return total, err
+		}
+		if end != len(rest) || len(group.order) == 0 {
+			return total, fmt.Errorf("invalid formula %s", formula)
+		}
+		total.addAll(group, times)
+	}
+	return total, nil
+}
+
+// parseGroup reads elements and bracketed groups from s at i until the
+// closing bracket, if any, and returns the position after it
+func parseGroup(formula, s string, i int, closing byte) (composition, int, error) {
+	group := newComposition()
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == '(' || c == '[':
+			want := byte(')')
+			if c == '[' {
+				want = ']'
+			}
+			inner, end, err := parseGroup(formula, s, i+1, want)
+			if err != nil {
+				return group, 0, err
+			}
+			n, next := count(s, end)
+			group.addAll(inner, n)
+			i = next
+		case c == ')' || c == ']':
+			if c != closing {
+				return group, 0, fmt.Errorf("unbalanced %q in %s", c, formula)
+			}
+			return group, i + 1, nil
+		case c >= 'A' && c <= 'Z':
+			j := i + 1
+			for j < len(s) && s[j] >= 'a' && s[j] <= 'z' {
+				j++
+			}
+			element := s[i:j]
+			if _, ok := atomicWeights[element]; !ok {
+				return group, 0, fmt.Errorf("unknown element %q in %s", element, formula)
+			}
+			n, next := count(s, j)
+			group.add(element, n)
+			i = next
+		default:
+			return group, 0, fmt.Errorf("unexpected %q in %s", s[i:i+1], formula)
+		}
+	}
+	if closing != 0 {
+		return group, 0, fmt.Errorf("missing %q in %s", closing, formula)
+	}
+	return group, i, nil
+}
+
+// count reads the count after an element or group, 1 when there is none
+func count(s string, i int) (int, int) {
+	j := i
+	for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+		j++
+	}
+	if j == i {
+		return 1, i
+	}
+	n, _ := strconv.Atoi(s[i:j])
+	return n, j
+}
+
+// leadingCount splits the count off the front of a hydrate part: "5H2O"
+func leadingCount(part string) (int, string) {
+	n, end := count(part, 0)
+	return n, part[end:]
+}
@@ -0,0 +1,15 @@
+package chem
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// An unknown element is reported instead of left to the evaluators after,
+	// and the molar mass breakdown is a block of "> " lines
+	registry.Register(registry.Evaluator{
+		Name:     "chemistry",
+		Priority: registry.PriorityChemistry,
+		Traits:   registry.MultiLine | registry.ReportsErrors,
+		Detect:   IsChemistryExpression,
+		Eval:     EvalChemistry,
+	})
+}
@@ -8,7 +8,7 @@ import (
 	"strings"
 )
 
-// IsColorExpression checks if an expression is a color conversion
+// IsColorExpression checks if an expression is a color conversion or color math
 func IsColorExpression(expr string) bool {
 	expr = strings.TrimSpace(strings.ToLower(expr))
 
@@ -22,6 +22,11 @@ func IsColorExpression(expr string) bool {
 		`^hsl\s*\(\s*\d+\s*,\s*\d+%?\s*,\s*\d+%?\s*\)\s+(?:to|in)\s+(?:rgb|hex)$`,
 	}
 
+	// Lighten, darken, mix and contrast
+	if adjustPattern.MatchString(expr) || mixPattern.MatchString(expr) || contrastPattern.MatchString(expr) {
+		return true
+	}
+
 	for _, pattern := range patterns {
 		if matched, _ := regexp.MatchString(pattern, expr); matched {
 			return true
@@ -36,6 +41,10 @@ func EvalColor(expr string) (string, error) {
 	expr = strings.TrimSpace(expr)
 	exprLower := strings.ToLower(expr)
 
+	if result, ok, err := evalColorMath(exprLower); ok {
+		return result, err
+	}
+
 	// Parse the expression to get source color and target format
 	parts := regexp.MustCompile(`\s+(?:to|in)\s+`).Split(exprLower, 2)
 	if len(parts) != 2 {
@@ -185,6 +194,13 @@ func parseHSL(hsl string) (int, int, int, error) {
 
 // rgbToHSL converts RGB values to HSL
 func rgbToHSL(r, g, b int) (int, int, int) {
+	h, s, l := rgbToHSLf(r, g, b)
+	return int(math.Round(h)), int(math.Round(s * 100)), int(math.Round(l * 100))
+}
+
+// rgbToHSLf converts RGB values to an unrounded hue in degrees and saturation
+// and lightness between 0 and 1
+func rgbToHSLf(r, g, b int) (float64, float64, float64) {
 	rf := float64(r) / 255.0
 	gf := float64(g) / 255.0
 	bf := float64(b) / 255.0
@@ -197,7 +213,7 @@ func rgbToHSL(r, g, b int) (int, int, int) {
 	l := (max + min) / 2.0
 
 	if delta == 0 {
-		return 0, 0, int(math.Round(l * 100))
+		return 0, 0, l
 	}
 
 	// Saturation
@@ -223,27 +239,31 @@ func rgbToHSL(r, g, b int) (int, int, int) {
 	}
 	h *= 60
 
-	return int(math.Round(h)), int(math.Round(s * 100)), int(math.Round(l * 100))
+	return h, s, l
 }
 
 // hslToRGB converts HSL values to RGB
 func hslToRGB(h, s, l int) (int, int, int) {
-	hf := float64(h) / 360.0
-	sf := float64(s) / 100.0
-	lf := float64(l) / 100.0
+	return hslToRGBf(float64(h), float64(s)/100.0, float64(l)/100.0)
+}
 
-	if sf == 0 {
-		v := int(math.Round(lf * 255))
+// hslToRGBf converts a hue in degrees and saturation and lightness between 0
+// and 1 to RGB
+func hslToRGBf(h, s, l float64) (int, int, int) {
+	hf := h / 360.0
+
+	if s == 0 {
+		v := int(math.Round(l * 255))
 		return v, v, v
 	}
 
 	var q float64
-	if lf < 0.5 {
-		q = lf * (1 + sf)
+	if l < 0.5 {
+		q = l * (1 + s)
 	} else {
-		q = lf + sf - lf*sf
+		q = l + s - l*s
 	}
-	p := 2*lf - q
+	p := 2*l - q
 
 	r := hueToRGB(p, q, hf+1.0/3.0)
 	g := hueToRGB(p, q, hf)
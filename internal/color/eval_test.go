@@ -27,11 +27,21 @@ func TestIsColorExpression(t *testing.T) {
 		{"hsl(14, 100, 60) to hex", true},
 		{"hsl(14,100%,60%) in rgb", true},
 
+		// Color math
+		{"#3498db lighten 20%", true},
+		{"rgb(52, 152, 219) darken 10%", true},
+		{"mix #ff0000 #0000ff", true},
+		{"mix #ff0000 and #0000ff 25%", true},
+		{"contrast #ffffff #777777", true},
+		{"contrast ratio #fff on #000", true},
+
 		// Invalid expressions
 		{"hello world", false},
 		{"100 + 50", false},
 		{"#FF5733", false},
 		{"rgb(255, 87, 51)", false},
+		{"#3498db lighten", false},
+		{"mix #ff0000", false},
 	}
 
 	for _, tt := range tests {
@@ -233,3 +243,38 @@ func abs(x int) int {
 	}
 	return x
 }
+
+func TestColorMath(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		// Matches Sass lighten()/darken(), which work on HSL lightness
+		{"#3498db lighten 20%", "#8BC4EA · rgb(139, 196, 234)"},
+		{"#3498db darken 10%", "#217DBB · rgb(33, 125, 187)"},
+		{"#3498DB lighten 100%", "#FFFFFF · rgb(255, 255, 255)"},
+		{"hsl(204, 70%, 53%) darken 53", "#000000 · rgb(0, 0, 0)"},
+		{"mix #ff0000 #0000ff 50%", "#800080 · rgb(128, 0, 128)"},
+		{"mix #ff0000 #0000ff", "#800080 · rgb(128, 0, 128)"},
+		{"mix #ff0000 with rgb(0, 0, 255) 25%", "#4000BF · rgb(64, 0, 191)"},
+		{"contrast #ffffff #777777", "4.47:1 (passes AA large text only)"},
+		{"contrast #767676 #fff", "4.54:1 (passes AA, AAA large text)"},
+		{"contrast #fff on #000", "21.00:1 (passes AAA)"},
+		{"contrast #777 #888", "1.26:1 (fails AA)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := EvalColor(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalColor(%q) error: %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalColor(%q) = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := EvalColor("mix #ff0000 #0000ff 150%"); err == nil {
+		t.Error("expected an error for a mix share over 100%")
+	}
+}
@@ -0,0 +1,152 @@
+package color
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// colorTerm matches a color operand: #RGB, #RRGGBB, rgb(...) or hsl(...)
+const colorTerm = `(#[0-9a-f]{6}|#[0-9a-f]{3}|rgb\s*\([^)]*\)|hsl\s*\([^)]*\))`
+
+// adjustPattern matches "#3498db lighten 20%" and "#3498db darken 10%"
+var adjustPattern = regexp.MustCompile(`^` + colorTerm + `\s+(lighten|darken)\s+(\d+(?:\.\d+)?)\s*%?$`)
+
+// mixPattern matches "mix #ff0000 #0000ff" with an optional share of the first
+// color: "mix #ff0000 #0000ff 25%"
+var mixPattern = regexp.MustCompile(`^mix\s+` + colorTerm + `\s+(?:and\s+|with\s+)?` + colorTerm + `(?:\s+(\d+(?:\.\d+)?)\s*%)?$`)
+
+// contrastPattern matches "contrast #ffffff #777777" and "contrast ratio #fff on #777"
+var contrastPattern = regexp.MustCompile(`^contrast(?:\s+ratio)?\s+` + colorTerm + `\s+(?:(?:and|on|vs)\s+)?` + colorTerm + `$`)
+
+// evalColorMath evaluates lighten, darken, mix and contrast expressions. ok is
+// false for other expressions. exprLower must be trimmed and lower case.
+func evalColorMath(exprLower string) (result string, ok bool, err error) {
+	if m := adjustPattern.FindStringSubmatch(exprLower); m != nil {
+		result, err = adjustLightness(m[1], m[2], m[3])
+		return result, true, err
+	}
+	if m := mixPattern.FindStringSubmatch(exprLower); m != nil {
+		result, err = mix(m[1], m[2], m[3])
+		return result, true, err
+	}
+	if m := contrastPattern.FindStringSubmatch(exprLower); m != nil {
+		result, err = contrast(m[1], m[2])
+		return result, true, err
+	}
+	return "", false, nil
+}
+
+// parseColor parses a color in any of the supported notations
+func parseColor(s string) (int, int, int, error) {
+	switch s[0] {
+	case '#':
+		return parseHex(s)
+	case 'r':
+		return parseRGB(s)
+	}
+	h, sat, l, err := parseHSL(s)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	r, g, b := hslToRGB(h, sat, l)
+	return r, g, b, nil
+}
+
+// formatColor shows a color as hex and rgb(), like the conversions do
+func formatColor(r, g, b int) string {
+	return fmt.Sprintf("#%02X%02X%02X · rgb(%d, %d, %d)", r, g, b, r, g, b)
+}
+
+// adjustLightness lightens or darkens a color by a number of percentage points
+// of HSL lightness, which keeps its hue and saturation
+func adjustLightness(c, verb, amount string) (string, error) {
+	r, g, b, err := parseColor(c)
+	if err != nil {
+		return "", err
+	}
+	pct, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return "", err
+	}
+	if verb == "darken" {
+		pct = -pct
+	}
+	h, s, l := rgbToHSLf(r, g, b)
+	l = math.Max(0, math.Min(1, l+pct/100))
+	return formatColor(hslToRGBf(h, s, l)), nil
+}
+
+// mix blends two colors channel by channel in RGB. weight is the share of the
+// first color in percent and defaults to 50.
+func mix(c1, c2, weight string) (string, error) {
+	r1, g1, b1, err := parseColor(c1)
+	if err != nil {
+		return "", err
+	}
+	r2, g2, b2, err := parseColor(c2)
+	if err != nil {
+		return "", err
+	}
+	w := 0.5
+	if weight != "" {
+		pct, err := strconv.ParseFloat(weight, 64)
+		if err != nil {
+			return "", err
+		}
+		if pct > 100 {
+			return "", fmt.Errorf("mix share must be between 0%% and 100%%")
+		}
+		w = pct / 100
+	}
+	blend := func(a, b int) int {
+		return int(math.Round(float64(a)*w + float64(b)*(1-w)))
+	}
+	return formatColor(blend(r1, r2), blend(g1, g2), blend(b1, b2)), nil
+}
+
+// relativeLuminance is the WCAG 2 relative luminance of an sRGB color
+func relativeLuminance(r, g, b int) float64 {
+	channel := func(v int) float64 {
+		c := float64(v) / 255
+		if c <= 0.03928 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(r) + 0.7152*channel(g) + 0.0722*channel(b)
+}
+
+// contrast returns the WCAG contrast ratio of two colors and the levels it
+// passes: AA needs 4.5:1 for normal text and 3:1 for large text, AAA 7:1 and
+// 4.5:1
+func contrast(c1, c2 string) (string, error) {
+	r1, g1, b1, err := parseColor(c1)
+	if err != nil {
+		return "", err
+	}
+	r2, g2, b2, err := parseColor(c2)
+	if err != nil {
+		return "", err
+	}
+	l1, l2 := relativeLuminance(r1, g1, b1), relativeLuminance(r2, g2, b2)
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	ratio := (l1 + 0.05) / (l2 + 0.05)
+
+	var level string
+	switch {
+	case ratio >= 7:
+		level = "passes AAA"
+	case ratio >= 4.5:
+		level = "passes AA, AAA large text"
+	case ratio >= 3:
+		level = "passes AA large text only"
+	default:
+		level = "fails AA"
+	}
+	// Truncate so a ratio just short of a threshold is not shown as meeting it
+	return fmt.Sprintf("%.2f:1 (%s)", math.Floor(ratio*100)/100, level), nil
+}
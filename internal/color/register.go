@@ -0,0 +1,12 @@
+package color
+
+import "smartcalc/internal/registry"
+
+func init() {
+	registry.Register(registry.Evaluator{
+		Name:     "color",
+		Priority: registry.PriorityColor,
+		Detect:   IsColorExpression,
+		Eval:     registry.TextEval(EvalColor),
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -61,6 +62,35 @@ var constants = map[string]Constant{
 	"parsec":            {3.0857e16, "m", "parsec"},
 }
 
+// Lookup finds a constant by name, ignoring case. A name of several words
+// may be joined with underscores, as ReplaceNames writes it: "speed_of_light".
+func Lookup(name string) (value float64, unit string, ok bool) {
+	c, ok := constants[strings.ReplaceAll(strings.ToLower(name), "_", " ")]
+	return c.Value, c.Unit, ok
+}
+
+// multiWordPattern matches the constant names of several words, longest
+// first so "stefan boltzmann" wins over "boltzmann"
+var multiWordPattern = func() *regexp.Regexp {
+	var names []string
+	for name := range constants {
+		if strings.Contains(name, " ") {
+			names = append(names, strings.ReplaceAll(regexp.QuoteMeta(name), " ", `\s+`))
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(names, "|") + `)\b`)
+}()
+
+// ReplaceNames joins the words of the constant names in an arithmetic
+// expression with underscores, so "speed of light * 2" reads as a single
+// identifier times 2. Single-word names such as pi are identifiers already.
+func ReplaceNames(expr string) string {
+	return multiWordPattern.ReplaceAllStringFunc(expr, func(name string) string {
+		return strings.Join(strings.Fields(strings.ToLower(name)), "_")
+	})
+}
+
 // Handler defines the interface for constant handlers.
 type Handler interface {
 	Handle(expr, exprLower string) (string, bool)
@@ -1,6 +1,7 @@
 package constants
 
 import (
+	"math"
 	"strings"
 	"testing"
 )
@@ -104,3 +105,48 @@ func TestIsConstantExpression(t *testing.T) {
 		})
 	}
 }
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name  string
+		value float64
+		unit  string
+		ok    bool
+	}{
+		{"pi", math.Pi, "", true},
+		{"Planck", 6.62607015e-34, "J·s", true},
+		{"speed_of_light", 299792458, "m/s", true},
+		{"stefan boltzmann", 5.670374419e-8, "W/(m²·K⁴)", true},
+		{"x", 0, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, unit, ok := Lookup(tt.name)
+			if value != tt.value || unit != tt.unit || ok != tt.ok {
+				t.Errorf("Lookup(%q) = %v, %q, %v, want %v, %q, %v", tt.name, value, unit, ok, tt.value, tt.unit, tt.ok)
+			}
+		})
+	}
+}
+
+func TestReplaceNames(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"speed of light * 2", "speed_of_light * 2"},
+		{"Earth Mass * gravitational / earth  radius^2", "earth_mass * gravitational / earth_radius^2"},
+		{"stefan boltzmann * 300^4", "stefan_boltzmann * 300^4"},
+		{"2 * pi * 6371", "2 * pi * 6371"},
+		{"lightyear * 2", "lightyear * 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := ReplaceNames(tt.expr); got != tt.expected {
+				t.Errorf("ReplaceNames(%q) = %q, want %q", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package constants
+
+import "smartcalc/internal/registry"
+
+func init() {
+	registry.Register(registry.Evaluator{
+		Name:     "constants",
+		Priority: registry.PriorityConstants,
+		Detect:   IsConstantExpression,
+		Eval:     registry.TextEval(EvalConstants),
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"smartcalc/internal/utils"
 )
 
 // Volume units in milliliters (base unit)
@@ -392,18 +394,15 @@ func handleSpecialUnit(matches []string, unit string, conv struct {
 	tbsp := ml / 14.7868
 
 	unitName := unit
-	if value != 1 && !strings.HasSuffix(unit, "s") {
-		unitName = unit + "s"
+	if !strings.Contains(unit, " ") {
+		singular := strings.TrimSuffix(unit, "s")
+		unitName = utils.Plural(value, singular, singular+"s")
 	}
 
 	if targetUnit == "" {
 		// Show comprehensive conversion
-		if value == 1 {
-			return fmt.Sprintf("1 %s %s = %.1fg = %.0f ml = %.2f cups = %.1f tbsp",
-				unit, conv.desc, grams, ml, cups, tbsp), nil
-		}
-		return fmt.Sprintf("%.0f %s %s = %.1fg = %.0f ml = %.2f cups = %.1f tbsp",
-			value, unitName, conv.desc, grams, ml, cups, tbsp), nil
+		return fmt.Sprintf("%.0f %s %s = %.1fg = %.0f ml = %.2f %s = %.1f tbsp",
+			value, unitName, conv.desc, grams, ml, cups, utils.Plural(cups, "cup", "cups"), tbsp), nil
 	}
 
 	// Convert to specific unit
@@ -415,7 +414,7 @@ func handleSpecialUnit(matches []string, unit string, conv struct {
 	case "ml", "milliliters":
 		return fmt.Sprintf("%.0f ml", ml), nil
 	case "cups", "cup":
-		return fmt.Sprintf("%.2f cups", cups), nil
+		return fmt.Sprintf("%.2f %s", cups, utils.Plural(cups, "cup", "cups")), nil
 	case "tbsp", "tablespoons", "tablespoon":
 		return fmt.Sprintf("%.1f tbsp", tbsp), nil
 	}
@@ -475,7 +474,7 @@ func handleIngredientConversion(matches []string) (string, error) {
 	switch {
 	case strings.HasPrefix(toUnit, "cup"):
 		cups := grams / density
-		return fmt.Sprintf("%.2f cups", cups), nil
+		return fmt.Sprintf("%.2f %s", cups, utils.Plural(cups, "cup", "cups")), nil
 	case toUnit == "tbsp" || strings.HasPrefix(toUnit, "tablespoon"):
 		tbsp := grams / density * 16
 		return fmt.Sprintf("%.1f tbsp", tbsp), nil
@@ -522,10 +521,11 @@ func handleVolumeConversion(matches []string) (string, error) {
 	ml := value * fromML
 	result := ml / toML
 
-	// Format output
+	// Format output: spelled-out units agree with the result, abbreviations stay as-is
 	toUnitDisplay := toUnit
-	if result != 1 && !strings.HasSuffix(toUnit, "s") && len(toUnit) > 2 {
-		toUnitDisplay = toUnit + "s"
+	singular := strings.TrimSuffix(toUnit, "s")
+	if plural, ok := cookingUnitPlurals[singular]; ok {
+		toUnitDisplay = utils.Plural(result, singular, plural)
 	}
 
 	if result == float64(int(result)) {
@@ -534,6 +534,14 @@ func handleVolumeConversion(matches []string) (string, error) {
 	return fmt.Sprintf("%.2f %s", result, toUnitDisplay), nil
 }
 
+// cookingUnitPlurals maps spelled-out volume units to their plural form
+var cookingUnitPlurals = map[string]string{
+	"cup":    "cups",
+	"pint":   "pints",
+	"quart":  "quarts",
+	"gallon": "gallons",
+}
+
 func normalizeUnit(unit string) string {
 	// Handle multi-word units
 	unit = strings.ReplaceAll(unit, " ", "")
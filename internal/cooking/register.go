@@ -0,0 +1,12 @@
+package cooking
+
+import "smartcalc/internal/registry"
+
+func init() {
+	registry.Register(registry.Evaluator{
+		Name:     "cooking",
+		Priority: registry.PriorityCooking,
+		Detect:   IsCookingExpression,
+		Eval:     registry.TextEval(EvalCooking),
+	})
+}
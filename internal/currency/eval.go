@@ -0,0 +1,174 @@
+package currency
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/utils"
+)
+
+// knownCodes lists the ISO 4217 codes recognized in expressions
+var knownCodes = map[string]bool{
+	"USD": true, "EUR": true, "GBP": true, "JPY": true, "CNY": true, "INR": true,
+	"CAD": true, "AUD": true, "NZD": true, "CHF": true, "SEK": true, "NOK": true,
+	"DKK": true, "PLN": true, "CZK": true, "HUF": true, "UAH": true, "RUB": true,
+	"TRY": true, "ILS": true, "AED": true, "SAR": true, "BRL": true, "MXN": true,
+	"ARS": true, "CLP": true, "COP": true, "KRW": true, "SGD": true, "HKD": true,
+	"TWD": true, "THB": true, "IDR": true, "MYR": true, "PHP": true, "VND": true,
+	"ZAR": true, "EGP": true, "NGN": true, "KES": true,
+}
+
+// symbolCodes maps common currency symbols to ISO codes
+var symbolCodes = map[string]string{
+	"$": "USD",
+	"€": "EUR",
+	"£": "GBP",
+	"¥": "JPY",
+	"₹": "INR",
+	"₴": "UAH",
+	"₽": "RUB",
+	"₩": "KRW",
+}
+
+// Pattern: "100 USD to EUR", "$250 in GBP", "€50 to $", "1,000 jpy in usd"
+var conversionPattern = regexp.MustCompile(`(?i)^([$€£¥₹₴₽₩]?)\s*([\d.,]+)\s*([a-z]{3})?\s+(?:to|in)\s+([a-z]{3}|[$€£¥₹₴₽₩])$`)
+
+// parseConversion extracts the amount and currency codes from an expression
+func parseConversion(expr string) (amount float64, from, to string, ok bool) {
+	m := conversionPattern.FindStringSubmatch(strings.TrimSpace(expr))
+	if m == nil {
+		return 0, "", "", false
+	}
+
+	// Exactly one of symbol or code must name the source currency
+	symbol, code := m[1], strings.ToUpper(m[3])
+	switch {
+	case symbol != "" && code == "":
+		from = symbolCodes[symbol]
+	case symbol == "" && code != "":
+		from = code
+	default:
+		return 0, "", "", false
+	}
+
+	to = strings.ToUpper(m[4])
+	if c, isSymbol := symbolCodes[m[4]]; isSymbol {
+		to = c
+	}
+	if !knownCodes[from] || !knownCodes[to] {
+		return 0, "", "", false
+	}
+
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(m[2], ",", ""), 64)
+	if err != nil {
+		return 0, "", "", false
+	}
+	return amount, from, to, true
+}
+
+// SymbolFor returns the symbol amounts in a currency are written with, given
+// its code or symbol: "€" for EUR, and the code and a space ("CHF ") for
+// currencies without a common symbol
+func SymbolFor(currency string) (string, bool) {
+	if _, ok := symbolCodes[currency]; ok {
+		return currency, true
+	}
+	code := strings.ToUpper(currency)
+	if !knownCodes[code] {
+		return "", false
+	}
+	for symbol, c := range symbolCodes {
+		if c == code {
+			return symbol, true
+		}
+	}
+	return code + " ", true
+}
+
+// symbolPattern finds the currency symbols of an expression
+var symbolPattern = regexp.MustCompile(`[$€£¥₹₴₽₩]`)
+
+// SymbolsIn returns the currency symbols an expression writes amounts with,
+// each once, in order: "€10 + £5" has "€" and "£"
+func SymbolsIn(expr string) []string {
+	var symbols []string
+	for _, s := range symbolPattern.FindAllString(expr, -1) {
+		if !slices.Contains(symbols, s) {
+			symbols = append(symbols, s)
+		}
+	}
+	return symbols
+}
+
+// wholeCodes are the currencies without a minor unit in use, whose amounts
+// are shown without cents
+var wholeCodes = map[string]bool{"JPY": true, "KRW": true, "CLP": true, "VND": true}
+
+// HasCents reports whether amounts written with a symbol, "€" or "CHF ",
+// are shown with cents. Yen and won are not.
+func HasCents(symbol string) bool {
+	code, ok := symbolCodes[symbol]
+	if !ok {
+		code = strings.ToUpper(strings.TrimSpace(symbol))
+	}
+	return !wholeCodes[code]
+}
+
+// IsCurrencyExpression checks if an expression is a currency conversion
+func IsCurrencyExpression(expr string) bool {
+	_, _, _, ok := parseConversion(expr)
+	return ok
+}
+
+// EvalCurrency converts an amount between currencies using cached exchange rates.
+// When the rates could not be refreshed the result notes their date.
+func EvalCurrency(expr string) (string, error) {
+	amount, from, to, ok := parseConversion(expr)
+	if !ok {
+		return "", fmt.Errorf("unable to evaluate currency conversion: %s", expr)
+	}
+
+	rates, stale, err := getRates()
+	if err != nil {
+		return "", err
+	}
+
+	fromRate, err := rateFor(rates, from)
+	if err != nil {
+		return "", err
+	}
+	toRate, err := rateFor(rates, to)
+	if err != nil {
+		return "", err
+	}
+
+	result := formatAmount(amount/fromRate*toRate, to)
+	if stale {
+		result += fmt.Sprintf(" (rate as of %s)", rates.Date)
+	}
+	return result, nil
+}
+
+// rateFor returns the rate of code relative to the rates' base currency
+func rateFor(rates *Rates, code string) (float64, error) {
+	if code == rates.Base {
+		return 1, nil
+	}
+	r, ok := rates.Rates[code]
+	if !ok || r == 0 {
+		return 0, fmt.Errorf("no exchange rate for %s", code)
+	}
+	return r, nil
+}
+
+// formatAmount formats a converted amount: "$1,234.56" for USD, "1,234.56 EUR" otherwise
+func formatAmount(v float64, code string) string {
+	s := utils.FormatCurrency(v)
+	if code == "USD" {
+		return s
+	}
+	return strings.Replace(s, "$", "", 1) + " " + code
+}
@@ -0,0 +1,163 @@
+package currency
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// stubRates installs a fixed rate source and a temporary cache file
+func stubRates(t *testing.T) *int {
+	t.Helper()
+	calls := 0
+	SetCachePath(filepath.Join(t.TempDir(), "rates.json"))
+	SetRateSource(func() (*Rates, error) {
+		calls++
+		return &Rates{
+			Base:  "USD",
+			Date:  "2024-05-01",
+			Rates: map[string]float64{"EUR": 0.9, "GBP": 0.8, "JPY": 150},
+		}, nil
+	})
+	t.Cleanup(func() {
+		SetRateSource(nil)
+		SetCachePath("")
+	})
+	return &calls
+}
+
+func TestEvalCurrency(t *testing.T) {
+	stubRates(t)
+
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"100 USD to EUR", "90.00 EUR"},
+		{"$250 in GBP", "200.00 GBP"},
+		{"90 eur to usd", "$100.00"},
+		{"€90 to $", "$100.00"},
+		{"1,500 JPY in EUR", "9.00 EUR"},
+		{"£80 in EUR", "90.00 EUR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalCurrency(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalCurrency(%q) error: %v", tt.expr, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvalCurrency(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalCurrencyCachesRates(t *testing.T) {
+	calls := stubRates(t)
+
+	for i := 0; i < 3; i++ {
+		if _, err := EvalCurrency("100 USD to EUR"); err != nil {
+			t.Fatalf("EvalCurrency error: %v", err)
+		}
+	}
+	if *calls != 1 {
+		t.Errorf("rate source called %d times, want 1", *calls)
+	}
+	if _, err := os.Stat(cachePath); err != nil {
+		t.Errorf("rates were not cached to disk: %v", err)
+	}
+}
+
+func TestEvalCurrencyOfflineUsesStaleCache(t *testing.T) {
+	stubRates(t)
+
+	stale := Rates{
+		Base:      "USD",
+		Date:      "2024-05-01",
+		FetchedAt: time.Now().Add(-48 * time.Hour),
+		Rates:     map[string]float64{"EUR": 0.5},
+	}
+	data, _ := json.Marshal(stale)
+	if err := os.WriteFile(cachePath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	SetRateSource(func() (*Rates, error) { return nil, errors.New("offline") })
+
+	result, err := EvalCurrency("100 USD to EUR")
+	if err != nil {
+		t.Fatalf("EvalCurrency error: %v", err)
+	}
+	if want := "50.00 EUR (rate as of 2024-05-01)"; result != want {
+		t.Errorf("EvalCurrency = %q, want %q", result, want)
+	}
+}
+
+func TestEvalCurrencyErrors(t *testing.T) {
+	stubRates(t)
+
+	if _, err := EvalCurrency("100 USD to CHF"); err == nil {
+		t.Error("expected error for currency missing from rates")
+	}
+
+	SetRateSource(func() (*Rates, error) { return nil, errors.New("offline") })
+	SetCachePath(filepath.Join(t.TempDir(), "empty.json"))
+	if _, err := EvalCurrency("100 USD to EUR"); err == nil {
+		t.Error("expected error without network or cache")
+	}
+}
+
+func TestIsCurrencyExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"100 USD to EUR", true},
+		{"$250 in GBP", true},
+		{"€50 to usd", true},
+		{"$100 USD to EUR", false}, // both symbol and code
+		{"100 gal to qts", false},
+		{"5 miles in km", false},
+		{"$100 - 20%", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsCurrencyExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsCurrencyExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSymbolsIn(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected []string
+	}{
+		{"€10 + £5 + €2", []string{"€", "£"}},
+		{"¥1000 + ¥500", []string{"¥"}},
+		{"10 + 5", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := SymbolsIn(tt.expr); !slices.Equal(got, tt.expected) {
+				t.Errorf("SymbolsIn(%q) = %q, want %q", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHasCents(t *testing.T) {
+	for symbol, expected := range map[string]bool{"$": true, "€": true, "CHF ": true, "¥": false, "₩": false, "CLP ": false} {
+		if got := HasCents(symbol); got != expected {
+			t.Errorf("HasCents(%q) = %v, want %v", symbol, got, expected)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package currency
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// cacheTTL is how long fetched rates are used before trying to refresh them
+const cacheTTL = 12 * time.Hour
+
+// Rates holds exchange rates relative to a base currency
+type Rates struct {
+	Base      string             `json:"base"`
+	Date      string             `json:"date"` // date the rates were published (YYYY-MM-DD)
+	FetchedAt time.Time          `json:"fetched_at"`
+	Rates     map[string]float64 `json:"rates"`
+}
+
+// RateSource fetches the latest exchange rates
+type RateSource func() (*Rates, error)
+
+var (
+	mu         sync.Mutex
+	source     RateSource = fetchRates
+	cachePath             = defaultCachePath()
+	cached     *Rates
+	httpClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+// SetRateSource replaces the rate source (used by tests to avoid the network)
+// and drops any rates held in memory. nil restores the default web source.
+func SetRateSource(src RateSource) {
+	mu.Lock()
+	defer mu.Unlock()
+	if src == nil {
+		src = fetchRates
+	}
+	source = src
+	cached = nil
+}
+
+// SetCachePath sets the file rates are cached in and drops rates held in
+// memory. An empty path restores the default location.
+func SetCachePath(path string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if path == "" {
+		path = defaultCachePath()
+	}
+	cachePath = path
+	cached = nil
+}
+
+// defaultCachePath returns the rates cache file in the smartcalc config directory
+func defaultCachePath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		configDir = os.TempDir()
+	}
+	return filepath.Join(configDir, "smartcalc", "rates.json")
+}
+
+// getRates returns current rates, refreshing them when the cache is older than
+// cacheTTL. If refreshing fails, stale cached rates are returned with stale=true.
+func getRates() (rates *Rates, stale bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if cached == nil {
+		cached = loadCache()
+	}
+	if cached != nil && time.Since(cached.FetchedAt) < cacheTTL {
+		return cached, false, nil
+	}
+
+	fresh, fetchErr := source()
+	if fetchErr == nil {
+		fresh.FetchedAt = time.Now()
+		cached = fresh
+		saveCache(fresh)
+		return fresh, false, nil
+	}
+
+	if cached != nil {
+		return cached, true, nil
+	}
+	return nil, false, fmt.Errorf("exchange rates unavailable: %v", fetchErr)
+}
+
+// loadCache reads cached rates from disk
+func loadCache() *Rates {
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil
+	}
+	var r Rates
+	if err := json.Unmarshal(data, &r); err != nil || len(r.Rates) == 0 {
+		return nil
+	}
+	return &r
+}
+
+// saveCache writes rates to disk
+func saveCache(r *Rates) {
+	os.MkdirAll(filepath.Dir(cachePath), 0755)
+	data, _ := json.Marshal(r)
+	os.WriteFile(cachePath, data, 0644)
+}
+
+// erAPIResponse represents the response from open.er-api.com
+type erAPIResponse struct {
+	Result             string             `json:"result"`
+	BaseCode           string             `json:"base_code"`
+	TimeLastUpdateUnix int64              `json:"time_last_update_unix"`
+	Rates              map[string]float64 `json:"rates"`
+}
+
+// fetchRates queries the public open.er-api.com endpoint for USD-based rates
+func fetchRates() (*Rates, error) {
+	resp, err := httpClient.Get("https://open.er-api.com/v6/latest/USD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exchange rate service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("exchange rate service returned status %d", resp.StatusCode)
+	}
+
+	var result erAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse exchange rate response: %v", err)
+	}
+	if result.Result != "success" || len(result.Rates) == 0 {
+		return nil, fmt.Errorf("exchange rate service returned no rates")
+	}
+
+	return &Rates{
+		Base:  result.BaseCode,
+		Date:  time.Unix(result.TimeLastUpdateUnix, 0).UTC().Format("2006-01-02"),
+		Rates: result.Rates,
+	}, nil
+}
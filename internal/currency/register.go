@@ -0,0 +1,14 @@
+package currency
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// Exchange rates may be fetched over the network
+	registry.Register(registry.Evaluator{
+		Name:     "currency",
+		Priority: registry.PriorityCurrency,
+		Traits:   registry.Expensive | registry.ReportsErrors,
+		Detect:   IsCurrencyExpression,
+		Eval:     registry.TextEval(EvalCurrency),
+	})
+}
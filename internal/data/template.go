@@ -0,0 +1,108 @@
+package data
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// placeholderPattern matches a placeholder: {{name}} or {{name:default}}
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z][A-Za-z0-9_ ]*?)\s*(?::([^{}]*))?\}\}`)
+
+// numberPattern matches a number as a placeholder takes it: "250000",
+// "$250,000", "-1.5", "6.5%"
+var numberPattern = regexp.MustCompile(`^[-+]?[$€£¥]?\s*([\d,_]*\.?\d+)\s*%?$`)
+
+// Placeholder is a value a template asks for
+type Placeholder struct {
+	Name    string `json:"name"`
+	Default string `json:"default"`
+	Numeric bool   `json:"numeric"`
+}
+
+// Template is a snippet whose content has placeholders. Inserting it asks
+// for a value of each placeholder first, offering the default after the
+// colon:
+//
+//	loan {{principal:$250,000}} at {{rate:6.5}}% for {{years:30}} years =
+//
+// A placeholder whose default is a number, such as $250,000, 6.5 or 15%,
+// only takes numbers. A name used twice is asked for once.
+type Template struct {
+	Name         string        `json:"name"`
+	Content      string        `json:"content"`
+	Placeholders []Placeholder `json:"placeholders"`
+}
+
+// Template returns the snippet as a template, or false if its content has no
+// placeholders
+func (s Snippet) Template() (Template, bool) {
+	t, err := ParseTemplate(s.Name, s.Content)
+	if err != nil || len(t.Placeholders) == 0 {
+		return Template{}, false
+	}
+	return t, true
+}
+
+// ParseTemplate reads the placeholders of a template's content, in the order
+// they first appear
+func ParseTemplate(name, content string) (Template, error) {
+	t := Template{Name: name, Content: content}
+	seen := make(map[string]int)
+	for _, m := range placeholderPattern.FindAllStringSubmatch(content, -1) {
+		p := Placeholder{Name: m[1], Default: strings.TrimSpace(m[2])}
+		p.Numeric = isNumber(p.Default)
+		if i, ok := seen[p.Name]; ok {
+			if p.Default != "" && p.Default != t.Placeholders[i].Default {
+				return Template{}, fmt.Errorf("placeholder %s has two defaults: %q and %q", p.Name, t.Placeholders[i].Default, p.Default)
+			}
+			continue
+		}
+		seen[p.Name] = len(t.Placeholders)
+		t.Placeholders = append(t.Placeholders, p)
+	}
+	if rest := placeholderPattern.ReplaceAllString(content, ""); strings.Contains(rest, "{{") {
+		return Template{}, fmt.Errorf("unterminated placeholder in %q", content)
+	}
+	return t, nil
+}
+
+// RenderTemplate replaces the placeholders of a template's content with
+// values by name. A missing or blank value takes the placeholder's default;
+// a numeric placeholder rejects a value that is not a number.
+func RenderTemplate(content string, values map[string]string) (string, error) {
+	t, err := ParseTemplate("", content)
+	if err != nil {
+		return "", err
+	}
+	resolved := make(map[string]string, len(t.Placeholders))
+	for _, p := range t.Placeholders {
+		value := strings.TrimSpace(values[p.Name])
+		if value == "" {
+			value = p.Default
+		}
+		switch {
+		case value == "":
+			return "", fmt.Errorf("%s needs a value", p.Name)
+		case p.Numeric && !isNumber(value):
+			return "", fmt.Errorf("%s must be a number, not %q", p.Name, value)
+		}
+		resolved[p.Name] = value
+	}
+	return placeholderPattern.ReplaceAllStringFunc(content, func(m string) string {
+		return resolved[placeholderPattern.FindStringSubmatch(m)[1]]
+	}), nil
+}
+
+// isNumber reports whether a value is a number, possibly with a currency
+// sign, thousands separators or a percent sign
+func isNumber(s string) bool {
+	m := numberPattern.FindStringSubmatch(s)
+	if m == nil {
+		return false
+	}
+	digits := strings.NewReplacer(",", "", "_", "").Replace(m[1])
+	_, err := strconv.ParseFloat(digits, 64)
+	return err == nil
+}
@@ -0,0 +1,82 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTemplate(t *testing.T) {
+	content := "principal = {{principal:$250,000}} =\nloan principal at {{ rate : 6.5% }} for {{years:30}} years, {{note}} {{principal}} =\n"
+	got, err := ParseTemplate("Mortgage", content)
+	if err != nil {
+		t.Fatalf("ParseTemplate error: %v", err)
+	}
+	want := []Placeholder{
+		{Name: "principal", Default: "$250,000", Numeric: true},
+		{Name: "rate", Default: "6.5%", Numeric: true},
+		{Name: "years", Default: "30", Numeric: true},
+		{Name: "note", Default: "", Numeric: false},
+	}
+	if !reflect.DeepEqual(got.Placeholders, want) {
+		t.Errorf("placeholders = %+v, want %+v", got.Placeholders, want)
+	}
+	if got.Name != "Mortgage" || got.Content != content {
+		t.Errorf("ParseTemplate = %q, %q; want the name and content", got.Name, got.Content)
+	}
+}
+
+func TestParseTemplateErrors(t *testing.T) {
+	for _, content := range []string{
+		"loan {{principal:$250,000 at 6% =",
+		"{{x:1}} + {{x:2}} =",
+	} {
+		if _, err := ParseTemplate("", content); err == nil {
+			t.Errorf("ParseTemplate(%q) succeeded, want an error", content)
+		}
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	content := "loan {{principal:$250,000}} at {{rate:6.5}}% for {{years:30}} years = # {{who:me}}, {{principal}}"
+	tests := []struct {
+		values   map[string]string
+		expected string
+		err      string
+	}{
+		{nil, "loan $250,000 at 6.5% for 30 years = # me, $250,000", ""},
+		{map[string]string{"principal": "300000", "rate": " 7.25 ", "who": "Ann and Bo"}, "loan 300000 at 7.25% for 30 years = # Ann and Bo, 300000", ""},
+		{map[string]string{"years": "", "rate": "5"}, "loan $250,000 at 5% for 30 years = # me, $250,000", ""},
+		{map[string]string{"rate": "six"}, "", `rate must be a number, not "six"`},
+		{map[string]string{"principal": "$1,2x"}, "", `principal must be a number, not "$1,2x"`},
+	}
+	for _, tt := range tests {
+		got, err := RenderTemplate(content, tt.values)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("RenderTemplate(%v) error = %v, want %q", tt.values, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.expected {
+			t.Errorf("RenderTemplate(%v) = %q, %v; want %q", tt.values, got, err, tt.expected)
+		}
+	}
+
+	if _, err := RenderTemplate("hello {{name}} =", nil); err == nil || err.Error() != "name needs a value" {
+		t.Errorf("RenderTemplate without a value for a placeholder with no default: %v", err)
+	}
+}
+
+func TestSnippetTemplate(t *testing.T) {
+	if _, ok := (Snippet{"Arithmetic", "10 + 20 * 3 =\n\n"}).Template(); ok {
+		t.Error("a snippet without placeholders is a template")
+	}
+	for _, category := range GetSnippetCategories() {
+		for _, s := range category.Snippets {
+			_, ok := s.Template()
+			if ok != (category.Name == "Templates") {
+				t.Errorf("%s/%s: Template() ok = %v", category.Name, s.Name, ok)
+			}
+		}
+	}
+}
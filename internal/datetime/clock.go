@@ -0,0 +1,29 @@
+package datetime
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	clockMu sync.RWMutex
+	clock   = time.Now
+)
+
+// Now returns the current time used for "now", "today" and relative dates
+func Now() time.Time {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	return clock()
+}
+
+// SetClock replaces the time source, e.g. to freeze "now" for one evaluation
+// pass so every reference agrees. nil restores the system clock.
+func SetClock(fn func() time.Time) {
+	clockMu.Lock()
+	defer clockMu.Unlock()
+	if fn == nil {
+		fn = time.Now
+	}
+	clock = fn
+}
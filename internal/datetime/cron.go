@@ -0,0 +1,433 @@
+package datetime
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronField describes one of the five fields of a cron schedule
+type cronField struct {
+	name     string
+	min, max int
+	unit     string         // what a step counts: "every 2 hours"
+	names    map[string]int // month or weekday names, if the field has them
+}
+
+// cronFields are the fields of a schedule in order. Day of week 7 is Sunday
+// like 0, as in Vixie cron.
+var cronFields = [5]cronField{
+	{name: "minute", min: 0, max: 59, unit: "minute"},
+	{name: "hour", min: 0, max: 23, unit: "hour"},
+	{name: "day of month", min: 1, max: 31, unit: "day"},
+	{name: "month", min: 1, max: 12, unit: "month", names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	{name: "day of week", min: 0, max: 7, unit: "day", names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+// Indexes of the fields of a schedule
+const (
+	cronMinute = iota
+	cronHour
+	cronDayOfMonth
+	cronMonth
+	cronDayOfWeek
+)
+
+// cronMacros are the shorthand schedules of Vixie cron
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronTerm is one comma-separated part of a field: "*", "5", "9-17" or
+// any of them with a step, "*/15"
+type cronTerm struct {
+	start, end, step int
+	star             bool
+}
+
+// CronSchedule is a parsed five-field cron schedule
+type CronSchedule struct {
+	terms [5][]cronTerm
+	sets  [5]uint64 // the values each field matches, as bits
+	// A day field starting with "*" doesn't restrict the day, so the other
+	// day field alone decides; when both are restricted either may match
+	domStar, dowStar bool
+}
+
+// ParseCron parses a standard five-field cron schedule, "*/15 9-17 * * 1-5",
+// or a macro such as "@daily". An invalid field is reported by name.
+func ParseCron(spec string) (*CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if macro, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("a cron schedule has 5 fields (minute hour day month weekday), not %d", len(fields))
+	}
+	s := &CronSchedule{
+		domStar: strings.HasPrefix(fields[cronDayOfMonth], "*"),
+		dowStar: strings.HasPrefix(fields[cronDayOfWeek], "*"),
+	}
+	for i, text := range fields {
+		def := cronFields[i]
+		for _, part := range strings.Split(strings.ToLower(text), ",") {
+			term, err := parseCronTerm(part, def)
+			if err != nil {
+				return nil, fmt.Errorf("%s field %q: %v", def.name, text, err)
+			}
+			s.terms[i] = append(s.terms[i], term)
+			for v := term.start; v <= term.end; v += term.step {
+				s.sets[i] |= 1 << uint(v)
+			}
+		}
+	}
+	if s.sets[cronDayOfWeek]&(1<<7) != 0 {
+		s.sets[cronDayOfWeek] |= 1 // 7 is Sunday
+	}
+	return s, nil
+}
+
+// parseCronTerm parses one part of a field: a value, a range, "*", with an
+// optional step. A value with a step runs to the end of the field: "5/15"
+// is "5-59/15".
+func parseCronTerm(part string, def cronField) (cronTerm, error) {
+	base, stepText, hasStep := strings.Cut(part, "/")
+	term := cronTerm{step: 1}
+	if hasStep {
+		step, err := strconv.Atoi(stepText)
+		if err != nil || step < 1 {
+			return term, fmt.Errorf("step %q is not a positive number", stepText)
+		}
+		term.step = step
+	}
+	switch {
+	case base == "*":
+		term.start, term.end, term.star = def.min, def.max, true
+		if def.max == 7 {
+			term.end = 6 // "*" in the day of week is 0-6, so Sunday counts once
+		}
+	case strings.Contains(base, "-"):
+		from, to, _ := strings.Cut(base, "-")
+		var err error
+		if term.start, err = cronValue(from, def); err != nil {
+			return term, err
+		}
+		if term.end, err = cronValue(to, def); err != nil {
+			return term, err
+		}
+		if term.start > term.end {
+			return term, fmt.Errorf("range %s runs backwards", base)
+		}
+	default:
+		v, err := cronValue(base, def)
+		if err != nil {
+			return term, err
+		}
+		term.start, term.end = v, v
+		if hasStep {
+			term.end = def.max
+		}
+	}
+	return term, nil
+}
+
+// cronValue reads a value of a field, a number or a month or weekday name
+func cronValue(s string, def cronField) (int, error) {
+	if v, ok := def.names[s]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		if s == "" {
+			return 0, fmt.Errorf("missing value")
+		}
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	if v < def.min || v > def.max {
+		return 0, fmt.Errorf("%d is outside %d-%d", v, def.min, def.max)
+	}
+	return v, nil
+}
+
+// has checks if a field of the schedule matches a value
+func (s *CronSchedule) has(field, v int) bool {
+	return s.sets[field]&(1<<uint(v)) != 0
+}
+
+// dayMatches checks if the schedule fires on the day of t. As in Vixie cron,
+// when both day fields are restricted the day matches either of them.
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	dom := s.has(cronDayOfMonth, t.Day())
+	dow := s.has(cronDayOfWeek, int(t.Weekday()))
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next returns the first time after t the schedule fires, in t's location,
+// or false if it doesn't fire in the next five years ("0 0 30 2 *")
+func (s *CronSchedule) Next(t time.Time) (time.Time, bool) {
+	loc := t.Location()
+	limit := t.AddDate(5, 0, 0)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+	for t.Before(limit) {
+		var next time.Time
+		switch {
+		case !s.has(cronMonth, int(t.Month())):
+			next = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			next = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !s.has(cronHour, t.Hour()):
+			next = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !s.has(cronMinute, t.Minute()):
+			next = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+		if !next.After(t) {
+			next = t.Add(time.Minute) // the wall clock went back at a DST change
+		}
+		t = next
+	}
+	return time.Time{}, false
+}
+
+// Describe explains the schedule in words: "every 15 minutes, 9am–5pm, Mon–Fri"
+func (s *CronSchedule) Describe() string {
+	parts := []string{s.describeTime()}
+	dom, dow := "", ""
+	if !s.domStar || s.terms[cronDayOfMonth][0].step > 1 {
+		dom = describeTerms(s.terms[cronDayOfMonth], cronFields[cronDayOfMonth], ordinal)
+		if !s.terms[cronDayOfMonth][0].star {
+			dom = "on the " + dom
+		}
+	}
+	if !s.dowStar || s.terms[cronDayOfWeek][0].step > 1 {
+		dow = describeTerms(s.terms[cronDayOfWeek], cronFields[cronDayOfWeek], weekdayName)
+	}
+	switch {
+	case dom != "" && dow != "" && !s.domStar && !s.dowStar:
+		parts = append(parts, dom+" or "+dow)
+	case dom != "" && dow != "":
+		parts = append(parts, dom, dow)
+	case dom != "":
+		parts = append(parts, dom)
+	case dow != "":
+		parts = append(parts, dow)
+	}
+	if months := s.terms[cronMonth]; !months[0].star || months[0].step > 1 {
+		text := describeTerms(months, cronFields[cronMonth], monthName)
+		if !months[0].star {
+			text = "in " + text
+		}
+		parts = append(parts, text)
+	}
+	if len(parts) == 1 && strings.HasPrefix(parts[0], "at ") {
+		return "every day " + parts[0]
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeTime explains the minute and hour fields: "at 9:30am", "every 15
+// minutes, 9am–5pm", "every 2 hours at :30"
+func (s *CronSchedule) describeTime() string {
+	minutes, hours := s.terms[cronMinute], s.terms[cronHour]
+	minute, minuteSingle := singleValue(minutes)
+	if minuteSingle && allSingle(hours) {
+		var times []string
+		for _, h := range hours {
+			times = append(times, clockTime(h.start, minute))
+		}
+		return "at " + joinList(times)
+	}
+
+	var text string
+	switch {
+	case minutes[0].star && len(minutes) == 1 && minutes[0].step == 1:
+		text = "every minute"
+	case minutes[0].star && len(minutes) == 1:
+		text = fmt.Sprintf("every %d minutes", minutes[0].step)
+	case minuteSingle && minute == 0:
+		text = "every hour"
+	case minuteSingle:
+		text = fmt.Sprintf("every hour at :%02d", minute)
+	default:
+		text = "minutes " + describeTerms(minutes, cronFields[cronMinute], strconv.Itoa) + " of every hour"
+	}
+
+	h := hours[0]
+	switch {
+	case h.star && len(hours) == 1 && h.step == 1:
+		return text
+	case h.star && len(hours) == 1 && minuteSingle:
+		text = fmt.Sprintf("every %d hours", h.step)
+		if minute != 0 {
+			text += fmt.Sprintf(" at :%02d", minute)
+		}
+		return text
+	}
+	return text + ", " + describeTerms(hours, cronFields[cronHour], func(h int) string { return clockTime(h, 0) })
+}
+
+// singleValue returns the value of a field that matches exactly one
+func singleValue(terms []cronTerm) (int, bool) {
+	if len(terms) == 1 && !terms[0].star && terms[0].start == terms[0].end {
+		return terms[0].start, true
+	}
+	return 0, false
+}
+
+// allSingle checks if every term of a field is a single value: "9,13,17"
+func allSingle(terms []cronTerm) bool {
+	for _, t := range terms {
+		if t.star || t.start != t.end {
+			return false
+		}
+	}
+	return true
+}
+
+// describeTerms explains the terms of a field with each value named by
+// name: "Mon–Fri", "1st and 15th", "every 2 hours from 9am to 5pm"
+func describeTerms(terms []cronTerm, def cronField, name func(int) string) string {
+	var texts []string
+	for _, t := range terms {
+		var text string
+		switch {
+		case t.star && t.step > 1:
+			text = fmt.Sprintf("every %d %ss", t.step, def.unit)
+		case t.step > 1:
+			text = fmt.Sprintf("every %d %ss from %s to %s", t.step, def.unit, name(t.start), name(t.end))
+		case t.start == t.end:
+			text = name(t.start)
+		default:
+			text = name(t.start) + "–" + name(t.end)
+		}
+		texts = append(texts, text)
+	}
+	return joinList(texts)
+}
+
+// joinList joins items as "a, b and c"
+func joinList(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+// clockTime writes a time of day the short way: "9am", "5:30pm", "12am"
+func clockTime(hour, minute int) string {
+	suffix := "am"
+	if hour >= 12 {
+		suffix = "pm"
+	}
+	h := hour % 12
+	if h == 0 {
+		h = 12
+	}
+	if minute == 0 {
+		return fmt.Sprintf("%d%s", h, suffix)
+	}
+	return fmt.Sprintf("%d:%02d%s", h, minute, suffix)
+}
+
+// ordinal writes a day of the month: "1st", "22nd"
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// weekdayName writes a day of the week, where 7 is Sunday like 0
+func weekdayName(d int) string {
+	return time.Weekday(d % 7).String()[:3]
+}
+
+// monthName writes a month: "Jan"
+func monthName(m int) string {
+	return time.Month(m).String()[:3]
+}
+
+// cronPattern matches "cron "*/15 9-17 * * 1-5" next 5 in UTC" and "cron
+// @daily describe"; the schedule may be quoted or not
+var cronPattern = regexp.MustCompile(`(?i)^cron\s+(?:"([^"]*)"|'([^']*)'|(@\w+|\S+(?:\s+\S+){4}))\s+(?:(next)(?:\s+(\d+))?(?:\s+in\s+(.+))?|describe|explain)$`)
+
+// maxCronTimes bounds how many fire times "next N" lists
+const maxCronTimes = 100
+
+// IsCronExpression checks if an expression previews a cron schedule
+func IsCronExpression(expr string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(expr)), "cron ")
+}
+
+// EvalCron lists the next fire times of a cron schedule, one per "> " line,
+// in local time or the time zone after "in", or describes the schedule in words
+func EvalCron(expr string) (string, error) {
+	m := cronPattern.FindStringSubmatch(strings.TrimSpace(expr))
+	if m == nil {
+		return "", fmt.Errorf(`use cron "<schedule>" next 5 or cron "<schedule>" describe`)
+	}
+	schedule, err := ParseCron(m[1] + m[2] + m[3])
+	if err != nil {
+		return "", err
+	}
+	if m[4] == "" {
+		return schedule.Describe(), nil
+	}
+
+	count := 1
+	if m[5] != "" {
+		count, _ = strconv.Atoi(m[5])
+		if count < 1 || count > maxCronTimes {
+			return "", fmt.Errorf("list 1 to %d fire times", maxCronTimes)
+		}
+	}
+	loc := time.Local
+	if m[6] != "" {
+		if loc, err = LookupTimezone(m[6]); err != nil {
+			return "", err
+		}
+	}
+
+	var times []string
+	t := Now().In(loc)
+	for range count {
+		next, ok := schedule.Next(t)
+		if !ok {
+			break
+		}
+		times = append(times, FormatTime(next))
+		t = next
+	}
+	switch {
+	case len(times) == 0:
+		return "", fmt.Errorf("the schedule never fires")
+	case count == 1:
+		return times[0], nil
+	}
+	return "\n> " + strings.Join(times, "\n> "), nil
+}
@@ -0,0 +1,167 @@
+package datetime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	utc := time.UTC
+	tests := []struct {
+		spec  string
+		after time.Time
+		want  []string
+	}{
+		// Friday 17:20: the rest of the afternoon, then Monday morning
+		{"*/15 9-17 * * 1-5", time.Date(2026, 10, 16, 17, 20, 0, 0, utc),
+			[]string{"2026-10-16 17:30", "2026-10-16 17:45", "2026-10-19 09:00"}},
+		// Both day fields restricted: the 13th or any Friday
+		{"0 0 13 * 5", time.Date(2026, 2, 1, 0, 0, 0, 0, utc),
+			[]string{"2026-02-06 00:00", "2026-02-13 00:00", "2026-02-20 00:00", "2026-02-27 00:00", "2026-03-06 00:00", "2026-03-13 00:00"}},
+		// A day field starting with "*" leaves the other to decide
+		{"0 0 */2 * 1", time.Date(2026, 6, 1, 0, 0, 0, 0, utc),
+			[]string{"2026-06-15 00:00", "2026-06-29 00:00"}},
+		// Day of week 7 is Sunday
+		{"30 6 * * 7", time.Date(2026, 10, 16, 0, 0, 0, 0, utc),
+			[]string{"2026-10-18 06:30", "2026-10-25 06:30"}},
+		{"0 12 29 feb *", time.Date(2026, 1, 1, 0, 0, 0, 0, utc),
+			[]string{"2028-02-29 12:00"}},
+		{"@hourly", time.Date(2026, 10, 16, 8, 0, 0, 0, utc),
+			[]string{"2026-10-16 09:00", "2026-10-16 10:00"}},
+		{"5/20 * * * *", time.Date(2026, 10, 16, 8, 0, 0, 0, utc),
+			[]string{"2026-10-16 08:05", "2026-10-16 08:25", "2026-10-16 08:45", "2026-10-16 09:05"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) error: %v", tt.spec, err)
+			}
+			next := tt.after
+			for _, want := range tt.want {
+				var ok bool
+				if next, ok = s.Next(next); !ok {
+					t.Fatalf("Next() found no time, want %s", want)
+				}
+				if got := next.Format("2006-01-02 15:04"); got != want {
+					t.Fatalf("Next() = %s, want %s", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCronNextAcrossDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no time zone data")
+	}
+	s, _ := ParseCron("30 2 * * *")
+	// 2:30am doesn't exist on 2026-03-08, when clocks skip from 2am to 3am
+	next, ok := s.Next(time.Date(2026, 3, 7, 12, 0, 0, 0, ny))
+	if !ok || next.Format("2006-01-02 15:04 MST") != "2026-03-09 02:30 EDT" {
+		t.Errorf("Next() = %v, want 2026-03-09 02:30 EDT", next)
+	}
+}
+
+func TestCronNextNever(t *testing.T) {
+	s, _ := ParseCron("0 0 30 2 *")
+	if next, ok := s.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); ok {
+		t.Errorf("Next() = %v, want none", next)
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"* * * *", "a cron schedule has 5 fields (minute hour day month weekday), not 4"},
+		{"61 * * * *", `minute field "61": 61 is outside 0-59`},
+		{"0 9-25 * * *", `hour field "9-25": 25 is outside 0-23`},
+		{"0 0 0 * *", `day of month field "0": 0 is outside 1-31`},
+		{"0 0 * foo *", `month field "foo": "foo" is not a number`},
+		{"0 0 * * 5-1", `day of week field "5-1": range 5-1 runs backwards`},
+		{"*/0 * * * *", `minute field "*/0": step "0" is not a positive number`},
+		{"0,,5 * * * *", `minute field "0,,5": missing value`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := ParseCron(tt.spec)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("ParseCron(%q) error = %v, want %q", tt.spec, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronDescribe(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"*/15 9-17 * * 1-5", "every 15 minutes, 9am–5pm, Mon–Fri"},
+		{"* * * * *", "every minute"},
+		{"0 0 * * *", "every day at 12am"},
+		{"30 8 * * *", "every day at 8:30am"},
+		{"0 9,13,17 * * *", "every day at 9am, 1pm and 5pm"},
+		{"0 * * * *", "every hour"},
+		{"15 */2 * * *", "every 2 hours at :15"},
+		{"0,30 9-17 * * *", "minutes 0 and 30 of every hour, 9am–5pm"},
+		{"0 22 * * mon-fri", "at 10pm, Mon–Fri"},
+		{"30 8 1,15 * 1", "at 8:30am, on the 1st and 15th or Mon"},
+		{"0 0 */2 * *", "at 12am, every 2 days"},
+		{"0 12 * jan-mar sat,sun", "at 12pm, Sat and Sun, in Jan–Mar"},
+		{"0 0 1 * *", "at 12am, on the 1st"},
+		{"@weekly", "at 12am, Sun"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) error: %v", tt.spec, err)
+			}
+			if got := s.Describe(); got != tt.want {
+				t.Errorf("Describe(%q) = %q, want %q", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalCron(t *testing.T) {
+	SetClock(func() time.Time { return time.Date(2026, 10, 16, 17, 20, 0, 0, time.UTC) })
+	defer SetClock(nil)
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{`cron "*/15 9-17 * * 1-5" next 3 in UTC`, "\n> 2026-10-16 17:30 UTC\n> 2026-10-16 17:45 UTC\n> 2026-10-19 09:00 UTC"},
+		{`cron 0 9 * * * next in Tokyo`, "2026-10-17 09:00 JST"},
+		{`cron '*/15 9-17 * * 1-5' describe`, "every 15 minutes, 9am–5pm, Mon–Fri"},
+		{`cron @daily explain`, "every day at 12am"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if !IsCronExpression(tt.expr) {
+				t.Fatalf("IsCronExpression(%q) = false", tt.expr)
+			}
+			got, err := EvalCron(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalCron(%q) error: %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalCron(%q) = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+
+	for _, expr := range []string{`cron "0 0 30 2 *" next`, `cron "* * *" next`, `cron "* * * * *" next 500`, `cron "* * * * *" soon`} {
+		if _, err := EvalCron(expr); err == nil {
+			t.Errorf("EvalCron(%q) should fail", expr)
+		}
+	}
+}
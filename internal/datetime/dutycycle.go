@@ -0,0 +1,42 @@
+package datetime
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DutyCyclePattern matches how much of the time something runs: "24/7",
+// "6 hours/day", "8h a day" or "8 hours/day 5 days/week". It has no groups,
+// so it can be embedded in larger patterns; ParseDutyCycle reads the match.
+const DutyCyclePattern = `(?:24\s*/\s*7|\d+(?:\.\d+)?\s*(?:h|hrs?|hours?)\s*(?:/\s*|per\s+|an?\s+)day(?:,?\s*\d+(?:\.\d+)?\s*days?\s*(?:/\s*|per\s+|an?\s+)week)?)`
+
+// dutyCyclePattern reads the hours a day and the days a week of a duty cycle
+var dutyCyclePattern = regexp.MustCompile(`^(?:24\s*/\s*7|(\d+(?:\.\d+)?)\s*(?:h|hrs?|hours?)\s*(?:/\s*|per\s+|an?\s+)day(?:,?\s*(\d+(?:\.\d+)?)\s*days?\s*(?:/\s*|per\s+|an?\s+)week)?)$`)
+
+// ParseDutyCycle reads a duty cycle as the share of the time something runs:
+// "24/7" is 1, "6 hours/day" is 0.25 and "8 hours/day 5 days/week" is 40/168
+func ParseDutyCycle(s string) (float64, error) {
+	s = strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	m := dutyCyclePattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("invalid duty cycle %q", s)
+	}
+	if m[1] == "" {
+		return 1, nil // 24/7
+	}
+	hours, _ := strconv.ParseFloat(m[1], 64)
+	if hours > 24 {
+		return 0, fmt.Errorf("a day has 24 hours, not %s", m[1])
+	}
+	share := hours / 24
+	if m[2] != "" {
+		days, _ := strconv.ParseFloat(m[2], 64)
+		if days > 7 {
+			return 0, fmt.Errorf("a week has 7 days, not %s", m[2])
+		}
+		share *= days / 7
+	}
+	return share, nil
+}
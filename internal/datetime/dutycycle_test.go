@@ -0,0 +1,45 @@
+package datetime
+
+import (
+	"math"
+	"regexp"
+	"testing"
+)
+
+func TestParseDutyCycle(t *testing.T) {
+	tests := []struct {
+		cycle   string
+		want    float64
+		wantErr bool
+	}{
+		{"24/7", 1, false},
+		{"24 / 7", 1, false},
+		{"6 hours/day", 0.25, false},
+		{"6h per day", 0.25, false},
+		{"8 hrs a day", 1.0 / 3, false},
+		{"8 Hours/Day 5 days/week", 40.0 / 168, false},
+		{"12 hours/day, 3.5 days a week", 0.25, false},
+		{"25 hours/day", 0, true},
+		{"8 hours/day 8 days/week", 0, true},
+		{"6 hours", 0, true},
+	}
+
+	embedded := regexp.MustCompile(`^(?i)` + DutyCyclePattern + `$`)
+	for _, tt := range tests {
+		t.Run(tt.cycle, func(t *testing.T) {
+			got, err := ParseDutyCycle(tt.cycle)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDutyCycle(%q) = %v, want error", tt.cycle, got)
+				}
+				return
+			}
+			if err != nil || math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("ParseDutyCycle(%q) = %v, %v, want %v", tt.cycle, got, err, tt.want)
+			}
+			if !embedded.MatchString(tt.cycle) {
+				t.Errorf("DutyCyclePattern does not match %q", tt.cycle)
+			}
+		})
+	}
+}
@@ -3,9 +3,12 @@ package datetime
 import (
 	"fmt"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+
+	"smartcalc/internal/utils"
 )
 
 // RefResolver is a function that resolves line references like \1 to their string values
@@ -32,6 +35,10 @@ var handlerChain = []Handler{
 	HandlerFunc(handleNowIn),
 	HandlerFunc(handleNow),
 	HandlerFunc(handleToday),
+	HandlerFunc(handleRecurrence),  // before the duration handlers: "every 2 weeks" is not a duration
+	HandlerFunc(handleTimeSpan),    // before handleDateRange: "time until Dec 25" is not a range
+	HandlerFunc(handleEpochToDate), // before handleNumberPlusDuration: "1718000000 ms" is not a duration
+	HandlerFunc(handleDateToEpoch),
 	HandlerFunc(handleNumberPlusDuration),
 	HandlerFunc(handleTimeConversion),
 	HandlerFunc(handleDurationConversion),
@@ -80,6 +87,19 @@ func resolveRefsInExpr(expr string, resolver RefResolver) string {
 // It uses the Chain of Responsibility pattern to delegate to handlers.
 func EvalDateTime(expr string) (string, error) {
 	expr = strings.TrimSpace(expr)
+
+	// "timezones for CST" asks about the abbreviation rather than using it
+	if m := timezonesForPattern.FindStringSubmatch(expr); m != nil {
+		return evalTimezonesFor(m[1])
+	}
+	if tokens := findAmbiguousTokens(expr); len(tokens) > 0 {
+		return evalAmbiguous(expr, tokens)
+	}
+	return evalHandlers(expr)
+}
+
+// evalHandlers runs the handler chain on an expression without ambiguous abbreviations
+func evalHandlers(expr string) (string, error) {
 	exprLower := strings.ToLower(expr)
 
 	for _, h := range handlerChain {
@@ -91,6 +111,109 @@ func EvalDateTime(expr string) (string, error) {
 	return "", fmt.Errorf("unable to evaluate date/time expression: %s", expr)
 }
 
+// evalAmbiguous handles expressions using ambiguous abbreviations such as IST.
+// In strict mode it refuses them and in default mode it assumes the most
+// common candidate of each; otherwise every combination of candidates is
+// evaluated and listed on "> Region: result" lines.
+func evalAmbiguous(expr string, tokens []ambiguousToken) (string, error) {
+	switch ambiguityMode {
+	case AmbiguityRequireRegion:
+		abbr := tokens[0].abbr
+		return "", fmt.Errorf("ambiguous timezone %s: use %s", strings.ToUpper(abbr), ambiguityHint(abbr))
+	case AmbiguityAssumeDefault:
+		return evalAssumed(expr, tokens)
+	}
+
+	type variant struct {
+		expr   string
+		labels []string
+	}
+	variants := []variant{{expr: expr}}
+
+	// Substitute from the last token backwards so earlier offsets stay valid;
+	// the first abbreviation's candidates end up outermost in the listing
+	for t := len(tokens) - 1; t >= 0; t-- {
+		tok := tokens[t]
+		var next []variant
+		for _, c := range AmbiguousAbbreviations[tok.abbr] {
+			for _, v := range variants {
+				named := fmt.Sprintf("%s(%s)", strings.ToUpper(tok.abbr), c.Region)
+				next = append(next, variant{
+					expr:   v.expr[:tok.start] + named + v.expr[tok.end:],
+					labels: append([]string{c.Region}, v.labels...),
+				})
+			}
+		}
+		variants = next
+	}
+
+	var sb strings.Builder
+	for _, v := range variants {
+		result, err := evalHandlers(v.expr)
+		if err != nil {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n> %s: %s", strings.Join(v.labels, ", "), result))
+	}
+	if sb.Len() == 0 {
+		return "", fmt.Errorf("unable to evaluate date/time expression: %s", expr)
+	}
+	return sb.String(), nil
+}
+
+// evalAssumed evaluates an expression with the most common candidate of each
+// ambiguous abbreviation and notes the assumption after the result:
+// "2025-01-15 16:00 UTC (assumed US Central)"
+func evalAssumed(expr string, tokens []ambiguousToken) (string, error) {
+	var assumed []string
+	for t := len(tokens) - 1; t >= 0; t-- {
+		tok := tokens[t]
+		c := AmbiguousAbbreviations[tok.abbr][0]
+		expr = expr[:tok.start] + fmt.Sprintf("%s(%s)", strings.ToUpper(tok.abbr), c.Region) + expr[tok.end:]
+		if !slices.Contains(assumed, c.Name) {
+			assumed = append([]string{c.Name}, assumed...)
+		}
+	}
+	result, err := evalHandlers(expr)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s (assumed %s)", result, strings.Join(assumed, ", ")), nil
+}
+
+// timezonesForPattern matches "timezones for CST" and "time zone of Seattle"
+var timezonesForPattern = regexp.MustCompile(`(?i)^time\s*zones?\s+(?:of|in|for)\s+(.+?)\??$`)
+
+// evalTimezonesFor lists the zones an abbreviation, city or offset may
+// stand for, with their current offsets from UTC. An ambiguous abbreviation
+// lists every candidate, the most common first:
+//
+//	timezones for CST = US: America/Chicago (UTC-05:00), China: Asia/Shanghai (UTC+08:00), Cuba: America/Havana (UTC-04:00)
+func evalTimezonesFor(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	now := Now()
+	if candidates, ok := AmbiguousAbbreviations[strings.ToLower(name)]; ok {
+		var zones []string
+		for _, c := range candidates {
+			loc, err := time.LoadLocation(c.Zone)
+			if err != nil {
+				continue
+			}
+			zones = append(zones, fmt.Sprintf("%s: %s (%s)", c.Region, c.Zone, UTCOffset(now.In(loc))))
+		}
+		if len(zones) == 0 {
+			return "", fmt.Errorf("no time zone data for %s", strings.ToUpper(name))
+		}
+		return strings.Join(zones, ", "), nil
+	}
+
+	loc, err := LookupTimezone(name)
+	if err != nil {
+		return "", fmt.Errorf("unknown time zone: %s", name)
+	}
+	return fmt.Sprintf("%s (%s)", loc, UTCOffset(now.In(loc))), nil
+}
+
 // IsDateTimeExpression checks if an expression looks like a date/time expression
 func IsDateTimeExpression(expr string) bool {
 	exprLower := strings.ToLower(expr)
@@ -105,7 +228,7 @@ func IsDateTimeExpression(expr string) bool {
 		"weeks", "week",
 		"months", "month",
 		"years", "year", "yrs", "yr",
-		" in ", " till ", " until ", " to ",
+		" in ", " till ", " until ", " to ", "since ",
 		"am", "pm",
 	}
 
@@ -128,7 +251,13 @@ func IsDateTimeExpression(expr string) bool {
 		}
 	}
 
-	return false
+	// "every quarter" and "every fortnight" name no other keyword; date
+	// and time zone queries and dates like "end of quarter" are recognized
+	// whole
+	if _, ok := parseNaturalDate(normalizeOrdinals(expr), time.Local); ok {
+		return true
+	}
+	return IsRecurrenceExpression(expr) || IsDateQuery(expr) || timezonesForPattern.MatchString(strings.TrimSpace(expr))
 }
 
 func handleNowIn(expr, exprLower string) (string, bool) {
@@ -150,19 +279,19 @@ func handleNowIn(expr, exprLower string) (string, bool) {
 		return "", false
 	}
 
-	return FormatTime(time.Now().In(loc)), true
+	return FormatTime(Now().In(loc)), true
 }
 
 func handleNow(expr, exprLower string) (string, bool) {
 	if exprLower == "now" || exprLower == "now()" {
-		return FormatTime(time.Now()), true
+		return FormatTime(Now()), true
 	}
 	return "", false
 }
 
 func handleToday(expr, exprLower string) (string, bool) {
 	if exprLower == "today" || exprLower == "today()" {
-		now := time.Now()
+		now := Now()
 		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).Format("2006-01-02"), true
 	}
 	return "", false
@@ -232,10 +361,15 @@ func handleDurationConversion(expr, exprLower string) (string, bool) {
 	}
 
 	// Format nicely
+	number := fmt.Sprintf("%.2f", result)
 	if result == float64(int(result)) {
-		return fmt.Sprintf("%.0f %s", result, toUnit), true
+		number = fmt.Sprintf("%.0f", result)
+	}
+	id, ok := durationUnitMessage(toUnit)
+	if !ok {
+		return number + " " + toUnit, true
 	}
-	return fmt.Sprintf("%.2f %s", result, toUnit), true
+	return utils.FormatCount(number, id), true
 }
 
 func handleDateArithmetic(expr, exprLower string) (string, bool) {
@@ -256,10 +390,10 @@ func handleDateArithmetic(expr, exprLower string) (string, bool) {
 	dateLower := strings.ToLower(dateExpr)
 
 	if dateLower == "today" || dateLower == "today()" {
-		now := time.Now()
+		now := Now()
 		baseTime = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
 	} else if dateLower == "now" || dateLower == "now()" {
-		baseTime = time.Now()
+		baseTime = Now()
 	} else {
 		// Try to parse time with timezone like "12 am PST" or "3:00 pm EST"
 		if t, ok := parseTimeWithTimezone(dateExpr); ok {
@@ -292,7 +426,7 @@ func handleDateArithmetic(expr, exprLower string) (string, bool) {
 // parseTimeWithTimezone parses time expressions like "12 am PST", "3:00 pm EST", "14:00 UTC"
 func parseTimeWithTimezone(expr string) (time.Time, bool) {
 	// Pattern: time followed by timezone
-	re := regexp.MustCompile(`(?i)^(\d{1,2}(?::\d{2})?(?::\d{2})?\s*(?:am|pm)?)\s+([A-Za-z]+(?:\s+[A-Za-z]+)?)$`)
+	re := regexp.MustCompile(`(?i)^(\d{1,2}(?::\d{2})?(?::\d{2})?\s*(?:am|pm)?)\s+([A-Za-z]+(?:\s+[A-Za-z]+)?(?:\s*\([A-Za-z ]+\))?)$`)
 	matches := re.FindStringSubmatch(expr)
 	if matches == nil {
 		return time.Time{}, false
@@ -316,7 +450,7 @@ func parseTimeWithTimezone(expr string) (time.Time, bool) {
 
 	for _, format := range timeFormats {
 		if t, err := time.ParseInLocation(format, strings.ToLower(timeStr), loc); err == nil {
-			now := time.Now().In(loc)
+			now := Now().In(loc)
 			return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc), true
 		}
 	}
@@ -325,8 +459,8 @@ func parseTimeWithTimezone(expr string) (time.Time, bool) {
 }
 
 func handleDateTimeConversion(expr, exprLower string) (string, bool) {
-	// Pattern: "2025-09-25 19:00:00 EST in Seattle"
-	re := regexp.MustCompile(`(?i)^(.+?)\s+([A-Z]{2,4})\s+in\s+(\w+(?:\s+\w+)?)$`)
+	// Pattern: "2025-09-25 19:00:00 EST in Seattle" or "2024-06-01 12:00 +02:00 in Tokyo"
+	re := regexp.MustCompile(`(?i)^(.+?)\s+([A-Z]{2,4}(?:\s*\([A-Za-z ]+\))?|(?:UTC|GMT)?[+-]\d{1,2}(?::?\d{2})?)\s+in\s+(\w+(?:\s+\w+)?(?:\s*\([A-Za-z ]+\))?)$`)
 	matches := re.FindStringSubmatch(expr)
 	if matches == nil {
 		return "", false
@@ -354,6 +488,48 @@ func handleDateTimeConversion(expr, exprLower string) (string, bool) {
 	return FormatTime(t.In(toLoc)), true
 }
 
+// timeSpanPattern matches countdowns and elapsed time: "time until Dec 25",
+// "time till 2025-01-01 09:00 EST", "time since 2020-03-15"
+var timeSpanPattern = regexp.MustCompile(`(?i)^time\s+(until|till|since)\s+(.+)$`)
+
+func handleTimeSpan(expr, exprLower string) (string, bool) {
+	m := timeSpanPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return "", false
+	}
+	since := strings.EqualFold(m[1], "since")
+	now := Now()
+	target, ok := parseSpanTarget(strings.TrimSpace(m[2]), now, since)
+	if !ok {
+		return "", false
+	}
+
+	span := FormatDetailedDuration(now, target)
+	switch {
+	case !since && target.Before(now):
+		return fmt.Sprintf(utils.Message(utils.MsgPassedAgo), span), true
+	case since && target.After(now):
+		return fmt.Sprintf(utils.Message(utils.MsgNotYetIn), span), true
+	}
+	return span, true
+}
+
+// parseSpanTarget parses the date a countdown counts to or from. A month and
+// day without a year ("Dec 25") is the next one for "until" and the last one
+// for "since".
+func parseSpanTarget(s string, now time.Time, since bool) (time.Time, bool) {
+	t, ok := parseMonthDay(s, time.Local)
+	if !ok {
+		return parseDateTimeWithZone(s)
+	}
+	if since && t.After(now) {
+		t = t.AddDate(-1, 0, 0)
+	} else if !since && t.Before(now) {
+		t = t.AddDate(1, 0, 0)
+	}
+	return t, true
+}
+
 func handleDateRange(expr, exprLower string) (string, bool) {
 	start, end, err := ParseDateRange(expr)
 	if err != nil {
@@ -362,9 +538,9 @@ func handleDateRange(expr, exprLower string) (string, bool) {
 
 	days := DaysBetween(start, end)
 	if days == float64(int(days)) {
-		return fmt.Sprintf("%.0f days", days), true
+		return utils.FormatCount(fmt.Sprintf("%.0f", days), utils.MsgDay), true
 	}
-	return fmt.Sprintf("%.1f days", days), true
+	return utils.FormatCount(fmt.Sprintf("%.1f", days), utils.MsgDay), true
 }
 
 func handleDateDifference(expr, exprLower string) (string, bool) {
@@ -387,9 +563,9 @@ func handleDateDifference(expr, exprLower string) (string, bool) {
 	var date1 time.Time
 	date1Lower := strings.ToLower(date1Str)
 	if date1Lower == "now" || date1Lower == "now()" {
-		date1 = time.Now()
+		date1 = Now()
 	} else if date1Lower == "today" || date1Lower == "today()" {
-		now := time.Now()
+		now := Now()
 		date1 = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
 	} else {
 		var err error
@@ -403,9 +579,9 @@ func handleDateDifference(expr, exprLower string) (string, bool) {
 	var date2 time.Time
 	date2Lower := strings.ToLower(date2Str)
 	if date2Lower == "now" || date2Lower == "now()" {
-		date2 = time.Now()
+		date2 = Now()
 	} else if date2Lower == "today" || date2Lower == "today()" {
-		now := time.Now()
+		now := Now()
 		date2 = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
 	} else {
 		var err error
@@ -435,7 +611,7 @@ func handleNumberPlusDuration(expr, exprLower string) (string, bool) {
 	// If base is 0, treat as "now"
 	var baseTime time.Time
 	if baseNum == 0 {
-		baseTime = time.Now()
+		baseTime = Now()
 	} else {
 		// Otherwise, this isn't a datetime expression
 		return "", false
@@ -457,6 +633,112 @@ func handleNumberPlusDuration(expr, exprLower string) (string, bool) {
 	return FormatTime(baseTime), true
 }
 
+// Pattern: "1718000000 to date", "1718000000000 ms to date", "1718000000 sec in date"
+var epochToDatePattern = regexp.MustCompile(`(?i)^(\d{1,19})\s*(s|secs?|seconds?|ms|millis|milliseconds?|us|µs|micros|microseconds?)?\s+(?:to|in|as)\s+date$`)
+
+// Pattern: "2024-06-10 08:00 UTC to epoch", "\1 to epoch ms", "now to unix"
+var dateToEpochPattern = regexp.MustCompile(`(?i)^(.+?)\s+(?:to|in|as)\s+(?:epoch|unix|timestamp)(?:\s+(s|ms|us|µs))?$`)
+
+// epochUnit returns the duration of one tick of an epoch value. An explicit
+// unit wins; otherwise it is guessed from the digit count: up to 10 digits are
+// seconds, up to 13 milliseconds and up to 16 microseconds.
+func epochUnit(digits, unit string) (time.Duration, bool) {
+	switch strings.ToLower(unit) {
+	case "":
+	case "ms", "millis", "millisecond", "milliseconds":
+		return time.Millisecond, true
+	case "us", "µs", "micros", "microsecond", "microseconds":
+		return time.Microsecond, true
+	default:
+		return time.Second, true
+	}
+
+	switch n := len(strings.TrimLeft(digits, "0")); {
+	case n <= 10:
+		return time.Second, true
+	case n <= 13:
+		return time.Millisecond, true
+	case n <= 16:
+		return time.Microsecond, true
+	}
+	return 0, false
+}
+
+func handleEpochToDate(expr, exprLower string) (string, bool) {
+	matches := epochToDatePattern.FindStringSubmatch(exprLower)
+	if matches == nil {
+		return "", false
+	}
+
+	unit, ok := epochUnit(matches[1], matches[2])
+	if !ok {
+		return "", false
+	}
+	n, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return "", false
+	}
+
+	var t time.Time
+	switch unit {
+	case time.Millisecond:
+		t = time.UnixMilli(n)
+	case time.Microsecond:
+		t = time.UnixMicro(n)
+	default:
+		t = time.Unix(n, 0)
+	}
+	return FormatTimeSeconds(t), true
+}
+
+func handleDateToEpoch(expr, exprLower string) (string, bool) {
+	matches := dateToEpochPattern.FindStringSubmatch(expr)
+	if matches == nil {
+		return "", false
+	}
+
+	dateStr := strings.TrimSpace(matches[1])
+	var t time.Time
+	switch strings.ToLower(dateStr) {
+	case "now", "now()":
+		t = Now()
+	case "today", "today()":
+		now := Now()
+		t = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	default:
+		var ok bool
+		if t, ok = parseDateTimeWithZone(dateStr); !ok {
+			return "", false
+		}
+	}
+
+	switch strings.ToLower(matches[2]) {
+	case "ms":
+		return strconv.FormatInt(t.UnixMilli(), 10), true
+	case "us", "µs":
+		return strconv.FormatInt(t.UnixMicro(), 10), true
+	}
+	return strconv.FormatInt(t.Unix(), 10), true
+}
+
+// parseDateTimeWithZone parses a date/time with an optional trailing timezone
+// ("2024-06-10 08:00 UTC", "2024-06-10 08:00 IST(India)"). Without a known
+// zone the date is read in local time.
+func parseDateTimeWithZone(s string) (time.Time, bool) {
+	if idx := strings.LastIndex(s, " "); idx > 0 {
+		if loc, err := LookupTimezone(s[idx+1:]); err == nil {
+			if t, err := ParseDateTime(s[:idx], loc); err == nil {
+				return t, true
+			}
+		}
+	}
+	if t, ok := parseTimeWithTimezone(s); ok {
+		return t, true
+	}
+	t, err := ParseDateTime(s, time.Local)
+	return t, err == nil
+}
+
 func handlePlainDateTime(expr, exprLower string) (string, bool) {
 	// Handle plain datetime strings like "2025-12-26 11:12 EST" or "2025-12-26 11:12:00"
 	// This allows them to be stored and referenced by \N
@@ -4,6 +4,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"smartcalc/internal/utils"
 )
 
 func TestEvalNowIn(t *testing.T) {
@@ -39,9 +41,9 @@ func TestEvalDurationConversion(t *testing.T) {
 		expected string
 	}{
 		{"861.5 hours in days", "35.90 days"},
-		{"24 hours in days", "1 days"},
-		{"7 days in weeks", "1 weeks"},
-		{"60 minutes in hours", "1 hours"},
+		{"24 hours in days", "1 day"},
+		{"7 days in weeks", "1 week"},
+		{"60 minutes in hours", "1 hour"},
 	}
 
 	for _, tt := range tests {
@@ -142,7 +144,7 @@ func TestEvalDurationMultiplication(t *testing.T) {
 		contains string
 	}{
 		{"13 x 3 min", "39"},
-		{"8 hours x 5", "days"}, // 40 hours = some days
+		{"8 hours x 5", "1 day 16.0 hours"},
 	}
 
 	for _, tt := range tests {
@@ -185,6 +187,24 @@ func TestParseDuration(t *testing.T) {
 	}
 }
 
+func TestParseAmountDuration(t *testing.T) {
+	tests := []struct {
+		amount, unit string
+		expected     time.Duration
+	}{
+		{"30", "days", 30 * 24 * time.Hour},
+		{"an", "hour", time.Hour},
+		{"A", "minute", time.Minute},
+	}
+
+	for _, tt := range tests {
+		result, err := ParseAmountDuration(tt.amount, tt.unit)
+		if err != nil || result != tt.expected {
+			t.Errorf("ParseAmountDuration(%q, %q) = %v, %v, want %v", tt.amount, tt.unit, result, err, tt.expected)
+		}
+	}
+}
+
 func TestLookupTimezone(t *testing.T) {
 	tests := []struct {
 		city    string
@@ -196,6 +216,17 @@ func TestLookupTimezone(t *testing.T) {
 		{"Moscow", false},
 		{"EST", false},
 		{"PST", false},
+		{"IST(India)", false},
+		{"cst (China)", false},
+		{"IST", true},       // ambiguous without a region
+		{"IST(Mars)", true}, // unknown region
+		{"UTC+5:30", false},
+		{"GMT-3", false},
+		{"+02:00", false},
+		{"-0800", false},
+		{"+5", true},        // bare offsets take two-digit hours
+		{"UTC+15", true},    // beyond any zone
+		{"UTC+05:75", true}, // not a minute
 		{"unknown_city_xyz", true},
 	}
 
@@ -375,6 +406,208 @@ func TestEvalDateTimeConversionWithTimezone(t *testing.T) {
 	}
 }
 
+func TestEvalAmbiguousTimezones(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"2025-01-15 15:00 IST in UTC",
+			"\n> India: 2025-01-15 09:30 UTC\n> Israel: 2025-01-15 13:00 UTC\n> Ireland: 2025-01-15 15:00 UTC"},
+		{"2025-01-15 10:00 CST in UTC",
+			"\n> US: 2025-01-15 16:00 UTC\n> China: 2025-01-15 02:00 UTC\n> Cuba: 2025-01-15 15:00 UTC"},
+		{"2025-07-15 12:00 BST in UTC",
+			"\n> UK: 2025-07-15 11:00 UTC\n> Bangladesh: 2025-07-15 06:00 UTC"},
+		{"2025-01-15 15:00 IST(India) in UTC", "2025-01-15 09:30 UTC"},
+		{"2025-01-15 10:00 CST(China) in UTC", "2025-01-15 02:00 UTC"},
+		{"2025-07-15 12:00 BST(UK) in UTC", "2025-07-15 11:00 UTC"},
+		{"2025-01-15 15:00 EST in UTC", "2025-01-15 20:00 UTC"}, // unambiguous, unchanged
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalDateTime(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalDateTime(%q) error: %v", tt.expr, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvalDateTime(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalAmbiguousTimezonesStrict(t *testing.T) {
+	SetAmbiguityMode(AmbiguityRequireRegion)
+	defer SetAmbiguityMode(AmbiguityShowAll)
+
+	for _, expr := range []string{"3pm IST to PST", "2025-01-15 10:00 CST in UTC", "now in BST"} {
+		if result, err := EvalDateTime(expr); err == nil {
+			t.Errorf("EvalDateTime(%q) = %q, want ambiguity error", expr, result)
+		}
+	}
+
+	result, err := EvalDateTime("2025-01-15 15:00 IST(Ireland) in UTC")
+	if err != nil || result != "2025-01-15 15:00 UTC" {
+		t.Errorf("disambiguated IST = (%q, %v), want 2025-01-15 15:00 UTC", result, err)
+	}
+}
+
+func TestEvalAmbiguousTimezonesAssumed(t *testing.T) {
+	SetAmbiguityMode(AmbiguityAssumeDefault)
+	defer SetAmbiguityMode(AmbiguityShowAll)
+
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"2025-01-15 10:00 CST in UTC", "2025-01-15 16:00 UTC (assumed US Central)"},
+		{"2025-01-15 15:00 IST in UTC", "2025-01-15 09:30 UTC (assumed India)"},
+		{"2025-01-15 15:00 IST in CST", "2025-01-15 03:30 CST (assumed India, US Central)"},
+		{"2025-01-15 10:00 CST(China) in UTC", "2025-01-15 02:00 UTC"}, // explicit, nothing assumed
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalDateTime(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalDateTime(%q) error: %v", tt.expr, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvalDateTime(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalUTCOffsets(t *testing.T) {
+	frozen := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	SetClock(func() time.Time { return frozen })
+	defer SetClock(nil)
+
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"3pm UTC+5:30 in Seattle", "2025-01-15 01:30 PST"},
+		{"2024-06-01 12:00 +02:00 in Tokyo", "2024-06-01 19:00 JST"},
+		{"2024-06-01 12:00 GMT-3 in London", "2024-06-01 16:00 BST"},
+		{"14:00 UTC in UTC+05:45", "2025-01-15 19:45 UTC+05:45"},
+		{"2024-06-01 12:00 -0800 in UTC", "2024-06-01 20:00 UTC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalDateTime(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalDateTime(%q) error: %v", tt.expr, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvalDateTime(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
+
+// Abbreviations name a region, whose clocks change: EST and EDT both stand
+// for New York. Offsets are fixed on either side of a change.
+func TestEvalTimezonesAcrossDST(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		// New York springs forward at 2024-03-10 02:00
+		{"2024-03-10 01:30 EST in UTC", "2024-03-10 06:30 UTC"},
+		{"2024-03-10 03:30 EST in UTC", "2024-03-10 07:30 UTC"},
+		{"2024-03-10 03:30 -05:00 in UTC", "2024-03-10 08:30 UTC"},
+		// Chicago falls back at 2024-11-03 02:00; 01:30 happens twice
+		{"2024-11-03 01:30 -05:00 in Chicago", "2024-11-03 01:30 CDT"},
+		{"2024-11-03 01:30 -06:00 in Chicago", "2024-11-03 01:30 CST"},
+		{"2024-11-03 06:30 UTC in Chicago", "2024-11-03 01:30 CDT"},
+		{"2024-11-03 08:30 UTC in Chicago", "2024-11-03 02:30 CST"},
+		// London's summer time starts at 2024-03-31 01:00 UTC
+		{"2024-03-31 00:30 UTC in London", "2024-03-31 00:30 GMT"},
+		{"2024-03-31 01:30 UTC in London", "2024-03-31 02:30 BST"},
+		// Sydney's daylight time ends at 2024-04-07 03:00
+		{"2024-04-06 12:00 UTC in Sydney", "2024-04-06 23:00 AEDT"},
+		{"2024-04-07 12:00 UTC in Sydney", "2024-04-07 22:00 AEST"},
+		// Arithmetic keeps the abbreviation's zone rather than reading it as UTC
+		{"2024-11-02 12:00 PST + 1 day", "2024-11-03 11:00 PST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalDateTime(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalDateTime(%q) error: %v", tt.expr, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvalDateTime(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseDateTimeTrailingZone(t *testing.T) {
+	tests := []struct {
+		input  string
+		offset int // seconds east of UTC
+	}{
+		{"2025-12-17 16:00 PST", -8 * 3600},
+		{"2025-07-17 16:00 PDT", -7 * 3600},
+		{"2025-07-17 16:00 +05:30", 5*3600 + 30*60},
+		{"2025-07-17 16:00 IST(Israel)", 3 * 3600},
+		{"2025-07-17 16:00", 0}, // the default location
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			parsed, err := ParseDateTime(tt.input, time.UTC)
+			if err != nil {
+				t.Fatalf("ParseDateTime(%q) error: %v", tt.input, err)
+			}
+			if _, offset := parsed.Zone(); offset != tt.offset {
+				t.Errorf("ParseDateTime(%q) offset = %d, want %d", tt.input, offset, tt.offset)
+			}
+		})
+	}
+}
+
+func TestEvalTimezonesFor(t *testing.T) {
+	frozen := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	SetClock(func() time.Time { return frozen })
+	defer SetClock(nil)
+
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"timezones for CST", "US: America/Chicago (UTC-06:00), China: Asia/Shanghai (UTC+08:00), Cuba: America/Havana (UTC-05:00)"},
+		{"time zones for IST?", "India: Asia/Kolkata (UTC+05:30), Israel: Asia/Jerusalem (UTC+02:00), Ireland: Europe/Dublin (UTC)"},
+		{"timezone of EST", "America/New_York (UTC-05:00)"},
+		{"timezone for Seattle", "America/Los_Angeles (UTC-08:00)"},
+		{"timezone of UTC+5:30", "UTC+05:30 (UTC+05:30)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if !IsDateTimeExpression(tt.expr) {
+				t.Errorf("IsDateTimeExpression(%q) = false", tt.expr)
+			}
+			result, err := EvalDateTime(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalDateTime(%q) error: %v", tt.expr, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvalDateTime(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+
+	if _, err := EvalDateTime("timezones for Atlantis"); err == nil {
+		t.Error("timezones for Atlantis should be an error")
+	}
+}
+
 func TestEvalComplexDurationExpressions(t *testing.T) {
 	tests := []struct {
 		expr        string
@@ -484,6 +717,118 @@ func TestFormatDuration(t *testing.T) {
 	}
 }
 
+func TestFormatDurationLanguages(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		lang     string
+		duration time.Duration
+		detailed time.Time
+		want     string
+	}{
+		{"en", 3 * 24 * time.Hour, time.Time{}, "3 days"},
+		{"de", 3 * 24 * time.Hour, time.Time{}, "3 Tage"},
+		{"fr", 36 * time.Hour, time.Time{}, "1 jour 12.0 heures"},
+		{"es", 90 * time.Minute, time.Time{}, "1.50 horas"},
+		{"uk", 2 * 24 * time.Hour, time.Time{}, "2 дні"},
+		{"uk", 5 * 24 * time.Hour, time.Time{}, "5 днів"},
+		{"uk", 21 * 24 * time.Hour, time.Time{}, "21 день"},
+		{"en", 0, from.AddDate(1, 2, 1), "1 year 2 months 1 day"},
+		{"de", 0, from.AddDate(2, 1, 0), "2 Jahre 1 Monat"},
+		{"uk", 0, from.AddDate(0, 3, 22).Add(5 * time.Minute), "3 місяці 3 тижні 1 день 5 хв"},
+	}
+
+	defer utils.SetLanguage("en")
+	for _, tt := range tests {
+		t.Run(tt.lang+" "+tt.want, func(t *testing.T) {
+			utils.SetLanguage(tt.lang)
+			got := FormatDuration(tt.duration)
+			if !tt.detailed.IsZero() {
+				got = FormatDetailedDuration(from, tt.detailed)
+			}
+			if got != tt.want {
+				t.Errorf("in %s got %q, want %q", tt.lang, got, tt.want)
+			}
+		})
+	}
+
+	// Expressions stay English; only the result is translated
+	utils.SetLanguage("de")
+	if got, err := EvalDateTime("48 hours in days"); err != nil || got != "2 Tage" {
+		t.Errorf("EvalDateTime(48 hours in days) in de = %q, %v, want 2 Tage", got, err)
+	}
+}
+
+func TestEvalEpochToDate(t *testing.T) {
+	want := FormatTimeSeconds(time.Unix(1718000000, 0))
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"1718000000 to date", want},       // seconds by digit count
+		{"1718000000000 to date", want},    // milliseconds by digit count
+		{"1718000000000000 to date", want}, // microseconds by digit count
+		{"1718000000000 ms to date", want}, // explicit unit
+		{"1718000000 sec in date", want},
+		{"1718000 ms to date", FormatTimeSeconds(time.UnixMilli(1718000))}, // unit overrides digit count
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalDateTime(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalDateTime(%q) error: %v", tt.expr, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvalDateTime(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+
+	if _, err := EvalDateTime("12345678901234567 to date"); err == nil {
+		t.Error("17-digit epoch without a unit should not be guessed")
+	}
+}
+
+func TestEvalDateToEpoch(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"2024-06-10 08:00 UTC to epoch", "1718006400"},
+		{"2024-06-10 08:00:30 UTC to unix", "1718006430"},
+		{"2024-06-10 08:00 PDT to epoch ms", "1718031600000"},
+		{"2024-06-10 08:00 IST(India) to epoch", "1717986600"},
+		{"2024-06-10 08:00 UTC to epoch us", "1718006400000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalDateTime(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalDateTime(%q) error: %v", tt.expr, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvalDateTime(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEpochRoundTrip(t *testing.T) {
+	date, err := EvalDateTime("1718000000 to date")
+	if err != nil {
+		t.Fatalf("EvalDateTime error: %v", err)
+	}
+	resolver := func(n int) (string, bool) { return date, n == 1 }
+	epoch, err := EvalDateTimeWithRefs("\\1 to epoch", resolver)
+	if err != nil {
+		t.Fatalf("EvalDateTimeWithRefs error: %v", err)
+	}
+	if epoch != "1718000000" {
+		t.Errorf("round trip through %q = %q, want 1718000000", date, epoch)
+	}
+}
+
 func TestIsDateTimeExpression(t *testing.T) {
 	tests := []struct {
 		expr     string
@@ -493,6 +838,8 @@ func TestIsDateTimeExpression(t *testing.T) {
 		{"5 hours in days", true},
 		{"today() - 10 days", true},
 		{"6:00 am Seattle in Kiev", true},
+		{"1718000000 to date", true},
+		{"2024-06-10 08:00 UTC to epoch", true},
 		{"100 + 50", false},
 		{"$100 - 20%", false},
 		{"sin(45)", false},
@@ -589,3 +936,57 @@ func TestEvalNowNoSeconds(t *testing.T) {
 		t.Errorf("EvalDateTime('now') = %q, expected exactly 1 colon (no seconds)", result)
 	}
 }
+
+func TestSetClock(t *testing.T) {
+	frozen := time.Date(2025, 7, 15, 9, 30, 0, 0, time.Local)
+	SetClock(func() time.Time { return frozen })
+	defer SetClock(nil)
+
+	result, err := EvalDateTime("today")
+	if err != nil {
+		t.Fatalf("EvalDateTime(today) error: %v", err)
+	}
+	if !strings.Contains(result, "2025") || !strings.Contains(result, "15") {
+		t.Errorf("EvalDateTime(today) = %q, want the frozen date", result)
+	}
+
+	SetClock(nil)
+	if d := time.Since(Now()); d < -time.Second || d > time.Second {
+		t.Errorf("Now() after SetClock(nil) is %v away from the system clock", d)
+	}
+}
+
+func TestTimeSpan(t *testing.T) {
+	frozen := time.Date(2025, 6, 15, 8, 0, 0, 0, time.Local)
+	SetClock(func() time.Time { return frozen })
+	defer SetClock(nil)
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"time until Dec 25", "6 months 1 week 2 days 16 hours"},
+		{"time till 2025-06-15 20:30", "12 hours 30 min"},
+		{"time until Jan 1", "6 months 2 weeks 2 days 16 hours"},
+		{"time until Jun 1", "11 months 2 weeks 2 days 16 hours"}, // next year's
+		{"time since 2025-03-15", "3 months 8 hours"},
+		{"time since Dec 25", "5 months 3 weeks 8 hours"}, // last year's
+		{"time until 2025-06-12", "already passed 3 days 8 hours ago"},
+		{"time since 2025-06-18", "not yet, in 2 days 16 hours"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := EvalDateTime(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalDateTime(%q) error: %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalDateTime(%q) = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := EvalDateTime("time until someday"); err == nil {
+		t.Error("expected an error for an unparseable target")
+	}
+}
@@ -0,0 +1,142 @@
+package datetime
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ordinalPattern matches a day with an ordinal suffix: "3rd", "21st"
+	ordinalPattern = regexp.MustCompile(`(?i)\b(\d{1,2})(?:st|nd|rd|th)\b`)
+	// dayOfMonthPattern matches "3 of June" once the suffix is dropped
+	dayOfMonthPattern = regexp.MustCompile(`(?i)\b(\d{1,2})\s+of\s+([a-z]+)\b`)
+
+	monthDayPattern    = regexp.MustCompile(`^([a-z]+)\.?\s+(\d{1,2})$`)
+	dayMonthPattern    = regexp.MustCompile(`^(\d{1,2})\s+([a-z]+)$`)
+	relativeDayPattern = regexp.MustCompile(`^(next|last|this)\s+([a-z]+)$`)
+	inPeriodPattern    = regexp.MustCompile(`^in\s+(\d+|an?)\s+(days?|weeks?|months?|years?)$`)
+	periodEdgePattern  = regexp.MustCompile(`^(?:the\s+)?(beginning|start|end)\s+of\s+(?:(this|next|last)\s+|the\s+)?(week|month|quarter|year)$`)
+)
+
+// normalizeOrdinals drops ordinal suffixes from days so the layouts can read
+// them: "June 3rd, 2025" is "June 3, 2025" and "3rd of June" is "3 June"
+func normalizeOrdinals(s string) string {
+	s = ordinalPattern.ReplaceAllString(s, "$1")
+	return dayOfMonthPattern.ReplaceAllStringFunc(s, func(m string) string {
+		parts := dayOfMonthPattern.FindStringSubmatch(m)
+		if _, ok := monthNames[strings.ToLower(parts[2])]; !ok {
+			return m
+		}
+		return parts[1] + " " + parts[2]
+	})
+}
+
+// parseNaturalDate reads the natural-language dates ParseDateTime falls back
+// on. Each is a day, at midnight in loc:
+//
+//	June 3rd, 3rd of June        June 3 of the current year
+//	next Tuesday, last Friday    the next or last such day, never today
+//	this Friday                  the next such day, or today
+//	in 2 weeks, in a month       counted from today
+//	beginning of next month      the first day of a week, month, quarter or year
+//	end of quarter               its last day
+//
+// Weeks begin on Monday, as ISO week numbers do.
+func parseNaturalDate(s string, loc *time.Location) (time.Time, bool) {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	now := Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	if t, ok := parseMonthDay(s, loc); ok {
+		return t, true
+	}
+
+	if m := relativeDayPattern.FindStringSubmatch(s); m != nil {
+		weekday, ok := weekdayNames[m[2]]
+		if !ok {
+			return time.Time{}, false
+		}
+		ahead := (int(weekday) - int(today.Weekday()) + 7) % 7
+		switch m[1] {
+		case "next":
+			if ahead == 0 {
+				ahead = 7
+			}
+		case "last":
+			ahead -= 7
+		}
+		return today.AddDate(0, 0, ahead), true
+	}
+
+	if m := inPeriodPattern.FindStringSubmatch(s); m != nil {
+		n := 1
+		if m[1] != "a" && m[1] != "an" {
+			n, _ = strconv.Atoi(m[1])
+		}
+		switch strings.TrimSuffix(m[2], "s") {
+		case "day":
+			return today.AddDate(0, 0, n), true
+		case "week":
+			return today.AddDate(0, 0, 7*n), true
+		case "month":
+			return today.AddDate(0, n, 0), true
+		}
+		return today.AddDate(n, 0, 0), true
+	}
+
+	if m := periodEdgePattern.FindStringSubmatch(s); m != nil {
+		start, end := periodAround(today, m[3])
+		switch m[2] {
+		case "next":
+			start = end
+		case "last":
+			start, _ = periodAround(start.AddDate(0, 0, -1), m[3])
+		}
+		if m[1] == "end" {
+			_, end = periodAround(start, m[3])
+			return end.AddDate(0, 0, -1), true
+		}
+		return start, true
+	}
+
+	return time.Time{}, false
+}
+
+// parseMonthDay parses a month and day without a year, "Dec 6", "6 Dec" or
+// "6th of December", in the current year
+func parseMonthDay(s string, loc *time.Location) (time.Time, bool) {
+	s = strings.ToLower(strings.TrimSpace(normalizeOrdinals(s)))
+	monthStr, dayStr := "", ""
+	if m := monthDayPattern.FindStringSubmatch(s); m != nil {
+		monthStr, dayStr = m[1], m[2]
+	} else if m := dayMonthPattern.FindStringSubmatch(s); m != nil {
+		monthStr, dayStr = m[2], m[1]
+	}
+	month, ok := monthNames[monthStr]
+	if !ok {
+		return time.Time{}, false
+	}
+	day, _ := strconv.Atoi(dayStr)
+	return time.Date(Now().In(loc).Year(), month, day, 0, 0, 0, 0, loc), true
+}
+
+// periodAround returns the first day of the week, month, quarter or year
+// holding day, and the first day of the next one
+func periodAround(day time.Time, period string) (start, next time.Time) {
+	loc := day.Location()
+	switch period {
+	case "week":
+		start = day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return start, start.AddDate(0, 0, 7)
+	case "month":
+		start = time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 1, 0)
+	case "quarter":
+		start = time.Date(day.Year(), day.Month()-(day.Month()-1)%3, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 3, 0)
+	}
+	start = time.Date(day.Year(), time.January, 1, 0, 0, 0, 0, loc)
+	return start, start.AddDate(1, 0, 0)
+}
@@ -0,0 +1,119 @@
+package datetime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateTimeNatural(t *testing.T) {
+	SetClock(func() time.Time { return time.Date(2026, 10, 20, 10, 30, 0, 0, time.UTC) }) // a Tuesday
+	defer SetClock(nil)
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"June 3rd", "2026-06-03"},
+		{"3rd of June", "2026-06-03"},
+		{"June 3rd, 2025", "2025-06-03"},
+		{"21st of December 2025", "2025-12-21"},
+		{"Aug 22nd, 2025 14:30", "2025-08-22"},
+		// "next" is never today: next Tuesday on a Tuesday is a week out
+		{"next Tuesday", "2026-10-27"},
+		{"next friday", "2026-10-23"},
+		{"last Tuesday", "2026-10-13"},
+		{"last Friday", "2026-10-16"},
+		{"this Tuesday", "2026-10-20"},
+		{"this mon", "2026-10-26"},
+		{"in 2 weeks", "2026-11-03"},
+		{"in 3 days", "2026-10-23"},
+		{"in a month", "2026-11-20"},
+		{"in 1 year", "2027-10-20"},
+		{"beginning of next month", "2026-11-01"},
+		{"end of month", "2026-10-31"},
+		{"end of next month", "2026-11-30"},
+		{"start of quarter", "2026-10-01"},
+		{"end of quarter", "2026-12-31"},
+		{"end of last quarter", "2026-09-30"},
+		{"beginning of next quarter", "2027-01-01"},
+		{"start of this week", "2026-10-19"},
+		{"end of the week", "2026-10-25"},
+		{"beginning of last year", "2025-01-01"},
+		{"End of Year", "2026-12-31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDateTime(tt.input, time.UTC)
+			if err != nil {
+				t.Fatalf("ParseDateTime(%q) error: %v", tt.input, err)
+			}
+			if got.Format("2006-01-02") != tt.want {
+				t.Errorf("ParseDateTime(%q) = %s, want %s", tt.input, got.Format("2006-01-02"), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateTimeNaturalMidnight(t *testing.T) {
+	SetClock(func() time.Time { return time.Date(2026, 10, 20, 10, 30, 0, 0, time.UTC) })
+	defer SetClock(nil)
+
+	loc := time.FixedZone("UTC+14", 14*3600) // already Wednesday
+	got, err := ParseDateTime("next Wednesday", loc)
+	if err != nil {
+		t.Fatalf("ParseDateTime error: %v", err)
+	}
+	if want := time.Date(2026, 10, 28, 0, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("ParseDateTime(next Wednesday) = %v, want %v", got, want)
+	}
+}
+
+func TestParseDateTimeNaturalFailures(t *testing.T) {
+	for _, input := range []string{"next blursday", "in 2 fortnights", "end of decade", "3rd", "next", "in 2 hours"} {
+		t.Run(input, func(t *testing.T) {
+			_, err := ParseDateTime(input, time.UTC)
+			if err == nil {
+				t.Fatalf("ParseDateTime(%q) succeeded, want an error", input)
+			}
+			if want := "unable to parse date/time: " + input; err.Error() != want {
+				t.Errorf("ParseDateTime(%q) error = %q, want %q", input, err, want)
+			}
+		})
+	}
+}
+
+func TestEvalNaturalDates(t *testing.T) {
+	local := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = local }()
+	SetClock(func() time.Time { return time.Date(2026, 10, 20, 10, 30, 0, 0, time.UTC) })
+	defer SetClock(nil)
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"next Tuesday + 1 day", "2026-10-28 00:00 UTC"},
+		{"June 3rd 2025 + 2 weeks", "2025-06-17 00:00 UTC"},
+		{"June 3rd to July 4th", "31 days"},
+		{"3rd of June till 4th of July", "31 days"},
+		{"beginning of next month to end of quarter", "60 days"},
+		{"end of quarter - today", "2 months 1 week 4 days"},
+		{"time until June 3rd", "7 months 1 week 6 days 13 hours 30 min"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if !IsDateTimeExpression(tt.expr) {
+				t.Fatalf("%q is not a date/time expression", tt.expr)
+			}
+			got, err := EvalDateTime(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalDateTime(%q) error: %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalDateTime(%q) = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"smartcalc/internal/utils"
 )
 
 // Common date/time formats to try when parsing
@@ -60,31 +62,65 @@ var monthNames = map[string]time.Month{
 	"dec": time.December, "december": time.December,
 }
 
-// ParseDateTime attempts to parse a date/time string
+// ParseDateTime attempts to parse a date/time string. A trailing abbreviation
+// the calculator knows, such as PST, or an offset like +05:30 sets the zone.
+// Days may have ordinal suffixes ("June 3rd"), and natural-language dates
+// such as "next Tuesday" or "end of quarter" are read as a last resort.
 func ParseDateTime(s string, defaultLoc *time.Location) (time.Time, error) {
 	s = strings.TrimSpace(s)
 	if defaultLoc == nil {
 		defaultLoc = time.Local
 	}
+	if rest, loc, ok := splitTrailingZone(s); ok {
+		s, defaultLoc = rest, loc
+	}
 
 	// Try each format
+	text := normalizeOrdinals(s)
 	for _, format := range dateFormats {
-		if t, err := time.ParseInLocation(format, s, defaultLoc); err == nil {
+		if t, err := time.ParseInLocation(format, text, defaultLoc); err == nil {
 			return t, nil
 		}
 	}
 
 	// Try time-only formats (use today's date)
 	for _, format := range timeFormats {
-		if t, err := time.ParseInLocation(format, strings.ToLower(s), defaultLoc); err == nil {
-			now := time.Now().In(defaultLoc)
+		if t, err := time.ParseInLocation(format, strings.ToLower(text), defaultLoc); err == nil {
+			now := Now().In(defaultLoc)
 			return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, defaultLoc), nil
 		}
 	}
 
+	if t, ok := parseNaturalDate(text, defaultLoc); ok {
+		return t, nil
+	}
+
 	return time.Time{}, fmt.Errorf("unable to parse date/time: %s", s)
 }
 
+// splitTrailingZone splits a known abbreviation or an offset from UTC off the
+// end of a date/time. The layouts' MST would read an abbreviation the
+// location doesn't use, such as PST in UTC, as a zone at UTC's offset.
+// Abbreviations are upper case, as MST reads them: date ranges lower-case
+// their ends and are not meant to take zones.
+func splitTrailingZone(s string) (string, *time.Location, bool) {
+	idx := strings.LastIndex(s, " ")
+	if idx <= 0 {
+		return s, nil, false
+	}
+	zone := s[idx+1:]
+	_, known := TimezoneAbbreviations[strings.ToLower(zone)]
+	known = known && zone == strings.ToUpper(zone)
+	if !known && !regionAbbrevPattern.MatchString(strings.ToLower(zone)) && !utcOffsetPattern.MatchString(zone) {
+		return s, nil, false
+	}
+	loc, err := LookupTimezone(zone)
+	if err != nil {
+		return s, nil, false
+	}
+	return strings.TrimSpace(s[:idx]), loc, true
+}
+
 // ParseDuration parses duration expressions like "5 hours", "3.5 days", "30 minutes"
 func ParseDuration(s string) (time.Duration, error) {
 	s = strings.ToLower(strings.TrimSpace(s))
@@ -124,6 +160,38 @@ func ParseDuration(s string) (time.Duration, error) {
 	return 0, fmt.Errorf("unknown duration unit: %s", unit)
 }
 
+// ParseAmountDuration parses an amount of a time unit typed apart, as in
+// "data at 50 MB/s for an hour", where "a" and "an" are one
+func ParseAmountDuration(amount, unit string) (time.Duration, error) {
+	if strings.EqualFold(amount, "a") || strings.EqualFold(amount, "an") {
+		amount = "1"
+	}
+	return ParseDuration(amount + " " + unit)
+}
+
+// durationUnitMessage returns the message a duration unit as typed by the
+// user is shown with ("hrs" -> utils.MsgHour). ok is false for other units.
+func durationUnitMessage(unit string) (id utils.MessageID, ok bool) {
+	unit = strings.ToLower(strings.TrimSpace(unit))
+	switch {
+	case strings.HasPrefix(unit, "sec") || unit == "s":
+		return utils.MsgSecond, true
+	case strings.HasPrefix(unit, "min") || unit == "m":
+		return utils.MsgMinute, true
+	case strings.HasPrefix(unit, "hour") || strings.HasPrefix(unit, "hr") || unit == "h":
+		return utils.MsgHour, true
+	case strings.HasPrefix(unit, "day") || unit == "d":
+		return utils.MsgDay, true
+	case strings.HasPrefix(unit, "week") || unit == "w":
+		return utils.MsgWeek, true
+	case strings.HasPrefix(unit, "month"):
+		return utils.MsgMonth, true
+	case strings.HasPrefix(unit, "year") || strings.HasPrefix(unit, "yr") || unit == "y":
+		return utils.MsgYear, true
+	}
+	return 0, false
+}
+
 // ConvertDuration converts a duration to a specific unit and returns the value
 func ConvertDuration(d time.Duration, toUnit string) (float64, error) {
 	toUnit = strings.ToLower(strings.TrimSpace(toUnit))
@@ -176,8 +244,8 @@ func ParseDateRange(s string) (time.Time, time.Time, error) {
 		return time.Time{}, time.Time{}, err
 	}
 
-	// If end is before start, assume it's next year
-	if endTime.Before(startTime) {
+	// If end is a month and day before start, assume it's next year
+	if _, yearless := parseMonthDay(end, time.Local); yearless && endTime.Before(startTime) {
 		endTime = endTime.AddDate(1, 0, 0)
 	}
 
@@ -186,30 +254,8 @@ func ParseDateRange(s string) (time.Time, time.Time, error) {
 
 // parsePartialDate parses dates like "Dec 6" or "March 11"
 func parsePartialDate(s string) (time.Time, error) {
-	s = strings.TrimSpace(s)
-
-	// Try "Month Day" format
-	re := regexp.MustCompile(`^([a-zA-Z]+)\s+(\d+)$`)
-	if matches := re.FindStringSubmatch(s); matches != nil {
-		monthStr := strings.ToLower(matches[1])
-		day, _ := strconv.Atoi(matches[2])
-
-		if month, ok := monthNames[monthStr]; ok {
-			now := time.Now()
-			return time.Date(now.Year(), month, day, 0, 0, 0, 0, time.Local), nil
-		}
-	}
-
-	// Try "Day Month" format
-	re = regexp.MustCompile(`^(\d+)\s+([a-zA-Z]+)$`)
-	if matches := re.FindStringSubmatch(s); matches != nil {
-		day, _ := strconv.Atoi(matches[1])
-		monthStr := strings.ToLower(matches[2])
-
-		if month, ok := monthNames[monthStr]; ok {
-			now := time.Now()
-			return time.Date(now.Year(), month, day, 0, 0, 0, 0, time.Local), nil
-		}
+	if t, ok := parseMonthDay(s, time.Local); ok {
+		return t, nil
 	}
 
 	// Try full date parsing
@@ -221,7 +267,13 @@ func FormatTime(t time.Time) string {
 	return t.Format("2006-01-02 15:04 MST")
 }
 
-// FormatDuration formats a duration for display
+// FormatTimeSeconds formats a time like FormatTime but keeps the seconds,
+// so converted epoch values round-trip exactly
+func FormatTimeSeconds(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05 MST")
+}
+
+// FormatDuration formats a duration for display in the output language
 func FormatDuration(d time.Duration) string {
 	if d < 0 {
 		return "-" + FormatDuration(-d)
@@ -229,25 +281,27 @@ func FormatDuration(d time.Duration) string {
 
 	days := d.Hours() / 24
 	if days >= 1 {
-		hours := d.Hours() - float64(int(days))*24
+		wholeDays := float64(int(days))
+		hours := d.Hours() - wholeDays*24
 		if hours > 0 {
-			return fmt.Sprintf("%.0f days %.1f hours", days, hours)
+			return utils.FormatCount(fmt.Sprintf("%.0f", wholeDays), utils.MsgDay) + " " +
+				utils.FormatCount(fmt.Sprintf("%.1f", hours), utils.MsgHour)
 		}
-		if days == float64(int(days)) {
-			return fmt.Sprintf("%.0f days", days)
+		if days == wholeDays {
+			return utils.FormatCount(fmt.Sprintf("%.0f", days), utils.MsgDay)
 		}
-		return fmt.Sprintf("%.2f days", days)
+		return utils.FormatCount(fmt.Sprintf("%.2f", days), utils.MsgDay)
 	}
 
 	if d.Hours() >= 1 {
-		return fmt.Sprintf("%.2f hours", d.Hours())
+		return utils.FormatCount(fmt.Sprintf("%.2f", d.Hours()), utils.MsgHour)
 	}
 
 	if d.Minutes() >= 1 {
-		return fmt.Sprintf("%.2f minutes", d.Minutes())
+		return utils.FormatCount(fmt.Sprintf("%.2f", d.Minutes()), utils.MsgMinute)
 	}
 
-	return fmt.Sprintf("%.2f seconds", d.Seconds())
+	return utils.FormatCount(fmt.Sprintf("%.2f", d.Seconds()), utils.MsgSecond)
 }
 
 // DaysBetween calculates the number of days between two dates
@@ -255,7 +309,8 @@ func DaysBetween(start, end time.Time) float64 {
 	return end.Sub(start).Hours() / 24
 }
 
-// FormatDetailedDuration formats a duration between two dates showing years, months, weeks, days, hours, minutes
+// FormatDetailedDuration formats a duration between two dates showing years,
+// months, weeks, days, hours, minutes in the output language
 func FormatDetailedDuration(from, to time.Time) string {
 	if to.Before(from) {
 		from, to = to, from
@@ -299,51 +354,25 @@ func FormatDetailedDuration(from, to time.Time) string {
 
 	// Build result string
 	var parts []string
-	if years > 0 {
-		if years == 1 {
-			parts = append(parts, "1 year")
-		} else {
-			parts = append(parts, fmt.Sprintf("%d years", years))
-		}
-	}
-	if months > 0 {
-		if months == 1 {
-			parts = append(parts, "1 month")
-		} else {
-			parts = append(parts, fmt.Sprintf("%d months", months))
-		}
-	}
-	if weeks > 0 {
-		if weeks == 1 {
-			parts = append(parts, "1 week")
-		} else {
-			parts = append(parts, fmt.Sprintf("%d weeks", weeks))
-		}
-	}
-	if days > 0 {
-		if days == 1 {
-			parts = append(parts, "1 day")
-		} else {
-			parts = append(parts, fmt.Sprintf("%d days", days))
-		}
-	}
-	if hours > 0 {
-		if hours == 1 {
-			parts = append(parts, "1 hour")
-		} else {
-			parts = append(parts, fmt.Sprintf("%d hours", hours))
-		}
+	counts := []struct {
+		n  int
+		id utils.MessageID
+	}{
+		{years, utils.MsgYear},
+		{months, utils.MsgMonth},
+		{weeks, utils.MsgWeek},
+		{days, utils.MsgDay},
+		{hours, utils.MsgHour},
+		{minutes, utils.MsgMin},
 	}
-	if minutes > 0 {
-		if minutes == 1 {
-			parts = append(parts, "1 min")
-		} else {
-			parts = append(parts, fmt.Sprintf("%d min", minutes))
+	for _, c := range counts {
+		if c.n > 0 {
+			parts = append(parts, utils.FormatCount(strconv.Itoa(c.n), c.id))
 		}
 	}
 
 	if len(parts) == 0 {
-		return "0 min"
+		return utils.FormatCount("0", utils.MsgMin)
 	}
 
 	return strings.Join(parts, " ")
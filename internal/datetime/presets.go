@@ -0,0 +1,102 @@
+package datetime
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/utils"
+)
+
+// Preset is a named offset added to a date: "#preset netting 45 days", or
+// payment terms such as "net 30" and "2/10 net 30", which also give a
+// discount for paying early
+type Preset struct {
+	Days         int     // days from the date to the due date
+	DiscountDays int     // days the early payment discount runs, if any
+	DiscountPct  float64 // early payment discount, in percent
+}
+
+// Presets are the named offsets of a document, by lowercase name
+type Presets map[string]Preset
+
+// presetLinePattern matches a "#preset netting 45 days" directive line
+var presetLinePattern = regexp.MustCompile(`(?i)^\s*#\s*preset\s+([a-z_][\w-]*)\s+(\d+)\s*(days?|weeks?)\s*$`)
+
+// paymentTermsPattern matches the built-in payment terms "net 30" and
+// "2/10 net 30": a 2% discount if paid within 10 days, else due in 30
+var paymentTermsPattern = regexp.MustCompile(`(?i)^(?:(\d+(?:\.\d+)?)\s*%?\s*/\s*(\d+)\s+)?net\s*(\d+)$`)
+
+// presetExprPattern matches "<date> + <preset>", with the preset last
+var presetExprPattern = regexp.MustCompile(`^(.+?)\s*\+\s*([^+]+?)\s*$`)
+
+// ParsePresetLine parses a "#preset netting 45 days" directive line. ok is
+// false for other lines.
+func ParsePresetLine(line string) (name string, p Preset, ok bool) {
+	m := presetLinePattern.FindStringSubmatch(line)
+	if m == nil {
+		return "", Preset{}, false
+	}
+	n, _ := strconv.Atoi(m[2])
+	if strings.HasPrefix(strings.ToLower(m[3]), "week") {
+		n *= 7
+	}
+	return strings.ToLower(m[1]), Preset{Days: n}, true
+}
+
+// Lookup finds a preset by name: the document's own presets first, then the
+// built-in payment terms
+func (p Presets) Lookup(name string) (Preset, bool) {
+	name = strings.Join(strings.Fields(strings.ToLower(name)), " ")
+	if preset, ok := p[name]; ok {
+		return preset, true
+	}
+	m := paymentTermsPattern.FindStringSubmatch(name)
+	if m == nil {
+		return Preset{}, false
+	}
+	preset := Preset{}
+	preset.Days, _ = strconv.Atoi(m[3])
+	if m[1] != "" {
+		preset.DiscountPct, _ = strconv.ParseFloat(m[1], 64)
+		preset.DiscountDays, _ = strconv.Atoi(m[2])
+	}
+	return preset, true
+}
+
+// Match splits "2025-03-01 + net 30" into the date and the preset added to it
+func (p Presets) Match(expr string) (date string, preset Preset, ok bool) {
+	m := presetExprPattern.FindStringSubmatch(strings.TrimSpace(expr))
+	if m == nil {
+		return "", Preset{}, false
+	}
+	if preset, ok = p.Lookup(m[2]); !ok {
+		return "", Preset{}, false
+	}
+	return m[1], preset, true
+}
+
+// EvalPreset adds a preset to a date and returns the due date. Terms with an
+// early payment discount list the discount deadline and the due date on
+// "> " lines; out is the due date otherwise.
+func EvalPreset(date string, p Preset, resolver RefResolver) (due, out string, err error) {
+	offset := func(days int) (string, error) {
+		return EvalDateTimeWithRefs(fmt.Sprintf("%s + %d days", date, days), resolver)
+	}
+	if due, err = offset(p.Days); err != nil {
+		return "", "", err
+	}
+	if p.DiscountDays == 0 {
+		return due, due, nil
+	}
+	if p.DiscountDays > p.Days {
+		return "", "", fmt.Errorf("the discount runs %d days, past the due date in %d", p.DiscountDays, p.Days)
+	}
+	discount, err := offset(p.DiscountDays)
+	if err != nil {
+		return "", "", err
+	}
+	out = fmt.Sprintf("\n> %s%% discount by: %s\n> due: %s", utils.FormatResult(false, p.DiscountPct), discount, due)
+	return due, out, nil
+}
@@ -0,0 +1,67 @@
+package datetime
+
+import "testing"
+
+func TestParsePresetLine(t *testing.T) {
+	tests := []struct {
+		line string
+		name string
+		days int
+		ok   bool
+	}{
+		{"#preset netting 45 days", "netting", 45, true},
+		{"# Preset Sprint 2 weeks", "sprint", 14, true},
+		{"#preset notice 1 day", "notice", 1, true},
+		{"#preset netting soon", "", 0, false},
+		{"preset netting 45 days", "", 0, false},
+	}
+	for _, tt := range tests {
+		name, p, ok := ParsePresetLine(tt.line)
+		if name != tt.name || p.Days != tt.days || ok != tt.ok {
+			t.Errorf("ParsePresetLine(%q) = %q, %d, %v, want %q, %d, %v", tt.line, name, p.Days, ok, tt.name, tt.days, tt.ok)
+		}
+	}
+}
+
+func TestPresetsLookup(t *testing.T) {
+	presets := Presets{"netting": {Days: 45}, "net 30": {Days: 31}}
+	tests := []struct {
+		name string
+		want Preset
+		ok   bool
+	}{
+		{"Netting", Preset{Days: 45}, true},
+		// A document preset wins over the built-in terms
+		{"net  30", Preset{Days: 31}, true},
+		{"net 60", Preset{Days: 60}, true},
+		{"NET90", Preset{Days: 90}, true},
+		{"2/10 net 30", Preset{Days: 30, DiscountDays: 10, DiscountPct: 2}, true},
+		{"1.5% / 15 net 45", Preset{Days: 45, DiscountDays: 15, DiscountPct: 1.5}, true},
+		{"days", Preset{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := presets.Lookup(tt.name)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Lookup(%q) = %+v, %v, want %+v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestEvalPreset(t *testing.T) {
+	var presets Presets
+	date, p, ok := presets.Match("2025-03-01 + 2/10 net 30")
+	if !ok || date != "2025-03-01" {
+		t.Fatalf("Match() = %q, %+v, %v", date, p, ok)
+	}
+	due, out, err := EvalPreset(date, p, nil)
+	if err != nil {
+		t.Fatalf("EvalPreset() error: %v", err)
+	}
+	if due != "2025-03-31 00:00 UTC" || out != "\n> 2% discount by: 2025-03-11 00:00 UTC\n> due: 2025-03-31 00:00 UTC" {
+		t.Errorf("EvalPreset() = %q, %q", due, out)
+	}
+
+	if _, _, err := EvalPreset("2025-03-01", Preset{Days: 30, DiscountDays: 40, DiscountPct: 5}, nil); err == nil {
+		t.Error("EvalPreset() should fail for a discount past the due date")
+	}
+}
@@ -0,0 +1,167 @@
+package datetime
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"smartcalc/internal/utils"
+)
+
+// Date queries ask about a date rather than compute one: its ISO week, its
+// day of the year, its weekday, the days of its month and whether its year
+// is a leap year
+var (
+	weekNumberPattern = regexp.MustCompile(`(?i)^(?:iso\s+)?week(?:\s+number)?\s+(?:of|for)\s+(.+?)\??$`)
+	dayOfYearPattern  = regexp.MustCompile(`(?i)^day\s+of\s+(?:the\s+)?year\s+(?:(?:of|for)\s+)?(.+?)\??$`)
+	weekdayPattern    = regexp.MustCompile(`(?i)^what\s+(?:day|weekday)\s+(?:is|was|will\s+be)\s+(.+?)\??$`)
+	daysInPattern     = regexp.MustCompile(`(?i)^(?:how\s+many\s+)?days\s+(?:are\s+)?in\s+(.+?)\??$`)
+	leapYearPattern   = regexp.MustCompile(`(?i)^is\s+(.+?)\s+a\s+leap\s+year\??$`)
+	monthYearPattern  = regexp.MustCompile(`(?i)^([a-z]+)(?:\s+(\d{4}))?$`)
+	yearPattern       = regexp.MustCompile(`^\d{4}$`)
+	dateQueryPatterns = []*regexp.Regexp{weekNumberPattern, dayOfYearPattern, weekdayPattern, daysInPattern, leapYearPattern}
+)
+
+// IsDateQuery checks if an expression asks for the week number, day of
+// year or weekday of a date, the days in a month or year, or whether a year
+// is a leap year
+func IsDateQuery(expr string) bool {
+	expr = strings.TrimSpace(expr)
+	for _, p := range dateQueryPatterns {
+		if p.MatchString(expr) {
+			return true
+		}
+	}
+	return false
+}
+
+// EvalDateQuery answers a date query. The date may be "today", "now" or a
+// \n reference to a date line as well as a literal date. The week number,
+// day of year and days in a month have the number as their value:
+//
+//	week number of 2024-06-10 = 24
+//	day of year 2024-06-10 = 162
+//	what day is 2025-01-01 = Wednesday
+//	days in February 2024 = 29
+//	is 2100 a leap year = no (365 days)
+func EvalDateQuery(expr string, resolver RefResolver) (utils.Result, error) {
+	expr = strings.TrimSpace(expr)
+	if resolver != nil {
+		expr = resolveRefsInExpr(expr, resolver)
+	}
+
+	if m := weekNumberPattern.FindStringSubmatch(expr); m != nil {
+		t, err := parseQueryDate(m[1])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		year, week := t.ISOWeek()
+		text := strconv.Itoa(week)
+		// The first days of January can be in the last week of the year
+		// before, and the last days of December in week 1 of the next
+		if year != t.Year() {
+			text += fmt.Sprintf(" (%d-W%02d)", year, week)
+		}
+		return utils.ValueResult(text, float64(week), false), nil
+	}
+	if m := dayOfYearPattern.FindStringSubmatch(expr); m != nil {
+		t, err := parseQueryDate(m[1])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		return utils.ValueResult(strconv.Itoa(t.YearDay()), float64(t.YearDay()), false), nil
+	}
+	if m := weekdayPattern.FindStringSubmatch(expr); m != nil {
+		t, err := parseQueryDate(m[1])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		return utils.TextResult(t.Weekday().String()), nil
+	}
+	if m := daysInPattern.FindStringSubmatch(expr); m != nil {
+		return evalDaysIn(m[1])
+	}
+	if m := leapYearPattern.FindStringSubmatch(expr); m != nil {
+		year, err := parseQueryYear(m[1])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		if isLeapYear(year) {
+			return utils.TextResult("yes (366 days)"), nil
+		}
+		return utils.TextResult("no (365 days)"), nil
+	}
+	return utils.Result{}, fmt.Errorf("invalid date query")
+}
+
+// evalDaysIn counts the days in a month, "February 2024" or "February" for
+// this year's, in the month of a date, or in a year
+func evalDaysIn(s string) (utils.Result, error) {
+	s = strings.TrimSpace(s)
+	if yearPattern.MatchString(s) {
+		year, _ := strconv.Atoi(s)
+		days := 365
+		if isLeapYear(year) {
+			days = 366
+		}
+		return utils.ValueResult(strconv.Itoa(days), float64(days), false), nil
+	}
+
+	var year int
+	var month time.Month
+	if m := monthYearPattern.FindStringSubmatch(s); m != nil && monthNames[strings.ToLower(m[1])] != 0 {
+		month = monthNames[strings.ToLower(m[1])]
+		year = Now().Year()
+		if m[2] != "" {
+			year, _ = strconv.Atoi(m[2])
+		}
+	} else {
+		t, err := parseQueryDate(s)
+		if err != nil {
+			return utils.Result{}, err
+		}
+		year, month = t.Year(), t.Month()
+	}
+	// Day 0 of the next month is the last day of this one
+	days := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	return utils.ValueResult(strconv.Itoa(days), float64(days), false), nil
+}
+
+// parseQueryDate parses the date of a query: "today", "now", "tomorrow",
+// "yesterday" or a date
+func parseQueryDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "today", "today()", "now", "now()":
+		return Now(), nil
+	case "tomorrow":
+		return Now().AddDate(0, 0, 1), nil
+	case "yesterday":
+		return Now().AddDate(0, 0, -1), nil
+	}
+	if t, ok := parseDateTimeWithZone(s); ok {
+		return t, nil
+	}
+	return ParseDateTime(s, time.Local)
+}
+
+// parseQueryYear parses the year of a leap year query: a year, or the year
+// of a date
+func parseQueryYear(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if yearPattern.MatchString(s) {
+		return strconv.Atoi(s)
+	}
+	t, err := parseQueryDate(s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Year(), nil
+}
+
+// isLeapYear reports whether a year of the Gregorian calendar has 366 days
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
@@ -0,0 +1,83 @@
+package datetime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvalDateQuery(t *testing.T) {
+	local := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = local }()
+	SetClock(func() time.Time { return time.Date(2026, 10, 16, 17, 20, 0, 0, time.UTC) }) // a Friday
+	defer SetClock(nil)
+
+	resolver := func(n int) (string, bool) {
+		if n == 3 {
+			return "2024-12-30", true
+		}
+		return "", false
+	}
+
+	tests := []struct {
+		expr     string
+		want     string
+		value    float64
+		hasValue bool
+	}{
+		{"week number of 2024-06-10", "24", 24, true},
+		{"ISO week of Jan 1, 2021", "53 (2020-W53)", 53, true},
+		{"week number of \\3", "1 (2025-W01)", 1, true},
+		{"week number of today", "42", 42, true},
+		{"day of year 2024-06-10", "162", 162, true},
+		{"day of year 2023-12-31", "365", 365, true},
+		{"day of the year of now", "289", 289, true},
+		{"what day is 2025-01-01", "Wednesday", 0, false},
+		{"what day was \\3?", "Monday", 0, false},
+		{"what day is tomorrow", "Saturday", 0, false},
+		{"days in February 2024", "29", 29, true},
+		{"days in feb 2023", "28", 28, true},
+		{"how many days in April", "30", 30, true},
+		{"days in 2024", "366", 366, true},
+		{"days in \\3", "31", 31, true},
+		{"is 2100 a leap year", "no (365 days)", 0, false},
+		{"is 2000 a leap year?", "yes (366 days)", 0, false},
+		{"is 2024-06-10 a leap year", "yes (366 days)", 0, false},
+		{"is today a leap year", "no (365 days)", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if !IsDateQuery(tt.expr) || !IsDateTimeExpression(tt.expr) {
+				t.Fatalf("%q is not a date query", tt.expr)
+			}
+			got, err := EvalDateQuery(tt.expr, resolver)
+			if err != nil {
+				t.Fatalf("EvalDateQuery(%q) error: %v", tt.expr, err)
+			}
+			if got.Text != tt.want {
+				t.Errorf("EvalDateQuery(%q) = %q, want %q", tt.expr, got.Text, tt.want)
+			}
+			if got.HasValue != tt.hasValue || got.Value != tt.value {
+				t.Errorf("EvalDateQuery(%q) value = %v (%v), want %v (%v)", tt.expr, got.Value, got.HasValue, tt.value, tt.hasValue)
+			}
+		})
+	}
+}
+
+func TestEvalDateQueryErrors(t *testing.T) {
+	for _, expr := range []string{
+		"week number of 2024-13-45",
+		"what day is someday",
+		"days in Smarch 2024",
+		"is \\9 a leap year",
+	} {
+		if _, err := EvalDateQuery(expr, nil); err == nil {
+			t.Errorf("EvalDateQuery(%q) did not fail", expr)
+		}
+	}
+	for _, expr := range []string{"week 3", "day of", "2 weeks in days"} {
+		if IsDateQuery(expr) {
+			t.Errorf("IsDateQuery(%q) = true", expr)
+		}
+	}
+}
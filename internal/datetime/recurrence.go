@@ -0,0 +1,208 @@
+package datetime
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// weekdayNames maps the names of the days of the week to time.Weekday
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// weekdayPart matches a day of the week by its name or abbreviation
+const weekdayPart = `sun(?:day)?|mon(?:day)?|tue(?:s|sday)?|wed(?:nesday)?|thu(?:r|rs|rsday)?|fri(?:day)?|sat(?:urday)?`
+
+// recurrencePattern matches "every 2 weeks from 2024-06-03, next 6", "every
+// month on the 15th, next 3", "every friday, next 4" and "every other
+// monday from Jan 6"
+var recurrencePattern = regexp.MustCompile(`(?i)^every\s+(?:(\d+|other)\s+)?(?:(days?|weeks?|fortnights?|months?|quarters?|years?)|(` + weekdayPart + `)s?)` +
+	`(?:\s+on\s+(?:the\s+)?(\d{1,2})(?:st|nd|rd|th)?|\s+on\s+(` + weekdayPart + `)s?)?` +
+	`(?:\s+(?:from|starting(?:\s+(?:from|on))?)\s+(.+?))?` +
+	`(?:\s*,?\s+next\s+(\d+))?$`)
+
+// maxOccurrences bounds how many dates "next N" lists
+const maxOccurrences = 100
+
+// recurrence is a parsed repeating date: every n units (days, weeks, months
+// or years), anchored at a first date
+type recurrence struct {
+	unit  string // "day", "week", "month" or "year"
+	n     int
+	first time.Time
+	day   int // day of the month a monthly or yearly date falls on, clamped to the month's end
+}
+
+// at returns the k-th date of the recurrence, k = 0 being the first
+func (r recurrence) at(k int) time.Time {
+	f := r.first
+	switch r.unit {
+	case "day":
+		return f.AddDate(0, 0, k*r.n)
+	case "week":
+		return f.AddDate(0, 0, 7*k*r.n)
+	case "month":
+		return clampedDate(f.Year(), f.Month()+time.Month(k*r.n), r.day, f)
+	}
+	return clampedDate(f.Year()+k*r.n, f.Month(), r.day, f)
+}
+
+// clampedDate is day of a month, or the month's last day if it is shorter,
+// at the time of day of clock: the 31st of February is the 28th or 29th
+func clampedDate(year int, month time.Month, day int, clock time.Time) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, clock.Location()).Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(year, month, day, clock.Hour(), clock.Minute(), clock.Second(), 0, clock.Location())
+}
+
+// parseRecurrenceStart parses the date a recurrence starts from: a date,
+// with or without a time zone, a month and day ("Jan 6"), or "today",
+// "tomorrow" or "yesterday"
+func parseRecurrenceStart(s string, today time.Time) (time.Time, bool) {
+	switch strings.ToLower(s) {
+	case "today", "today()":
+		return today, true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	}
+	if t, ok := parseDateTimeWithZone(s); ok {
+		return t, true
+	}
+	t, err := parsePartialDate(s)
+	return t, err == nil
+}
+
+// parseRecurrence reads a recurrence from the parts of recurrencePattern.
+// Without a start date it starts today. A day of the week or of the month
+// moves the first date forward to the first such day on or after the start.
+func parseRecurrence(m []string, today time.Time) (recurrence, error) {
+	r := recurrence{n: 1}
+	switch {
+	case strings.EqualFold(m[1], "other"):
+		r.n = 2
+	case m[1] != "":
+		r.n, _ = strconv.Atoi(m[1])
+		if r.n < 1 {
+			return r, fmt.Errorf("repeat every 1 or more")
+		}
+	}
+
+	unit := strings.TrimSuffix(strings.ToLower(m[2]), "s")
+	weekday := strings.ToLower(m[3])
+	if weekday == "" {
+		weekday = strings.ToLower(m[5])
+	}
+	switch unit {
+	case "fortnight":
+		unit, r.n = "week", 2*r.n
+	case "quarter":
+		unit, r.n = "month", 3*r.n
+	case "":
+		unit = "week" // "every friday"
+	}
+	r.unit = unit
+
+	r.first = today
+	if m[6] != "" {
+		var ok bool
+		if r.first, ok = parseRecurrenceStart(strings.TrimSpace(m[6]), today); !ok {
+			return r, fmt.Errorf("unable to parse start date: %s", m[6])
+		}
+	}
+
+	if weekday != "" {
+		if unit != "week" {
+			return r, fmt.Errorf("a day of the week repeats weekly")
+		}
+		wd := weekdayNames[weekday]
+		r.first = r.first.AddDate(0, 0, (int(wd)-int(r.first.Weekday())+7)%7)
+	}
+
+	r.day = r.first.Day()
+	if m[4] != "" {
+		if unit != "month" && unit != "year" {
+			return r, fmt.Errorf("a day of the month repeats monthly or yearly")
+		}
+		r.day, _ = strconv.Atoi(m[4])
+		if r.day < 1 || r.day > 31 {
+			return r, fmt.Errorf("day %d is not a day of the month", r.day)
+		}
+		first := clampedDate(r.first.Year(), r.first.Month(), r.day, r.first)
+		if first.Before(r.first) {
+			first = clampedDate(r.first.Year(), r.first.Month()+1, r.day, r.first)
+		}
+		r.first = first
+	}
+	return r, nil
+}
+
+// IsRecurrenceExpression checks if an expression lists the dates of a
+// repeating schedule, "every 2 weeks from 2024-06-03, next 6"
+func IsRecurrenceExpression(expr string) bool {
+	return recurrencePattern.MatchString(strings.TrimSpace(expr))
+}
+
+// handleRecurrence lists the next dates of a repeating schedule, one per
+// "> " line: "every 2 weeks from 2024-06-03, next 6". The dates keep the
+// rhythm of the start date; the first is strictly after now, so on a Friday
+// afternoon "every friday" starts next week. A month that is
+// shorter than the day falls on its last day, so "every month on the 31st"
+// is Jan 31, Feb 28, Mar 31. Without "next N" it gives the next date alone.
+func handleRecurrence(expr, exprLower string) (string, bool) {
+	m := recurrencePattern.FindStringSubmatch(expr)
+	if m == nil {
+		return "", false
+	}
+	now := Now().In(time.Local)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	r, err := parseRecurrence(m, today)
+	if err != nil {
+		return "", false
+	}
+
+	count := 1
+	if m[7] != "" {
+		count, _ = strconv.Atoi(m[7])
+		if count < 1 || count > maxOccurrences {
+			return "", false
+		}
+	}
+
+	// Skip to the first date after now. Days and weeks jump there directly;
+	// months and years are few enough to step through.
+	k := 0
+	if r.unit == "day" || r.unit == "week" {
+		step := r.n
+		if r.unit == "week" {
+			step *= 7
+		}
+		if days := int(now.Sub(r.first).Hours() / 24); days > 0 {
+			k = days / step
+		}
+	}
+	for !r.at(k).After(now) {
+		k++
+	}
+
+	dates := make([]string, count)
+	for i := range dates {
+		dates[i] = FormatTime(r.at(k + i))
+	}
+	if count == 1 {
+		return dates[0], true
+	}
+	return "\n> " + strings.Join(dates, "\n> "), true
+}
@@ -0,0 +1,95 @@
+package datetime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvalRecurrence(t *testing.T) {
+	local := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = local }()
+	SetClock(func() time.Time { return time.Date(2026, 10, 16, 17, 20, 0, 0, time.UTC) }) // a Friday
+	defer SetClock(nil)
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		// The rhythm of a past start date carries on from today
+		{"every 2 weeks from 2024-06-03, next 3", "\n> 2026-10-19 00:00 UTC\n> 2026-11-02 00:00 UTC\n> 2026-11-16 00:00 UTC"},
+		{"every month on the 15th, next 3", "\n> 2026-11-15 00:00 UTC\n> 2026-12-15 00:00 UTC\n> 2027-01-15 00:00 UTC"},
+		{"every friday, next 2", "\n> 2026-10-23 00:00 UTC\n> 2026-10-30 00:00 UTC"}, // today's has passed
+		{"every other Monday", "2026-10-19 00:00 UTC"},
+		{"every 2 weeks on tue from Nov 1, next 2", "\n> 2026-11-03 00:00 UTC\n> 2026-11-17 00:00 UTC"},
+		{"every fortnight starting tomorrow, next 2", "\n> 2026-10-17 00:00 UTC\n> 2026-10-31 00:00 UTC"},
+		{"every 10 days from 2026-10-01", "2026-10-21 00:00 UTC"},
+		{"every year from 2024-02-29, next 2", "\n> 2027-02-28 00:00 UTC\n> 2028-02-29 00:00 UTC"},
+
+		// A month shorter than the day falls on its last day
+		{"every month on the 31st from 2027-01-01, next 4", "\n> 2027-01-31 00:00 UTC\n> 2027-02-28 00:00 UTC\n> 2027-03-31 00:00 UTC\n> 2027-04-30 00:00 UTC"},
+		{"every quarter from 2027-01-31, next 3", "\n> 2027-01-31 00:00 UTC\n> 2027-04-30 00:00 UTC\n> 2027-07-31 00:00 UTC"},
+		{"every month from 2026-12-31 09:00 UTC, next 2", "\n> 2026-12-31 09:00 UTC\n> 2027-01-31 09:00 UTC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if !IsDateTimeExpression(tt.expr) {
+				t.Fatalf("IsDateTimeExpression(%q) = false", tt.expr)
+			}
+			got, err := EvalDateTime(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalDateTime(%q) error: %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalDateTime(%q) = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+
+	for _, expr := range []string{"every 0 days", "every friday on the 15th", "every week, next 500", "every month from someday"} {
+		if got, err := EvalDateTime(expr); err == nil {
+			t.Errorf("EvalDateTime(%q) = %q, should fail", expr, got)
+		}
+	}
+}
+
+func TestEvalRecurrenceWithRefs(t *testing.T) {
+	local := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = local }()
+	SetClock(func() time.Time { return time.Date(2026, 10, 16, 17, 20, 0, 0, time.UTC) })
+	defer SetClock(nil)
+
+	resolver := func(n int) (string, bool) {
+		return "2026-10-20 00:00 UTC", n == 1
+	}
+	got, err := EvalDateTimeWithRefs(`every week from \1, next 2`, resolver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "\n> 2026-10-20 00:00 UTC\n> 2026-10-27 00:00 UTC"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEvalRecurrenceStartsAfterNow(t *testing.T) {
+	local := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = local }()
+	SetClock(func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) }) // Friday noon
+	defer SetClock(nil)
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"every friday, next 4", "\n> 2026-10-23 00:00 UTC\n> 2026-10-30 00:00 UTC\n> 2026-11-06 00:00 UTC\n> 2026-11-13 00:00 UTC"},
+		{"every week from 2026-10-02", "2026-10-23 00:00 UTC"},
+		{"every day from 2026-10-16 18:00 UTC, next 2", "\n> 2026-10-16 18:00 UTC\n> 2026-10-17 18:00 UTC"}, // later today
+	}
+	for _, tt := range tests {
+		if got, err := EvalDateTime(tt.expr); err != nil || got != tt.want {
+			t.Errorf("EvalDateTime(%q) = %q, %v; want %q", tt.expr, got, err, tt.want)
+		}
+	}
+}
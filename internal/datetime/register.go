@@ -0,0 +1,15 @@
+package datetime
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// Cron schedules are kept as typed, list their fire times on "> " lines
+	// and report an invalid field
+	registry.Register(registry.Evaluator{
+		Name:     "cron",
+		Priority: registry.PriorityCron,
+		Traits:   registry.MultiLine | registry.NoFormat | registry.ReportsErrors,
+		Detect:   IsCronExpression,
+		Eval:     registry.TextEval(EvalCron),
+	})
+}
@@ -1,6 +1,10 @@
 package datetime
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -118,13 +122,11 @@ var TimezoneAbbreviations = map[string]string{
 	"pdt":       "America/Los_Angeles",
 	"mst":       "America/Denver",
 	"mdt":       "America/Denver",
-	"cst":       "America/Chicago",
 	"cdt":       "America/Chicago",
 	"est":       "America/New_York",
 	"edt":       "America/New_York",
 	"utc":       "UTC",
 	"gmt":       "UTC",
-	"bst":       "Europe/London",
 	"cet":       "Europe/Paris",
 	"cest":      "Europe/Paris",
 	"eet":       "Europe/Kyiv",
@@ -133,14 +135,157 @@ var TimezoneAbbreviations = map[string]string{
 	"jst":       "Asia/Tokyo",
 	"kst":       "Asia/Seoul",
 	"cst china": "Asia/Shanghai",
-	"ist":       "Asia/Kolkata",
 	"aest":      "Australia/Sydney",
 	"aedt":      "Australia/Sydney",
 	"nzst":      "Pacific/Auckland",
 	"nzdt":      "Pacific/Auckland",
 }
 
-// LookupTimezone finds a timezone by city name or abbreviation
+// RegionZone is one candidate meaning of an ambiguous timezone abbreviation
+type RegionZone struct {
+	Region string
+	Zone   string
+	// Name describes the zone when it is assumed: "US Central"
+	Name string
+}
+
+// AmbiguousAbbreviations maps abbreviations shared by unrelated timezones to
+// their candidates, the most common first. They are written as IST(India) to
+// pick one explicitly.
+var AmbiguousAbbreviations = map[string][]RegionZone{
+	"ist": {{"India", "Asia/Kolkata", "India"}, {"Israel", "Asia/Jerusalem", "Israel"}, {"Ireland", "Europe/Dublin", "Ireland"}},
+	"cst": {{"US", "America/Chicago", "US Central"}, {"China", "Asia/Shanghai", "China"}, {"Cuba", "America/Havana", "Cuba"}},
+	"bst": {{"UK", "Europe/London", "UK"}, {"Bangladesh", "Asia/Dhaka", "Bangladesh"}},
+}
+
+// AmbiguityMode controls how an ambiguous abbreviation without a region is handled
+type AmbiguityMode string
+
+const (
+	// AmbiguityShowAll converts for every candidate and lists them on > lines
+	AmbiguityShowAll AmbiguityMode = "all"
+	// AmbiguityRequireRegion rejects the expression until a region is given
+	AmbiguityRequireRegion AmbiguityMode = "strict"
+	// AmbiguityAssumeDefault converts for the most common candidate and
+	// notes the assumption: "(assumed US Central)"
+	AmbiguityAssumeDefault AmbiguityMode = "default"
+)
+
+var ambiguityMode = AmbiguityShowAll
+
+// SetAmbiguityMode sets how ambiguous abbreviations are handled.
+// Unknown modes fall back to AmbiguityShowAll.
+func SetAmbiguityMode(mode AmbiguityMode) {
+	if mode != AmbiguityRequireRegion && mode != AmbiguityAssumeDefault {
+		mode = AmbiguityShowAll
+	}
+	ambiguityMode = mode
+}
+
+// GetAmbiguityMode returns the current ambiguity handling mode
+func GetAmbiguityMode() AmbiguityMode {
+	return ambiguityMode
+}
+
+// regionAbbrevPattern matches a disambiguated abbreviation like "ist(india)"
+var regionAbbrevPattern = regexp.MustCompile(`^([a-z]+)\s*\(\s*([a-z ]+?)\s*\)$`)
+
+// ambiguousTokenPattern matches ambiguous abbreviations as whole words; the
+// optional groups detect an explicit region or the legacy "cst china" form.
+var ambiguousTokenPattern = func() *regexp.Regexp {
+	abbrs := make([]string, 0, len(AmbiguousAbbreviations))
+	for a := range AmbiguousAbbreviations {
+		abbrs = append(abbrs, a)
+	}
+	sort.Strings(abbrs)
+	return regexp.MustCompile(`(?i)\b(` + strings.Join(abbrs, "|") + `)\b(\s*\(|\s+china\b)?`)
+}()
+
+// ambiguousToken is an ambiguous abbreviation found in an expression
+type ambiguousToken struct {
+	start, end int
+	abbr       string
+}
+
+// findAmbiguousTokens returns the ambiguous abbreviations in expr that are
+// not already disambiguated by a region.
+func findAmbiguousTokens(expr string) []ambiguousToken {
+	var tokens []ambiguousToken
+	for _, m := range ambiguousTokenPattern.FindAllStringSubmatchIndex(expr, -1) {
+		if m[4] >= 0 {
+			continue // already has a region
+		}
+		tokens = append(tokens, ambiguousToken{m[2], m[3], strings.ToLower(expr[m[2]:m[3]])})
+	}
+	return tokens
+}
+
+// ambiguityHint lists the accepted spellings for an ambiguous abbreviation
+func ambiguityHint(abbr string) string {
+	var opts []string
+	for _, c := range AmbiguousAbbreviations[abbr] {
+		opts = append(opts, fmt.Sprintf("%s(%s)", strings.ToUpper(abbr), c.Region))
+	}
+	return strings.Join(opts, ", ")
+}
+
+// utcOffsetPattern matches an explicit offset from UTC: "UTC+5:30",
+// "GMT-3", "+02:00" or "-0800". Without the UTC or GMT prefix the hours take
+// two digits.
+var utcOffsetPattern = regexp.MustCompile(`(?i)^(utc|gmt)?\s*([+-])(\d{1,2})(?::?(\d{2}))?$`)
+
+// parseUTCOffset reads an explicit offset from UTC as a fixed zone named
+// like "UTC+05:30". Fixed zones have no daylight saving time.
+func parseUTCOffset(s string) (*time.Location, bool) {
+	m := utcOffsetPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || m[1] == "" && len(m[3]) != 2 {
+		return nil, false
+	}
+	hours, _ := strconv.Atoi(m[3])
+	minutes := 0
+	if m[4] != "" {
+		minutes, _ = strconv.Atoi(m[4])
+	}
+	if hours > 14 || minutes > 59 {
+		return nil, false
+	}
+	offset := hours*3600 + minutes*60
+	if m[2] == "-" {
+		offset = -offset
+	}
+	return time.FixedZone(formatOffset(offset), offset), true
+}
+
+// UTCOffset writes the offset of a time from UTC: "UTC", "UTC+05:30",
+// "UTC-03:00"
+func UTCOffset(t time.Time) string {
+	_, offset := t.Zone()
+	return formatOffset(offset)
+}
+
+// formatOffset writes an offset in seconds east of UTC as UTCOffset does
+func formatOffset(offset int) string {
+	if offset == 0 {
+		return "UTC"
+	}
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	return fmt.Sprintf("UTC%s%02d:%02d", sign, offset/3600, offset%3600/60)
+}
+
+// IsTimezoneAbbreviation checks if name is a timezone abbreviation such as
+// EST, ambiguous ones like CST included
+func IsTimezoneAbbreviation(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	_, known := TimezoneAbbreviations[name]
+	_, ambiguous := AmbiguousAbbreviations[name]
+	return known || ambiguous
+}
+
+// LookupTimezone finds a timezone by city name, abbreviation or explicit
+// offset from UTC
 func LookupTimezone(name string) (*time.Location, error) {
 	name = strings.ToLower(strings.TrimSpace(name))
 
@@ -154,6 +299,25 @@ func LookupTimezone(name string) (*time.Location, error) {
 		return time.LoadLocation(tz)
 	}
 
+	// Try disambiguated abbreviation like "IST(India)"
+	if m := regionAbbrevPattern.FindStringSubmatch(name); m != nil {
+		for _, c := range AmbiguousAbbreviations[m[1]] {
+			if strings.ToLower(c.Region) == m[2] {
+				return time.LoadLocation(c.Zone)
+			}
+		}
+		return nil, fmt.Errorf("unknown region for %s: %s", strings.ToUpper(m[1]), m[2])
+	}
+
+	// Ambiguous abbreviations never silently resolve to one candidate
+	if _, ok := AmbiguousAbbreviations[name]; ok {
+		return nil, fmt.Errorf("ambiguous timezone %s: use %s", strings.ToUpper(name), ambiguityHint(name))
+	}
+
+	if loc, ok := parseUTCOffset(name); ok {
+		return loc, nil
+	}
+
 	// Try as IANA timezone directly
 	return time.LoadLocation(name)
 }
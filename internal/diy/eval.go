@@ -0,0 +1,266 @@
+package diy
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/units"
+	"smartcalc/internal/utils"
+)
+
+// Yields and coverage the estimates assume. They are echoed in the results,
+// so the assumptions are visible next to the numbers.
+const (
+	concreteBag80lbFt3 = 0.6   // ft³ of concrete mixed from an 80 lb bag
+	concreteBag25kgM3  = 0.012 // m³ of concrete mixed from a 25 kg bag
+	paintCoverage      = 10.0  // m² covered by a liter of paint, per coat
+	mulchBagFt3        = 2.0   // ft³ in a bag of mulch
+)
+
+// Conversion factors of the results
+const (
+	ft3M3    = 0.0283168 // m³ in a cubic foot
+	yd3M3    = 0.764555  // m³ in a cubic yard
+	gallonsL = 3.78541   // liters in a US gallon
+)
+
+// diyPattern matches "concrete for slab 4 m x 3 m x 10 cm", "paint for 40 sqm
+// two coats" and "mulch for 20 sqm at 5 cm deep". An optional word names what
+// is built; the measurements must start with a number.
+var diyPattern = regexp.MustCompile(`^(concrete|paint|mulch|gravel|topsoil|soil|sand|compost)\s+(?:for\s+)?(?:(?:a|the)\s+)?(?:slab|footing|pad|patio|driveway|floor|wall|walls|room|ceiling|bed|beds|garden|path)?\s*(\d.*)$`)
+
+// coatsPattern matches the number of coats at the end of a paint estimate
+var coatsPattern = regexp.MustCompile(`\s+(?:with\s+|in\s+)?(\d+|one|two|three|four)\s+coats?$`)
+
+// depthPattern matches the depth at the end of a volume: "at 5 cm deep",
+// "10 cm thick", "at 2 in"
+var depthPattern = regexp.MustCompile(`\s+(?:(?:at\s+)?(\d+(?:\.\d+)?)\s*([a-z'"]+)\s+(?:deep|thick)|at\s+(\d+(?:\.\d+)?)\s*([a-z'"]+))$`)
+
+// separatorPattern splits the sides of "4 m x 3 m" or "12 ft by 10 ft"
+var separatorPattern = regexp.MustCompile(`\s*(?:×|\bx\b|\bby\b)\s*`)
+
+// measurePattern splits a side into its number and unit: "10 cm", "400 sq ft", "12'"
+var measurePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(.*)$`)
+
+// coatWords are the spelled-out numbers of coats
+var coatWords = map[string]int{"one": 1, "two": 2, "three": 3, "four": 4}
+
+// IsDIYExpression checks if an expression is a concrete, paint or landscaping
+// material estimate
+func IsDIYExpression(expr string) bool {
+	return diyPattern.MatchString(utils.NormalizeExpr(expr))
+}
+
+// EvalDIY estimates the material for a concrete slab, a paint job or a layer
+// of mulch, gravel, soil or sand. Dimensions may mix units: each is converted
+// to meters, and echoed that way.
+func EvalDIY(expr string) (utils.Result, error) {
+	m := diyPattern.FindStringSubmatch(utils.NormalizeExpr(expr))
+	if m == nil {
+		return utils.Result{}, fmt.Errorf("invalid material estimate")
+	}
+	switch m[1] {
+	case "concrete":
+		return evalConcrete(m[2])
+	case "paint":
+		return evalPaint(m[2])
+	default:
+		return evalLayer(m[1], m[2])
+	}
+}
+
+// evalConcrete estimates the volume of a slab and the bags of premixed
+// concrete that fill it
+func evalConcrete(s string) (utils.Result, error) {
+	v, err := parseVolume(s)
+	if err != nil {
+		return utils.Result{}, err
+	}
+	bags80 := bagsFor(v.m3, concreteBag80lbFt3*ft3M3)
+	bags25 := bagsFor(v.m3, concreteBag25kgM3)
+	text := fmt.Sprintf("%s (%s): %d bags of 80 lb at %g ft³ each, or %d bags of 25 kg at %g m³ each",
+		volumeText(v.m3), v.text, bags80, concreteBag80lbFt3, bags25, concreteBag25kgM3)
+	return utils.ValueResult(text, v.m3, false), nil
+}
+
+// evalPaint estimates the paint for an area and number of coats
+func evalPaint(s string) (utils.Result, error) {
+	coats := 1
+	if m := coatsPattern.FindStringSubmatch(s); m != nil {
+		if n, ok := coatWords[m[1]]; ok {
+			coats = n
+		} else {
+			coats, _ = strconv.Atoi(m[1])
+		}
+		if coats < 1 {
+			return utils.Result{}, fmt.Errorf("at least one coat is needed")
+		}
+		s = s[:len(s)-len(m[0])]
+	}
+	sqm, areaText, err := parseArea(s)
+	if err != nil {
+		return utils.Result{}, err
+	}
+	liters := sqm * float64(coats) / paintCoverage
+	coatText := "1 coat"
+	if coats > 1 {
+		coatText = fmt.Sprintf("%d coats", coats)
+	}
+	text := fmt.Sprintf("%s L / %s gal (%s × %s at %g m²/L per coat)",
+		utils.FormatAmount(liters, 2), utils.FormatAmount(liters/gallonsL, 2), areaText, coatText, paintCoverage)
+	return utils.ValueResult(text, liters, false), nil
+}
+
+// evalLayer estimates the volume of a layer of mulch, gravel, soil or sand,
+// with the bags of mulch it takes
+func evalLayer(material, s string) (utils.Result, error) {
+	v, err := parseVolume(s)
+	if err != nil {
+		return utils.Result{}, err
+	}
+	text := fmt.Sprintf("%s (%s)", volumeText(v.m3), v.text)
+	if material == "mulch" {
+		text += fmt.Sprintf(": %d bags of %g ft³", bagsFor(v.m3, mulchBagFt3*ft3M3), mulchBagFt3)
+	}
+	return utils.ValueResult(text, v.m3, false), nil
+}
+
+// volume is a parsed volume with its dimensions as echoed in a result
+type volume struct {
+	m3   float64
+	text string // "4 m × 3 m × 10 cm"
+}
+
+// side is one measurement of an area or volume, converted to meters
+type side struct {
+	value float64 // meters, or square meters for an area
+	dims  int     // 1 for a length, 2 for an area
+}
+
+// parseVolume reads "4 m x 3 m x 10 cm", "20 sqm at 5 cm deep" or
+// "12 ft x 10 ft at 4 in"
+func parseVolume(s string) (volume, error) {
+	var depth []side
+	if m := depthPattern.FindStringSubmatch(s); m != nil {
+		value, unit := m[1], m[2]
+		if value == "" {
+			value, unit = m[3], m[4]
+		}
+		d, err := parseSide(value + " " + unit)
+		if err != nil {
+			return volume{}, err
+		}
+		if d.dims != 1 {
+			return volume{}, fmt.Errorf("depth %s %s is not a length", value, unit)
+		}
+		depth = append(depth, d)
+		s = s[:len(s)-len(m[0])]
+	}
+	sides, err := parseSides(s)
+	if err != nil {
+		return volume{}, err
+	}
+	sides = append(sides, depth...)
+	m3, dims := product(sides)
+	if dims != 3 {
+		return volume{}, fmt.Errorf("expected a volume such as 4 m x 3 m x 10 cm or 20 sqm at 5 cm deep")
+	}
+	if m3 <= 0 {
+		return volume{}, fmt.Errorf("dimensions must be more than zero")
+	}
+	return volume{m3: m3, text: sidesText(sides)}, nil
+}
+
+// parseArea reads "40 sqm", "400 sq ft" or "4 m x 2.5 m"
+func parseArea(s string) (float64, string, error) {
+	sides, err := parseSides(s)
+	if err != nil {
+		return 0, "", err
+	}
+	sqm, dims := product(sides)
+	if dims != 2 {
+		return 0, "", fmt.Errorf("expected an area such as 40 sqm or 4 m x 2.5 m")
+	}
+	if sqm <= 0 {
+		return 0, "", fmt.Errorf("dimensions must be more than zero")
+	}
+	return sqm, utils.FormatAmount(sqm, 2) + " m²", nil
+}
+
+// parseSides reads the sides of "4 m x 3 m x 10 cm"
+func parseSides(s string) ([]side, error) {
+	var sides []side
+	for _, part := range separatorPattern.Split(strings.TrimSpace(s), -1) {
+		sd, err := parseSide(part)
+		if err != nil {
+			return nil, err
+		}
+		sides = append(sides, sd)
+	}
+	return sides, nil
+}
+
+// parseSide converts a length such as "10 cm" or "12'", or an area such as
+// "40 sqm", to meters or square meters
+func parseSide(s string) (side, error) {
+	m := measurePattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return side{}, fmt.Errorf("expected a measurement, got %q", s)
+	}
+	value, _ := strconv.ParseFloat(m[1], 64)
+	unit := strings.TrimSpace(m[2])
+	switch unit {
+	case "":
+		return side{}, fmt.Errorf("%s has no unit", m[1])
+	case "'", "′":
+		unit = "ft"
+	case "\"", "″":
+		unit = "in"
+	}
+	if meters, ok := units.LengthInMeters(value, unit); ok {
+		return side{value: meters, dims: 1}, nil
+	}
+	if sqm, ok := units.AreaInSquareMeters(value, unit); ok {
+		return side{value: sqm, dims: 2}, nil
+	}
+	return side{}, fmt.Errorf("unknown unit %q", unit)
+}
+
+// product multiplies the sides, returning the dimensions of the result
+func product(sides []side) (float64, int) {
+	v, dims := 1.0, 0
+	for _, sd := range sides {
+		v *= sd.value
+		dims += sd.dims
+	}
+	return v, dims
+}
+
+// sidesText echoes sides in metric units: "4 m × 3 m × 10 cm"
+func sidesText(sides []side) string {
+	parts := make([]string, len(sides))
+	for i, sd := range sides {
+		switch {
+		case sd.dims == 2:
+			parts[i] = utils.FormatAmount(sd.value, 2) + " m²"
+		case sd.value < 1:
+			parts[i] = utils.FormatAmount(sd.value*100, 1) + " cm"
+		default:
+			parts[i] = utils.FormatAmount(sd.value, 2) + " m"
+		}
+	}
+	return strings.Join(parts, " × ")
+}
+
+// volumeText shows a volume in cubic meters and cubic yards: "1.2 m³ / 1.57 yd³"
+func volumeText(m3 float64) string {
+	return fmt.Sprintf("%s m³ / %s yd³", utils.FormatAmount(m3, 3), utils.FormatAmount(m3/yd3M3, 2))
+}
+
+// bagsFor counts the bags of a given yield needed for a volume, rounded up
+func bagsFor(m3, perBag float64) int {
+	return int(math.Ceil(m3/perBag - 1e-9))
+}
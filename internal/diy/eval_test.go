@@ -0,0 +1,92 @@
+package diy
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIsDIYExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"concrete for slab 4 m x 3 m x 10 cm", true},
+		{"Paint for 40 sqm two coats", true},
+		{"mulch for 20 sqm at 5 cm deep", true},
+		{"gravel 400 sq ft at 2 in", true},
+
+		// Variables and plain conversions are left alone
+		{"paint = 40", false},
+		{"paint * 2", false},
+		{"40 sqm to sq ft", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsDIYExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsDIYExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalDIY(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+		value    float64
+	}{
+		{"concrete for slab 4 m x 3 m x 10 cm",
+			"1.2 m³ / 1.57 yd³ (4 m × 3 m × 10 cm): 71 bags of 80 lb at 0.6 ft³ each, or 100 bags of 25 kg at 0.012 m³ each", 1.2},
+		{"concrete for a patio 10' x 10' x 4\"",
+			"0.944 m³ / 1.23 yd³ (3.05 m × 3.05 m × 10.2 cm): 56 bags of 80 lb at 0.6 ft³ each, or 79 bags of 25 kg at 0.012 m³ each", 0.944},
+		// Feet by meters converts each side to meters
+		{"concrete 12 ft x 3 m x 4 in",
+			"1.115 m³ / 1.46 yd³ (3.66 m × 3 m × 10.2 cm): 66 bags of 80 lb at 0.6 ft³ each, or 93 bags of 25 kg at 0.012 m³ each", 1.115},
+		{"paint for 40 sqm two coats", "8 L / 2.11 gal (40 m² × 2 coats at 10 m²/L per coat)", 8},
+		{"paint for walls 12 ft by 10 ft", "1.11 L / 0.29 gal (11.15 m² × 1 coat at 10 m²/L per coat)", 1.115},
+		{"paint for 4 m x 2.5 m 3 coats", "3 L / 0.79 gal (10 m² × 3 coats at 10 m²/L per coat)", 3},
+		{"mulch for 20 sqm at 5 cm deep", "1 m³ / 1.31 yd³ (20 m² × 5 cm): 18 bags of 2 ft³", 1},
+		{"gravel for 400 sq ft at 2 in", "1.888 m³ / 2.47 yd³ (37.16 m² × 5.1 cm)", 1.888},
+		{"topsoil for bed 10 m x 2 m x 15 cm", "3 m³ / 3.92 yd³ (10 m × 2 m × 15 cm)", 3},
+		{"sand for 2 m x 2 m 10 cm thick", "0.4 m³ / 0.52 yd³ (2 m × 2 m × 10 cm)", 0.4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalDIY(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalDIY(%q) error: %v", tt.expr, err)
+			}
+			if result.Text != tt.expected {
+				t.Errorf("EvalDIY(%q) = %q, want %q", tt.expr, result.Text, tt.expected)
+			}
+			if math.Abs(result.Value-tt.value) > 0.001 {
+				t.Errorf("EvalDIY(%q) value = %v, want %v", tt.expr, result.Value, tt.value)
+			}
+		})
+	}
+}
+
+func TestEvalDIYErrors(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"concrete for slab 4 m x 3 m", "expected a volume such as 4 m x 3 m x 10 cm or 20 sqm at 5 cm deep"},
+		{"paint for 4 m x 3 m x 2 m", "expected an area such as 40 sqm or 4 m x 2.5 m"},
+		{"paint for 40 two coats", "40 has no unit"},
+		{"mulch for 20 sqm at 5 sqm deep", "depth 5 sqm is not a length"},
+		{"mulch for 20 parsecs at 5 cm", "unknown unit \"parsecs\""},
+		{"sand for 2 m x 2 m at 0 cm", "dimensions must be more than zero"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := EvalDIY(tt.expr)
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("EvalDIY(%q) error = %v, want %q", tt.expr, err, tt.expected)
+			}
+		})
+	}
+}
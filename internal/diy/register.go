@@ -0,0 +1,16 @@
+package diy
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// Missing units and measurements that don't make an area or volume are
+	// reported instead of left to unit conversions. Dimensions are shown as
+	// typed: "4 m x 3 m" is not arithmetic to space out.
+	registry.Register(registry.Evaluator{
+		Name:     "diy",
+		Priority: registry.PriorityDIY,
+		Traits:   registry.ReportsErrors | registry.NoFormat,
+		Detect:   IsDIYExpression,
+		Eval:     EvalDIY,
+	})
+}
@@ -0,0 +1,239 @@
+package energy
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/datetime"
+	"smartcalc/internal/units"
+	"smartcalc/internal/utils"
+)
+
+// daysPerMonth is the month monthly costs are given for
+const daysPerMonth = 30
+
+// Parts of the patterns: a power such as "1500 w" or "1.5 kw", the clauses
+// that follow it, and the clauses themselves: a duty cycle such as "for 6
+// hours/day" or "for 24/7", a duration such as "for 30 days", and a rate such
+// as "at $0.14/kwh"
+const (
+	power     = `(\d+(?:\.\d+)?)\s*(w|watts?|kw|kilowatts?|megawatts?|hp|horsepower)`
+	clauses   = `((?:\s+(?:for|at)\s+.*)?)`
+	cyclePart = `for\s+(` + datetime.DutyCyclePattern + `)`
+	spanPart  = `for\s+(\d+(?:\.\d+)?|an?)\s*(hours?|hrs?|h|days?|weeks?|months?|years?|yrs?)\b`
+	ratePart  = `at\s+\$\s*(\d+(?:\.\d+)?)\s*(?:/\s*|per\s+)kwh`
+)
+
+// costPattern matches "cost of 1500 w for 6 hours/day at $0.14/kwh"
+var costPattern = regexp.MustCompile(`^(?:energy\s+)?cost\s+of\s+` + power + clauses + `$`)
+
+// kwhPattern matches "kwh of 65 w for 24/7 for 30 days"
+var kwhPattern = regexp.MustCompile(`^(?:kwh|energy)\s+(?:of|for)\s+` + power + clauses + `$`)
+
+// comparePattern matches "compare 9 w led vs 60 w incandescent at
+// $0.14/kwh for 1000 hours"
+var comparePattern = regexp.MustCompile(`^compare\s+` + power + `(?:\s+([a-z][a-z -]*?))?\s+(?:vs\.?|versus)\s+` + power + `(?:\s+([a-z][a-z -]*?))?` + clauses + `$`)
+
+// clausePattern matches the clause at the start of the rest of an
+// expression. A duty cycle is tried before a duration, which would otherwise
+// claim the "6 hours" of "6 hours/day".
+var clausePattern = regexp.MustCompile(`^\s*(?:` + cyclePart + `|` + spanPart + `|` + ratePart + `)`)
+
+// usage is how long an appliance runs and what its energy costs
+type usage struct {
+	share   float64 // share of the time it runs, 1 for all of it
+	cycle   bool    // a duty cycle was given
+	hours   float64 // hours the usage spans, 0 if none were given
+	rate    float64 // price of a kWh
+	hasRate bool
+}
+
+// IsEnergyExpression checks if an expression asks for the energy or the cost
+// of running an appliance, or compares two appliances
+func IsEnergyExpression(expr string) bool {
+	expr = utils.NormalizeExpr(expr)
+	return costPattern.MatchString(expr) || kwhPattern.MatchString(expr) || comparePattern.MatchString(expr)
+}
+
+// EvalEnergy evaluates the energy an appliance of a given power uses and
+// what it costs at a price per kWh: over a duration ("for 1000 hours"), or
+// per day and per month when it runs part of the time ("for 6 hours/day").
+// Comparing two appliances gives the savings of the one that uses less.
+func EvalEnergy(expr string) (utils.Result, error) {
+	expr = utils.NormalizeExpr(expr)
+	if m := comparePattern.FindStringSubmatch(expr); m != nil {
+		return evalCompare(m)
+	}
+	m := costPattern.FindStringSubmatch(expr)
+	wantCost := m != nil
+	if m == nil {
+		if m = kwhPattern.FindStringSubmatch(expr); m == nil {
+			return utils.Result{}, fmt.Errorf("invalid energy expression")
+		}
+	}
+	kw, err := kilowatts(m[1], m[2])
+	if err != nil {
+		return utils.Result{}, err
+	}
+	u, err := parseUsage(m[3])
+	if err != nil {
+		return utils.Result{}, err
+	}
+	if wantCost && !u.hasRate {
+		return utils.Result{}, fmt.Errorf("a cost needs a price such as at $0.14/kwh")
+	}
+
+	if u.hours > 0 {
+		kwh := kw * u.hours * u.share
+		if wantCost {
+			cost := kwh * u.rate
+			return utils.ValueResult(fmt.Sprintf("%s (%s)", utils.FormatCurrency(cost), kwhText(kwh)), cost, true), nil
+		}
+		text := kwhText(kwh)
+		if u.hasRate {
+			text += " (" + utils.FormatCurrency(kwh*u.rate) + ")"
+		}
+		return utils.ValueResult(text, kwh, false), nil
+	}
+
+	// Without a duration, a duty cycle gives the usage per day and per month
+	daily := kw * 24 * u.share
+	if wantCost {
+		cost := daily * u.rate
+		text := fmt.Sprintf("%s/day, %s/month (%s/day)", utils.FormatCurrency(cost),
+			utils.FormatCurrency(cost*daysPerMonth), kwhText(daily))
+		return utils.ValueResult(text, cost, true), nil
+	}
+	text := fmt.Sprintf("%s/day, %s/month", kwhText(daily), kwhText(daily*daysPerMonth))
+	if u.hasRate {
+		text = fmt.Sprintf("%s/day (%s), %s/month (%s)", kwhText(daily), utils.FormatCurrency(daily*u.rate),
+			kwhText(daily*daysPerMonth), utils.FormatCurrency(daily*daysPerMonth*u.rate))
+	}
+	return utils.ValueResult(text, daily, false), nil
+}
+
+// evalCompare compares the energy and the cost of two appliances over the
+// same usage: "led saves $7.14 (51 kWh) over incandescent: $1.26 vs $8.40"
+func evalCompare(m []string) (utils.Result, error) {
+	kw1, err := kilowatts(m[1], m[2])
+	if err != nil {
+		return utils.Result{}, err
+	}
+	kw2, err := kilowatts(m[4], m[5])
+	if err != nil {
+		return utils.Result{}, err
+	}
+	u, err := parseUsage(m[7])
+	if err != nil {
+		return utils.Result{}, err
+	}
+	// Without a duration the comparison is per month
+	hours, per := u.hours, ""
+	if hours == 0 {
+		hours, per = 24*daysPerMonth, "/month"
+	}
+	names := [2]string{label(m[3], m[1], m[2]), label(m[6], m[4], m[5])}
+	kwh := [2]float64{kw1 * hours * u.share, kw2 * hours * u.share}
+	if names[0] == names[1] {
+		names = [2]string{"the first", "the second"}
+	}
+
+	less, more := 0, 1
+	if kwh[1] < kwh[0] {
+		less, more = 1, 0
+	}
+	saved := kwh[more] - kwh[less]
+	if !u.hasRate {
+		text := fmt.Sprintf("%s saves %s%s over %s: %s vs %s", names[less], kwhText(saved), per, names[more],
+			kwhText(kwh[0]), kwhText(kwh[1]))
+		return utils.ValueResult(text, saved, false), nil
+	}
+	cost := saved * u.rate
+	text := fmt.Sprintf("%s saves %s%s (%s%s) over %s: %s vs %s", names[less], utils.FormatCurrency(cost), per,
+		kwhText(saved), per, names[more], utils.FormatCurrency(kwh[0]*u.rate), utils.FormatCurrency(kwh[1]*u.rate))
+	return utils.ValueResult(text, cost, true), nil
+}
+
+// parseUsage reads the duty cycle, duration and price clauses that follow
+// the power, in any order. A duty cycle or a duration is required.
+func parseUsage(rest string) (usage, error) {
+	u := usage{share: 1}
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		m := clausePattern.FindStringSubmatch(rest)
+		if m == nil {
+			return usage{}, fmt.Errorf("unexpected %q", rest)
+		}
+		rest = rest[len(m[0]):]
+		switch {
+		case m[1] != "":
+			if u.cycle {
+				return usage{}, fmt.Errorf("more than one duty cycle")
+			}
+			share, err := datetime.ParseDutyCycle(m[1])
+			if err != nil {
+				return usage{}, err
+			}
+			u.share, u.cycle = share, true
+		case m[2] != "":
+			if u.hours > 0 {
+				return usage{}, fmt.Errorf("more than one duration")
+			}
+			amount := m[2]
+			if amount == "a" || amount == "an" {
+				amount = "1"
+			}
+			d, err := datetime.ParseDuration(amount + " " + m[3])
+			if err != nil {
+				return usage{}, err
+			}
+			if d <= 0 {
+				return usage{}, fmt.Errorf("the duration must be more than zero")
+			}
+			u.hours = d.Hours()
+		default:
+			if u.hasRate {
+				return usage{}, fmt.Errorf("more than one price")
+			}
+			u.rate, _ = strconv.ParseFloat(m[4], 64)
+			u.hasRate = true
+		}
+	}
+	if !u.cycle && u.hours == 0 {
+		return usage{}, fmt.Errorf("say how long it runs: for 6 hours/day, for 24/7 or for 30 days")
+	}
+	return u, nil
+}
+
+// kilowatts reads a power such as "1500 w" in kW
+func kilowatts(amount, unit string) (float64, error) {
+	v, _ := strconv.ParseFloat(amount, 64)
+	w, ok := units.PowerInWatts(v, unit)
+	if !ok {
+		return 0, fmt.Errorf("unknown power unit %q", unit)
+	}
+	return w / 1000, nil
+}
+
+// powerSymbols are the symbols appliances without a name are labeled with
+var powerSymbols = map[string]string{
+	"w": "W", "watt": "W", "watts": "W",
+	"kw": "kW", "kilowatt": "kW", "kilowatts": "kW",
+	"megawatt": "MW", "megawatts": "MW",
+	"hp": "hp", "horsepower": "hp",
+}
+
+// label names an appliance by its name, or by its power without one: "9 W"
+func label(name, amount, unit string) string {
+	if name != "" {
+		return name
+	}
+	return amount + " " + powerSymbols[unit]
+}
+
+// kwhText shows an amount of energy: "46.8 kWh"
+func kwhText(kwh float64) string {
+	return utils.FormatResult(false, math.Round(kwh*100)/100) + " kWh"
+}
@@ -0,0 +1,92 @@
+package energy
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestIsEnergyExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"cost of 1500 w for 6 hours/day at $0.14/kwh", true},
+		{"Energy cost of 1.5 kW for 1000 hours at $0.14 per kWh", true},
+		{"kwh of 65 w for 24/7 for 30 days", true},
+		{"compare 9 w led vs 60 w incandescent at $0.14/kwh for 1000 hours", true},
+		{"compare 9 w vs 60 w for 24/7", true},
+
+		// Prices, conversions and dBm are left alone
+		{"cost of 3 apples", false},
+		{"1500 w in kw", false},
+		{"combine 100 w and 50 w in db", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsEnergyExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsEnergyExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalEnergy(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+		value    float64
+		currency bool
+	}{
+		{"cost of 1500 w for 6 hours/day at $0.14/kwh", "$1.26/day, $37.80/month (9 kWh/day)", 1.26, true},
+		{"cost of 1500 w at $0.14/kwh for 6 hours/day", "$1.26/day, $37.80/month (9 kWh/day)", 1.26, true},
+		{"cost of 1500 w for 1000 hours at $0.14/kwh", "$210.00 (1,500 kWh)", 210, true},
+		{"energy cost of 1 hp for a week at $0.10 per kwh", "$12.53 (125.28 kWh)", 0.7457 * 168 * 0.1, true},
+		{"kwh of 65 w for 24/7 for 30 days", "46.8 kWh", 46.8, false},
+		{"kwh of 1.5 kw for 2 hrs at $0.20/kwh", "3 kWh ($0.60)", 3, false},
+		{"kwh of 2 kw for 8 hours/day 5 days/week", "11.43 kWh/day, 342.86 kWh/month", 2 * 24 * 40.0 / 168, false},
+		{"compare 9 w led vs 60 w incandescent at $0.14/kwh for 1000 hours", "led saves $7.14 (51 kWh) over incandescent: $1.26 vs $8.40", 7.14, true},
+		// The appliance that uses less saves, whichever is named first
+		{"compare 60 w vs 9 w at $0.14/kwh for 6 hours/day", "9 W saves $1.29/month (9.18 kWh/month) over 60 W: $1.51 vs $0.23", 0.051 * 180 * 0.14, true},
+		{"compare 9 w vs 60 w for 1000 hours", "9 W saves 51 kWh over 60 W: 9 kWh vs 60 kWh", 51, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			r, err := EvalEnergy(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalEnergy(%q) error: %v", tt.expr, err)
+			}
+			if r.Text != tt.expected {
+				t.Errorf("EvalEnergy(%q) = %q, want %q", tt.expr, r.Text, tt.expected)
+			}
+			if !r.HasValue || math.Abs(r.Value-tt.value) > 1e-9*math.Max(1, tt.value) || r.IsCurrency != tt.currency {
+				t.Errorf("EvalEnergy(%q) value = %v (currency %v), want %v (currency %v)", tt.expr, r.Value, r.IsCurrency, tt.value, tt.currency)
+			}
+		})
+	}
+}
+
+func TestEvalEnergyErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"cost of 1500 w for 6 hours/day", "a cost needs a price"},
+		{"cost of 1500 w at $0.14/kwh", "say how long it runs"},
+		{"kwh of 65 w for 30 hours/day", "a day has 24 hours"},
+		{"kwh of 65 w for 8 hours/day 9 days/week", "a week has 7 days"},
+		{"kwh of 65 w for 2 days for 3 days", "more than one duration"},
+		{"kwh of 65 w for 2 days please", "unexpected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := EvalEnergy(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("EvalEnergy(%q) error = %v, want %q", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package energy
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// Missing prices and durations are reported instead of left to the
+	// evaluators after, which would claim "for 30 days" as a date
+	registry.Register(registry.Evaluator{
+		Name:     "energy",
+		Priority: registry.PriorityEnergy,
+		Traits:   registry.ReportsErrors,
+		Detect:   IsEnergyExpression,
+		Eval:     EvalEnergy,
+	})
+}
@@ -3,11 +3,28 @@ package eval
 import "fmt"
 
 func EvalExpr(expr string, refResolver func(n int) (float64, error)) (float64, error) {
-	toks, err := Lex(expr)
+	return EvalExprWithVars(expr, refResolver, nil)
+}
+
+// EvalExprWithVars evaluates expr like EvalExpr, additionally resolving bare
+// identifiers (variables defined with "name = expr") through varResolver.
+func EvalExprWithVars(expr string, refResolver func(n int) (float64, error), varResolver func(name string) (float64, error)) (float64, error) {
+	return EvalExprWithOptions(expr, refResolver, varResolver, Options{})
+}
+
+// Options change how an expression is evaluated
+type Options struct {
+	Degrees      bool // trig functions take and return degrees instead of radians
+	DecimalComma bool // numbers are written 1.234,56 instead of 1,234.56
+}
+
+// EvalExprWithOptions evaluates expr like EvalExprWithVars with opts applied
+func EvalExprWithOptions(expr string, refResolver func(n int) (float64, error), varResolver func(name string) (float64, error), opts Options) (float64, error) {
+	toks, err := lex(expr, opts.DecimalComma)
 	if err != nil {
 		return 0, err
 	}
-	p := &parser{toks: toks, refs: refResolver}
+	p := &parser{toks: toks, refs: refResolver, vars: varResolver, opts: opts}
 	v, err := p.parseExpr(0)
 	if err != nil {
 		return 0, err
@@ -17,3 +34,19 @@ func EvalExpr(expr string, refResolver func(n int) (float64, error)) (float64, e
 	}
 	return v.v, nil
 }
+
+// LeadingBase returns the base (16, 8 or 2) of the first numeric literal in expr
+// when it is written as 0x/0o/0b, or 0 if the first number is decimal.
+// Results of mixed-base arithmetic are displayed in this base.
+func LeadingBase(expr string) int {
+	toks, err := Lex(expr)
+	if err != nil {
+		return 0
+	}
+	for _, t := range toks {
+		if t.Kind == tokNumber {
+			return t.Base
+		}
+	}
+	return 0
+}
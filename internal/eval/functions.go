@@ -3,9 +3,114 @@ package eval
 import (
 	"fmt"
 	"math"
+	"sort"
+	"strings"
+	"sync"
 )
 
+// UserFunction is a one-argument function defined by the user, like
+// "fahr(x) = x * 9/5 + 32"
+type UserFunction struct {
+	Name  string
+	Param string
+	Body  string
+}
+
+// String returns the definition as it is written
+func (f UserFunction) String() string {
+	return fmt.Sprintf("%s(%s) = %s", f.Name, f.Param, f.Body)
+}
+
+var (
+	userFuncsMu sync.RWMutex
+	userFuncs   = make(map[string]UserFunction)
+)
+
+// SetUserFunctions replaces the user-defined functions. The definitions must
+// already be validated: none may be recursive or shadow a built-in.
+func SetUserFunctions(fns []UserFunction) {
+	m := make(map[string]UserFunction, len(fns))
+	for _, fn := range fns {
+		m[strings.ToLower(fn.Name)] = fn
+	}
+	userFuncsMu.Lock()
+	defer userFuncsMu.Unlock()
+	userFuncs = m
+}
+
+// UserFunctions returns the user-defined functions sorted by name
+func UserFunctions() []UserFunction {
+	userFuncsMu.RLock()
+	defer userFuncsMu.RUnlock()
+	fns := make([]UserFunction, 0, len(userFuncs))
+	for _, fn := range userFuncs {
+		fns = append(fns, fn)
+	}
+	sort.Slice(fns, func(i, j int) bool { return fns[i].Name < fns[j].Name })
+	return fns
+}
+
+func lookupUserFunction(name string) (UserFunction, bool) {
+	userFuncsMu.RLock()
+	defer userFuncsMu.RUnlock()
+	fn, ok := userFuncs[strings.ToLower(name)]
+	return fn, ok
+}
+
+// IsFunctionName reports whether name is a function usable as fn(x), built-in
+// or user-defined.
+func IsFunctionName(name string) bool {
+	if IsBuiltinFunctionName(name) {
+		return true
+	}
+	_, ok := lookupUserFunction(name)
+	return ok
+}
+
+// IsBuiltinFunctionName reports whether name is a built-in function.
+func IsBuiltinFunctionName(name string) bool {
+	_, err := callBuiltin(strings.ToLower(name), 0)
+	return err == nil
+}
+
 func callFn(name string, x float64) (float64, error) {
+	return callFnWith(name, x, Options{})
+}
+
+// callFnWith calls a function with opts applied: in degrees, sin, cos and tan
+// take an angle in degrees and asin, acos and atan return one
+func callFnWith(name string, x float64, opts Options) (float64, error) {
+	if fn, ok := lookupUserFunction(name); ok {
+		return callUserFunction(fn, x, opts)
+	}
+	if !opts.Degrees {
+		return callBuiltin(name, x)
+	}
+	switch name {
+	case "sin", "cos", "tan":
+		return callBuiltin(name, x*math.Pi/180)
+	case "asin", "acos", "atan":
+		v, err := callBuiltin(name, x)
+		return v * 180 / math.Pi, err
+	}
+	return callBuiltin(name, x)
+}
+
+// callUserFunction evaluates the body of fn with its parameter bound to x
+func callUserFunction(fn UserFunction, x float64, opts Options) (float64, error) {
+	v, err := EvalExprWithOptions(fn.Body, nil, func(name string) (float64, error) {
+		if strings.EqualFold(name, fn.Param) {
+			return x, nil
+		}
+		return 0, fmt.Errorf("unknown variable: %s", name)
+	}, opts)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn.Name, err)
+	}
+	return v, nil
+}
+
+func callBuiltin(name string, x float64) (float64, error) {
 	switch name {
 	case "sin":
 		return math.Sin(x), nil
@@ -79,3 +79,68 @@ func TestEvalExprFunctions(t *testing.T) {
 		})
 	}
 }
+
+func TestEvalExprDegrees(t *testing.T) {
+	SetUserFunctions([]UserFunction{{Name: "slope", Param: "a", Body: "tan(a)"}})
+	defer SetUserFunctions(nil)
+
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"sin(30)", 0.5},
+		{"cos(60) + sin(90)", 1.5},
+		{"atan(1)", 45},
+		{"acos(0)", 90},
+		{"slope(45)", 1},
+		{"sqrt(16)", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := EvalExprWithOptions(tt.input, nil, nil, Options{Degrees: true})
+			if err != nil {
+				t.Fatalf("EvalExprWithOptions(%q) error: %v", tt.input, err)
+			}
+			if math.Abs(result-tt.expected) > 0.0001 {
+				t.Errorf("EvalExprWithOptions(%q) = %v, want %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUserFunctions(t *testing.T) {
+	SetUserFunctions([]UserFunction{
+		{Name: "fahr", Param: "x", Body: "x * 9/5 + 32"},
+		{Name: "sq", Param: "n", Body: "n * n"},
+		{Name: "hyp", Param: "a", Body: "sqrt(sq(a) + sq(a))"},
+	})
+	defer SetUserFunctions(nil)
+
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"fahr(20)", 68},
+		{"FAHR(100) + 1", 213},
+		{"sq(sq(2))", 16},
+		{"hyp(3)", math.Sqrt(18)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := EvalExpr(tt.input, nil)
+			if err != nil {
+				t.Fatalf("EvalExpr(%q) error: %v", tt.input, err)
+			}
+			if math.Abs(result-tt.expected) > 0.0001 {
+				t.Errorf("EvalExpr(%q) = %v, want %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+
+	if !IsFunctionName("fahr") || IsBuiltinFunctionName("fahr") {
+		t.Error("fahr should be a user function name")
+	}
+	if !IsReservedName("sq") {
+		t.Error("user function names should not be usable as variables")
+	}
+}
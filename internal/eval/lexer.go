@@ -6,6 +6,8 @@ import (
 	"strings"
 	"unicode"
 	"unicode/utf8"
+
+	"smartcalc/internal/utils"
 )
 
 func stripCommas(s string) string {
@@ -15,8 +17,22 @@ func stripCommas(s string) string {
 	return strings.ReplaceAll(s, ",", "")
 }
 
+// parseNumber reads the digits of a number literal with its separators
+func (l *lexer) parseNumber(s string) (float64, error) {
+	if l.decimalComma {
+		return utils.ParseNumber(s, true)
+	}
+	return strconv.ParseFloat(stripCommas(s), 64)
+}
+
 func Lex(input string) ([]Token, error) {
-	l := &lexer{s: normalize(input)}
+	return lex(input, false)
+}
+
+// lex splits input into tokens. With decimalComma, numbers are written
+// 1.234,56: the comma is the decimal mark and periods separate thousands.
+func lex(input string, decimalComma bool) ([]Token, error) {
+	l := &lexer{s: normalize(input), decimalComma: decimalComma}
 	var toks []Token
 	for {
 		tok, err := l.next()
@@ -51,7 +67,7 @@ func normalizeMulX(s string) string {
 	n := len(s)
 	for i := 0; i < n; {
 		r, size := utf8.DecodeRuneInString(s[i:])
-		if (r == 'x' || r == 'X') && isMulContext(s, i) {
+		if (r == 'x' || r == 'X') && !isHexPrefix(s, i) && isMulContext(s, i) {
 			b.WriteByte('*')
 			i += size
 			continue
@@ -63,6 +79,65 @@ func normalizeMulX(s string) string {
 	return b.String()
 }
 
+// isHexPrefix reports whether the 'x' at idx is part of a "0x" literal prefix,
+// i.e. preceded by a lone '0' and followed by a hex digit.
+func isHexPrefix(s string, idx int) bool {
+	if idx < 1 || s[idx-1] != '0' || idx+1 >= len(s) || !isDigitInBase(rune(s[idx+1]), 16) {
+		return false
+	}
+	if idx >= 2 {
+		before := s[idx-2]
+		if (before >= '0' && before <= '9') || before == '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// isDigitInBase reports whether r is a valid digit in the given base (2, 8, 10 or 16).
+func isDigitInBase(r rune, base int) bool {
+	switch {
+	case r >= '0' && r <= '9':
+		return int(r-'0') < base
+	case r >= 'a' && r <= 'f', r >= 'A' && r <= 'F':
+		return base == 16
+	}
+	return false
+}
+
+// lexBaseLiteral lexes a 0x/0o/0b integer literal at the current position.
+// Returns ok=false if the input does not start with a prefixed literal.
+func (l *lexer) lexBaseLiteral() (Token, bool, error) {
+	if l.i+1 >= len(l.s) || l.s[l.i] != '0' {
+		return Token{}, false, nil
+	}
+	base := 0
+	switch l.s[l.i+1] {
+	case 'x', 'X':
+		base = 16
+	case 'o', 'O':
+		base = 8
+	case 'b', 'B':
+		base = 2
+	default:
+		return Token{}, false, nil
+	}
+	start := l.i
+	j := l.i + 2
+	for j < len(l.s) && isDigitInBase(rune(l.s[j]), base) {
+		j++
+	}
+	if j == l.i+2 {
+		return Token{}, false, nil
+	}
+	n, err := strconv.ParseUint(l.s[l.i+2:j], base, 64)
+	if err != nil {
+		return Token{}, true, err
+	}
+	l.i = j
+	return Token{Kind: tokNumber, Text: l.s[start:j], Num: float64(n), Base: base}, true, nil
+}
+
 func isMulContext(s string, idx int) bool {
 	left := prevNonSpaceRune(s, idx)
 	right := nextNonSpaceRune(s, idx+1)
@@ -103,6 +178,26 @@ func nextNonSpaceRune(s string, idx int) rune {
 	return 0
 }
 
+// lexExponent consumes a scientific notation exponent ("e23", "E-9", "e+6")
+// following a number. An 'e' not followed by digits is left alone.
+func (l *lexer) lexExponent() {
+	j := l.i
+	if j >= len(l.s) || (l.s[j] != 'e' && l.s[j] != 'E') {
+		return
+	}
+	j++
+	if j < len(l.s) && (l.s[j] == '+' || l.s[j] == '-') {
+		j++
+	}
+	if j >= len(l.s) || l.s[j] < '0' || l.s[j] > '9' {
+		return
+	}
+	for j < len(l.s) && l.s[j] >= '0' && l.s[j] <= '9' {
+		j++
+	}
+	l.i = j
+}
+
 func (l *lexer) advance(n int) {
 	l.i += n
 }
@@ -173,6 +268,14 @@ func (l *lexer) next() (Token, error) {
 			return Token{Kind: tokNE, Text: "!="}, nil
 		}
 		return Token{}, fmt.Errorf("unexpected '!'")
+	case '~':
+		l.advance(size)
+		// Only ~= (approximately equal) is supported
+		if l.i < len(l.s) && l.s[l.i] == '=' {
+			l.advance(1)
+			return Token{Kind: tokApprox, Text: "~="}, nil
+		}
+		return Token{}, fmt.Errorf("unexpected '~'")
 	case '(':
 		l.advance(size)
 		return Token{Kind: tokLParen, Text: "("}, nil
@@ -219,7 +322,7 @@ func (l *lexer) next() (Token, error) {
 				l.i += s2
 				continue
 			}
-			if r2 == ',' {
+			if r2 == ',' || (r2 == '.' && l.decimalComma) {
 				l.i += s2
 				continue
 			}
@@ -230,13 +333,23 @@ func (l *lexer) next() (Token, error) {
 			}
 			break
 		}
-		n, err := strconv.ParseFloat(stripCommas(l.s[start:l.i]), 64)
+		n, err := l.parseNumber(l.s[start:l.i])
 		if err != nil {
 			return Token{}, err
 		}
 		return Token{Kind: tokNumber, Text: "$" + l.s[start:l.i], Num: n}, nil
 	}
 
+	if r == '0' {
+		tok, ok, err := l.lexBaseLiteral()
+		if err != nil {
+			return Token{}, err
+		}
+		if ok {
+			return tok, nil
+		}
+	}
+
 	if unicode.IsDigit(r) || r == '.' {
 		start := l.i
 		dotSeen := r == '.'
@@ -247,7 +360,7 @@ func (l *lexer) next() (Token, error) {
 				l.i += s2
 				continue
 			}
-			if r2 == ',' {
+			if r2 == ',' || (r2 == '.' && l.decimalComma) {
 				l.i += s2
 				continue
 			}
@@ -258,7 +371,8 @@ func (l *lexer) next() (Token, error) {
 			}
 			break
 		}
-		n, err := strconv.ParseFloat(stripCommas(l.s[start:l.i]), 64)
+		l.lexExponent()
+		n, err := l.parseNumber(l.s[start:l.i])
 		if err != nil {
 			return Token{}, err
 		}
@@ -284,7 +398,20 @@ func (l *lexer) next() (Token, error) {
 			}
 			break
 		}
-		return Token{Kind: tokIdent, Text: strings.ToLower(l.s[start:l.i])}, nil
+		text := strings.ToLower(l.s[start:l.i])
+		switch text {
+		case "within":
+			return Token{Kind: tokWithin, Text: text}, nil
+		case "of":
+			return Token{Kind: tokOf, Text: text}, nil
+		case "between":
+			return Token{Kind: tokBetween, Text: text}, nil
+		case "not":
+			return Token{Kind: tokNot, Text: text}, nil
+		case "and":
+			return Token{Kind: tokAnd, Text: text}, nil
+		}
+		return Token{Kind: tokIdent, Text: text}, nil
 	}
 
 	return Token{}, fmt.Errorf("unexpected character: %q", r)
@@ -64,6 +64,14 @@ func TestLexNumbers(t *testing.T) {
 		{"1,234.56", 1234.56, false},
 		{"20%", 0.20, true},
 		{"50%", 0.50, true},
+		{"0xFF", 255, false},
+		{"0b1010", 10, false},
+		{"0o17", 15, false},
+		{"0.5", 0.5, false},
+		{"6.02e23", 6.02e23, false},
+		{"1.5e-9", 1.5e-9, false},
+		{"2E6", 2e6, false},
+		{"1e+3", 1000, false},
 	}
 
 	for _, tt := range tests {
@@ -88,6 +96,32 @@ func TestLexNumbers(t *testing.T) {
 	}
 }
 
+func TestLexBaseLiterals(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedBase int
+	}{
+		{"0xFF", 16},
+		{"0XfF", 16},
+		{"0b1010", 2},
+		{"0o17", 8},
+		{"42", 0},
+		{"0", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			toks, err := Lex(tt.input)
+			if err != nil {
+				t.Fatalf("Lex(%q) error: %v", tt.input, err)
+			}
+			if toks[0].Base != tt.expectedBase {
+				t.Errorf("Lex(%q) Base = %v, want %v", tt.input, toks[0].Base, tt.expectedBase)
+			}
+		})
+	}
+}
+
 func TestLexCurrency(t *testing.T) {
 	tests := []struct {
 		input       string
@@ -130,6 +164,9 @@ func TestLexOperators(t *testing.T) {
 		{"^", tokPow},
 		{"(", tokLParen},
 		{")", tokRParen},
+		{"~=", tokApprox},
+		{"within", tokWithin},
+		{"of", tokOf},
 	}
 
 	for _, tt := range tests {
@@ -260,3 +297,16 @@ func TestLexErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestLexExponentNeedsDigits(t *testing.T) {
+	// "2e" and "3em" are a number followed by an identifier, not e-notation
+	for _, input := range []string{"2e", "3em"} {
+		toks, err := Lex(input)
+		if err != nil {
+			t.Fatalf("Lex(%q) error: %v", input, err)
+		}
+		if len(toks) != 3 || toks[0].Kind != tokNumber || toks[1].Kind != tokIdent {
+			t.Errorf("Lex(%q) = %v, want number then identifier", input, toks)
+		}
+	}
+}
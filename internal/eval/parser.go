@@ -27,6 +27,11 @@ func (p *parser) parseExpr(minPrec int) (val, error) {
 		return val{}, err
 	}
 
+	// In a chain like "1 < x < 10" each comparison reads the right operand of
+	// the one before it, and the chain holds only if every comparison does
+	chained := false
+	var chainOperand float64
+
 	for {
 		t := p.cur()
 		prec, rightAssoc := infixPrec(t.Kind)
@@ -34,6 +39,22 @@ func (p *parser) parseExpr(minPrec int) (val, error) {
 			break
 		}
 		p.pos++
+		switch t.Kind {
+		case tokWithin:
+			left, err = p.parseWithin(left)
+			if err != nil {
+				return val{}, err
+			}
+			chained = false
+			continue
+		case tokBetween, tokNot:
+			left, err = p.parseBetween(left, t.Kind == tokNot)
+			if err != nil {
+				return val{}, err
+			}
+			chained = false
+			continue
+		}
 		nextMin := prec + 1
 		if rightAssoc {
 			nextMin = prec
@@ -61,18 +82,28 @@ func (p *parser) parseExpr(minPrec int) (val, error) {
 			left = val{v: left.v / right.v}
 		case tokPow:
 			left = val{v: math.Pow(left.v, right.v)}
-		case tokGT:
-			left = val{v: boolToFloat(left.v > right.v)}
-		case tokLT:
-			left = val{v: boolToFloat(left.v < right.v)}
-		case tokGTE:
-			left = val{v: boolToFloat(left.v >= right.v)}
-		case tokLTE:
-			left = val{v: boolToFloat(left.v <= right.v)}
-		case tokEQ:
-			left = val{v: boolToFloat(left.v == right.v)}
-		case tokNE:
-			left = val{v: boolToFloat(left.v != right.v)}
+		case tokGT, tokLT, tokGTE, tokLTE, tokEQ, tokNE, tokApprox:
+			operand := left.v
+			if chained {
+				operand = chainOperand
+			}
+			var holds bool
+			if t.Kind == tokApprox && p.cur().Kind == tokWithin {
+				// "a ~= b within 1e-6" gives ~= its own tolerance
+				p.pos++
+				tol, err := p.parseExpr(precCmp + 1)
+				if err != nil {
+					return val{}, err
+				}
+				holds = withinTolerance(operand, right.v, tol)
+			} else {
+				holds = compare(t.Kind, operand, right.v)
+			}
+			if chained {
+				holds = holds && left.v != 0
+			}
+			left = val{v: boolToFloat(holds)}
+			chained, chainOperand = true, right.v
 		default:
 			return val{}, fmt.Errorf("unexpected operator: %s", t.Text)
 		}
@@ -81,6 +112,99 @@ func (p *parser) parseExpr(minPrec int) (val, error) {
 	return left, nil
 }
 
+// parseWithin parses the tail of "a within t of b" after the "within" keyword.
+// A percent tolerance (within 1% of b) is relative to b, otherwise it is absolute.
+func (p *parser) parseWithin(left val) (val, error) {
+	tol, err := p.parseExpr(precCmp + 1)
+	if err != nil {
+		return val{}, err
+	}
+	if _, err := p.eat(tokOf); err != nil {
+		return val{}, err
+	}
+	target, err := p.parseExpr(precCmp + 1)
+	if err != nil {
+		return val{}, err
+	}
+	return val{v: boolToFloat(withinTolerance(left.v, target.v, tol))}, nil
+}
+
+// withinTolerance reports whether a is within tol of target: a percent
+// tolerance is relative to target, any other is absolute
+func withinTolerance(a, target float64, tol val) bool {
+	limit := math.Abs(tol.v)
+	if tol.pct {
+		limit = math.Abs(tol.v * target)
+	}
+	return math.Abs(a-target) <= limit
+}
+
+// compare applies a comparison operator
+func compare(op TokenKind, a, b float64) bool {
+	switch op {
+	case tokGT:
+		return a > b
+	case tokLT:
+		return a < b
+	case tokGTE:
+		return a >= b
+	case tokLTE:
+		return a <= b
+	case tokEQ:
+		return numbersEqual(a, b)
+	case tokNE:
+		return !numbersEqual(a, b)
+	}
+	return approxEqual(a, b, defaultApproxTolerance)
+}
+
+// parseBetween parses the tail of "a between lo and hi" after "between", or
+// of "a not between lo and hi" after "not". Bounds are inclusive and may be
+// given in either order.
+func (p *parser) parseBetween(left val, negate bool) (val, error) {
+	if negate {
+		if _, err := p.eat(tokBetween); err != nil {
+			return val{}, err
+		}
+	}
+	lo, err := p.parseExpr(precCmp + 1)
+	if err != nil {
+		return val{}, err
+	}
+	if _, err := p.eat(tokAnd); err != nil {
+		return val{}, err
+	}
+	hi, err := p.parseExpr(precCmp + 1)
+	if err != nil {
+		return val{}, err
+	}
+	inside := left.v >= math.Min(lo.v, hi.v) && left.v <= math.Max(lo.v, hi.v)
+	return val{v: boolToFloat(inside != negate)}, nil
+}
+
+// numbersEqual is == on numbers: integers compare exactly, and fractional
+// values within equalTolerance of each other are equal, so 0.1 + 0.2 == 0.3
+func numbersEqual(a, b float64) bool {
+	if a == math.Trunc(a) && b == math.Trunc(b) {
+		return a == b
+	}
+	return approxEqual(a, b, equalTolerance)
+}
+
+// approxEqual reports whether a and b are equal within the relative tolerance
+// tol. Values very close to zero are compared with tol as an absolute bound.
+func approxEqual(a, b, tol float64) bool {
+	if a == b {
+		return true
+	}
+	diff := math.Abs(a - b)
+	scale := math.Max(math.Abs(a), math.Abs(b))
+	if scale < 1 {
+		return diff <= tol
+	}
+	return diff <= tol*scale
+}
+
 func boolToFloat(b bool) float64 {
 	if b {
 		return 1
@@ -90,7 +214,7 @@ func boolToFloat(b bool) float64 {
 
 func infixPrec(k TokenKind) (prec int, rightAssoc bool) {
 	switch k {
-	case tokGT, tokLT, tokGTE, tokLTE, tokEQ, tokNE:
+	case tokGT, tokLT, tokGTE, tokLTE, tokEQ, tokNE, tokApprox, tokWithin, tokBetween, tokNot:
 		return precCmp, false
 	case tokPlus, tokMinus:
 		return precAdd, false
@@ -132,6 +256,14 @@ func (p *parser) parsePrefix() (val, error) {
 	case tokIdent:
 		p.pos++
 		fn := t.Text
+		// A bare identifier (not followed by "(") is a variable
+		if p.cur().Kind != tokLParen && p.vars != nil {
+			vv, err := p.vars(t.Text)
+			if err != nil {
+				return val{}, err
+			}
+			return val{v: vv}, nil
+		}
 		_, err := p.eat(tokLParen)
 		if err != nil {
 			return val{}, err
@@ -144,7 +276,7 @@ func (p *parser) parsePrefix() (val, error) {
 		if err != nil {
 			return val{}, err
 		}
-		out, err := callFn(fn, arg.v)
+		out, err := callFnWith(fn, arg.v, p.opts)
 		if err != nil {
 			return val{}, err
 		}
@@ -64,6 +64,42 @@ func TestEvalExprCurrency(t *testing.T) {
 	}
 }
 
+func TestEvalExprDecimalComma(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"1,5 + 1", 2.5},
+		{"1.234,56 + 10%", 1358.016},
+		{"$1.500,00 - 5%", 1425},
+		{"$1.234,56 + $765,44", 2000},
+		{"1.000.000 / 4", 250000},
+		{"2,5e3", 2500},
+		{"0.5 * 2", 1}, // only readable with a decimal point
+		{"(1,5 + 2,5) x 2", 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := EvalExprWithOptions(tt.input, nil, nil, Options{DecimalComma: true})
+			if err != nil {
+				t.Fatalf("EvalExprWithOptions(%q) error: %v", tt.input, err)
+			}
+			if math.Abs(result-tt.expected) > 1e-9 {
+				t.Errorf("EvalExprWithOptions(%q) = %v, want %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+
+	// The same numbers read with a decimal point
+	if result, _ := EvalExpr("1,234.56 + 10%", nil); math.Abs(result-1358.016) > 1e-9 {
+		t.Errorf("EvalExpr(%q) = %v, want %v", "1,234.56 + 10%", result, 1358.016)
+	}
+	if _, err := EvalExprWithOptions("1,2,3", nil, nil, Options{DecimalComma: true}); err == nil {
+		t.Errorf("EvalExprWithOptions(%q) expected error, got nil", "1,2,3")
+	}
+}
+
 func TestEvalExprPercentage(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -153,15 +189,136 @@ func TestEvalExprMultiplicationWithX(t *testing.T) {
 	}
 }
 
+func TestEvalExprMixedBase(t *testing.T) {
+	tests := []struct {
+		input        string
+		expected     float64
+		expectedBase int
+	}{
+		{"0xFF + 0x10", 271, 16},
+		{"0xFF + 16", 271, 16},
+		{"16 + 0xFF", 271, 0},
+		{"0b1010 * 3", 30, 2},
+		{"0o17 + 1", 16, 8},
+		{"(0x10 + 1) * 2", 34, 16},
+		{"10x5", 50, 0}, // x still works as multiplication
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := EvalExpr(tt.input, nil)
+			if err != nil {
+				t.Fatalf("EvalExpr(%q) error: %v", tt.input, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvalExpr(%q) = %v, want %v", tt.input, result, tt.expected)
+			}
+			if base := LeadingBase(tt.input); base != tt.expectedBase {
+				t.Errorf("LeadingBase(%q) = %v, want %v", tt.input, base, tt.expectedBase)
+			}
+		})
+	}
+}
+
+func TestEvalExprApproximateEquality(t *testing.T) {
+	values := map[int]float64{1: 100.3}
+	resolver := func(n int) (float64, error) {
+		return values[n], nil
+	}
+
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"0.1 + 0.2 == 0.3", 1}, // == absorbs floating-point rounding
+		{"0.1 + 0.2 != 0.3", 0}, // and != agrees with it
+		{"0.3 == 0.3000001", 0}, // but only rounding
+		{"1e15 + 1 == 1e15", 0}, // integers compare exactly
+		{"2 == 3", 0},
+		{"0.1 + 0.2 ~= 0.3", 1},           // default relative tolerance
+		{"1 ~= 1.1", 0},                   // outside default tolerance
+		{"1000000 ~= 1000000.0000001", 1}, // relative, not absolute
+		{"\\1 within 0.5 of 100", 1},      // absolute tolerance
+		{"\\1 within 0.2 of 100", 0},
+		{"\\1 within 1% of 100", 1}, // percent tolerance is relative to target
+		{"\\1 within 0.1% of 100", 0},
+		{"2 + 3 within 1 of 5.5", 1},         // arithmetic binds tighter than within
+		{"0.30000001 ~= 0.3 within 1e-6", 1}, // explicit tolerance for ~=
+		{"0.30000001 ~= 0.3 within 1e-9", 0},
+		{"\\1 ~= 100 within 1%", 1}, // percent tolerance is relative to the right side
+		{"\\1 ~= 100 within 0.1%", 0},
+		{"1 < 2 ~= 2.1 within 0.5", 1}, // chains like the other comparisons
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := EvalExpr(tt.input, resolver)
+			if err != nil {
+				t.Fatalf("EvalExpr(%q) error: %v", tt.input, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvalExpr(%q) = %v, want %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalExprComparisonChains(t *testing.T) {
+	values := map[int]float64{1: 75, 2: 5.5, 3: 1250.75}
+	resolver := func(n int) (float64, error) {
+		return values[n], nil
+	}
+
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"1 < \\2 < 10", 1},
+		{"1 < 5 < 3", 0}, // not (1 < 5) < 3
+		{"10 > 5 > 7", 0},
+		{"0 < \\2 <= 5.5 < 6", 1},
+		{"0.1 < 0.2 < 0.3", 1},
+		{"$1,000 <= \\3 < $1,250.75", 0},
+		{"$1,000 <= \\3 <= $1,250.75", 1},
+		{"1 < 2 == 2", 1},
+		{"2 * 3 < \\1 - 60 < 20", 1}, // arithmetic binds tighter
+		{"\\1 between 50 and 100", 1},
+		{"\\1 between $75 and $100", 1}, // bounds are inclusive
+		{"\\1 between 100 and 50", 1},   // in either order
+		{"\\1 between 75.01 and 100", 0},
+		{"\\2 between 5 and 6", 1},
+		{"\\1 not between 50 and 100", 0},
+		{"\\1 NOT BETWEEN 80 AND 100", 1},
+		{"\\3 between \\1 * 10 and \\1 * 20", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := EvalExpr(tt.input, resolver)
+			if err != nil {
+				t.Fatalf("EvalExpr(%q) error: %v", tt.input, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvalExpr(%q) = %v, want %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestEvalExprErrors(t *testing.T) {
 	tests := []struct {
 		input string
 	}{
-		{"2 +"},        // incomplete expression
-		{"* 3"},        // missing left operand
-		{"sin"},        // function without parens
-		{"unknown(5)"}, // unknown function
-		{"(2 + 3"},     // unclosed paren
+		{"2 +"},              // incomplete expression
+		{"* 3"},              // missing left operand
+		{"sin"},              // function without parens
+		{"unknown(5)"},       // unknown function
+		{"(2 + 3"},           // unclosed paren
+		{"1 ~ 2"},            // lone tilde
+		{"5 within 1"},       // within without "of"
+		{"5 between 1"},      // between without "and"
+		{"5 not 1 and 2"},    // not without "between"
+		{"5 between 1 or 2"}, // or is not and
 	}
 
 	for _, tt := range tests {
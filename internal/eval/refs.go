@@ -3,6 +3,7 @@ package eval
 import (
 	"fmt"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -44,9 +45,37 @@ func FindDeletionPoint(oldLines, newLines []string) int {
 	return minLen + 1
 }
 
+// refPattern matches a line reference like \3, or a range of them like \1..\10
+var refPattern = regexp.MustCompile(`\\(\d+)(?:\.\.\\(\d+))?`)
+
+// referenceRange reads the lines of a refPattern match, lowest first. A single
+// reference is a range of one line, and a reversed range \10..\1 is read as \1..\10.
+func referenceRange(m []string) (from, to int) {
+	from, _ = strconv.Atoi(m[1])
+	to = from
+	if m[2] != "" {
+		to, _ = strconv.Atoi(m[2])
+	}
+	return min(from, to), max(from, to)
+}
+
+// ReferencedLines returns the line numbers (1-based) an expression references,
+// in order, with a range \1..\3 expanded to lines 1, 2 and 3
+func ReferencedLines(expr string) []int {
+	var lines []int
+	for _, m := range refPattern.FindAllStringSubmatch(expr, -1) {
+		from, to := referenceRange(m)
+		for n := from; n <= to; n++ {
+			lines = append(lines, n)
+		}
+	}
+	return lines
+}
+
 // AdjustReferencesForInsert updates \n references when lines are inserted.
 // insertAt is 1-based line number where insertion happened.
-// delta is the number of lines inserted (positive).
+// delta is the number of lines inserted (positive). A range \1..\10 grows to
+// take in lines inserted inside it.
 func AdjustReferencesForInsert(text string, insertAt, delta int) string {
 	re := regexp.MustCompile(`\\(\d+)`)
 	return re.ReplaceAllStringFunc(text, func(match string) string {
@@ -62,33 +91,88 @@ func AdjustReferencesForInsert(text string, insertAt, delta int) string {
 
 // AdjustReferencesForDelete updates \n references when lines are deleted.
 // deleteAt is 1-based line number where deletion started.
-// delta is the number of lines deleted (positive).
+// delta is the number of lines deleted (positive). A range \1..\10 shrinks to
+// the lines of it that are left.
 func AdjustReferencesForDelete(text string, deleteAt, delta int) string {
-	re := regexp.MustCompile(`\\(\d+)`)
-	return re.ReplaceAllStringFunc(text, func(match string) string {
-		numStr := match[1:] // strip leading \
-		n, _ := strconv.Atoi(numStr)
-		deletedEnd := deleteAt + delta
-		// References in deleted range stay as-is (will error)
-		if n >= deleteAt && n < deletedEnd {
-			return match
-		}
+	deletedEnd := deleteAt + delta
+	shift := func(n int) int {
 		// References after deleted range shift down
 		if n >= deletedEnd {
-			return fmt.Sprintf("\\%d", n-delta)
+			return n - delta
 		}
-		return match
+		return n
+	}
+	return refPattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := refPattern.FindStringSubmatch(match)
+		if m[2] == "" {
+			n, _ := strconv.Atoi(m[1])
+			// References in deleted range stay as-is (will error)
+			if n >= deleteAt && n < deletedEnd {
+				return match
+			}
+			return fmt.Sprintf("\\%d", shift(n))
+		}
+		from, to := referenceRange(m)
+		reversed := m[1] != strconv.Itoa(from) // \10..\1 stays reversed
+		if from >= deleteAt && to < deletedEnd {
+			return match // the whole range was deleted
+		}
+		if from >= deleteAt && from < deletedEnd {
+			from = deletedEnd
+		}
+		if to >= deleteAt && to < deletedEnd {
+			to = deleteAt - 1
+		}
+		from, to = shift(from), shift(to)
+		if reversed && from != to {
+			from, to = to, from
+		}
+		return fmt.Sprintf("\\%d..\\%d", from, to)
 	})
 }
 
-// AdjustReferences is a convenience function that detects insert/delete and adjusts.
+// FindMovedBlock compares old and new lines of the same length to find lines
+// that were moved, as dragging a line does. It returns the moved block
+// start..end (1-based, in oldLines) and how far it moved; ok is false unless
+// the only change is one block of lines moved as a whole.
+func FindMovedBlock(oldLines, newLines []string) (start, end, delta int, ok bool) {
+	if len(oldLines) != len(newLines) {
+		return 0, 0, 0, false
+	}
+	first, last := 0, len(oldLines)-1
+	for first <= last && oldLines[first] == newLines[first] {
+		first++
+	}
+	for last > first && oldLines[last] == newLines[last] {
+		last--
+	}
+	if first >= last {
+		return 0, 0, 0, false
+	}
+
+	// The changed range must be the old range rotated: the first k lines
+	// moved below the rest
+	changed := oldLines[first : last+1]
+	for k := 1; k < len(changed); k++ {
+		if slices.Equal(newLines[first:last+1-k], changed[k:]) && slices.Equal(newLines[last+1-k:last+1], changed[:k]) {
+			return first + 1, first + k, len(changed) - k, true
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// AdjustReferences is a convenience function that detects insert/delete/move and adjusts.
 func AdjustReferences(oldText, newText string) string {
 	oldLines := strings.Split(oldText, "\n")
 	newLines := strings.Split(newText, "\n")
 
 	delta := len(newLines) - len(oldLines)
 	if delta == 0 {
-		return newText // no line count change
+		// Lines moved, or edited in place
+		if start, end, moveBy, ok := FindMovedBlock(oldLines, newLines); ok {
+			return AdjustReferencesForBlockMove(newText, start, end, moveBy)
+		}
+		return newText
 	}
 
 	if delta > 0 {
@@ -102,41 +186,82 @@ func AdjustReferences(oldText, newText string) string {
 	return AdjustReferencesForDelete(newText, deleteAt, -delta)
 }
 
-// ReplaceReferencesWithValues replaces \n references with actual numeric values.
-// values is a map from line number (1-based) to the formatted result string.
-func ReplaceReferencesWithValues(text string, values map[int]string) string {
+// AdjustReferencesForMove updates \n references when one line moves from
+// fromLine to toLine (1-based), as when it is swapped with a neighbor.
+func AdjustReferencesForMove(text string, fromLine, toLine int) string {
+	return AdjustReferencesForBlockMove(text, fromLine, fromLine, toLine-fromLine)
+}
+
+// AdjustReferencesForBlockMove updates \n references when lines start..end
+// (1-based, inclusive) move by delta lines; negative delta moves them up.
+// Every reference follows the line it points at, so references between lines
+// of the block stay relative to the block. Only references change, so text may
+// be taken before or after the lines are reordered.
+func AdjustReferencesForBlockMove(text string, start, end, delta int) string {
+	if delta == 0 || start < 1 || end < start {
+		return text
+	}
+	size := end - start + 1
 	re := regexp.MustCompile(`\\(\d+)`)
 	return re.ReplaceAllStringFunc(text, func(match string) string {
-		numStr := match[1:] // strip leading \
-		n, _ := strconv.Atoi(numStr)
-		if val, ok := values[n]; ok {
-			return val
+		n, _ := strconv.Atoi(match[1:])
+		switch {
+		case n >= start && n <= end:
+			n += delta // a line of the block
+		case delta > 0 && n > end && n <= end+delta:
+			n -= size // a line the block moved down past
+		case delta < 0 && n >= start+delta && n < start:
+			n += size // a line the block moved up past
+		default:
+			return match
 		}
-		return match // keep original if no value found
+		return fmt.Sprintf("\\%d", n)
 	})
 }
 
-// ExprReferencesCurrency detects references like \1, \2 in the expression
-// and returns true if any referenced line was currency.
-func ExprReferencesCurrency(expr string, currencyByLine []bool) bool {
-	for i := 0; i < len(expr); i++ {
-		if expr[i] != '\\' {
-			continue
-		}
-		j := i + 1
-		if j >= len(expr) || expr[j] < '0' || expr[j] > '9' {
-			continue
+// MoveLines moves lines start..end (1-based, inclusive) of text by delta lines
+// and renumbers the references to every line that changed place. A move past
+// the first or last line leaves text unchanged.
+func MoveLines(text string, start, end, delta int) string {
+	lines := strings.Split(text, "\n")
+	if start < 1 || end < start || end > len(lines) || start+delta < 1 || end+delta > len(lines) || delta == 0 {
+		return text
+	}
+	block := append([]string(nil), lines[start-1:end]...)
+	rest := append(append([]string(nil), lines[:start-1]...), lines[end:]...)
+	at := start - 1 + delta
+	moved := append(append(append([]string(nil), rest[:at]...), block...), rest[at:]...)
+	return AdjustReferencesForBlockMove(strings.Join(moved, "\n"), start, end, delta)
+}
+
+// ReplaceReferencesWithValues replaces \n references with actual numeric values.
+// values is a map from line number (1-based) to the formatted result string.
+// A range \1..\3 is replaced with the values of its lines that have one,
+// separated by commas.
+func ReplaceReferencesWithValues(text string, values map[int]string) string {
+	return refPattern.ReplaceAllStringFunc(text, func(match string) string {
+		from, to := referenceRange(refPattern.FindStringSubmatch(match))
+		var vals []string
+		for n := from; n <= to; n++ {
+			if val, ok := values[n]; ok {
+				vals = append(vals, val)
+			}
 		}
-		n := 0
-		for j < len(expr) && expr[j] >= '0' && expr[j] <= '9' {
-			n = n*10 + int(expr[j]-'0')
-			j++
+		if len(vals) == 0 {
+			return match // keep original if no value found
 		}
+		return strings.Join(vals, ", ")
+	})
+}
+
+// ExprReferencesCurrency detects references like \1, \2 or \1..\5 in the
+// expression and returns true if any referenced line was currency.
+func ExprReferencesCurrency(expr string, currencyByLine []bool) bool {
+	for _, n := range ReferencedLines(expr) {
 		idx := n - 1
 		if idx >= 0 && idx < len(currencyByLine) && currencyByLine[idx] {
 			return true
 		}
-		i = j - 1
 	}
 	return false
 }
@@ -1,6 +1,7 @@
 package eval
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -120,6 +121,20 @@ func TestAdjustReferencesForInsert(t *testing.T) {
 			delta:    3,
 			expected: "\\8",
 		},
+		{
+			name:     "range grows with a line inserted inside it",
+			text:     "trend \\1..\\10",
+			insertAt: 5,
+			delta:    1,
+			expected: "trend \\1..\\11",
+		},
+		{
+			name:     "range shifts with lines inserted above it",
+			text:     "trend \\3..\\5",
+			insertAt: 1,
+			delta:    2,
+			expected: "trend \\5..\\7",
+		},
 	}
 
 	for _, tt := range tests {
@@ -169,6 +184,41 @@ func TestAdjustReferencesForDelete(t *testing.T) {
 			delta:    1,
 			expected: "\\1 + \\2 + \\3",
 		},
+		{
+			name:     "range shrinks when a line inside it is deleted",
+			text:     "trend \\1..\\10",
+			deleteAt: 4,
+			delta:    2,
+			expected: "trend \\1..\\8",
+		},
+		{
+			name:     "range start deleted",
+			text:     "trend \\2..\\6",
+			deleteAt: 1,
+			delta:    3,
+			expected: "trend \\1..\\3",
+		},
+		{
+			name:     "range end deleted",
+			text:     "trend \\2..\\6",
+			deleteAt: 5,
+			delta:    3,
+			expected: "trend \\2..\\4",
+		},
+		{
+			name:     "reversed range stays reversed",
+			text:     "trend \\10..\\3",
+			deleteAt: 1,
+			delta:    1,
+			expected: "trend \\9..\\2",
+		},
+		{
+			name:     "whole range deleted stays",
+			text:     "trend \\2..\\3",
+			deleteAt: 2,
+			delta:    2,
+			expected: "trend \\2..\\3",
+		},
 	}
 
 	for _, tt := range tests {
@@ -340,6 +390,18 @@ func TestReplaceReferencesWithValues(t *testing.T) {
 			values:   map[int]string{1: "100"},
 			expected: "100 =\n100 * 2 =",
 		},
+		{
+			name:     "range skips lines without values",
+			text:     "trend \\1..\\4 =",
+			values:   map[int]string{1: "10", 2: "20", 4: "40"},
+			expected: "trend 10, 20, 40 =",
+		},
+		{
+			name:     "reversed range",
+			text:     "trend \\2..\\1 =",
+			values:   map[int]string{1: "10", 2: "20"},
+			expected: "trend 10, 20 =",
+		},
 	}
 
 	for _, tt := range tests {
@@ -389,6 +451,18 @@ func TestExprReferencesCurrency(t *testing.T) {
 			currencyByLine: []bool{true, false},
 			expected:       false,
 		},
+		{
+			name:           "range including currency line",
+			expr:           "trend \\1..\\3",
+			currencyByLine: []bool{false, false, true},
+			expected:       true,
+		},
+		{
+			name:           "range without currency line",
+			expr:           "trend \\1..\\2",
+			currencyByLine: []bool{false, false, true},
+			expected:       false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -400,3 +474,175 @@ func TestExprReferencesCurrency(t *testing.T) {
 		})
 	}
 }
+
+func TestAdjustReferencesForMove(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		from, to int
+		expected string
+	}{
+		{"moved line follows", "\\2 + 1", 2, 1, "\\1 + 1"},
+		{"swapped neighbor follows", "\\1 + 1", 2, 1, "\\2 + 1"},
+		{"move down", "\\1 * \\2 * \\3", 1, 3, "\\3 * \\1 * \\2"},
+		{"untouched lines keep their numbers", "\\1 + \\4 + \\12", 2, 3, "\\1 + \\4 + \\12"},
+		{"no move", "\\2", 2, 2, "\\2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AdjustReferencesForMove(tt.text, tt.from, tt.to); got != tt.expected {
+				t.Errorf("AdjustReferencesForMove(%q, %d, %d) = %q, want %q", tt.text, tt.from, tt.to, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMoveLines(t *testing.T) {
+	tests := []struct {
+		name              string
+		text              string
+		start, end, delta int
+		expected          string
+	}{
+		{
+			name:     "move the middle of a chain up",
+			text:     "10 =\n\\1 * 2 =\n\\2 + 1 =",
+			start:    2,
+			end:      2,
+			delta:    -1,
+			expected: "\\2 * 2 =\n10 =\n\\1 + 1 =",
+		},
+		{
+			name:     "move the middle of a chain down",
+			text:     "10 =\n\\1 * 2 =\n\\2 + 1 =",
+			start:    2,
+			end:      2,
+			delta:    1,
+			expected: "10 =\n\\3 + 1 =\n\\1 * 2 =",
+		},
+		{
+			name:     "block keeps its internal references",
+			text:     "x =\n5 =\n\\2 * 2 =\n\\3 + \\1 =\ny =",
+			start:    2,
+			end:      3,
+			delta:    2,
+			expected: "x =\n\\5 + \\1 =\ny =\n5 =\n\\4 * 2 =",
+		},
+		{
+			name:     "block moved up past several lines",
+			text:     "1 =\n2 =\n3 =\n\\1 + \\3 =\n\\4 * 2 =",
+			start:    4,
+			end:      5,
+			delta:    -2,
+			expected: "1 =\n\\1 + \\5 =\n\\2 * 2 =\n2 =\n3 =",
+		},
+		{
+			name:     "references outside the document are left alone",
+			text:     "\\9 =\n2 =",
+			start:    1,
+			end:      1,
+			delta:    1,
+			expected: "2 =\n\\9 =",
+		},
+		{
+			name:     "past the first line",
+			text:     "1 =\n\\1 =",
+			start:    1,
+			end:      1,
+			delta:    -1,
+			expected: "1 =\n\\1 =",
+		},
+		{
+			name:     "past the last line",
+			text:     "1 =\n\\1 =",
+			start:    1,
+			end:      2,
+			delta:    1,
+			expected: "1 =\n\\1 =",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MoveLines(tt.text, tt.start, tt.end, tt.delta); got != tt.expected {
+				t.Errorf("MoveLines(%q, %d, %d, %d) = %q, want %q", tt.text, tt.start, tt.end, tt.delta, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAdjustReferencesForMovedLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldText  string
+		newText  string
+		expected string
+	}{
+		{
+			name:     "move up",
+			oldText:  "100 =\n50 =\n\\1 + \\2 =",
+			newText:  "100 =\n\\1 + \\2 =\n50 =",
+			expected: "100 =\n\\1 + \\3 =\n50 =",
+		},
+		{
+			name:     "move down",
+			oldText:  "100 =\n50 =\n\\2 * 2 =\n\\1 + 1 =",
+			newText:  "50 =\n\\2 * 2 =\n100 =\n\\1 + 1 =",
+			expected: "50 =\n\\1 * 2 =\n100 =\n\\3 + 1 =",
+		},
+		{
+			name:     "move across a reference",
+			oldText:  "10 =\n\\1 * 2 =\n\\2 + 1 =",
+			newText:  "\\1 * 2 =\n10 =\n\\2 + 1 =",
+			expected: "\\2 * 2 =\n10 =\n\\1 + 1 =",
+		},
+		{
+			name:     "move a referenced line",
+			oldText:  "a =\nb =\nc =\n42 =\n\\4 / 2 =",
+			newText:  "42 =\na =\nb =\nc =\n\\4 / 2 =",
+			expected: "42 =\na =\nb =\nc =\n\\1 / 2 =",
+		},
+		{
+			name:     "move a block with internal references",
+			oldText:  "x =\n5 =\n\\2 * 2 =\ny =",
+			newText:  "x =\ny =\n5 =\n\\2 * 2 =",
+			expected: "x =\ny =\n5 =\n\\3 * 2 =",
+		},
+		{
+			name:     "edited lines are not a move",
+			oldText:  "10 =\n20 =\n\\1 + \\2 =",
+			newText:  "11 =\n21 =\n\\1 + \\2 =",
+			expected: "11 =\n21 =\n\\1 + \\2 =",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AdjustReferences(tt.oldText, tt.newText); got != tt.expected {
+				t.Errorf("AdjustReferences() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReferencedLines(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected []int
+	}{
+		{"\\1 + \\3", []int{1, 3}},
+		{"trend \\2..\\5", []int{2, 3, 4, 5}},
+		{"trend \\5..\\2", []int{2, 3, 4, 5}},
+		{"\\1..\\2 + \\7", []int{1, 2, 7}},
+		{"2 + 3", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := ReferencedLines(tt.expr); !slices.Equal(got, tt.expected) {
+				t.Errorf("ReferencedLines(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
@@ -14,31 +14,48 @@ const (
 	tokPow
 	tokLParen
 	tokRParen
-	tokGT  // >
-	tokLT  // <
-	tokGTE // >=
-	tokLTE // <=
-	tokEQ  // ==
-	tokNE  // !=
+	tokGT      // >
+	tokLT      // <
+	tokGTE     // >=
+	tokLTE     // <=
+	tokEQ      // ==
+	tokNE      // !=
+	tokApprox  // ~=
+	tokWithin  // within (as in "a within t of b")
+	tokOf      // of
+	tokBetween // between (as in "a between lo and hi")
+	tokNot     // not (as in "a not between lo and hi")
+	tokAnd     // and
 )
 
+// defaultApproxTolerance is the relative tolerance used by the ~= operator.
+const defaultApproxTolerance = 1e-9
+
+// equalTolerance is the relative tolerance == and != allow between values
+// that are not both integers, enough to absorb floating-point rounding.
+const equalTolerance = 1e-12
+
 type Token struct {
 	Kind TokenKind
 	Text string
 	Num  float64
 	Ref  int
 	Pct  bool
+	Base int // 16, 8 or 2 for 0x/0o/0b literals, 0 for decimal
 }
 
 type lexer struct {
-	s string
-	i int
+	s            string
+	i            int
+	decimalComma bool // numbers are written 1.234,56
 }
 
 type parser struct {
 	toks []Token
 	pos  int
 	refs func(n int) (float64, error)
+	vars func(name string) (float64, error)
+	opts Options
 }
 
 type val struct {
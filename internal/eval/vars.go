@@ -0,0 +1,85 @@
+package eval
+
+import (
+	"regexp"
+	"strings"
+)
+
+// assignmentPattern matches "name = expr" where name is a plain identifier.
+// The expression must not start with '=' so that "a == b" is not an assignment.
+var assignmentPattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*=\s*([^=].*)$`)
+
+// ParseAssignment splits a variable definition like "rent = $1800" into the
+// lowercase variable name and its expression. Returns ok=false if expr is not
+// an assignment or the name is reserved (function names and keywords).
+func ParseAssignment(expr string) (name, rhs string, ok bool) {
+	matches := assignmentPattern.FindStringSubmatch(strings.TrimSpace(expr))
+	if matches == nil {
+		return "", "", false
+	}
+	name = strings.ToLower(matches[1])
+	if IsReservedName(name) {
+		return "", "", false
+	}
+	rhs = strings.TrimSpace(matches[2])
+	if rhs == "" {
+		return "", "", false
+	}
+	return name, rhs, true
+}
+
+// IsReservedName reports whether name cannot be used as a variable name.
+func IsReservedName(name string) bool {
+	switch strings.ToLower(name) {
+	case "within", "of":
+		return true
+	}
+	return IsFunctionName(name)
+}
+
+// ExprVariables returns the variable names used in expr, in order of appearance.
+// Identifiers followed by '(' are function calls and are not included.
+func ExprVariables(expr string) []string {
+	toks, err := Lex(expr)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for i, t := range toks {
+		if t.Kind != tokIdent {
+			continue
+		}
+		if i+1 < len(toks) && toks[i+1].Kind == tokLParen {
+			continue
+		}
+		names = append(names, t.Text)
+	}
+	return names
+}
+
+// ExprFunctionCalls returns the names of the functions called in expr, in
+// order of appearance.
+func ExprFunctionCalls(expr string) []string {
+	toks, err := Lex(expr)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for i, t := range toks {
+		if t.Kind == tokIdent && i+1 < len(toks) && toks[i+1].Kind == tokLParen {
+			names = append(names, t.Text)
+		}
+	}
+	return names
+}
+
+// ExprReferencesCurrencyVar returns true if expr uses any variable whose
+// defining line was currency.
+func ExprReferencesCurrencyVar(expr string, currencyByVar map[string]bool) bool {
+	for _, name := range ExprVariables(expr) {
+		if currencyByVar[name] {
+			return true
+		}
+	}
+	return false
+}
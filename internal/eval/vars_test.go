@@ -0,0 +1,128 @@
+package eval
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParseAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantName string
+		wantRHS  string
+		wantOK   bool
+	}{
+		{"rent = $1800", "rent", "$1800", true},
+		{"tax = 8.5%", "tax", "8.5%", true},
+		{"Total_2=\\1 + \\2", "total_2", "\\1 + \\2", true},
+		{"rent == 5", "", "", false},   // comparison, not assignment
+		{"sqrt = 4", "", "", false},    // function name is reserved
+		{"within = 1", "", "", false},  // keyword is reserved
+		{"2 + 3", "", "", false},       // no assignment
+		{"10m vf=0.66", "", "", false}, // name must be the whole left side
+		{"rent =", "", "", false},      // empty expression
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			name, rhs, ok := ParseAssignment(tt.input)
+			if ok != tt.wantOK || name != tt.wantName || rhs != tt.wantRHS {
+				t.Errorf("ParseAssignment(%q) = (%q, %q, %v), want (%q, %q, %v)",
+					tt.input, name, rhs, ok, tt.wantName, tt.wantRHS, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestExprVariables(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"rent * 12", []string{"rent"}},
+		{"Rent + tax * sqrt(4)", []string{"rent", "tax"}},
+		{"2 + 3", nil},
+		{"\\1 + sin(0)", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := ExprVariables(tt.input)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ExprVariables(%q) = %v, want %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExprFunctionCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"sqrt(sq(x) + 1)", []string{"sqrt", "sq"}},
+		{"Fahr(20) * rate", []string{"fahr"}},
+		{"rent * 12", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := ExprFunctionCalls(tt.input)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ExprFunctionCalls(%q) = %v, want %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalExprWithVars(t *testing.T) {
+	vars := map[string]float64{"rent": 1800, "tax": 0.085}
+	resolver := func(name string) (float64, error) {
+		if v, ok := vars[name]; ok {
+			return v, nil
+		}
+		return 0, fmt.Errorf("undefined variable %s", name)
+	}
+
+	tests := []struct {
+		input    string
+		expected float64
+		wantErr  bool
+	}{
+		{"rent * 12", 21600, false},
+		{"RENT + 200", 2000, false},
+		{"1000 * tax", 85, false},
+		{"sqrt(rent / 2)", 30, false},
+		{"unknown + 1", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := EvalExprWithVars(tt.input, nil, resolver)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("EvalExprWithVars(%q) expected error, got %v", tt.input, result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EvalExprWithVars(%q) error: %v", tt.input, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvalExprWithVars(%q) = %v, want %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExprReferencesCurrencyVar(t *testing.T) {
+	currencyByVar := map[string]bool{"rent": true, "tax": false}
+
+	if !ExprReferencesCurrencyVar("rent * 12", currencyByVar) {
+		t.Error("expected rent * 12 to reference currency")
+	}
+	if ExprReferencesCurrencyVar("tax * 2", currencyByVar) {
+		t.Error("expected tax * 2 not to reference currency")
+	}
+}
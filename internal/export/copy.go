@@ -0,0 +1,142 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"smartcalc/internal/calc"
+	"smartcalc/internal/utils"
+)
+
+// Copy styles for CopyFormatted
+const (
+	CopyPlain       = "plain"        // references resolved, lines as they are
+	CopyAligned     = "aligned"      // '=' signs lined up, results right-aligned
+	CopyResultsOnly = "results-only" // one result per line, for spreadsheets
+)
+
+// currencyResultPattern matches a currency amount such as "$1,800.00",
+// "-€5.50" or "1,234.56 EUR"
+var currencyResultPattern = regexp.MustCompile(`^(?:-?\p{Sc}\s?-?\d[\d,.]*|-?\d[\d,.]*\s?(?:\p{Sc}|[A-Z]{3}))$`)
+
+// CopyFormatted resolves the line references of text, like a plain copy,
+// and lays it out in a copy style: CopyPlain, CopyAligned or
+// CopyResultsOnly.
+func CopyFormatted(text, style string) (string, error) {
+	lines := strings.Split(calc.ReplaceRefsWithValues(text), "\n")
+	switch style {
+	case CopyPlain:
+		return strings.Join(lines, "\n"), nil
+	case CopyAligned:
+		return strings.Join(alignLines(lines), "\n"), nil
+	case CopyResultsOnly:
+		return strings.Join(resultLines(lines), "\n"), nil
+	}
+	return "", fmt.Errorf("unknown copy style: %s", style)
+}
+
+// resultRow is a line with a result, split for alignment
+type resultRow struct {
+	expr, result, comment string
+}
+
+// splitRow splits a result line. Comment lines and the "> " lines of
+// multi-line output have no row.
+func splitRow(line string) (resultRow, bool) {
+	if calc.IsCommentLine(line) || strings.HasPrefix(strings.TrimSpace(line), ">") {
+		return resultRow{}, false
+	}
+	expr, result, comment, ok := calc.SplitResult(line)
+	if !ok {
+		return resultRow{}, false
+	}
+	return resultRow{expr, result, comment}, true
+}
+
+// alignLines pads the result lines so their '=' signs line up and their
+// results are right-aligned, currency amounts on the decimal point. Other
+// lines are kept as they are and don't count toward the columns.
+func alignLines(lines []string) []string {
+	rows := make([]*resultRow, len(lines))
+	exprWidth, fracWidth := 0, 0
+	for i, line := range lines {
+		row, ok := splitRow(line)
+		if !ok {
+			continue
+		}
+		rows[i] = &row
+		exprWidth = max(exprWidth, width(row.expr))
+		if currencyResultPattern.MatchString(row.result) {
+			fracWidth = max(fracWidth, width(fraction(row.result)))
+		}
+	}
+
+	// Currency amounts are padded on the right to the widest fraction, so
+	// their decimal points line up once every result is right-aligned
+	results := make([]string, len(lines))
+	resultWidth := 0
+	for i, row := range rows {
+		if row == nil {
+			continue
+		}
+		results[i] = row.result
+		if currencyResultPattern.MatchString(row.result) {
+			results[i] += strings.Repeat(" ", fracWidth-width(fraction(row.result)))
+		}
+		resultWidth = max(resultWidth, width(results[i]))
+	}
+
+	aligned := make([]string, len(lines))
+	for i, row := range rows {
+		if row == nil {
+			aligned[i] = lines[i]
+			continue
+		}
+		line := pad(row.expr, exprWidth) + " ="
+		if row.result != "" {
+			line += " " + strings.Repeat(" ", resultWidth-width(results[i])) + results[i]
+		}
+		if row.comment != "" {
+			line += " " + row.comment
+		}
+		aligned[i] = strings.TrimRight(line, " ")
+	}
+	return aligned
+}
+
+// resultLines returns the results of the lines, one per line. Lines without
+// a result, such as comments and multi-line output, are left out.
+func resultLines(lines []string) []string {
+	var results []string
+	for _, line := range lines {
+		if row, ok := splitRow(line); ok && row.result != "" {
+			results = append(results, row.result)
+		}
+	}
+	return results
+}
+
+// fraction returns the part of a currency amount from its decimal mark on,
+// "" for a whole amount
+func fraction(amount string) string {
+	mark := "."
+	if utils.DecimalComma() {
+		mark = ","
+	}
+	if i := strings.LastIndex(amount, mark); i >= 0 {
+		return amount[i:]
+	}
+	return ""
+}
+
+// width counts the characters of s
+func width(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// pad pads s with spaces on the right to n characters
+func pad(s string, n int) string {
+	return s + strings.Repeat(" ", max(0, n-width(s)))
+}
@@ -0,0 +1,48 @@
+package export
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// TestCopyFormatted copies testdata/copy_sheet.txt, a sheet of currency
+// amounts, comments and subnet output, in each style and compares it with
+// testdata/copy_<style>.golden. Run with -update to rewrite them.
+func TestCopyFormatted(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "copy_sheet.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, style := range []string{CopyPlain, CopyAligned, CopyResultsOnly} {
+		t.Run(style, func(t *testing.T) {
+			got, err := CopyFormatted(string(data), style)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "copy_"+style+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("CopyFormatted(%q) =\n%s\nwant\n%s", style, got, want)
+			}
+		})
+	}
+}
+
+func TestCopyFormattedUnknownStyle(t *testing.T) {
+	if _, err := CopyFormatted("1 + 1 = 2", "fancy"); err == nil {
+		t.Error("CopyFormatted with an unknown style did not fail")
+	}
+}
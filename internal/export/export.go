@@ -0,0 +1,193 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"smartcalc/internal/calc"
+)
+
+// blockKind identifies the kind of a worksheet block
+type blockKind int
+
+const (
+	blockHeading   blockKind = iota // comment line starting a section
+	blockParagraph                  // other comment or plain text line
+	blockRow                        // expression with a single-line result
+	blockOutput                     // expression with multi-line ("> ") output
+)
+
+// block is one rendered unit of a worksheet
+type block struct {
+	kind    blockKind
+	text    string   // heading or paragraph text
+	expr    string   // expression of a row or output block
+	result  string   // single-line result
+	comment string   // inline comment after the result, without '#'
+	output  []string // multi-line output lines, without the "> " prefix
+	isError bool     // result is an ERR
+}
+
+// parse splits worksheet text into blocks. Line references are replaced by
+// their values first, so the export reads on its own.
+func parse(text string) []block {
+	lines := strings.Split(calc.ReplaceRefsWithValues(text), "\n")
+
+	var blocks []block
+	sectionStart := true // a comment after a blank line starts a new section
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			sectionStart = true
+			continue
+		case strings.HasPrefix(trimmed, ">"):
+			out := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			if n := len(blocks); n > 0 && (blocks[n-1].kind == blockOutput || blocks[n-1].kind == blockRow) {
+				blocks[n-1].kind = blockOutput
+				blocks[n-1].output = append(blocks[n-1].output, out)
+			} else {
+				blocks = append(blocks, block{kind: blockParagraph, text: out})
+			}
+		case calc.IsCommentLine(line):
+			text := strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			kind := blockParagraph
+			if sectionStart {
+				kind = blockHeading
+			}
+			blocks = append(blocks, block{kind: kind, text: text})
+		default:
+			expr, result, comment, ok := calc.SplitResult(line)
+			if !ok {
+				blocks = append(blocks, block{kind: blockParagraph, text: trimmed})
+				break
+			}
+			blocks = append(blocks, block{
+				kind:    blockRow,
+				expr:    expr,
+				result:  result,
+				comment: strings.TrimSpace(strings.TrimPrefix(comment, "#")),
+				isError: strings.HasPrefix(result, "ERR"),
+			})
+		}
+		sectionStart = false
+	}
+	return blocks
+}
+
+// ExportMarkdown renders a worksheet as Markdown. Comment lines become headings
+// and paragraphs, consecutive results a table, and multi-line output a quoted block.
+func ExportMarkdown(text string) string {
+	var sb strings.Builder
+	inTable := false
+	endTable := func() {
+		if inTable {
+			sb.WriteString("\n")
+			inTable = false
+		}
+	}
+
+	for _, b := range parse(text) {
+		if b.kind != blockRow {
+			endTable()
+		}
+		switch b.kind {
+		case blockHeading:
+			fmt.Fprintf(&sb, "## %s\n\n", b.text)
+		case blockParagraph:
+			fmt.Fprintf(&sb, "%s\n\n", b.text)
+		case blockRow:
+			if !inTable {
+				sb.WriteString("| Expression | Result | Note |\n|---|---|---|\n")
+				inTable = true
+			}
+			result := markdownCell(b.result)
+			if b.isError {
+				result = "⚠️ **" + result + "**"
+			}
+			fmt.Fprintf(&sb, "| `%s` | %s | %s |\n", markdownCell(b.expr), result, markdownCell(b.comment))
+		case blockOutput:
+			fmt.Fprintf(&sb, "`%s`", b.expr)
+			if b.comment != "" {
+				fmt.Fprintf(&sb, " — %s", b.comment)
+			}
+			sb.WriteString("\n\n")
+			for _, out := range b.output {
+				fmt.Fprintf(&sb, "> %s  \n", out)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	endTable()
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+// markdownCell escapes text for use inside a Markdown table cell
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// htmlStyle is the stylesheet embedded in exported HTML
+const htmlStyle = `body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; max-width: 48em; margin: 2em auto; color: #222; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.8em; text-align: left; }
+code, pre { font-family: Menlo, Consolas, monospace; }
+.error td.result { color: #c00; font-weight: bold; }
+blockquote { border-left: 3px solid #ccc; margin: 0.5em 0 1em; padding-left: 1em; }
+.note { color: #666; }`
+
+// ExportHTML renders a worksheet as a standalone HTML page with the same
+// structure as ExportMarkdown. All worksheet text is escaped.
+func ExportHTML(text string) string {
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>SmartCalc Worksheet</title>\n")
+	fmt.Fprintf(&sb, "<style>\n%s\n</style>\n</head>\n<body>\n", htmlStyle)
+
+	inTable := false
+	endTable := func() {
+		if inTable {
+			sb.WriteString("</table>\n")
+			inTable = false
+		}
+	}
+
+	for _, b := range parse(text) {
+		if b.kind != blockRow {
+			endTable()
+		}
+		switch b.kind {
+		case blockHeading:
+			fmt.Fprintf(&sb, "<h2>%s</h2>\n", html.EscapeString(b.text))
+		case blockParagraph:
+			fmt.Fprintf(&sb, "<p>%s</p>\n", html.EscapeString(b.text))
+		case blockRow:
+			if !inTable {
+				sb.WriteString("<table>\n<tr><th>Expression</th><th>Result</th><th>Note</th></tr>\n")
+				inTable = true
+			}
+			class := ""
+			if b.isError {
+				class = ` class="error"`
+			}
+			fmt.Fprintf(&sb, "<tr%s><td><code>%s</code></td><td class=\"result\">%s</td><td class=\"note\">%s</td></tr>\n",
+				class, html.EscapeString(b.expr), html.EscapeString(b.result), html.EscapeString(b.comment))
+		case blockOutput:
+			fmt.Fprintf(&sb, "<p><code>%s</code>", html.EscapeString(b.expr))
+			if b.comment != "" {
+				fmt.Fprintf(&sb, " <span class=\"note\">%s</span>", html.EscapeString(b.comment))
+			}
+			sb.WriteString("</p>\n<blockquote><pre>")
+			for i, out := range b.output {
+				if i > 0 {
+					sb.WriteString("\n")
+				}
+				sb.WriteString(html.EscapeString(out))
+			}
+			sb.WriteString("</pre></blockquote>\n")
+		}
+	}
+	endTable()
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String()
+}
@@ -0,0 +1,65 @@
+package export
+
+import (
+	"strings"
+	"testing"
+)
+
+const worksheet = `# Budget
+rent = $1800 = $1,800.00 # monthly
+\2 * 12 = $21,600.00
+1/0 = ERR: division by zero
+
+# Loan
+$200k loan at 6% for 30 years = 
+> Monthly: $1,199.10
+> Total: $431,676.38
+a | b <x> = 3`
+
+func TestExportMarkdown(t *testing.T) {
+	got := ExportMarkdown(worksheet)
+
+	for _, want := range []string{
+		"## Budget\n",
+		"| Expression | Result | Note |\n|---|---|---|\n",
+		"| `rent = $1800` | $1,800.00 | monthly |\n",
+		"| `$1,800.00 * 12` | $21,600.00 |  |\n", // reference resolved
+		"| `1/0` | ⚠️ **ERR: division by zero** |  |\n",
+		"## Loan\n",
+		"`$200k loan at 6% for 30 years`\n\n> Monthly: $1,199.10  \n> Total: $431,676.38  \n",
+		"| `a \\| b <x>` | 3 |  |\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("ExportMarkdown missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestExportHTML(t *testing.T) {
+	got := ExportHTML(worksheet)
+
+	for _, want := range []string{
+		"<h2>Budget</h2>",
+		`<tr><td><code>rent = $1800</code></td><td class="result">$1,800.00</td><td class="note">monthly</td></tr>`,
+		`<tr class="error"><td><code>1/0</code></td><td class="result">ERR: division by zero</td>`,
+		"<blockquote><pre>Monthly: $1,199.10\nTotal: $431,676.38</pre></blockquote>",
+		"<code>a | b &lt;x&gt;</code>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("ExportHTML missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "<x>") {
+		t.Error("ExportHTML did not escape worksheet text")
+	}
+}
+
+func TestExportCommentParagraphs(t *testing.T) {
+	got := ExportMarkdown("# Title\n# some notes\n2 + 2 = 4\n#FF5733 to rgb = rgb(255, 87, 51)")
+	if !strings.Contains(got, "## Title\n\nsome notes\n\n") {
+		t.Errorf("want heading then paragraph, got:\n%s", got)
+	}
+	if !strings.Contains(got, "| `#FF5733 to rgb` | rgb(255, 87, 51) |  |") {
+		t.Errorf("hex color expression exported as a comment:\n%s", got)
+	}
+}
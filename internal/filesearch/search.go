@@ -0,0 +1,231 @@
+// Package filesearch finds lines in the documents a user worked on recently,
+// for the quick-open palette
+package filesearch
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"smartcalc/internal/calc"
+)
+
+const (
+	// MaxFileSize is how much of a file is searched; the rest is skipped
+	MaxFileSize = 1 << 20
+	// MaxMatches is the most matches a search returns
+	MaxMatches = 50
+	// MaxNotesFiles is the most files of a notes directory that are searched
+	MaxNotesFiles = 200
+	// snippetWidth is the most characters of a matching line shown
+	snippetWidth = 80
+)
+
+// NotesExtensions are the extensions of the documents in a notes directory
+var NotesExtensions = []string{".txt", ".sc"}
+
+// Match is a line of a file that matches a search
+type Match struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`    // 1-based
+	Snippet string `json:"snippet"` // the line, shortened around the match
+	Exact   bool   `json:"exact"`   // the line's expression is the query
+}
+
+// Match ranks, best first
+const (
+	rankExact = iota
+	rankPrefix
+	rankSubstring
+)
+
+// cachedFile is the searchable lines of a file as of its size and
+// modification time
+type cachedFile struct {
+	modTime time.Time
+	size    int64
+	lines   []string
+}
+
+// Searcher searches files, keeping their lines until they change on disk, so
+// it is fast enough to run on every keystroke
+type Searcher struct {
+	mu    sync.Mutex
+	files map[string]cachedFile
+}
+
+// NewSearcher creates a searcher with an empty cache
+func NewSearcher() *Searcher {
+	return &Searcher{files: make(map[string]cachedFile)}
+}
+
+// Search finds the lines of files containing query, ignoring case. A line
+// whose expression is the query ranks above one whose expression starts with
+// it, which ranks above any other hit; ties keep the order of files and
+// lines. "> " output lines are skipped. Files that can't be read, and binary
+// files, are skipped; only the first MaxFileSize bytes of a file are read.
+func (s *Searcher) Search(files []string, query string) []Match {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+	pattern := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(query))
+
+	type ranked struct {
+		Match
+		rank int
+	}
+	var found []ranked
+	seen := make(map[string]bool)
+	for _, path := range files {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		for i, line := range s.lines(path) {
+			loc := pattern.FindStringIndex(line)
+			if loc == nil || strings.HasPrefix(strings.TrimSpace(line), ">") {
+				continue
+			}
+			rank := lineRank(line, query)
+			found = append(found, ranked{Match{Path: path, Line: i + 1, Snippet: snippet(line, loc[0], loc[1]), Exact: rank == rankExact}, rank})
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].rank < found[j].rank
+	})
+	matches := make([]Match, 0, min(len(found), MaxMatches))
+	for _, f := range found[:min(len(found), MaxMatches)] {
+		matches = append(matches, f.Match)
+	}
+	return matches
+}
+
+// lineRank ranks a line containing query by how well its expression matches
+func lineRank(line, query string) int {
+	expr := line
+	if e, _, _, ok := calc.SplitResult(line); ok {
+		expr = e
+	}
+	expr = strings.TrimSpace(expr)
+	switch {
+	case strings.EqualFold(expr, query):
+		return rankExact
+	case len(expr) >= len(query) && strings.EqualFold(expr[:len(query)], query):
+		return rankPrefix
+	}
+	return rankSubstring
+}
+
+// lines returns the lines of a file, from the cache while the file is
+// unchanged. A file that can't be read has none.
+func (s *Searcher) lines(path string) []string {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil
+	}
+
+	s.mu.Lock()
+	cached, ok := s.files[path]
+	s.mu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.lines
+	}
+
+	lines, err := readLines(path)
+	if err != nil {
+		return nil
+	}
+	s.mu.Lock()
+	s.files[path] = cachedFile{modTime: info.ModTime(), size: info.Size(), lines: lines}
+	s.mu.Unlock()
+	return lines
+}
+
+// readLines reads the first MaxFileSize bytes of a text file as lines. A
+// line cut off by the limit is dropped; a binary file has no lines.
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, MaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxFileSize {
+		data = data[:bytes.LastIndexByte(data[:MaxFileSize], '\n')+1]
+	}
+	if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		return nil, nil
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n"), nil
+}
+
+// snippet shortens a line to snippetWidth characters around the match at
+// line[matchFrom:matchTo], marking what was cut with "…"
+func snippet(line string, matchFrom, matchTo int) string {
+	line = strings.TrimRight(line, " \t")
+	if utf8.RuneCountInString(line) <= snippetWidth {
+		return strings.TrimSpace(line)
+	}
+	runes := []rune(line)
+	start := utf8.RuneCountInString(line[:matchFrom])
+	end := start + utf8.RuneCountInString(line[matchFrom:matchTo])
+	// Center the match in the window, keeping the window inside the line
+	from := max(0, min(start-(snippetWidth-(end-start))/2, len(runes)-snippetWidth))
+	to := min(len(runes), from+snippetWidth)
+	s := strings.TrimSpace(string(runes[from:to]))
+	if from > 0 {
+		s = "…" + s
+	}
+	if to < len(runes) {
+		s += "…"
+	}
+	return s
+}
+
+// NotesFiles lists the documents in a notes directory and its
+// subdirectories, by their NotesExtensions, up to MaxNotesFiles. Hidden
+// directories are skipped, and so are directories that can't be read.
+func NotesFiles(dir string) []string {
+	if dir == "" {
+		return nil
+	}
+	var files []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && path != dir {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if slices.Contains(NotesExtensions, strings.ToLower(filepath.Ext(path))) {
+			files = append(files, path)
+		}
+		if len(files) >= MaxNotesFiles {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return files
+}
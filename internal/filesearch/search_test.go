@@ -0,0 +1,119 @@
+package filesearch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile writes a file in dir and returns its path
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSearch(t *testing.T) {
+	dir := t.TempDir()
+	budget := writeFile(t, dir, "budget.txt", "# Budget\nrent = $1,200\nrent + utilities = $1,350\n> 2026-10-01: $1,350\n")
+	trip := writeFile(t, dir, "trip.txt", "hotel rent per night = $90\nrent = $2,000\n")
+	missing := filepath.Join(dir, "missing.txt")
+
+	matches := NewSearcher().Search([]string{missing, budget, trip}, "RENT")
+	want := []Match{
+		{Path: budget, Line: 2, Snippet: "rent = $1,200", Exact: true},
+		{Path: trip, Line: 2, Snippet: "rent = $2,000", Exact: true},
+		{Path: budget, Line: 3, Snippet: "rent + utilities = $1,350"},
+		{Path: trip, Line: 1, Snippet: "hotel rent per night = $90"},
+	}
+	if len(matches) != len(want) {
+		t.Fatalf("got %d matches %v, want %d", len(matches), matches, len(want))
+	}
+	for i, m := range matches {
+		if m != want[i] {
+			t.Errorf("match %d = %+v, want %+v", i, m, want[i])
+		}
+	}
+
+	if matches := NewSearcher().Search([]string{budget}, "  "); matches != nil {
+		t.Errorf("an empty query matched %v", matches)
+	}
+	// Output lines are not searched
+	if matches := NewSearcher().Search([]string{budget}, "2026-10-01"); len(matches) != 0 {
+		t.Errorf("an output line matched: %v", matches)
+	}
+}
+
+func TestSearchRereadsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "notes.txt", "old value = 1\n")
+	s := NewSearcher()
+	if n := len(s.Search([]string{path}, "new")); n != 0 {
+		t.Fatalf("got %d matches before the change", n)
+	}
+
+	writeFile(t, dir, "notes.txt", "new value = 2\nold value = 1\n")
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later)
+	if matches := s.Search([]string{path}, "new"); len(matches) != 1 || matches[0].Line != 1 {
+		t.Errorf("matches after the change = %v", matches)
+	}
+}
+
+func TestSearchSkipsBinaryAndLargeFiles(t *testing.T) {
+	dir := t.TempDir()
+	binary := writeFile(t, dir, "image.png", "rent\x00\x01\x02")
+	large := writeFile(t, dir, "large.txt", strings.Repeat("filler line\n", MaxFileSize/12)+"rent at the end\n")
+
+	if matches := NewSearcher().Search([]string{binary, large}, "rent"); len(matches) != 0 {
+		t.Errorf("got matches %v", matches)
+	}
+}
+
+func TestSearchLimitsMatches(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "many.txt", strings.Repeat("x = 1\n", MaxMatches*2))
+	if n := len(NewSearcher().Search([]string{path}, "x")); n != MaxMatches {
+		t.Errorf("got %d matches, want %d", n, MaxMatches)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("a", 100) + " rent " + strings.Repeat("b", 100)
+	got := snippet(long, 101, 105)
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || !strings.Contains(got, "rent") {
+		t.Errorf("snippet = %q", got)
+	}
+	if n := len([]rune(got)); n != snippetWidth+2 {
+		t.Errorf("snippet has %d characters, want %d", n, snippetWidth+2)
+	}
+
+	if got := snippet("  rent = 5  ", 2, 6); got != "rent = 5" {
+		t.Errorf("snippet = %q, want %q", got, "rent = 5")
+	}
+}
+
+func TestNotesFiles(t *testing.T) {
+	dir := t.TempDir()
+	want := []string{
+		writeFile(t, dir, "a.txt", ""),
+		writeFile(t, dir, "sub/b.sc", ""),
+	}
+	writeFile(t, dir, "c.md", "")
+	writeFile(t, dir, ".git/d.txt", "")
+
+	got := NotesFiles(dir)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("NotesFiles = %v, want %v", got, want)
+	}
+	if got := NotesFiles(""); got != nil {
+		t.Errorf("NotesFiles(\"\") = %v", got)
+	}
+}
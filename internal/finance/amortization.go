@@ -0,0 +1,164 @@
+package finance
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"smartcalc/internal/datetime"
+	"smartcalc/internal/utils"
+)
+
+// PaymentRow is one monthly payment of an amortization schedule
+type PaymentRow struct {
+	Month     int     `json:"month"` // 1-based payment number
+	Date      string  `json:"date"`  // "Apr 2025"; payments start next month
+	Payment   float64 `json:"payment"`
+	Principal float64 `json:"principal"`
+	Interest  float64 `json:"interest"`
+	Balance   float64 `json:"balance"` // left after this payment
+}
+
+// Summary holds the totals of an amortization schedule
+type Summary struct {
+	MonthlyPayment float64 `json:"monthlyPayment"` // scheduled payment, without the extra
+	Extra          float64 `json:"extra"`          // paid on top of every payment
+	Payments       int     `json:"payments"`
+	TotalPaid      float64 `json:"totalPaid"`
+	TotalInterest  float64 `json:"totalInterest"`
+	Payoff         string  `json:"payoff"` // date of the last payment
+}
+
+// Amortization is a schedule with its summary, as returned to the frontend
+type Amortization struct {
+	Rows    []PaymentRow `json:"rows"`
+	Summary Summary      `json:"summary"`
+}
+
+// amortizationPattern matches a mortgage or loan with its optional extra
+// payment: "mortgage $350000 at 7% for 30 years extra $500"
+var amortizationPattern = regexp.MustCompile(`(?:mortgage|loan)\s+\$?([\d,]+)\s+at\s+([\d.]+)%\s+for\s+(\d+)\s+years?(?:\s+extra\s+(?:payment\s+)?\$?([\d,]+))?`)
+
+// monthlyPaymentFor returns the fixed monthly payment that repays principal
+// over numPayments months at monthlyRate
+func monthlyPaymentFor(principal, monthlyRate float64, numPayments int) float64 {
+	if monthlyRate == 0 {
+		return principal / float64(numPayments)
+	}
+	return principal * (monthlyRate * math.Pow(1+monthlyRate, float64(numPayments))) /
+		(math.Pow(1+monthlyRate, float64(numPayments)) - 1)
+}
+
+// AmortizationSchedule returns the monthly payments that repay principal at
+// an annual rate in percent (7 for 7%) over years, with extra paid on top of
+// every payment. Extra payments shorten the schedule; the last payment only
+// covers what is left.
+func AmortizationSchedule(principal, rate float64, years int, extra float64) ([]PaymentRow, Summary) {
+	monthlyRate := rate / 100 / 12
+	numPayments := years * 12
+	monthlyPayment := monthlyPaymentFor(principal, monthlyRate, numPayments)
+	summary := Summary{MonthlyPayment: monthlyPayment, Extra: extra}
+	if principal <= 0 || numPayments <= 0 {
+		return nil, summary
+	}
+
+	startDate := datetime.Now()
+	rows := make([]PaymentRow, 0, numPayments)
+	balance := principal
+	for month := 1; month <= numPayments && balance > 0; month++ {
+		interest := balance * monthlyRate
+		payment := monthlyPayment + extra
+		if extra > 0 && payment > balance+interest {
+			payment = balance + interest
+		}
+		principalPaid := payment - interest
+		balance -= principalPaid
+		// Ensure balance doesn't go negative due to rounding
+		if balance < 0 {
+			balance = 0
+		}
+
+		rows = append(rows, PaymentRow{
+			Month:     month,
+			Date:      startDate.AddDate(0, month, 0).Format("Jan 2006"),
+			Payment:   payment,
+			Principal: principalPaid,
+			Interest:  interest,
+			Balance:   balance,
+		})
+		summary.TotalPaid += payment
+		summary.TotalInterest += interest
+	}
+	summary.Payments = len(rows)
+	summary.Payoff = rows[len(rows)-1].Date
+	return rows, summary
+}
+
+// ScheduleFor returns the amortization schedule of a mortgage or loan
+// expression such as "mortgage $350000 at 7% for 30 years pay schedule"
+func ScheduleFor(expr string) (Amortization, error) {
+	m := amortizationPattern.FindStringSubmatch(strings.ToLower(expr))
+	if m == nil {
+		return Amortization{}, fmt.Errorf("not a mortgage or loan: %s", expr)
+	}
+	principal := parseAmount(m[1])
+	years := parseInt(m[3])
+	if principal == 0 || years == 0 {
+		return Amortization{}, fmt.Errorf("principal and term must not be zero")
+	}
+	rows, summary := AmortizationSchedule(principal, parseFloat(m[2]), years, parseAmount(m[4]))
+	return Amortization{Rows: rows, Summary: summary}, nil
+}
+
+// formatSchedule renders a schedule as a "> " table: one row per payment
+// when full is set, otherwise one row per year of payments
+func formatSchedule(rows []PaymentRow, summary Summary, full bool) string {
+	title := "> Payment Schedule: yearly totals (add \"full schedule\" for every month)"
+	table := [][]string{{"Months", "Payments", "Principal", "Interest", "Balance"}}
+	if full {
+		title = "> Payment Schedule:"
+		table[0] = []string{"Month", "Payment", "Principal", "Interest", "Balance"}
+		for _, r := range rows {
+			table = append(table, []string{
+				r.Date,
+				utils.FormatCurrency(r.Payment),
+				utils.FormatCurrency(r.Principal),
+				utils.FormatCurrency(r.Interest),
+				utils.FormatCurrency(r.Balance),
+			})
+		}
+	} else {
+		for start := 0; start < len(rows); start += 12 {
+			year := rows[start:min(start+12, len(rows))]
+			var paid, principal, interest float64
+			for _, r := range year {
+				paid += r.Payment
+				principal += r.Principal
+				interest += r.Interest
+			}
+			table = append(table, []string{
+				year[0].Date + " – " + year[len(year)-1].Date,
+				utils.FormatCurrency(paid),
+				utils.FormatCurrency(principal),
+				utils.FormatCurrency(interest),
+				utils.FormatCurrency(year[len(year)-1].Balance),
+			})
+		}
+	}
+
+	// Amounts are right-aligned; the rules span the header
+	lines := utils.AlignColumns(table, 1, 2, 3, 4)
+	rule := "> " + strings.Repeat("─", utils.DisplayWidth(lines[0])) + "\n"
+	var sb strings.Builder
+	sb.WriteString("\n" + title + "\n")
+	sb.WriteString(rule)
+	sb.WriteString("> " + lines[0] + "\n")
+	sb.WriteString(rule)
+	for _, line := range lines[1:] {
+		sb.WriteString("> " + line + "\n")
+	}
+	sb.WriteString(rule)
+	sb.WriteString(fmt.Sprintf("> Total Interest: %s", utils.FormatCurrency(summary.TotalInterest)))
+	return sb.String()
+}
@@ -0,0 +1,137 @@
+package finance
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"smartcalc/internal/datetime"
+	"smartcalc/internal/utils"
+)
+
+func TestAmortizationSchedule(t *testing.T) {
+	rows, summary := AmortizationSchedule(350000, 7, 30, 0)
+	if len(rows) != 360 || summary.Payments != 360 {
+		t.Fatalf("got %d rows and %d payments, want 360", len(rows), summary.Payments)
+	}
+	if got := utils.FormatCurrency(summary.MonthlyPayment); got != "$2,328.56" {
+		t.Errorf("monthly payment = %s, want $2,328.56", got)
+	}
+	if got := rows[len(rows)-1].Balance; math.Abs(got) > 0.005 {
+		t.Errorf("final balance = %v, want 0", got)
+	}
+
+	var paid, principal, interest float64
+	for i, r := range rows {
+		if r.Month != i+1 {
+			t.Fatalf("row %d has month %d", i, r.Month)
+		}
+		paid += r.Payment
+		principal += r.Principal
+		interest += r.Interest
+	}
+	if utils.FormatCurrency(paid) != utils.FormatCurrency(summary.TotalPaid) ||
+		utils.FormatCurrency(interest) != utils.FormatCurrency(summary.TotalInterest) {
+		t.Errorf("rows add up to %v paid and %v interest, summary has %v and %v", paid, interest, summary.TotalPaid, summary.TotalInterest)
+	}
+	if utils.FormatCurrency(principal) != "$350,000.00" {
+		t.Errorf("principal repaid = %s, want $350,000.00", utils.FormatCurrency(principal))
+	}
+}
+
+func TestAmortizationScheduleExtraPayment(t *testing.T) {
+	rows, summary := AmortizationSchedule(350000, 7, 30, 500)
+	if summary.Payments >= 360 || summary.Payments != len(rows) {
+		t.Fatalf("extra payments should shorten the schedule, got %d payments", summary.Payments)
+	}
+	last := rows[len(rows)-1]
+	if last.Balance != 0 {
+		t.Errorf("final balance = %v, want 0", last.Balance)
+	}
+	if last.Payment > summary.MonthlyPayment+summary.Extra {
+		t.Errorf("last payment %v is more than the regular %v", last.Payment, summary.MonthlyPayment+summary.Extra)
+	}
+
+	// The extra payment summary uses the same schedule
+	text, err := EvalFinance("mortgage $350000 at 7% for 30 years extra payment $500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "With Extra Payment: " + utils.FormatCurrency(summary.TotalInterest); !strings.Contains(text, want) {
+		t.Errorf("extra payment text missing %q\nGot: %s", want, text)
+	}
+}
+
+// TestScheduleTotalsMatchText checks that the structured schedule and the
+// inline text agree to the cent
+func TestScheduleTotalsMatchText(t *testing.T) {
+	datetime.SetClock(func() time.Time { return time.Date(2025, time.March, 15, 0, 0, 0, 0, time.UTC) })
+	defer datetime.SetClock(nil)
+
+	for _, expr := range []string{
+		"mortgage $350000 at 7% for 30 years pay schedule",
+		"mortgage $350000 at 7% for 30 years full schedule",
+		"mortgage $120000 at 0% for 10 years pay schedule",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			schedule, err := ScheduleFor(expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			text, err := EvalFinance(expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "> Total Interest: " + utils.FormatCurrency(schedule.Summary.TotalInterest); !strings.HasSuffix(text, want) {
+				t.Errorf("text total differs from %q\nGot: %s", want, text)
+			}
+
+			lines := strings.Split(text, "\n")
+			tableRows := 0
+			for _, line := range lines {
+				if strings.Contains(line, " | ") {
+					tableRows++
+				}
+			}
+			wantRows := len(schedule.Rows)/12 + 1
+			if strings.Contains(expr, "full") {
+				wantRows = len(schedule.Rows) + 1
+			}
+			if tableRows != wantRows {
+				t.Errorf("text has %d table rows, want %d", tableRows, wantRows)
+			}
+
+			last := schedule.Rows[len(schedule.Rows)-1]
+			if schedule.Summary.Payoff != last.Date || !strings.Contains(text, last.Date+" |") {
+				t.Errorf("payoff %s not shown as the last row\nGot: %s", schedule.Summary.Payoff, text)
+			}
+		})
+	}
+}
+
+func TestYearlySchedule(t *testing.T) {
+	datetime.SetClock(func() time.Time { return time.Date(2025, time.March, 15, 0, 0, 0, 0, time.UTC) })
+	defer datetime.SetClock(nil)
+
+	text, err := EvalFinance("mortgage $100000 at 5% for 2 years pay schedule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"> Apr 2025 – Mar 2026 | $52,645.67 | $48,752.86 | $3,892.81 | $51,247.14",
+		"> Apr 2026 – Mar 2027 | $52,645.67 | $51,247.14 | $1,398.52 |      $0.00",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("yearly schedule missing %q\nGot: %s", want, text)
+		}
+	}
+}
+
+func TestScheduleForErrors(t *testing.T) {
+	for _, expr := range []string{"invest $1000 at 7% for 20 years", "mortgage $0 at 5% for 30 years", "mortgage $1000 at 5% for 0 years"} {
+		if _, err := ScheduleFor(expr); err == nil {
+			t.Errorf("ScheduleFor(%q) should fail", expr)
+		}
+	}
+}
@@ -6,35 +6,56 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"time"
 
+	"smartcalc/internal/datetime"
 	"smartcalc/internal/utils"
 )
 
 // Handler defines the interface for financial calculation handlers.
 type Handler interface {
-	Handle(expr, exprLower string) (string, bool)
+	Handle(expr, exprLower string) (utils.Result, bool)
 }
 
 // HandlerFunc is an adapter to allow ordinary functions to be used as Handlers.
 type HandlerFunc func(expr, exprLower string) (string, bool)
 
+// Handle calls the underlying function; its output has no referenceable value.
+func (f HandlerFunc) Handle(expr, exprLower string) (utils.Result, bool) {
+	text, ok := f(expr, exprLower)
+	return utils.TextResult(text), ok
+}
+
+// ValueHandlerFunc is an adapter for handlers whose result has a primary
+// numeric value that later lines can reference.
+type ValueHandlerFunc func(expr, exprLower string) (utils.Result, bool)
+
 // Handle calls the underlying function.
-func (f HandlerFunc) Handle(expr, exprLower string) (string, bool) {
+func (f ValueHandlerFunc) Handle(expr, exprLower string) (utils.Result, bool) {
 	return f(expr, exprLower)
 }
 
 // handlerChain is the ordered list of handlers for financial calculations.
 var handlerChain = []Handler{
-	HandlerFunc(handleLoanPayment),
-	HandlerFunc(handleCompoundInterest),
-	HandlerFunc(handleSimpleInterest),
-	HandlerFunc(handleMortgagePayment),
-	HandlerFunc(handleInvestmentGrowth),
+	ValueHandlerFunc(handleLoanPayment),
+	ValueHandlerFunc(handleCompoundInterest),
+	ValueHandlerFunc(handleSimpleInterest),
+	ValueHandlerFunc(handleMortgagePayment),
+	ValueHandlerFunc(handleInvestmentGrowth),
 }
 
 // EvalFinance evaluates a financial expression and returns the result.
 func EvalFinance(expr string) (string, error) {
+	result, err := EvalFinanceResult(expr)
+	return result.Text, err
+}
+
+// EvalFinanceResult evaluates a financial expression and also reports its
+// referenceable currency value:
+//   - loan, mortgage and pay schedule: the monthly payment
+//   - mortgage with extra payment: the monthly payment including the extra
+//   - compound interest and invest: the final amount
+//   - simple interest: the total (principal + interest)
+func EvalFinanceResult(expr string) (utils.Result, error) {
 	expr = strings.TrimSpace(expr)
 	exprLower := strings.ToLower(expr)
 
@@ -44,7 +65,7 @@ func EvalFinance(expr string) (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("unable to evaluate financial expression: %s", expr)
+	return utils.Result{}, fmt.Errorf("unable to evaluate financial expression: %s", expr)
 }
 
 // IsFinanceExpression checks if an expression looks like a financial calculation.
@@ -69,12 +90,12 @@ func IsFinanceExpression(expr string) bool {
 	return false
 }
 
-func handleLoanPayment(expr, exprLower string) (string, bool) {
+func handleLoanPayment(expr, exprLower string) (utils.Result, bool) {
 	// Pattern: "loan $250000 at 6.5% for 30 years" or "loan 250000 at 6.5% for 30 years"
 	re := regexp.MustCompile(`loan\s+\$?([\d,]+)\s+at\s+([\d.]+)%\s+for\s+(\d+)\s+years?`)
 	matches := re.FindStringSubmatch(exprLower)
 	if matches == nil {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	principal := parseAmount(matches[1])
@@ -82,7 +103,7 @@ func handleLoanPayment(expr, exprLower string) (string, bool) {
 	years := parseInt(matches[3])
 
 	if principal == 0 || years == 0 {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	monthlyRate := annualRate / 12
@@ -99,21 +120,22 @@ func handleLoanPayment(expr, exprLower string) (string, bool) {
 	totalPayment := monthlyPayment * float64(numPayments)
 	totalInterest := totalPayment - principal
 
-	return fmt.Sprintf("\n> Monthly: %s\n> Total: %s\n> Interest: %s",
-		utils.FormatCurrency(monthlyPayment), utils.FormatCurrency(totalPayment), utils.FormatCurrency(totalInterest)), true
+	text := fmt.Sprintf("\n> Monthly: %s\n> Total: %s\n> Interest: %s",
+		utils.FormatCurrency(monthlyPayment), utils.FormatCurrency(totalPayment), utils.FormatCurrency(totalInterest))
+	return utils.ValueResult(text, monthlyPayment, true), true
 }
 
-func handleCompoundInterest(expr, exprLower string) (string, bool) {
+func handleCompoundInterest(expr, exprLower string) (utils.Result, bool) {
 	// Pattern: "$10000 at 5% for 10 years compounded monthly" or "compound interest $10000 at 5% for 10 years"
 	re := regexp.MustCompile(`(?:compound\s+interest\s+)?\$?([\d,]+)\s+at\s+([\d.]+)%\s+for\s+(\d+)\s+years?\s*(?:compounded\s+)?(\w+)?`)
 	matches := re.FindStringSubmatch(exprLower)
 	if matches == nil {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	// Must contain "compound" keyword
 	if !strings.Contains(exprLower, "compound") {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	principal := parseAmount(matches[1])
@@ -125,22 +147,23 @@ func handleCompoundInterest(expr, exprLower string) (string, bool) {
 	}
 
 	if principal == 0 || years == 0 {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	n := getCompoundingFrequency(compoundFreq)
 	amount := principal * math.Pow(1+annualRate/float64(n), float64(n*years))
 	interest := amount - principal
 
-	return fmt.Sprintf("\n> Final: %s\n> Interest earned: %s", utils.FormatCurrency(amount), utils.FormatCurrency(interest)), true
+	text := fmt.Sprintf("\n> Final: %s\n> Interest earned: %s", utils.FormatCurrency(amount), utils.FormatCurrency(interest))
+	return utils.ValueResult(text, amount, true), true
 }
 
-func handleSimpleInterest(expr, exprLower string) (string, bool) {
+func handleSimpleInterest(expr, exprLower string) (utils.Result, bool) {
 	// Pattern: "simple interest $5000 at 3% for 2 years"
 	re := regexp.MustCompile(`simple\s+interest\s+\$?([\d,]+)\s+at\s+([\d.]+)%\s+for\s+(\d+)\s+years?`)
 	matches := re.FindStringSubmatch(exprLower)
 	if matches == nil {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	principal := parseAmount(matches[1])
@@ -148,16 +171,17 @@ func handleSimpleInterest(expr, exprLower string) (string, bool) {
 	years := parseInt(matches[3])
 
 	if principal == 0 || years == 0 {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	interest := principal * rate * float64(years)
 	total := principal + interest
 
-	return fmt.Sprintf("\n> Interest: %s\n> Total: %s", utils.FormatCurrency(interest), utils.FormatCurrency(total)), true
+	text := fmt.Sprintf("\n> Interest: %s\n> Total: %s", utils.FormatCurrency(interest), utils.FormatCurrency(total))
+	return utils.ValueResult(text, total, true), true
 }
 
-func handleMortgagePayment(expr, exprLower string) (string, bool) {
+func handleMortgagePayment(expr, exprLower string) (utils.Result, bool) {
 	// Check for extra payment variant first
 	// Pattern: "mortgage $350000 at 7% for 30 years extra payment $500" or "extra $500"
 	extraRe := regexp.MustCompile(`mortgage\s+\$?([\d,]+)\s+at\s+([\d.]+)%\s+for\s+(\d+)\s+years?\s+extra\s+(?:payment\s+)?\$?([\d,]+)`)
@@ -167,8 +191,9 @@ func handleMortgagePayment(expr, exprLower string) (string, bool) {
 	}
 
 	// Check for pay schedule variant
-	// Pattern: "mortgage $350000 at 7% for 30 years pay schedule"
-	scheduleRe := regexp.MustCompile(`mortgage\s+\$?([\d,]+)\s+at\s+([\d.]+)%\s+for\s+(\d+)\s+years?\s+pay\s+schedule`)
+	// Pattern: "mortgage $350000 at 7% for 30 years pay schedule" (yearly totals)
+	// or "... full schedule" (every month)
+	scheduleRe := regexp.MustCompile(`mortgage\s+\$?([\d,]+)\s+at\s+([\d.]+)%\s+for\s+(\d+)\s+years?\s+(pay|full(?:\s+pay)?)\s+schedule`)
 	scheduleMatches := scheduleRe.FindStringSubmatch(exprLower)
 	if scheduleMatches != nil {
 		return handleMortgagePaySchedule(scheduleMatches)
@@ -178,7 +203,7 @@ func handleMortgagePayment(expr, exprLower string) (string, bool) {
 	re := regexp.MustCompile(`mortgage\s+\$?([\d,]+)\s+at\s+([\d.]+)%\s+for\s+(\d+)\s+years?`)
 	matches := re.FindStringSubmatch(exprLower)
 	if matches == nil {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	principal := parseAmount(matches[1])
@@ -186,7 +211,7 @@ func handleMortgagePayment(expr, exprLower string) (string, bool) {
 	years := parseInt(matches[3])
 
 	if principal == 0 || years == 0 {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	monthlyRate := annualRate / 12
@@ -204,122 +229,51 @@ func handleMortgagePayment(expr, exprLower string) (string, bool) {
 	totalInterest := totalPayment - principal
 
 	// Calculate payoff date (assuming payments start next month)
-	startDate := time.Now()
+	startDate := datetime.Now()
 	payoffDate := startDate.AddDate(0, numPayments, 0)
 
-	return fmt.Sprintf("\n> Monthly: %s\n> Total: %s\n> Interest: %s\n> Payoff: %s",
+	text := fmt.Sprintf("\n> Monthly: %s\n> Total: %s\n> Interest: %s\n> Payoff: %s",
 		utils.FormatCurrency(monthlyPayment), utils.FormatCurrency(totalPayment),
-		utils.FormatCurrency(totalInterest), payoffDate.Format("Jan 2006")), true
+		utils.FormatCurrency(totalInterest), payoffDate.Format("Jan 2006"))
+	return utils.ValueResult(text, monthlyPayment, true), true
 }
 
-func handleMortgagePaySchedule(matches []string) (string, bool) {
+func handleMortgagePaySchedule(matches []string) (utils.Result, bool) {
 	principal := parseAmount(matches[1])
-	annualRate := parseFloat(matches[2]) / 100
 	years := parseInt(matches[3])
 
 	if principal == 0 || years == 0 {
-		return "", false
-	}
-
-	monthlyRate := annualRate / 12
-	numPayments := years * 12
-
-	var monthlyPayment float64
-	if monthlyRate == 0 {
-		monthlyPayment = principal / float64(numPayments)
-	} else {
-		monthlyPayment = principal * (monthlyRate * math.Pow(1+monthlyRate, float64(numPayments))) /
-			(math.Pow(1+monthlyRate, float64(numPayments)) - 1)
+		return utils.Result{}, false
 	}
 
-	// Build amortization schedule
-	var sb strings.Builder
-	sb.WriteString("\n> Payment Schedule:\n")
-	sb.WriteString("> ──────────────────────────────────────────────────────────────\n")
-	sb.WriteString("> Month      | Payment    | Principal  | Interest   | Balance\n")
-	sb.WriteString("> ──────────────────────────────────────────────────────────────\n")
-
-	balance := principal
-	startDate := time.Now()
-	totalInterest := 0.0
-
-	for i := 1; i <= numPayments; i++ {
-		interestPayment := balance * monthlyRate
-		principalPayment := monthlyPayment - interestPayment
-		balance -= principalPayment
-		totalInterest += interestPayment
-
-		// Ensure balance doesn't go negative due to rounding
-		if balance < 0 {
-			balance = 0
-		}
-
-		paymentDate := startDate.AddDate(0, i, 0)
-		sb.WriteString(fmt.Sprintf("> %s | %10s | %10s | %10s | %10s\n",
-			paymentDate.Format("Jan 2006"),
-			utils.FormatCurrency(monthlyPayment),
-			utils.FormatCurrency(principalPayment),
-			utils.FormatCurrency(interestPayment),
-			utils.FormatCurrency(balance)))
-	}
-
-	sb.WriteString("> ──────────────────────────────────────────────────────────────\n")
-	sb.WriteString(fmt.Sprintf("> Total Interest: %s", utils.FormatCurrency(totalInterest)))
-
-	return sb.String(), true
+	rows, summary := AmortizationSchedule(principal, parseFloat(matches[2]), years, 0)
+	text := formatSchedule(rows, summary, strings.HasPrefix(matches[4], "full"))
+	return utils.ValueResult(text, summary.MonthlyPayment, true), true
 }
 
-func handleMortgageWithExtraPayment(matches []string) (string, bool) {
+func handleMortgageWithExtraPayment(matches []string) (utils.Result, bool) {
 	principal := parseAmount(matches[1])
 	annualRate := parseFloat(matches[2]) / 100
 	years := parseInt(matches[3])
 	extraPayment := parseAmount(matches[4])
 
 	if principal == 0 || years == 0 {
-		return "", false
+		return utils.Result{}, false
 	}
 
-	monthlyRate := annualRate / 12
 	numPayments := years * 12
-
-	var monthlyPayment float64
-	if monthlyRate == 0 {
-		monthlyPayment = principal / float64(numPayments)
-	} else {
-		monthlyPayment = principal * (monthlyRate * math.Pow(1+monthlyRate, float64(numPayments))) /
-			(math.Pow(1+monthlyRate, float64(numPayments)) - 1)
-	}
+	monthlyPayment := monthlyPaymentFor(principal, annualRate/12, numPayments)
 
 	// Calculate standard mortgage totals
 	standardTotal := monthlyPayment * float64(numPayments)
 	standardInterest := standardTotal - principal
-	startDate := time.Now()
+	startDate := datetime.Now()
 	standardPayoffDate := startDate.AddDate(0, numPayments, 0)
 
 	// Calculate with extra payment
-	balance := principal
-	totalInterestWithExtra := 0.0
-	monthsWithExtra := 0
-
-	for balance > 0 {
-		monthsWithExtra++
-		interestPayment := balance * monthlyRate
-		totalInterestWithExtra += interestPayment
-
-		// Apply regular payment + extra payment
-		totalPaymentThisMonth := monthlyPayment + extraPayment
-		principalPayment := totalPaymentThisMonth - interestPayment
-
-		balance -= principalPayment
-		if balance < 0 {
-			balance = 0
-		}
-
-		// Safety check to prevent infinite loop
-		if monthsWithExtra > numPayments*2 {
-			break
-		}
-	}
+	_, withExtra := AmortizationSchedule(principal, parseFloat(matches[2]), years, extraPayment)
+	totalInterestWithExtra := withExtra.TotalInterest
+	monthsWithExtra := withExtra.Payments
 
 	extraPayoffDate := startDate.AddDate(0, monthsWithExtra, 0)
 	interestSavings := standardInterest - totalInterestWithExtra
@@ -329,28 +283,31 @@ func handleMortgageWithExtraPayment(matches []string) (string, bool) {
 	yearsSaved := timeSaved / 12
 	monthsSaved := timeSaved % 12
 	var timeSavedStr string
+	yearsStr := fmt.Sprintf("%d %s", yearsSaved, utils.Plural(float64(yearsSaved), "year", "years"))
+	monthsStr := fmt.Sprintf("%d %s", monthsSaved, utils.Plural(float64(monthsSaved), "month", "months"))
 	if yearsSaved > 0 && monthsSaved > 0 {
-		timeSavedStr = fmt.Sprintf("%d years, %d months", yearsSaved, monthsSaved)
+		timeSavedStr = yearsStr + ", " + monthsStr
 	} else if yearsSaved > 0 {
-		timeSavedStr = fmt.Sprintf("%d years", yearsSaved)
+		timeSavedStr = yearsStr
 	} else {
-		timeSavedStr = fmt.Sprintf("%d months", monthsSaved)
+		timeSavedStr = monthsStr
 	}
 
-	return fmt.Sprintf("\n> Monthly: %s (+ %s extra)\n> Standard Interest: %s\n> With Extra Payment: %s\n> Interest Savings: %s\n> Standard Payoff: %s\n> New Payoff: %s\n> Time Saved: %s",
+	text := fmt.Sprintf("\n> Monthly: %s (+ %s extra)\n> Standard Interest: %s\n> With Extra Payment: %s\n> Interest Savings: %s\n> Standard Payoff: %s\n> New Payoff: %s\n> Time Saved: %s",
 		utils.FormatCurrency(monthlyPayment), utils.FormatCurrency(extraPayment),
 		utils.FormatCurrency(standardInterest), utils.FormatCurrency(totalInterestWithExtra),
 		utils.FormatCurrency(interestSavings),
 		standardPayoffDate.Format("Jan 2006"), extraPayoffDate.Format("Jan 2006"),
-		timeSavedStr), true
+		timeSavedStr)
+	return utils.ValueResult(text, monthlyPayment+extraPayment, true), true
 }
 
-func handleInvestmentGrowth(expr, exprLower string) (string, bool) {
+func handleInvestmentGrowth(expr, exprLower string) (utils.Result, bool) {
 	// Pattern: "invest $1000 at 7% for 20 years"
 	re := regexp.MustCompile(`invest\s+\$?([\d,]+)\s+at\s+([\d.]+)%\s+for\s+(\d+)\s+years?`)
 	matches := re.FindStringSubmatch(exprLower)
 	if matches == nil {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	principal := parseAmount(matches[1])
@@ -358,7 +315,7 @@ func handleInvestmentGrowth(expr, exprLower string) (string, bool) {
 	years := parseInt(matches[3])
 
 	if principal == 0 || years == 0 {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	// Assume annual compounding for simple invest command
@@ -366,7 +323,8 @@ func handleInvestmentGrowth(expr, exprLower string) (string, bool) {
 	growth := amount - principal
 	growthPercent := (growth / principal) * 100
 
-	return fmt.Sprintf("\n> Final: %s\n> Growth: %s (+%.1f%%)", utils.FormatCurrency(amount), utils.FormatCurrency(growth), growthPercent), true
+	text := fmt.Sprintf("\n> Final: %s\n> Growth: %s (+%.1f%%)", utils.FormatCurrency(amount), utils.FormatCurrency(growth), growthPercent)
+	return utils.ValueResult(text, amount, true), true
 }
 
 func parseAmount(s string) float64 {
@@ -1,8 +1,15 @@
 package finance
 
 import (
+	"flag"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"smartcalc/internal/datetime"
+	"smartcalc/internal/utils"
 )
 
 func TestLoanPayment(t *testing.T) {
@@ -106,6 +113,45 @@ func TestMortgagePayment(t *testing.T) {
 	}
 }
 
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func TestMortgagePayScheduleGolden(t *testing.T) {
+	datetime.SetClock(func() time.Time { return time.Date(2025, time.March, 15, 0, 0, 0, 0, time.UTC) })
+	defer datetime.SetClock(nil)
+
+	result, err := EvalFinance("mortgage $1000000 at 5% for 1 year full schedule")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every row of the table, including the rules, has the same display width
+	var width int
+	for _, line := range strings.Split(result, "\n") {
+		if !strings.Contains(line, "|") && !strings.Contains(line, "─") {
+			continue
+		}
+		if w := utils.DisplayWidth(line); width == 0 {
+			width = w
+		} else if w != width {
+			t.Errorf("row %q is %d columns wide, want %d", line, w, width)
+		}
+	}
+
+	golden := filepath.Join("testdata", "pay_schedule.golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(result), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if result != string(want) {
+		t.Errorf("pay schedule differs from %s (run with -update to accept):\n%s", golden, result)
+	}
+}
+
 func TestMortgagePaySchedule(t *testing.T) {
 	tests := []struct {
 		expr     string
@@ -206,6 +252,34 @@ func TestInvestmentGrowth(t *testing.T) {
 	}
 }
 
+func TestEvalFinanceResultValue(t *testing.T) {
+	tests := []struct {
+		expr string
+		min  float64
+		max  float64
+	}{
+		{"loan $250000 at 6.5% for 30 years", 1580, 1581},       // monthly payment
+		{"mortgage $400000 at 7% for 30 years", 2661, 2662},     // monthly payment
+		{"simple interest $1000 at 5% for 3 years", 1150, 1150}, // total
+		{"compound interest $5000 at 7% for 5 years", 7012, 7013},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalFinanceResult(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalFinanceResult(%q) error: %v", tt.expr, err)
+			}
+			if !result.HasValue || !result.IsCurrency {
+				t.Fatalf("EvalFinanceResult(%q) has no currency value", tt.expr)
+			}
+			if result.Value < tt.min || result.Value > tt.max {
+				t.Errorf("EvalFinanceResult(%q) value = %v, want in [%v, %v]", tt.expr, result.Value, tt.min, tt.max)
+			}
+		})
+	}
+}
+
 func TestIsFinanceExpression(t *testing.T) {
 	tests := []struct {
 		expr     string
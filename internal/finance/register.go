@@ -0,0 +1,12 @@
+package finance
+
+import "smartcalc/internal/registry"
+
+func init() {
+	registry.Register(registry.Evaluator{
+		Name:     "finance",
+		Priority: registry.PriorityFinance,
+		Detect:   IsFinanceExpression,
+		Eval:     EvalFinanceResult,
+	})
+}
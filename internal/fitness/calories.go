@@ -0,0 +1,120 @@
+package fitness
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/units"
+	"smartcalc/internal/utils"
+)
+
+// metValues are the metabolic equivalents of common activities, from the
+// Compendium of Physical Activities: the energy spent relative to sitting
+// still, 1 kcal per kg per hour
+var metValues = map[string]float64{
+	"walking":       3.5,
+	"hiking":        6.0,
+	"jogging":       7.0,
+	"running":       9.8,
+	"cycling":       7.5,
+	"swimming":      6.0,
+	"rowing":        7.0,
+	"yoga":          2.5,
+	"weightlifting": 5.0,
+	"dancing":       5.0,
+	"jumping rope":  12.3,
+}
+
+// activityAliases map other names of an activity to the one of metValues
+var activityAliases = map[string]string{
+	"walk": "walking", "hike": "hiking", "jog": "jogging", "run": "running",
+	"biking": "cycling", "bike": "cycling", "swim": "swimming", "row": "rowing",
+	"weights": "weightlifting", "weight lifting": "weightlifting", "strength training": "weightlifting",
+	"dance": "dancing", "jump rope": "jumping rope", "skipping": "jumping rope",
+}
+
+// caloriesPattern matches "calories 30 min running 75kg"; the duration,
+// activity and weight may come in any order
+var caloriesPattern = regexp.MustCompile(`^(?:calories|kcal)\s+(?:burned\s+|burnt\s+)?(?:for\s+|of\s+)?(\d.*|[a-z].*\d.*)$`)
+
+// Parts of a calorie estimate
+var (
+	calorieWeightPattern   = regexp.MustCompile(`\b(\d+(?:\.\d+)?)\s*(kg|kgs|kilograms?|lbs?|pounds?)\b`)
+	calorieDurationPattern = regexp.MustCompile(`\b\d+(?::\d{2}){1,2}\b|(?:\b\d+(?:\.\d+)?\s*(?:hours?|hrs?|h|minutes?|mins?|min)\b\s*)+`)
+	calorieFillerPattern   = regexp.MustCompile(`\b(?:of|at|for|and|weighing|while)\b|,`)
+)
+
+// handleCalories handles "calories 30 min running 75kg"
+func handleCalories(expr string) (utils.Result, bool, error) {
+	m := caloriesPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return utils.Result{}, false, nil
+	}
+	r, err := evalCalories(m[1])
+	return r, true, err
+}
+
+// evalCalories estimates the calories burned as MET × kg × hours, shown as a
+// breakdown of the activity, its MET value, the duration and the weight
+func evalCalories(s string) (utils.Result, error) {
+	var kg float64
+	if m := calorieWeightPattern.FindStringSubmatch(s); m != nil {
+		value, _ := strconv.ParseFloat(m[1], 64)
+		unit := m[2]
+		if unit == "kgs" {
+			unit = "kg"
+		}
+		kg, _ = units.WeightInKilograms(value, unit)
+		s = strings.Replace(s, m[0], " ", 1)
+	} else {
+		return utils.Result{}, fmt.Errorf("missing weight, such as 75 kg or 165 lb")
+	}
+
+	var secs float64
+	if d := calorieDurationPattern.FindString(s); d != "" {
+		var err error
+		if secs, err = parseDuration(strings.TrimSpace(d)); err != nil {
+			return utils.Result{}, err
+		}
+		s = strings.Replace(s, d, " ", 1)
+	} else {
+		return utils.Result{}, fmt.Errorf("missing duration, such as 30 min or 1:15:00")
+	}
+
+	name := strings.Join(strings.Fields(calorieFillerPattern.ReplaceAllString(s, " ")), " ")
+	if alias, ok := activityAliases[name]; ok {
+		name = alias
+	}
+	met, ok := metValues[name]
+	if !ok {
+		if name == "" {
+			return utils.Result{}, fmt.Errorf("missing activity, such as running or cycling")
+		}
+		return utils.Result{}, fmt.Errorf("unknown activity %q", name)
+	}
+	if kg <= 0 || secs <= 0 {
+		return utils.Result{}, fmt.Errorf("weight and duration must be more than zero")
+	}
+
+	kcal := met * kg * secs / 3600
+	text := fmt.Sprintf("\n> Calories: %s kcal\n> Activity: %s (MET %g)\n> Duration: %s\n> Weight: %s kg / %s lb",
+		utils.FormatResult(false, math.Round(kcal)), name, met, durationText(secs),
+		utils.FormatDecimal(kg, 1), utils.FormatDecimal(kg/0.453592, 1))
+	return utils.ValueResult(text, kcal, false), nil
+}
+
+// durationText renders a duration in hours and minutes: "1 h 15 min", "30 min"
+func durationText(secs float64) string {
+	mins := int64(math.Round(secs / 60))
+	switch {
+	case mins < 60:
+		return fmt.Sprintf("%d min", mins)
+	case mins%60 == 0:
+		return fmt.Sprintf("%d h", mins/60)
+	default:
+		return fmt.Sprintf("%d h %d min", mins/60, mins%60)
+	}
+}
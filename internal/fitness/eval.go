@@ -0,0 +1,308 @@
+package fitness
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/units"
+	"smartcalc/internal/utils"
+)
+
+// Meters in a kilometer and in a mile, the two distances a pace is given per
+const (
+	kmMeters   = 1000.0
+	mileMeters = 1609.344
+)
+
+// raceDistances names the standard race distances, in meters. "5k" and "10k"
+// are read as kilometers like any other distance.
+var raceDistances = map[string]float64{
+	"half": 21097.5, "half marathon": 21097.5, "marathon": 42195,
+}
+
+// Parts of the pace expressions. A distance is a race name or a number with a
+// length unit; a duration is "52:30", "1:45:00" or "1 h 45 min"; a pace is a
+// "mm:ss" time per kilometer or mile and a speed is in km/h or mph.
+const (
+	distancePart = `(half\s+marathon|half|marathon|\d+(?:\.\d+)?\s*(?:kilometers?|kilometres?|km|k|meters?|metres?|m|miles?|mi)\b)`
+	durationPart = `(\d+(?::\d{1,2}){1,2}(?:\.\d+)?|(?:\d+(?:\.\d+)?\s*(?:hours?|hrs?|h|minutes?|mins?|seconds?|secs?|s)\b\s*)+)`
+	pacePart     = `(\d+:\d{2}(?:\.\d+)?)\s*(?:min\s*)?(?:/\s*|per\s+)(kilometers?|kilometres?|km|k|miles?|mi)\b`
+	speedPart    = `(\d+(?:\.\d+)?)\s*(km/h|kmh|kph|mph)`
+)
+
+// splitPattern matches the pace of a run or ride: "pace for 10 km in 52:30"
+var splitPattern = regexp.MustCompile(`^(?:pace\s+(?:for\s+|of\s+)?)?` + distancePart + `\s+in\s+` + durationPart + `$`)
+
+// finishPattern matches the finish time at a pace or speed:
+// "marathon at 5:20/km", "time for 100 km at 28 km/h". Without "time for", a
+// distance at a speed is a trip, which travel answers first.
+var finishPattern = regexp.MustCompile(`^(?:(?:finish\s+)?time\s+(?:for\s+)?)?` + distancePart + `\s+at\s+(?:` + pacePart + `|` + speedPart + `)$`)
+
+// convertPattern matches a pace or speed conversion: "convert 8:00/mile to /km"
+var convertPattern = regexp.MustCompile(`^(?:convert\s+)?(?:` + pacePart + `|` + speedPart + `)\s+(?:to|in|as)\s+(?:min\s*)?(?:/\s*|per\s+)?(km/h|kmh|kph|mph|kilometers?|kilometres?|km|k|miles?|mi)$`)
+
+// durationUnitPattern matches one part of a duration such as "1 h 45 min"
+var durationUnitPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*([a-z]+)`)
+
+// distanceUnitPattern splits a distance into its number and unit
+var distanceUnitPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([a-z]+)$`)
+
+// Handler defines the interface for fitness expression handlers. ok is false
+// for expressions of another kind; err reports a malformed one of its kind.
+type Handler interface {
+	Handle(expr string) (result utils.Result, ok bool, err error)
+}
+
+// HandlerFunc is an adapter to allow ordinary functions to be used as Handlers.
+type HandlerFunc func(expr string) (utils.Result, bool, error)
+
+// Handle calls the underlying function.
+func (f HandlerFunc) Handle(expr string) (utils.Result, bool, error) {
+	return f(expr)
+}
+
+// handlerChain is the ordered list of handlers for fitness expressions.
+var handlerChain = []Handler{
+	HandlerFunc(handleSplit),
+	HandlerFunc(handleFinish),
+	HandlerFunc(handleConvert),
+	HandlerFunc(handleCalories),
+}
+
+// fitnessPatterns recognize the expressions of the handler chain
+var fitnessPatterns = []*regexp.Regexp{splitPattern, finishPattern, convertPattern, caloriesPattern}
+
+// IsFitnessExpression checks if an expression is a pace or calorie
+// calculation. Paces need a distance, a "/km" or "/mile" pace or a speed, so
+// clock times such as "10:30 to 11:45" are left to dates and times.
+func IsFitnessExpression(expr string) bool {
+	expr = utils.NormalizeExpr(expr)
+	for _, p := range fitnessPatterns {
+		if p.MatchString(expr) {
+			return true
+		}
+	}
+	return false
+}
+
+// EvalFitness evaluates a pace, finish time, pace conversion or calorie
+// estimate
+func EvalFitness(expr string) (utils.Result, error) {
+	expr = utils.NormalizeExpr(expr)
+	for _, h := range handlerChain {
+		if result, ok, err := h.Handle(expr); ok {
+			return result, err
+		}
+	}
+	return utils.Result{}, fmt.Errorf("unable to evaluate fitness expression: %s", expr)
+}
+
+// handleSplit handles "pace for 10 km in 52:30"
+func handleSplit(expr string) (utils.Result, bool, error) {
+	m := splitPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return utils.Result{}, false, nil
+	}
+	r, err := evalSplit(m[1], m[2])
+	return r, true, err
+}
+
+// handleFinish handles "marathon at 5:20/km"
+func handleFinish(expr string) (utils.Result, bool, error) {
+	m := finishPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return utils.Result{}, false, nil
+	}
+	r, err := evalFinish(m[1], m[2], m[3], m[4], m[5])
+	return r, true, err
+}
+
+// handleConvert handles "convert 8:00/mile to /km"
+func handleConvert(expr string) (utils.Result, bool, error) {
+	m := convertPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return utils.Result{}, false, nil
+	}
+	r, err := evalConvert(m[1], m[2], m[3], m[4], m[5])
+	return r, true, err
+}
+
+// evalSplit computes the pace per kilometer and mile of a distance covered in
+// a time, with the matching speed
+func evalSplit(distance, duration string) (utils.Result, error) {
+	meters, err := parseDistance(distance)
+	if err != nil {
+		return utils.Result{}, err
+	}
+	secs, err := parseDuration(duration)
+	if err != nil {
+		return utils.Result{}, err
+	}
+	if meters <= 0 || secs <= 0 {
+		return utils.Result{}, fmt.Errorf("distance and time must be more than zero")
+	}
+	perMeter := secs / meters
+	kmh := meters / kmMeters / (secs / 3600)
+	text := fmt.Sprintf("%s min/km, %s min/mile (%s km/h, %s mph)",
+		formatTime(perMeter*kmMeters), formatTime(perMeter*mileMeters),
+		utils.FormatDecimal(kmh, 1), utils.FormatDecimal(kmh*kmMeters/mileMeters, 1))
+	return utils.TextResult(text), nil
+}
+
+// evalFinish computes the time to cover a distance at a pace or speed. The
+// distance is shown in the unit of the pace.
+func evalFinish(distance, pace, paceUnit, speed, speedUnit string) (utils.Result, error) {
+	meters, err := parseDistance(distance)
+	if err != nil {
+		return utils.Result{}, err
+	}
+	perMeter, err := parsePaceOrSpeed(pace, paceUnit, speed, speedUnit)
+	if err != nil {
+		return utils.Result{}, err
+	}
+	if meters <= 0 {
+		return utils.Result{}, fmt.Errorf("distance must be more than zero")
+	}
+
+	unitMeters, unitName := kmMeters, "km"
+	if isMiles(paceUnit) || speedUnit == "mph" {
+		unitMeters, unitName = mileMeters, "mi"
+	}
+	rate := fmt.Sprintf("%s min/%s", formatTime(perMeter*unitMeters), paceUnitName(unitMeters))
+	if speed != "" {
+		rate = speed + " " + speedUnitName(speedUnit)
+	}
+	text := fmt.Sprintf("%s (%s %s at %s)", formatTime(perMeter*meters), utils.FormatDecimal(meters/unitMeters, 4), unitName, rate)
+	return utils.TextResult(text), nil
+}
+
+// evalConvert converts a pace or speed to a pace per kilometer or mile, or to
+// a speed
+func evalConvert(pace, paceUnit, speed, speedUnit, target string) (utils.Result, error) {
+	perMeter, err := parsePaceOrSpeed(pace, paceUnit, speed, speedUnit)
+	if err != nil {
+		return utils.Result{}, err
+	}
+	switch target {
+	case "km/h", "kmh", "kph":
+		return utils.TextResult(utils.FormatDecimal(3600/perMeter/kmMeters, 2) + " km/h"), nil
+	case "mph":
+		return utils.TextResult(utils.FormatDecimal(3600/perMeter/mileMeters, 2) + " mph"), nil
+	}
+	unitMeters := kmMeters
+	if isMiles(target) {
+		unitMeters = mileMeters
+	}
+	return utils.TextResult(fmt.Sprintf("%s min/%s", formatTime(perMeter*unitMeters), paceUnitName(unitMeters))), nil
+}
+
+// parseDistance converts a race name or a distance such as "10 km", "5k" or
+// "13.1 mi" to meters
+func parseDistance(s string) (float64, error) {
+	s = strings.Join(strings.Fields(s), " ")
+	if meters, ok := raceDistances[s]; ok {
+		return meters, nil
+	}
+	m := distanceUnitPattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("invalid distance %q", s)
+	}
+	meters, _, err := units.ParseDistance(m[1], m[2])
+	return meters, err
+}
+
+// parseDuration converts "52:30" (minutes and seconds), "1:45:00" (hours,
+// minutes and seconds) or "1 h 45 min" to seconds
+func parseDuration(s string) (float64, error) {
+	if strings.Contains(s, ":") {
+		return parseClock(s)
+	}
+	total := 0.0
+	for _, m := range durationUnitPattern.FindAllStringSubmatch(s, -1) {
+		value, _ := strconv.ParseFloat(m[1], 64)
+		switch {
+		case strings.HasPrefix(m[2], "h"):
+			total += value * 3600
+		case strings.HasPrefix(m[2], "m"):
+			total += value * 60
+		default:
+			total += value
+		}
+	}
+	return total, nil
+}
+
+// parseClock converts "mm:ss" or "h:mm:ss" to seconds. Two parts are always
+// minutes and seconds, as race times are written.
+func parseClock(s string) (float64, error) {
+	parts := strings.Split(s, ":")
+	total := 0.0
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || (i > 0 && value >= 60) {
+			return 0, fmt.Errorf("invalid time %s", s)
+		}
+		total = total*60 + value
+	}
+	return total, nil
+}
+
+// parsePaceOrSpeed converts a "5:20" pace per kilometer or mile, or a speed in
+// km/h or mph, to seconds per meter
+func parsePaceOrSpeed(pace, paceUnit, speed, speedUnit string) (float64, error) {
+	if pace != "" {
+		secs, err := parseClock(pace)
+		if err != nil {
+			return 0, err
+		}
+		if secs <= 0 {
+			return 0, fmt.Errorf("pace must be more than zero")
+		}
+		if isMiles(paceUnit) {
+			return secs / mileMeters, nil
+		}
+		return secs / kmMeters, nil
+	}
+	value, _ := strconv.ParseFloat(speed, 64)
+	if value <= 0 {
+		return 0, fmt.Errorf("speed must be more than zero")
+	}
+	if speedUnit == "mph" {
+		return 3600 / (value * mileMeters), nil
+	}
+	return 3600 / (value * kmMeters), nil
+}
+
+// isMiles checks if a pace unit is a mile
+func isMiles(unit string) bool {
+	return strings.HasPrefix(unit, "mi")
+}
+
+// paceUnitName names the distance of a pace: "km" or "mile"
+func paceUnitName(unitMeters float64) string {
+	if unitMeters == mileMeters {
+		return "mile"
+	}
+	return "km"
+}
+
+// speedUnitName writes a speed unit the way it is shown: "km/h" or "mph"
+func speedUnitName(unit string) string {
+	if unit == "mph" {
+		return "mph"
+	}
+	return "km/h"
+}
+
+// formatTime renders seconds as "m:ss", or "h:mm:ss" from an hour up
+func formatTime(secs float64) string {
+	total := int64(math.Round(secs))
+	h, m, s := total/3600, total%3600/60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
@@ -0,0 +1,138 @@
+package fitness
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIsFitnessExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"pace for 10 km in 52:30", true},
+		{"10 km in 52:30", true},
+		{"5k in 25:00", true},
+		{"half marathon in 1:45:00", true},
+		{"13.1 mi in 1 h 45 min", true},
+		{"marathon at 5:20/km", true},
+		{"100 km at 28 km/h", true},
+		{"convert 8:00/mile to /km", true},
+		{"5:00 per km to mph", true},
+		{"25 km/h to /km", true},
+		{"calories 30 min running 75kg", true},
+		{"kcal 45:00 swimming 60 kg", true},
+
+		// Clock times without a distance or pace unit belong to dates and times
+		{"10:30 to 11:45", false},
+		{"5:20 pm", false},
+		{"10:30 in tokyo", false},
+		{"meet at 5:20", false},
+		{"5:20/km", false},
+		// Plain unit conversions
+		{"10 km in miles", false},
+		{"10 km to mi", false},
+		// A variable named calories is arithmetic
+		{"calories * 2", false},
+		{"calories", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsFitnessExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsFitnessExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalFitness(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"pace for 10 km in 52:30", "5:15 min/km, 8:27 min/mile (11.4 km/h, 7.1 mph)"},
+		{"5K in 25:00", "5:00 min/km, 8:03 min/mile (12 km/h, 7.5 mph)"},
+		{"13.1 mi in 1 h 45 min", "4:59 min/km, 8:01 min/mile (12 km/h, 7.5 mph)"},
+		{"40 km in 1:15:00", "1:53 min/km, 3:01 min/mile (32 km/h, 19.9 mph)"},
+		{"marathon at 5:20/km", "3:45:02 (42.195 km at 5:20 min/km)"},
+		{"half marathon at 5:00 min/km", "1:45:29 (21.0975 km at 5:00 min/km)"},
+		{"marathon at 8:00/mile", "3:29:45 (26.2188 mi at 8:00 min/mile)"},
+		{"time for 10k at 4:30 per km", "45:00 (10 km at 4:30 min/km)"},
+		{"100 km at 28 km/h", "3:34:17 (100 km at 28 km/h)"},
+		{"convert 8:00/mile to /km", "4:58 min/km"},
+		{"5:00/km to min/mile", "8:03 min/mile"},
+		{"5:00/km to mph", "7.46 mph"},
+		{"25 km/h to /km", "2:24 min/km"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalFitness(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalFitness(%q) error: %v", tt.expr, err)
+			}
+			if result.Text != tt.expected {
+				t.Errorf("EvalFitness(%q) = %q, want %q", tt.expr, result.Text, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalFitnessErrors(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"5:75/km to /mile", "invalid time 5:75"},
+		{"10 km in 0:00", "distance and time must be more than zero"},
+		{"marathon at 0 km/h", "speed must be more than zero"},
+		{"0 km at 5:00/km", "distance must be more than zero"},
+		{"calories 30 min 75 kg", "missing activity, such as running or cycling"},
+		{"calories 30 min flying 75 kg", `unknown activity "flying"`},
+		{"calories running 75kg", "missing duration, such as 30 min or 1:15:00"},
+		{"calories 30 min running", "missing weight, such as 75 kg or 165 lb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := EvalFitness(tt.expr)
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("EvalFitness(%q) error = %v, want %q", tt.expr, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalCalories(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+		value    float64
+	}{
+		{"calories 30 min running 75kg",
+			"\n> Calories: 368 kcal\n> Activity: running (MET 9.8)\n> Duration: 30 min\n> Weight: 75 kg / 165.3 lb", 367.5},
+		{"calories burned cycling for 1 h 15 min at 165 lb",
+			"\n> Calories: 702 kcal\n> Activity: cycling (MET 7.5)\n> Duration: 1 h 15 min\n> Weight: 74.8 kg / 165 lb", 701.6},
+		// Colon times are minutes and seconds, or hours, minutes and seconds
+		{"kcal 45:00 swimming 60 kg",
+			"\n> Calories: 270 kcal\n> Activity: swimming (MET 6)\n> Duration: 45 min\n> Weight: 60 kg / 132.3 lb", 270},
+		{"calories 2:00:00 of weight lifting 80 kg",
+			"\n> Calories: 800 kcal\n> Activity: weightlifting (MET 5)\n> Duration: 2 h\n> Weight: 80 kg / 176.4 lb", 800},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalFitness(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalFitness(%q) error: %v", tt.expr, err)
+			}
+			if result.Text != tt.expected {
+				t.Errorf("EvalFitness(%q) = %q, want %q", tt.expr, result.Text, tt.expected)
+			}
+			if math.Abs(result.Value-tt.value) > 0.1 {
+				t.Errorf("EvalFitness(%q) value = %v, want %v", tt.expr, result.Value, tt.value)
+			}
+		})
+	}
+}
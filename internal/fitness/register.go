@@ -0,0 +1,15 @@
+package fitness
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// Malformed times such as "5:75/km" are reported instead of left to
+	// dates and unit conversions. Calorie estimates are shown as a breakdown.
+	registry.Register(registry.Evaluator{
+		Name:     "fitness",
+		Priority: registry.PriorityFitness,
+		Traits:   registry.ReportsErrors | registry.MultiLine,
+		Detect:   IsFitnessExpression,
+		Eval:     EvalFitness,
+	})
+}
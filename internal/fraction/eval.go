@@ -0,0 +1,226 @@
+package fraction
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"sync"
+
+	"smartcalc/internal/utils"
+)
+
+var (
+	modeMu  sync.RWMutex
+	enabled bool // show integer-ratio arithmetic as exact fractions
+)
+
+// SetEnabled turns fractions mode on or off. In fractions mode "1/3 + 1/6" is
+// computed exactly and shown as "1/2 (0.5)"; conversions such as
+// "0.375 as fraction" work either way.
+func SetEnabled(on bool) {
+	modeMu.Lock()
+	defer modeMu.Unlock()
+	enabled = on
+}
+
+// Enabled reports whether fractions mode is on
+func Enabled() bool {
+	modeMu.RLock()
+	defer modeMu.RUnlock()
+	return enabled
+}
+
+// ratioPattern matches arithmetic on integers only, with at least one division
+var ratioPattern = regexp.MustCompile(`^[\d\s+\-*/()]*/[\d\s+\-*/()]*$`)
+
+// dateLikePattern matches "6/7/2024": three numbers joined by slashes are a
+// date, not two divisions
+var dateLikePattern = regexp.MustCompile(`\d\s*/\s*\d+\s*/\s*\d`)
+
+// conversionPattern matches "0.375 as fraction" and "2.5 as mixed number"
+var conversionPattern = regexp.MustCompile(`(?i)^(.+?)\s+(?:as|to|in)\s+(fraction|mixed\s+number)$`)
+
+// conversionOperandPattern matches the operand of a conversion: a decimal or
+// integer-ratio arithmetic
+var conversionOperandPattern = regexp.MustCompile(`^[\d\s.+\-*/()]+$`)
+
+// IsFractionExpression checks if an expression is a fraction conversion or, in
+// fractions mode, arithmetic on integer ratios
+func IsFractionExpression(expr string) bool {
+	expr = strings.TrimSpace(expr)
+	if m := conversionPattern.FindStringSubmatch(expr); m != nil {
+		return conversionOperandPattern.MatchString(m[1]) && strings.ContainsAny(m[1], "0123456789")
+	}
+	return Enabled() && isRatioArithmetic(expr)
+}
+
+// isRatioArithmetic checks if expr uses only integers, + - * / and
+// parentheses, divides at least once and does not look like a date
+func isRatioArithmetic(expr string) bool {
+	return ratioPattern.MatchString(expr) && strings.ContainsAny(expr, "0123456789") &&
+		!dateLikePattern.MatchString(expr)
+}
+
+// EvalFraction evaluates a fraction expression. The value of the result is
+// the decimal value of the fraction.
+func EvalFraction(expr string) (utils.Result, error) {
+	expr = strings.TrimSpace(expr)
+	if m := conversionPattern.FindStringSubmatch(expr); m != nil {
+		r, err := evalRational(m[1])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		v, _ := r.Float64()
+		if strings.HasPrefix(strings.ToLower(m[2]), "mixed") {
+			return utils.ValueResult(formatMixed(r), v, false), nil
+		}
+		return utils.ValueResult(formatFraction(r), v, false), nil
+	}
+	if !isRatioArithmetic(expr) {
+		return utils.Result{}, fmt.Errorf("not a fraction expression")
+	}
+	r, err := evalRational(expr)
+	if err != nil {
+		return utils.Result{}, err
+	}
+	v, _ := r.Float64()
+	if r.IsInt() {
+		return utils.ValueResult(r.Num().String(), v, false), nil
+	}
+	return utils.ValueResult(fmt.Sprintf("%s (%s)", formatFraction(r), utils.FormatResult(false, v)), v, false), nil
+}
+
+// formatFraction shows a reduced fraction such as "3/8"; whole numbers have no
+// denominator
+func formatFraction(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	return r.RatString()
+}
+
+// formatMixed shows a fraction as a whole part and a proper fraction:
+// 5/2 is "2 1/2" and -7/4 is "-1 3/4"
+func formatMixed(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	whole, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	rem.Abs(rem)
+	if whole.Sign() == 0 {
+		return r.RatString()
+	}
+	return fmt.Sprintf("%s %s/%s", whole, rem, r.Denom())
+}
+
+// evalRational evaluates + - * / arithmetic exactly
+func evalRational(expr string) (*big.Rat, error) {
+	p := &parser{input: expr}
+	r, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != 0 {
+		return nil, fmt.Errorf("unexpected %q", p.input[p.pos:])
+	}
+	return r, nil
+}
+
+// parser is a recursive descent parser. Spaces separate tokens, so "1 2" is
+// an error rather than 12.
+type parser struct {
+	input string
+	pos   int
+}
+
+// peek returns the next character after any spaces, or 0 at the end
+func (p *parser) peek() byte {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+// parseSum parses terms joined by + and -
+func (p *parser) parseSum() (*big.Rat, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '+' || op == '-'; op = p.peek() {
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		if op == '+' {
+			left.Add(left, right)
+		} else {
+			left.Sub(left, right)
+		}
+	}
+	return left, nil
+}
+
+// parseProduct parses factors joined by * and /
+func (p *parser) parseProduct() (*big.Rat, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '*' || op == '/'; op = p.peek() {
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		if op == '*' {
+			left.Mul(left, right)
+			continue
+		}
+		if right.Sign() == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		left.Quo(left, right)
+	}
+	return left, nil
+}
+
+// parseFactor parses a number, a negated factor or a parenthesized sum
+func (p *parser) parseFactor() (*big.Rat, error) {
+	switch c := p.peek(); {
+	case c == '-':
+		p.pos++
+		f, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return f.Neg(f), nil
+	case c == '(':
+		p.pos++
+		r, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return r, nil
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] >= '0' && p.input[p.pos] <= '9' || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		r, ok := new(big.Rat).SetString(p.input[start:p.pos])
+		if !ok {
+			return nil, fmt.Errorf("invalid number %q", p.input[start:p.pos])
+		}
+		return r, nil
+	}
+	return nil, fmt.Errorf("expected a number at %q", p.input[p.pos:])
+}
@@ -0,0 +1,95 @@
+package fraction
+
+import "testing"
+
+func TestIsFractionExpression(t *testing.T) {
+	SetEnabled(true)
+	defer SetEnabled(false)
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"1/3 + 1/6", true},
+		{"(1/2 + 1/3) * 6/5", true},
+		{"-3/4", true},
+		{"0.375 as fraction", true},
+		{"2.5 as mixed number", true},
+		{"7/4 in mixed number", true},
+
+		// Dates, networks and other arithmetic are not fractions
+		{"6/7/2024", false},
+		{"6/7 / 2024", false},
+		{"10.0.0.0/24", false},
+		{"192.168.1.0/24", false},
+		{"0.5 / 2", false},
+		{"1 + 2", false},
+		{"$10 / 4", false},
+		{"x / 2", false},
+		{"as fraction", false},
+		{"hello as fraction", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsFractionExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsFractionExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFractionsModeOff(t *testing.T) {
+	if IsFractionExpression("1/3 + 1/6") {
+		t.Error("ratio arithmetic should be left to plain arithmetic when fractions mode is off")
+	}
+	if !IsFractionExpression("0.375 as fraction") {
+		t.Error("conversions should work when fractions mode is off")
+	}
+}
+
+func TestEvalFraction(t *testing.T) {
+	tests := []struct {
+		expr  string
+		text  string
+		value float64
+	}{
+		{"1/3 + 1/6", "1/2 (0.5)", 0.5},
+		{"1/3", "1/3 (0.3333333333)", 1.0 / 3},
+		{"2/3 - 5/6", "-1/6 (-0.1666666667)", -1.0 / 6},
+		{"(1/2 + 1/3) * 6/5", "1", 1},
+		{"10/4", "5/2 (2.5)", 2.5},
+		{"10/2", "5", 5},
+		{"1/10 + 2/10", "3/10 (0.3)", 0.3},
+		{"0.375 as fraction", "3/8", 0.375},
+		{"0.1 + 0.2 as fraction", "3/10", 0.3},
+		{"4 as fraction", "4", 4},
+		{"2.5 as mixed number", "2 1/2", 2.5},
+		{"7/4 as mixed number", "1 3/4", 1.75},
+		{"-2.5 as mixed number", "-2 1/2", -2.5},
+		{"0.25 as mixed number", "1/4", 0.25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			r, err := EvalFraction(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalFraction(%q) error: %v", tt.expr, err)
+			}
+			if r.Text != tt.text {
+				t.Errorf("EvalFraction(%q) = %q, want %q", tt.expr, r.Text, tt.text)
+			}
+			if !r.HasValue || r.Value != tt.value {
+				t.Errorf("EvalFraction(%q) value = %v, want %v", tt.expr, r.Value, tt.value)
+			}
+		})
+	}
+}
+
+func TestEvalFractionErrors(t *testing.T) {
+	for _, expr := range []string{"1/0", "1/(2 - 2)", "1 2/3", "1/2 +", "(1/2", "1.2.3 as fraction"} {
+		if r, err := EvalFraction(expr); err == nil {
+			t.Errorf("EvalFraction(%q) = %q, want an error", expr, r.Text)
+		}
+	}
+}
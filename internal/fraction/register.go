@@ -0,0 +1,12 @@
+package fraction
+
+import "smartcalc/internal/registry"
+
+func init() {
+	registry.Register(registry.Evaluator{
+		Name:     "fraction",
+		Priority: registry.PriorityFraction,
+		Detect:   IsFractionExpression,
+		Eval:     EvalFraction,
+	})
+}
@@ -0,0 +1,211 @@
+package health
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/units"
+	"smartcalc/internal/utils"
+)
+
+// healthPattern matches "bmi 82 kg 1.78 m", "bmr male 35 82 kg 178 cm" and
+// "target heart rate age 40". The keyword must be followed by a measurement,
+// a sex or an age, so a variable named bmi still works in arithmetic.
+var healthPattern = regexp.MustCompile(`(?i)^(bmi|bmr|target\s+heart\s+rate)\s+(?:(?:of|for)\s+)?((?:\d|male\b|female\b|man\b|woman\b|age\b).*)$`)
+
+// feetInchesPattern matches a height written as 5'10" or 5′10″
+var feetInchesPattern = regexp.MustCompile(`(\d+)\s*['′]\s*(\d+(?:\.\d+)?)\s*(?:"|″|'')?`)
+
+// tokenPattern splits the measurements of an expression into numbers and words
+var tokenPattern = regexp.MustCompile(`\d+(?:\.\d+)?|[a-z]+|\S`)
+
+// ageUnits name the unit of an age: "35 years", "35 y", "35 yo"
+var ageUnits = map[string]bool{"y": true, "yo": true, "yr": true, "yrs": true, "year": true, "years": true}
+
+// fillerWords may appear between the measurements: "age 35 years old, 82 kg and 1.78 m tall"
+var fillerWords = map[string]bool{
+	"age": true, "aged": true, "old": true, "and": true, "tall": true, "height": true,
+	"weight": true, "weighing": true, "at": true, ",": true,
+}
+
+// profile holds the measurements of a body metric expression in metric units
+type profile struct {
+	weightKg, heightM, age float64
+	sex                    string // "male", "female" or ""
+	// The parts as typed, to tell whether a weight or height was given
+	haveWeight, haveHeight, haveAge bool
+}
+
+// IsHealthExpression checks if an expression is a BMI, BMR or target heart
+// rate calculation
+func IsHealthExpression(expr string) bool {
+	return healthPattern.MatchString(strings.TrimSpace(expr))
+}
+
+// EvalHealth evaluates a body metric. Weights and heights are shown in both
+// metric and imperial units, so a mistyped input is easy to spot.
+func EvalHealth(expr string) (utils.Result, error) {
+	m := healthPattern.FindStringSubmatch(strings.TrimSpace(expr))
+	if m == nil {
+		return utils.Result{}, fmt.Errorf("invalid body metric expression")
+	}
+	p, err := parseProfile(m[2])
+	if err != nil {
+		return utils.Result{}, err
+	}
+	switch strings.Join(strings.Fields(strings.ToLower(m[1])), " ") {
+	case "bmi":
+		return evalBMI(p)
+	case "bmr":
+		return evalBMR(p)
+	default:
+		return evalTargetHeartRate(p)
+	}
+}
+
+// parseProfile reads the weight, height, age and sex of an expression, in
+// any order: "82 kg 1.78 m", "180 lb 5 ft 10 in", "male 35 82 kg 5'10""
+func parseProfile(s string) (profile, error) {
+	s = feetInchesPattern.ReplaceAllString(strings.ToLower(s), "$1 ft $2 in")
+	tokens := tokenPattern.FindAllString(s, -1)
+
+	var p profile
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		value, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			switch {
+			case tok == "male" || tok == "man":
+				p.sex = "male"
+			case tok == "female" || tok == "woman":
+				p.sex = "female"
+			case !fillerWords[tok]:
+				return p, fmt.Errorf("unexpected %q", tok)
+			}
+			continue
+		}
+
+		unit := ""
+		if i+1 < len(tokens) {
+			unit = tokens[i+1]
+		}
+		if kg, ok := units.WeightInKilograms(value, unit); ok {
+			p.weightKg += kg // "11 st 4 lb" adds up
+			p.haveWeight = true
+			i++
+			continue
+		}
+		if m, ok := units.LengthInMeters(value, unit); ok {
+			p.heightM += m // "5 ft 10 in" adds up
+			p.haveHeight = true
+			i++
+			continue
+		}
+		if ageUnits[unit] {
+			i++
+		}
+		if p.haveAge {
+			return p, fmt.Errorf("%s has no unit", tok)
+		}
+		p.age, p.haveAge = value, true
+	}
+	return p, nil
+}
+
+// evalBMI computes the body mass index, kg/m², with its WHO category
+func evalBMI(p profile) (utils.Result, error) {
+	if err := p.require(true, true, false, false); err != nil {
+		return utils.Result{}, err
+	}
+	bmi := p.weightKg / (p.heightM * p.heightM)
+	text := fmt.Sprintf("%s %s (%s, %s)", utils.FormatDecimal(bmi, 1), bmiCategory(bmi), p.weightText(), p.heightText())
+	return utils.ValueResult(text, bmi, false), nil
+}
+
+// bmiCategory returns the WHO category of a BMI
+func bmiCategory(bmi float64) string {
+	switch {
+	case bmi < 18.5:
+		return "underweight"
+	case bmi < 25:
+		return "normal"
+	case bmi < 30:
+		return "overweight"
+	default:
+		return "obese"
+	}
+}
+
+// evalBMR computes the basal metabolic rate with the Mifflin-St Jeor
+// equation: 10 × kg + 6.25 × cm − 5 × age, plus 5 for men or minus 161 for
+// women
+func evalBMR(p profile) (utils.Result, error) {
+	if err := p.require(true, true, true, true); err != nil {
+		return utils.Result{}, err
+	}
+	bmr := 10*p.weightKg + 6.25*p.heightM*100 - 5*p.age
+	if p.sex == "male" {
+		bmr += 5
+	} else {
+		bmr -= 161
+	}
+	text := fmt.Sprintf("%s kcal/day (%s, %s years, %s, %s)",
+		utils.FormatResult(false, math.Round(bmr)), p.sex, utils.FormatDecimal(p.age, 1), p.weightText(), p.heightText())
+	return utils.ValueResult(text, bmr, false), nil
+}
+
+// Target heart rate zone as fractions of the maximum heart rate
+const (
+	zoneLow  = 0.50
+	zoneHigh = 0.85
+)
+
+// evalTargetHeartRate computes the 50–85% zone of the maximum heart rate,
+// estimated as 220 minus the age
+func evalTargetHeartRate(p profile) (utils.Result, error) {
+	if err := p.require(false, false, true, false); err != nil {
+		return utils.Result{}, err
+	}
+	if p.age <= 0 || p.age >= 120 {
+		return utils.Result{}, fmt.Errorf("age must be between 0 and 120")
+	}
+	maxRate := 220 - p.age
+	low, high := math.Round(maxRate*zoneLow), math.Round(maxRate*zoneHigh)
+	text := fmt.Sprintf("%.0f–%.0f bpm (%.0f–%.0f%% of max %s bpm, age %s)",
+		low, high, zoneLow*100, zoneHigh*100, utils.FormatDecimal(maxRate, 1), utils.FormatDecimal(p.age, 1))
+	return utils.ValueResult(text, low, false), nil
+}
+
+// require reports the first missing measurement of a formula
+func (p profile) require(weight, height, age, sex bool) error {
+	switch {
+	case weight && !p.haveWeight:
+		return fmt.Errorf("missing weight, such as 82 kg or 180 lb")
+	case height && !p.haveHeight:
+		return fmt.Errorf("missing height, such as 1.78 m or 5 ft 10 in")
+	case age && !p.haveAge:
+		return fmt.Errorf("missing age, such as age 35")
+	case sex && p.sex == "":
+		return fmt.Errorf("missing sex: male or female")
+	case weight && p.weightKg <= 0, height && p.heightM <= 0:
+		return fmt.Errorf("weight and height must be more than zero")
+	}
+	return nil
+}
+
+// weightText shows the weight in kilograms and pounds: "82 kg / 180.8 lb"
+func (p profile) weightText() string {
+	lb := p.weightKg / 0.453592
+	return fmt.Sprintf("%s kg / %s lb", utils.FormatDecimal(p.weightKg, 1), utils.FormatDecimal(lb, 1))
+}
+
+// heightText shows the height in centimeters and in feet and inches:
+// "178 cm / 5 ft 10 in"
+func (p profile) heightText() string {
+	inches := math.Round(p.heightM / 0.0254)
+	feet := math.Floor(inches / 12)
+	return fmt.Sprintf("%s cm / %.0f ft %.0f in", utils.FormatDecimal(p.heightM*100, 1), feet, inches-feet*12)
+}
@@ -0,0 +1,93 @@
+package health
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestIsHealthExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"bmi 82 kg 1.78 m", true},
+		{"BMI of 180 lb 5'10\"", true},
+		{"bmr male 35 82 kg 178 cm", true},
+		{"target heart rate age 40", true},
+		{"target heart rate for 40", true},
+
+		// A variable named bmi is arithmetic
+		{"bmi * 2", false},
+		{"bmi", false},
+		{"82 kg to lb", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsHealthExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsHealthExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalHealth(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+		value    float64
+	}{
+		{"bmi 82 kg 1.78 m", "25.9 overweight (82 kg / 180.8 lb, 178 cm / 5 ft 10 in)", 25.88},
+		{"bmi 180 lb 5 ft 10 in", "25.8 overweight (81.6 kg / 180 lb, 177.8 cm / 5 ft 10 in)", 25.83},
+		{"bmi 180lbs 5'10\"", "25.8 overweight (81.6 kg / 180 lb, 177.8 cm / 5 ft 10 in)", 25.83},
+		{"bmi 11 st 4 lb 170 cm", "24.8 normal (71.7 kg / 158 lb, 170 cm / 5 ft 7 in)", 24.80},
+		{"bmi 50 kg 1.8 m", "15.4 underweight (50 kg / 110.2 lb, 180 cm / 5 ft 11 in)", 15.43},
+		{"bmi 110 kg 1.75 m", "35.9 obese (110 kg / 242.5 lb, 175 cm / 5 ft 9 in)", 35.92},
+		{"bmr male 35 82 kg 178 cm", "1,763 kcal/day (male, 35 years, 82 kg / 180.8 lb, 178 cm / 5 ft 10 in)", 1762.5},
+		{"bmr female age 30 60 kg 165 cm", "1,320 kcal/day (female, 30 years, 60 kg / 132.3 lb, 165 cm / 5 ft 5 in)", 1320.25},
+		{"bmr 132 lb 5 ft 5 in woman 30 years old", "1,320 kcal/day (female, 30 years, 59.9 kg / 132 lb, 165.1 cm / 5 ft 5 in)", 1319.62},
+		{"target heart rate age 40", "90–153 bpm (50–85% of max 180 bpm, age 40)", 90},
+		{"target heart rate for 25 years", "98–166 bpm (50–85% of max 195 bpm, age 25)", 98},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			r, err := EvalHealth(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalHealth(%q) error: %v", tt.expr, err)
+			}
+			if r.Text != tt.expected {
+				t.Errorf("EvalHealth(%q) = %q, want %q", tt.expr, r.Text, tt.expected)
+			}
+			if math.Abs(r.Value-tt.value) > 0.01 {
+				t.Errorf("EvalHealth(%q) value = %v, want %v", tt.expr, r.Value, tt.value)
+			}
+		})
+	}
+}
+
+func TestEvalHealthErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"bmi 82 kg", "missing height"},
+		{"bmi 1.78 m", "missing weight"},
+		{"bmr 35 82 kg 178 cm", "missing sex"},
+		{"bmr male 82 kg 178 cm", "missing age"},
+		{"bmi 82 kg 0 m", "more than zero"},
+		{"bmi 82 kg 1.78 m 35 40", "40 has no unit"},
+		{"bmi 82 kg 1.78 parsecs", `unexpected "parsecs"`},
+		{"target heart rate age 150", "between 0 and 120"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := EvalHealth(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("EvalHealth(%q) error = %v, want %q", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package health
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// Missing or unreadable measurements are reported instead of left to
+	// unit conversions and arithmetic
+	registry.Register(registry.Evaluator{
+		Name:     "health",
+		Priority: registry.PriorityHealth,
+		Traits:   registry.ReportsErrors,
+		Detect:   IsHealthExpression,
+		Eval:     EvalHealth,
+	})
+}
@@ -0,0 +1,12 @@
+package hourlycost
+
+import "smartcalc/internal/registry"
+
+func init() {
+	registry.Register(registry.Evaluator{
+		Name:     "hourlycost",
+		Priority: registry.PriorityHourlyCost,
+		Detect:   IsHourlyCostExpression,
+		Eval:     registry.TextEval(EvalHourlyCost),
+	})
+}
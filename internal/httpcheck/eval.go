@@ -0,0 +1,175 @@
+package httpcheck
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// requestTimeout bounds each request of a redirect chain
+	requestTimeout = 10 * time.Second
+	// maxRedirects is the longest redirect chain followed
+	maxRedirects = 10
+	// maxBodyBytes caps how much of a response body is read. Only headers are
+	// shown; the body is drained so the connection can be reused.
+	maxBodyBytes = 64 << 10
+)
+
+// expressionPattern matches "headers <url>", "http headers <url>" and
+// "http status <url>"
+var expressionPattern = regexp.MustCompile(`(?i)^(?:(?:http\s+)?(headers)|http\s+(status))\s+(\S+)$`)
+
+var (
+	transportMu sync.RWMutex
+	transport   http.RoundTripper // nil is http.DefaultTransport
+)
+
+// SetTransport replaces the transport requests are made with (used by tests
+// to avoid the network). nil restores http.DefaultTransport.
+func SetTransport(rt http.RoundTripper) {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	transport = rt
+}
+
+// httpClient returns a client that does not follow redirects itself, so each
+// hop can be shown
+func httpClient() *http.Client {
+	transportMu.RLock()
+	defer transportMu.RUnlock()
+	return &http.Client{
+		Timeout:   requestTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// hop is one response of a redirect chain
+type hop struct {
+	url      string
+	proto    string
+	status   string
+	location string
+	header   http.Header
+}
+
+// IsHTTPExpression checks if an expression is an HTTP header or status check
+func IsHTTPExpression(expr string) bool {
+	return expressionPattern.MatchString(strings.TrimSpace(expr))
+}
+
+// EvalHTTP fetches a URL and returns its response headers ("headers <url>") or
+// its status code and latency ("http status <url>"). Redirects are followed
+// and shown as a chain. The result is a block of "> " lines.
+func EvalHTTP(expr string) (string, error) {
+	matches := expressionPattern.FindStringSubmatch(strings.TrimSpace(expr))
+	if matches == nil {
+		return "", fmt.Errorf("invalid HTTP expression")
+	}
+
+	urlStr := strings.Trim(matches[3], `"'`)
+	// Add https:// if no scheme provided
+	if !strings.HasPrefix(strings.ToLower(urlStr), "http://") && !strings.HasPrefix(strings.ToLower(urlStr), "https://") {
+		urlStr = "https://" + urlStr
+	}
+
+	start := time.Now()
+	hops, err := fetch(urlStr)
+	if err != nil {
+		return "", err
+	}
+	latency := time.Since(start).Round(time.Millisecond)
+
+	if matches[1] != "" {
+		return formatHeaders(hops), nil
+	}
+	return formatStatus(hops, latency), nil
+}
+
+// fetch requests urlStr and follows its redirects, returning every response
+func fetch(urlStr string) ([]hop, error) {
+	client := httpClient()
+	var hops []hop
+	for len(hops) <= maxRedirects {
+		req, err := http.NewRequest(http.MethodGet, urlStr, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL: %v", err)
+		}
+		if req.URL.Host == "" {
+			return nil, fmt.Errorf("invalid URL: no host specified")
+		}
+		req.Header.Set("User-Agent", "SmartCalc-App")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			// Drop the "Get <url>:" prefix so TLS errors read plainly
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			return nil, err
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodyBytes))
+		resp.Body.Close()
+
+		h := hop{
+			url:    urlStr,
+			proto:  resp.Proto,
+			status: resp.Status,
+			header: resp.Header,
+		}
+		location, err := resp.Location()
+		if err != nil || resp.StatusCode < 300 || resp.StatusCode >= 400 {
+			return append(hops, h), nil
+		}
+		h.location = location.String()
+		hops = append(hops, h)
+		urlStr = h.location
+	}
+	return nil, fmt.Errorf("stopped after %d redirects", maxRedirects)
+}
+
+// formatHeaders shows each redirect with its Location, then the final
+// response's status line and headers sorted by name
+func formatHeaders(hops []hop) string {
+	var sb strings.Builder
+	for _, h := range hops[:len(hops)-1] {
+		sb.WriteString(fmt.Sprintf("\n> %s %s", h.proto, h.status))
+		sb.WriteString(fmt.Sprintf("\n> Location: %s", h.location))
+	}
+
+	last := hops[len(hops)-1]
+	sb.WriteString(fmt.Sprintf("\n> %s %s", last.proto, last.status))
+	names := make([]string, 0, len(last.header))
+	for name := range last.header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range last.header[name] {
+			sb.WriteString(fmt.Sprintf("\n> %s: %s", name, value))
+		}
+	}
+	return sb.String()
+}
+
+// formatStatus shows the redirect chain as "301 -> 200" lines with each
+// Location, then the final status and the time the whole chain took
+func formatStatus(hops []hop, latency time.Duration) string {
+	var sb strings.Builder
+	for _, h := range hops[:len(hops)-1] {
+		sb.WriteString(fmt.Sprintf("\n> %s -> %s", h.status, h.location))
+	}
+	sb.WriteString(fmt.Sprintf("\n> %s in %s", hops[len(hops)-1].status, latency))
+	return sb.String()
+}
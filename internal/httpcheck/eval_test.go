@@ -0,0 +1,144 @@
+package httpcheck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestIsHTTPExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"headers https://example.com", true},
+		{"headers example.com", true},
+		{"HTTP HEADERS example.com", true},
+		{"http status example.com", true},
+		{"http status https://example.com/path?q=1", true},
+
+		{"headers", false},
+		{"http status", false},
+		{"cert decode example.com", false},
+		{"headers of the table", false},
+		{"2 + 2", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsHTTPExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsHTTPExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+// newTestServer serves "/" directly and redirects "/old" there via "/moved"
+func newTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test", "yes")
+		w.Header().Add("Set-Cookie", "a=1")
+		w.Header().Add("Set-Cookie", "b=2")
+		w.Write([]byte("hello"))
+	})
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/", http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/big", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 10*maxBodyBytes)))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestEvalHTTPHeaders(t *testing.T) {
+	srv := newTestServer(t)
+
+	result, err := EvalHTTP("headers " + srv.URL)
+	if err != nil {
+		t.Fatalf("EvalHTTP error: %v", err)
+	}
+	for _, want := range []string{"\n> HTTP/1.1 200 OK", "\n> X-Test: yes", "\n> Set-Cookie: a=1\n> Set-Cookie: b=2"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result = %q, want to contain %q", result, want)
+		}
+	}
+	if !strings.HasPrefix(result, "\n> HTTP/1.1 200 OK\n> Content-Length:") {
+		t.Errorf("headers should follow the status line sorted by name: %q", result)
+	}
+}
+
+func TestEvalHTTPRedirects(t *testing.T) {
+	srv := newTestServer(t)
+
+	result, err := EvalHTTP("http headers " + srv.URL + "/old")
+	if err != nil {
+		t.Fatalf("EvalHTTP error: %v", err)
+	}
+	want := "\n> HTTP/1.1 301 Moved Permanently\n> Location: " + srv.URL + "/moved" +
+		"\n> HTTP/1.1 302 Found\n> Location: " + srv.URL + "/" +
+		"\n> HTTP/1.1 200 OK"
+	if !strings.HasPrefix(result, want) {
+		t.Errorf("result = %q, want to start with %q", result, want)
+	}
+
+	result, err = EvalHTTP("http status " + srv.URL + "/old")
+	if err != nil {
+		t.Fatalf("EvalHTTP error: %v", err)
+	}
+	pattern := regexp.MustCompile(`^\n> 301 Moved Permanently -> ` + regexp.QuoteMeta(srv.URL) + `/moved` +
+		`\n> 302 Found -> ` + regexp.QuoteMeta(srv.URL) + `/` +
+		`\n> 200 OK in \d+(?:\.\d+)?[µm]?s$`)
+	if !pattern.MatchString(result) {
+		t.Errorf("result = %q, want to match %s", result, pattern)
+	}
+
+	if _, err := EvalHTTP("http status " + srv.URL + "/loop"); err == nil || !strings.Contains(err.Error(), "redirects") {
+		t.Errorf("redirect loop error = %v, want a redirect limit error", err)
+	}
+}
+
+func TestEvalHTTPLargeBody(t *testing.T) {
+	srv := newTestServer(t)
+
+	result, err := EvalHTTP("http status " + srv.URL + "/big")
+	if err != nil {
+		t.Fatalf("EvalHTTP error: %v", err)
+	}
+	if !strings.HasPrefix(result, "\n> 200 OK in ") {
+		t.Errorf("result = %q, want the status", result)
+	}
+}
+
+func TestEvalHTTPTLSError(t *testing.T) {
+	// The test server's certificate is not trusted by the default client
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	_, err := EvalHTTP("headers " + srv.URL)
+	if err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Fatalf("EvalHTTP error = %v, want a certificate error", err)
+	}
+	if strings.HasPrefix(err.Error(), "Get ") {
+		t.Errorf("error should not repeat the request: %v", err)
+	}
+}
+
+func TestEvalHTTPInvalid(t *testing.T) {
+	if _, err := EvalHTTP("headers"); err == nil {
+		t.Error("expected error for a missing URL")
+	}
+	if _, err := EvalHTTP("headers https://"); err == nil {
+		t.Error("expected error for a URL without a host")
+	}
+}
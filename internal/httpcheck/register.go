@@ -0,0 +1,13 @@
+package httpcheck
+
+import "smartcalc/internal/registry"
+
+func init() {
+	registry.Register(registry.Evaluator{
+		Name:     "http",
+		Priority: registry.PriorityHTTP,
+		Traits:   registry.Expensive | registry.MultiLine | registry.NoFormat | registry.ReportsErrors,
+		Detect:   IsHTTPExpression,
+		Eval:     registry.TextEval(EvalHTTP),
+	})
+}
@@ -7,6 +7,8 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"smartcalc/internal/datetime"
 )
 
 // IsJWTExpression checks if an expression is a JWT decode expression
@@ -89,30 +91,13 @@ func decodeJWT(token string) (string, error) {
 		return "", fmt.Errorf("invalid JWT: expected 3 parts, got %d", len(parts))
 	}
 
-	// Decode header
-	headerJSON, err := base64URLDecode(parts[0])
+	header, payload, err := decodeParts(parts)
 	if err != nil {
-		return "", fmt.Errorf("invalid JWT header: %v", err)
-	}
-
-	var header map[string]interface{}
-	if err := json.Unmarshal(headerJSON, &header); err != nil {
-		return "", fmt.Errorf("invalid JWT header JSON: %v", err)
-	}
-
-	// Decode payload
-	payloadJSON, err := base64URLDecode(parts[1])
-	if err != nil {
-		return "", fmt.Errorf("invalid JWT payload: %v", err)
-	}
-
-	var payload map[string]interface{}
-	if err := json.Unmarshal(payloadJSON, &payload); err != nil {
-		return "", fmt.Errorf("invalid JWT payload JSON: %v", err)
+		return "", err
 	}
 
 	// Format output
-	return formatJWTOutput(header, payload, parts[2])
+	return formatJWTOutput(header, payload, parts[2], "")
 }
 
 // base64URLDecode decodes a base64url encoded string
@@ -132,8 +117,9 @@ func base64URLDecode(s string) ([]byte, error) {
 	return base64.StdEncoding.DecodeString(s)
 }
 
-// formatJWTOutput formats the decoded JWT for display
-func formatJWTOutput(header, payload map[string]interface{}, signature string) (string, error) {
+// formatJWTOutput formats the decoded JWT for display. A verified token shows
+// its verdict ("VALID", "INVALID") in place of the signature.
+func formatJWTOutput(header, payload map[string]interface{}, signature, verdict string) (string, error) {
 	var result strings.Builder
 
 	// Format header
@@ -158,7 +144,9 @@ func formatJWTOutput(header, payload map[string]interface{}, signature string) (
 
 	// Signature info
 	result.WriteString("> Signature: ")
-	if signature == "" {
+	if verdict != "" {
+		result.WriteString(verdict)
+	} else if signature == "" {
 		result.WriteString("(none - unsigned token)")
 	} else {
 		// Show truncated signature
@@ -174,10 +162,11 @@ func formatJWTOutput(header, payload map[string]interface{}, signature string) (
 		expTime := parseTimestamp(exp)
 		if expTime != nil {
 			result.WriteString("\n> Status: ")
-			if time.Now().After(*expTime) {
+			now := datetime.Now()
+			if now.After(*expTime) {
 				result.WriteString("⚠ EXPIRED")
 			} else {
-				remaining := time.Until(*expTime)
+				remaining := expTime.Sub(now)
 				result.WriteString(fmt.Sprintf("✓ Valid (expires in %s)", formatDuration(remaining)))
 			}
 		}
@@ -0,0 +1,42 @@
+package jwt
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// Formatting the expression would corrupt the base64url token
+	registry.Register(registry.Evaluator{
+		Name:     "jwt",
+		Priority: registry.PriorityJWT,
+		Traits:   registry.MultiLine | registry.NoFormat,
+		Detect:   IsJWTExpression,
+		Eval: registry.TextEval(func(expr string) (string, error) {
+			result, err := EvalJWT(expr)
+			return "\n> " + result, err
+		}),
+	})
+
+	// Verifying with a secret reports a token signed another way
+	registry.Register(registry.Evaluator{
+		Name:     "jwtverify",
+		Priority: registry.PriorityJWT,
+		Traits:   registry.MultiLine | registry.NoFormat | registry.ReportsErrors,
+		Detect:   IsVerifyExpression,
+		Eval: registry.TextEval(func(expr string) (string, error) {
+			result, err := EvalVerify(expr)
+			return "\n> " + result, err
+		}),
+	})
+
+	// The key set is fetched over the network, so as with certificate
+	// lookups only the line being edited fetches it
+	registry.Register(registry.Evaluator{
+		Name:     "jwks",
+		Priority: registry.PriorityJWT,
+		Traits:   registry.Expensive | registry.MultiLine | registry.NoFormat | registry.ReportsErrors,
+		Detect:   IsJWKSExpression,
+		Eval: registry.TextEval(func(expr string) (string, error) {
+			result, err := EvalVerify(expr)
+			return "\n> " + result, err
+		}),
+	})
+}
@@ -0,0 +1,327 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"smartcalc/internal/datetime"
+)
+
+const (
+	// jwksTimeout bounds fetching a key set
+	jwksTimeout = 10 * time.Second
+	// maxJWKSBytes caps how much of a key set response is read
+	maxJWKSBytes = 1 << 20
+)
+
+// verifyPattern matches "jwt verify <token> with secret <value>" and
+// "jwt verify <token> with jwks <url>"
+var verifyPattern = regexp.MustCompile(`(?i)^jwt\s+verify\s+(\S+)\s+with\s+(secret|jwks)\s+(.+)$`)
+
+var (
+	transportMu sync.RWMutex
+	transport   http.RoundTripper // nil is http.DefaultTransport
+)
+
+// SetTransport replaces the transport key sets are fetched with (used by
+// tests to avoid the network). nil restores http.DefaultTransport.
+func SetTransport(rt http.RoundTripper) {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	transport = rt
+}
+
+// IsVerifyExpression checks if an expression verifies a token with a secret
+func IsVerifyExpression(expr string) bool {
+	m := verifyPattern.FindStringSubmatch(strings.TrimSpace(expr))
+	return m != nil && strings.EqualFold(m[2], "secret")
+}
+
+// IsJWKSExpression checks if an expression verifies a token with a key set
+// fetched from a URL
+func IsJWKSExpression(expr string) bool {
+	m := verifyPattern.FindStringSubmatch(strings.TrimSpace(expr))
+	return m != nil && strings.EqualFold(m[2], "jwks")
+}
+
+// EvalVerify verifies the signature of a token with an HMAC secret (HS256,
+// HS384, HS512) or the key set at a JWKS URL (RS256, RS384, RS512, ES256,
+// ES384, ES512). The decoded token is shown with "Signature: VALID" or
+// "Signature: INVALID", and warnings for an exp in the past or an nbf in the
+// future.
+func EvalVerify(expr string) (string, error) {
+	m := verifyPattern.FindStringSubmatch(strings.TrimSpace(expr))
+	if m == nil {
+		return "", fmt.Errorf("invalid JWT verify expression")
+	}
+	token := strings.Trim(m[1], `"'`)
+	key := strings.Trim(strings.TrimSpace(m[3]), `"'`)
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("invalid JWT: expected 3 parts, got %d", len(parts))
+	}
+	header, payload, err := decodeParts(parts)
+	if err != nil {
+		return "", err
+	}
+	alg, _ := header["alg"].(string)
+	signature, err := base64URLDecode(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("invalid JWT signature: %v", err)
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+
+	var valid bool
+	if strings.EqualFold(m[2], "secret") {
+		valid, err = verifyHMAC(alg, signed, signature, []byte(key))
+	} else {
+		valid, err = verifyWithJWKS(alg, header, signed, signature, key)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	verdict := "INVALID"
+	if valid {
+		verdict = "VALID"
+	}
+	result, err := formatJWTOutput(header, payload, parts[2], verdict)
+	if err != nil {
+		return "", err
+	}
+	return result + timeWarnings(payload), nil
+}
+
+// decodeParts decodes the header and payload of a token
+func decodeParts(parts []string) (header, payload map[string]interface{}, err error) {
+	headerJSON, err := base64URLDecode(parts[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid JWT header: %v", err)
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, nil, fmt.Errorf("invalid JWT header JSON: %v", err)
+	}
+	payloadJSON, err := base64URLDecode(parts[1])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid JWT payload: %v", err)
+	}
+	if err := json.Unmarshal(payloadJSON, &payload); err != nil {
+		return nil, nil, fmt.Errorf("invalid JWT payload JSON: %v", err)
+	}
+	return header, payload, nil
+}
+
+// algHash returns the hash of an algorithm's size: 256, 384 or 512
+func algHash(alg string) (crypto.Hash, func() hash.Hash, bool) {
+	if len(alg) != 5 {
+		return 0, nil, false
+	}
+	switch alg[2:] {
+	case "256":
+		return crypto.SHA256, sha256.New, true
+	case "384":
+		return crypto.SHA384, sha512.New384, true
+	case "512":
+		return crypto.SHA512, sha512.New, true
+	}
+	return 0, nil, false
+}
+
+// verifyHMAC checks an HS256, HS384 or HS512 signature
+func verifyHMAC(alg string, signed, signature, secret []byte) (bool, error) {
+	if !strings.HasPrefix(alg, "HS") {
+		if strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "ES") {
+			return false, fmt.Errorf("%s tokens are verified with a key set: jwt verify <token> with jwks <url>", alg)
+		}
+		return false, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	_, newHash, ok := algHash(alg)
+	if !ok {
+		return false, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	mac := hmac.New(newHash, secret)
+	mac.Write(signed)
+	return hmac.Equal(mac.Sum(nil), signature), nil
+}
+
+// jwk is one key of a JSON Web Key Set
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// verifyWithJWKS fetches the key set at url and checks an RS or ES signature
+// with the key the token's kid names, or the first key of the right type
+func verifyWithJWKS(alg string, header map[string]interface{}, signed, signature []byte, url string) (bool, error) {
+	if strings.HasPrefix(alg, "HS") {
+		return false, fmt.Errorf("%s tokens are verified with a secret: jwt verify <token> with secret <value>", alg)
+	}
+	if !strings.HasPrefix(alg, "RS") && !strings.HasPrefix(alg, "ES") {
+		return false, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	hashID, _, ok := algHash(alg)
+	if !ok {
+		return false, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	keys, err := fetchJWKS(url)
+	if err != nil {
+		return false, err
+	}
+	kty := "RSA"
+	if strings.HasPrefix(alg, "ES") {
+		kty = "EC"
+	}
+	kid, _ := header["kid"].(string)
+	key, err := findKey(keys, kid, kty)
+	if err != nil {
+		return false, err
+	}
+
+	h := hashID.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	if kty == "RSA" {
+		pub, err := key.rsaPublicKey()
+		if err != nil {
+			return false, err
+		}
+		return rsa.VerifyPKCS1v15(pub, hashID, digest, signature) == nil, nil
+	}
+	pub, err := key.ecdsaPublicKey()
+	if err != nil {
+		return false, err
+	}
+	// ES signatures are r and s side by side, each the size of the curve
+	size := (pub.Curve.Params().BitSize + 7) / 8
+	if len(signature) != 2*size {
+		return false, nil
+	}
+	r := new(big.Int).SetBytes(signature[:size])
+	s := new(big.Int).SetBytes(signature[size:])
+	return ecdsa.Verify(pub, digest, r, s), nil
+}
+
+// fetchJWKS downloads the keys of a JSON Web Key Set
+func fetchJWKS(url string) ([]jwk, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		url = "https://" + url
+	}
+	transportMu.RLock()
+	client := &http.Client{Timeout: jwksTimeout, Transport: transport}
+	transportMu.RUnlock()
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch key set: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("key set returned status %d", resp.StatusCode)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSBytes)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("invalid key set: %v", err)
+	}
+	return set.Keys, nil
+}
+
+// findKey picks the key a token names with its kid, or without a kid the
+// first signing key of type kty
+func findKey(keys []jwk, kid, kty string) (jwk, error) {
+	for _, k := range keys {
+		if kid != "" && k.Kid != kid {
+			continue
+		}
+		if k.Kty != kty {
+			if kid != "" {
+				return jwk{}, fmt.Errorf("key %q is %s, not %s", kid, k.Kty, kty)
+			}
+			continue
+		}
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		return k, nil
+	}
+	if kid != "" {
+		return jwk{}, fmt.Errorf("no key with kid %q in the key set", kid)
+	}
+	return jwk{}, fmt.Errorf("no %s key in the key set", kty)
+}
+
+// rsaPublicKey builds the RSA public key of a JWK
+func (k jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64URLDecode(k.N)
+	if err != nil || len(n) == 0 {
+		return nil, fmt.Errorf("invalid RSA key %q: bad modulus", k.Kid)
+	}
+	e, err := base64URLDecode(k.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, fmt.Errorf("invalid RSA key %q: bad exponent", k.Kid)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+}
+
+// ecdsaPublicKey builds the EC public key of a JWK
+func (k jwk) ecdsaPublicKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, errX := base64URLDecode(k.X)
+	y, errY := base64URLDecode(k.Y)
+	if errX != nil || errY != nil {
+		return nil, fmt.Errorf("invalid EC key %q: bad coordinates", k.Kid)
+	}
+	pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !curve.IsOnCurve(pub.X, pub.Y) {
+		return nil, fmt.Errorf("invalid EC key %q: point is not on %s", k.Kid, k.Crv)
+	}
+	return pub, nil
+}
+
+// timeWarnings warns of a token that expired or is not valid yet
+func timeWarnings(payload map[string]interface{}) string {
+	var sb strings.Builder
+	now := datetime.Now()
+	if exp := parseTimestamp(payload["exp"]); exp != nil && now.After(*exp) {
+		sb.WriteString(fmt.Sprintf("\n> ⚠ Warning: expired at %s (exp is in the past)", exp.Format("2006-01-02 15:04:05 MST")))
+	}
+	if nbf := parseTimestamp(payload["nbf"]); nbf != nil && now.Before(*nbf) {
+		sb.WriteString(fmt.Sprintf("\n> ⚠ Warning: not valid before %s (nbf is in the future)", nbf.Format("2006-01-02 15:04:05 MST")))
+	}
+	return sb.String()
+}
@@ -0,0 +1,289 @@
+package lookup
+
+// Country is an ISO 3166-1 country or territory with its calling code,
+// ISO 4217 currency and IANA time zones, the zone of the capital first
+type Country struct {
+	Name        string
+	Alpha2      string
+	Alpha3      string
+	Numeric     int
+	CallingCode string // without the "+"
+	Currency    string
+	Zones       []string
+	Aliases     []string // other names it is known by, lowercase
+}
+
+// countries are the ISO 3166-1 countries and the inhabited territories with
+// their own code, in alphabetical order of their codes' English names
+var countries = []Country{
+	{"Afghanistan", "AF", "AFG", 4, "93", "AFN", []string{"Asia/Kabul"}, nil},
+	{"Åland Islands", "AX", "ALA", 248, "358", "EUR", []string{"Europe/Mariehamn"}, []string{"aland"}},
+	{"Albania", "AL", "ALB", 8, "355", "ALL", []string{"Europe/Tirane"}, nil},
+	{"Algeria", "DZ", "DZA", 12, "213", "DZD", []string{"Africa/Algiers"}, nil},
+	{"American Samoa", "AS", "ASM", 16, "1", "USD", []string{"Pacific/Pago_Pago"}, nil},
+	{"Andorra", "AD", "AND", 20, "376", "EUR", []string{"Europe/Andorra"}, nil},
+	{"Angola", "AO", "AGO", 24, "244", "AOA", []string{"Africa/Luanda"}, nil},
+	{"Anguilla", "AI", "AIA", 660, "1", "XCD", []string{"America/Anguilla"}, nil},
+	{"Antigua and Barbuda", "AG", "ATG", 28, "1", "XCD", []string{"America/Antigua"}, nil},
+	{"Argentina", "AR", "ARG", 32, "54", "ARS", []string{"America/Argentina/Buenos_Aires"}, nil},
+	{"Armenia", "AM", "ARM", 51, "374", "AMD", []string{"Asia/Yerevan"}, nil},
+	{"Aruba", "AW", "ABW", 533, "297", "AWG", []string{"America/Aruba"}, nil},
+	{"Australia", "AU", "AUS", 36, "61", "AUD", []string{"Australia/Sydney", "Australia/Brisbane", "Australia/Adelaide", "Australia/Darwin", "Australia/Perth"}, nil},
+	{"Austria", "AT", "AUT", 40, "43", "EUR", []string{"Europe/Vienna"}, nil},
+	{"Azerbaijan", "AZ", "AZE", 31, "994", "AZN", []string{"Asia/Baku"}, nil},
+	{"Bahamas", "BS", "BHS", 44, "1", "BSD", []string{"America/Nassau"}, nil},
+	{"Bahrain", "BH", "BHR", 48, "973", "BHD", []string{"Asia/Bahrain"}, nil},
+	{"Bangladesh", "BD", "BGD", 50, "880", "BDT", []string{"Asia/Dhaka"}, nil},
+	{"Barbados", "BB", "BRB", 52, "1", "BBD", []string{"America/Barbados"}, nil},
+	{"Belarus", "BY", "BLR", 112, "375", "BYN", []string{"Europe/Minsk"}, nil},
+	{"Belgium", "BE", "BEL", 56, "32", "EUR", []string{"Europe/Brussels"}, nil},
+	{"Belize", "BZ", "BLZ", 84, "501", "BZD", []string{"America/Belize"}, nil},
+	{"Benin", "BJ", "BEN", 204, "229", "XOF", []string{"Africa/Porto-Novo"}, nil},
+	{"Bermuda", "BM", "BMU", 60, "1", "BMD", []string{"Atlantic/Bermuda"}, nil},
+	{"Bhutan", "BT", "BTN", 64, "975", "BTN", []string{"Asia/Thimphu"}, nil},
+	{"Bolivia", "BO", "BOL", 68, "591", "BOB", []string{"America/La_Paz"}, nil},
+	{"Bosnia and Herzegovina", "BA", "BIH", 70, "387", "BAM", []string{"Europe/Sarajevo"}, []string{"bosnia"}},
+	{"Botswana", "BW", "BWA", 72, "267", "BWP", []string{"Africa/Gaborone"}, nil},
+	{"Brazil", "BR", "BRA", 76, "55", "BRL", []string{"America/Sao_Paulo", "America/Manaus", "America/Rio_Branco", "America/Noronha"}, nil},
+	{"British Virgin Islands", "VG", "VGB", 92, "1", "USD", []string{"America/Tortola"}, nil},
+	{"Brunei", "BN", "BRN", 96, "673", "BND", []string{"Asia/Brunei"}, []string{"brunei darussalam"}},
+	{"Bulgaria", "BG", "BGR", 100, "359", "EUR", []string{"Europe/Sofia"}, nil},
+	{"Burkina Faso", "BF", "BFA", 854, "226", "XOF", []string{"Africa/Ouagadougou"}, nil},
+	{"Burundi", "BI", "BDI", 108, "257", "BIF", []string{"Africa/Bujumbura"}, nil},
+	{"Cabo Verde", "CV", "CPV", 132, "238", "CVE", []string{"Atlantic/Cape_Verde"}, []string{"cape verde"}},
+	{"Cambodia", "KH", "KHM", 116, "855", "KHR", []string{"Asia/Phnom_Penh"}, nil},
+	{"Cameroon", "CM", "CMR", 120, "237", "XAF", []string{"Africa/Douala"}, nil},
+	{"Canada", "CA", "CAN", 124, "1", "CAD", []string{"America/Toronto", "America/Halifax", "America/St_Johns", "America/Winnipeg", "America/Edmonton", "America/Vancouver"}, nil},
+	{"Cayman Islands", "KY", "CYM", 136, "1", "KYD", []string{"America/Cayman"}, nil},
+	{"Central African Republic", "CF", "CAF", 140, "236", "XAF", []string{"Africa/Bangui"}, nil},
+	{"Chad", "TD", "TCD", 148, "235", "XAF", []string{"Africa/Ndjamena"}, nil},
+	{"Chile", "CL", "CHL", 152, "56", "CLP", []string{"America/Santiago", "Pacific/Easter"}, nil},
+	{"China", "CN", "CHN", 156, "86", "CNY", []string{"Asia/Shanghai"}, nil},
+	{"Colombia", "CO", "COL", 170, "57", "COP", []string{"America/Bogota"}, nil},
+	{"Comoros", "KM", "COM", 174, "269", "KMF", []string{"Indian/Comoro"}, nil},
+	{"Congo", "CG", "COG", 178, "242", "XAF", []string{"Africa/Brazzaville"}, []string{"republic of the congo", "congo-brazzaville"}},
+	{"Cook Islands", "CK", "COK", 184, "682", "NZD", []string{"Pacific/Rarotonga"}, nil},
+	{"Costa Rica", "CR", "CRI", 188, "506", "CRC", []string{"America/Costa_Rica"}, nil},
+	{"Côte d'Ivoire", "CI", "CIV", 384, "225", "XOF", []string{"Africa/Abidjan"}, []string{"ivory coast"}},
+	{"Croatia", "HR", "HRV", 191, "385", "EUR", []string{"Europe/Zagreb"}, nil},
+	{"Cuba", "CU", "CUB", 192, "53", "CUP", []string{"America/Havana"}, nil},
+	{"Curaçao", "CW", "CUW", 531, "599", "XCG", []string{"America/Curacao"}, nil},
+	{"Cyprus", "CY", "CYP", 196, "357", "EUR", []string{"Asia/Nicosia"}, nil},
+	{"Czechia", "CZ", "CZE", 203, "420", "CZK", []string{"Europe/Prague"}, []string{"czech republic"}},
+	{"Democratic Republic of the Congo", "CD", "COD", 180, "243", "CDF", []string{"Africa/Kinshasa", "Africa/Lubumbashi"}, []string{"dr congo", "drc", "congo-kinshasa"}},
+	{"Denmark", "DK", "DNK", 208, "45", "DKK", []string{"Europe/Copenhagen"}, nil},
+	{"Djibouti", "DJ", "DJI", 262, "253", "DJF", []string{"Africa/Djibouti"}, nil},
+	{"Dominica", "DM", "DMA", 212, "1", "XCD", []string{"America/Dominica"}, nil},
+	{"Dominican Republic", "DO", "DOM", 214, "1", "DOP", []string{"America/Santo_Domingo"}, nil},
+	{"Ecuador", "EC", "ECU", 218, "593", "USD", []string{"America/Guayaquil", "Pacific/Galapagos"}, nil},
+	{"Egypt", "EG", "EGY", 818, "20", "EGP", []string{"Africa/Cairo"}, nil},
+	{"El Salvador", "SV", "SLV", 222, "503", "USD", []string{"America/El_Salvador"}, nil},
+	{"Equatorial Guinea", "GQ", "GNQ", 226, "240", "XAF", []string{"Africa/Malabo"}, nil},
+	{"Eritrea", "ER", "ERI", 232, "291", "ERN", []string{"Africa/Asmara"}, nil},
+	{"Estonia", "EE", "EST", 233, "372", "EUR", []string{"Europe/Tallinn"}, nil},
+	{"Eswatini", "SZ", "SWZ", 748, "268", "SZL", []string{"Africa/Mbabane"}, []string{"swaziland"}},
+	{"Ethiopia", "ET", "ETH", 231, "251", "ETB", []string{"Africa/Addis_Ababa"}, nil},
+	{"Falkland Islands", "FK", "FLK", 238, "500", "FKP", []string{"Atlantic/Stanley"}, nil},
+	{"Faroe Islands", "FO", "FRO", 234, "298", "DKK", []string{"Atlantic/Faroe"}, nil},
+	{"Fiji", "FJ", "FJI", 242, "679", "FJD", []string{"Pacific/Fiji"}, nil},
+	{"Finland", "FI", "FIN", 246, "358", "EUR", []string{"Europe/Helsinki"}, nil},
+	{"France", "FR", "FRA", 250, "33", "EUR", []string{"Europe/Paris"}, nil},
+	{"French Guiana", "GF", "GUF", 254, "594", "EUR", []string{"America/Cayenne"}, nil},
+	{"French Polynesia", "PF", "PYF", 258, "689", "XPF", []string{"Pacific/Tahiti"}, nil},
+	{"Gabon", "GA", "GAB", 266, "241", "XAF", []string{"Africa/Libreville"}, nil},
+	{"Gambia", "GM", "GMB", 270, "220", "GMD", []string{"Africa/Banjul"}, nil},
+	{"Georgia", "GE", "GEO", 268, "995", "GEL", []string{"Asia/Tbilisi"}, nil},
+	{"Germany", "DE", "DEU", 276, "49", "EUR", []string{"Europe/Berlin"}, nil},
+	{"Ghana", "GH", "GHA", 288, "233", "GHS", []string{"Africa/Accra"}, nil},
+	{"Gibraltar", "GI", "GIB", 292, "350", "GIP", []string{"Europe/Gibraltar"}, nil},
+	{"Greece", "GR", "GRC", 300, "30", "EUR", []string{"Europe/Athens"}, nil},
+	{"Greenland", "GL", "GRL", 304, "299", "DKK", []string{"America/Nuuk"}, nil},
+	{"Grenada", "GD", "GRD", 308, "1", "XCD", []string{"America/Grenada"}, nil},
+	{"Guadeloupe", "GP", "GLP", 312, "590", "EUR", []string{"America/Guadeloupe"}, nil},
+	{"Guam", "GU", "GUM", 316, "1", "USD", []string{"Pacific/Guam"}, nil},
+	{"Guatemala", "GT", "GTM", 320, "502", "GTQ", []string{"America/Guatemala"}, nil},
+	{"Guernsey", "GG", "GGY", 831, "44", "GBP", []string{"Europe/Guernsey"}, nil},
+	{"Guinea", "GN", "GIN", 324, "224", "GNF", []string{"Africa/Conakry"}, nil},
+	{"Guinea-Bissau", "GW", "GNB", 624, "245", "XOF", []string{"Africa/Bissau"}, nil},
+	{"Guyana", "GY", "GUY", 328, "592", "GYD", []string{"America/Guyana"}, nil},
+	{"Haiti", "HT", "HTI", 332, "509", "HTG", []string{"America/Port-au-Prince"}, nil},
+	{"Holy See", "VA", "VAT", 336, "39", "EUR", []string{"Europe/Vatican"}, []string{"vatican", "vatican city"}},
+	{"Honduras", "HN", "HND", 340, "504", "HNL", []string{"America/Tegucigalpa"}, nil},
+	{"Hong Kong", "HK", "HKG", 344, "852", "HKD", []string{"Asia/Hong_Kong"}, nil},
+	{"Hungary", "HU", "HUN", 348, "36", "HUF", []string{"Europe/Budapest"}, nil},
+	{"Iceland", "IS", "ISL", 352, "354", "ISK", []string{"Atlantic/Reykjavik"}, nil},
+	{"India", "IN", "IND", 356, "91", "INR", []string{"Asia/Kolkata"}, nil},
+	{"Indonesia", "ID", "IDN", 360, "62", "IDR", []string{"Asia/Jakarta", "Asia/Makassar", "Asia/Jayapura"}, nil},
+	{"Iran", "IR", "IRN", 364, "98", "IRR", []string{"Asia/Tehran"}, nil},
+	{"Iraq", "IQ", "IRQ", 368, "964", "IQD", []string{"Asia/Baghdad"}, nil},
+	{"Ireland", "IE", "IRL", 372, "353", "EUR", []string{"Europe/Dublin"}, nil},
+	{"Isle of Man", "IM", "IMN", 833, "44", "GBP", []string{"Europe/Isle_of_Man"}, nil},
+	{"Israel", "IL", "ISR", 376, "972", "ILS", []string{"Asia/Jerusalem"}, nil},
+	{"Italy", "IT", "ITA", 380, "39", "EUR", []string{"Europe/Rome"}, nil},
+	{"Jamaica", "JM", "JAM", 388, "1", "JMD", []string{"America/Jamaica"}, nil},
+	{"Japan", "JP", "JPN", 392, "81", "JPY", []string{"Asia/Tokyo"}, nil},
+	{"Jersey", "JE", "JEY", 832, "44", "GBP", []string{"Europe/Jersey"}, nil},
+	{"Jordan", "JO", "JOR", 400, "962", "JOD", []string{"Asia/Amman"}, nil},
+	{"Kazakhstan", "KZ", "KAZ", 398, "7", "KZT", []string{"Asia/Almaty"}, nil},
+	{"Kenya", "KE", "KEN", 404, "254", "KES", []string{"Africa/Nairobi"}, nil},
+	{"Kiribati", "KI", "KIR", 296, "686", "AUD", []string{"Pacific/Tarawa", "Pacific/Kanton", "Pacific/Kiritimati"}, nil},
+	{"Kuwait", "KW", "KWT", 414, "965", "KWD", []string{"Asia/Kuwait"}, nil},
+	{"Kyrgyzstan", "KG", "KGZ", 417, "996", "KGS", []string{"Asia/Bishkek"}, nil},
+	{"Laos", "LA", "LAO", 418, "856", "LAK", []string{"Asia/Vientiane"}, []string{"lao pdr"}},
+	{"Latvia", "LV", "LVA", 428, "371", "EUR", []string{"Europe/Riga"}, nil},
+	{"Lebanon", "LB", "LBN", 422, "961", "LBP", []string{"Asia/Beirut"}, nil},
+	{"Lesotho", "LS", "LSO", 426, "266", "LSL", []string{"Africa/Maseru"}, nil},
+	{"Liberia", "LR", "LBR", 430, "231", "LRD", []string{"Africa/Monrovia"}, nil},
+	{"Libya", "LY", "LBY", 434, "218", "LYD", []string{"Africa/Tripoli"}, nil},
+	{"Liechtenstein", "LI", "LIE", 438, "423", "CHF", []string{"Europe/Vaduz"}, nil},
+	{"Lithuania", "LT", "LTU", 440, "370", "EUR", []string{"Europe/Vilnius"}, nil},
+	{"Luxembourg", "LU", "LUX", 442, "352", "EUR", []string{"Europe/Luxembourg"}, nil},
+	{"Macao", "MO", "MAC", 446, "853", "MOP", []string{"Asia/Macau"}, []string{"macau"}},
+	{"Madagascar", "MG", "MDG", 450, "261", "MGA", []string{"Indian/Antananarivo"}, nil},
+	{"Malawi", "MW", "MWI", 454, "265", "MWK", []string{"Africa/Blantyre"}, nil},
+	{"Malaysia", "MY", "MYS", 458, "60", "MYR", []string{"Asia/Kuala_Lumpur"}, nil},
+	{"Maldives", "MV", "MDV", 462, "960", "MVR", []string{"Indian/Maldives"}, nil},
+	{"Mali", "ML", "MLI", 466, "223", "XOF", []string{"Africa/Bamako"}, nil},
+	{"Malta", "MT", "MLT", 470, "356", "EUR", []string{"Europe/Malta"}, nil},
+	{"Marshall Islands", "MH", "MHL", 584, "692", "USD", []string{"Pacific/Majuro"}, nil},
+	{"Martinique", "MQ", "MTQ", 474, "596", "EUR", []string{"America/Martinique"}, nil},
+	{"Mauritania", "MR", "MRT", 478, "222", "MRU", []string{"Africa/Nouakchott"}, nil},
+	{"Mauritius", "MU", "MUS", 480, "230", "MUR", []string{"Indian/Mauritius"}, nil},
+	{"Mayotte", "YT", "MYT", 175, "262", "EUR", []string{"Indian/Mayotte"}, nil},
+	{"Mexico", "MX", "MEX", 484, "52", "MXN", []string{"America/Mexico_City", "America/Cancun", "America/Hermosillo", "America/Tijuana"}, nil},
+	{"Micronesia", "FM", "FSM", 583, "691", "USD", []string{"Pacific/Pohnpei", "Pacific/Chuuk", "Pacific/Kosrae"}, []string{"federated states of micronesia"}},
+	{"Moldova", "MD", "MDA", 498, "373", "MDL", []string{"Europe/Chisinau"}, nil},
+	{"Monaco", "MC", "MCO", 492, "377", "EUR", []string{"Europe/Monaco"}, nil},
+	{"Mongolia", "MN", "MNG", 496, "976", "MNT", []string{"Asia/Ulaanbaatar", "Asia/Hovd"}, nil},
+	{"Montenegro", "ME", "MNE", 499, "382", "EUR", []string{"Europe/Podgorica"}, nil},
+	{"Montserrat", "MS", "MSR", 500, "1", "XCD", []string{"America/Montserrat"}, nil},
+	{"Morocco", "MA", "MAR", 504, "212", "MAD", []string{"Africa/Casablanca"}, nil},
+	{"Mozambique", "MZ", "MOZ", 508, "258", "MZN", []string{"Africa/Maputo"}, nil},
+	{"Myanmar", "MM", "MMR", 104, "95", "MMK", []string{"Asia/Yangon"}, []string{"burma"}},
+	{"Namibia", "NA", "NAM", 516, "264", "NAD", []string{"Africa/Windhoek"}, nil},
+	{"Nauru", "NR", "NRU", 520, "674", "AUD", []string{"Pacific/Nauru"}, nil},
+	{"Nepal", "NP", "NPL", 524, "977", "NPR", []string{"Asia/Kathmandu"}, nil},
+	{"Netherlands", "NL", "NLD", 528, "31", "EUR", []string{"Europe/Amsterdam"}, []string{"holland"}},
+	{"New Caledonia", "NC", "NCL", 540, "687", "XPF", []string{"Pacific/Noumea"}, nil},
+	{"New Zealand", "NZ", "NZL", 554, "64", "NZD", []string{"Pacific/Auckland", "Pacific/Chatham"}, nil},
+	{"Nicaragua", "NI", "NIC", 558, "505", "NIO", []string{"America/Managua"}, nil},
+	{"Niger", "NE", "NER", 562, "227", "XOF", []string{"Africa/Niamey"}, nil},
+	{"Nigeria", "NG", "NGA", 566, "234", "NGN", []string{"Africa/Lagos"}, nil},
+	{"North Korea", "KP", "PRK", 408, "850", "KPW", []string{"Asia/Pyongyang"}, []string{"dprk"}},
+	{"North Macedonia", "MK", "MKD", 807, "389", "MKD", []string{"Europe/Skopje"}, []string{"macedonia"}},
+	{"Northern Mariana Islands", "MP", "MNP", 580, "1", "USD", []string{"Pacific/Saipan"}, nil},
+	{"Norway", "NO", "NOR", 578, "47", "NOK", []string{"Europe/Oslo"}, nil},
+	{"Oman", "OM", "OMN", 512, "968", "OMR", []string{"Asia/Muscat"}, nil},
+	{"Pakistan", "PK", "PAK", 586, "92", "PKR", []string{"Asia/Karachi"}, nil},
+	{"Palau", "PW", "PLW", 585, "680", "USD", []string{"Pacific/Palau"}, nil},
+	{"Palestine", "PS", "PSE", 275, "970", "ILS", []string{"Asia/Gaza", "Asia/Hebron"}, []string{"state of palestine"}},
+	{"Panama", "PA", "PAN", 591, "507", "PAB", []string{"America/Panama"}, nil},
+	{"Papua New Guinea", "PG", "PNG", 598, "675", "PGK", []string{"Pacific/Port_Moresby"}, nil},
+	{"Paraguay", "PY", "PRY", 600, "595", "PYG", []string{"America/Asuncion"}, nil},
+	{"Peru", "PE", "PER", 604, "51", "PEN", []string{"America/Lima"}, nil},
+	{"Philippines", "PH", "PHL", 608, "63", "PHP", []string{"Asia/Manila"}, nil},
+	{"Poland", "PL", "POL", 616, "48", "PLN", []string{"Europe/Warsaw"}, nil},
+	{"Portugal", "PT", "PRT", 620, "351", "EUR", []string{"Europe/Lisbon", "Atlantic/Madeira", "Atlantic/Azores"}, nil},
+	{"Puerto Rico", "PR", "PRI", 630, "1", "USD", []string{"America/Puerto_Rico"}, nil},
+	{"Qatar", "QA", "QAT", 634, "974", "QAR", []string{"Asia/Qatar"}, nil},
+	{"Réunion", "RE", "REU", 638, "262", "EUR", []string{"Indian/Reunion"}, nil},
+	{"Romania", "RO", "ROU", 642, "40", "RON", []string{"Europe/Bucharest"}, nil},
+	{"Russia", "RU", "RUS", 643, "7", "RUB", []string{"Europe/Moscow", "Europe/Kaliningrad", "Europe/Samara", "Asia/Yekaterinburg", "Asia/Omsk", "Asia/Novosibirsk", "Asia/Irkutsk", "Asia/Yakutsk", "Asia/Vladivostok", "Asia/Magadan", "Asia/Kamchatka"}, []string{"russian federation"}},
+	{"Rwanda", "RW", "RWA", 646, "250", "RWF", []string{"Africa/Kigali"}, nil},
+	{"Saint Kitts and Nevis", "KN", "KNA", 659, "1", "XCD", []string{"America/St_Kitts"}, nil},
+	{"Saint Lucia", "LC", "LCA", 662, "1", "XCD", []string{"America/St_Lucia"}, nil},
+	{"Saint Vincent and the Grenadines", "VC", "VCT", 670, "1", "XCD", []string{"America/St_Vincent"}, nil},
+	{"Samoa", "WS", "WSM", 882, "685", "WST", []string{"Pacific/Apia"}, nil},
+	{"San Marino", "SM", "SMR", 674, "378", "EUR", []string{"Europe/San_Marino"}, nil},
+	{"Sao Tome and Principe", "ST", "STP", 678, "239", "STN", []string{"Africa/Sao_Tome"}, nil},
+	{"Saudi Arabia", "SA", "SAU", 682, "966", "SAR", []string{"Asia/Riyadh"}, nil},
+	{"Senegal", "SN", "SEN", 686, "221", "XOF", []string{"Africa/Dakar"}, nil},
+	{"Serbia", "RS", "SRB", 688, "381", "RSD", []string{"Europe/Belgrade"}, nil},
+	{"Seychelles", "SC", "SYC", 690, "248", "SCR", []string{"Indian/Mahe"}, nil},
+	{"Sierra Leone", "SL", "SLE", 694, "232", "SLE", []string{"Africa/Freetown"}, nil},
+	{"Singapore", "SG", "SGP", 702, "65", "SGD", []string{"Asia/Singapore"}, nil},
+	{"Sint Maarten", "SX", "SXM", 534, "1", "XCG", []string{"America/Lower_Princes"}, nil},
+	{"Slovakia", "SK", "SVK", 703, "421", "EUR", []string{"Europe/Bratislava"}, nil},
+	{"Slovenia", "SI", "SVN", 705, "386", "EUR", []string{"Europe/Ljubljana"}, nil},
+	{"Solomon Islands", "SB", "SLB", 90, "677", "SBD", []string{"Pacific/Guadalcanal"}, nil},
+	{"Somalia", "SO", "SOM", 706, "252", "SOS", []string{"Africa/Mogadishu"}, nil},
+	{"South Africa", "ZA", "ZAF", 710, "27", "ZAR", []string{"Africa/Johannesburg"}, nil},
+	{"South Korea", "KR", "KOR", 410, "82", "KRW", []string{"Asia/Seoul"}, []string{"korea", "republic of korea"}},
+	{"South Sudan", "SS", "SSD", 728, "211", "SSP", []string{"Africa/Juba"}, nil},
+	{"Spain", "ES", "ESP", 724, "34", "EUR", []string{"Europe/Madrid", "Atlantic/Canary"}, nil},
+	{"Sri Lanka", "LK", "LKA", 144, "94", "LKR", []string{"Asia/Colombo"}, nil},
+	{"Sudan", "SD", "SDN", 729, "249", "SDG", []string{"Africa/Khartoum"}, nil},
+	{"Suriname", "SR", "SUR", 740, "597", "SRD", []string{"America/Paramaribo"}, nil},
+	{"Sweden", "SE", "SWE", 752, "46", "SEK", []string{"Europe/Stockholm"}, nil},
+	{"Switzerland", "CH", "CHE", 756, "41", "CHF", []string{"Europe/Zurich"}, nil},
+	{"Syria", "SY", "SYR", 760, "963", "SYP", []string{"Asia/Damascus"}, nil},
+	{"Taiwan", "TW", "TWN", 158, "886", "TWD", []string{"Asia/Taipei"}, nil},
+	{"Tajikistan", "TJ", "TJK", 762, "992", "TJS", []string{"Asia/Dushanbe"}, nil},
+	{"Tanzania", "TZ", "TZA", 834, "255", "TZS", []string{"Africa/Dar_es_Salaam"}, nil},
+	{"Thailand", "TH", "THA", 764, "66", "THB", []string{"Asia/Bangkok"}, nil},
+	{"Timor-Leste", "TL", "TLS", 626, "670", "USD", []string{"Asia/Dili"}, []string{"east timor"}},
+	{"Togo", "TG", "TGO", 768, "228", "XOF", []string{"Africa/Lome"}, nil},
+	{"Tonga", "TO", "TON", 776, "676", "TOP", []string{"Pacific/Tongatapu"}, nil},
+	{"Trinidad and Tobago", "TT", "TTO", 780, "1", "TTD", []string{"America/Port_of_Spain"}, nil},
+	{"Tunisia", "TN", "TUN", 788, "216", "TND", []string{"Africa/Tunis"}, nil},
+	{"Türkiye", "TR", "TUR", 792, "90", "TRY", []string{"Europe/Istanbul"}, []string{"turkey"}},
+	{"Turkmenistan", "TM", "TKM", 795, "993", "TMT", []string{"Asia/Ashgabat"}, nil},
+	{"Turks and Caicos Islands", "TC", "TCA", 796, "1", "USD", []string{"America/Grand_Turk"}, nil},
+	{"Tuvalu", "TV", "TUV", 798, "688", "AUD", []string{"Pacific/Funafuti"}, nil},
+	{"U.S. Virgin Islands", "VI", "VIR", 850, "1", "USD", []string{"America/St_Thomas"}, []string{"us virgin islands"}},
+	{"Uganda", "UG", "UGA", 800, "256", "UGX", []string{"Africa/Kampala"}, nil},
+	{"Ukraine", "UA", "UKR", 804, "380", "UAH", []string{"Europe/Kyiv"}, nil},
+	{"United Arab Emirates", "AE", "ARE", 784, "971", "AED", []string{"Asia/Dubai"}, []string{"uae"}},
+	{"United Kingdom", "GB", "GBR", 826, "44", "GBP", []string{"Europe/London"}, []string{"uk", "great britain", "britain"}},
+	{"United States", "US", "USA", 840, "1", "USD", []string{"America/New_York", "America/Chicago", "America/Denver", "America/Phoenix", "America/Los_Angeles", "America/Anchorage", "Pacific/Honolulu"}, []string{"united states of america", "america"}},
+	{"Uruguay", "UY", "URY", 858, "598", "UYU", []string{"America/Montevideo"}, nil},
+	{"Uzbekistan", "UZ", "UZB", 860, "998", "UZS", []string{"Asia/Tashkent"}, nil},
+	{"Vanuatu", "VU", "VUT", 548, "678", "VUV", []string{"Pacific/Efate"}, nil},
+	{"Venezuela", "VE", "VEN", 862, "58", "VES", []string{"America/Caracas"}, nil},
+	{"Vietnam", "VN", "VNM", 704, "84", "VND", []string{"Asia/Ho_Chi_Minh"}, []string{"viet nam"}},
+	{"Yemen", "YE", "YEM", 887, "967", "YER", []string{"Asia/Aden"}, nil},
+	{"Zambia", "ZM", "ZMB", 894, "260", "ZMW", []string{"Africa/Lusaka"}, nil},
+	{"Zimbabwe", "ZW", "ZWE", 716, "263", "ZWG", []string{"Africa/Harare"}, nil},
+}
+
+// currencyNames are the English names of the ISO 4217 currencies above
+var currencyNames = map[string]string{
+	"AED": "UAE dirham", "AFN": "Afghan afghani", "ALL": "Albanian lek", "AMD": "Armenian dram",
+	"AOA": "Angolan kwanza", "ARS": "Argentine peso", "AUD": "Australian dollar", "AWG": "Aruban florin",
+	"AZN": "Azerbaijani manat", "BAM": "convertible mark", "BBD": "Barbadian dollar", "BDT": "Bangladeshi taka",
+	"BHD": "Bahraini dinar", "BIF": "Burundian franc", "BMD": "Bermudian dollar", "BND": "Brunei dollar",
+	"BOB": "Bolivian boliviano", "BRL": "Brazilian real", "BSD": "Bahamian dollar", "BTN": "Bhutanese ngultrum",
+	"BWP": "Botswana pula", "BYN": "Belarusian ruble", "BZD": "Belize dollar", "CAD": "Canadian dollar",
+	"CDF": "Congolese franc", "CHF": "Swiss franc", "CLP": "Chilean peso", "CNY": "Chinese yuan",
+	"COP": "Colombian peso", "CRC": "Costa Rican colón", "CUP": "Cuban peso", "CVE": "Cape Verdean escudo",
+	"CZK": "Czech koruna", "DJF": "Djiboutian franc", "DKK": "Danish krone", "DOP": "Dominican peso",
+	"DZD": "Algerian dinar", "EGP": "Egyptian pound", "ERN": "Eritrean nakfa", "ETB": "Ethiopian birr",
+	"EUR": "euro", "FJD": "Fijian dollar", "FKP": "Falkland Islands pound", "GBP": "pound sterling",
+	"GEL": "Georgian lari", "GHS": "Ghanaian cedi", "GIP": "Gibraltar pound", "GMD": "Gambian dalasi",
+	"GNF": "Guinean franc", "GTQ": "Guatemalan quetzal", "GYD": "Guyanese dollar", "HKD": "Hong Kong dollar",
+	"HNL": "Honduran lempira", "HTG": "Haitian gourde", "HUF": "Hungarian forint", "IDR": "Indonesian rupiah",
+	"ILS": "Israeli new shekel", "INR": "Indian rupee", "IQD": "Iraqi dinar", "IRR": "Iranian rial",
+	"ISK": "Icelandic króna", "JMD": "Jamaican dollar", "JOD": "Jordanian dinar", "JPY": "Japanese yen",
+	"KES": "Kenyan shilling", "KGS": "Kyrgyzstani som", "KHR": "Cambodian riel", "KMF": "Comorian franc",
+	"KPW": "North Korean won", "KRW": "South Korean won", "KWD": "Kuwaiti dinar", "KYD": "Cayman Islands dollar",
+	"KZT": "Kazakhstani tenge", "LAK": "Lao kip", "LBP": "Lebanese pound", "LKR": "Sri Lankan rupee",
+	"LRD": "Liberian dollar", "LSL": "Lesotho loti", "LYD": "Libyan dinar", "MAD": "Moroccan dirham",
+	"MDL": "Moldovan leu", "MGA": "Malagasy ariary", "MKD": "Macedonian denar", "MMK": "Myanmar kyat",
+	"MNT": "Mongolian tögrög", "MOP": "Macanese pataca", "MRU": "Mauritanian ouguiya", "MUR": "Mauritian rupee",
+	"MVR": "Maldivian rufiyaa", "MWK": "Malawian kwacha", "MXN": "Mexican peso", "MYR": "Malaysian ringgit",
+	"MZN": "Mozambican metical", "NAD": "Namibian dollar", "NGN": "Nigerian naira", "NIO": "Nicaraguan córdoba",
+	"NOK": "Norwegian krone", "NPR": "Nepalese rupee", "NZD": "New Zealand dollar", "OMR": "Omani rial",
+	"PAB": "Panamanian balboa", "PEN": "Peruvian sol", "PGK": "Papua New Guinean kina", "PHP": "Philippine peso",
+	"PKR": "Pakistani rupee", "PLN": "Polish złoty", "PYG": "Paraguayan guaraní", "QAR": "Qatari riyal",
+	"RON": "Romanian leu", "RSD": "Serbian dinar", "RUB": "Russian ruble", "RWF": "Rwandan franc",
+	"SAR": "Saudi riyal", "SBD": "Solomon Islands dollar", "SCR": "Seychellois rupee", "SDG": "Sudanese pound",
+	"SEK": "Swedish krona", "SGD": "Singapore dollar", "SLE": "Sierra Leonean leone", "SOS": "Somali shilling",
+	"SRD": "Surinamese dollar", "SSP": "South Sudanese pound", "STN": "São Tomé and Príncipe dobra", "SYP": "Syrian pound",
+	"SZL": "Swazi lilangeni", "THB": "Thai baht", "TJS": "Tajikistani somoni", "TMT": "Turkmenistan manat",
+	"TND": "Tunisian dinar", "TOP": "Tongan paʻanga", "TRY": "Turkish lira", "TTD": "Trinidad and Tobago dollar",
+	"TWD": "New Taiwan dollar", "TZS": "Tanzanian shilling", "UAH": "Ukrainian hryvnia", "UGX": "Ugandan shilling",
+	"USD": "US dollar", "UYU": "Uruguayan peso", "UZS": "Uzbekistani som", "VES": "Venezuelan bolívar",
+	"VND": "Vietnamese đồng", "VUV": "Vanuatu vatu", "WST": "Samoan tālā", "XAF": "Central African CFA franc",
+	"XCD": "East Caribbean dollar", "XCG": "Caribbean guilder", "XOF": "West African CFA franc", "XPF": "CFP franc",
+	"YER": "Yemeni rial", "ZAR": "South African rand", "ZMW": "Zambian kwacha", "ZWG": "Zimbabwe gold",
+}
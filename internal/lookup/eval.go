@@ -0,0 +1,191 @@
+package lookup
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"smartcalc/internal/datetime"
+	"smartcalc/internal/utils"
+)
+
+// Patterns of the lookups. Each starts with its keywords, so a two-letter
+// variable such as "ua" or "de" is never taken for a country on its own.
+var (
+	// countryCodePattern matches "country code UA", "country code UKR",
+	// "country code 804" and "country code of Ukraine"
+	countryCodePattern = regexp.MustCompile(`(?i)^country\s+code\s+(?:of\s+|for\s+)?(.+)$`)
+	// currencyPattern matches "currency of Japan"
+	currencyPattern = regexp.MustCompile(`(?i)^currency\s+(?:of|in|for)\s+(.+)$`)
+	// callingCodePattern matches "calling code +49" and "calling code of
+	// Germany"
+	callingCodePattern = regexp.MustCompile(`(?i)^(?:calling|dialing|dial|phone)\s+code\s+(?:of\s+|for\s+)?(.+)$`)
+	// timezonePattern matches "timezone of Portugal" and "time zones in
+	// Australia"
+	timezonePattern = regexp.MustCompile(`(?i)^time\s*zones?\s+(?:of|in|for)\s+(.+)$`)
+)
+
+// callingNumberPattern matches the number of a reverse calling code lookup:
+// "+49", "49", "00380"
+var callingNumberPattern = regexp.MustCompile(`^(?:\+|00)?(\d{1,4})$`)
+
+// nameFolder folds the accented letters of country names so "Cote d'Ivoire"
+// and "Curacao" find them
+var nameFolder = strings.NewReplacer("å", "a", "ç", "c", "é", "e", "ô", "o", "ü", "u", "’", "'")
+
+// byKey indexes the countries by lowercase name, alias, alpha-2 and alpha-3
+// code, and by numeric code
+var byKey = func() map[string]*Country {
+	m := make(map[string]*Country)
+	for i := range countries {
+		c := &countries[i]
+		m[foldName(c.Name)] = c
+		for _, alias := range c.Aliases {
+			m[alias] = c
+		}
+		m[strings.ToLower(c.Alpha2)] = c
+		m[strings.ToLower(c.Alpha3)] = c
+		m[strconv.Itoa(c.Numeric)] = c
+	}
+	return m
+}()
+
+// foldName lowercases a country name, folds its accents and drops a leading
+// "the": "The Gambia" -> "gambia"
+func foldName(name string) string {
+	name = nameFolder.Replace(strings.ToLower(strings.Join(strings.Fields(name), " ")))
+	return strings.TrimPrefix(name, "the ")
+}
+
+// FindCountry looks a country up by its name, one of its other names, its
+// alpha-2 or alpha-3 code or its numeric code, in any case
+func FindCountry(query string) (*Country, bool) {
+	query = strings.Trim(strings.TrimSpace(query), `"'`)
+	if n, err := strconv.Atoi(query); err == nil {
+		query = strconv.Itoa(n) // "076" is Brazil's 76
+	}
+	c, ok := byKey[foldName(query)]
+	return c, ok
+}
+
+// IsLookupExpression checks if an expression looks up a country's codes,
+// currency, calling code or time zones, or the countries of a calling code.
+// "country code" and "calling code" lines are claimed even when the country
+// is unknown, to report it; "currency of" and "timezone of" lines only for a
+// known country. "timezone of EST" is left to the date evaluator, as the
+// abbreviation rather than Estonia.
+func IsLookupExpression(expr string) bool {
+	expr = strings.TrimSpace(expr)
+	if countryCodePattern.MatchString(expr) || callingCodePattern.MatchString(expr) {
+		return true
+	}
+	if m := timezonePattern.FindStringSubmatch(expr); m != nil && datetime.IsTimezoneAbbreviation(m[1]) {
+		return false
+	}
+	for _, p := range []*regexp.Regexp{currencyPattern, timezonePattern} {
+		if m := p.FindStringSubmatch(expr); m != nil {
+			_, ok := FindCountry(m[1])
+			return ok
+		}
+	}
+	return false
+}
+
+// EvalLookup answers a country lookup:
+//
+//	country code UA      -> Ukraine (UA, UKR, 804), calling code +380, currency UAH (Ukrainian hryvnia)
+//	currency of Japan    -> JPY (Japanese yen)
+//	calling code +49     -> Germany (DE)
+//	calling code of UK   -> +44
+//	timezone of Portugal -> Europe/Lisbon (UTC+01:00), Atlantic/Madeira (UTC+01:00), Atlantic/Azores (UTC)
+func EvalLookup(expr string) (utils.Result, error) {
+	expr = strings.TrimSpace(expr)
+	switch {
+	case countryCodePattern.MatchString(expr):
+		c, err := findCountry(countryCodePattern.FindStringSubmatch(expr)[1])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		return utils.TextResult(fmt.Sprintf("%s (%s, %s, %03d), calling code +%s, currency %s",
+			c.Name, c.Alpha2, c.Alpha3, c.Numeric, c.CallingCode, currencyLabel(c.Currency))), nil
+
+	case callingCodePattern.MatchString(expr):
+		query := strings.TrimSpace(callingCodePattern.FindStringSubmatch(expr)[1])
+		if m := callingNumberPattern.FindStringSubmatch(query); m != nil {
+			return evalCallingCode(m[1])
+		}
+		c, err := findCountry(query)
+		if err != nil {
+			return utils.Result{}, err
+		}
+		return utils.TextResult("+" + c.CallingCode), nil
+
+	case currencyPattern.MatchString(expr):
+		c, err := findCountry(currencyPattern.FindStringSubmatch(expr)[1])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		return utils.TextResult(currencyLabel(c.Currency)), nil
+
+	case timezonePattern.MatchString(expr):
+		c, err := findCountry(timezonePattern.FindStringSubmatch(expr)[1])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		return evalTimezones(c, datetime.Now())
+	}
+	return utils.Result{}, fmt.Errorf("invalid lookup expression")
+}
+
+// findCountry looks a country up, or explains that there is none
+func findCountry(query string) (*Country, error) {
+	c, ok := FindCountry(query)
+	if !ok {
+		return nil, fmt.Errorf("unknown country %q", strings.TrimSpace(query))
+	}
+	return c, nil
+}
+
+// currencyLabel is a currency code with its name: "UAH (Ukrainian hryvnia)"
+func currencyLabel(code string) string {
+	if name, ok := currencyNames[code]; ok {
+		return code + " (" + name + ")"
+	}
+	return code
+}
+
+// evalCallingCode lists the countries that share a calling code, by name
+// with their alpha-2 code: "+7" is "Kazakhstan (KZ), Russia (RU)"
+func evalCallingCode(code string) (utils.Result, error) {
+	code = strings.TrimLeft(code, "0")
+	var names []string
+	for _, c := range countries {
+		if c.CallingCode == code {
+			names = append(names, fmt.Sprintf("%s (%s)", c.Name, c.Alpha2))
+		}
+	}
+	if len(names) == 0 {
+		return utils.Result{}, fmt.Errorf("no country has calling code +%s", code)
+	}
+	return utils.TextResult(strings.Join(names, ", ")), nil
+}
+
+// evalTimezones lists a country's time zones, the capital's first, with
+// their UTC offsets at now. A zone missing from the system's tz database is
+// left out.
+func evalTimezones(c *Country, now time.Time) (utils.Result, error) {
+	var zones []string
+	for _, name := range c.Zones {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			continue
+		}
+		zones = append(zones, fmt.Sprintf("%s (%s)", name, datetime.UTCOffset(now.In(loc))))
+	}
+	if len(zones) == 0 {
+		return utils.Result{}, fmt.Errorf("no time zone data for %s", c.Name)
+	}
+	return utils.TextResult(strings.Join(zones, ", ")), nil
+}
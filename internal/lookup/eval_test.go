@@ -0,0 +1,119 @@
+package lookup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsLookupExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"country code UA", true},
+		{"country code of Ukraine", true},
+		{"Country Code 804", true},
+		{"country code XX", true}, // reported as unknown
+		{"currency of Japan", true},
+		{"calling code +49", true},
+		{"calling code of Germany", true},
+		{"dialing code for uk", true},
+		{"timezone of Portugal", true},
+		{"time zones in Australia", true},
+
+		// Variables and other evaluators' lines are left alone
+		{"ua", false},
+		{"de * 2", false},
+		{"currency of money", false},
+		{"timezone of Tokyo", false},
+		{"timezone of EST", false}, // the abbreviation, not Estonia
+		{"100 usd in eur", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsLookupExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsLookupExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalLookup(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"country code UA", "Ukraine (UA, UKR, 804), calling code +380, currency UAH (Ukrainian hryvnia)"},
+		{"country code ukr", "Ukraine (UA, UKR, 804), calling code +380, currency UAH (Ukrainian hryvnia)"},
+		{"country code 076", "Brazil (BR, BRA, 076), calling code +55, currency BRL (Brazilian real)"},
+		{"country code of Ivory Coast", "Côte d'Ivoire (CI, CIV, 384), calling code +225, currency XOF (West African CFA franc)"},
+		{"country code of the Netherlands", "Netherlands (NL, NLD, 528), calling code +31, currency EUR (euro)"},
+		{"currency of Japan", "JPY (Japanese yen)"},
+		{"currency of Curacao", "XCG (Caribbean guilder)"},
+		{"calling code +49", "Germany (DE)"},
+		{"calling code 0044", "Guernsey (GG), Isle of Man (IM), Jersey (JE), United Kingdom (GB)"},
+		{"calling code +7", "Kazakhstan (KZ), Russia (RU)"},
+		{"calling code of Germany", "+49"},
+		{"phone code for USA", "+1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalLookup(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalLookup(%q) error: %v", tt.expr, err)
+			}
+			if result.Text != tt.expected {
+				t.Errorf("EvalLookup(%q) = %q, want %q", tt.expr, result.Text, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalLookupErrors(t *testing.T) {
+	for _, expr := range []string{"country code XX", "country code of Atlantis", "calling code +999"} {
+		if _, err := EvalLookup(expr); err == nil {
+			t.Errorf("EvalLookup(%q) should fail", expr)
+		}
+	}
+}
+
+func TestEvalTimezones(t *testing.T) {
+	portugal, _ := FindCountry("Portugal")
+	tests := []struct {
+		now      time.Time
+		expected string
+	}{
+		{time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC), "Europe/Lisbon (UTC+01:00), Atlantic/Madeira (UTC+01:00), Atlantic/Azores (UTC)"},
+		{time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC), "Europe/Lisbon (UTC), Atlantic/Madeira (UTC), Atlantic/Azores (UTC-01:00)"},
+	}
+
+	for _, tt := range tests {
+		result, err := evalTimezones(portugal, tt.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Text != tt.expected {
+			t.Errorf("evalTimezones(Portugal, %s) = %q, want %q", tt.now.Format("2006-01-02"), result.Text, tt.expected)
+		}
+	}
+}
+
+func TestCountryData(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, c := range countries {
+		for _, code := range []string{c.Alpha2, c.Alpha3} {
+			if seen[code] {
+				t.Errorf("code %s is used twice", code)
+			}
+			seen[code] = true
+		}
+		if _, ok := currencyNames[c.Currency]; !ok {
+			t.Errorf("%s: currency %s has no name", c.Name, c.Currency)
+		}
+		if len(c.Zones) == 0 {
+			t.Errorf("%s has no time zone", c.Name)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package lookup
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// Unknown countries and calling codes are reported instead of left to
+	// the evaluators after, which would show a bare ERR
+	registry.Register(registry.Evaluator{
+		Name:     "lookup",
+		Priority: registry.PriorityLookup,
+		Traits:   registry.NoFormat | registry.ReportsErrors,
+		Detect:   IsLookupExpression,
+		Eval:     EvalLookup,
+	})
+}
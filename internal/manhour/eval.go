@@ -5,6 +5,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"smartcalc/internal/utils"
 )
 
 const (
@@ -80,13 +82,13 @@ func EvalManHour(expr string) (string, error) {
 		switch {
 		case strings.HasPrefix(targetUnit, "month"):
 			result = hoursPerPerson / HoursPerBusinessMonth
-			unitLabel = pluralize(result, "business month", "business months")
+			unitLabel = utils.Plural(result, "business month", "business months")
 		case strings.HasPrefix(targetUnit, "week"):
 			result = hoursPerPerson / HoursPerBusinessWeek
-			unitLabel = pluralize(result, "business week", "business weeks")
+			unitLabel = utils.Plural(result, "business week", "business weeks")
 		case strings.HasPrefix(targetUnit, "day"):
 			result = hoursPerPerson / HoursPerBusinessDay
-			unitLabel = pluralize(result, "business day", "business days")
+			unitLabel = utils.Plural(result, "business day", "business days")
 		default:
 			return "", fmt.Errorf("unknown target unit: %s", targetUnit)
 		}
@@ -94,13 +96,13 @@ func EvalManHour(expr string) (string, error) {
 		switch {
 		case strings.HasPrefix(targetUnit, "month"):
 			result = hoursPerPerson / HoursPerCalendarMonth
-			unitLabel = pluralize(result, "month", "months")
+			unitLabel = utils.Plural(result, "month", "months")
 		case strings.HasPrefix(targetUnit, "week"):
 			result = hoursPerPerson / HoursPerCalendarWeek
-			unitLabel = pluralize(result, "week", "weeks")
+			unitLabel = utils.Plural(result, "week", "weeks")
 		case strings.HasPrefix(targetUnit, "day"):
 			result = hoursPerPerson / HoursPerCalendarDay
-			unitLabel = pluralize(result, "day", "days")
+			unitLabel = utils.Plural(result, "day", "days")
 		default:
 			return "", fmt.Errorf("unknown target unit: %s", targetUnit)
 		}
@@ -112,11 +114,3 @@ func EvalManHour(expr string) (string, error) {
 	}
 	return fmt.Sprintf("%.2f %s", result, unitLabel), nil
 }
-
-// pluralize returns singular or plural form based on value
-func pluralize(value float64, singular, plural string) string {
-	if value == 1 {
-		return singular
-	}
-	return plural
-}
@@ -0,0 +1,12 @@
+package manhour
+
+import "smartcalc/internal/registry"
+
+func init() {
+	registry.Register(registry.Evaluator{
+		Name:     "manhour",
+		Priority: registry.PriorityManHour,
+		Detect:   IsManHourExpression,
+		Eval:     registry.TextEval(EvalManHour),
+	})
+}
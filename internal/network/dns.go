@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/miekg/dns"
@@ -19,16 +21,65 @@ var dohServers = []string{
 	"https://dns.quad9.net/dns-query",
 }
 
+// dnsTimeout bounds each DNS-over-HTTPS request
+const dnsTimeout = 2 * time.Second
+
+// Resolver answers DNS queries. The default resolver uses DNS-over-HTTPS.
+type Resolver interface {
+	Query(name string, qtype uint16) (*dns.Msg, error)
+}
+
+// ResolverFunc is an adapter to allow ordinary functions to be used as Resolvers.
+type ResolverFunc func(name string, qtype uint16) (*dns.Msg, error)
+
+// Query calls the underlying function.
+func (f ResolverFunc) Query(name string, qtype uint16) (*dns.Msg, error) {
+	return f(name, qtype)
+}
+
+var (
+	resolverMu sync.RWMutex
+	resolver   Resolver = ResolverFunc(queryDoH)
+)
+
+// SetResolver replaces the DNS resolver (used by tests to avoid the network).
+// nil restores the default DNS-over-HTTPS resolver.
+func SetResolver(r Resolver) {
+	resolverMu.Lock()
+	defer resolverMu.Unlock()
+	if r == nil {
+		r = ResolverFunc(queryDoH)
+	}
+	resolver = r
+}
+
+// recordTypes maps the record types that can be queried on their own to DNS types
+var recordTypes = map[string]uint16{
+	"A":     dns.TypeA,
+	"AAAA":  dns.TypeAAAA,
+	"MX":    dns.TypeMX,
+	"TXT":   dns.TypeTXT,
+	"NS":    dns.TypeNS,
+	"CNAME": dns.TypeCNAME,
+}
+
+// typedLookupPattern matches a lookup of a single record type: "dns MX example.com"
+var typedLookupPattern = regexp.MustCompile(`(?i)^(?:dig|nslookup|dns|lookup|resolve)\s+(a|aaaa|mx|txt|ns|cname)\s+(\S+)$`)
+
+// reverseLookupPattern matches PTR lookups: "reverse dns 8.8.8.8", "ptr 8.8.8.8"
+var reverseLookupPattern = regexp.MustCompile(`(?i)^(?:reverse\s+dns|rdns|ptr)\s+(\S+)$`)
+
 // IsDNSExpression checks if an expression is a DNS lookup expression
 func IsDNSExpression(expr string) bool {
 	exprLower := strings.ToLower(strings.TrimSpace(expr))
 
 	patterns := []string{
-		`^dig\s+`,      // dig <domain>
-		`^nslookup\s+`, // nslookup <domain>
-		`^dns\s+`,      // dns <domain>
-		`^lookup\s+`,   // lookup <domain>
-		`^resolve\s+`,  // resolve <domain>
+		`^dig\s+`,                        // dig <domain>, dig <type> <domain>
+		`^nslookup\s+`,                   // nslookup <domain>
+		`^dns\s+`,                        // dns <domain>, dns <type> <domain>
+		`^lookup\s+`,                     // lookup <domain>
+		`^resolve\s+`,                    // resolve <domain>
+		`^(?:reverse\s+dns|rdns|ptr)\s+`, // reverse dns <ip>, ptr <ip>
 	}
 
 	for _, pattern := range patterns {
@@ -45,6 +96,14 @@ func EvalDNS(expr string) (string, error) {
 	expr = strings.TrimSpace(expr)
 	exprLower := strings.ToLower(expr)
 
+	if m := reverseLookupPattern.FindStringSubmatch(expr); m != nil {
+		return lookupPTR(strings.Trim(m[1], "\"'"))
+	}
+	if m := typedLookupPattern.FindStringSubmatch(expr); m != nil {
+		domain := strings.TrimSuffix(strings.Trim(m[2], "\"'"), ".")
+		return lookupRecordType(domain, strings.ToUpper(m[1]))
+	}
+
 	var domain string
 
 	// Extract domain from different formats
@@ -76,8 +135,16 @@ func EvalDNS(expr string) (string, error) {
 	return lookupDomain(domain)
 }
 
-// queryDNS sends a DNS query using DNS-over-HTTPS to bypass network interception
+// queryDNS sends a DNS query through the current resolver
 func queryDNS(domain string, qtype uint16) (*dns.Msg, error) {
+	resolverMu.RLock()
+	r := resolver
+	resolverMu.RUnlock()
+	return r.Query(domain, qtype)
+}
+
+// queryDoH sends a DNS query using DNS-over-HTTPS to bypass network interception
+func queryDoH(domain string, qtype uint16) (*dns.Msg, error) {
 	m := new(dns.Msg)
 	m.SetQuestion(dns.Fqdn(domain), qtype)
 	m.RecursionDesired = true
@@ -88,7 +155,7 @@ func queryDNS(domain string, qtype uint16) (*dns.Msg, error) {
 		return nil, fmt.Errorf("failed to pack DNS message: %w", err)
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := httpClient(dnsTimeout)
 
 	var lastErr error
 	for _, server := range dohServers {
@@ -221,6 +288,86 @@ func lookupDomain(domain string) (string, error) {
 	return strings.TrimSuffix(output, "\n"), nil
 }
 
+// lookupRecordType queries a single record type, e.g. only the MX records of a domain
+func lookupRecordType(domain, recordType string) (string, error) {
+	if domain == "" {
+		return "", fmt.Errorf("no domain specified")
+	}
+	r, err := queryDNS(domain, recordTypes[recordType])
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("> DNS Lookup: %s (%s)\n", domain, recordType))
+	result.WriteString(fmt.Sprintf("> %s Records:", recordType))
+	found := false
+	for _, ans := range r.Answer {
+		record, ok := formatRecord(ans, recordType)
+		if !ok {
+			continue // e.g. the CNAME hops in front of the A records
+		}
+		result.WriteString(fmt.Sprintf("\n>   %s", record))
+		found = true
+	}
+	if !found {
+		return "", fmt.Errorf("no %s records", recordType)
+	}
+	return result.String(), nil
+}
+
+// formatRecord formats an answer of the requested type the same way the full lookup does
+func formatRecord(rr dns.RR, recordType string) (string, bool) {
+	switch rec := rr.(type) {
+	case *dns.A:
+		return rec.A.String(), recordType == "A"
+	case *dns.AAAA:
+		return rec.AAAA.String(), recordType == "AAAA"
+	case *dns.MX:
+		return fmt.Sprintf("%s (priority: %d)", strings.TrimSuffix(rec.Mx, "."), rec.Preference), recordType == "MX"
+	case *dns.NS:
+		return strings.TrimSuffix(rec.Ns, "."), recordType == "NS"
+	case *dns.CNAME:
+		return strings.TrimSuffix(rec.Target, "."), recordType == "CNAME"
+	case *dns.TXT:
+		txt := strings.Join(rec.Txt, "")
+		if len(txt) > 80 {
+			txt = txt[:77] + "..."
+		}
+		return fmt.Sprintf("\"%s\"", txt), recordType == "TXT"
+	}
+	return "", false
+}
+
+// lookupPTR performs a reverse DNS lookup of an IP address
+func lookupPTR(ip string) (string, error) {
+	if net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("invalid IP address: %s", ip)
+	}
+	arpa, err := dns.ReverseAddr(ip)
+	if err != nil {
+		return "", err
+	}
+	r, err := queryDNS(arpa, dns.TypePTR)
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("> Reverse DNS: %s\n> PTR Records:", ip))
+	found := false
+	for _, ans := range r.Answer {
+		if ptr, ok := ans.(*dns.PTR); ok {
+			result.WriteString(fmt.Sprintf("\n>   %s", strings.TrimSuffix(ptr.Ptr, ".")))
+			found = true
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("no PTR records")
+	}
+	return result.String(), nil
+}
+
 // lookupIPsPublicDNS queries A and AAAA records using public DNS
 func lookupIPsPublicDNS(domain string) (ipv4s, ipv6s []string) {
 	// Query A records
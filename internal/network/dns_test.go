@@ -1,8 +1,11 @@
 package network
 
 import (
+	"fmt"
 	"strings"
 	"testing"
+
+	"github.com/miekg/dns"
 )
 
 func TestIsDNSExpression(t *testing.T) {
@@ -17,6 +20,12 @@ func TestIsDNSExpression(t *testing.T) {
 		{"resolve google.com", true},
 		{"DIG GOOGLE.COM", true},
 		{"NSLOOKUP google.com", true},
+		{"dns MX example.com", true},
+		{"dig TXT example.com", true},
+		{"reverse dns 8.8.8.8", true},
+		{"ptr 8.8.8.8", true},
+		{`/\d+/ test "dns a example.com"`, false},
+		{`regex /ptr/ test "ptr 8.8.8.8"`, false},
 		{"dig", false},
 		{"google.com", false},
 		{"10.0.0.1/24", false},
@@ -105,3 +114,112 @@ func TestEvalDNS_MXRecords(t *testing.T) {
 		t.Error("Result for gmail.com should contain 'MX Records:'")
 	}
 }
+
+// fakeResolver answers from canned zone records instead of the network
+func fakeResolver(t *testing.T, records map[string][]string) Resolver {
+	return ResolverFunc(func(name string, qtype uint16) (*dns.Msg, error) {
+		key := dns.TypeToString[qtype] + " " + dns.Fqdn(name)
+		m := new(dns.Msg)
+		for _, rr := range records[key] {
+			parsed, err := dns.NewRR(rr)
+			if err != nil {
+				t.Fatalf("bad fake record %q: %v", rr, err)
+			}
+			m.Answer = append(m.Answer, parsed)
+		}
+		return m, nil
+	})
+}
+
+func TestEvalDNSRecordType(t *testing.T) {
+	SetResolver(fakeResolver(t, map[string][]string{
+		"A example.com.": {"example.com. 300 IN A 93.184.216.34"},
+		"MX example.com.": {
+			"example.com. 300 IN MX 10 mail.example.com.",
+			"example.com. 300 IN MX 20 backup.example.com.",
+		},
+		"TXT example.com.":  {`example.com. 300 IN TXT "v=spf1 -all"`},
+		"NS example.com.":   {"example.com. 300 IN NS a.iana-servers.net."},
+		"AAAA example.com.": {"example.com. 300 IN AAAA 2606:2800:220:1::1"},
+		"A www.example.com.": {
+			"www.example.com. 300 IN CNAME example.com.",
+			"example.com. 300 IN A 93.184.216.34",
+		},
+	}))
+	defer SetResolver(nil)
+
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"dns A example.com", "> DNS Lookup: example.com (A)\n> A Records:\n>   93.184.216.34"},
+		{"dns mx example.com", "> DNS Lookup: example.com (MX)\n> MX Records:\n>   mail.example.com (priority: 10)\n>   backup.example.com (priority: 20)"},
+		{"dig TXT example.com.", "> DNS Lookup: example.com (TXT)\n> TXT Records:\n>   \"v=spf1 -all\""},
+		{"dns NS example.com", "> DNS Lookup: example.com (NS)\n> NS Records:\n>   a.iana-servers.net"},
+		{"dns AAAA example.com", "> DNS Lookup: example.com (AAAA)\n> AAAA Records:\n>   2606:2800:220:1::1"},
+		{"dns A www.example.com", "> DNS Lookup: www.example.com (A)\n> A Records:\n>   93.184.216.34"}, // CNAME hop skipped
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalDNS(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalDNS(%q) error: %v", tt.expr, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvalDNS(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalDNSNoRecords(t *testing.T) {
+	SetResolver(fakeResolver(t, nil))
+	defer SetResolver(nil)
+
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"dns MX example.com", "no MX records"},
+		{"dns TXT example.com", "no TXT records"},
+		{"ptr 10.0.0.1", "no PTR records"},
+		{"reverse dns not-an-ip", "invalid IP address: not-an-ip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := EvalDNS(tt.expr)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("EvalDNS(%q) error = %v, want %q", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEvalDNSResolverError(t *testing.T) {
+	SetResolver(ResolverFunc(func(name string, qtype uint16) (*dns.Msg, error) {
+		return nil, fmt.Errorf("timeout")
+	}))
+	defer SetResolver(nil)
+
+	if _, err := EvalDNS("dns A example.com"); err == nil || err.Error() != "timeout" {
+		t.Errorf("EvalDNS error = %v, want timeout", err)
+	}
+}
+
+func TestEvalReverseDNS(t *testing.T) {
+	SetResolver(fakeResolver(t, map[string][]string{
+		"PTR 8.8.8.8.in-addr.arpa.": {"8.8.8.8.in-addr.arpa. 300 IN PTR dns.google."},
+	}))
+	defer SetResolver(nil)
+
+	want := "> Reverse DNS: 8.8.8.8\n> PTR Records:\n>   dns.google"
+	for _, expr := range []string{"reverse dns 8.8.8.8", "ptr 8.8.8.8", "rdns 8.8.8.8"} {
+		result, err := EvalDNS(expr)
+		if err != nil {
+			t.Fatalf("EvalDNS(%q) error: %v", expr, err)
+		}
+		if result != want {
+			t.Errorf("EvalDNS(%q) = %q, want %q", expr, result, want)
+		}
+	}
+}
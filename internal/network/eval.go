@@ -5,20 +5,32 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"smartcalc/internal/utils"
 )
 
 // Handler defines the interface for network expression handlers.
 // Each handler attempts to process an expression and returns the result
 // along with a boolean indicating whether it handled the expression.
 type Handler interface {
-	Handle(expr, exprLower string) (string, bool)
+	Handle(expr, exprLower string) (utils.Result, bool)
 }
 
 // HandlerFunc is an adapter to allow ordinary functions to be used as Handlers.
 type HandlerFunc func(expr, exprLower string) (string, bool)
 
+// Handle calls the underlying function; its output has no referenceable value.
+func (f HandlerFunc) Handle(expr, exprLower string) (utils.Result, bool) {
+	text, ok := f(expr, exprLower)
+	return utils.TextResult(text), ok
+}
+
+// ValueHandlerFunc is an adapter for handlers whose result has a primary
+// numeric value that later lines can reference.
+type ValueHandlerFunc func(expr, exprLower string) (utils.Result, bool)
+
 // Handle calls the underlying function.
-func (f HandlerFunc) Handle(expr, exprLower string) (string, bool) {
+func (f ValueHandlerFunc) Handle(expr, exprLower string) (utils.Result, bool) {
 	return f(expr, exprLower)
 }
 
@@ -27,8 +39,8 @@ func (f HandlerFunc) Handle(expr, exprLower string) (string, bool) {
 var handlerChain = []Handler{
 	HandlerFunc(handleDivideToSubnets),
 	HandlerFunc(handleDivideByHosts),
-	HandlerFunc(handleHostCount),
-	HandlerFunc(handleSubnetInfo),
+	ValueHandlerFunc(handleHostCount),
+	ValueHandlerFunc(handleSubnetInfo),
 	HandlerFunc(handleWildcardMask), // must be before handleMaskForPrefix
 	HandlerFunc(handleMaskForPrefix),
 	HandlerFunc(handlePrefixFromMask),
@@ -36,12 +48,20 @@ var handlerChain = []Handler{
 	HandlerFunc(handleNextSubnet),
 	HandlerFunc(handleBroadcast),
 	HandlerFunc(handleNetworkAddress),
-	HandlerFunc(handleJustCIDR),
+	ValueHandlerFunc(handleJustCIDR),
 }
 
 // EvalNetwork evaluates a network/IP expression and returns the result.
 // It uses the Chain of Responsibility pattern to delegate to handlers.
 func EvalNetwork(expr string) (string, error) {
+	result, err := EvalNetworkResult(expr)
+	return result.Text, err
+}
+
+// EvalNetworkResult evaluates a network/IP expression and also reports its
+// referenceable value: the usable host count for host count and subnet info
+// expressions. Other network results have no value.
+func EvalNetworkResult(expr string) (utils.Result, error) {
 	expr = strings.TrimSpace(expr)
 	exprLower := strings.ToLower(expr)
 
@@ -51,7 +71,7 @@ func EvalNetwork(expr string) (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("unable to evaluate network expression: %s", expr)
+	return utils.Result{}, fmt.Errorf("unable to evaluate network expression: %s", expr)
 }
 
 // IsNetworkExpression checks if an expression looks like a network/IP expression
@@ -140,7 +160,7 @@ func handleDivideByHosts(expr, exprLower string) (string, bool) {
 	return FormatSubnetList(subnets), true
 }
 
-func handleHostCount(expr, exprLower string) (string, bool) {
+func handleHostCount(expr, exprLower string) (utils.Result, bool) {
 	// Pattern: "how many hosts in 10.100.0.0/28" or "hosts in 10.100.0.0/28" or "host count 10.100.0.0/28"
 	re := regexp.MustCompile(`(?:how\s+many\s+)?hosts?\s+(?:in|for|count)?\s*(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}/\d{1,2})`)
 	matches := re.FindStringSubmatch(exprLower)
@@ -149,38 +169,39 @@ func handleHostCount(expr, exprLower string) (string, bool) {
 		re = regexp.MustCompile(`(?:how\s+many\s+)?hosts?\s+(?:in|for)?\s*/(\d{1,2})`)
 		matches = re.FindStringSubmatch(exprLower)
 		if matches == nil {
-			return "", false
+			return utils.Result{}, false
 		}
 		prefix, _ := strconv.Atoi(matches[1])
 		hosts := HostsInPrefix(prefix)
-		return fmt.Sprintf("%d hosts", hosts), true
+		return utils.ValueResult(fmt.Sprintf("%d %s", hosts, utils.Plural(float64(hosts), "host", "hosts")), float64(hosts), false), true
 	}
 
 	cidr := matches[1]
 	info, err := ParseCIDR(cidr)
 	if err != nil {
-		return fmt.Sprintf("Error: %s", err), true
+		return utils.TextResult(fmt.Sprintf("Error: %s", err)), true
 	}
 
-	return fmt.Sprintf("%d hosts", info.HostCount), true
+	return utils.ValueResult(fmt.Sprintf("%d %s", info.HostCount, utils.Plural(float64(info.HostCount), "host", "hosts")), float64(info.HostCount), false), true
 }
 
-func handleSubnetInfo(expr, exprLower string) (string, bool) {
+func handleSubnetInfo(expr, exprLower string) (utils.Result, bool) {
 	// Pattern: "subnet info 10.100.0.0/24" or "info for 10.100.0.0/24"
 	re := regexp.MustCompile(`(?:subnet\s+)?info\s+(?:for\s+)?(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}/\d{1,2})`)
 	matches := re.FindStringSubmatch(exprLower)
 	if matches == nil {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	cidr := matches[1]
 	info, err := ParseCIDR(cidr)
 	if err != nil {
-		return fmt.Sprintf("Error: %s", err), true
+		return utils.TextResult(fmt.Sprintf("Error: %s", err)), true
 	}
 
-	return fmt.Sprintf("\n> Network: %s/%d\n> Mask: %s\n> Hosts: %d\n> Range: %s - %s\n> Broadcast: %s",
-		info.NetworkAddr, info.CIDR, info.Mask, info.HostCount, info.FirstHost, info.LastHost, info.Broadcast), true
+	text := fmt.Sprintf("\n> Network: %s/%d\n> Mask: %s\n> Hosts: %d\n> Range: %s - %s\n> Broadcast: %s",
+		info.NetworkAddr, info.CIDR, info.Mask, info.HostCount, info.FirstHost, info.LastHost, info.Broadcast)
+	return utils.ValueResult(text, float64(info.HostCount), false), true
 }
 
 func handleMaskForPrefix(expr, exprLower string) (string, bool) {
@@ -307,19 +328,19 @@ func handleNetworkAddress(expr, exprLower string) (string, bool) {
 	return fmt.Sprintf("%s/%d", info.NetworkAddr, info.CIDR), true
 }
 
-func handleJustCIDR(expr, exprLower string) (string, bool) {
+func handleJustCIDR(expr, exprLower string) (utils.Result, bool) {
 	// Just a CIDR notation - return basic info
 	re := regexp.MustCompile(`^(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}/\d{1,2})$`)
 	matches := re.FindStringSubmatch(strings.TrimSpace(expr))
 	if matches == nil {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	cidr := matches[1]
 	info, err := ParseCIDR(cidr)
 	if err != nil {
-		return fmt.Sprintf("Error: %s", err), true
+		return utils.TextResult(fmt.Sprintf("Error: %s", err)), true
 	}
 
-	return FormatSubnetInfo(info), true
+	return utils.ValueResult(FormatSubnetInfo(info), float64(info.HostCount), false), true
 }
@@ -102,6 +102,31 @@ func TestEvalSubnetInfo(t *testing.T) {
 	}
 }
 
+func TestEvalNetworkResultValue(t *testing.T) {
+	tests := []struct {
+		expr     string
+		value    float64
+		hasValue bool
+	}{
+		{"hosts in 10.100.0.0/28", 14, true},
+		{"subnet info 10.100.0.0/24", 254, true},
+		{"10.0.0.0/30", 2, true},
+		{"mask for /24", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalNetworkResult(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalNetworkResult(%q) error: %v", tt.expr, err)
+			}
+			if result.HasValue != tt.hasValue || result.Value != tt.value {
+				t.Errorf("EvalNetworkResult(%q) = (%v, %v), want (%v, %v)", tt.expr, result.Value, result.HasValue, tt.value, tt.hasValue)
+			}
+		})
+	}
+}
+
 func TestEvalMaskForPrefix(t *testing.T) {
 	tests := []struct {
 		expr     string
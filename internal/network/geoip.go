@@ -7,9 +7,17 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
+// geoipTimeout bounds each lookup, so a slow service doesn't hold up a bulk
+// lookup
+const geoipTimeout = 4 * time.Second
+
+// maxBulkGeoIP is the most addresses one line looks up
+const maxBulkGeoIP = 20
+
 // GeoIPResponse represents the response from ip-api.com
 type GeoIPResponse struct {
 	Status      string  `json:"status"`
@@ -29,9 +37,49 @@ type GeoIPResponse struct {
 	Query       string  `json:"query"`
 }
 
+// GeoIPProvider looks up where an IP address is. The default provider
+// queries ip-api.com.
+type GeoIPProvider interface {
+	Lookup(ip string) (*GeoIPResponse, error)
+}
+
+// GeoIPProviderFunc is an adapter to allow ordinary functions to be used as GeoIPProviders.
+type GeoIPProviderFunc func(ip string) (*GeoIPResponse, error)
+
+// Lookup calls the underlying function.
+func (f GeoIPProviderFunc) Lookup(ip string) (*GeoIPResponse, error) {
+	return f(ip)
+}
+
+var (
+	geoipMu       sync.RWMutex
+	geoipProvider GeoIPProvider = GeoIPProviderFunc(lookupIP)
+	// geoipCache keeps the locations looked up this session, by IP address
+	geoipCache = make(map[string]*GeoIPResponse)
+)
+
+// SetGeoIPProvider replaces the GeoIP provider (used by tests to avoid the
+// network) and forgets the locations looked up so far. nil restores the
+// default ip-api.com provider.
+func SetGeoIPProvider(p GeoIPProvider) {
+	geoipMu.Lock()
+	defer geoipMu.Unlock()
+	if p == nil {
+		p = GeoIPProviderFunc(lookupIP)
+	}
+	geoipProvider = p
+	clear(geoipCache)
+}
+
+// bulkGeoIPPattern matches "geoip 8.8.8.8, 1.1.1.1, 9.9.9.9"
+var bulkGeoIPPattern = regexp.MustCompile(`(?i)^geoip\s+([0-9a-f.:]+(?:\s*,\s*[0-9a-f.:]+)+)$`)
+
 // IsGeoIPExpression checks if an expression is a geoip lookup
 func IsGeoIPExpression(expr string) bool {
 	expr = strings.TrimSpace(strings.ToLower(expr))
+	if bulkGeoIPPattern.MatchString(expr) {
+		return true
+	}
 	patterns := []string{
 		`^geoip\s+\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}$`,
 		`^geoip\s+[a-f0-9:]+$`, // IPv6
@@ -50,30 +98,75 @@ func IsGeoIPExpression(expr string) bool {
 	return false
 }
 
-// EvalGeoIP evaluates a geoip expression and returns location info
+// EvalGeoIP evaluates a geoip expression and returns location info: a block
+// of "> " lines for one address, or one line per address for a list of them
+// ("geoip 8.8.8.8, 1.1.1.1"), which are looked up concurrently
 func EvalGeoIP(expr string) (string, error) {
+	if m := bulkGeoIPPattern.FindStringSubmatch(strings.TrimSpace(expr)); m != nil {
+		return evalBulkGeoIP(strings.Split(m[1], ","))
+	}
 	ip := extractIP(expr)
 	if ip == "" {
 		return "", fmt.Errorf("no valid IP address found")
 	}
+	result, err := geolocate(ip)
+	if err != nil {
+		return "", err
+	}
+	return formatGeoIPResult(result), nil
+}
 
-	// Validate IP address
-	if net.ParseIP(ip) == nil {
-		return "", fmt.Errorf("invalid IP address: %s", ip)
+// evalBulkGeoIP looks up several addresses at once. An address that fails
+// shows its error on its own line.
+func evalBulkGeoIP(ips []string) (string, error) {
+	if len(ips) > maxBulkGeoIP {
+		return "", fmt.Errorf("at most %d addresses can be looked up at once", maxBulkGeoIP)
 	}
+	lines := make([]string, len(ips))
+	var wg sync.WaitGroup
+	for i, ip := range ips {
+		ip = strings.TrimSpace(ip)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := geolocate(ip)
+			if err != nil {
+				lines[i] = fmt.Sprintf("\n> %s: ERR: %v", ip, err)
+				return
+			}
+			lines[i] = fmt.Sprintf("\n> %s: %s", ip, geoIPSummary(result))
+		}()
+	}
+	wg.Wait()
+	return strings.Join(lines, ""), nil
+}
 
-	// Check for private/reserved IPs
+// geolocate validates an address and looks it up, or returns the location
+// found earlier this session
+func geolocate(ip string) (*GeoIPResponse, error) {
+	if net.ParseIP(ip) == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
 	if isPrivateIP(ip) {
-		return "", fmt.Errorf("cannot geolocate private IP address: %s", ip)
+		return nil, fmt.Errorf("cannot geolocate private IP address: %s", ip)
 	}
 
-	// Query ip-api.com
-	result, err := lookupIP(ip)
-	if err != nil {
-		return "", err
+	geoipMu.RLock()
+	cached, ok := geoipCache[ip]
+	provider := geoipProvider
+	geoipMu.RUnlock()
+	if ok {
+		return cached, nil
 	}
 
-	return formatGeoIPResult(result), nil
+	result, err := provider.Lookup(ip)
+	if err != nil {
+		return nil, err
+	}
+	geoipMu.Lock()
+	geoipCache[ip] = result
+	geoipMu.Unlock()
+	return result, nil
 }
 
 // extractIP extracts the IP address from the expression
@@ -129,9 +222,7 @@ func isPrivateIP(ipStr string) bool {
 func lookupIP(ip string) (*GeoIPResponse, error) {
 	url := fmt.Sprintf("http://ip-api.com/json/%s", ip)
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
+	client := httpClient(geoipTimeout)
 
 	resp, err := client.Get(url)
 	if err != nil {
@@ -159,30 +250,60 @@ func lookupIP(ip string) (*GeoIPResponse, error) {
 func formatGeoIPResult(r *GeoIPResponse) string {
 	var sb strings.Builder
 
-	// Location line
-	location := r.City
-	if r.RegionName != "" && r.RegionName != r.City {
-		location += ", " + r.RegionName
-	}
 	if r.Country != "" {
-		location += ", " + r.Country
+		country := r.Country
+		if r.CountryCode != "" {
+			country += " (" + r.CountryCode + ")"
+		}
+		sb.WriteString(fmt.Sprintf("\n> Country: %s", country))
+	}
+	if r.RegionName != "" {
+		sb.WriteString(fmt.Sprintf("\n> Region: %s", r.RegionName))
+	}
+	if r.City != "" {
+		sb.WriteString(fmt.Sprintf("\n> City: %s", r.City))
 	}
-	sb.WriteString(fmt.Sprintf("\n> Location: %s", location))
 
-	// ISP/Org
-	if r.ISP != "" {
-		sb.WriteString(fmt.Sprintf("\n> ISP: %s", r.ISP))
-	} else if r.Org != "" {
+	// Network: "AS15169 Google LLC" is the ASN and the name it is registered to
+	if asn, name, _ := strings.Cut(r.AS, " "); asn != "" {
+		if name != "" {
+			asn += " (" + name + ")"
+		}
+		sb.WriteString(fmt.Sprintf("\n> ASN: %s", asn))
+	}
+	if r.Org != "" {
 		sb.WriteString(fmt.Sprintf("\n> Org: %s", r.Org))
 	}
+	if r.ISP != "" && r.ISP != r.Org {
+		sb.WriteString(fmt.Sprintf("\n> ISP: %s", r.ISP))
+	}
 
-	// Coordinates
 	sb.WriteString(fmt.Sprintf("\n> Coords: %.4f, %.4f", r.Lat, r.Lon))
-
-	// Timezone
 	if r.Timezone != "" {
 		sb.WriteString(fmt.Sprintf("\n> Timezone: %s", r.Timezone))
 	}
+	sb.WriteString("\n> Map: " + geoIPMapURL(r))
 
 	return sb.String()
 }
+
+// geoIPSummary describes a location on one line, for bulk lookups:
+// "Mountain View, California, United States (AS15169 Google LLC)"
+func geoIPSummary(r *GeoIPResponse) string {
+	var parts []string
+	for _, part := range []string{r.City, r.RegionName, r.Country} {
+		if part != "" && (len(parts) == 0 || parts[len(parts)-1] != part) {
+			parts = append(parts, part)
+		}
+	}
+	summary := strings.Join(parts, ", ")
+	if r.AS != "" {
+		summary += " (" + r.AS + ")"
+	}
+	return summary
+}
+
+// geoIPMapURL links to the location on OpenStreetMap
+func geoIPMapURL(r *GeoIPResponse) string {
+	return fmt.Sprintf("https://www.openstreetmap.org/?mlat=%.4f&mlon=%.4f#map=10/%.4f/%.4f", r.Lat, r.Lon, r.Lat, r.Lon)
+}
@@ -1,7 +1,9 @@
 package network
 
 import (
+	"fmt"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -18,6 +20,8 @@ func TestIsGeoIPExpression(t *testing.T) {
 		{"locate ip 8.8.8.8", true},
 		{"where is 8.8.8.8", true},
 		{"geoip 2001:4860:4860::8888", true},
+		{"geoip 8.8.8.8, 1.1.1.1, 9.9.9.9", true},
+		{"geoip 8.8.8.8,1.1.1.1", true},
 
 		// Invalid expressions
 		{"hello world", false},
@@ -130,3 +134,87 @@ func TestFormatGeoIPResult(t *testing.T) {
 		t.Error("Expected timezone in output")
 	}
 }
+
+// stubGeoIP answers lookups from a table and counts them
+type stubGeoIP struct {
+	mu        sync.Mutex
+	calls     map[string]int
+	locations map[string]*GeoIPResponse
+}
+
+func (s *stubGeoIP) Lookup(ip string) (*GeoIPResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[ip]++
+	if r, ok := s.locations[ip]; ok {
+		return r, nil
+	}
+	return nil, fmt.Errorf("geoip lookup failed: reserved range")
+}
+
+func newStubGeoIP() *stubGeoIP {
+	return &stubGeoIP{
+		calls: make(map[string]int),
+		locations: map[string]*GeoIPResponse{
+			"8.8.8.8": {Country: "United States", CountryCode: "US", RegionName: "Virginia", City: "Ashburn",
+				AS: "AS15169 Google LLC", Org: "Google Public DNS", ISP: "Google LLC", Lat: 39.03, Lon: -77.5, Timezone: "America/New_York"},
+			"1.1.1.1": {Country: "Australia", CountryCode: "AU", RegionName: "Queensland", City: "South Brisbane",
+				AS: "AS13335 Cloudflare, Inc.", Org: "APNIC and Cloudflare DNS Resolver project", ISP: "Cloudflare, Inc", Lat: -27.4766, Lon: 153.0166},
+		},
+	}
+}
+
+func TestEvalGeoIPWithProvider(t *testing.T) {
+	stub := newStubGeoIP()
+	SetGeoIPProvider(stub)
+	defer SetGeoIPProvider(nil)
+
+	want := "\n> Country: United States (US)" +
+		"\n> Region: Virginia" +
+		"\n> City: Ashburn" +
+		"\n> ASN: AS15169 (Google LLC)" +
+		"\n> Org: Google Public DNS" +
+		"\n> ISP: Google LLC" +
+		"\n> Coords: 39.0300, -77.5000" +
+		"\n> Timezone: America/New_York" +
+		"\n> Map: https://www.openstreetmap.org/?mlat=39.0300&mlon=-77.5000#map=10/39.0300/-77.5000"
+	for range 2 {
+		got, err := EvalGeoIP("geoip 8.8.8.8")
+		if err != nil {
+			t.Fatalf("EvalGeoIP() error: %v", err)
+		}
+		if got != want {
+			t.Errorf("EvalGeoIP() = %q, want %q", got, want)
+		}
+	}
+	// The second lookup of an address comes from the session cache
+	if stub.calls["8.8.8.8"] != 1 {
+		t.Errorf("provider called %d times for 8.8.8.8, want 1", stub.calls["8.8.8.8"])
+	}
+}
+
+func TestEvalGeoIPBulk(t *testing.T) {
+	stub := newStubGeoIP()
+	SetGeoIPProvider(stub)
+	defer SetGeoIPProvider(nil)
+
+	got, err := EvalGeoIP("geoip 8.8.8.8, 1.1.1.1, 192.168.1.1, 9.9.9.9")
+	if err != nil {
+		t.Fatalf("EvalGeoIP() error: %v", err)
+	}
+	want := "\n> 8.8.8.8: Ashburn, Virginia, United States (AS15169 Google LLC)" +
+		"\n> 1.1.1.1: South Brisbane, Queensland, Australia (AS13335 Cloudflare, Inc.)" +
+		"\n> 192.168.1.1: ERR: cannot geolocate private IP address: 192.168.1.1" +
+		"\n> 9.9.9.9: ERR: geoip lookup failed: reserved range"
+	if got != want {
+		t.Errorf("EvalGeoIP() = %q, want %q", got, want)
+	}
+	if stub.calls["192.168.1.1"] != 0 {
+		t.Error("private address should not be looked up")
+	}
+
+	many := "geoip " + strings.TrimSuffix(strings.Repeat("8.8.8.8, ", maxBulkGeoIP+1), ", ")
+	if _, err := EvalGeoIP(many); err == nil {
+		t.Errorf("EvalGeoIP() of %d addresses should fail", maxBulkGeoIP+1)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"math"
 	"net"
 	"strings"
+
+	"smartcalc/internal/utils"
 )
 
 // SubnetInfo holds information about a subnet
@@ -258,7 +260,7 @@ func FormatSubnetList(subnets []SubnetInfo) string {
 		// Each line starts with newline (first line too, so it appears on its own line)
 		sb.WriteString("\n")
 		// Prefix with "> " so output lines are not re-parsed
-		sb.WriteString(fmt.Sprintf("> %d: %s/%d (%d hosts)", i+1, s.NetworkAddr, s.CIDR, s.HostCount))
+		sb.WriteString(fmt.Sprintf("> %d: %s/%d (%d %s)", i+1, s.NetworkAddr, s.CIDR, s.HostCount, utils.Plural(float64(s.HostCount), "host", "hosts")))
 	}
 	return sb.String()
 }
@@ -3,7 +3,6 @@ package network
 import (
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"regexp"
 	"strings"
 	"time"
@@ -51,9 +50,7 @@ func IsMyIPExpression(expr string) bool {
 
 // EvalMyIP returns the user's public IP address with location info
 func EvalMyIP() (string, error) {
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-	}
+	client := httpClient(5 * time.Second)
 
 	// ip-api.com returns info about the requesting IP when no IP is specified
 	resp, err := client.Get("http://ip-api.com/json/")
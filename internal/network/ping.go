@@ -0,0 +1,224 @@
+package network
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"smartcalc/internal/utils"
+)
+
+const (
+	// pingProbes is how many probes a ping sends
+	pingProbes = 3
+	// probeTimeout bounds one probe, so a ping takes at most pingProbes of them
+	probeTimeout = time.Second
+	// portTimeout bounds a port check; a port that doesn't answer by then is
+	// filtered
+	portTimeout = 3 * time.Second
+	// pingFallbackPort is connected to when ICMP is not permitted
+	pingFallbackPort = "443"
+)
+
+// pingPattern matches "ping example.com"
+var pingPattern = regexp.MustCompile(`(?i)^ping\s+([\w.:\[\]-]+)$`)
+
+// portPattern matches "port 8080 open on 192.168.1.10 ?" and "is port 22
+// open on example.com"
+var portPattern = regexp.MustCompile(`(?i)^(?:is\s+)?port\s+(\d{1,5})\s+open\s+on\s+([\w.:\[\]-]+?)\s*\??$`)
+
+// IsPingExpression checks if an expression pings a host
+func IsPingExpression(expr string) bool {
+	return pingPattern.MatchString(strings.TrimSpace(expr))
+}
+
+// IsPortExpression checks if an expression checks whether a TCP port of a
+// host is open
+func IsPortExpression(expr string) bool {
+	return portPattern.MatchString(strings.TrimSpace(expr))
+}
+
+// EvalPing sends pingProbes probes to a host and reports whether it is
+// reachable, with the round-trip times of the replies: "reachable, 3/3
+// replies, min/avg/max 11.2/12.5/14.1 ms". ICMP echo is used where raw
+// sockets are permitted; otherwise each probe is a TCP connect to port 443,
+// marked "(tcp 443)". The average round trip in ms is the value of the line.
+func EvalPing(expr string) (utils.Result, error) {
+	m := pingPattern.FindStringSubmatch(strings.TrimSpace(expr))
+	if m == nil {
+		return utils.Result{}, fmt.Errorf("invalid ping expression")
+	}
+	host := strings.Trim(m[1], "[]")
+
+	rtts, via, err := probeICMP(host)
+	if err != nil {
+		rtts, via, err = probeTCP(host)
+	}
+	if err != nil {
+		return utils.Result{}, err
+	}
+	return pingResult(rtts, via), nil
+}
+
+// probeICMP sends ICMP echo requests to host, returning the round trip of
+// each reply. It fails when raw sockets are not permitted.
+func probeICMP(host string) ([]time.Duration, string, error) {
+	conn, err := Dial("ip4:icmp", host, probeTimeout)
+	if err != nil {
+		return nil, "", err
+	}
+	defer conn.Close()
+
+	id := os.Getpid() & 0xffff
+	var rtts []time.Duration
+	for seq := 1; seq <= pingProbes; seq++ {
+		start := time.Now()
+		conn.SetDeadline(start.Add(probeTimeout))
+		if _, err := conn.Write(echoRequest(id, seq)); err != nil {
+			return nil, "", err
+		}
+		if awaitEchoReply(conn, id, seq) {
+			rtts = append(rtts, time.Since(start))
+		}
+	}
+	return rtts, "", nil
+}
+
+// echoRequest builds an ICMP echo request
+func echoRequest(id, seq int) []byte {
+	msg := []byte{8, 0, 0, 0, 0, 0, 0, 0, 's', 'm', 'a', 'r', 't', 'c', 'a', 'l', 'c'}
+	binary.BigEndian.PutUint16(msg[4:], uint16(id))
+	binary.BigEndian.PutUint16(msg[6:], uint16(seq))
+	binary.BigEndian.PutUint16(msg[2:], icmpChecksum(msg))
+	return msg
+}
+
+// icmpChecksum is the Internet checksum of an ICMP message
+func icmpChecksum(msg []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(msg); i += 2 {
+		sum += uint32(msg[i])<<8 | uint32(msg[i+1])
+	}
+	if len(msg)%2 == 1 {
+		sum += uint32(msg[len(msg)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+// awaitEchoReply reads until the echo reply to id and seq arrives or the
+// connection's deadline passes
+func awaitEchoReply(conn net.Conn, id, seq int) bool {
+	buf := make([]byte, 1500)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return false
+		}
+		reply := buf[:n]
+		// A raw socket may deliver the IPv4 header too
+		if n > 20 && reply[0]>>4 == 4 {
+			reply = reply[int(reply[0]&0x0f)*4:]
+		}
+		if len(reply) >= 8 && reply[0] == 0 &&
+			int(binary.BigEndian.Uint16(reply[4:])) == id && int(binary.BigEndian.Uint16(reply[6:])) == seq {
+			return true
+		}
+	}
+}
+
+// probeTCP times pingProbes TCP connects to port 443 of host. A refused
+// connection is an answer too: the host is up.
+func probeTCP(host string) ([]time.Duration, string, error) {
+	address := net.JoinHostPort(host, pingFallbackPort)
+	var rtts []time.Duration
+	for range pingProbes {
+		start := time.Now()
+		conn, err := Dial("tcp", address, probeTimeout)
+		switch {
+		case err == nil:
+			conn.Close()
+		case errors.Is(err, syscall.ECONNREFUSED):
+		case isTimeout(err):
+			continue
+		default:
+			return nil, "", lookupError(err)
+		}
+		rtts = append(rtts, time.Since(start))
+	}
+	return rtts, " (tcp " + pingFallbackPort + ")", nil
+}
+
+// pingResult shows the replies to a ping and their round trips
+func pingResult(rtts []time.Duration, via string) utils.Result {
+	if len(rtts) == 0 {
+		return utils.TextResult(fmt.Sprintf("unreachable, 0/%d replies%s", pingProbes, via))
+	}
+	least, most, total := math.Inf(1), 0.0, 0.0
+	for _, rtt := range rtts {
+		ms := float64(rtt) / float64(time.Millisecond)
+		least, most, total = math.Min(least, ms), math.Max(most, ms), total+ms
+	}
+	avg := total / float64(len(rtts))
+	text := fmt.Sprintf("reachable, %d/%d replies, min/avg/max %s/%s/%s ms%s",
+		len(rtts), pingProbes, formatMillis(least), formatMillis(avg), formatMillis(most), via)
+	return utils.ValueResult(text, math.Round(avg*10)/10, false)
+}
+
+// formatMillis rounds a round trip to a tenth of a millisecond
+func formatMillis(ms float64) string {
+	return strconv.FormatFloat(math.Round(ms*10)/10, 'f', 1, 64)
+}
+
+// EvalPort connects once to a TCP port of a host: "open" when it accepts,
+// "closed" when the host refuses, and "filtered (timeout)" when nothing
+// answers within portTimeout
+func EvalPort(expr string) (string, error) {
+	m := portPattern.FindStringSubmatch(strings.TrimSpace(expr))
+	if m == nil {
+		return "", fmt.Errorf("invalid port expression")
+	}
+	port, _ := strconv.Atoi(m[1])
+	if port < 1 || port > 65535 {
+		return "", fmt.Errorf("invalid port %d", port)
+	}
+	host := strings.Trim(m[2], "[]")
+
+	conn, err := Dial("tcp", net.JoinHostPort(host, m[1]), portTimeout)
+	switch {
+	case err == nil:
+		conn.Close()
+		return "open", nil
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "closed", nil
+	case isTimeout(err):
+		return "filtered (timeout)", nil
+	}
+	return "", lookupError(err)
+}
+
+// isTimeout checks if a dial gave up waiting
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// lookupError shortens the error of a dial that failed before connecting,
+// such as a host name that doesn't resolve
+func lookupError(err error) error {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return fmt.Errorf("unknown host %s", dnsErr.Name)
+	}
+	return err
+}
@@ -0,0 +1,183 @@
+package network
+
+import (
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// stubDialer answers dials by address: an open port accepts, a closed one
+// refuses and a filtered one times out. Raw ICMP sockets are not permitted.
+func stubDialer(t *testing.T, answers map[string]string) {
+	t.Helper()
+	SetDialer(func(network, address string, _ time.Duration) (net.Conn, error) {
+		if network == "ip4:icmp" {
+			return nil, &net.OpError{Op: "dial", Net: network, Err: os.NewSyscallError("socket", syscall.EPERM)}
+		}
+		switch answers[address] {
+		case "open":
+			client, server := net.Pipe()
+			server.Close()
+			return client, nil
+		case "closed":
+			return nil, &net.OpError{Op: "dial", Net: network, Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+		case "filtered":
+			return nil, &net.OpError{Op: "dial", Net: network, Err: os.ErrDeadlineExceeded}
+		}
+		host, _, _ := net.SplitHostPort(address)
+		return nil, &net.OpError{Op: "dial", Net: network, Err: &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}}
+	})
+	t.Cleanup(func() { SetDialer(nil) })
+}
+
+func TestIsPingExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"ping example.com", true},
+		{"PING 192.168.1.1", true},
+		{"ping [::1]", true},
+		{"ping", false},
+		{"ping example.com twice", false},
+		{"ping pong", true},
+		{"port 80 open on example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsPingExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsPingExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsPortExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"port 8080 open on 192.168.1.10 ?", true},
+		{"port 8080 open on 192.168.1.10?", true},
+		{"is port 22 open on example.com", true},
+		{"Port 443 Open On example.com", true},
+		{"port open on example.com", false},
+		{"port 8080", false},
+		{"ping example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsPortExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsPortExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalPing(t *testing.T) {
+	stubDialer(t, map[string]string{
+		"example.com:443": "open",
+		"10.0.0.1:443":    "closed",
+		"10.0.0.2:443":    "filtered",
+	})
+
+	tests := []struct {
+		expr     string
+		prefix   string
+		suffix   string
+		hasValue bool
+	}{
+		{"ping example.com", "reachable, 3/3 replies, min/avg/max ", " ms (tcp 443)", true},
+		// A refused connection still means the host answered
+		{"ping 10.0.0.1", "reachable, 3/3 replies", "(tcp 443)", true},
+		{"ping 10.0.0.2", "unreachable, 0/3 replies", "(tcp 443)", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalPing(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalPing(%q) error: %v", tt.expr, err)
+			}
+			if !strings.HasPrefix(result.Text, tt.prefix) || !strings.HasSuffix(result.Text, tt.suffix) {
+				t.Errorf("EvalPing(%q) = %q, want %q...%q", tt.expr, result.Text, tt.prefix, tt.suffix)
+			}
+			if result.HasValue != tt.hasValue {
+				t.Errorf("EvalPing(%q) HasValue = %v, want %v", tt.expr, result.HasValue, tt.hasValue)
+			}
+		})
+	}
+}
+
+func TestEvalPingUnknownHost(t *testing.T) {
+	stubDialer(t, nil)
+
+	_, err := EvalPing("ping nowhere.invalid")
+	if err == nil || err.Error() != "unknown host nowhere.invalid" {
+		t.Errorf("EvalPing error = %v, want unknown host nowhere.invalid", err)
+	}
+}
+
+func TestEvalPort(t *testing.T) {
+	stubDialer(t, map[string]string{
+		"192.168.1.10:8080": "open",
+		"192.168.1.10:22":   "closed",
+		"example.com:3306":  "filtered",
+	})
+
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"port 8080 open on 192.168.1.10 ?", "open"},
+		{"is port 22 open on 192.168.1.10", "closed"},
+		{"port 3306 open on example.com?", "filtered (timeout)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := EvalPort(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalPort(%q) error: %v", tt.expr, err)
+			}
+			if got != tt.expected {
+				t.Errorf("EvalPort(%q) = %q, want %q", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalPortErrors(t *testing.T) {
+	stubDialer(t, nil)
+
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"port 0 open on example.com", "invalid port 0"},
+		{"port 70000 open on example.com", "invalid port 70000"},
+		{"port 80 open on nowhere.invalid", "unknown host nowhere.invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := EvalPort(tt.expr)
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("EvalPort(%q) error = %v, want %q", tt.expr, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPingTimeoutsAreBounded(t *testing.T) {
+	// Three probes that each run out their timeout must still answer within
+	// about three seconds, so the line never holds the UI longer
+	if pingProbes*probeTimeout > 3*time.Second || portTimeout > 3*time.Second {
+		t.Errorf("ping takes up to %v and a port check %v, want at most 3s", pingProbes*probeTimeout, portTimeout)
+	}
+}
@@ -0,0 +1,63 @@
+package network
+
+import "smartcalc/internal/registry"
+
+func init() {
+	lookup := registry.Expensive | registry.MultiLine | registry.NoFormat | registry.ReportsErrors
+	registry.Register(registry.Evaluator{
+		Name:     "dns",
+		Priority: registry.PriorityDNS,
+		Traits:   lookup,
+		Detect:   IsDNSExpression,
+		Eval: registry.TextEval(func(expr string) (string, error) {
+			result, err := EvalDNS(expr)
+			return "\n" + result, err
+		}),
+	})
+	registry.Register(registry.Evaluator{
+		Name:     "ping",
+		Priority: registry.PriorityPing,
+		Traits:   registry.Expensive | registry.NoFormat | registry.ReportsErrors,
+		Detect:   IsPingExpression,
+		Eval:     EvalPing,
+	})
+	registry.Register(registry.Evaluator{
+		Name:     "port",
+		Priority: registry.PriorityPing,
+		Traits:   registry.Expensive | registry.NoFormat | registry.ReportsErrors,
+		Detect:   IsPortExpression,
+		Eval:     registry.TextEval(EvalPort),
+	})
+	registry.Register(registry.Evaluator{
+		Name:     "whois",
+		Priority: registry.PriorityWhois,
+		Traits:   lookup,
+		Detect:   IsWhoisExpression,
+		Eval: registry.TextEval(func(expr string) (string, error) {
+			result, err := EvalWhois(expr)
+			return "\n" + result, err
+		}),
+	})
+	registry.Register(registry.Evaluator{
+		Name:     "network",
+		Priority: registry.PriorityNetwork,
+		Detect:   IsNetworkExpression,
+		Eval:     EvalNetworkResult,
+	})
+	registry.Register(registry.Evaluator{
+		Name:     "geoip",
+		Priority: registry.PriorityGeoIP,
+		Traits:   registry.Expensive | registry.MultiLine | registry.NoFormat,
+		Detect:   IsGeoIPExpression,
+		Eval:     registry.TextEval(EvalGeoIP),
+	})
+	registry.Register(registry.Evaluator{
+		Name:     "myip",
+		Priority: registry.PriorityMyIP,
+		Traits:   registry.Expensive | registry.Volatile | registry.MultiLine,
+		Detect:   IsMyIPExpression,
+		Eval: registry.TextEval(func(string) (string, error) {
+			return EvalMyIP()
+		}),
+	})
+}
@@ -0,0 +1,54 @@
+package network
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DialFunc opens a connection to address, as net.DialTimeout does
+type DialFunc func(network, address string, timeout time.Duration) (net.Conn, error)
+
+var (
+	transportMu sync.RWMutex
+	dialer      DialFunc          = net.DialTimeout
+	transport   http.RoundTripper // nil is http.DefaultTransport
+)
+
+// SetDialer replaces how whois servers, pinged hosts, checked ports and the
+// servers of certificate lookups are connected to (used by tests to avoid the
+// network). nil restores net.DialTimeout.
+func SetDialer(fn DialFunc) {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	if fn == nil {
+		fn = net.DialTimeout
+	}
+	dialer = fn
+}
+
+// SetTransport replaces the transport of the HTTP requests behind DNS,
+// GeoIP and "my ip" lookups (used by tests to avoid the network). nil
+// restores http.DefaultTransport.
+func SetTransport(rt http.RoundTripper) {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	transport = rt
+}
+
+// Dial connects to address with the current dialer
+func Dial(network, address string, timeout time.Duration) (net.Conn, error) {
+	transportMu.RLock()
+	fn := dialer
+	transportMu.RUnlock()
+	return fn(network, address, timeout)
+}
+
+// httpClient returns a client with the current transport that gives up
+// after timeout
+func httpClient(timeout time.Duration) *http.Client {
+	transportMu.RLock()
+	defer transportMu.RUnlock()
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
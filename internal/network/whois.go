@@ -3,10 +3,13 @@ package network
 import (
 	"bufio"
 	"fmt"
-	"net"
-	"regexp"
+	"math"
+	"slices"
 	"strings"
 	"time"
+
+	"smartcalc/internal/datetime"
+	"smartcalc/internal/utils"
 )
 
 // IsWhoisExpression checks if an expression is a whois expression
@@ -26,6 +29,13 @@ func EvalWhois(expr string) (string, error) {
 
 	domain := strings.TrimSpace(expr[6:])
 
+	// "whois raw example.com" shows the full response
+	raw := false
+	if rest, ok := strings.CutPrefix(strings.ToLower(domain), "raw "); ok {
+		raw = true
+		domain = strings.TrimSpace(domain[len(domain)-len(rest):])
+	}
+
 	// Remove quotes if present
 	domain = strings.Trim(domain, "\"'")
 
@@ -42,16 +52,24 @@ func EvalWhois(expr string) (string, error) {
 		return "", fmt.Errorf("no domain specified")
 	}
 
-	return queryWhois(domain)
+	response, err := queryWhois(domain)
+	if err != nil {
+		return "", err
+	}
+	if raw {
+		return formatWhoisRaw(domain, response), nil
+	}
+	return formatWhoisResponse(domain, response), nil
 }
 
-// queryWhois queries the whois server for domain information
+// queryWhois queries the whois server for domain information and returns its
+// response as is
 func queryWhois(domain string) (string, error) {
 	// Determine the appropriate whois server based on TLD
 	whoisServer := getWhoisServer(domain)
 
 	// Connect to whois server
-	conn, err := net.DialTimeout("tcp", whoisServer+":43", 10*time.Second)
+	conn, err := Dial("tcp", whoisServer+":43", 10*time.Second)
 	if err != nil {
 		return "", fmt.Errorf("failed to connect to whois server: %v", err)
 	}
@@ -77,13 +95,10 @@ func queryWhois(domain string) (string, error) {
 		return "", fmt.Errorf("failed to read whois response: %v", err)
 	}
 
-	rawResponse := response.String()
-	if rawResponse == "" {
+	if response.Len() == 0 {
 		return "", fmt.Errorf("empty response from whois server")
 	}
-
-	// Parse and format the response
-	return formatWhoisResponse(domain, rawResponse), nil
+	return response.String(), nil
 }
 
 // getWhoisServer returns the appropriate whois server for a domain
@@ -136,87 +151,206 @@ func getWhoisServer(domain string) string {
 	return "whois.iana.org"
 }
 
-// formatWhoisResponse extracts and formats key information from whois response
-func formatWhoisResponse(domain, rawResponse string) string {
-	var result strings.Builder
+// whoisFields lists the fields shown from a whois response, each with the
+// keys registries use for it, most specific first. Keys are matched without
+// case.
+var whoisFields = []struct {
+	label   string
+	aliases []string
+}{
+	{"Registrar", []string{"registrar", "sponsoring registrar", "registrar name"}},
+	{"Created", []string{"creation date", "created", "created on", "created date", "registered on",
+		"registration date", "registration time", "domain registration date"}},
+	{"Updated", []string{"updated date", "last updated", "last updated on", "last modified", "last update", "changed", "modified"}},
+	{"Expires", []string{"registry expiry date", "registrar registration expiration date", "expiration date",
+		"expiry date", "expires", "expires on", "expire date", "expiration time", "paid-till", "renewal date"}},
+	{"Status", []string{"domain status", "status", "state", "registration status"}},
+	{"Name Servers", []string{"name server", "name servers", "nameserver", "nameservers", "nserver"}},
+}
 
-	result.WriteString(fmt.Sprintf("> WHOIS: %s\n", domain))
-
-	// Key fields to extract
-	fields := []struct {
-		label    string
-		patterns []string
-	}{
-		{"Registrar", []string{`(?i)Registrar:\s*(.+)`, `(?i)Registrar Name:\s*(.+)`}},
-		{"Created", []string{`(?i)Creation Date:\s*(.+)`, `(?i)Created:\s*(.+)`, `(?i)Registration Date:\s*(.+)`}},
-		{"Updated", []string{`(?i)Updated Date:\s*(.+)`, `(?i)Last Updated:\s*(.+)`}},
-		{"Expires", []string{`(?i)Expir(?:y|ation) Date:\s*(.+)`, `(?i)Registry Expiry Date:\s*(.+)`}},
-		{"Status", []string{`(?i)Domain Status:\s*(.+)`}},
-		{"Name Servers", []string{`(?i)Name Server:\s*(.+)`}},
-	}
-
-	foundFields := make(map[string][]string)
-
-	for _, field := range fields {
-		for _, pattern := range field.patterns {
-			re := regexp.MustCompile(pattern)
-			matches := re.FindAllStringSubmatch(rawResponse, -1)
-			for _, match := range matches {
-				if len(match) > 1 {
-					value := strings.TrimSpace(match[1])
-					// Clean up the value
-					if idx := strings.Index(value, " "); field.label == "Status" && idx != -1 {
-						value = value[:idx] // Remove extra status info URLs
-					}
-					foundFields[field.label] = append(foundFields[field.label], value)
-				}
-			}
-			if len(foundFields[field.label]) > 0 {
-				break // Found matches with this pattern, no need to try others
-			}
+// multiValueFields are shown with every value rather than the first one
+var multiValueFields = map[string]bool{"Status": true, "Name Servers": true}
+
+// whoisDateLayouts are the date formats registries use, tried in order
+var whoisDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"02-Jan-2006",
+	"2006/01/02",
+	"2006.01.02",
+	"02.01.2006",
+}
+
+// parseWhoisLines collects the values of each key in a whois response. Keys
+// are lowercased. A key with no value on its line takes the indented lines
+// below it, as in .uk responses:
+//
+//	Name servers:
+//	    ns1.example.co.uk
+//	    ns2.example.co.uk
+func parseWhoisLines(response string) map[string][]string {
+	values := make(map[string][]string)
+	blockKey := ""
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimRight(line, "\r ")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "%") || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ">>>") {
+			blockKey = ""
+			continue
+		}
+		key, value, hasKey := strings.Cut(trimmed, ":")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		// Lines indented under a block are its values, unless they name a
+		// field of their own ("Registered on: ..." under "Relevant dates:")
+		indented := line != strings.TrimLeft(line, " \t")
+		if blockKey != "" && indented && (!hasKey || value != "" && !isWhoisAlias(key)) {
+			values[blockKey] = append(values[blockKey], trimmed)
+			continue
+		}
+		if !hasKey {
+			blockKey = ""
+			continue
+		}
+		if value == "" {
+			blockKey = key
+			continue
+		}
+		blockKey = ""
+		values[key] = append(values[key], value)
+	}
+	return values
+}
+
+// isWhoisAlias checks if key names one of the whois fields
+func isWhoisAlias(key string) bool {
+	for _, field := range whoisFields {
+		if slices.Contains(field.aliases, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// whoisFieldValues returns the values of a field under its first alias found
+func whoisFieldValues(values map[string][]string, aliases []string) []string {
+	for _, alias := range aliases {
+		if v := values[alias]; len(v) > 0 {
+			return v
 		}
 	}
+	return nil
+}
+
+// cleanWhoisValue tidies one value of a field: a status without the ICANN
+// URL after it, a name server without the trailing dot or addresses after it
+func cleanWhoisValue(label, value string) string {
+	switch label {
+	case "Status":
+		value, _, _ = strings.Cut(value, " http")
+	case "Name Servers":
+		value, _, _ = strings.Cut(value, " ")
+		value = strings.ToLower(strings.TrimSuffix(value, "."))
+	}
+	return value
+}
 
-	// Output found fields
-	for _, field := range fields {
-		if values, ok := foundFields[field.label]; ok && len(values) > 0 {
-			if field.label == "Name Servers" || field.label == "Status" {
-				// Show multiple values
-				result.WriteString(fmt.Sprintf("> %s:\n", field.label))
-				seen := make(map[string]bool)
-				for _, v := range values {
-					vLower := strings.ToLower(v)
-					if !seen[vLower] {
-						seen[vLower] = true
-						result.WriteString(fmt.Sprintf(">   %s\n", v))
-					}
-				}
-			} else {
-				// Show first value only
-				result.WriteString(fmt.Sprintf("> %s: %s\n", field.label, values[0]))
+// parseWhoisDate parses a date as registries write it; text after the date,
+// such as a time zone name, is ignored
+func parseWhoisDate(s string) (time.Time, bool) {
+	candidates := []string{s}
+	if first, _, ok := strings.Cut(s, " "); ok {
+		candidates = append(candidates, first)
+	}
+	for _, c := range candidates {
+		for _, layout := range whoisDateLayouts {
+			if t, err := time.Parse(layout, c); err == nil {
+				return t, true
 			}
 		}
 	}
+	return time.Time{}, false
+}
 
-	output := result.String()
-	if output == fmt.Sprintf("> WHOIS: %s\n", domain) {
-		// No fields found, return raw response (truncated)
-		lines := strings.Split(rawResponse, "\n")
-		result.WriteString("> Raw Response:\n")
-		count := 0
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line != "" && !strings.HasPrefix(line, "%") && !strings.HasPrefix(line, "#") {
-				result.WriteString(fmt.Sprintf(">   %s\n", line))
-				count++
-				if count >= 20 {
-					result.WriteString(">   ... (truncated)\n")
-					break
-				}
+// formatWhoisResponse extracts the key fields of a whois response as a "> "
+// block, with the days left until the domain expires
+func formatWhoisResponse(domain, rawResponse string) string {
+	values := parseWhoisLines(rawResponse)
+
+	var result strings.Builder
+	header := fmt.Sprintf("> WHOIS: %s\n", domain)
+	result.WriteString(header)
+	for _, field := range whoisFields {
+		found := whoisFieldValues(values, field.aliases)
+		if len(found) == 0 {
+			continue
+		}
+		if !multiValueFields[field.label] {
+			value := found[0]
+			t, isDate := parseWhoisDate(value)
+			if isDate {
+				value = t.Format("2006-01-02")
+			}
+			result.WriteString(fmt.Sprintf("> %s: %s\n", field.label, value))
+			if field.label == "Expires" && isDate {
+				result.WriteString("> " + expiresIn(t) + "\n")
+			}
+			continue
+		}
+		var shown []string
+		seen := make(map[string]bool)
+		for _, v := range found {
+			v = cleanWhoisValue(field.label, v)
+			if v != "" && !seen[strings.ToLower(v)] {
+				seen[strings.ToLower(v)] = true
+				shown = append(shown, v)
 			}
 		}
+		result.WriteString(fmt.Sprintf("> %s: %s\n", field.label, strings.Join(shown, ", ")))
+	}
+
+	if result.Len() > len(header) {
 		return strings.TrimSuffix(result.String(), "\n")
 	}
 
-	return strings.TrimSuffix(output, "\n")
+	// No fields found, return raw response (truncated)
+	lines := strings.Split(rawResponse, "\n")
+	result.WriteString("> Raw Response:\n")
+	count := 0
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "%") && !strings.HasPrefix(line, "#") {
+			result.WriteString(fmt.Sprintf(">   %s\n", line))
+			count++
+			if count >= 20 {
+				result.WriteString(fmt.Sprintf(">   ... (truncated; \"whois raw %s\" shows all)\n", domain))
+				break
+			}
+		}
+	}
+	return strings.TrimSuffix(result.String(), "\n")
+}
+
+// expiresIn describes the time left until an expiry date: "Expires in: 142
+// days", or "Expired: 3 days ago" once it has passed
+func expiresIn(expiry time.Time) string {
+	days := int(math.Floor(expiry.Sub(datetime.Now()).Hours() / 24))
+	if days < 0 {
+		return fmt.Sprintf("Expired: %d %s ago", -days, utils.Plural(float64(-days), "day", "days"))
+	}
+	return fmt.Sprintf("Expires in: %s %s", utils.FormatResult(false, float64(days)), utils.Plural(float64(days), "day", "days"))
+}
+
+// formatWhoisRaw shows a whole whois response as a "> " block
+func formatWhoisRaw(domain, rawResponse string) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("> WHOIS: %s (raw)", domain))
+	for _, line := range strings.Split(rawResponse, "\n") {
+		if line = strings.TrimRight(line, "\r \t"); line != "" {
+			result.WriteString("\n> " + line)
+		}
+	}
+	return result.String()
 }
@@ -1,8 +1,12 @@
 package network
 
 import (
+	"os"
 	"strings"
 	"testing"
+	"time"
+
+	"smartcalc/internal/datetime"
 )
 
 func TestIsWhoisExpression(t *testing.T) {
@@ -99,3 +103,129 @@ func TestGetWhoisServer(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatWhoisResponse(t *testing.T) {
+	datetime.SetClock(func() time.Time { return time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC) })
+	defer datetime.SetClock(nil)
+
+	tests := []struct {
+		file     string
+		domain   string
+		expected string
+	}{
+		{"com.txt", "google.com", `> WHOIS: google.com
+> Registrar: MarkMonitor Inc.
+> Created: 1997-09-15
+> Updated: 2019-09-09
+> Expires: 2028-09-14
+> Expires in: 1,200 days
+> Status: clientDeleteProhibited, clientTransferProhibited, clientUpdateProhibited, serverDeleteProhibited
+> Name Servers: ns1.google.com, ns2.google.com, ns3.google.com, ns4.google.com`},
+		{"org.txt", "wikipedia.org", `> WHOIS: wikipedia.org
+> Registrar: MarkMonitor Inc.
+> Created: 2001-01-13
+> Updated: 2024-12-12
+> Expires: 2026-01-13
+> Expires in: 225 days
+> Status: clientDeleteProhibited, clientTransferProhibited
+> Name Servers: ns0.wikimedia.org, ns1.wikimedia.org, ns2.wikimedia.org`},
+		// Registrar expiration date instead of the registry's
+		{"io.txt", "github.io", `> WHOIS: github.io
+> Registrar: MarkMonitor Inc.
+> Created: 2013-03-08
+> Updated: 2025-02-04
+> Expires: 2026-03-08
+> Expires in: 280 days
+> Status: clientUpdateProhibited, clientTransferProhibited
+> Name Servers: dns1.p05.nsone.net, dns2.p05.nsone.net, ns-1339.awsdns-39.org`},
+		// Lowercase keys, paid-till and name servers with addresses
+		{"ru.txt", "yandex.ru", `> WHOIS: yandex.ru
+> Registrar: RU-CENTER-RU
+> Created: 1997-09-23
+> Expires: 2025-09-30
+> Expires in: 121 days
+> Status: REGISTERED, DELEGATED, VERIFIED
+> Name Servers: ns1.yandex.ru, ns2.yandex.ru`},
+		// Values in indented blocks under their keys
+		{"uk.txt", "bbc.co.uk", `> WHOIS: bbc.co.uk
+> Registrar: British Broadcasting Corporation [Tag = BBC]
+> Created: before Aug-1996
+> Updated: 2024-11-11
+> Expires: 2025-12-13
+> Expires in: 194 days
+> Status: Registered until expiry date.
+> Name Servers: dns0.bbc.co.uk, dns0.bbc.com, dns1.bbc.co.uk`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			response, err := os.ReadFile("testdata/whois/" + tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := formatWhoisResponse(tt.domain, string(response)); got != tt.expected {
+				t.Errorf("formatWhoisResponse(%s) =\n%s\nwant\n%s", tt.file, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatWhoisResponseExpired(t *testing.T) {
+	datetime.SetClock(func() time.Time { return time.Date(2025, 10, 3, 12, 0, 0, 0, time.UTC) })
+	defer datetime.SetClock(nil)
+
+	response, err := os.ReadFile("testdata/whois/ru.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := formatWhoisResponse("yandex.ru", string(response)); !strings.Contains(got, "> Expired: 3 days ago") {
+		t.Errorf("expired domain:\n%s", got)
+	}
+}
+
+func TestFormatWhoisResponseUnknownFormat(t *testing.T) {
+	got := formatWhoisResponse("example.test", "This registry\nanswers in prose\n")
+	want := "> WHOIS: example.test\n> Raw Response:\n>   This registry\n>   answers in prose"
+	if got != want {
+		t.Errorf("formatWhoisResponse() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatWhoisRaw(t *testing.T) {
+	response, err := os.ReadFile("testdata/whois/com.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := formatWhoisRaw("google.com", string(response))
+	if !strings.HasPrefix(got, "> WHOIS: google.com (raw)\n>    Domain Name: GOOGLE.COM") {
+		t.Errorf("raw output starts with %q", got[:60])
+	}
+	if !strings.Contains(got, "\n> registrar.") || strings.Contains(got, "\n>\n") {
+		t.Error("raw output should keep every non-empty line of the response")
+	}
+}
+
+func TestParseWhoisDate(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"2028-09-14T04:00:00Z", "2028-09-14"},
+		{"2025-09-30T21:00:00.0Z", "2025-09-30"},
+		{"2025-11-01", "2025-11-01"},
+		{"13-Dec-2025", "2025-12-13"},
+		{"2026/03/31 (JST)", "2026-03-31"},
+		{"2024-05-01 12:00:00", "2024-05-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			d, ok := parseWhoisDate(tt.value)
+			if !ok || d.Format("2006-01-02") != tt.expected {
+				t.Errorf("parseWhoisDate(%q) = %v, %v, want %s", tt.value, d, ok, tt.expected)
+			}
+		})
+	}
+	if _, ok := parseWhoisDate("before Aug-1996"); ok {
+		t.Error("parseWhoisDate accepted a date without a day")
+	}
+}
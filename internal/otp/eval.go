@@ -0,0 +1,126 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"smartcalc/internal/datetime"
+)
+
+// period is the length of a TOTP time step in seconds (RFC 6238 default)
+const period = 30
+
+// totpPattern matches "totp JBSWY3DPEHPK3PXP" with optional "sha256" and
+// "8 digits" modifiers and an optional "at <time>"
+var totpPattern = regexp.MustCompile(`(?i)^totp\s+([a-z2-7]+=*)((?:\s+(?:sha1|sha256|sha512|\d+\s*digits))*)(?:\s+at\s+(.+))?$`)
+
+// modifierPattern matches one modifier of a totp expression
+var modifierPattern = regexp.MustCompile(`(?i)sha1|sha256|sha512|(\d+)\s*digits`)
+
+// unixTimePattern matches a time given as seconds since the Unix epoch
+var unixTimePattern = regexp.MustCompile(`^\d+$`)
+
+// IsOTPExpression checks if an expression is a TOTP code request
+func IsOTPExpression(expr string) bool {
+	return totpPattern.MatchString(strings.TrimSpace(expr))
+}
+
+// EvalOTP computes the TOTP code for a base32 secret at the current time or
+// at the given time, with the seconds left in its time step
+func EvalOTP(expr string) (string, error) {
+	m := totpPattern.FindStringSubmatch(strings.TrimSpace(expr))
+	if m == nil {
+		return "", fmt.Errorf("invalid totp expression")
+	}
+	secret, err := decodeSecret(m[1])
+	if err != nil {
+		return "", err
+	}
+
+	algorithm, digits := "sha1", 6
+	for _, mod := range modifierPattern.FindAllStringSubmatch(m[2], -1) {
+		if mod[1] == "" {
+			algorithm = strings.ToLower(mod[0])
+			continue
+		}
+		digits, _ = strconv.Atoi(mod[1])
+		if digits < 6 || digits > 10 {
+			return "", fmt.Errorf("totp codes have 6 to 10 digits")
+		}
+	}
+
+	t := datetime.Now()
+	if m[3] != "" {
+		if t, err = parseTime(m[3]); err != nil {
+			return "", err
+		}
+	}
+
+	code := generate(secret, t, algorithm, digits)
+	left := period - t.Unix()%period
+	return fmt.Sprintf("%s (%ds left)", code, left), nil
+}
+
+// decodeSecret decodes a base32 secret, as shown by authenticator setup
+// pages, with or without padding
+func decodeSecret(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.ToUpper(s), "=")
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil || len(secret) == 0 {
+		return nil, fmt.Errorf("invalid base32 secret")
+	}
+	return secret, nil
+}
+
+// parseTime parses the time after "at": a date and time, or Unix seconds
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if unixTimePattern.MatchString(s) {
+		secs, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	return datetime.ParseDateTime(s, time.Local)
+}
+
+// generate computes the RFC 6238 code of secret at time t: the RFC 4226 HOTP
+// of the number of 30-second steps since the Unix epoch
+func generate(secret []byte, t time.Time, algorithm string, digits int) string {
+	var h func() hash.Hash
+	switch algorithm {
+	case "sha256":
+		h = sha256.New
+	case "sha512":
+		h = sha512.New
+	default:
+		h = sha1.New
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(t.Unix()/period))
+	mac := hmac.New(h, secret)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation (RFC 4226 section 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint64(1)
+	for range digits {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, uint64(bin)%mod)
+}
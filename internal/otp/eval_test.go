@@ -0,0 +1,109 @@
+package otp
+
+import (
+	"encoding/base32"
+	"fmt"
+	"testing"
+	"time"
+
+	"smartcalc/internal/datetime"
+)
+
+// RFC 6238 appendix B seeds, base32-encoded as authenticator apps expect
+var (
+	seedSHA1   = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	seedSHA256 = base32.StdEncoding.EncodeToString([]byte("12345678901234567890123456789012"))
+	seedSHA512 = base32.StdEncoding.EncodeToString([]byte("1234567890123456789012345678901234567890123456789012345678901234"))
+)
+
+func TestRFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix                 int64
+		sha1, sha256, sha512 string
+	}{
+		{59, "94287082", "46119246", "90693936"},
+		{1111111109, "07081804", "68084774", "25091201"},
+		{1111111111, "14050471", "67062674", "99943326"},
+		{1234567890, "89005924", "91819424", "93441116"},
+		{2000000000, "69279037", "90698825", "38618901"},
+		{20000000000, "65353130", "77737706", "47863826"},
+	}
+
+	for _, tt := range tests {
+		for _, c := range []struct{ algorithm, seed, want string }{
+			{"sha1", seedSHA1, tt.sha1},
+			{"sha256", seedSHA256, tt.sha256},
+			{"sha512", seedSHA512, tt.sha512},
+		} {
+			expr := fmt.Sprintf("totp %s %s 8 digits at %d", c.seed, c.algorithm, tt.unix)
+			left := period - tt.unix%period
+			want := fmt.Sprintf("%s (%ds left)", c.want, left)
+			if got, err := EvalOTP(expr); err != nil || got != want {
+				t.Errorf("EvalOTP(%q) = %q, %v; want %q", expr, got, err, want)
+			}
+		}
+	}
+}
+
+func TestEvalOTP(t *testing.T) {
+	datetime.SetClock(func() time.Time { return time.Unix(1111111111, 0) })
+	defer datetime.SetClock(nil)
+
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		// Six digits are the last six of the eight-digit RFC code
+		{"totp " + seedSHA1, "050471 (29s left)"},
+		{"TOTP " + seedSHA1 + " 8 digits", "14050471 (29s left)"},
+		{"totp " + seedSHA1 + " at 2005-03-18 01:58:29 UTC", "081804 (1s left)"},
+		{"totp " + seedSHA256 + " sha256", "062674 (29s left)"},
+		// Lower case and padded secrets are accepted
+		{"totp gezdgnbvgy3tqojqgezdgnbvgy3tqojq", "050471 (29s left)"},
+		{"totp GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ====", "050471 (29s left)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := EvalOTP(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalOTP(%q) error: %v", tt.expr, err)
+			}
+			if got != tt.expected {
+				t.Errorf("EvalOTP(%q) = %q, want %q", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalOTPErrors(t *testing.T) {
+	for _, expr := range []string{
+		"totp A",
+		"totp " + seedSHA1 + " 4 digits",
+		"totp " + seedSHA1 + " at someday",
+	} {
+		if got, err := EvalOTP(expr); err == nil {
+			t.Errorf("EvalOTP(%q) = %q, want an error", expr, got)
+		}
+	}
+}
+
+func TestIsOTPExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"totp JBSWY3DPEHPK3PXP", true},
+		{"totp JBSWY3DPEHPK3PXP at 2024-06-01 12:00:00 UTC", true},
+		{"totp JBSWY3DPEHPK3PXP sha256 8 digits", true},
+		{"totp", false},
+		{"totp JBSW-Y3DP", false},
+		{"otp JBSWY3DPEHPK3PXP", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsOTPExpression(tt.expr); got != tt.expected {
+			t.Errorf("IsOTPExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+		}
+	}
+}
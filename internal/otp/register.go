@@ -0,0 +1,14 @@
+package otp
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// Formatting the expression would corrupt the base32 secret
+	registry.Register(registry.Evaluator{
+		Name:     "otp",
+		Priority: registry.PriorityOTP,
+		Traits:   registry.NoFormat | registry.ReportsErrors,
+		Detect:   IsOTPExpression,
+		Eval:     registry.TextEval(EvalOTP),
+	})
+}
@@ -24,6 +24,8 @@ func (f HandlerFunc) Handle(expr, exprLower string) (string, bool) {
 
 // handlerChain is the ordered list of handlers for percentage calculations.
 var handlerChain = []Handler{
+	HandlerFunc(handlePlusTax),
+	HandlerFunc(handleBill),
 	HandlerFunc(handleWhatIsPercentOf),
 	HandlerFunc(handleWhatPercentIs),
 	HandlerFunc(handleDecreaseByPercent), // must be before increase to avoid false matches
@@ -47,18 +49,45 @@ func EvalPercentage(expr string) (string, error) {
 	return "", fmt.Errorf("unable to evaluate percentage expression: %s", expr)
 }
 
+// valueHandlerChain lists the percentage forms that produce a plain number,
+// in the same precedence order as handlerChain.
+var valueHandlerChain = []func(exprLower string) (float64, bool){
+	plusTax,
+	percentOf,
+	decreaseByPercent,
+	increaseByPercent,
+}
+
+// EvalPercentageValue evaluates percentage expressions whose result is a plain
+// number ("15% of 200", "increase 100 by 20%") and returns the raw value, so
+// callers can apply their own formatting (e.g. currency) and reference it later.
+func EvalPercentageValue(expr string) (float64, error) {
+	exprLower := strings.ToLower(strings.TrimSpace(expr))
+
+	for _, h := range valueHandlerChain {
+		if result, ok := h(exprLower); ok {
+			return result, nil
+		}
+	}
+
+	return 0, fmt.Errorf("not a numeric percentage expression: %s", expr)
+}
+
 // IsPercentageExpression checks if an expression looks like a percentage calculation.
 func IsPercentageExpression(expr string) bool {
 	exprLower := strings.ToLower(expr)
 
 	patterns := []string{
 		`what\s+is\s+[\d.]+%?\s+of`,
+		`^[\d.]+\s*%\s+of\s+\$?[\d.]+$`,
 		`[\d.]+\s+is\s+what\s+(?:%|percent|percentage)`,
-		`increase\s+[\d.]+\s+by`,
-		`decrease\s+[\d.]+\s+by`,
+		`increase\s+\$?[\d.]+\s+by`,
+		`decrease\s+\$?[\d.]+\s+by`,
 		`percent\s+change`,
 		`tip\s+[\d.]+%?\s+on`,
 		`split\s+\$?[\d.]+`,
+		`^\$?[\d,.]+\s*\+\s*tax\b`,
+		`\bwith\s+(?:tax\b|[\d.]+\s*%\s*tip\b)`,
 	}
 
 	for _, pattern := range patterns {
@@ -71,25 +100,32 @@ func IsPercentageExpression(expr string) bool {
 }
 
 func handleWhatIsPercentOf(expr, exprLower string) (string, bool) {
+	result, ok := percentOf(exprLower)
+	if !ok {
+		return "", false
+	}
+	return formatResult(result), true
+}
+
+func percentOf(exprLower string) (float64, bool) {
 	// Pattern: "what is 15% of 200" or "15% of 200"
-	re := regexp.MustCompile(`(?:what\s+is\s+)?([\d.]+)\s*%?\s+of\s+([\d.]+)`)
+	re := regexp.MustCompile(`(?:what\s+is\s+)?([\d.]+)\s*%?\s+of\s+\$?([\d.]+)`)
 	matches := re.FindStringSubmatch(exprLower)
 	if matches == nil {
-		return "", false
+		return 0, false
 	}
 
 	percent, err := strconv.ParseFloat(matches[1], 64)
 	if err != nil {
-		return "", false
+		return 0, false
 	}
 
 	value, err := strconv.ParseFloat(matches[2], 64)
 	if err != nil {
-		return "", false
+		return 0, false
 	}
 
-	result := value * percent / 100
-	return formatResult(result), true
+	return value * percent / 100, true
 }
 
 func handleWhatPercentIs(expr, exprLower string) (string, bool) {
@@ -119,67 +155,81 @@ func handleWhatPercentIs(expr, exprLower string) (string, bool) {
 }
 
 func handleIncreaseByPercent(expr, exprLower string) (string, bool) {
+	result, ok := increaseByPercent(exprLower)
+	if !ok {
+		return "", false
+	}
+	return formatResult(result), true
+}
+
+func increaseByPercent(exprLower string) (float64, bool) {
 	// Pattern: "increase 100 by 20%" or "100 increased by 20%"
 	// Must contain "increase" keyword
 	if !strings.Contains(exprLower, "increase") {
-		return "", false
+		return 0, false
 	}
 
-	re := regexp.MustCompile(`(?:increase\s+)([\d.]+)\s+by\s+([\d.]+)\s*%`)
+	re := regexp.MustCompile(`(?:increase\s+)\$?([\d.]+)\s+by\s+([\d.]+)\s*%`)
 	matches := re.FindStringSubmatch(exprLower)
 	if matches == nil {
 		// Try alternate pattern: "100 increased by 20%"
-		re = regexp.MustCompile(`([\d.]+)\s+increased\s+by\s+([\d.]+)\s*%`)
+		re = regexp.MustCompile(`\$?([\d.]+)\s+increased\s+by\s+([\d.]+)\s*%`)
 		matches = re.FindStringSubmatch(exprLower)
 		if matches == nil {
-			return "", false
+			return 0, false
 		}
 	}
 
 	value, err := strconv.ParseFloat(matches[1], 64)
 	if err != nil {
-		return "", false
+		return 0, false
 	}
 
 	percent, err := strconv.ParseFloat(matches[2], 64)
 	if err != nil {
-		return "", false
+		return 0, false
 	}
 
-	result := value * (1 + percent/100)
-	return formatResult(result), true
+	return value * (1 + percent/100), true
 }
 
 func handleDecreaseByPercent(expr, exprLower string) (string, bool) {
+	result, ok := decreaseByPercent(exprLower)
+	if !ok {
+		return "", false
+	}
+	return formatResult(result), true
+}
+
+func decreaseByPercent(exprLower string) (float64, bool) {
 	// Pattern: "decrease 500 by 15%" or "500 decreased by 15%"
 	// Must contain "decrease" keyword to avoid matching increase expressions
 	if !strings.Contains(exprLower, "decrease") {
-		return "", false
+		return 0, false
 	}
 
-	re := regexp.MustCompile(`(?:decrease\s+)([\d.]+)\s+by\s+([\d.]+)\s*%`)
+	re := regexp.MustCompile(`(?:decrease\s+)\$?([\d.]+)\s+by\s+([\d.]+)\s*%`)
 	matches := re.FindStringSubmatch(exprLower)
 	if matches == nil {
 		// Try alternate pattern: "500 decreased by 15%"
-		re = regexp.MustCompile(`([\d.]+)\s+decreased\s+by\s+([\d.]+)\s*%`)
+		re = regexp.MustCompile(`\$?([\d.]+)\s+decreased\s+by\s+([\d.]+)\s*%`)
 		matches = re.FindStringSubmatch(exprLower)
 		if matches == nil {
-			return "", false
+			return 0, false
 		}
 	}
 
 	value, err := strconv.ParseFloat(matches[1], 64)
 	if err != nil {
-		return "", false
+		return 0, false
 	}
 
 	percent, err := strconv.ParseFloat(matches[2], 64)
 	if err != nil {
-		return "", false
+		return 0, false
 	}
 
-	result := value * (1 - percent/100)
-	return formatResult(result), true
+	return value * (1 - percent/100), true
 }
 
 func handlePercentChange(expr, exprLower string) (string, bool) {
@@ -189,6 +189,12 @@ func TestIsPercentageExpression(t *testing.T) {
 		{"increase 100 by 20%", true},
 		{"decrease 500 by 15%", true},
 		{"tip 20% on $85", true},
+		{"15% of $1200", true},
+		{"$45.99 + tax", true},
+		{"$45.99 + tax 9.5%", true},
+		{"price $100 with tax and 18% tip split 3 ways", true},
+		{"$100 + taxi", false},
+		{"x within 5% of 100", false},
 		{"100 + 50", false},
 		{"5 miles in km", false},
 	}
@@ -202,3 +208,33 @@ func TestIsPercentageExpression(t *testing.T) {
 		})
 	}
 }
+
+func TestEvalPercentageValue(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected float64
+	}{
+		{"15% of 1200", 180},
+		{"what is 15% of $200", 30},
+		{"increase $100 by 20%", 120},
+		{"500 decreased by 15%", 425},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalPercentageValue(tt.expr)
+			if err != nil {
+				t.Errorf("EvalPercentageValue(%q) error: %v", tt.expr, err)
+				return
+			}
+			if result != tt.expected {
+				t.Errorf("EvalPercentageValue(%q) = %v, want %v", tt.expr, result, tt.expected)
+			}
+		})
+	}
+
+	// Text-only results are not numeric values
+	if _, err := EvalPercentageValue("50 is what % of 200"); err == nil {
+		t.Error("EvalPercentageValue should reject \"is what %\" expressions")
+	}
+}
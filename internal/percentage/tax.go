@@ -0,0 +1,155 @@
+package percentage
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"smartcalc/internal/utils"
+)
+
+var (
+	taxMu          sync.RWMutex
+	defaultTaxRate float64 // percent; 0 means not configured
+)
+
+// SetDefaultTaxRate sets the sales tax rate (in percent) used by "$45.99 + tax".
+// Negative or invalid rates clear it.
+func SetDefaultTaxRate(rate float64) {
+	if rate < 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		rate = 0
+	}
+	taxMu.Lock()
+	defer taxMu.Unlock()
+	defaultTaxRate = rate
+}
+
+// GetDefaultTaxRate returns the default sales tax rate in percent
+func GetDefaultTaxRate() float64 {
+	taxMu.RLock()
+	defer taxMu.RUnlock()
+	return defaultTaxRate
+}
+
+// plusTaxPattern matches "$45.99 + tax" and "$45.99 + tax 9.5%"
+var plusTaxPattern = regexp.MustCompile(`^\$?([\d,]*\.?\d+)\s*\+\s*tax(?:\s+([\d.]+)\s*%)?$`)
+
+// billPattern matches "price $100 with tax and 18% tip split 3 ways"; tax,
+// tip and split are each optional but applied in that order
+var billPattern = regexp.MustCompile(`^(?:price\s+)?\$?([\d,]*\.?\d+)\s+with\s+(tax(?:\s+([\d.]+)\s*%)?)?(?:\s*(?:and\s+)?([\d.]+)\s*%\s*tip)?(?:\s+split\s+(\d+)\s+ways?)?$`)
+
+// taxRate returns the explicit rate if given, else the default rate
+func taxRate(explicit string) (float64, error) {
+	if explicit == "" {
+		rate := GetDefaultTaxRate()
+		if rate <= 0 {
+			return 0, fmt.Errorf("no default tax rate set")
+		}
+		return rate, nil
+	}
+	return strconv.ParseFloat(explicit, 64)
+}
+
+// parseAmount parses a dollar amount like "1,250.50"
+func parseAmount(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	return v, err == nil
+}
+
+// roundCents rounds an amount to whole cents
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// plusTax evaluates "$45.99 + tax" to the amount including tax, rounded to cents
+func plusTax(exprLower string) (float64, bool) {
+	m := plusTaxPattern.FindStringSubmatch(exprLower)
+	if m == nil {
+		return 0, false
+	}
+	amount, ok := parseAmount(m[1])
+	if !ok {
+		return 0, false
+	}
+	rate, err := taxRate(m[2])
+	if err != nil {
+		return 0, false
+	}
+	return amount + roundCents(amount*rate/100), true
+}
+
+func handlePlusTax(expr, exprLower string) (string, bool) {
+	m := plusTaxPattern.FindStringSubmatch(exprLower)
+	if m == nil {
+		return "", false
+	}
+	if _, err := taxRate(m[2]); err != nil {
+		return "ERR: " + err.Error(), true
+	}
+	total, ok := plusTax(exprLower)
+	if !ok {
+		return "", false
+	}
+	return utils.FormatCurrency(total), true
+}
+
+func handleBill(expr, exprLower string) (string, bool) {
+	m := billPattern.FindStringSubmatch(exprLower)
+	if m == nil || (m[2] == "" && m[4] == "") {
+		return "", false
+	}
+	subtotal, ok := parseAmount(m[1])
+	if !ok {
+		return "", false
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n> Subtotal: %s", utils.FormatCurrency(subtotal))
+	total := subtotal
+
+	if m[2] != "" {
+		rate, err := taxRate(m[3])
+		if err != nil {
+			return "ERR: " + err.Error(), true
+		}
+		tax := roundCents(subtotal * rate / 100)
+		total += tax
+		fmt.Fprintf(&sb, "\n> Tax (%s%%): %s", formatResult(rate), utils.FormatCurrency(tax))
+	}
+	if m[4] != "" {
+		// Tip is calculated on the pre-tax subtotal
+		tipPercent, err := strconv.ParseFloat(m[4], 64)
+		if err != nil {
+			return "", false
+		}
+		tip := roundCents(subtotal * tipPercent / 100)
+		total += tip
+		fmt.Fprintf(&sb, "\n> Tip (%s%%): %s", formatResult(tipPercent), utils.FormatCurrency(tip))
+	}
+	fmt.Fprintf(&sb, "\n> Total: %s", utils.FormatCurrency(total))
+
+	if m[5] != "" {
+		ways, err := strconv.Atoi(m[5])
+		if err != nil || ways == 0 {
+			return "", false
+		}
+		sb.WriteString("\n> Per person: " + splitCents(total, ways))
+	}
+	return sb.String(), true
+}
+
+// splitCents splits an amount into whole-cent shares that add up to it exactly:
+// $100 split 3 ways is "$33.34 × 1, $33.33 × 2"
+func splitCents(total float64, ways int) string {
+	cents := int64(math.Round(total * 100))
+	base, extra := cents/int64(ways), cents%int64(ways)
+	share := utils.FormatCurrency(float64(base) / 100)
+	if extra == 0 {
+		return share
+	}
+	return fmt.Sprintf("%s × %d, %s × %d",
+		utils.FormatCurrency(float64(base+1)/100), extra, share, int64(ways)-extra)
+}
@@ -0,0 +1,105 @@
+package percentage
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPlusTax(t *testing.T) {
+	SetDefaultTaxRate(0)
+	defer SetDefaultTaxRate(0)
+
+	if result, _ := EvalPercentage("$45.99 + tax"); result != "ERR: no default tax rate set" {
+		t.Errorf("without a default rate got %q", result)
+	}
+	if _, err := EvalPercentageValue("$45.99 + tax"); err == nil {
+		t.Error("EvalPercentageValue should fail without a default rate")
+	}
+
+	SetDefaultTaxRate(9.5)
+	tests := []struct {
+		expr     string
+		expected float64
+	}{
+		{"$45.99 + tax", 50.36},     // tax $4.369 rounds to $4.37
+		{"$45.99 + tax 8%", 49.67},  // explicit rate wins; tax $3.6792 rounds to $3.68
+		{"1,200 + tax 7.25%", 1287}, // tax $87.00
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := EvalPercentageValue(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalPercentageValue(%q) error: %v", tt.expr, err)
+			}
+			if math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("EvalPercentageValue(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSetDefaultTaxRateRejectsInvalid(t *testing.T) {
+	defer SetDefaultTaxRate(0)
+	SetDefaultTaxRate(-5)
+	if got := GetDefaultTaxRate(); got != 0 {
+		t.Errorf("GetDefaultTaxRate() = %v after a negative rate, want 0", got)
+	}
+}
+
+func TestBillBreakdown(t *testing.T) {
+	SetDefaultTaxRate(9.5)
+	defer SetDefaultTaxRate(0)
+
+	result, err := EvalPercentage("price $100 with tax and 18% tip split 3 ways")
+	if err != nil {
+		t.Fatalf("EvalPercentage error: %v", err)
+	}
+	want := "\n> Subtotal: $100.00\n> Tax (9.5%): $9.50\n> Tip (18%): $18.00\n> Total: $127.50\n> Per person: $42.50"
+	if result != want {
+		t.Errorf("got %q, want %q", result, want)
+	}
+
+	result, _ = EvalPercentage("$85.50 with 20% tip")
+	if result != "\n> Subtotal: $85.50\n> Tip (20%): $17.10\n> Total: $102.60" {
+		t.Errorf("tip only got %q", result)
+	}
+}
+
+func TestSplitCentsSumsToTotal(t *testing.T) {
+	tests := []struct {
+		total    float64
+		ways     int
+		expected string
+	}{
+		{100, 3, "$33.34 × 1, $33.33 × 2"},
+		{120, 7, "$17.15 × 2, $17.14 × 5"},
+		{127.50, 3, "$42.50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			got := splitCents(tt.total, tt.ways)
+			if got != tt.expected {
+				t.Errorf("splitCents(%v, %d) = %q, want %q", tt.total, tt.ways, got, tt.expected)
+			}
+
+			// The shares add back up to the total within one cent
+			sum := 0.0
+			for _, part := range strings.Split(got, ", ") {
+				var share float64
+				count := 1
+				if n, _ := fmt.Sscanf(part, "$%f × %d", &share, &count); n == 0 {
+					t.Fatalf("cannot parse share %q", part)
+				}
+				if !strings.Contains(part, "×") {
+					count = tt.ways
+				}
+				sum += share * float64(count)
+			}
+			if math.Abs(sum-tt.total) > 0.01 {
+				t.Errorf("shares sum to %.2f, want %.2f", sum, tt.total)
+			}
+		})
+	}
+}
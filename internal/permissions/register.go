@@ -0,0 +1,12 @@
+package permissions
+
+import "smartcalc/internal/registry"
+
+func init() {
+	registry.Register(registry.Evaluator{
+		Name:     "permissions",
+		Priority: registry.PriorityPermissions,
+		Detect:   IsPermissionsExpression,
+		Eval:     registry.TextEval(EvalPermissions),
+	})
+}
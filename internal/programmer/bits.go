@@ -0,0 +1,141 @@
+package programmer
+
+import (
+	"fmt"
+	"math/bits"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// bitsPattern matches "bits 0xC3" and "bits 0xC3 as 16"
+var bitsPattern = regexp.MustCompile(`(?i)^bits\s+(0x[0-9a-f]+|0b[01]+|\d+)(?:\s+as\s+(8|16|32|64))?$`)
+
+// bitOpPattern matches "set bit 3 of 0x40", "clear bit 6 of 0xFF",
+// "toggle bit 0 of 0b1010" and "test bit 7 of 0x80"
+var bitOpPattern = regexp.MustCompile(`(?i)^(set|clear|toggle|test)\s+bit\s+(\d+)\s+of\s+(0x[0-9a-f]+|0b[01]+|\d+)$`)
+
+// parseUnsigned parses a hex, binary or decimal value of up to 64 bits
+func parseUnsigned(s string) (uint64, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	base := 10
+	switch {
+	case strings.HasPrefix(s, "0x"):
+		s, base = s[2:], 16
+	case strings.HasPrefix(s, "0b"):
+		s, base = s[2:], 2
+	}
+	val, err := strconv.ParseUint(s, base, 64)
+	return val, err == nil
+}
+
+// bitWidth returns the smallest of 8, 16, 32 and 64 bits that holds v
+func bitWidth(v uint64) int {
+	for _, w := range []int{8, 16, 32} {
+		if bits.Len64(v) <= w {
+			return w
+		}
+	}
+	return 64
+}
+
+func handleBits(expr, exprLower string) (string, bool) {
+	// Pattern: "bits 0xC3" or "bits 0xC3 as 16"
+	matches := bitsPattern.FindStringSubmatch(expr)
+	if matches == nil {
+		return "", false
+	}
+
+	v, ok := parseUnsigned(matches[1])
+	if !ok {
+		return "", false
+	}
+	width := bitWidth(v)
+	if matches[2] != "" {
+		width, _ = strconv.Atoi(matches[2])
+		if bits.Len64(v) > width {
+			return "", false
+		}
+	}
+	return formatBitField(v, width), true
+}
+
+// formatBitField lays out the bits of v in nibbles, most significant first,
+// with a ruler below that numbers the top bit of each nibble and bit 0:
+//
+//	> bin: 1100 0011
+//	> bit: 7    3  0
+func formatBitField(v uint64, width int) string {
+	var bin, ruler strings.Builder
+	var set []string
+	for i := width - 1; i >= 0; i-- {
+		if v&(1<<uint(i)) != 0 {
+			bin.WriteByte('1')
+			set = append(set, strconv.Itoa(i))
+		} else {
+			bin.WriteByte('0')
+		}
+		if i%4 == 3 {
+			// The nibble's top bit index, left-aligned under the nibble
+			label := strconv.Itoa(i)
+			ruler.WriteString(label)
+			ruler.WriteString(strings.Repeat(" ", 5-len(label)))
+		}
+		if i%4 == 0 && i > 0 {
+			bin.WriteByte(' ')
+		}
+	}
+	// Bit 0 closes the ruler under the last column
+	rulerText := []byte(strings.TrimRight(ruler.String(), " "))
+	for len(rulerText) < bin.Len()-1 {
+		rulerText = append(rulerText, ' ')
+	}
+	rulerText = append(rulerText, '0')
+
+	setText := "none"
+	if len(set) > 0 {
+		setText = strings.Join(set, ", ")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n> bin: " + bin.String())
+	sb.WriteString("\n> bit: " + string(rulerText))
+	sb.WriteString(fmt.Sprintf("\n> set: %s (%d of %d)", setText, len(set), width))
+	sb.WriteString(fmt.Sprintf("\n> dec: %d", v))
+	sb.WriteString(fmt.Sprintf("\n> hex: 0x%0*X", width/4, v))
+	return sb.String()
+}
+
+func handleBitOp(expr, exprLower string) (string, bool) {
+	// Pattern: "set bit 3 of 0x40", "test bit 7 of 0x80"
+	matches := bitOpPattern.FindStringSubmatch(expr)
+	if matches == nil {
+		return "", false
+	}
+
+	bit, err := strconv.Atoi(matches[2])
+	if err != nil || bit > 63 {
+		return "", false
+	}
+	v, ok := parseUnsigned(matches[3])
+	if !ok {
+		return "", false
+	}
+
+	mask := uint64(1) << uint(bit)
+	var result uint64
+	switch strings.ToLower(matches[1]) {
+	case "set":
+		result = v | mask
+	case "clear":
+		result = v &^ mask
+	case "toggle":
+		result = v ^ mask
+	default:
+		if v&mask != 0 {
+			return fmt.Sprintf("1 (bit %d is set)", bit), true
+		}
+		return fmt.Sprintf("0 (bit %d is clear)", bit), true
+	}
+	return fmt.Sprintf("%d (0x%X)", result, result), true
+}
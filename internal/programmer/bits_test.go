@@ -0,0 +1,68 @@
+package programmer
+
+import "testing"
+
+func TestBits(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"bits 0xC3", "\n> bin: 1100 0011\n> bit: 7    3  0\n> set: 7, 6, 1, 0 (4 of 8)\n> dec: 195\n> hex: 0xC3"},
+		{"bits 0xC3 as 16", "\n> bin: 0000 0000 1100 0011\n> bit: 15   11   7    3  0\n> set: 7, 6, 1, 0 (4 of 16)\n> dec: 195\n> hex: 0x00C3"},
+		{"BITS 0b1", "\n> bin: 0000 0001\n> bit: 7    3  0\n> set: 0 (1 of 8)\n> dec: 1\n> hex: 0x01"},
+		{"bits 0", "\n> bin: 0000 0000\n> bit: 7    3  0\n> set: none (0 of 8)\n> dec: 0\n> hex: 0x00"},
+		// The width grows to fit the value
+		{"bits 256", "\n> bin: 0000 0001 0000 0000\n> bit: 15   11   7    3  0\n> set: 8 (1 of 16)\n> dec: 256\n> hex: 0x0100"},
+		{"bits 0x80000000", "\n> bin: 1000 0000 0000 0000 0000 0000 0000 0000\n> bit: 31   27   23   19   15   11   7    3  0\n> set: 31 (1 of 32)\n> dec: 2147483648\n> hex: 0x80000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalProgrammer(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalProgrammer(%q) error: %v", tt.expr, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvalProgrammer(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+
+	// A forced width must hold the value
+	if _, err := EvalProgrammer("bits 0x1FF as 8"); err == nil {
+		t.Error("EvalProgrammer(bits 0x1FF as 8) should fail")
+	}
+}
+
+func TestBitOps(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"set bit 3 of 0x40", "72 (0x48)"},
+		{"set bit 3 of 0x48", "72 (0x48)"},
+		{"clear bit 6 of 0xFF", "191 (0xBF)"},
+		{"clear bit 0 of 0xFE", "254 (0xFE)"},
+		{"toggle bit 0 of 0b1010", "11 (0xB)"},
+		{"Toggle Bit 1 of 10", "8 (0x8)"},
+		{"test bit 7 of 0x80", "1 (bit 7 is set)"},
+		{"test bit 6 of 0x80", "0 (bit 6 is clear)"},
+		{"set bit 63 of 0", "9223372036854775808 (0x8000000000000000)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalProgrammer(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalProgrammer(%q) error: %v", tt.expr, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvalProgrammer(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+
+	if _, err := EvalProgrammer("set bit 64 of 1"); err == nil {
+		t.Error("EvalProgrammer(set bit 64 of 1) should fail")
+	}
+}
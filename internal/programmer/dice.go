@@ -0,0 +1,239 @@
+package programmer
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/utils"
+)
+
+// Limits keep a roll or sample small enough to show on one line
+const (
+	maxDice    = 100
+	maxSides   = 1000000
+	maxSamples = 1000
+)
+
+// rollPattern matches "roll 3d6", "roll 2d20 + 5" and "roll 4d6 drop lowest"
+var rollPattern = regexp.MustCompile(`(?i)^roll\s+(.+?)(?:\s+drop\s+(lowest|highest)(?:\s+(\d+))?)?$`)
+
+// diceTermPattern matches one term of a roll with its sign: "3d6", "d20" or
+// a modifier such as "5"
+var diceTermPattern = regexp.MustCompile(`(?i)^\s*([+-])?\s*(?:(\d*)d(\d+)|(\d+))\s*`)
+
+// samplePattern matches "sample 5 from 1..50" and "sample 3 from 1 to 10"
+var samplePattern = regexp.MustCompile(`(?i)^sample\s+(\d+)\s+from\s+(-?\d+)\s*(?:\.\.|to)\s*(-?\d+)$`)
+
+// randomNumberPattern matches "random 1 to 100" and "random 1-100"
+var randomNumberPattern = regexp.MustCompile(`(?i)^random\s+(\d+)\s*(?:to|-)\s*(\d+)$`)
+
+// diceTerm is one term of a roll: dice such as "3d6" or a constant
+type diceTerm struct {
+	sign     int
+	count    int // dice rolled, 0 for a constant
+	sides    int
+	constant int
+}
+
+// parseRoll reads the terms of a roll such as "2d20 + 5"
+func parseRoll(spec string) ([]diceTerm, error) {
+	var terms []diceTerm
+	dice := false
+	for rest := spec; rest != ""; {
+		m := diceTermPattern.FindStringSubmatch(rest)
+		if m == nil || m[1] == "" && len(terms) > 0 {
+			return nil, fmt.Errorf("invalid dice %q", spec)
+		}
+		rest = rest[len(m[0]):]
+		t := diceTerm{sign: 1}
+		if m[1] == "-" {
+			t.sign = -1
+		}
+		if m[4] != "" {
+			t.constant, _ = strconv.Atoi(m[4])
+			terms = append(terms, t)
+			continue
+		}
+		t.count = 1
+		if m[2] != "" {
+			t.count, _ = strconv.Atoi(m[2])
+		}
+		t.sides, _ = strconv.Atoi(m[3])
+		if t.count < 1 || t.count > maxDice {
+			return nil, fmt.Errorf("roll 1 to %d dice at a time", maxDice)
+		}
+		if t.sides < 2 || t.sides > maxSides {
+			return nil, fmt.Errorf("dice need 2 to %d sides", maxSides)
+		}
+		dice = true
+		terms = append(terms, t)
+	}
+	if !dice {
+		return nil, fmt.Errorf("invalid dice %q", spec)
+	}
+	return terms, nil
+}
+
+// IsRandomExpression checks if an expression draws random numbers: a dice
+// roll, a sample of distinct numbers or a random number in a range
+func IsRandomExpression(expr string) bool {
+	expr = strings.TrimSpace(expr)
+	if m := rollPattern.FindStringSubmatch(expr); m != nil {
+		_, err := parseRoll(m[1])
+		return err == nil
+	}
+	return samplePattern.MatchString(expr) || randomNumberPattern.MatchString(expr)
+}
+
+// EvalRandom rolls dice, "roll 2d20 + 5 = 27" with each die below as a "> "
+// line, samples distinct numbers, "sample 5 from 1..50 = 3, 17, 22, 40, 48",
+// or draws a number, "random 1 to 100". Every draw is uniform, from the
+// random source.
+func EvalRandom(expr string) (utils.Result, error) {
+	expr = strings.TrimSpace(expr)
+	if m := rollPattern.FindStringSubmatch(expr); m != nil {
+		return roll(m[1], m[2], m[3])
+	}
+	if m := samplePattern.FindStringSubmatch(expr); m != nil {
+		return sample(m[1], m[2], m[3])
+	}
+	if m := randomNumberPattern.FindStringSubmatch(expr); m != nil {
+		lo, _ := strconv.ParseInt(m[1], 10, 64)
+		hi, _ := strconv.ParseInt(m[2], 10, 64)
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		v := lo + randInt(hi-lo+1)
+		return utils.ValueResult(strconv.FormatInt(v, 10), float64(v), false), nil
+	}
+	return utils.Result{}, fmt.Errorf("invalid random expression")
+}
+
+// roll rolls the dice of spec and adds them up, dropping the lowest or
+// highest drop dice when asked. The total is the value; each set of dice
+// shows below it.
+func roll(spec, drop, dropCount string) (utils.Result, error) {
+	terms, err := parseRoll(spec)
+	if err != nil {
+		return utils.Result{}, err
+	}
+	dropped := 0
+	if drop != "" {
+		dropped = 1
+		if dropCount != "" {
+			dropped, _ = strconv.Atoi(dropCount)
+		}
+		var dice []diceTerm
+		for _, t := range terms {
+			if t.count > 0 {
+				dice = append(dice, t)
+			}
+		}
+		if len(dice) > 1 {
+			return utils.Result{}, fmt.Errorf("drop works on a single set of dice")
+		}
+		if dropped >= dice[0].count {
+			return utils.Result{}, fmt.Errorf("can't drop %d of %d dice", dropped, dice[0].count)
+		}
+	}
+
+	total := 0
+	var lines strings.Builder
+	for _, t := range terms {
+		if t.count == 0 {
+			total += t.sign * t.constant
+			continue
+		}
+		rolls := make([]int, t.count)
+		for i := range rolls {
+			rolls[i] = 1 + int(randInt(int64(t.sides)))
+		}
+		kept, out := rolls, []int(nil)
+		if dropped > 0 {
+			kept, out = dropDice(rolls, dropped, strings.EqualFold(drop, "lowest"))
+		}
+		for _, r := range kept {
+			total += t.sign * r
+		}
+		sign := ""
+		if t.sign < 0 {
+			sign = "-"
+		}
+		fmt.Fprintf(&lines, "\n> %s%dd%d: %s", sign, t.count, t.sides, joinInts(rolls))
+		if len(out) > 0 {
+			fmt.Fprintf(&lines, " (dropped %s)", joinInts(out))
+		}
+	}
+	return utils.ValueResult(strconv.Itoa(total)+lines.String(), float64(total), false), nil
+}
+
+// dropDice splits rolls into the dice kept and the n lowest (or highest)
+// dropped, keeping the order they were rolled in
+func dropDice(rolls []int, n int, lowest bool) (kept, dropped []int) {
+	order := make([]int, len(rolls))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		if lowest {
+			return rolls[a] - rolls[b]
+		}
+		return rolls[b] - rolls[a]
+	})
+	out := make(map[int]bool, n)
+	for _, i := range order[:n] {
+		out[i] = true
+	}
+	for i, r := range rolls {
+		if out[i] {
+			dropped = append(dropped, r)
+		} else {
+			kept = append(kept, r)
+		}
+	}
+	return kept, dropped
+}
+
+// sample draws count distinct numbers from lo to hi and lists them in order
+func sample(count, lo, hi string) (utils.Result, error) {
+	n, _ := strconv.Atoi(count)
+	from, _ := strconv.ParseInt(lo, 10, 64)
+	to, _ := strconv.ParseInt(hi, 10, 64)
+	if from > to {
+		from, to = to, from
+	}
+	size := to - from + 1
+	if n < 1 || n > maxSamples {
+		return utils.Result{}, fmt.Errorf("sample 1 to %d numbers at a time", maxSamples)
+	}
+	if int64(n) > size {
+		return utils.Result{}, fmt.Errorf("can't draw %d distinct numbers from %d", n, size)
+	}
+	// Floyd's algorithm draws each number once, however large the range
+	picked := make(map[int64]bool, n)
+	for j := size - int64(n); j < size; j++ {
+		v := randInt(j + 1)
+		if picked[v] {
+			v = j
+		}
+		picked[v] = true
+	}
+	values := make([]int, 0, n)
+	for v := range picked {
+		values = append(values, int(from+v))
+	}
+	slices.Sort(values)
+	return utils.TextResult(joinInts(values)), nil
+}
+
+// joinInts lists numbers separated by commas
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ", ")
+}
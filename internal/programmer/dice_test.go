@@ -0,0 +1,146 @@
+package programmer
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestIsRandomExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"roll 3d6", true},
+		{"roll 2d20 + 5", true},
+		{"roll d20 - 1", true},
+		{"roll 4d6 drop lowest", true},
+		{"roll 5d10 drop highest 2", true},
+		{"sample 5 from 1..50", true},
+		{"sample 3 from 1 to 10", true},
+		{"random 1 to 100", true},
+		{"random 1-1000", true},
+
+		{"roll 3", false},
+		{"roll 3d1", false},
+		{"roll 500d6", false},
+		{"roll the dice", false},
+		{"sample mean of 1, 2, 3", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsRandomExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsRandomExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+// dice reads the dice of a roll's "> NdM: a, b, c" lines, leaving out any dropped
+func dice(t *testing.T, text string) []int {
+	t.Helper()
+	var rolled []int
+	for _, line := range strings.Split(text, "\n")[1:] {
+		_, list, _ := strings.Cut(line, ": ")
+		list, _, _ = strings.Cut(list, " (dropped")
+		for _, s := range strings.Split(list, ", ") {
+			v, err := strconv.Atoi(s)
+			if err != nil {
+				t.Fatalf("roll line %q: %v", line, err)
+			}
+			rolled = append(rolled, v)
+		}
+	}
+	return rolled
+}
+
+func TestEvalRandomRoll(t *testing.T) {
+	for range 50 {
+		r, err := EvalRandom("roll 2d20 + 5")
+		if err != nil {
+			t.Fatal(err)
+		}
+		rolled := dice(t, r.Text)
+		if len(rolled) != 2 {
+			t.Fatalf("roll 2d20 + 5 = %q, want two dice", r.Text)
+		}
+		total := 5
+		for _, v := range rolled {
+			if v < 1 || v > 20 {
+				t.Fatalf("roll 2d20 + 5 rolled %d", v)
+			}
+			total += v
+		}
+		if r.Value != float64(total) || !strings.HasPrefix(r.Text, strconv.Itoa(total)+"\n> 2d20: ") {
+			t.Fatalf("roll 2d20 + 5 = %q (%v), want a total of %d", r.Text, r.Value, total)
+		}
+	}
+}
+
+func TestEvalRandomDrop(t *testing.T) {
+	defer SetRandSource(nil)
+	// Each byte is a die of 8 sides; the top 2 of 8 are drawn again
+	SetRandSource(bytes.NewReader([]byte{4, 0, 5, 2}))
+	r, err := EvalRandom("roll 4d6 drop lowest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Text != "14\n> 4d6: 5, 1, 6, 3 (dropped 1)" || r.Value != 14 {
+		t.Errorf("roll 4d6 drop lowest = %q (%v), want 14 with the 1 dropped", r.Text, r.Value)
+	}
+
+	SetRandSource(bytes.NewReader([]byte{4, 0, 5, 2}))
+	r, _ = EvalRandom("roll 4d6 drop highest 2")
+	if r.Text != "4\n> 4d6: 5, 1, 6, 3 (dropped 5, 6)" {
+		t.Errorf("roll 4d6 drop highest 2 = %q, want 4 with the 5 and 6 dropped", r.Text)
+	}
+}
+
+func TestEvalRandomSample(t *testing.T) {
+	for range 50 {
+		r, err := EvalRandom("sample 5 from 1..50")
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts := strings.Split(r.Text, ", ")
+		if len(parts) != 5 {
+			t.Fatalf("sample 5 from 1..50 = %q, want 5 numbers", r.Text)
+		}
+		prev := 0
+		for _, p := range parts {
+			v, _ := strconv.Atoi(p)
+			if v <= prev || v > 50 {
+				t.Fatalf("sample 5 from 1..50 = %q, want distinct numbers in order", r.Text)
+			}
+			prev = v
+		}
+	}
+
+	// Every number of the range, when all of them are drawn
+	r, _ := EvalRandom("sample 5 from 10 to 14")
+	if r.Text != "10, 11, 12, 13, 14" {
+		t.Errorf("sample 5 from 10 to 14 = %q, want all five", r.Text)
+	}
+}
+
+func TestEvalRandomErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{"sample 9 from 1..5", "can't draw 9 distinct numbers from 5"},
+		{"roll 2d6 drop lowest 2", "can't drop 2 of 2 dice"},
+		{"roll 2d6 + 1d4 drop lowest", "drop works on a single set of dice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := EvalRandom(tt.expr)
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("EvalRandom(%q) error = %v, want %s", tt.expr, err, tt.err)
+			}
+		})
+	}
+}
@@ -1,19 +1,20 @@
 package programmer
 
 import (
+	"bytes"
 	"crypto/md5"
-	"crypto/rand"
 	"crypto/sha1"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"math/big"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
 
-	"github.com/google/uuid"
+	"smartcalc/internal/utils"
 )
 
 // Handler defines the interface for programmer utility handlers.
@@ -36,6 +37,8 @@ var handlerChain = []Handler{
 	HandlerFunc(handleBitwiseOr),
 	HandlerFunc(handleBitwiseXor),
 	HandlerFunc(handleBitwiseNot),
+	HandlerFunc(handleBits),
+	HandlerFunc(handleBitOp),
 	HandlerFunc(handleLeftShift),
 	HandlerFunc(handleRightShift),
 	HandlerFunc(handleAsciiToChar),
@@ -46,7 +49,10 @@ var handlerChain = []Handler{
 	HandlerFunc(handleSHA256),
 	HandlerFunc(handleBase64Encode),
 	HandlerFunc(handleBase64Decode),
-	HandlerFunc(handleRandomNumber),
+	HandlerFunc(handleURLEncode),
+	HandlerFunc(handleURLDecode),
+	HandlerFunc(handleJSONPretty),
+	HandlerFunc(handleJSONMinify),
 	HandlerFunc(handlePasswordGenerator),
 }
 
@@ -75,6 +81,8 @@ func IsProgrammerExpression(expr string) bool {
 		`not\s+0x[0-9a-f]+`,
 		`(?:0x[0-9a-f]+|\d+)\s*<<\s*\d+`,
 		`(?:0x[0-9a-f]+|\d+)\s*>>\s*\d+`,
+		`^bits\s+(?:0x[0-9a-f]+|0b[01]+|\d+)(?:\s+as\s+\d+)?$`,
+		`^(?:set|clear|toggle|test)\s+bit\s+\d+\s+of\s+(?:0x[0-9a-f]+|0b[01]+|\d+)$`,
 		`^ascii\s+`,
 		`^ascii\s*table$`,
 		`^char\s+`,
@@ -82,9 +90,10 @@ func IsProgrammerExpression(expr string) bool {
 		`^md5\s+`,
 		`^sha1\s+`,
 		`^sha256\s+`,
-		`^random\s+`,
 		`^base64\s+(?:encode|-e)\s+`,
 		`^base64\s+(?:decode|-d)\s+`,
+		`^url\s+(?:encode|decode)\s+`,
+		`^json\s+(?:pretty|minify)\s+`,
 		`^pwgen`,
 	}
 
@@ -97,6 +106,15 @@ func IsProgrammerExpression(expr string) bool {
 	return false
 }
 
+// textExprPattern matches utilities whose argument is free text or JSON
+var textExprPattern = regexp.MustCompile(`(?i)^(?:url\s+(?:encode|decode)|json\s+(?:pretty|minify))\s+`)
+
+// IsTextExpression checks if an expression carries URL text or JSON that must
+// be kept exactly as typed rather than reformatted like arithmetic.
+func IsTextExpression(expr string) bool {
+	return textExprPattern.MatchString(strings.TrimSpace(expr))
+}
+
 func handleAsciiTable(expr, exprLower string) (string, bool) {
 	// Pattern: "ascii table"
 	if exprLower != "ascii table" {
@@ -116,15 +134,15 @@ func handleAsciiTable(expr, exprLower string) (string, bool) {
 		"CAN", "EM", "SUB", "ESC", "FS", "GS", "RS", "US",
 	}
 	for i := 0; i < 32; i += 4 {
-		sb.WriteString(fmt.Sprintf("\n> %3d 0x%02X %-4s", i, i, controlNames[i]))
+		sb.WriteString(fmt.Sprintf("\n> %3d 0x%02X %s", i, i, utils.PadRight(controlNames[i], 4)))
 		if i+1 < 32 {
-			sb.WriteString(fmt.Sprintf(" | %3d 0x%02X %-4s", i+1, i+1, controlNames[i+1]))
+			sb.WriteString(fmt.Sprintf(" | %3d 0x%02X %s", i+1, i+1, utils.PadRight(controlNames[i+1], 4)))
 		}
 		if i+2 < 32 {
-			sb.WriteString(fmt.Sprintf(" | %3d 0x%02X %-4s", i+2, i+2, controlNames[i+2]))
+			sb.WriteString(fmt.Sprintf(" | %3d 0x%02X %s", i+2, i+2, utils.PadRight(controlNames[i+2], 4)))
 		}
 		if i+3 < 32 {
-			sb.WriteString(fmt.Sprintf(" | %3d 0x%02X %-4s", i+3, i+3, controlNames[i+3]))
+			sb.WriteString(fmt.Sprintf(" | %3d 0x%02X %s", i+3, i+3, utils.PadRight(controlNames[i+3], 4)))
 		}
 	}
 
@@ -134,19 +152,19 @@ func handleAsciiTable(expr, exprLower string) (string, bool) {
 	sb.WriteString("\n> Dec Hex Char | Dec Hex Char | Dec Hex Char | Dec Hex Char")
 	sb.WriteString("\n> --- --- ---- | --- --- ---- | --- --- ---- | --- --- ----")
 	for i := 32; i < 128; i += 4 {
-		sb.WriteString(fmt.Sprintf("\n> %3d %02X  %-4c", i, i, rune(i)))
+		sb.WriteString(fmt.Sprintf("\n> %3d %02X  %s", i, i, utils.PadRight(string(rune(i)), 4)))
 		if i+1 < 128 {
-			sb.WriteString(fmt.Sprintf(" | %3d %02X  %-4c", i+1, i+1, rune(i+1)))
+			sb.WriteString(fmt.Sprintf(" | %3d %02X  %s", i+1, i+1, utils.PadRight(string(rune(i+1)), 4)))
 		}
 		if i+2 < 128 {
-			sb.WriteString(fmt.Sprintf(" | %3d %02X  %-4c", i+2, i+2, rune(i+2)))
+			sb.WriteString(fmt.Sprintf(" | %3d %02X  %s", i+2, i+2, utils.PadRight(string(rune(i+2)), 4)))
 		}
 		if i+3 < 128 {
+			char := string(rune(i + 3))
 			if i+3 == 127 {
-				sb.WriteString(fmt.Sprintf(" | %3d %02X  DEL", i+3, i+3))
-			} else {
-				sb.WriteString(fmt.Sprintf(" | %3d %02X  %-4c", i+3, i+3, rune(i+3)))
+				char = "DEL"
 			}
+			sb.WriteString(fmt.Sprintf(" | %3d %02X  %s", i+3, i+3, utils.PadRight(char, 4)))
 		}
 	}
 
@@ -323,7 +341,7 @@ func handleUUID(expr, exprLower string) (string, bool) {
 		return "", false
 	}
 
-	return uuid.New().String(), true
+	return newUUID().String(), true
 }
 
 func handleMD5(expr, exprLower string) (string, bool) {
@@ -394,31 +412,66 @@ func handleBase64Decode(expr, exprLower string) (string, bool) {
 	return string(decoded), true
 }
 
-func handleRandomNumber(expr, exprLower string) (string, bool) {
-	// Pattern: "random 1 to 100" or "random 1-100"
-	re := regexp.MustCompile(`(?i)^random\s+(\d+)\s*(?:to|-)\s*(\d+)$`)
+func handleURLEncode(expr, exprLower string) (string, bool) {
+	// Pattern: "url encode hello world&x=1" or "url encode 'a b'"
+	re := regexp.MustCompile(`(?i)^url\s+encode\s+['"]?(.+?)['"]?$`)
 	matches := re.FindStringSubmatch(expr)
 	if matches == nil {
 		return "", false
 	}
 
-	min, _ := strconv.Atoi(matches[1])
-	max, _ := strconv.Atoi(matches[2])
+	return url.QueryEscape(matches[1]), true
+}
+
+func handleURLDecode(expr, exprLower string) (string, bool) {
+	// Pattern: "url decode hello+world%26x%3D1"
+	re := regexp.MustCompile(`(?i)^url\s+decode\s+['"]?(.+?)['"]?$`)
+	matches := re.FindStringSubmatch(expr)
+	if matches == nil {
+		return "", false
+	}
 
-	if min > max {
-		min, max = max, min
+	decoded, err := url.QueryUnescape(matches[1])
+	if err != nil {
+		return "ERR: invalid URL encoding", true
 	}
+	return decoded, true
+}
 
-	// Use uuid to generate randomness (simple approach)
-	u := uuid.New()
-	bytes := u[:]
-	var num uint64
-	for _, b := range bytes[:8] {
-		num = num<<8 | uint64(b)
+func handleJSONPretty(expr, exprLower string) (string, bool) {
+	// Pattern: `json pretty {"name":"smartcalc","tags":["a","b"]}`
+	re := regexp.MustCompile(`(?i)^json\s+pretty\s+(.+)$`)
+	matches := re.FindStringSubmatch(expr)
+	if matches == nil {
+		return "", false
 	}
 
-	result := min + int(num%uint64(max-min+1))
-	return fmt.Sprintf("%d", result), true
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(matches[1]), "", "  "); err != nil {
+		return "ERR: invalid JSON", true
+	}
+
+	// Multi-line output: one "> " line per line of JSON
+	var sb strings.Builder
+	for _, line := range strings.Split(buf.String(), "\n") {
+		sb.WriteString("\n> " + line)
+	}
+	return sb.String(), true
+}
+
+func handleJSONMinify(expr, exprLower string) (string, bool) {
+	// Pattern: `json minify { "name": "smartcalc" }`
+	re := regexp.MustCompile(`(?i)^json\s+minify\s+(.+)$`)
+	matches := re.FindStringSubmatch(expr)
+	if matches == nil {
+		return "", false
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(matches[1])); err != nil {
+		return "ERR: invalid JSON", true
+	}
+	return buf.String(), true
 }
 
 func handlePasswordGenerator(expr, exprLower string) (string, bool) {
@@ -472,7 +525,7 @@ func generatePassword(length int, hyphenated bool) string {
 		charset = lowercase + uppercase + digits + special
 	}
 
-	charsetLen := big.NewInt(int64(len(charset)))
+	charsetLen := int64(len(charset))
 	var result strings.Builder
 
 	if hyphenated {
@@ -488,8 +541,7 @@ func generatePassword(length int, hyphenated bool) string {
 				result.WriteString("-")
 			}
 			for i := 0; i < segmentLen; i++ {
-				idx, _ := rand.Int(rand.Reader, charsetLen)
-				result.WriteByte(charset[idx.Int64()])
+				result.WriteByte(charset[randInt(charsetLen)])
 			}
 		}
 
@@ -503,8 +555,7 @@ func generatePassword(length int, hyphenated bool) string {
 
 	// Regular password generation
 	for i := 0; i < length; i++ {
-		idx, _ := rand.Int(rand.Reader, charsetLen)
-		result.WriteByte(charset[idx.Int64()])
+		result.WriteByte(charset[randInt(charsetLen)])
 	}
 
 	return result.String()
@@ -1,10 +1,17 @@
 package programmer
 
 import (
+	"flag"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"smartcalc/internal/utils"
 )
 
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
 func TestBitwiseAnd(t *testing.T) {
 	tests := []struct {
 		expr     string
@@ -219,6 +226,39 @@ func TestAsciiTable(t *testing.T) {
 	}
 }
 
+// TestAsciiTableGolden locks in the layout of the ASCII table: every row is
+// as wide as the header of its section
+func TestAsciiTableGolden(t *testing.T) {
+	result, err := EvalProgrammer("ascii table")
+	if err != nil {
+		t.Fatalf("EvalProgrammer(ascii table) error: %v", err)
+	}
+
+	golden := filepath.Join("testdata", "ascii_table.golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(result), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if result != string(want) {
+		t.Errorf("ascii table differs from %s (run with -update to accept):\n%s", golden, result)
+	}
+
+	width := 0
+	for _, line := range strings.Split(result, "\n") {
+		switch {
+		case strings.HasPrefix(line, "> Dec"):
+			width = utils.DisplayWidth(line)
+		case width > 0 && strings.Contains(line, " | ") && utils.DisplayWidth(line) != width:
+			t.Errorf("row %q is %d columns wide, header %d", line, utils.DisplayWidth(line), width)
+		}
+	}
+}
+
 func TestPasswordGenerator(t *testing.T) {
 	tests := []struct {
 		expr       string
@@ -269,6 +309,58 @@ func TestPasswordGenerator(t *testing.T) {
 	}
 }
 
+func TestURLEncodeDecode(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"url encode hello world&x=1", "hello+world%26x%3D1"},
+		{`url encode "a#b"`, "a%23b"},
+		{"url decode hello+world%26x%3D1", "hello world&x=1"},
+		{"URL DECODE caf%C3%A9", "café"},
+		{"url decode %zz", "ERR: invalid URL encoding"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalProgrammer(tt.expr)
+			if err != nil {
+				t.Errorf("EvalProgrammer(%q) error: %v", tt.expr, err)
+				return
+			}
+			if result != tt.expected {
+				t.Errorf("EvalProgrammer(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestJSONPrettyMinify(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{`json pretty {"name":"smartcalc","tags":["#a"]}`,
+			"\n> {\n>   \"name\": \"smartcalc\",\n>   \"tags\": [\n>     \"#a\"\n>   ]\n> }"},
+		{`json minify { "name": "smartcalc", "n": [1, 2] }`, `{"name":"smartcalc","n":[1,2]}`},
+		{`json pretty {"name":}`, "ERR: invalid JSON"},
+		{`json minify [1, 2`, "ERR: invalid JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalProgrammer(tt.expr)
+			if err != nil {
+				t.Errorf("EvalProgrammer(%q) error: %v", tt.expr, err)
+				return
+			}
+			if result != tt.expected {
+				t.Errorf("EvalProgrammer(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestIsProgrammerExpression(t *testing.T) {
 	tests := []struct {
 		expr     string
@@ -276,6 +368,11 @@ func TestIsProgrammerExpression(t *testing.T) {
 	}{
 		{"0xFF AND 0x0F", true},
 		{"1 << 8", true},
+		{"bits 0xC3", true},
+		{"bits 0xC3 as 16", true},
+		{"set bit 3 of 0x40", true},
+		{"test bit 7 of 0b10000000", true},
+		{"bits of 0xC3", false},
 		{"ascii A", true},
 		{"ascii table", true},
 		{"uuid", true},
@@ -283,6 +380,10 @@ func TestIsProgrammerExpression(t *testing.T) {
 		{"pwgen", true},
 		{"pwgen -c 20", true},
 		{"pwgen -h", true},
+		{"url encode a b", true},
+		{"url decode a+b", true},
+		{`json pretty {"a":1}`, true},
+		{`json minify {"a": 1}`, true},
 		{"100 + 50", false},
 		{"5 miles in km", false},
 	}
@@ -0,0 +1,191 @@
+package programmer
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// fileHashPattern matches "sha256 file ~/Downloads/ubuntu.iso"
+var fileHashPattern = regexp.MustCompile(`(?i)^(md5|sha1|sha256)\s+file\s+(.+)$`)
+
+// verifyPattern matches "verify sha256 <hash> file ~/Downloads/ubuntu.iso"
+var verifyPattern = regexp.MustCompile(`(?i)^verify\s+(md5|sha1|sha256)\s+([0-9a-f]+)\s+file\s+(.+)$`)
+
+// hashers create the hash of each algorithm a file can be hashed with
+var hashers = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+}
+
+// maxHashSize is the largest file hashed, so a mistyped path to a disk image
+// doesn't keep the calculator busy for minutes
+var maxHashSize int64 = 16 << 30
+
+// Progress of a file hash is reported after every progressStep bytes of a
+// file of at least progressThreshold bytes
+const (
+	progressThreshold = 64 << 20
+	progressStep      = 16 << 20
+)
+
+// HashProgress reports how much of a large file has been hashed so far
+type HashProgress struct {
+	Path  string `json:"path"`
+	Done  int64  `json:"done"`
+	Total int64  `json:"total"`
+}
+
+var (
+	progressMu   sync.RWMutex
+	hashProgress func(HashProgress)
+)
+
+// SetHashProgress sets the function told how far the hash of a large file has
+// got; nil turns progress reports off
+func SetHashProgress(progress func(HashProgress)) {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	hashProgress = progress
+}
+
+// reportProgress tells the progress function, if set, how far a hash has got
+func reportProgress(p HashProgress) {
+	progressMu.RLock()
+	progress := hashProgress
+	progressMu.RUnlock()
+	if progress != nil {
+		progress(p)
+	}
+}
+
+// IsFileHashExpression checks if an expression hashes a file or verifies its checksum
+func IsFileHashExpression(expr string) bool {
+	expr = strings.TrimSpace(expr)
+	return fileHashPattern.MatchString(expr) || verifyPattern.MatchString(expr)
+}
+
+// EvalFileHash hashes a file from disk, or checks it against an expected
+// checksum and returns "MATCH" or "MISMATCH (expected ..., got ...)"
+func EvalFileHash(expr string) (string, error) {
+	expr = strings.TrimSpace(expr)
+	if m := fileHashPattern.FindStringSubmatch(expr); m != nil {
+		return hashFile(strings.ToLower(m[1]), m[2])
+	}
+	if m := verifyPattern.FindStringSubmatch(expr); m != nil {
+		algorithm := strings.ToLower(m[1])
+		expected := strings.ToLower(m[2])
+		if size := hashers[algorithm]().Size() * 2; len(expected) != size {
+			return "", fmt.Errorf("a %s checksum is %d hex digits, not %d", algorithm, size, len(expected))
+		}
+		got, err := hashFile(algorithm, m[3])
+		if err != nil {
+			return "", err
+		}
+		if got != expected {
+			return fmt.Sprintf("MISMATCH (expected %s, got %s)", expected, got), nil
+		}
+		return "MATCH", nil
+	}
+	return "", fmt.Errorf("unable to evaluate file hash expression: %s", expr)
+}
+
+// hashFile streams a file through a hash and returns its hex digest
+func hashFile(algorithm, path string) (string, error) {
+	path, err := expandPath(path)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fileError(path, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", fileError(path, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size() > maxHashSize {
+		return "", fmt.Errorf("%s is larger than the %d GiB hashing limit", path, maxHashSize>>30)
+	}
+
+	h := hashers[algorithm]()
+	var r io.Reader = f
+	if info.Size() >= progressThreshold {
+		r = &progressReader{r: f, p: HashProgress{Path: path, Total: info.Size()}}
+	}
+	if _, err := io.Copy(h, r); err != nil {
+		return "", fileError(path, err)
+	}
+	if info.Size() >= progressThreshold {
+		reportProgress(HashProgress{Path: path, Done: info.Size(), Total: info.Size()})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// progressReader reports the progress of a hash after every progressStep bytes read
+type progressReader struct {
+	r        io.Reader
+	p        HashProgress
+	reported int64
+}
+
+// Read reads from the file, reporting progress as it goes
+func (pr *progressReader) Read(b []byte) (int, error) {
+	n, err := pr.r.Read(b)
+	pr.p.Done += int64(n)
+	if pr.p.Done-pr.reported >= progressStep && pr.p.Done < pr.p.Total {
+		pr.reported = pr.p.Done
+		reportProgress(pr.p)
+	}
+	return n, err
+}
+
+// expandPath trims the quotes around a path and expands a leading "~" to the
+// home directory
+func expandPath(path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if len(path) >= 2 && (path[0] == '"' || path[0] == '\'') && path[len(path)-1] == path[0] {
+		path = path[1 : len(path)-1]
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("cannot expand ~: %v", err)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return path, nil
+}
+
+// fileError explains why a file could not be hashed without the "open"
+// prefix of os errors
+func fileError(path string, err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("file not found: %s", path)
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("permission denied: %s", path)
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return fmt.Errorf("%s: %v", path, pathErr.Err)
+	}
+	return err
+}
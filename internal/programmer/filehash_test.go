@@ -0,0 +1,95 @@
+package programmer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile writes content to a file in a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEvalFileHash(t *testing.T) {
+	path := writeFile(t, "hello.txt", "hello")
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"md5 file " + path, "5d41402abc4b2a76b9719d911017c592"},
+		{"sha1 file " + path, "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"},
+		{"SHA256 file " + path, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{`sha256 file "` + path + `"`, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{"verify sha256 2CF24DBA5FB0A30E26E83B2AC5B9E29E1B161E5C1FA7425E73043362938B9824 file " + path, "MATCH"},
+		{"verify md5 098f6bcd4621d373cade4e832627b4f6 file " + path,
+			"MISMATCH (expected 098f6bcd4621d373cade4e832627b4f6, got 5d41402abc4b2a76b9719d911017c592)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if !IsFileHashExpression(tt.expr) {
+				t.Fatalf("IsFileHashExpression(%q) = false", tt.expr)
+			}
+			got, err := EvalFileHash(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalFileHash(%q) error: %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalFileHash(%q) = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalFileHashExpandsHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.WriteFile(filepath.Join(home, "a.txt"), []byte("test"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := EvalFileHash("md5 file ~/a.txt")
+	if err != nil || got != "098f6bcd4621d373cade4e832627b4f6" {
+		t.Errorf("EvalFileHash(md5 file ~/a.txt) = %q, %v", got, err)
+	}
+}
+
+func TestEvalFileHashErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, "data.bin", "0123456789")
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"sha256 file " + filepath.Join(dir, "missing.iso"), "file not found: "},
+		{"sha256 file " + dir, "is a directory"},
+		{"verify sha256 abc123 file " + path, "a sha256 checksum is 64 hex digits, not 6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := EvalFileHash(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("EvalFileHash(%q) error = %v, want %q", tt.expr, err, tt.want)
+			}
+		})
+	}
+
+	defer func(saved int64) { maxHashSize = saved }(maxHashSize)
+	maxHashSize = 5
+	if _, err := EvalFileHash("md5 file " + path); err == nil || !strings.Contains(err.Error(), "hashing limit") {
+		t.Errorf("EvalFileHash() of a file over the limit error = %v", err)
+	}
+}
+
+func TestIsFileHashExpressionKeepsTextHashes(t *testing.T) {
+	for _, expr := range []string{"sha256 hello", "md5 filename", "verify sha256 abc"} {
+		if IsFileHashExpression(expr) {
+			t.Errorf("IsFileHashExpression(%q) = true", expr)
+		}
+	}
+}
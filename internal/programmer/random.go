@@ -0,0 +1,48 @@
+package programmer
+
+import (
+	"crypto/rand"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var (
+	randMu     sync.Mutex
+	randSource io.Reader = rand.Reader
+)
+
+// SetRandSource replaces the random bytes behind uuid, random and pwgen
+// (used by tests for repeatable output). nil restores crypto/rand.
+func SetRandSource(r io.Reader) {
+	randMu.Lock()
+	defer randMu.Unlock()
+	if r == nil {
+		r = rand.Reader
+	}
+	randSource = r
+}
+
+// newUUID returns a random (version 4) UUID from the random source
+func newUUID() uuid.UUID {
+	randMu.Lock()
+	defer randMu.Unlock()
+	u, err := uuid.NewRandomFromReader(randSource)
+	if err != nil {
+		return uuid.New()
+	}
+	return u
+}
+
+// randInt returns a uniform random number in [0, n) from the random source
+func randInt(n int64) int64 {
+	randMu.Lock()
+	defer randMu.Unlock()
+	v, err := rand.Int(randSource, big.NewInt(n))
+	if err != nil {
+		return 0
+	}
+	return v.Int64()
+}
@@ -0,0 +1,25 @@
+package programmer
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSetRandSource(t *testing.T) {
+	defer SetRandSource(nil)
+	seed := bytes.Repeat([]byte{0x42, 0x17, 0x99}, 100)
+
+	SetRandSource(bytes.NewReader(seed))
+	uuid1, _ := handleUUID("uuid", "uuid")
+	pw1 := generatePassword(16, false)
+	SetRandSource(bytes.NewReader(seed))
+	uuid2, _ := handleUUID("uuid", "uuid")
+	pw2 := generatePassword(16, false)
+
+	if uuid1 != uuid2 || pw1 != pw2 {
+		t.Errorf("same random bytes gave %s %s, then %s %s", uuid1, pw1, uuid2, pw2)
+	}
+	if uuid1 != "42179942-1799-4217-9942-179942179942" {
+		t.Errorf("uuid = %s, want the random bytes as a version 4 UUID", uuid1)
+	}
+}
@@ -0,0 +1,53 @@
+package programmer
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// Hashing a file may read gigabytes from disk, so file hashes are
+	// deferred and kept like network lookups, and their paths kept as typed
+	registry.Register(registry.Evaluator{
+		Name:     "filehash",
+		Priority: registry.PriorityFileHash,
+		Traits:   registry.Expensive | registry.NoFormat | registry.ReportsErrors,
+		Detect:   IsFileHashExpression,
+		Eval:     registry.TextEval(EvalFileHash),
+	})
+	// Dice rolls and samples are drawn once: an inactive line keeps what it
+	// shows, and the dice of a roll are "> " lines below its total
+	registry.Register(registry.Evaluator{
+		Name:     "random",
+		Priority: registry.PriorityProgrammer,
+		Traits:   registry.Random | registry.MultiLine | registry.ReportsErrors,
+		Detect:   IsRandomExpression,
+		Eval:     EvalRandom,
+	})
+	// The ASCII table and bit fields are blocks of "> " lines
+	registry.Register(registry.Evaluator{
+		Name:     "programmer",
+		Priority: registry.PriorityProgrammer,
+		Traits:   registry.MultiLine,
+		Detect: func(expr string) bool {
+			return IsProgrammerExpression(expr) && !IsTextExpression(expr)
+		},
+		Eval: registry.TextEval(EvalProgrammer),
+	})
+	// Text helpers keep their text as typed; lengths and word counts are
+	// numbers later lines can reference
+	registry.Register(registry.Evaluator{
+		Name:     "textcase",
+		Priority: registry.PriorityProgrammer,
+		Traits:   registry.NoFormat,
+		Detect:   IsTextCaseExpression,
+		Eval:     EvalTextCase,
+	})
+	// URL text and JSON are kept as typed; pretty JSON is a block of "> " lines
+	registry.Register(registry.Evaluator{
+		Name:     "encoding",
+		Priority: registry.PriorityProgrammer,
+		Traits:   registry.NoFormat | registry.MultiLine,
+		Detect: func(expr string) bool {
+			return IsProgrammerExpression(expr) && IsTextExpression(expr)
+		},
+		Eval: registry.TextEval(EvalProgrammer),
+	})
+}
@@ -0,0 +1,143 @@
+package programmer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"smartcalc/internal/utils"
+)
+
+// textCasePattern matches "upper hello world", "snake SomeVariableName",
+// "length "hello world"" and "count words "a b c"". The text may be quoted to
+// keep its spacing and '#' characters.
+var textCasePattern = regexp.MustCompile(`(?i)^(upper|lower|title|camel|snake|kebab|reverse|length|count\s+(?:words|chars))\s+(.+)$`)
+
+// IsTextCaseExpression checks if an expression transforms or measures text.
+// "reverse dns 8.8.8.8" is a PTR lookup, not text to reverse.
+func IsTextCaseExpression(expr string) bool {
+	m := textCasePattern.FindStringSubmatch(strings.TrimSpace(expr))
+	if m == nil {
+		return false
+	}
+	return !strings.EqualFold(m[1], "reverse") || !strings.HasPrefix(strings.ToLower(m[2]), "dns ")
+}
+
+// EvalTextCase transforms text ("upper", "lower", "title", "camel", "snake",
+// "kebab", "reverse") or measures it ("length" in characters, "count words",
+// "count chars"). The measures are referenceable numbers.
+func EvalTextCase(expr string) (utils.Result, error) {
+	m := textCasePattern.FindStringSubmatch(strings.TrimSpace(expr))
+	if m == nil {
+		return utils.Result{}, fmt.Errorf("invalid text expression")
+	}
+	op := strings.Join(strings.Fields(strings.ToLower(m[1])), " ")
+	text := unquote(m[2])
+
+	switch op {
+	case "upper":
+		return utils.TextResult(strings.ToUpper(text)), nil
+	case "lower":
+		return utils.TextResult(strings.ToLower(text)), nil
+	case "title":
+		return utils.TextResult(titleCase(text)), nil
+	case "camel":
+		return utils.TextResult(camelCase(splitWords(text))), nil
+	case "snake":
+		return utils.TextResult(strings.ToLower(strings.Join(splitWords(text), "_"))), nil
+	case "kebab":
+		return utils.TextResult(strings.ToLower(strings.Join(splitWords(text), "-"))), nil
+	case "reverse":
+		return utils.TextResult(reverseText(text)), nil
+	case "length", "count chars":
+		return countResult(utf8.RuneCountInString(text)), nil
+	case "count words":
+		return countResult(CountText(text).Words), nil
+	}
+	return utils.Result{}, fmt.Errorf("unknown text operation %q", op)
+}
+
+// unquote strips the double or single quotes around text, if it has them
+func unquote(text string) string {
+	if len(text) >= 2 && (text[0] == '"' || text[0] == '\'') && text[len(text)-1] == text[0] {
+		return text[1 : len(text)-1]
+	}
+	return text
+}
+
+// countResult shows a count as a referenceable number
+func countResult(n int) utils.Result {
+	return utils.ValueResult(utils.FormatResult(false, float64(n)), float64(n), false)
+}
+
+// titleCase capitalizes the first letter of every space-separated word and
+// lowercases the rest: "some WORDS here" -> "Some Words Here"
+func titleCase(text string) string {
+	runes := []rune(strings.ToLower(text))
+	for i, r := range runes {
+		if i == 0 || unicode.IsSpace(runes[i-1]) {
+			runes[i] = unicode.ToTitle(r)
+		}
+	}
+	return string(runes)
+}
+
+// splitWords breaks an identifier or phrase into its words at spaces,
+// underscores, hyphens, dots and case changes: "parseHTTPResponse_v2" ->
+// "parse", "HTTP", "Response", "v2"
+func splitWords(text string) []string {
+	var words []string
+	runes := []rune(text)
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start >= 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// "someName" splits before N; "HTTPServer" splits before the S
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// camelCase joins words as lowerCamelCase: "some", "variable", "name" ->
+// "someVariableName"
+func camelCase(words []string) string {
+	var sb strings.Builder
+	for i, w := range words {
+		w = strings.ToLower(w)
+		if i > 0 {
+			r, size := utf8.DecodeRuneInString(w)
+			w = string(unicode.ToUpper(r)) + w[size:]
+		}
+		sb.WriteString(w)
+	}
+	return sb.String()
+}
+
+// reverseText reverses text by characters, so multi-byte letters stay whole
+func reverseText(text string) string {
+	runes := []rune(text)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
@@ -0,0 +1,53 @@
+package programmer
+
+import "testing"
+
+func TestEvalTextCase(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"upper hello world", "HELLO WORLD"},
+		{"lower SHOUTING", "shouting"},
+		{"title some WORDS here", "Some Words Here"},
+		{"camel some_variable_name", "someVariableName"},
+		{"camel Some variable-name", "someVariableName"},
+		{"snake SomeVariableName", "some_variable_name"},
+		{"snake parseHTTPResponse2", "parse_http_response2"},
+		{"kebab SomeVariableName", "some-variable-name"},
+		{"reverse abc", "cba"},
+		{"reverse naïve", "evïan"},
+		{`upper "  keep  # spacing "`, "  KEEP  # SPACING "},
+		{`length "hello world"`, "11"},
+		{"length café", "4"},
+		{`count words "a b c"`, "3"},
+		{`count chars 'a b'`, "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if !IsTextCaseExpression(tt.expr) {
+				t.Fatalf("IsTextCaseExpression(%q) = false", tt.expr)
+			}
+			result, err := EvalTextCase(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalTextCase(%q) error: %v", tt.expr, err)
+			}
+			if result.Text != tt.expected {
+				t.Errorf("EvalTextCase(%q) = %q, want %q", tt.expr, result.Text, tt.expected)
+			}
+		})
+	}
+
+	// Lengths and counts are referenceable
+	if r, _ := EvalTextCase(`count words "one two"`); !r.HasValue || r.Value != 2 {
+		t.Errorf("count words value = %v (has %v), want 2", r.Value, r.HasValue)
+	}
+	if r, _ := EvalTextCase("upper abc"); r.HasValue {
+		t.Error("upper should have no referenceable value")
+	}
+	// A PTR lookup is not text to reverse
+	if IsTextCaseExpression("reverse dns 8.8.8.8") {
+		t.Error("IsTextCaseExpression(reverse dns 8.8.8.8) = true")
+	}
+}
@@ -0,0 +1,133 @@
+package programmer
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"smartcalc/internal/utils"
+)
+
+// wordsPerMinute is the reading speed reading times are estimated at
+const wordsPerMinute = 200
+
+// textStatsPattern matches "stats of "some text"", "wordcount \3" and
+// "reading time of \2..\6": a quoted subject or the text of referenced lines
+var textStatsPattern = regexp.MustCompile(`(?i)^(?:(?:text\s+)?stats\s+of|word\s*count(?:\s+of)?|reading\s+time\s+of)\s+("(?:[^"]*)"|'(?:[^']*)'|\\\d+(?:\.\.\\\d+)?)$`)
+
+// TextStats are the counts of a piece of text
+type TextStats struct {
+	Runes     int // characters, as Unicode code points
+	Bytes     int // UTF-8 bytes
+	Words     int
+	Sentences int
+}
+
+// IsTextStatsExpression checks if an expression counts the words of a text
+func IsTextStatsExpression(expr string) bool {
+	return textStatsPattern.MatchString(strings.TrimSpace(expr))
+}
+
+// TextStatsSubject returns the subject of a text statistics expression: the
+// text of a quoted subject, or the line references ("\3", "\2..\6") whose
+// text is counted, with quoted false
+func TextStatsSubject(expr string) (subject string, quoted bool) {
+	m := textStatsPattern.FindStringSubmatch(strings.TrimSpace(expr))
+	if m == nil {
+		return "", false
+	}
+	if strings.HasPrefix(m[1], `\`) {
+		return m[1], false
+	}
+	return m[1][1 : len(m[1])-1], true
+}
+
+// CountText counts the characters, words and sentences of a text. A word is
+// a run of letters and digits, which may be joined by an apostrophe or a
+// hyphen ("don't", "well-known"); a sentence ends with ".", "!", "?" or the
+// end of the text.
+func CountText(text string) TextStats {
+	s := TextStats{Runes: utf8.RuneCountInString(text), Bytes: len(text)}
+	runes := []rune(text)
+	inWord, inSentence := false, false
+	for i, r := range runes {
+		switch {
+		case isWordRune(r):
+			if !inWord {
+				s.Words++
+			}
+			inWord, inSentence = true, true
+		case inWord && isWordJoiner(r, runes, i):
+			// "don't", "3.14": the word goes on
+		case isSentenceEnd(r):
+			if inSentence {
+				s.Sentences++
+			}
+			inWord, inSentence = false, false
+		default:
+			inWord = false
+		}
+	}
+	if inSentence {
+		s.Sentences++
+	}
+	return s
+}
+
+// isWordRune reports whether r is part of a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+// isWordJoiner reports whether runes[i] joins two parts of one word: an
+// apostrophe or hyphen between letters, or a decimal point between digits
+func isWordJoiner(r rune, runes []rune, i int) bool {
+	if i == 0 || i+1 >= len(runes) {
+		return false
+	}
+	prev, next := runes[i-1], runes[i+1]
+	switch r {
+	case '\'', '’', '-':
+		return isWordRune(prev) && isWordRune(next)
+	case '.', ',':
+		return unicode.IsDigit(prev) && unicode.IsDigit(next)
+	}
+	return false
+}
+
+// isSentenceEnd reports whether r ends a sentence
+func isSentenceEnd(r rune) bool {
+	return strings.ContainsRune(".!?…。！？", r)
+}
+
+// ReadingTime estimates how long words take to read at 200 words a minute.
+// Any words at all take at least a second.
+func ReadingTime(words int) string {
+	secs := int(math.Round(float64(words) * 60 / wordsPerMinute))
+	if words > 0 {
+		secs = max(secs, 1)
+	}
+	if secs < 60 {
+		return fmt.Sprintf("%d sec", secs)
+	}
+	if secs%60 == 0 {
+		return fmt.Sprintf("%d min", secs/60)
+	}
+	return fmt.Sprintf("%d min %d sec", secs/60, secs%60)
+}
+
+// EvalTextStats reports the counts of a text on one line: "42 words, 230
+// characters (236 bytes), 3 sentences, reading time 13 sec". Its value is the
+// number of words.
+func EvalTextStats(text string) utils.Result {
+	s := CountText(text)
+	out := fmt.Sprintf("%s %s, %s %s (%s %s), %d %s, reading time %s",
+		utils.FormatResult(false, float64(s.Words)), utils.Plural(float64(s.Words), "word", "words"),
+		utils.FormatResult(false, float64(s.Runes)), utils.Plural(float64(s.Runes), "character", "characters"),
+		utils.FormatResult(false, float64(s.Bytes)), utils.Plural(float64(s.Bytes), "byte", "bytes"),
+		s.Sentences, utils.Plural(float64(s.Sentences), "sentence", "sentences"), ReadingTime(s.Words))
+	return utils.ValueResult(out, float64(s.Words), false)
+}
@@ -0,0 +1,74 @@
+package programmer
+
+import "testing"
+
+func TestCountText(t *testing.T) {
+	tests := []struct {
+		text string
+		want TextStats
+	}{
+		{"", TextStats{}},
+		{"Hello, world. How are you?", TextStats{Runes: 26, Bytes: 26, Words: 5, Sentences: 2}},
+		// Apostrophes, hyphens and decimal points stay inside words
+		{"Don't panic: a well-known 3.14 value", TextStats{Runes: 36, Bytes: 36, Words: 6, Sentences: 1}},
+		// Characters are runes, not bytes
+		{"naïve café…", TextStats{Runes: 11, Bytes: 15, Words: 2, Sentences: 1}},
+		{"Wait... what?!", TextStats{Runes: 14, Bytes: 14, Words: 2, Sentences: 2}},
+		{"日本語 テキスト", TextStats{Runes: 8, Bytes: 22, Words: 2, Sentences: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := CountText(tt.text); got != tt.want {
+				t.Errorf("CountText(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	tests := []struct {
+		words int
+		want  string
+	}{
+		{0, "0 sec"},
+		{1, "1 sec"},
+		{150, "45 sec"},
+		{400, "2 min"},
+		{450, "2 min 15 sec"},
+	}
+	for _, tt := range tests {
+		if got := ReadingTime(tt.words); got != tt.want {
+			t.Errorf("ReadingTime(%d) = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+}
+
+func TestEvalTextStats(t *testing.T) {
+	tests := []struct {
+		expr    string
+		subject string
+		quoted  bool
+	}{
+		{`stats of "Hello, world."`, "Hello, world.", true},
+		{`wordcount \3`, `\3`, false},
+		{`word count of \2..\6`, `\2..\6`, false},
+		{`reading time of "a b c"`, "a b c", true},
+		{`text stats of 'one two'`, "one two", true},
+		{`wordcount hello`, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			subject, quoted := TextStatsSubject(tt.expr)
+			if subject != tt.subject || quoted != tt.quoted {
+				t.Errorf("TextStatsSubject(%q) = %q, %v, want %q, %v", tt.expr, subject, quoted, tt.subject, tt.quoted)
+			}
+		})
+	}
+
+	r := EvalTextStats("Hello, wörld. How are you?")
+	want := "5 words, 26 characters (27 bytes), 2 sentences, reading time 2 sec"
+	if r.Text != want || r.Value != 5 {
+		t.Errorf("EvalTextStats() = %q (%v), want %q (5)", r.Text, r.Value, want)
+	}
+}
@@ -0,0 +1,352 @@
+package radio
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// copperResistivity is the resistivity of annealed copper at 20 °C in Ω·m,
+// the value AWG resistance tables are published for
+const copperResistivity = 1.724e-8
+
+// awgPattern captures a wire gauge written "14 awg", "awg 14" or "4/0 awg".
+// Gauges thicker than 1 are written 1/0 to 4/0, or 0 to 0000.
+const awgPattern = `(?:(\d{1,2}|[1-4]/0|0{1,4})\s*awg|awg\s*(\d{1,2}|[1-4]/0|0{1,4}))`
+
+// lengthPattern captures a wire run with its unit: "5m", "30 ft"
+const lengthPattern = `([\d.]+)\s*(m|meters?|metres?|ft|feet|foot)`
+
+// voltageDropPattern matches "voltage drop 12v 10a over 5m of 14 awg": the
+// supply voltage, the load current, the one-way length of the run and the gauge
+var voltageDropPattern = regexp.MustCompile(`(?i)^voltage\s+drop\s+(?:for\s+)?([\d.]+)\s*` + siPrefixPattern + `\s*(?:v|volts?)\s+(?:at\s+)?` +
+	`([\d.]+)\s*` + siPrefixPattern + `\s*(?:a|amps?)\s+(?:over|for|along)\s+` + lengthPattern + `\s+(?:of\s+)?` + awgPattern + `(?:\s+(?:copper|wire))*$`)
+
+// wireGaugePattern matches "wire gauge for 20a at 12v max 3% drop over 10m":
+// the load current, the supply voltage, the largest drop allowed (3% when
+// left out) and the one-way length of the run
+var wireGaugePattern = regexp.MustCompile(`(?i)^(?:wire\s+)?(?:gauge|awg)\s+for\s+([\d.]+)\s*` + siPrefixPattern + `\s*(?:a|amps?)\s+(?:at\s+)?` +
+	`([\d.]+)\s*` + siPrefixPattern + `\s*(?:v|volts?)\s+(?:(?:max(?:imum)?\s+)?([\d.]+)\s*%\s*(?:drop\s+)?)?(?:over|for|along)\s+` + lengthPattern + `$`)
+
+// resistorToColorsPattern matches "resistor 4.7k ohm to colors", with an
+// optional tolerance and band count: "resistor 4.75k 1% to 5 band colors"
+var resistorToColorsPattern = regexp.MustCompile(`(?i)^resistor\s+([\d.]+)\s*` + siPrefixPattern + `\s*(?:ohms?|Ω)?\s*(?:±\s*([\d.]+)\s*%\s*|([\d.]+)\s*%\s*)?` +
+	`(?:to|in)\s+(?:([45])[- ]?band\s+)?colou?r(?:s|\s+code)?$`)
+
+// resistorFromColorsPattern matches "resistor colors yellow violet red gold"
+var resistorFromColorsPattern = regexp.MustCompile(`(?i)^resistor\s+colou?r(?:s|\s+code)?\s+(.+)$`)
+
+// awgGauges lists the gauges from the thickest, 4/0, to the thinnest, 40.
+// 1/0 to 4/0 are numbered 0 to -3.
+var awgGauges = func() []int {
+	var gauges []int
+	for n := -3; n <= 40; n++ {
+		gauges = append(gauges, n)
+	}
+	return gauges
+}()
+
+// parseAWG reads a gauge as captured by awgPattern: "14", "2/0" or "00"
+func parseAWG(s string) (int, bool) {
+	if before, ok := strings.CutSuffix(s, "/0"); ok {
+		n, _ := strconv.Atoi(before)
+		return 1 - n, true
+	}
+	if strings.Trim(s, "0") == "" {
+		return 1 - len(s), true
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil && n <= 40
+}
+
+// awgName writes a gauge as it is printed on wire: "14 AWG", "2/0 AWG"
+func awgName(n int) string {
+	if n <= 0 {
+		return fmt.Sprintf("%d/0 AWG", 1-n)
+	}
+	return fmt.Sprintf("%d AWG", n)
+}
+
+// awgDiameter returns the diameter in meters of a gauge: 0.127 mm at 36 AWG,
+// growing 92 times over the 39 steps to 4/0
+func awgDiameter(n int) float64 {
+	return 0.127e-3 * math.Pow(92, float64(36-n)/39)
+}
+
+// awgArea returns the cross-section of a gauge in square meters
+func awgArea(n int) float64 {
+	d := awgDiameter(n)
+	return math.Pi * d * d / 4
+}
+
+// awgOhmsPerMeter returns the resistance of a meter of copper wire of a gauge
+func awgOhmsPerMeter(n int) float64 {
+	return copperResistivity / awgArea(n)
+}
+
+// lengthMeters converts a length captured by lengthPattern to meters
+func lengthMeters(number, unit string) float64 {
+	value, _ := strconv.ParseFloat(number, 64)
+	if strings.HasPrefix(strings.ToLower(unit), "f") {
+		return value * 0.3048
+	}
+	return value
+}
+
+// handleVoltageDrop calculates the voltage lost in the copper wires feeding a
+// load. The current flows out and back, so twice the run is counted.
+// Examples: "voltage drop 12v 10a over 5m of 14 awg", "voltage drop 120v 15a over 50 ft of awg 12"
+func handleVoltageDrop(expr, exprLower string) (string, bool) {
+	matches := voltageDropPattern.FindStringSubmatch(expr)
+	if matches == nil {
+		return "", false
+	}
+	supply := parseSIValue(matches[1], matches[2])
+	current := parseSIValue(matches[3], matches[4])
+	run := lengthMeters(matches[5], matches[6])
+	gauge, ok := parseAWG(matches[7] + matches[8])
+	if !ok || supply <= 0 || run <= 0 {
+		return "", false
+	}
+
+	// Vdrop = I * R, R = 2 * length * Ω/m
+	resistance := 2 * run * awgOhmsPerMeter(gauge)
+	drop := current * resistance
+
+	return fmt.Sprintf("%s (%.2f%%)\n> Wire resistance: %s (%s of %s there and back)\n> At the load: %s\n> Power lost: %s",
+		formatSI(drop, "V"), drop/supply*100,
+		formatSI(resistance, "Ω"), formatWireLength(2*run), awgName(gauge),
+		formatSI(supply-drop, "V"), formatSI(drop*current, "W")), true
+}
+
+// handleWireGauge recommends the thinnest copper wire that keeps the voltage
+// drop of a run within a share of the supply, 3% unless given
+// Examples: "wire gauge for 20a at 12v max 3% drop over 10m", "gauge for 5a at 24v over 30 ft"
+func handleWireGauge(expr, exprLower string) (string, bool) {
+	matches := wireGaugePattern.FindStringSubmatch(expr)
+	if matches == nil {
+		return "", false
+	}
+	current := parseSIValue(matches[1], matches[2])
+	supply := parseSIValue(matches[3], matches[4])
+	maxDrop := 3.0
+	if matches[5] != "" {
+		maxDrop, _ = strconv.ParseFloat(matches[5], 64)
+	}
+	run := lengthMeters(matches[6], matches[7])
+	if current <= 0 || supply <= 0 || run <= 0 || maxDrop <= 0 {
+		return "", false
+	}
+
+	dropPercent := func(gauge int) float64 {
+		return current * 2 * run * awgOhmsPerMeter(gauge) / supply * 100
+	}
+	// Gauges run from thick to thin, so the last one within the limit is
+	// the thinnest that will do
+	best, found := 0, false
+	for _, gauge := range awgGauges {
+		if dropPercent(gauge) <= maxDrop {
+			best, found = gauge, true
+		}
+	}
+	if !found {
+		return fmt.Sprintf("no gauge up to 4/0 AWG keeps the drop within %s%%", strconv.FormatFloat(maxDrop, 'f', -1, 64)), true
+	}
+
+	resistance := 2 * run * awgOhmsPerMeter(best)
+	result := fmt.Sprintf("%s\n> Drop: %s (%.2f%%)\n> Wire resistance: %s (%s there and back)\n> Diameter: %.3f mm (%.2f mm²)",
+		awgName(best), formatSI(current*resistance, "V"), dropPercent(best),
+		formatSI(resistance, "Ω"), formatWireLength(2*run),
+		awgDiameter(best)*1e3, awgArea(best)*1e6)
+	if best < 40 {
+		result += fmt.Sprintf("\n> %s would drop %.2f%%", awgName(best+1), dropPercent(best+1))
+	}
+	return result, true
+}
+
+// formatWireLength writes a length of wire in meters: "10 m", "15.24 m"
+func formatWireLength(meters float64) string {
+	return strconv.FormatFloat(math.Round(meters*100)/100, 'f', -1, 64) + " m"
+}
+
+// resistorColors are the colors of the digit bands, black for 0 to white for 9
+var resistorColors = []string{"black", "brown", "red", "orange", "yellow", "green", "blue", "violet", "grey", "white"}
+
+// colorAliases are other names used for band colors
+var colorAliases = map[string]string{"gray": "grey", "purple": "violet"}
+
+// multiplierColors are the colors of the multiplier band beyond the digits,
+// by power of ten
+var multiplierColors = map[int]string{-2: "silver", -1: "gold"}
+
+// toleranceColors are the colors of the tolerance band, by percent
+var toleranceColors = []struct {
+	color   string
+	percent float64
+}{
+	{"brown", 1}, {"red", 2}, {"green", 0.5}, {"blue", 0.25},
+	{"violet", 0.1}, {"grey", 0.05}, {"gold", 5}, {"silver", 10},
+}
+
+// resistorColorCode returns the bands of a resistor: the significant digits,
+// the power of ten they are multiplied by, and the tolerance band, if any.
+// ok is false when the value needs more digits than the bands have.
+func resistorColorCode(value float64, digits int, tolerance float64) (bands []string, ok bool) {
+	for exp := -2; exp <= 9; exp++ {
+		scale := math.Pow(10, float64(exp))
+		significant := math.Round(value / scale)
+		if math.Abs(significant*scale-value) > value*1e-9 || significant >= math.Pow(10, float64(digits)) {
+			continue
+		}
+		s := fmt.Sprintf("%0*d", digits, int(significant))
+		for _, d := range s {
+			bands = append(bands, resistorColors[d-'0'])
+		}
+		if exp >= 0 {
+			bands = append(bands, resistorColors[exp])
+		} else {
+			bands = append(bands, multiplierColors[exp])
+		}
+		if tolerance == 20 {
+			return bands, true
+		}
+		for _, t := range toleranceColors {
+			if t.percent == tolerance {
+				return append(bands, t.color), true
+			}
+		}
+		return nil, false
+	}
+	return nil, false
+}
+
+// handleResistorToColors gives the color bands of a resistor value. Values
+// with two significant digits get 4 bands, ±5% unless given; values that need
+// three, or "5 band", get 5 bands, ±1% unless given.
+// Examples: "resistor 4.7k ohm to colors", "resistor 10k 1% to 5 band colors"
+func handleResistorToColors(expr, exprLower string) (string, bool) {
+	matches := resistorToColorsPattern.FindStringSubmatch(expr)
+	if matches == nil {
+		return "", false
+	}
+	value := parseSIValue(matches[1], matches[2])
+	if value <= 0 {
+		return "", false
+	}
+	tolerance, hasTolerance := 0.0, matches[3]+matches[4] != ""
+	if hasTolerance {
+		tolerance, _ = strconv.ParseFloat(matches[3]+matches[4], 64)
+	}
+
+	digits := []int{2, 3}
+	switch matches[5] {
+	case "4":
+		digits = []int{2}
+	case "5":
+		digits = []int{3}
+	}
+	for _, d := range digits {
+		t := tolerance
+		if !hasTolerance {
+			t = map[int]float64{2: 5, 3: 1}[d]
+		}
+		if bands, ok := resistorColorCode(value, d, t); ok {
+			return strings.Join(bands, " "), true
+		}
+	}
+	if hasTolerance {
+		return fmt.Sprintf("no color bands for %s ±%s%%", formatResistance(value), matches[3]+matches[4]), true
+	}
+	return fmt.Sprintf("no color bands for %s", formatResistance(value)), true
+}
+
+// handleResistorFromColors reads the value of a resistor from its color
+// bands: 3 bands are two digits and a multiplier at ±20%, 4 bands add the
+// tolerance, and 5 bands have three digits
+// Examples: "resistor colors yellow violet red gold", "resistor colors brown black black red brown"
+func handleResistorFromColors(expr, exprLower string) (string, bool) {
+	matches := resistorFromColorsPattern.FindStringSubmatch(expr)
+	if matches == nil {
+		return "", false
+	}
+	bands := strings.FieldsFunc(strings.ToLower(matches[1]), func(r rune) bool {
+		return r == ' ' || r == ',' || r == '-'
+	})
+	if len(bands) < 3 || len(bands) > 5 {
+		return "", false
+	}
+	for i, b := range bands {
+		if alias, ok := colorAliases[b]; ok {
+			bands[i] = alias
+		}
+	}
+
+	digitCount := 2
+	if len(bands) == 5 {
+		digitCount = 3
+	}
+	significant := 0
+	for _, b := range bands[:digitCount] {
+		d := colorDigit(b)
+		if d < 0 {
+			return fmt.Sprintf("%q is not a digit band color", b), true
+		}
+		significant = significant*10 + d
+	}
+
+	exp, ok := multiplierExponent(bands[digitCount])
+	if !ok {
+		return fmt.Sprintf("%q is not a multiplier band color", bands[digitCount]), true
+	}
+	value := float64(significant) * math.Pow(10, float64(exp))
+
+	tolerance := 20.0
+	if len(bands) > digitCount+1 {
+		toleranceBand, found := bands[digitCount+1], false
+		for _, t := range toleranceColors {
+			if t.color == toleranceBand {
+				tolerance, found = t.percent, true
+			}
+		}
+		if !found {
+			return fmt.Sprintf("%q is not a tolerance band color", toleranceBand), true
+		}
+	}
+	return fmt.Sprintf("%s ±%s%%", formatResistance(value), strconv.FormatFloat(tolerance, 'f', -1, 64)), true
+}
+
+// colorDigit returns the digit of a band color, or -1 for gold, silver or
+// an unknown color
+func colorDigit(color string) int {
+	for d, c := range resistorColors {
+		if c == color {
+			return d
+		}
+	}
+	return -1
+}
+
+// multiplierExponent returns the power of ten of a multiplier band color
+func multiplierExponent(color string) (int, bool) {
+	if d := colorDigit(color); d >= 0 {
+		return d, true
+	}
+	for exp, c := range multiplierColors {
+		if c == color {
+			return exp, true
+		}
+	}
+	return 0, false
+}
+
+// formatResistance writes a resistor value the way it is marked, with only
+// the digits it needs: 4700 is "4.7 kΩ", 0.47 is "470 mΩ"
+func formatResistance(value float64) string {
+	exp := int(math.Floor(math.Log10(value) / 3))
+	exp = max(-1, min(3, exp))
+	scaled := value / math.Pow(1000, float64(exp))
+	scaled = math.Round(scaled*1e6) / 1e6
+	return strconv.FormatFloat(scaled, 'f', -1, 64) + " " + siPrefixes[exp+4] + "Ω"
+}
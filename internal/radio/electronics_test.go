@@ -0,0 +1,179 @@
+package radio
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestAWGOhmsPerMeter(t *testing.T) {
+	// Published resistance of annealed copper wire at 20 °C, in Ω per km
+	tests := []struct {
+		gauge    string
+		ohmsPerK float64
+	}{
+		{"4/0", 0.1608},
+		{"0", 0.3224},
+		{"4", 0.8152},
+		{"10", 3.277},
+		{"12", 5.211},
+		{"14", 8.286},
+		{"18", 20.95},
+		{"22", 52.96},
+		{"30", 338.6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.gauge, func(t *testing.T) {
+			gauge, ok := parseAWG(tt.gauge)
+			if !ok {
+				t.Fatalf("parseAWG(%q) failed", tt.gauge)
+			}
+			got := awgOhmsPerMeter(gauge) * 1000
+			if math.Abs(got-tt.ohmsPerK)/tt.ohmsPerK > 0.005 {
+				t.Errorf("%s AWG = %.4f Ω/km, want %.4f", tt.gauge, got, tt.ohmsPerK)
+			}
+		})
+	}
+}
+
+func TestParseAWG(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+		name     string
+	}{
+		{"14", 14, "14 AWG"},
+		{"0", 0, "1/0 AWG"},
+		{"1/0", 0, "1/0 AWG"},
+		{"00", -1, "2/0 AWG"},
+		{"4/0", -3, "4/0 AWG"},
+		{"0000", -3, "4/0 AWG"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := parseAWG(tt.input)
+			if !ok || got != tt.expected {
+				t.Fatalf("parseAWG(%q) = %d, %v, want %d", tt.input, got, ok, tt.expected)
+			}
+			if name := awgName(got); name != tt.name {
+				t.Errorf("awgName(%d) = %q, want %q", got, name, tt.name)
+			}
+		})
+	}
+}
+
+func TestVoltageDrop(t *testing.T) {
+	tests := []struct {
+		expr     string
+		contains []string
+	}{
+		// 10 m of 14 AWG at 8.286 Ω/km is 82.86 mΩ; 10 A drops 0.829 V of 12 V
+		{"voltage drop 12v 10a over 5m of 14 awg", []string{"828.", "mV (6.90%)", "10 m of 14 AWG", "At the load: 11.17"}},
+		// 50 ft each way is 30.48 m of 12 AWG
+		{"voltage drop 120v 15a over 50 ft of awg 12", []string{"2.38", "V (1.99%)", "30.48 m of 12 AWG"}},
+		{"voltage drop 12v 100a over 3m of 4/0 awg", []string{"(0.80%)", "4/0 AWG"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalRadio(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalRadio(%q) error: %v", tt.expr, err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(result, want) {
+					t.Errorf("EvalRadio(%q) = %q, want it to contain %q", tt.expr, result, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWireGauge(t *testing.T) {
+	tests := []struct {
+		expr     string
+		headline string
+		contains string
+	}{
+		// 20 A over 20 m of wire within 0.36 V needs at most 0.9 mΩ/m
+		{"wire gauge for 20a at 12v max 3% drop over 10m", "4 AWG", "5 AWG would drop 3.43%"},
+		{"gauge for 5a at 24v over 30 ft", "13 AWG", "(2.50%)"},
+		{"wire gauge for 1a at 120v max 5% drop over 10 m", "29 AWG", "Diameter: 0.286 mm"},
+		{"wire gauge for 200a at 12v max 1% drop over 100m", "no gauge up to 4/0 AWG keeps the drop within 1%", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalRadio(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalRadio(%q) error: %v", tt.expr, err)
+			}
+			headline, _, _ := strings.Cut(result, "\n")
+			if headline != tt.headline {
+				t.Errorf("EvalRadio(%q) headline = %q, want %q", tt.expr, headline, tt.headline)
+			}
+			if !strings.Contains(result, tt.contains) {
+				t.Errorf("EvalRadio(%q) = %q, want it to contain %q", tt.expr, result, tt.contains)
+			}
+		})
+	}
+}
+
+func TestResistorToColors(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"resistor 4.7k ohm to colors", "yellow violet red gold"},
+		{"resistor 1M ohm to colours", "brown black green gold"},
+		{"resistor 0.47 ohm to colors", "yellow violet silver gold"},
+		{"resistor 220 ohm 10% to colors", "red red brown silver"},
+		{"resistor 4.75k to colors", "yellow violet green brown brown"},
+		{"resistor 10k 1% to 5 band colors", "brown black black red brown"},
+		{"resistor 4.75k to 4 band colors", "no color bands for 4.75 kΩ"},
+		{"resistor 4.7k 3% to colors", "no color bands for 4.7 kΩ ±3%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalRadio(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalRadio(%q) error: %v", tt.expr, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvalRadio(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResistorFromColors(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"resistor colors yellow violet red gold", "4.7 kΩ ±5%"},
+		{"resistor colors brown black black red brown", "10 kΩ ±1%"},
+		{"resistor colors red red orange", "22 kΩ ±20%"},
+		{"resistor colors yellow violet gold silver", "4.7 Ω ±10%"},
+		{"resistor colours brown, black, blue, gold", "10 MΩ ±5%"},
+		{"resistor color code orange gray purple-gold", "380 MΩ ±5%"},
+		{"resistor colors pink violet red gold", `"pink" is not a digit band color`},
+		{"resistor colors yellow violet pink gold", `"pink" is not a multiplier band color`},
+		{"resistor colors yellow violet red black", `"black" is not a tolerance band color`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalRadio(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalRadio(%q) error: %v", tt.expr, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvalRadio(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
@@ -33,10 +33,17 @@ var handlerChain = []Handler{
 	HandlerFunc(handleYagiElements),
 	HandlerFunc(handleFreeToCable),
 	HandlerFunc(handleSWR),
+	HandlerFunc(handleDecibelMath),
 	HandlerFunc(handleDecibelConversion),
 	HandlerFunc(handlePowerConversion),
 	HandlerFunc(handleBandInfo),
+	HandlerFunc(handleVoltageDivider),
+	HandlerFunc(handleVoltageDrop),
+	HandlerFunc(handleWireGauge),
+	HandlerFunc(handleResistorToColors),
+	HandlerFunc(handleResistorFromColors),
 	HandlerFunc(handleOhmsLaw),
+	HandlerFunc(handleResistors),
 }
 
 // EvalRadio evaluates a radio/electrical expression and returns the result.
@@ -71,6 +78,8 @@ func IsRadioExpression(expr string) bool {
 		"db to times", "times to db",
 		"radio band", "ham band", "amateur band", "m band", "cm band",
 		"ohm", "volts", "amps", "watts",
+		"divider",
+		"voltage drop", "awg", "resistor colo",
 	}
 
 	for _, kw := range keywords {
@@ -87,10 +96,15 @@ func IsRadioExpression(expr string) bool {
 		`(?:quarter[- ]?wave|1/4\s*wave|λ/4)\s+(?:for\s+)?\d+`,
 		`swr\s+\d+`,
 		`\d+\.?\d*\s*dbm`,
-		`\d+\.?\d*\s*(?:v|volts?)\s+\d+\.?\d*\s*(?:a|amps?|ohms?|w|watts?)`,
-		`\d+\.?\d*\s*(?:a|amps?)\s+\d+\.?\d*\s*(?:v|volts?|ohms?|w|watts?)`,
-		`\d+\.?\d*\s*(?:ohms?)\s+\d+\.?\d*\s*(?:v|volts?|a|amps?|w|watts?)`,
-		`\d+\.?\d*\s*(?:w|watts?)\s+\d+\.?\d*\s*(?:v|volts?|a|amps?|ohms?)`,
+		`\d+\.?\d*\s*[kmµμu]?\s*(?:v|volts?)\s+\d+\.?\d*\s*[kmµμu]?\s*(?:a|amps?|ohms?|w|watts?)`,
+		`\d+\.?\d*\s*[kmµμu]?\s*(?:a|amps?)\s+\d+\.?\d*\s*[kmµμu]?\s*(?:v|volts?|ohms?|w|watts?)`,
+		`\d+\.?\d*\s*[kmµμu]?\s*(?:ohms?)\s+\d+\.?\d*\s*[kmµμu]?\s*(?:v|volts?|a|amps?|w|watts?)`,
+		`\d+\.?\d*\s*[kmµμu]?\s*(?:w|watts?)\s+\d+\.?\d*\s*[kmµμu]?\s*(?:v|volts?|a|amps?|ohms?)`,
+		`^resistors?\s+.+\s+in\s+(?:parallel|series)$`,
+		`^(?:wire\s+)?gauge\s+for\s+\d`,
+		`^resistor\s+.+\s+(?:to|in)\s+(?:[45][- ]?band\s+)?colou?r`,
+		`^(?:add|sum|combine|difference\s+between)\s+-?[\d.]+\s*(?:db|[kmµμu]?(?:w|watts?)\b)`,
+		`^-?[\d.]+\s*(?:dbm|dbw|db)\s*\+`,
 	}
 
 	for _, pattern := range patterns {
@@ -392,6 +406,122 @@ func handleSWR(expr, exprLower string) (string, bool) {
 	return "", false
 }
 
+// decibelMathPattern matches "add -67 dbm and -70 dbm", "combine 100 w and
+// 50 w in db", "difference between 30 dbm and 1 w" and "-67 dbm + -70 dbm"
+var decibelMathPattern = regexp.MustCompile(`(?i)^(?:(add|sum|combine|difference\s+between)\s+)?(.+?)(\s+in\s+db)?$`)
+
+// decibelOperandSeparator splits the operands of decibelMathPattern
+var decibelOperandSeparator = regexp.MustCompile(`(?i)\s*(?:,|\+|\s+and\s+)\s*`)
+
+// powerOperandPattern matches a power or level: "-67 dbm", "10 dBW", "3 dB",
+// "100 w", "50 mW"
+var powerOperandPattern = regexp.MustCompile(`(?i)^(-?[\d.]+)\s*(?:(dbm|dbw|db)|` + siPrefixPattern + `(?:w|watts?))$`)
+
+// powerOperand is a parsed operand of decibelMathPattern
+type powerOperand struct {
+	text     string  // as shown in the output
+	unit     string  // "dBm", "dBW", "W" or "dB" for a relative level
+	watts    float64 // absolute power; unused for "dB"
+	decibels float64 // relative level for "dB"
+}
+
+// parsePowerOperand parses a power in dBm, dBW or watts, or a level in dB
+func parsePowerOperand(s string) (powerOperand, bool) {
+	m := powerOperandPattern.FindStringSubmatch(s)
+	if m == nil {
+		return powerOperand{}, false
+	}
+	value, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return powerOperand{}, false
+	}
+	switch strings.ToLower(m[2]) {
+	case "dbm":
+		return powerOperand{text: fmt.Sprintf("%.3f dBm", value), unit: "dBm", watts: math.Pow(10, value/10) / 1000}, true
+	case "dbw":
+		return powerOperand{text: fmt.Sprintf("%.3f dBW", value), unit: "dBW", watts: math.Pow(10, value/10)}, true
+	case "db":
+		return powerOperand{text: fmt.Sprintf("%.3f dB", value), unit: "dB", decibels: value}, true
+	}
+	watts := value * siMultipliers[m[3]]
+	if watts <= 0 {
+		return powerOperand{}, false
+	}
+	return powerOperand{text: formatSI(watts, "W"), unit: "W", watts: watts}, true
+}
+
+// handleDecibelMath does arithmetic that has to happen in the log domain.
+// Powers given in dBm, dBW or watts (mixed freely) are summed as linear power,
+// never by adding their dB values; "in db" or "difference between" gives how
+// many dB the first power is above the second. Relative levels in dB, such as
+// gains in a chain, add directly.
+// Examples: "add -67 dbm and -70 dbm", "combine 100 w and 50 w in db",
+// "sum 30 dbm, 1 w", "-67 dbm + -70 dbm", "3 db + 3 db"
+func handleDecibelMath(expr, exprLower string) (string, bool) {
+	matches := decibelMathPattern.FindStringSubmatch(expr)
+	// Without a verb only "+" joins the operands: "-67 dbm + -70 dbm"
+	if matches == nil || (matches[1] == "" && !strings.Contains(matches[2], "+")) {
+		return "", false
+	}
+
+	var operands []powerOperand
+	relative := 0
+	for _, s := range decibelOperandSeparator.Split(matches[2], -1) {
+		op, ok := parsePowerOperand(s)
+		if !ok {
+			return "", false
+		}
+		if op.unit == "dB" {
+			relative++
+		}
+		operands = append(operands, op)
+	}
+	if len(operands) < 2 || (relative > 0 && relative < len(operands)) {
+		return "", false
+	}
+	texts := make([]string, len(operands))
+	for i, op := range operands {
+		texts[i] = op.text
+	}
+
+	if matches[3] != "" || strings.HasPrefix(strings.ToLower(matches[1]), "difference") {
+		if len(operands) != 2 {
+			return "", false
+		}
+		a, b := operands[0], operands[1]
+		if relative > 0 {
+			return fmt.Sprintf("\n> Difference: %.3f dB\n> (levels in dB are subtracted directly)", a.decibels-b.decibels), true
+		}
+		db := 10 * math.Log10(a.watts/b.watts)
+		return fmt.Sprintf("\n> Difference: %.3f dB\n> (%s is %.3f× the power of %s)", db, a.text, a.watts/b.watts, b.text), true
+	}
+
+	if relative > 0 {
+		var total float64
+		for _, op := range operands {
+			total += op.decibels
+		}
+		return fmt.Sprintf("\n> Sum: %.3f dB (%.3f× power)\n> (gains in dB add directly: %s)",
+			total, math.Pow(10, total/10), strings.Join(texts, " + ")), true
+	}
+
+	var watts float64
+	for _, op := range operands {
+		watts += op.watts
+	}
+	dbm := 10 * math.Log10(watts*1000)
+	var total string
+	switch operands[0].unit {
+	case "dBW":
+		total = fmt.Sprintf("%.3f dBW = %s", dbm-30, formatSI(watts, "W"))
+	case "W":
+		total = fmt.Sprintf("%s = %.3f dBm", formatSI(watts, "W"), dbm)
+	default:
+		total = fmt.Sprintf("%.3f dBm = %s", dbm, formatSI(watts, "W"))
+	}
+	return fmt.Sprintf("\n> Sum: %s\n> (powers added as watts, not as dB: %s)", total, strings.Join(texts, " + ")), true
+}
+
 // handleDecibelConversion converts between dB and linear ratios
 // Examples: "3 db to times", "10 times to db", "6 db voltage"
 func handleDecibelConversion(expr, exprLower string) (string, bool) {
@@ -539,7 +669,7 @@ func handleBandInfo(expr, exprLower string) (string, bool) {
 
 // handleOhmsLaw calculates electrical values using Ohm's Law and Power formulas
 // V = I * R, P = V * I, P = I² * R, P = V² / R
-// Examples: "12v 2a", "24v 100ohm", "5a 10ohm", "100w 50ohm"
+// Examples: "12v 2a", "24v 100ohm", "5a 10ohm", "100w 50ohm", "4.7k ohm 12 v", "220µA 10k ohm"
 func handleOhmsLaw(expr, exprLower string) (string, bool) {
 	// Parse two electrical values
 	// Patterns: "12v 2a", "12 volts 2 amps", "24v 100 ohm", "100w 50 ohm"
@@ -548,30 +678,30 @@ func handleOhmsLaw(expr, exprLower string) (string, bool) {
 	var hasV, hasA, hasR, hasP bool
 
 	// Voltage patterns
-	reV := regexp.MustCompile(`(?i)([\d.]+)\s*(?:v|volts?)(?:\s|$)`)
+	reV := regexp.MustCompile(`(?i)([\d.]+)\s*` + siPrefixPattern + `\s*(?:v|volts?)(?:\s|$)`)
 	if matches := reV.FindStringSubmatch(expr); matches != nil {
-		voltage, _ = strconv.ParseFloat(matches[1], 64)
+		voltage = parseSIValue(matches[1], matches[2])
 		hasV = true
 	}
 
 	// Current patterns
-	reA := regexp.MustCompile(`(?i)([\d.]+)\s*(?:a|amps?|amperes?)(?:\s|$)`)
+	reA := regexp.MustCompile(`(?i)([\d.]+)\s*` + siPrefixPattern + `\s*(?:a|amps?|amperes?)(?:\s|$)`)
 	if matches := reA.FindStringSubmatch(expr); matches != nil {
-		current, _ = strconv.ParseFloat(matches[1], 64)
+		current = parseSIValue(matches[1], matches[2])
 		hasA = true
 	}
 
 	// Resistance patterns
-	reR := regexp.MustCompile(`(?i)([\d.]+)\s*(?:ohms?|Ω)(?:\s|$)`)
+	reR := regexp.MustCompile(`(?i)([\d.]+)\s*` + siPrefixPattern + `\s*(?:ohms?|Ω)(?:\s|$)`)
 	if matches := reR.FindStringSubmatch(expr); matches != nil {
-		resistance, _ = strconv.ParseFloat(matches[1], 64)
+		resistance = parseSIValue(matches[1], matches[2])
 		hasR = true
 	}
 
 	// Power patterns
-	reP := regexp.MustCompile(`(?i)([\d.]+)\s*(?:w|watts?)(?:\s|$)`)
+	reP := regexp.MustCompile(`(?i)([\d.]+)\s*` + siPrefixPattern + `\s*(?:w|watts?)(?:\s|$)`)
 	if matches := reP.FindStringSubmatch(expr); matches != nil {
-		power, _ = strconv.ParseFloat(matches[1], 64)
+		power = parseSIValue(matches[1], matches[2])
 		hasP = true
 	}
 
@@ -622,12 +752,198 @@ func handleOhmsLaw(expr, exprLower string) (string, bool) {
 		current = math.Sqrt(power / resistance)
 	}
 
-	return fmt.Sprintf("\n> Voltage: %.3f V\n> Current: %.3f A\n> Resistance: %.3f Ω\n> Power: %.3f W",
-		voltage, current, resistance, power), true
+	return fmt.Sprintf("\n> Voltage: %s\n> Current: %s\n> Resistance: %s\n> Power: %s",
+		formatSI(voltage, "V"), formatSI(current, "A"), formatSI(resistance, "Ω"), formatSI(power, "W")), true
+}
+
+// resistorsPattern matches "resistors 4.7k and 10k in parallel" and
+// "resistors 100, 220, 330 ohm in series"
+var resistorsPattern = regexp.MustCompile(`(?i)^resistors?\s+(.+?)\s+in\s+(parallel|series)$`)
+
+// resistorValuePattern matches one resistor value in a list: "4.7k", "10 kohm", "220Ω"
+var resistorValuePattern = regexp.MustCompile(`([\d.]+)\s*` + siPrefixPattern + `\s*(?i:ohms?|Ω)?`)
+
+// resistorSeparatorPattern matches what may separate the values in a list
+var resistorSeparatorPattern = regexp.MustCompile(`(?i)^(?:\s|,|\band\b)*$`)
+
+// handleResistors calculates the total resistance of resistors in series or
+// parallel. Values are separated by commas, spaces or "and".
+// Examples: "resistors 4.7k and 10k in parallel", "resistors 100, 220, 330 in series"
+func handleResistors(expr, exprLower string) (string, bool) {
+	matches := resistorsPattern.FindStringSubmatch(expr)
+	if matches == nil {
+		return "", false
+	}
+	list := matches[1]
+	if !resistorSeparatorPattern.MatchString(resistorValuePattern.ReplaceAllString(list, "")) {
+		return "", false
+	}
+
+	var values []float64
+	for _, m := range resistorValuePattern.FindAllStringSubmatch(list, -1) {
+		value := parseSIValue(m[1], m[2])
+		if value <= 0 {
+			return "", false
+		}
+		values = append(values, value)
+	}
+	if len(values) < 2 {
+		return "", false
+	}
+
+	var total float64
+	if strings.EqualFold(matches[2], "series") {
+		// R = R1 + R2 + ...
+		for _, v := range values {
+			total += v
+		}
+	} else {
+		// 1/R = 1/R1 + 1/R2 + ...
+		for _, v := range values {
+			total += 1 / v
+		}
+		total = 1 / total
+	}
+
+	return formatSI(total, "Ω"), true
+}
+
+// voltageDividerPattern matches "divider 12 v with 10k and 4.7k": the input
+// voltage, then the top and bottom resistors
+var voltageDividerPattern = regexp.MustCompile(`(?i)^(?:voltage\s+)?divider\s+([\d.]+)\s*` + siPrefixPattern + `\s*(?:v|volts?)\s+(?:with\s+)?` +
+	`([\d.]+)\s*` + siPrefixPattern + `\s*(?:ohms?|Ω)?\s+(?:and\s+)?([\d.]+)\s*` + siPrefixPattern + `\s*(?:ohms?|Ω)?$`)
+
+// handleVoltageDivider calculates the output of a resistive voltage divider,
+// taken across the bottom resistor, and the current through the chain
+// Examples: "divider 12 v with 10k and 4.7k", "voltage divider 5v 1k 2k"
+func handleVoltageDivider(expr, exprLower string) (string, bool) {
+	matches := voltageDividerPattern.FindStringSubmatch(expr)
+	if matches == nil {
+		return "", false
+	}
+	vin := parseSIValue(matches[1], matches[2])
+	r1 := parseSIValue(matches[3], matches[4])
+	r2 := parseSIValue(matches[5], matches[6])
+	if r1+r2 <= 0 {
+		return "", false
+	}
+
+	// Vout = Vin * R2 / (R1 + R2), I = Vin / (R1 + R2)
+	total := r1 + r2
+	vout := vin * r2 / total
+	current := vin / total
+
+	return fmt.Sprintf("\n> Output: %s\n> Current: %s\n> Total resistance: %s\n> Power: %s",
+		formatSI(vout, "V"), formatSI(current, "A"), formatSI(total, "Ω"), formatSI(vin*current, "W")), true
+}
+
+// ledResistorPattern matches "resistor for led 2.1 v 20 mA from 5 v": the LED
+// forward voltage and current, then the supply voltage
+var ledResistorPattern = regexp.MustCompile(`(?i)^resistor\s+for\s+(?:an?\s+)?led\s+([\d.]+)\s*` + siPrefixPattern + `\s*(?:v|volts?)\s+` +
+	`(?:at\s+)?([\d.]+)\s*` + siPrefixPattern + `\s*(?:a|amps?)\s+(?:from|on|with)\s+([\d.]+)\s*` + siPrefixPattern + `\s*(?:v|volts?)(?:\s+supply)?$`)
+
+// IsLEDResistorExpression checks if an expression asks for the series
+// resistor of an LED
+func IsLEDResistorExpression(expr string) bool {
+	return ledResistorPattern.MatchString(strings.TrimSpace(expr))
+}
+
+// EvalLEDResistor calculates the series resistor that limits an LED to its
+// current, the nearest E12 and E24 standard values with the current each
+// would give, and the power the resistor dissipates. A supply that can't
+// light the LED is an error.
+// Examples: "resistor for led 2.1 v 20 mA from 5 v", "resistor for an led 3.2v 10ma from 12v"
+func EvalLEDResistor(expr string) (string, error) {
+	expr = strings.TrimSpace(expr)
+	matches := ledResistorPattern.FindStringSubmatch(expr)
+	if matches == nil {
+		return "", fmt.Errorf("unable to evaluate LED resistor expression: %s", expr)
+	}
+	vled := parseSIValue(matches[1], matches[2])
+	current := parseSIValue(matches[3], matches[4])
+	supply := parseSIValue(matches[5], matches[6])
+	if current <= 0 {
+		return "", fmt.Errorf("LED current must be positive")
+	}
+	if supply <= vled {
+		return "", fmt.Errorf("supply voltage must be higher than the LED voltage")
+	}
+
+	// R = (Vsupply - Vled) / I
+	drop := supply - vled
+	resistance := drop / current
+	e12 := nearestStandardValue(resistance, e12Series)
+	e24 := nearestStandardValue(resistance, e24Series)
+
+	return fmt.Sprintf("\n> Resistor: %s\n> Nearest E12: %s (%s)\n> Nearest E24: %s (%s)\n> Resistor power: %s",
+		formatSI(resistance, "Ω"),
+		formatSI(e12, "Ω"), formatSI(drop/e12, "A"),
+		formatSI(e24, "Ω"), formatSI(drop/e24, "A"),
+		formatSI(drop*current, "W")), nil
+}
+
+// E-series standard resistor values for one decade (IEC 60063)
+var (
+	e12Series = []float64{10, 12, 15, 18, 22, 27, 33, 39, 47, 56, 68, 82}
+	e24Series = []float64{10, 11, 12, 13, 15, 16, 18, 20, 22, 24, 27, 30, 33, 36, 39, 43, 47, 51, 56, 62, 68, 75, 82, 91}
+)
+
+// nearestStandardValue returns the value of an E-series closest to r
+func nearestStandardValue(r float64, series []float64) float64 {
+	// Scale the series to the decade of r, and include the next decade's first value
+	decade := math.Pow(10, math.Floor(math.Log10(r))-1)
+	best := series[0] * decade * 10
+	for _, v := range series {
+		if math.Abs(v*decade-r) < math.Abs(best-r) {
+			best = v * decade
+		}
+	}
+	return best
 }
 
 // Helper functions
 
+// siPrefixPattern captures an optional SI prefix before a unit. The case of
+// m and M matters: 5mA is milliamps, 1M ohm is a megohm.
+const siPrefixPattern = `([kKMmµμu]?)`
+
+// siMultipliers maps the prefixes captured by siPrefixPattern to their values
+var siMultipliers = map[string]float64{
+	"":  1,
+	"k": 1e3,
+	"K": 1e3,
+	"M": 1e6,
+	"m": 1e-3,
+	"µ": 1e-6, // micro sign
+	"μ": 1e-6, // Greek mu
+	"u": 1e-6,
+}
+
+// parseSIValue parses a number with an optional SI prefix: "4.7", "k" is 4700
+func parseSIValue(number, prefix string) float64 {
+	value, _ := strconv.ParseFloat(number, 64)
+	return value * siMultipliers[prefix]
+}
+
+// siPrefixes are the prefixes formatSI picks from, one per power of 1000 from
+// pico (10^-12) to tera (10^12)
+var siPrefixes = []string{"p", "n", "µ", "m", "", "k", "M", "G", "T"}
+
+// formatSI formats a value in engineering notation with an SI prefix on the
+// unit: 3197.3 Ω is "3.197 kΩ", 0.24 A is "240.000 mA"
+func formatSI(value float64, unit string) string {
+	if value == 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Sprintf("%.3f %s", value, unit)
+	}
+	exp := int(math.Floor(math.Log10(math.Abs(value)) / 3))
+	// Rounding can push the mantissa to 1000 (999.9996 -> "1000.000")
+	if math.Abs(value/math.Pow(1000, float64(exp))) >= 999.9995 {
+		exp++
+	}
+	exp = max(-4, min(4, exp))
+	return fmt.Sprintf("%.3f %s%s", value/math.Pow(1000, float64(exp)), siPrefixes[exp+4], unit)
+}
+
 func formatWavelength(meters float64) string {
 	if meters >= 1 {
 		return fmt.Sprintf("%.3f m", meters)
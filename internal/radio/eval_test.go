@@ -1,6 +1,7 @@
 package radio
 
 import (
+	"math"
 	"strings"
 	"testing"
 )
@@ -22,6 +23,19 @@ func TestIsRadioExpression(t *testing.T) {
 		{"30 dbm to watts", true},
 		{"ham band 14.2 MHz", true},
 		{"20m band", true},
+		{"220µa 10k ohm", true},
+		{"12v 5ma", true},
+		{"resistors 4.7k and 10k in parallel", true},
+		{"divider 12 v with 10k and 4.7k", true},
+		{"add -67 dbm and -70 dbm", true},
+		{"combine 100 w and 50 w in db", true},
+		{"3 db + 3 db", true},
+		{"resistor for led 2.1 v 20 mA from 5 v", true},
+		{"voltage drop 12v 10a over 5m of 14 awg", true},
+		{"wire gauge for 20a at 12v max 3% drop over 10m", true},
+		{"gauge for 5a at 24v over 30 ft", true},
+		{"resistor 4.7k to colors", true},
+		{"resistor colors yellow violet red gold", true},
 		{"simple math 2+2", false},
 		{"hello world", false},
 	}
@@ -307,7 +321,7 @@ func TestOhmsLaw(t *testing.T) {
 		{"12v 2a", []string{"Voltage: 12.000 V", "Current: 2.000 A", "Resistance: 6.000 Ω", "Power: 24.000 W"}},
 		{"12 volts 2 amps", []string{"Voltage: 12.000 V", "Current: 2.000 A"}},
 		// Voltage and Resistance given
-		{"24v 100ohm", []string{"Voltage: 24.000 V", "Resistance: 100.000 Ω", "Current: 0.240 A", "Power: 5.760 W"}},
+		{"24v 100ohm", []string{"Voltage: 24.000 V", "Resistance: 100.000 Ω", "Current: 240.000 mA", "Power: 5.760 W"}},
 		{"12v 50 ohm", []string{"Voltage: 12.000 V", "Resistance: 50.000 Ω"}},
 		// Current and Resistance given
 		{"2a 10ohm", []string{"Current: 2.000 A", "Resistance: 10.000 Ω", "Voltage: 20.000 V", "Power: 40.000 W"}},
@@ -317,6 +331,13 @@ func TestOhmsLaw(t *testing.T) {
 		{"100w 50v", []string{"Power: 100.000 W", "Voltage: 50.000 V", "Current: 2.000 A", "Resistance: 25.000 Ω"}},
 		// Power and Current given
 		{"100w 5a", []string{"Power: 100.000 W", "Current: 5.000 A", "Voltage: 20.000 V", "Resistance: 4.000 Ω"}},
+		// SI prefixes
+		{"4.7k ohm 12 v", []string{"Voltage: 12.000 V", "Current: 2.553 mA", "Resistance: 4.700 kΩ", "Power: 30.638 mW"}},
+		{"220µA 10k ohm", []string{"Voltage: 2.200 V", "Current: 220.000 µA", "Resistance: 10.000 kΩ", "Power: 484.000 µW"}},
+		{"220uA 10k ohm", []string{"Current: 220.000 µA"}},
+		{"5mA 12v", []string{"Resistance: 2.400 kΩ", "Power: 60.000 mW"}},
+		{"1kw 230v", []string{"Power: 1.000 kW", "Current: 4.348 A"}},
+		{"1M ohm 10v", []string{"Resistance: 1.000 MΩ", "Current: 10.000 µA"}},
 	}
 
 	for _, tt := range tests {
@@ -334,3 +355,163 @@ func TestOhmsLaw(t *testing.T) {
 		})
 	}
 }
+
+func TestResistors(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"resistors 4.7k and 10k in parallel", "3.197 kΩ"},
+		{"resistors 4.7k and 10k in series", "14.700 kΩ"},
+		{"resistors 100, 220, 330 in series", "650.000 Ω"},
+		{"resistors 1k 1k 1k in parallel", "333.333 Ω"},
+		{"resistors 4.7 k ohm and 10 kΩ in parallel", "3.197 kΩ"},
+		{"resistors 1M and 1M in parallel", "500.000 kΩ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalRadio(tt.expr)
+			if err != nil {
+				t.Errorf("EvalRadio(%q) error: %v", tt.expr, err)
+				return
+			}
+			if result != tt.expected {
+				t.Errorf("EvalRadio(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+
+	for _, expr := range []string{"resistors 10k in parallel", "resistors foo and 10k in parallel", "resistors 0 and 10k in parallel"} {
+		if _, err := EvalRadio(expr); err == nil {
+			t.Errorf("EvalRadio(%q) expected error", expr)
+		}
+	}
+}
+
+func TestVoltageDivider(t *testing.T) {
+	tests := []struct {
+		expr     string
+		contains []string
+	}{
+		{"divider 12 v with 10k and 4.7k", []string{"Output: 3.837 V", "Current: 816.327 µA", "Total resistance: 14.700 kΩ", "Power: 9.796 mW"}},
+		{"voltage divider 5v 1k 2k", []string{"Output: 3.333 V", "Current: 1.667 mA"}},
+		{"divider 3.3 volts with 100 ohm and 100 ohm", []string{"Output: 1.650 V", "Current: 16.500 mA"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalRadio(tt.expr)
+			if err != nil {
+				t.Errorf("EvalRadio(%q) error: %v", tt.expr, err)
+				return
+			}
+			for _, c := range tt.contains {
+				if !strings.Contains(result, c) {
+					t.Errorf("EvalRadio(%q) = %q, want to contain %q", tt.expr, result, c)
+				}
+			}
+		})
+	}
+}
+
+func TestLEDResistor(t *testing.T) {
+	tests := []struct {
+		expr     string
+		contains []string
+	}{
+		{"resistor for led 2.1 v 20 mA from 5 v", []string{"Resistor: 145.000 Ω", "Nearest E12: 150.000 Ω (19.333 mA)", "Nearest E24: 150.000 Ω (19.333 mA)", "Resistor power: 58.000 mW"}},
+		{"resistor for an led 3.2v 10ma from 12v", []string{"Resistor: 880.000 Ω", "Nearest E12: 820.000 Ω (10.732 mA)", "Nearest E24: 910.000 Ω (9.670 mA)"}},
+		{"resistor for led 1.8 v 15 mA from 9 v supply", []string{"Nearest E12: 470.000 Ω (15.319 mA)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if !IsLEDResistorExpression(tt.expr) {
+				t.Fatalf("IsLEDResistorExpression(%q) = false", tt.expr)
+			}
+			result, err := EvalLEDResistor(tt.expr)
+			if err != nil {
+				t.Errorf("EvalLEDResistor(%q) error: %v", tt.expr, err)
+				return
+			}
+			for _, c := range tt.contains {
+				if !strings.Contains(result, c) {
+					t.Errorf("EvalLEDResistor(%q) = %q, want to contain %q", tt.expr, result, c)
+				}
+			}
+		})
+	}
+
+	_, err := EvalLEDResistor("resistor for led 5v 20ma from 3.3v")
+	if err == nil || err.Error() != "supply voltage must be higher than the LED voltage" {
+		t.Errorf("EvalLEDResistor() with a low supply error = %v", err)
+	}
+}
+
+func TestNearestStandardValue(t *testing.T) {
+	tests := []struct {
+		r        float64
+		series   []float64
+		expected float64
+	}{
+		{145, e12Series, 150},
+		{880, e12Series, 820},
+		{880, e24Series, 910},
+		{9500, e12Series, 10000},
+		{4.7, e24Series, 4.7},
+	}
+
+	for _, tt := range tests {
+		got := nearestStandardValue(tt.r, tt.series)
+		if math.Abs(got-tt.expected) > 1e-9 {
+			t.Errorf("nearestStandardValue(%v) = %v, want %v", tt.r, got, tt.expected)
+		}
+	}
+}
+
+func TestDecibelMath(t *testing.T) {
+	tests := []struct {
+		expr     string
+		contains []string
+	}{
+		// Powers are summed in watts
+		{"add -67 dbm and -70 dbm", []string{"Sum: -65.236 dBm = 299.526 pW", "not as dB"}},
+		{"-67 dbm + -70 dbm", []string{"Sum: -65.236 dBm"}},
+		{"add 10 dbw + 10 dbw", []string{"Sum: 13.010 dBW = 20.000 W"}},
+		{"add 100 w and 50 w", []string{"Sum: 150.000 W = 51.761 dBm"}},
+		{"add 50 mW and 50 mW", []string{"Sum: 100.000 mW = 20.000 dBm"}},
+		// Mixed dBm and watts
+		{"sum 30 dbm, 1 w", []string{"Sum: 33.010 dBm = 2.000 W", "30.000 dBm + 1.000 W"}},
+		{"add 30 dbm and 1 w and 0 dbm", []string{"Sum: 33.012 dBm"}},
+		// Differences in dB
+		{"combine 100 w and 50 w in db", []string{"Difference: 3.010 dB", "100.000 W is 2.000× the power of 50.000 W"}},
+		{"difference between 30 dbm and 1 w", []string{"Difference: 0.000 dB"}},
+		{"difference between -70 dbm and -67 dbm", []string{"Difference: -3.000 dB"}},
+		// Relative levels add directly
+		{"3 db + 3 db", []string{"Sum: 6.000 dB (3.981× power)", "gains in dB add directly"}},
+		{"difference between 6 db and 3 db", []string{"Difference: 3.000 dB"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalRadio(tt.expr)
+			if err != nil {
+				t.Errorf("EvalRadio(%q) error: %v", tt.expr, err)
+				return
+			}
+			for _, c := range tt.contains {
+				if !strings.Contains(result, c) {
+					t.Errorf("EvalRadio(%q) = %q, want to contain %q", tt.expr, result, c)
+				}
+			}
+		})
+	}
+
+	// A gain cannot be added to an absolute power, and one operand is not a sum
+	for _, expr := range []string{"add 3 db and 30 dbm", "add 30 dbm", "combine 1 w and 2 w and 3 w in db"} {
+		if _, err := EvalRadio(expr); err == nil {
+			t.Errorf("EvalRadio(%q) expected error", expr)
+		}
+	}
+}
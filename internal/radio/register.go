@@ -0,0 +1,23 @@
+package radio
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// An LED the supply can't light is reported instead of left to the
+	// other evaluators
+	registry.Register(registry.Evaluator{
+		Name:     "ledresistor",
+		Priority: registry.PriorityRadio,
+		Traits:   registry.MultiLine | registry.ReportsErrors,
+		Detect:   IsLEDResistorExpression,
+		Eval:     registry.TextEval(EvalLEDResistor),
+	})
+
+	registry.Register(registry.Evaluator{
+		Name:     "radio",
+		Priority: registry.PriorityRadio,
+		Traits:   registry.MultiLine,
+		Detect:   IsRadioExpression,
+		Eval:     registry.TextEval(EvalRadio),
+	})
+}
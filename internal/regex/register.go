@@ -0,0 +1,13 @@
+package regex
+
+import "smartcalc/internal/registry"
+
+func init() {
+	registry.Register(registry.Evaluator{
+		Name:     "regex",
+		Priority: registry.PriorityRegex,
+		Traits:   registry.MultiLine,
+		Detect:   IsRegexExpression,
+		Eval:     registry.TextEval(EvalRegex),
+	})
+}
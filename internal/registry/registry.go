@@ -0,0 +1,134 @@
+package registry
+
+import (
+	"sort"
+	"sync"
+
+	"smartcalc/internal/utils"
+)
+
+// Trait describes how the calculator treats the lines an evaluator claims
+type Trait uint8
+
+const (
+	// Expensive evaluators look results up over the network or read large
+	// files. Their lines are deferred while typing and their results are
+	// never cached.
+	Expensive Trait = 1 << iota
+	// Volatile results change between lookups, so an inactive line is looked
+	// up again instead of keeping the result it shows.
+	Volatile
+	// MultiLine results starting with a newline are a block of "> " lines
+	// right below the expression
+	MultiLine
+	// NoFormat keeps the expression as typed, for URLs, domains and tokens
+	// that formatting would corrupt
+	NoFormat
+	// ReportsErrors shows an evaluation error as the line's result instead of
+	// offering the line to the evaluators after it
+	ReportsErrors
+	// Random results are drawn once: an inactive line keeps the result it
+	// shows until the document is refreshed, so rolls don't change while
+	// other lines are edited
+	Random
+)
+
+// Has checks if t includes all traits of other
+func (t Trait) Has(other Trait) bool {
+	return t&other == other
+}
+
+// Priorities order the evaluators; lower runs first. Where two evaluators
+// recognize the same text the earlier one wins, so the comments note what an
+// evaluator must come before, next to an example of the lines it claims.
+const (
+	PriorityLedger      = 5   // "- $120 groceries" is a transaction below a ledger's start line
+	PriorityData        = 6   // "data:" and "col 2 sum" read the rows of a data block
+	PriorityBase        = 10  // "255 in hex"
+	PriorityTextStats   = 15  // "wordcount \3" counts the words of a line
+	PriorityChemistry   = 18  // before constants and units: "mass of 2 mol NaCl" is not a quantity
+	PriorityConstants   = 20  // before units: "speed of light" is not a unit conversion
+	PriorityUncertainty = 21  // before tolerances: "(12.3 ± 0.2) * 2" is a calculation, not a band
+	PriorityTolerance   = 22  // before units and radio: "4.7k ohm ±5%" is a band, not a resistance
+	PriorityTravel      = 23  // before paces: "300 km at 100 km/h" is a trip, "marathon at 5:30/km" still a race
+	PriorityShipping    = 24  // before units: "fit 12 items of 10 x 8 x 6 cm in 60 x 40 x 40 cm box"
+	PriorityHealth      = 25  // before units: "bmi 82 kg 1.78 m"
+	PriorityResources   = 26  // before units: "3 pods x 250m cpu"
+	PriorityFitness     = 27  // before units: "10 km in 52:30"
+	PriorityDIY         = 28  // before units: "paint for 40 sqm"
+	PriorityCapacity    = 29  // before units: "data at 50 MB/s for 1 day"
+	PriorityUnits       = 30  // before cooking: "2 cups to ml" is a conversion
+	PriorityEnergy      = 31  // "kwh of 65 w for 24/7 for 30 days"
+	PriorityAviation    = 32  // before quantities: "density altitude 5000 ft 30 C" is not a length
+	PriorityBandwidth   = 33  // before quantities: "1 Gbps for 1 hour"
+	PriorityQuantity    = 40  // arithmetic with units: "5 km + 300 m"
+	PriorityRadio       = 50  // "12v 2a"
+	PriorityPercentage  = 60  // "15% of 200"
+	PriorityFinance     = 70  // "loan $300000 at 6% for 30 years"
+	PriorityTrend       = 75  // "trend \1..\10"
+	PriorityStats       = 80  // "percentile(95, 1, 2, 3)"
+	PriorityProbability = 82  // "+150 to probability"
+	PriorityGrades      = 85  // "88% to letter grade"
+	PriorityFileHash    = 88  // before programmer utilities: "sha256 file a.iso" does not hash the text
+	PriorityProgrammer  = 90  // "sha256 hello", "json pretty {...}"
+	PriorityRegex       = 100 // "regex /\d+/ test ..."
+	PriorityPermissions = 110 // "chmod 755"
+	PriorityCooking     = 120 // "2 cups flour to grams"
+	PriorityManHour     = 130 // "248 man-hours / 3 men in weeks"
+	PriorityHourlyCost  = 140 // "$35 per hour in week"
+	PriorityJWT         = 150 // "jwt decode eyJ..."
+	PriorityOTP         = 155 // "totp JBSWY3DPEHPK3PXP"
+	PriorityCert        = 160 // before DNS: "cert decode example.com" is not a lookup
+	PriorityHTTP        = 165 // before DNS: "http status example.com" is not a lookup
+	PriorityDNS         = 170 // "dns example.com"
+	PriorityPing        = 175 // "ping example.com"
+	PriorityWhois       = 180 // "whois example.com"
+	PriorityCurrency    = 190 // "$100 to EUR"
+	PriorityNetwork     = 200 // "192.168.1.0/24"
+	PriorityGeoIP       = 210 // "geoip 8.8.8.8"
+	PriorityLookup      = 215 // "country code UA"
+	PriorityMyIP        = 220 // "my ip"
+	PriorityColor       = 230 // "#FF5733 to rgb"
+	PriorityCron        = 235 // "cron */5 * * * *"
+	PriorityDateTime    = 240 // "today + 3 days"
+	PriorityFraction    = 250 // last, after dates have claimed "6/7/2024": "0.375 as fraction"
+)
+
+// Evaluator recognizes and evaluates one kind of expression
+type Evaluator struct {
+	Name     string
+	Priority int
+	Traits   Trait
+	Detect   func(expr string) bool
+	Eval     func(expr string) (utils.Result, error)
+}
+
+// TextEval adapts an evaluator whose output has no referenceable value
+func TextEval(eval func(expr string) (string, error)) func(expr string) (utils.Result, error) {
+	return func(expr string) (utils.Result, error) {
+		text, err := eval(expr)
+		return utils.TextResult(text), err
+	}
+}
+
+var (
+	mu         sync.Mutex
+	evaluators []Evaluator
+)
+
+// Register adds an evaluator. Packages register theirs from init.
+func Register(e Evaluator) {
+	mu.Lock()
+	defer mu.Unlock()
+	evaluators = append(evaluators, e)
+	sort.SliceStable(evaluators, func(i, j int) bool {
+		return evaluators[i].Priority < evaluators[j].Priority
+	})
+}
+
+// Evaluators returns the registered evaluators in priority order
+func Evaluators() []Evaluator {
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]Evaluator(nil), evaluators...)
+}
@@ -0,0 +1,50 @@
+package registry
+
+import (
+	"testing"
+
+	"smartcalc/internal/utils"
+)
+
+func TestRegisterOrdersByPriority(t *testing.T) {
+	defer func(saved []Evaluator) { evaluators = saved }(evaluators)
+	evaluators = nil
+
+	detect := func(string) bool { return true }
+	eval := TextEval(func(expr string) (string, error) { return expr, nil })
+	Register(Evaluator{Name: "late", Priority: 30, Detect: detect, Eval: eval})
+	Register(Evaluator{Name: "early", Priority: 10, Detect: detect, Eval: eval})
+	Register(Evaluator{Name: "middle", Priority: 20, Detect: detect, Eval: eval})
+	Register(Evaluator{Name: "middle-2", Priority: 20, Detect: detect, Eval: eval})
+
+	var names []string
+	for _, e := range Evaluators() {
+		names = append(names, e.Name)
+	}
+	want := []string{"early", "middle", "middle-2", "late"}
+	if len(names) != len(want) {
+		t.Fatalf("Evaluators() = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("Evaluators() = %v, want %v", names, want)
+		}
+	}
+}
+
+func TestTraitHas(t *testing.T) {
+	lookup := Expensive | MultiLine
+	if !lookup.Has(Expensive) || !lookup.Has(Expensive|MultiLine) {
+		t.Error("trait set should include its traits")
+	}
+	if lookup.Has(NoFormat) || lookup.Has(Expensive|NoFormat) {
+		t.Error("trait set should not include other traits")
+	}
+}
+
+func TestTextEval(t *testing.T) {
+	r, err := TextEval(func(expr string) (string, error) { return "= " + expr, nil })("x")
+	if err != nil || r != utils.TextResult("= x") {
+		t.Errorf("TextEval() = %+v, %v", r, err)
+	}
+}
@@ -0,0 +1,262 @@
+package shipping
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/units"
+	"smartcalc/internal/utils"
+)
+
+// Dim divisors: a metric divisor is the cm³ a carrier bills as 1 kg, an
+// imperial one the in³ it bills as 1 lb. Without one, centimeter boxes use
+// the common 5000 and inch boxes 139.
+const (
+	defaultMetricDivisor   = 5000
+	defaultImperialDivisor = 139
+	// divisors below this are imperial (139, 166), above it metric (4000,
+	// 5000, 6000)
+	imperialDivisorLimit = 1000
+)
+
+// Conversion factors
+const (
+	cm3PerIn3 = 16.387064 // cm³ in a cubic inch
+	kgPerLb   = 0.45359237
+)
+
+// dims are the sides of a box or item with a unit: "40 x 30 x 20 cm",
+// "16in x 12in x 10in"
+const dims = `(\d+(?:\.\d+)?\s*[a-z"']*\s*(?:x|×|by)\s*\d+(?:\.\d+)?\s*[a-z"']*\s*(?:x|×|by)\s*\d+(?:\.\d+)?\s*[a-z"']*)`
+
+// dimWeightPattern matches "dim weight 40 x 30 x 20 cm at 5000 actual 6 kg"
+var dimWeightPattern = regexp.MustCompile(`^(?:dim|dimensional|volumetric)\s+weight\s+(?:of\s+)?` + dims +
+	`(?:\s*,?\s+(?:at|divisor|/)\s*(\d+(?:\.\d+)?))?` +
+	`(?:\s*,?\s+(?:actual|actual\s+weight|weighing)\s+(\d+(?:\.\d+)?)\s*(kg|kgs|kilograms?|lbs?|pounds?|g|grams?|oz|ounces?))?$`)
+
+// fitPattern matches "fit 12 items of 10 x 8 x 6 cm in 60 x 40 x 40 cm box"
+// and "fit 10 x 8 x 6 cm into 60 x 40 x 40 cm"
+var fitPattern = regexp.MustCompile(`^(?:how\s+many\s+)?fit\s+(?:(\d+)\s+(?:items?|boxes|packages?|parcels?|pieces?|pcs|units?)?\s*(?:of\s+)?)?` + dims +
+	`\s+in(?:to)?\s+(?:an?\s+|the\s+)?` + dims + `(?:\s+(?:box|carton|container|crate|pallet))?$`)
+
+// sidePattern splits "40cm", "40 cm", "16\"" and "40" into number and unit
+var sidePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([a-z"']*)$`)
+
+// separatorPattern splits the sides of "40 x 30 x 20 cm"
+var separatorPattern = regexp.MustCompile(`\s*(?:×|x|\bby\b)\s*`)
+
+// IsShippingExpression checks if an expression asks for a dimensional weight
+// or how many items fit in a box
+func IsShippingExpression(expr string) bool {
+	expr = utils.NormalizeExpr(expr)
+	return dimWeightPattern.MatchString(expr) || fitPattern.MatchString(expr)
+}
+
+// EvalShipping computes the dimensional weight of a box, and the weight a
+// carrier bills when the actual weight is given, or estimates how many items
+// fit in a box. The results show the formula they come from.
+func EvalShipping(expr string) (utils.Result, error) {
+	expr = utils.NormalizeExpr(expr)
+	if m := dimWeightPattern.FindStringSubmatch(expr); m != nil {
+		return evalDimWeight(m)
+	}
+	if m := fitPattern.FindStringSubmatch(expr); m != nil {
+		return evalFit(m)
+	}
+	return utils.Result{}, fmt.Errorf("invalid shipping expression")
+}
+
+// box is a parsed box or item: its sides in meters and as typed
+type box struct {
+	sides [3]float64 // meters
+	unit  string     // the unit of the last side, which unitless sides share
+}
+
+// parseBox reads "40 x 30 x 20 cm" or "16in x 12in x 10in". Sides without a
+// unit take the unit of the side after them.
+func parseBox(s string) (box, error) {
+	parts := separatorPattern.Split(strings.TrimSpace(s), -1)
+	if len(parts) != 3 {
+		return box{}, fmt.Errorf("expected three sides such as 40 x 30 x 20 cm")
+	}
+	var b box
+	unit := ""
+	for i := 2; i >= 0; i-- {
+		m := sidePattern.FindStringSubmatch(strings.TrimSpace(parts[i]))
+		if m == nil {
+			return box{}, fmt.Errorf("expected a length, got %q", parts[i])
+		}
+		switch m[2] {
+		case "":
+		case `"`, "inch", "inches":
+			unit = "in"
+		case "'":
+			unit = "ft"
+		default:
+			unit = m[2]
+		}
+		if unit == "" {
+			return box{}, fmt.Errorf("%s has no unit", s)
+		}
+		value, _ := strconv.ParseFloat(m[1], 64)
+		meters, ok := units.LengthInMeters(value, unit)
+		if !ok {
+			return box{}, fmt.Errorf("unknown unit %q", unit)
+		}
+		if meters <= 0 {
+			return box{}, fmt.Errorf("sides must be more than zero")
+		}
+		b.sides[i] = meters
+		if i == 2 {
+			b.unit = unit
+		}
+	}
+	return b, nil
+}
+
+// imperial checks if a box is measured in inches or feet
+func (b box) imperial() bool {
+	return b.unit == "in" || b.unit == "ft"
+}
+
+// in returns the sides in centimeters, or in inches for an imperial box
+func (b box) in(imperial bool) [3]float64 {
+	scale := 100.0
+	if imperial {
+		scale = 100 / 2.54
+	}
+	var s [3]float64
+	for i, v := range b.sides {
+		s[i] = v * scale
+	}
+	return s
+}
+
+// volume is the box's volume in cm³, or in³
+func (b box) volume(imperial bool) float64 {
+	s := b.in(imperial)
+	return s[0] * s[1] * s[2]
+}
+
+// text shows the sides in cm or in: "40 × 30 × 20 cm"
+func (b box) text(imperial bool) string {
+	s := b.in(imperial)
+	unit := "cm"
+	if imperial {
+		unit = "in"
+	}
+	return fmt.Sprintf("%s × %s × %s %s", utils.FormatAmount(s[0], 2), utils.FormatAmount(s[1], 2), utils.FormatAmount(s[2], 2), unit)
+}
+
+// evalDimWeight computes volume / divisor, in kg for a metric divisor or lb
+// for an imperial one, and with an actual weight the larger of the two,
+// which is what the carrier bills
+func evalDimWeight(m []string) (utils.Result, error) {
+	b, err := parseBox(m[1])
+	if err != nil {
+		return utils.Result{}, err
+	}
+	divisor := float64(defaultMetricDivisor)
+	if b.imperial() {
+		divisor = defaultImperialDivisor
+	}
+	if m[2] != "" {
+		divisor, _ = strconv.ParseFloat(m[2], 64)
+		if divisor <= 0 {
+			return utils.Result{}, fmt.Errorf("the divisor must be more than zero")
+		}
+	}
+	imperial := divisor < imperialDivisorLimit
+	volUnit, weightUnit := "cm³", "kg"
+	if imperial {
+		volUnit, weightUnit = "in³", "lb"
+	}
+
+	volume := b.volume(imperial)
+	dim := volume / divisor
+	formula := fmt.Sprintf("%s → %s %s / %s", b.text(imperial), utils.FormatAmount(volume, 2), volUnit, strconv.FormatFloat(divisor, 'f', -1, 64))
+	if m[3] == "" {
+		text := fmt.Sprintf("%s %s (%s)", utils.FormatAmount(dim, 2), weightUnit, formula)
+		return utils.ValueResult(text, dim, false), nil
+	}
+
+	value, _ := strconv.ParseFloat(m[3], 64)
+	kg, ok := units.WeightInKilograms(value, m[4])
+	if !ok {
+		return utils.Result{}, fmt.Errorf("unknown weight unit %q", m[4])
+	}
+	actual := kg
+	if imperial {
+		actual = kg / kgPerLb
+	}
+	billed, basis := dim, "dim weight"
+	if actual >= dim {
+		billed, basis = actual, "actual weight"
+	}
+	text := fmt.Sprintf("billed %s %s by %s (actual %s %s, dim %s %s: %s)", utils.FormatAmount(billed, 2), weightUnit, basis,
+		utils.FormatAmount(actual, 2), weightUnit, utils.FormatAmount(dim, 2), weightUnit, formula)
+	return utils.ValueResult(text, billed, false), nil
+}
+
+// orientations are the ways an item can be turned to line its sides up with
+// the box's, as the order its sides take
+var orientations = [6][3]int{{0, 1, 2}, {0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 0, 1}, {2, 1, 0}}
+
+// evalFit estimates how many items fit in a box two ways: by volume, an upper
+// bound that ignores the gaps, and as a grid of items all turned the same
+// way along the box's sides, which can be packed for real. With a count of
+// items the value is the boxes they need at the grid's capacity.
+func evalFit(m []string) (utils.Result, error) {
+	item, err := parseBox(m[2])
+	if err != nil {
+		return utils.Result{}, err
+	}
+	container, err := parseBox(m[3])
+	if err != nil {
+		return utils.Result{}, err
+	}
+	imperial := container.imperial()
+	volUnit := "cm³"
+	if imperial {
+		volUnit = "in³"
+	}
+
+	byVolume := int(math.Floor(container.volume(imperial)/item.volume(imperial) + 1e-9))
+	best, grid, turned := 0, [3]int{}, [3]float64{}
+	itemSides := item.in(imperial)
+	boxSides := container.in(imperial)
+	for _, o := range orientations {
+		var g [3]int
+		n := 1
+		for axis := range 3 {
+			g[axis] = int(math.Floor(boxSides[axis]/itemSides[o[axis]] + 1e-9))
+			n *= g[axis]
+		}
+		if n > best {
+			best, grid = n, g
+			turned = [3]float64{itemSides[o[0]], itemSides[o[1]], itemSides[o[2]]}
+		}
+	}
+	if best == 0 {
+		return utils.Result{}, fmt.Errorf("a %s item does not fit in a %s box", item.text(imperial), container.text(imperial))
+	}
+
+	unit := "cm"
+	if imperial {
+		unit = "in"
+	}
+	estimate := fmt.Sprintf("%d per box as a %d × %d × %d grid of items turned %s × %s × %s %s; %d by volume (%s %s / %s %s)",
+		best, grid[0], grid[1], grid[2], utils.FormatAmount(turned[0], 2), utils.FormatAmount(turned[1], 2), utils.FormatAmount(turned[2], 2), unit,
+		byVolume, utils.FormatAmount(container.volume(imperial), 2), volUnit, utils.FormatAmount(item.volume(imperial), 2), volUnit)
+	if m[1] == "" {
+		return utils.ValueResult(estimate, float64(best), false), nil
+	}
+
+	count, _ := strconv.Atoi(m[1])
+	boxes := (count + best - 1) / best
+	text := fmt.Sprintf("%d %s: %s", boxes, utils.Plural(float64(boxes), "box", "boxes"), estimate)
+	return utils.ValueResult(text, float64(boxes), false), nil
+}
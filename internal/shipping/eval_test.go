@@ -0,0 +1,84 @@
+package shipping
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIsShippingExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"dim weight 40 x 30 x 20 cm at 5000", true},
+		{"Volumetric weight 40x30x20cm", true},
+		{"dim weight 16 x 12 x 10 in actual 5 lb", true},
+		{"fit 12 items of 10 x 8 x 6 cm in 60 x 40 x 40 cm box", true},
+		{"fit 10 x 8 x 6 cm into 60 x 40 x 40 cm", true},
+
+		// Conversions and material estimates are left alone
+		{"40 cm in inches", false},
+		{"concrete for slab 4 m x 3 m x 10 cm", false},
+		{"weight 5 kg", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsShippingExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsShippingExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalShipping(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+		value    float64
+	}{
+		{"dim weight 40 x 30 x 20 cm at 5000", "4.8 kg (40 × 30 × 20 cm → 24,000 cm³ / 5000)", 4.8},
+		{"dim weight 40 x 30 x 20 cm", "4.8 kg (40 × 30 × 20 cm → 24,000 cm³ / 5000)", 4.8},
+		{"dim weight 40 x 30 x 20 cm at 6000 actual 6 kg", "billed 6 kg by actual weight (actual 6 kg, dim 4 kg: 40 × 30 × 20 cm → 24,000 cm³ / 6000)", 6},
+		{"dim weight 60 x 40 x 40 cm, actual 6 kg", "billed 19.2 kg by dim weight (actual 6 kg, dim 19.2 kg: 60 × 40 × 40 cm → 96,000 cm³ / 5000)", 19.2},
+		{"dim weight 16 x 12 x 10 in", "13.81 lb (16 × 12 × 10 in → 1,920 in³ / 139)", 1920.0 / 139},
+		{"dim weight 16 x 12 x 10 in at 166 actual 5 lb", "billed 11.57 lb by dim weight (actual 5 lb, dim 11.57 lb: 16 × 12 × 10 in → 1,920 in³ / 166)", 1920.0 / 166},
+
+		// A metric divisor bills inch boxes in kg, and an imperial one cm boxes in lb
+		{"dim weight 10 x 10 x 10 in at 5000", "3.28 kg (25.4 × 25.4 × 25.4 cm → 16,387.06 cm³ / 5000)", 16387.064 / 5000},
+
+		{"fit 12 items of 10 x 8 x 6 cm in 60 x 40 x 40 cm box", "1 box: 200 per box as a 10 × 4 × 5 grid of items turned 6 × 10 × 8 cm; 200 by volume (96,000 cm³ / 480 cm³)", 1},
+		{"fit 500 items of 4 x 4 x 4 in in 12 x 12 x 12 in box", "19 boxes: 27 per box as a 3 × 3 × 3 grid of items turned 4 × 4 × 4 in; 27 by volume (1,728 in³ / 64 in³)", 19},
+		// The gaps make the grid hold fewer than the volume
+		{"fit 30 x 30 x 30 cm into 70 x 70 x 40 cm", "4 per box as a 2 × 2 × 1 grid of items turned 30 × 30 × 30 cm; 7 by volume (196,000 cm³ / 27,000 cm³)", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalShipping(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalShipping(%q) error: %v", tt.expr, err)
+			}
+			if result.Text != tt.expected {
+				t.Errorf("EvalShipping(%q) = %q, want %q", tt.expr, result.Text, tt.expected)
+			}
+			if math.Abs(result.Value-tt.value) > 1e-9 {
+				t.Errorf("EvalShipping(%q) value = %v, want %v", tt.expr, result.Value, tt.value)
+			}
+		})
+	}
+}
+
+func TestEvalShippingErrors(t *testing.T) {
+	tests := []string{
+		"dim weight 40 x 30 x 20 at 5000",
+		"dim weight 40 x 30 x 20 parsecs",
+		"dim weight 40 x 30 x 20 cm at 0",
+		"fit 1 item of 70 x 1 x 1 cm in 60 x 40 x 40 cm box",
+	}
+	for _, expr := range tests {
+		if _, err := EvalShipping(expr); err == nil {
+			t.Errorf("EvalShipping(%q) should fail", expr)
+		}
+	}
+}
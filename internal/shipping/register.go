@@ -0,0 +1,16 @@
+package shipping
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// Boxes that don't parse are reported instead of left to unit
+	// conversions. Dimensions are shown as typed: "40 x 30 x 20 cm" is not
+	// arithmetic to space out.
+	registry.Register(registry.Evaluator{
+		Name:     "shipping",
+		Priority: registry.PriorityShipping,
+		Traits:   registry.ReportsErrors | registry.NoFormat,
+		Detect:   IsShippingExpression,
+		Eval:     EvalShipping,
+	})
+}
@@ -0,0 +1,87 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Aggregate computes a statistic of numbers by name: sum, avg (average,
+// mean), median, min, max, stddev (stdev), variance (var), count or range.
+// ok is false for an unknown name or no numbers.
+func Aggregate(name string, numbers []float64) (v float64, ok bool) {
+	if len(numbers) == 0 {
+		return 0, false
+	}
+	switch strings.ToLower(name) {
+	case "sum", "total":
+		return sum(numbers), true
+	case "avg", "average", "mean":
+		return mean(numbers), true
+	case "median":
+		return median(numbers), true
+	case "min":
+		return minOf(numbers), true
+	case "max":
+		return maxOf(numbers), true
+	case "stddev", "stdev":
+		return math.Sqrt(variance(numbers)), true
+	case "variance", "var":
+		return variance(numbers), true
+	case "count":
+		return float64(len(numbers)), true
+	case "range":
+		return maxOf(numbers) - minOf(numbers), true
+	}
+	return 0, false
+}
+
+func sum(numbers []float64) float64 {
+	total := 0.0
+	for _, n := range numbers {
+		total += n
+	}
+	return total
+}
+
+func mean(numbers []float64) float64 {
+	return sum(numbers) / float64(len(numbers))
+}
+
+// median returns the middle value, or the mean of the two middle values,
+// without reordering numbers
+func median(numbers []float64) float64 {
+	sorted := append([]float64(nil), numbers...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 0 {
+		return (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	return sorted[n/2]
+}
+
+func minOf(numbers []float64) float64 {
+	lowest := numbers[0]
+	for _, n := range numbers[1:] {
+		lowest = math.Min(lowest, n)
+	}
+	return lowest
+}
+
+func maxOf(numbers []float64) float64 {
+	highest := numbers[0]
+	for _, n := range numbers[1:] {
+		highest = math.Max(highest, n)
+	}
+	return highest
+}
+
+// variance is the population variance, as stddev() and variance() compute it
+func variance(numbers []float64) float64 {
+	m := mean(numbers)
+	total := 0.0
+	for _, n := range numbers {
+		total += (n - m) * (n - m)
+	}
+	return total / float64(len(numbers))
+}
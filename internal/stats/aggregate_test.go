@@ -0,0 +1,45 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	numbers := []float64{4, 1, 3, 2, 10}
+	tests := []struct {
+		name     string
+		expected float64
+	}{
+		{"sum", 20},
+		{"total", 20},
+		{"avg", 4},
+		{"Mean", 4},
+		{"median", 3},
+		{"min", 1},
+		{"max", 10},
+		{"variance", 10},
+		{"stddev", math.Sqrt(10)},
+		{"count", 5},
+		{"range", 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Aggregate(tt.name, numbers)
+			if !ok || math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("Aggregate(%q) = %v, %v, want %v", tt.name, got, ok, tt.expected)
+			}
+		})
+	}
+
+	if _, ok := Aggregate("sum", nil); ok {
+		t.Error("Aggregate of no numbers is ok, want not")
+	}
+	if _, ok := Aggregate("product", numbers); ok {
+		t.Error("Aggregate(product) is ok, want not")
+	}
+	if numbers[0] != 4 {
+		t.Errorf("Aggregate reordered its numbers: %v", numbers)
+	}
+}
@@ -13,32 +13,53 @@ import (
 
 // Handler defines the interface for statistics handlers.
 type Handler interface {
-	Handle(expr, exprLower string) (string, bool)
+	Handle(expr, exprLower string) (utils.Result, bool)
 }
 
 // HandlerFunc is an adapter to allow ordinary functions to be used as Handlers.
 type HandlerFunc func(expr, exprLower string) (string, bool)
 
+// Handle calls the underlying function; its output has no referenceable value.
+func (f HandlerFunc) Handle(expr, exprLower string) (utils.Result, bool) {
+	text, ok := f(expr, exprLower)
+	return utils.TextResult(text), ok
+}
+
+// ValueHandlerFunc is an adapter for handlers whose result has a primary
+// numeric value that later lines can reference.
+type ValueHandlerFunc func(expr, exprLower string) (utils.Result, bool)
+
 // Handle calls the underlying function.
-func (f HandlerFunc) Handle(expr, exprLower string) (string, bool) {
+func (f ValueHandlerFunc) Handle(expr, exprLower string) (utils.Result, bool) {
 	return f(expr, exprLower)
 }
 
 // handlerChain is the ordered list of handlers for statistics.
 var handlerChain = []Handler{
-	HandlerFunc(handleAverage),
-	HandlerFunc(handleMedian),
-	HandlerFunc(handleSum),
-	HandlerFunc(handleMin),
-	HandlerFunc(handleMax),
-	HandlerFunc(handleStdDev),
-	HandlerFunc(handleVariance),
-	HandlerFunc(handleCount),
-	HandlerFunc(handleRange),
+	ValueHandlerFunc(handleAverage),
+	ValueHandlerFunc(handleMedian),
+	ValueHandlerFunc(handleSum),
+	ValueHandlerFunc(handleMin),
+	ValueHandlerFunc(handleMax),
+	ValueHandlerFunc(handleStdDev),
+	ValueHandlerFunc(handleVariance),
+	ValueHandlerFunc(handleCount),
+	ValueHandlerFunc(handleRange),
+	ValueHandlerFunc(handleDescribe),
+	ValueHandlerFunc(handlePercentile),
+	ValueHandlerFunc(handleMode),
+	ValueHandlerFunc(handleWeightedAverage),
 }
 
 // EvalStats evaluates a statistics expression and returns the result.
 func EvalStats(expr string) (string, error) {
+	result, err := EvalStatsResult(expr)
+	return result.Text, err
+}
+
+// EvalStatsResult evaluates a statistics expression and also reports its
+// referenceable value: the computed statistic, or the mean for describe().
+func EvalStatsResult(expr string) (utils.Result, error) {
 	expr = strings.TrimSpace(expr)
 	exprLower := strings.ToLower(expr)
 
@@ -48,7 +69,7 @@ func EvalStats(expr string) (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("unable to evaluate statistics expression: %s", expr)
+	return utils.Result{}, fmt.Errorf("unable to evaluate statistics expression: %s", expr)
 }
 
 // IsStatsExpression checks if an expression looks like a statistics calculation.
@@ -64,6 +85,10 @@ func IsStatsExpression(expr string) bool {
 		"variance(", "var(",
 		"count(",
 		"range(",
+		"describe(",
+		"percentile(",
+		"mode(",
+		"weightedavg(",
 	}
 
 	for _, fn := range statsFunctions {
@@ -72,7 +97,7 @@ func IsStatsExpression(expr string) bool {
 		}
 	}
 
-	return false
+	return weightedAvgPattern.MatchString(exprLower)
 }
 
 func parseNumbers(expr string) ([]float64, bool) {
@@ -107,201 +132,271 @@ func parseNumbers(expr string) ([]float64, bool) {
 	return numbers, true
 }
 
-func handleAverage(expr, exprLower string) (string, bool) {
+func handleAverage(expr, exprLower string) (utils.Result, bool) {
 	// Pattern: avg(1, 2, 3) or average(1, 2, 3) or mean(1, 2, 3)
 	if !strings.HasPrefix(exprLower, "avg(") &&
 		!strings.HasPrefix(exprLower, "average(") &&
 		!strings.HasPrefix(exprLower, "mean(") {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	numbers, ok := parseNumbers(expr)
 	if !ok {
-		return "", false
+		return utils.Result{}, false
 	}
 
-	sum := 0.0
-	for _, n := range numbers {
-		sum += n
-	}
-	avg := sum / float64(len(numbers))
+	avg := mean(numbers)
 
-	return formatResult(avg), true
+	return utils.ValueResult(formatResult(avg), avg, false), true
 }
 
-func handleMedian(expr, exprLower string) (string, bool) {
+func handleMedian(expr, exprLower string) (utils.Result, bool) {
 	if !strings.HasPrefix(exprLower, "median(") {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	numbers, ok := parseNumbers(expr)
 	if !ok {
-		return "", false
+		return utils.Result{}, false
 	}
 
-	sort.Float64s(numbers)
-	n := len(numbers)
-
-	var median float64
-	if n%2 == 0 {
-		median = (numbers[n/2-1] + numbers[n/2]) / 2
-	} else {
-		median = numbers[n/2]
-	}
-
-	return formatResult(median), true
+	mid := median(numbers)
+	return utils.ValueResult(formatResult(mid), mid, false), true
 }
 
-func handleSum(expr, exprLower string) (string, bool) {
+func handleSum(expr, exprLower string) (utils.Result, bool) {
 	if !strings.HasPrefix(exprLower, "sum(") {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	numbers, ok := parseNumbers(expr)
 	if !ok {
-		return "", false
+		return utils.Result{}, false
 	}
 
-	sum := 0.0
-	for _, n := range numbers {
-		sum += n
-	}
-
-	return formatResult(sum), true
+	total := sum(numbers)
+	return utils.ValueResult(formatResult(total), total, false), true
 }
 
-func handleMin(expr, exprLower string) (string, bool) {
+func handleMin(expr, exprLower string) (utils.Result, bool) {
 	if !strings.HasPrefix(exprLower, "min(") {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	numbers, ok := parseNumbers(expr)
 	if !ok {
-		return "", false
+		return utils.Result{}, false
 	}
 
-	min := numbers[0]
-	for _, n := range numbers[1:] {
-		if n < min {
-			min = n
-		}
-	}
-
-	return formatResult(min), true
+	lowest := minOf(numbers)
+	return utils.ValueResult(formatResult(lowest), lowest, false), true
 }
 
-func handleMax(expr, exprLower string) (string, bool) {
+func handleMax(expr, exprLower string) (utils.Result, bool) {
 	if !strings.HasPrefix(exprLower, "max(") {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	numbers, ok := parseNumbers(expr)
 	if !ok {
-		return "", false
+		return utils.Result{}, false
 	}
 
-	max := numbers[0]
-	for _, n := range numbers[1:] {
-		if n > max {
-			max = n
-		}
-	}
-
-	return formatResult(max), true
+	highest := maxOf(numbers)
+	return utils.ValueResult(formatResult(highest), highest, false), true
 }
 
-func handleStdDev(expr, exprLower string) (string, bool) {
+func handleStdDev(expr, exprLower string) (utils.Result, bool) {
 	if !strings.HasPrefix(exprLower, "stddev(") && !strings.HasPrefix(exprLower, "stdev(") {
-		return "", false
+		return utils.Result{}, false
 	}
 
 	numbers, ok := parseNumbers(expr)
 	if !ok || len(numbers) < 2 {
-		return "", false
+		return utils.Result{}, false
 	}
 
-	// Calculate mean
-	sum := 0.0
-	for _, n := range numbers {
-		sum += n
+	stddev := math.Sqrt(variance(numbers))
+	return utils.ValueResult(formatResult(stddev), stddev, false), true
+}
+
+func handleVariance(expr, exprLower string) (utils.Result, bool) {
+	if !strings.HasPrefix(exprLower, "variance(") && !strings.HasPrefix(exprLower, "var(") {
+		return utils.Result{}, false
 	}
-	mean := sum / float64(len(numbers))
 
-	// Calculate variance
-	variance := 0.0
-	for _, n := range numbers {
-		variance += (n - mean) * (n - mean)
+	numbers, ok := parseNumbers(expr)
+	if !ok || len(numbers) < 2 {
+		return utils.Result{}, false
 	}
-	variance /= float64(len(numbers))
 
-	stddev := math.Sqrt(variance)
-	return formatResult(stddev), true
+	v := variance(numbers)
+	return utils.ValueResult(formatResult(v), v, false), true
 }
 
-func handleVariance(expr, exprLower string) (string, bool) {
-	if !strings.HasPrefix(exprLower, "variance(") && !strings.HasPrefix(exprLower, "var(") {
-		return "", false
+func handleCount(expr, exprLower string) (utils.Result, bool) {
+	if !strings.HasPrefix(exprLower, "count(") {
+		return utils.Result{}, false
 	}
 
 	numbers, ok := parseNumbers(expr)
-	if !ok || len(numbers) < 2 {
-		return "", false
+	if !ok {
+		return utils.Result{}, false
 	}
 
-	// Calculate mean
-	sum := 0.0
-	for _, n := range numbers {
-		sum += n
+	return utils.ValueResult(fmt.Sprintf("%d", len(numbers)), float64(len(numbers)), false), true
+}
+
+func handleRange(expr, exprLower string) (utils.Result, bool) {
+	if !strings.HasPrefix(exprLower, "range(") {
+		return utils.Result{}, false
 	}
-	mean := sum / float64(len(numbers))
 
-	// Calculate variance
-	variance := 0.0
-	for _, n := range numbers {
-		variance += (n - mean) * (n - mean)
+	numbers, ok := parseNumbers(expr)
+	if !ok {
+		return utils.Result{}, false
 	}
-	variance /= float64(len(numbers))
 
-	return formatResult(variance), true
+	spread := maxOf(numbers) - minOf(numbers)
+	return utils.ValueResult(formatResult(spread), spread, false), true
 }
 
-func handleCount(expr, exprLower string) (string, bool) {
-	if !strings.HasPrefix(exprLower, "count(") {
-		return "", false
+func handleDescribe(expr, exprLower string) (utils.Result, bool) {
+	// Pattern: describe(1, 2, 3) - summary of the data set
+	if !strings.HasPrefix(exprLower, "describe(") {
+		return utils.Result{}, false
 	}
 
 	numbers, ok := parseNumbers(expr)
 	if !ok {
-		return "", false
+		return utils.Result{}, false
 	}
 
-	return fmt.Sprintf("%d", len(numbers)), true
+	avg := mean(numbers)
+	text := fmt.Sprintf("\n> Count: %d\n> Mean: %s\n> Median: %s\n> Std Dev: %s\n> Min: %s\n> Max: %s",
+		len(numbers), formatResult(avg), formatResult(median(numbers)), formatResult(math.Sqrt(variance(numbers))),
+		formatResult(minOf(numbers)), formatResult(maxOf(numbers)))
+	return utils.ValueResult(text, avg, false), true
 }
 
-func handleRange(expr, exprLower string) (string, bool) {
-	if !strings.HasPrefix(exprLower, "range(") {
-		return "", false
+func formatResult(value float64) string {
+	return utils.FormatResult(false, value)
+}
+
+func handlePercentile(expr, exprLower string) (utils.Result, bool) {
+	// Pattern: percentile(90, 12, 45, 67) - the first argument is the percentile
+	if !strings.HasPrefix(exprLower, "percentile(") {
+		return utils.Result{}, false
+	}
+
+	numbers, ok := parseNumbers(expr)
+	if !ok || len(numbers) < 2 {
+		return utils.Result{}, false
+	}
+	p, data := numbers[0], numbers[1:]
+	if p < 0 || p > 100 {
+		return utils.TextResult("ERR: percentile must be between 0 and 100"), true
+	}
+
+	value := percentile(data, p)
+	return utils.ValueResult(formatResult(value), value, false), true
+}
+
+// percentile returns the p-th percentile of data, interpolating linearly
+// between the two closest ranks (the method of Excel's PERCENTILE.INC)
+func percentile(data []float64, p float64) float64 {
+	sorted := append([]float64(nil), data...)
+	sort.Float64s(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	if lower >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	frac := rank - float64(lower)
+	return sorted[lower] + frac*(sorted[lower+1]-sorted[lower])
+}
+
+func handleMode(expr, exprLower string) (utils.Result, bool) {
+	// Pattern: mode(1, 2, 2, 3); multimodal data lists every mode
+	if !strings.HasPrefix(exprLower, "mode(") {
+		return utils.Result{}, false
 	}
 
 	numbers, ok := parseNumbers(expr)
 	if !ok {
-		return "", false
+		return utils.Result{}, false
 	}
 
-	min := numbers[0]
-	max := numbers[0]
-	for _, n := range numbers[1:] {
-		if n < min {
-			min = n
-		}
-		if n > max {
-			max = n
+	counts := make(map[float64]int)
+	best := 0
+	for _, n := range numbers {
+		counts[n]++
+		best = max(best, counts[n])
+	}
+	if best == 1 && len(numbers) > 1 {
+		return utils.TextResult("no mode"), true
+	}
+
+	var modes []float64
+	for n, c := range counts {
+		if c == best {
+			modes = append(modes, n)
 		}
 	}
+	sort.Float64s(modes)
+	if len(modes) == 1 {
+		return utils.ValueResult(formatResult(modes[0]), modes[0], false), true
+	}
 
-	return formatResult(max - min), true
+	parts := make([]string, len(modes))
+	for i, m := range modes {
+		parts[i] = formatResult(m)
+	}
+	return utils.TextResult(strings.Join(parts, ", ")), true
 }
 
-func formatResult(value float64) string {
-	return utils.FormatResult(false, value)
+// weightedPairPattern matches a (value, weight) pair of weightedavg()
+var weightedPairPattern = regexp.MustCompile(`\(\s*(-?[\d.]+)\s*,\s*(-?[\d.]+)\s*\)`)
+
+// weightedAvgPattern matches the alternate syntax "weighted avg 80*0.3 90*0.7"
+var weightedAvgPattern = regexp.MustCompile(`^weighted\s+(?:avg|average|mean)\s+(.+)$`)
+
+// weightedTermPattern matches a value*weight term of the alternate syntax
+var weightedTermPattern = regexp.MustCompile(`(-?[\d.]+)\s*[*×]\s*(-?[\d.]+)`)
+
+func handleWeightedAverage(expr, exprLower string) (utils.Result, bool) {
+	// Pattern: weightedavg((80, 0.3), (90, 0.7)) or weighted avg 80*0.3 90*0.7
+	var pairs [][]string
+	var rest string
+	if strings.HasPrefix(exprLower, "weightedavg(") && strings.HasSuffix(exprLower, ")") {
+		pairs = weightedPairPattern.FindAllStringSubmatch(exprLower, -1)
+		rest = weightedPairPattern.ReplaceAllString(exprLower[len("weightedavg("):len(exprLower)-1], "")
+	} else if m := weightedAvgPattern.FindStringSubmatch(exprLower); m != nil {
+		pairs = weightedTermPattern.FindAllStringSubmatch(m[1], -1)
+		rest = weightedTermPattern.ReplaceAllString(m[1], "")
+	} else {
+		return utils.Result{}, false
+	}
+	// Everything but the pairs must be separators
+	if len(pairs) == 0 || strings.Trim(rest, ", ") != "" {
+		return utils.Result{}, false
+	}
+
+	total, weights := 0.0, 0.0
+	for _, pair := range pairs {
+		value, err1 := strconv.ParseFloat(pair[1], 64)
+		weight, err2 := strconv.ParseFloat(pair[2], 64)
+		if err1 != nil || err2 != nil {
+			return utils.Result{}, false
+		}
+		total += value * weight
+		weights += weight
+	}
+	if weights == 0 {
+		return utils.TextResult("ERR: weights add up to zero"), true
+	}
+
+	avg := total / weights
+	return utils.ValueResult(formatResult(avg), avg, false), true
 }
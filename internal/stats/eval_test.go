@@ -1,6 +1,7 @@
 package stats
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -197,6 +198,131 @@ func TestRange(t *testing.T) {
 	}
 }
 
+func TestDescribe(t *testing.T) {
+	result, err := EvalStats("describe(2, 4, 4, 4, 5, 5, 7, 9)")
+	if err != nil {
+		t.Fatalf("EvalStats error: %v", err)
+	}
+	for _, c := range []string{"> Count: 8", "> Mean: 5", "> Median: 4.5", "> Std Dev: 2", "> Min: 2", "> Max: 9"} {
+		if !strings.Contains(result, c) {
+			t.Errorf("EvalStats(describe) = %q, want to contain %q", result, c)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	// Known values from linear interpolation between ranks (Excel PERCENTILE.INC)
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"percentile(90, 12, 45, 67, 89, 23)", "80.2"},
+		{"percentile(50, 12, 45, 67, 89, 23)", "45"},
+		{"percentile(25, 1, 2, 3, 4)", "1.75"},
+		{"percentile(0, 3, 1, 2)", "1"},
+		{"percentile(100, 3, 1, 2)", "3"},
+		{"percentile(40, 15, 20, 35, 40, 50)", "29"},
+		{"percentile(95, 7)", "7"},
+		{"percentile(101, 1, 2)", "ERR: percentile must be between 0 and 100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalStats(tt.expr)
+			if err != nil {
+				t.Errorf("EvalStats(%q) error: %v", tt.expr, err)
+				return
+			}
+			if result != tt.expected {
+				t.Errorf("EvalStats(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMode(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"mode(1, 2, 2, 3)", "2"},
+		{"mode(3, 1, 3, 1, 2)", "1, 3"},
+		{"mode(4.5, 4.5, -1)", "4.5"},
+		{"mode(7)", "7"},
+		{"mode(1, 2, 3)", "no mode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalStats(tt.expr)
+			if err != nil {
+				t.Errorf("EvalStats(%q) error: %v", tt.expr, err)
+				return
+			}
+			if result != tt.expected {
+				t.Errorf("EvalStats(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWeightedAverage(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"weightedavg((80, 0.3), (90, 0.7))", "87"},
+		{"weightedavg((1, 1), (4, 2))", "3"},
+		{"weighted avg 80*0.3 90*0.7", "87"},
+		{"weighted average 80 * 0.3, 90 * 0.7", "87"},
+		{"weightedavg((5, 0), (6, 0))", "ERR: weights add up to zero"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalStats(tt.expr)
+			if err != nil {
+				t.Errorf("EvalStats(%q) error: %v", tt.expr, err)
+				return
+			}
+			if result != tt.expected {
+				t.Errorf("EvalStats(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+
+	if _, err := EvalStats("weighted avg 80*0.3 oops"); err == nil {
+		t.Error("EvalStats(weighted avg with a bad term) should fail")
+	}
+}
+
+func TestEvalStatsResultValue(t *testing.T) {
+	tests := []struct {
+		expr  string
+		value float64
+	}{
+		{"avg(10, 20, 30)", 20},
+		{"sum(1, 2, 3)", 6},
+		{"count(5, 5, 5)", 3},
+		{"describe(2, 4, 4, 4, 5, 5, 7, 9)", 5}, // the mean
+		{"percentile(50, 1, 2, 3, 10)", 2.5},
+		{"mode(1, 2, 2)", 2},
+		{"weighted avg 80*0.3 90*0.7", 87},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalStatsResult(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalStatsResult(%q) error: %v", tt.expr, err)
+			}
+			if !result.HasValue || result.Value != tt.value {
+				t.Errorf("EvalStatsResult(%q) = (%v, %v), want (%v, true)", tt.expr, result.Value, result.HasValue, tt.value)
+			}
+		})
+	}
+}
+
 func TestIsStatsExpression(t *testing.T) {
 	tests := []struct {
 		expr     string
@@ -206,6 +332,10 @@ func TestIsStatsExpression(t *testing.T) {
 		{"median(1, 2, 3)", true},
 		{"sum(1, 2, 3)", true},
 		{"stddev(1, 2, 3)", true},
+		{"percentile(90, 1, 2, 3)", true},
+		{"mode(1, 2, 2)", true},
+		{"weightedavg((80, 0.3), (90, 0.7))", true},
+		{"weighted avg 80*0.3 90*0.7", true},
 		{"100 + 50", false},
 		{"5 miles in km", false},
 	}
@@ -0,0 +1,214 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/utils"
+)
+
+// gradePoints maps letter grades to points on the standard 4.0 scale
+var gradePoints = map[string]float64{
+	"A+": 4.0, "A": 4.0, "A-": 3.7,
+	"B+": 3.3, "B": 3.0, "B-": 2.7,
+	"C+": 2.3, "C": 2.0, "C-": 1.7,
+	"D+": 1.3, "D": 1.0, "D-": 0.7,
+	"F": 0,
+}
+
+// GradeBand is the lowest percentage that earns a letter grade
+type GradeBand struct {
+	Letter string
+	Min    float64
+}
+
+// GradeScale maps percentages to letter grades. Bands are ordered from the
+// highest minimum down; a percentage below every band is an F.
+type GradeScale []GradeBand
+
+// DefaultGradeScale is the common US scale: 93% for an A, 90% for an A-, and
+// so on down to 60% for a D-
+var DefaultGradeScale = GradeScale{
+	{"A", 93}, {"A-", 90},
+	{"B+", 87}, {"B", 83}, {"B-", 80},
+	{"C+", 77}, {"C", 73}, {"C-", 70},
+	{"D+", 67}, {"D", 63}, {"D-", 60},
+}
+
+// String renders the scale as the bands of a "#grade scale" directive
+func (s GradeScale) String() string {
+	parts := make([]string, len(s))
+	for i, b := range s {
+		parts[i] = fmt.Sprintf("%s %g", b.Letter, b.Min)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Letter returns the letter grade of a percentage
+func (s GradeScale) Letter(pct float64) string {
+	for _, b := range s {
+		if pct >= b.Min {
+			return b.Letter
+		}
+	}
+	return "F"
+}
+
+// gradeScalePattern matches a "#grade scale A 90, B 80, C 70, D 60" directive
+var gradeScalePattern = regexp.MustCompile(`(?i)^\s*#\s*grade\s+scale\s+(.+)$`)
+
+// gradeBandPattern matches one band of a grade scale directive: "B+ 87"
+var gradeBandPattern = regexp.MustCompile(`^([a-fA-F][+\-−]?)\s+(\d+(?:\.\d+)?)\s*%?$`)
+
+// ParseGradeScale parses a "#grade scale A 90, B 80, C 70, D 60" directive
+// line. ok is false for other lines; err reports a malformed directive.
+func ParseGradeScale(line string) (scale GradeScale, ok bool, err error) {
+	m := gradeScalePattern.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false, nil
+	}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(m[1], ",") {
+		b := gradeBandPattern.FindStringSubmatch(strings.TrimSpace(part))
+		if b == nil {
+			return nil, true, fmt.Errorf("grade scale: expected a letter and a percentage, got %q", strings.TrimSpace(part))
+		}
+		letter := normalizeGrade(b[1])
+		if seen[letter] {
+			return nil, true, fmt.Errorf("grade scale: %s is listed twice", letter)
+		}
+		seen[letter] = true
+		minPct, _ := strconv.ParseFloat(b[2], 64)
+		scale = append(scale, GradeBand{Letter: letter, Min: minPct})
+	}
+	sort.SliceStable(scale, func(i, j int) bool { return scale[i].Min > scale[j].Min })
+	for i := 1; i < len(scale); i++ {
+		if scale[i].Min == scale[i-1].Min {
+			return nil, true, fmt.Errorf("grade scale: %s and %s start at the same percentage", scale[i-1].Letter, scale[i].Letter)
+		}
+		if gradePoints[scale[i].Letter] > gradePoints[scale[i-1].Letter] {
+			return nil, true, fmt.Errorf("grade scale: %s needs a higher percentage than %s", scale[i].Letter, scale[i-1].Letter)
+		}
+	}
+	return scale, true, nil
+}
+
+// normalizeGrade upper-cases a letter grade and writes a typographic minus as "-"
+func normalizeGrade(s string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), "−", "-"))
+}
+
+// gpaPattern matches "gpa of A, A-, B+, B" with optional credits:
+// "gpa of A, A-, B+, B (3, 3, 4, 3 credits)"
+var gpaPattern = regexp.MustCompile(`(?i)^gpa\s+(?:of\s+)?([a-f][+\-−]?(?:\s*,\s*[a-f][+\-−]?)*)\s*(?:\(([^)]*?)\s*(?:credits?|hours?)?\s*\))?$`)
+
+// letterGradePattern matches "88% to letter grade"
+var letterGradePattern = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)?)\s*%?\s+(?:to|in|as)\s+(?:letter\s+)?grade$`)
+
+// gpaToPercentPattern matches "3.7 gpa to percentage"
+var gpaToPercentPattern = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)?)\s+gpa\s+(?:to|in|as)\s+(?:percentage|percent|%)$`)
+
+// IsGradeExpression checks if an expression is a GPA or grade conversion
+func IsGradeExpression(expr string) bool {
+	expr = strings.TrimSpace(expr)
+	return gpaPattern.MatchString(expr) || letterGradePattern.MatchString(expr) || gpaToPercentPattern.MatchString(expr)
+}
+
+// EvalGrade evaluates a GPA or grade conversion with a percentage scale
+func EvalGrade(expr string, scale GradeScale) (utils.Result, error) {
+	expr = strings.TrimSpace(expr)
+	if m := gpaPattern.FindStringSubmatch(expr); m != nil {
+		return evalGPA(m[1], m[2])
+	}
+	if m := letterGradePattern.FindStringSubmatch(expr); m != nil {
+		pct, _ := strconv.ParseFloat(m[1], 64)
+		return utils.TextResult(scale.Letter(pct)), nil
+	}
+	if m := gpaToPercentPattern.FindStringSubmatch(expr); m != nil {
+		gpa, _ := strconv.ParseFloat(m[1], 64)
+		return gpaToPercent(gpa, scale)
+	}
+	return utils.Result{}, fmt.Errorf("unable to evaluate grade expression: %s", expr)
+}
+
+// evalGPA computes the GPA of comma-separated letter grades, weighted by
+// credits when they are given
+func evalGPA(gradeList, creditList string) (utils.Result, error) {
+	grades := strings.Split(gradeList, ",")
+	credits := make([]float64, len(grades))
+	for i := range credits {
+		credits[i] = 1
+	}
+	if creditList != "" {
+		parts := strings.Split(creditList, ",")
+		if len(parts) != len(grades) {
+			return utils.Result{}, fmt.Errorf("%d grades need %d credit values, got %d", len(grades), len(grades), len(parts))
+		}
+		for i, part := range parts {
+			c, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil || c < 0 {
+				return utils.Result{}, fmt.Errorf("invalid credits %q", strings.TrimSpace(part))
+			}
+			credits[i] = c
+		}
+	}
+
+	points, total := 0.0, 0.0
+	for i, g := range grades {
+		p, ok := gradePoints[normalizeGrade(g)]
+		if !ok {
+			return utils.Result{}, fmt.Errorf("unknown grade %q", strings.TrimSpace(g))
+		}
+		points += p * credits[i]
+		total += credits[i]
+	}
+	if total == 0 {
+		return utils.Result{}, fmt.Errorf("credits add up to zero")
+	}
+	gpa := points / total
+	return utils.ValueResult(fmt.Sprintf("%.2f", gpa), gpa, false), nil
+}
+
+// gpaToPercent estimates the percentage behind a GPA by interpolating between
+// the letters of the scale: with the default scale a 3.7 (A-) is 90% and a
+// 3.5, halfway between B+ and A-, is 88.5%
+func gpaToPercent(gpa float64, scale GradeScale) (utils.Result, error) {
+	if gpa < 0 || gpa > 4 {
+		return utils.Result{}, fmt.Errorf("GPA must be between 0 and 4")
+	}
+	// Anchor each letter's points at the bottom of its band; A+ shares 4.0 with A
+	type anchor struct{ points, pct float64 }
+	var anchors []anchor
+	for _, b := range scale {
+		if p := gradePoints[b.Letter]; len(anchors) == 0 || p < anchors[len(anchors)-1].points {
+			anchors = append(anchors, anchor{p, b.Min})
+		}
+	}
+	if len(anchors) == 0 {
+		return utils.Result{}, fmt.Errorf("the grade scale has no bands")
+	}
+
+	var pct float64
+	lowest := anchors[len(anchors)-1]
+	switch {
+	case gpa >= anchors[0].points:
+		pct = anchors[0].pct
+	case gpa < lowest.points:
+		// Below the lowest band the scale runs down to 0%
+		pct = lowest.pct * gpa / lowest.points
+	default:
+		for i := 1; i < len(anchors); i++ {
+			hi, lo := anchors[i-1], anchors[i]
+			if gpa >= lo.points {
+				pct = lo.pct + (gpa-lo.points)/(hi.points-lo.points)*(hi.pct-lo.pct)
+				break
+			}
+		}
+	}
+	text := fmt.Sprintf("≈ %s%% (%s)", utils.FormatResult(false, math.Round(pct*10)/10), scale.Letter(pct))
+	return utils.ValueResult(text, pct, false), nil
+}
@@ -0,0 +1,100 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvalGrade(t *testing.T) {
+	custom := GradeScale{{"A", 90}, {"B", 80}, {"C", 70}, {"D", 60}}
+	tests := []struct {
+		expr     string
+		scale    GradeScale
+		expected string
+	}{
+		{"gpa of A, A-, B+, B (3, 3, 4, 3 credits)", DefaultGradeScale, "3.48"},
+		{"gpa of A, B", DefaultGradeScale, "3.50"},
+		{"GPA A−, c+", DefaultGradeScale, "3.00"},
+		{"gpa of B, F (4, 1 hours)", DefaultGradeScale, "2.40"},
+		{"88% to letter grade", DefaultGradeScale, "B+"},
+		{"93 to grade", DefaultGradeScale, "A"},
+		{"59.9% to letter grade", DefaultGradeScale, "F"},
+		{"88% to letter grade", custom, "B"},
+		{"3.7 gpa to percentage", DefaultGradeScale, "≈ 90% (A-)"},
+		{"3.5 gpa to percentage", DefaultGradeScale, "≈ 88.5% (B+)"},
+		{"4 gpa to percentage", DefaultGradeScale, "≈ 93% (A)"},
+		{"3.5 gpa to percentage", custom, "≈ 85% (B)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			r, err := EvalGrade(tt.expr, tt.scale)
+			if err != nil {
+				t.Fatalf("EvalGrade(%q) error: %v", tt.expr, err)
+			}
+			if r.Text != tt.expected {
+				t.Errorf("EvalGrade(%q) = %q, want %q", tt.expr, r.Text, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalGradeErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"gpa of A, B (3 credits)", "2 grades need 2 credit values, got 1"},
+		{"gpa of A, B (3, 3, 4)", "2 grades need 2 credit values, got 3"},
+		{"gpa of A, E", `unknown grade "E"`},
+		{"gpa of A (0 credits)", "credits add up to zero"},
+		{"4.5 gpa to percentage", "GPA must be between 0 and 4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := EvalGrade(tt.expr, DefaultGradeScale)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("EvalGrade(%q) error = %v, want %q", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseGradeScale(t *testing.T) {
+	scale, ok, err := ParseGradeScale("#grade scale D 60, C 70, B 80, A 90")
+	if !ok || err != nil {
+		t.Fatalf("ParseGradeScale() = %v, %v", ok, err)
+	}
+	if got := scale.String(); got != "A 90, B 80, C 70, D 60" {
+		t.Errorf("scale = %q, want bands sorted from the top", got)
+	}
+
+	if _, ok, _ := ParseGradeScale("# grades for the fall term"); ok {
+		t.Error("plain comment parsed as a grade scale")
+	}
+
+	for _, line := range []string{
+		"#grade scale A 90, B",
+		"#grade scale A 90, A 80",
+		"#grade scale A 90, B 90",
+		"#grade scale A 80, B 90",
+	} {
+		if _, ok, err := ParseGradeScale(line); !ok || err == nil {
+			t.Errorf("ParseGradeScale(%q) accepted a malformed scale", line)
+		}
+	}
+}
+
+func TestIsGradeExpression(t *testing.T) {
+	for _, expr := range []string{"gpa of A, B", "88% to letter grade", "3.7 gpa to percentage"} {
+		if !IsGradeExpression(expr) {
+			t.Errorf("IsGradeExpression(%q) = false", expr)
+		}
+	}
+	for _, expr := range []string{"avg(1, 2)", "88% of 200", "gpa"} {
+		if IsGradeExpression(expr) {
+			t.Errorf("IsGradeExpression(%q) = true", expr)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/utils"
+)
+
+// oddsPattern matches "odds 3 to 1 as probability", "odds 3:1 on to
+// probability" and the fractional betting odds "odds 5/2 to probability". Odds
+// are against the event unless "on" or "in favor" says otherwise.
+var oddsPattern = regexp.MustCompile(`^(?:fractional\s+)?odds\s+(?:of\s+)?(\d+(?:\.\d+)?)\s*(?:to|:|/)\s*(\d+(?:\.\d+)?)(?:\s+(against|on|in\s+favou?r))?\s+(?:to|as|in)\s+(?:implied\s+)?probability$`)
+
+// americanOddsPattern matches American betting odds: "+150 to probability",
+// "-200 odds as probability"
+var americanOddsPattern = regexp.MustCompile(`^(?:american\s+)?(?:odds\s+)?([+-]\d+(?:\.\d+)?)(?:\s+odds)?\s+(?:to|as|in)\s+(?:implied\s+)?probability$`)
+
+// decimalOddsPattern matches decimal betting odds: "decimal odds 2.5 to probability"
+var decimalOddsPattern = regexp.MustCompile(`^(?:decimal\s+odds\s+(\d+(?:\.\d+)?)|(\d+(?:\.\d+)?)\s+decimal\s+odds)\s+(?:to|as|in)\s+(?:implied\s+)?probability$`)
+
+// toOddsPattern matches "probability 0.4 as odds" and "probability 40% to odds"
+var toOddsPattern = regexp.MustCompile(`^(?:probability|p)\s+(?:of\s+)?(\S+)\s+(?:to|as|in)\s+odds$`)
+
+// combinedPattern matches the probability of two events both or either
+// happening: "p(A and B) for 0.5 and 0.3", "p(A or B) for 50% and 30%"
+var combinedPattern = regexp.MustCompile(`^p\s*\(\s*([a-z]\w*)\s+(and|or|∩|∪)\s+([a-z]\w*)\s*\)\s+(?:for|with|if|where)\s+(\S+)\s+and\s+(\S+)$`)
+
+// complementPattern matches the probability of an event not happening:
+// "p(not A) for 0.3"
+var complementPattern = regexp.MustCompile(`^p\s*\(\s*(?:not\s+|¬\s*)([a-z]\w*)\s*\)\s+(?:for|with|if|where)\s+(\S+)$`)
+
+// probabilityPatterns recognize the expressions EvalProbability handles
+var probabilityPatterns = []*regexp.Regexp{
+	oddsPattern, americanOddsPattern, decimalOddsPattern, toOddsPattern, combinedPattern, complementPattern,
+}
+
+// IsProbabilityExpression checks if an expression is an odds conversion or the
+// probability of combined events
+func IsProbabilityExpression(expr string) bool {
+	expr = normalizeProbability(expr)
+	for _, p := range probabilityPatterns {
+		if p.MatchString(expr) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeProbability lower-cases an expression, collapses its spaces and
+// writes a typographic minus as "-"
+func normalizeProbability(expr string) string {
+	expr = strings.ReplaceAll(strings.ToLower(expr), "−", "-")
+	return strings.Join(strings.Fields(expr), " ")
+}
+
+// EvalProbability converts between odds and probabilities, and combines the
+// probabilities of two events, which are assumed to be independent
+func EvalProbability(expr string) (utils.Result, error) {
+	expr = normalizeProbability(expr)
+	if m := oddsPattern.FindStringSubmatch(expr); m != nil {
+		a, _ := strconv.ParseFloat(m[1], 64)
+		b, _ := strconv.ParseFloat(m[2], 64)
+		if a+b == 0 {
+			return utils.Result{}, fmt.Errorf("odds of 0 to 0 have no probability")
+		}
+		p := b / (a + b) // against: 3 to 1 loses three times for every win
+		if m[3] != "" && m[3] != "against" {
+			p = a / (a + b)
+		}
+		return probabilityResult(p), nil
+	}
+	if m := americanOddsPattern.FindStringSubmatch(expr); m != nil {
+		odds, _ := strconv.ParseFloat(m[1], 64)
+		switch {
+		case odds >= 100:
+			return probabilityResult(100 / (odds + 100)), nil
+		case odds <= -100:
+			return probabilityResult(-odds / (-odds + 100)), nil
+		default:
+			return utils.Result{}, fmt.Errorf("American odds are +100 or more, or -100 or less")
+		}
+	}
+	if m := decimalOddsPattern.FindStringSubmatch(expr); m != nil {
+		odds, _ := strconv.ParseFloat(m[1]+m[2], 64)
+		if odds < 1 {
+			return utils.Result{}, fmt.Errorf("decimal odds are 1 or more")
+		}
+		return probabilityResult(1 / odds), nil
+	}
+	if m := toOddsPattern.FindStringSubmatch(expr); m != nil {
+		p, err := parseProbability(m[1])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		return oddsResult(p)
+	}
+	if m := combinedPattern.FindStringSubmatch(expr); m != nil {
+		pa, err := parseProbability(m[4])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		pb, err := parseProbability(m[5])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		p := pa * pb
+		if m[2] == "or" || m[2] == "∪" {
+			p = pa + pb - pa*pb
+		}
+		r := probabilityResult(p)
+		r.Text = fmt.Sprintf("%s (%s), assuming %s and %s are independent",
+			formatResult(roundTo(p, 10)), r.Text, eventName(m[1]), eventName(m[3]))
+		return r, nil
+	}
+	if m := complementPattern.FindStringSubmatch(expr); m != nil {
+		p, err := parseProbability(m[2])
+		if err != nil {
+			return utils.Result{}, err
+		}
+		r := probabilityResult(1 - p)
+		r.Text = fmt.Sprintf("%s (%s)", formatResult(roundTo(1-p, 10)), r.Text)
+		return r, nil
+	}
+	return utils.Result{}, fmt.Errorf("unable to evaluate probability expression: %s", expr)
+}
+
+// eventName writes the name of an event as typed, with a one-letter name
+// such as "a" capitalized
+func eventName(s string) string {
+	if len(s) == 1 {
+		return strings.ToUpper(s)
+	}
+	return s
+}
+
+// parseProbability reads a probability written as a decimal, a percentage or
+// a fraction: "0.4", "40%", "1/6"
+func parseProbability(s string) (float64, error) {
+	var p float64
+	var err error
+	if num, den, ok := strings.Cut(s, "/"); ok {
+		var n, d float64
+		if n, err = strconv.ParseFloat(num, 64); err == nil {
+			d, err = strconv.ParseFloat(den, 64)
+		}
+		if err == nil && d == 0 {
+			return 0, fmt.Errorf("probability %s divides by zero", s)
+		}
+		p = n / d
+	} else if pct, ok := strings.CutSuffix(s, "%"); ok {
+		p, err = strconv.ParseFloat(pct, 64)
+		p /= 100
+	} else {
+		p, err = strconv.ParseFloat(s, 64)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid probability %q", s)
+	}
+	if p < 0 || p > 1 {
+		return 0, fmt.Errorf("probability %s is outside 0 to 1", s)
+	}
+	return p, nil
+}
+
+// probabilityResult shows a probability as a percentage, "25%"; its value is
+// the probability itself, 0.25
+func probabilityResult(p float64) utils.Result {
+	return utils.ValueResult(formatResult(roundTo(p*100, 2))+"%", p, false)
+}
+
+// oddsResult shows a probability as traditional odds with the betting odds
+// that imply it: "3 to 2 against (decimal 2.5, fractional 3/2, American +150)"
+func oddsResult(p float64) (utils.Result, error) {
+	if p == 0 || p == 1 {
+		return utils.Result{}, fmt.Errorf("a probability of %s has no odds", formatResult(p))
+	}
+	against, ratio := true, (1-p)/p
+	if p > 0.5 {
+		against, ratio = false, p/(1-p)
+	}
+	num, den := ratioTerms(ratio)
+	traditional := fmt.Sprintf("%s to %s against", num, den)
+	switch {
+	case p == 0.5:
+		traditional = "evens"
+	case !against:
+		traditional = fmt.Sprintf("%s to %s on", num, den)
+	}
+	fracNum, fracDen := num, den
+	american := "+" + strconv.FormatFloat(math.Round(100*ratio), 'f', 0, 64)
+	if !against {
+		fracNum, fracDen = den, num
+		american = "-" + american[1:]
+	}
+	text := fmt.Sprintf("%s (decimal %s, fractional %s/%s, American %s)",
+		traditional, formatResult(roundTo(1/p, 2)), fracNum, fracDen, american)
+	return utils.TextResult(text), nil
+}
+
+// ratioTerms writes a ratio of at least 1 as the whole terms of odds: 1.5 is
+// 3 to 2. A ratio that no denominator up to 20 makes whole stays a decimal to 1.
+func ratioTerms(ratio float64) (string, string) {
+	for den := 1; den <= 20; den++ {
+		num := ratio * float64(den)
+		if math.Abs(num-math.Round(num)) < 1e-6 {
+			return formatResult(math.Round(num)), strconv.Itoa(den)
+		}
+	}
+	return formatResult(roundTo(ratio, 2)), "1"
+}
+
+// roundTo rounds v to places decimals
+func roundTo(v float64, places int) float64 {
+	scale := math.Pow10(places)
+	return math.Round(v*scale) / scale
+}
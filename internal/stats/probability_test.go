@@ -0,0 +1,105 @@
+package stats
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEvalProbability(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+		value    float64
+	}{
+		{"odds 3 to 1 as probability", "25%", 0.25},
+		{"odds 3:1 on to probability", "75%", 0.75},
+		{"odds 1 to 4 in favor as probability", "20%", 0.2},
+		{"odds 5/2 to probability", "28.57%", 2.0 / 7},
+		{"+150 to probability", "40%", 0.4},
+		{"-200 to probability", "66.67%", 2.0 / 3},
+		{"American odds +100 as probability", "50%", 0.5},
+		{"decimal odds 2.5 to probability", "40%", 0.4},
+		{"4 decimal odds to implied probability", "25%", 0.25},
+		{"p(A and B) for 0.5 and 0.3", "0.15 (15%), assuming A and B are independent", 0.15},
+		{"p(A or B) for 0.5 and 0.3", "0.65 (65%), assuming A and B are independent", 0.65},
+		{"P(rain and wind) for 40% and 1/4", "0.1 (10%), assuming rain and wind are independent", 0.1},
+		{"p(not A) for 30%", "0.7 (70%)", 0.7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if !IsProbabilityExpression(tt.expr) {
+				t.Fatalf("IsProbabilityExpression(%q) = false, want true", tt.expr)
+			}
+			r, err := EvalProbability(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalProbability(%q) error: %v", tt.expr, err)
+			}
+			if r.Text != tt.expected {
+				t.Errorf("EvalProbability(%q) = %q, want %q", tt.expr, r.Text, tt.expected)
+			}
+			if !r.HasValue || math.Abs(r.Value-tt.value) > 1e-9 {
+				t.Errorf("EvalProbability(%q) value = %v, want %v", tt.expr, r.Value, tt.value)
+			}
+		})
+	}
+}
+
+func TestEvalProbabilityAsOdds(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"probability 0.4 as odds", "3 to 2 against (decimal 2.5, fractional 3/2, American +150)"},
+		{"probability 75% to odds", "3 to 1 on (decimal 1.33, fractional 1/3, American -300)"},
+		{"probability 1/6 as odds", "5 to 1 against (decimal 6, fractional 5/1, American +500)"},
+		{"p 0.5 as odds", "evens (decimal 2, fractional 1/1, American +100)"},
+		{"probability 0.3 as odds", "7 to 3 against (decimal 3.33, fractional 7/3, American +233)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			r, err := EvalProbability(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalProbability(%q) error: %v", tt.expr, err)
+			}
+			if r.Text != tt.expected {
+				t.Errorf("EvalProbability(%q) = %q, want %q", tt.expr, r.Text, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalProbabilityErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"p(A and B) for 1.5 and 0.3", "probability 1.5 is outside 0 to 1"},
+		{"p(A or B) for 0.5 and -0.1", "probability -0.1 is outside 0 to 1"},
+		{"probability 120% as odds", "probability 120% is outside 0 to 1"},
+		{"probability 1 as odds", "a probability of 1 has no odds"},
+		{"p(not A) for 1/0", "divides by zero"},
+		{"+50 to probability", "American odds are +100 or more, or -100 or less"},
+		{"decimal odds 0.5 to probability", "decimal odds are 1 or more"},
+		{"odds 0 to 0 as probability", "odds of 0 to 0 have no probability"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := EvalProbability(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("EvalProbability(%q) error = %v, want %q", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsProbabilityExpressionRejects(t *testing.T) {
+	for _, expr := range []string{"p * 2", "probability", "odds = 3", "150 to probability", "avg(1, 2)"} {
+		if IsProbabilityExpression(expr) {
+			t.Errorf("IsProbabilityExpression(%q) = true, want false", expr)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package stats
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// Statistics and grades read the document, so the calculator runs them
+	// itself; odds and probabilities stand alone. A probability outside 0 to
+	// 1 is reported instead of left to arithmetic.
+	registry.Register(registry.Evaluator{
+		Name:     "probability",
+		Priority: registry.PriorityProbability,
+		Traits:   registry.ReportsErrors,
+		Detect:   IsProbabilityExpression,
+		Eval:     EvalProbability,
+	})
+
+	// Tolerances are shown as typed, since spacing would break "+/-", and
+	// a zero expected value is reported
+	registry.Register(registry.Evaluator{
+		Name:     "tolerance",
+		Priority: registry.PriorityTolerance,
+		Traits:   registry.NoFormat | registry.ReportsErrors,
+		Detect:   IsToleranceExpression,
+		Eval:     EvalTolerance,
+	})
+}
@@ -0,0 +1,190 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/utils"
+)
+
+// Parts of the tolerance patterns: a measurement such as "4.7k ohm", "$100"
+// or "9.81", and a tolerance such as "5%" or "0.2 mm" after a ± sign. A
+// k, M or G written straight after the number scales it; the unit after it is
+// echoed as typed.
+const (
+	measurementPart = `(\$)?(\d{1,3}(?:,\d{3})+(?:\.\d+)?|\d*\.?\d+)([kKMG](?:\b|$))?(?:\s*([a-zA-Zµμ°Ω][\wµμ°Ω/²³]*))?`
+	tolerancePart   = `±\s*(\$)?(\d{1,3}(?:,\d{3})+(?:\.\d+)?|\d*\.?\d+)([kKMG](?:\b|$))?\s*(%|ppm|[a-zA-Zµμ°Ω][\wµμ°Ω/²³]*)?`
+)
+
+// bandPattern matches "4.7k ohm ±5%", "100 mm ± 0.5 mm" and "tolerance of
+// $100 +/- 10%"
+var bandPattern = regexp.MustCompile(`(?i)^(?:tolerance\s+(?:of\s+)?)?` + measurementPart + `\s*` + tolerancePart + `$`)
+
+// withinPattern matches "is 102.3 within 100 ± 3%"
+var withinPattern = regexp.MustCompile(`(?i)^is\s+` + measurementPart + `\s+within\s+` + measurementPart + `\s*` + tolerancePart + `$`)
+
+// errorPattern matches "measured 9.73 expected 9.81" and "error of 9.73 vs
+// 9.81"
+var errorPattern = regexp.MustCompile(`(?i)^(?:measured\s+` + measurementPart + `\s*,?\s+(?:vs\.?\s+|versus\s+)?(?:expected|accepted|actual|true|theoretical|nominal)(?:\s+value)?|(?:percent\s+)?error\s+(?:of\s+)?` + measurementPart + `\s+(?:vs\.?|versus|from|against))\s+` + measurementPart + `$`)
+
+// siScale are the multipliers of the prefixes a measurement may carry
+var siScale = map[string]float64{"": 1, "k": 1e3, "K": 1e3, "M": 1e6, "G": 1e9}
+
+// measurement is a number as typed: its value, with any prefix applied, and
+// how to write numbers like it
+type measurement struct {
+	value    float64
+	currency bool
+	prefix   string
+	unit     string
+}
+
+// parseMeasurement reads the parts of measurementPart
+func parseMeasurement(m []string) measurement {
+	v, _ := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+	prefix := strings.TrimSpace(m[2])
+	return measurement{value: v * siScale[prefix], currency: m[0] != "", prefix: prefix, unit: m[3]}
+}
+
+// number writes a value like the measurement without its unit: "4.465k",
+// "$90.00"
+func (ms measurement) number(v float64) string {
+	if ms.currency {
+		return utils.FormatResult(true, v)
+	}
+	return utils.FormatResult(false, v/siScale[ms.prefix]) + ms.prefix
+}
+
+// format writes a value like the measurement: "4.465k ohm", "$90.00"
+func (ms measurement) format(v float64) string {
+	return ms.number(v) + ms.unitSuffix()
+}
+
+// unitSuffix is the measurement's unit after a space, if it has one
+func (ms measurement) unitSuffix() string {
+	if ms.unit == "" {
+		return ""
+	}
+	return " " + ms.unit
+}
+
+// tolerance is the allowed deviation from a nominal value
+type tolerance struct {
+	amount  float64 // absolute, in the nominal's units
+	percent float64 // the tolerance in percent, if it was given as one
+	text    string  // as typed: "5%", "0.2 mm"
+}
+
+// parseTolerance reads the parts of tolerancePart for a nominal value
+func parseTolerance(m []string, nominal float64) tolerance {
+	v, _ := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+	prefix := strings.TrimSpace(m[2])
+	switch m[3] {
+	case "%":
+		return tolerance{amount: math.Abs(nominal) * v / 100, percent: v, text: m[1] + "%"}
+	case "ppm":
+		return tolerance{amount: math.Abs(nominal) * v / 1e6, percent: v / 1e4, text: m[1] + " ppm"}
+	}
+	if m[0] != "" {
+		return tolerance{amount: v, text: "$" + m[1]}
+	}
+	text := m[1] + prefix
+	if m[3] != "" {
+		text += " " + m[3]
+	}
+	return tolerance{amount: v * siScale[prefix], text: text}
+}
+
+// IsToleranceExpression checks if an expression is a tolerance band ("4.7k
+// ohm ±5%"), a check against one ("is 102.3 within 100 ± 3%") or the error
+// of a measurement ("measured 9.73 expected 9.81"). ± may be written "+-" or
+// "+/-".
+func IsToleranceExpression(expr string) bool {
+	expr = normalizeTolerance(expr)
+	return bandPattern.MatchString(expr) || withinPattern.MatchString(expr) || errorPattern.MatchString(expr)
+}
+
+// normalizeTolerance collapses the spaces of an expression and writes the
+// spellings of ± as the sign
+func normalizeTolerance(expr string) string {
+	expr = utils.NormalizePlusMinus(expr)
+	return strings.Join(strings.Fields(expr), " ")
+}
+
+// EvalTolerance evaluates a tolerance band as its lowest and highest values,
+// checks a value against a band, or gives the absolute and percent error of
+// a measurement against the expected value
+func EvalTolerance(expr string) (utils.Result, error) {
+	expr = normalizeTolerance(expr)
+	if m := withinPattern.FindStringSubmatch(expr); m != nil {
+		return evalWithin(m)
+	}
+	if m := bandPattern.FindStringSubmatch(expr); m != nil {
+		return evalBand(m)
+	}
+	if m := errorPattern.FindStringSubmatch(expr); m != nil {
+		return evalError(m)
+	}
+	return utils.Result{}, fmt.Errorf("invalid tolerance expression")
+}
+
+// evalBand writes the band of a nominal value and tolerance: "4.465k to
+// 4.935k ohm (±0.235k ohm)"
+func evalBand(m []string) (utils.Result, error) {
+	nominal := parseMeasurement(m[1:5])
+	tol := parseTolerance(m[5:9], nominal.value)
+	text := fmt.Sprintf("%s to %s%s (±%s)", nominal.number(nominal.value-tol.amount), nominal.number(nominal.value+tol.amount),
+		nominal.unitSuffix(), nominal.format(tol.amount))
+	return utils.TextResult(text), nil
+}
+
+// evalWithin checks if a value lies in a band, showing the band and, for a
+// value outside it, how far out it is
+func evalWithin(m []string) (utils.Result, error) {
+	value := parseMeasurement(m[1:5])
+	nominal := parseMeasurement(m[5:9])
+	tol := parseTolerance(m[9:13], nominal.value)
+	lo, hi := nominal.value-tol.amount, nominal.value+tol.amount
+	band := fmt.Sprintf("%s ± %s is %s to %s%s", nominal.format(nominal.value), tol.text, nominal.number(lo), nominal.number(hi), nominal.unitSuffix())
+	if tol.percent == 0 && nominal.value != 0 {
+		band += fmt.Sprintf(", ±%s%%", formatPercent(tol.amount/math.Abs(nominal.value)*100))
+	}
+	switch {
+	case value.value < lo:
+		return utils.TextResult(fmt.Sprintf("no, %s below (%s)", nominal.format(lo-value.value), band)), nil
+	case value.value > hi:
+		return utils.TextResult(fmt.Sprintf("no, %s above (%s)", nominal.format(value.value-hi), band)), nil
+	}
+	return utils.TextResult(fmt.Sprintf("yes (%s)", band)), nil
+}
+
+// evalError gives the error of a measurement, measured - expected, and the
+// percent error, |measured - expected| / |expected| × 100. The percent error
+// is the line's value.
+func evalError(m []string) (utils.Result, error) {
+	parts := m[1:5]
+	if m[1] == "" && m[2] == "" {
+		parts = m[5:9]
+	}
+	measured := parseMeasurement(parts)
+	expected := parseMeasurement(m[9:13])
+	if expected.value == 0 {
+		return utils.Result{}, fmt.Errorf("the expected value is zero, so there is no percent error")
+	}
+	diff := measured.value - expected.value
+	pct := math.Abs(diff) / math.Abs(expected.value) * 100
+	sign := "+"
+	if diff < 0 {
+		sign = "-"
+	}
+	text := fmt.Sprintf("absolute error %s%s, percent error %s%%", sign, expected.format(math.Abs(diff)), formatPercent(pct))
+	return utils.ValueResult(text, pct, false), nil
+}
+
+// formatPercent writes a percentage to two decimals: 0.8155 is "0.82"
+func formatPercent(pct float64) string {
+	return utils.FormatResult(false, math.Round(pct*100)/100)
+}
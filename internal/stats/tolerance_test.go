@@ -0,0 +1,85 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIsToleranceExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"measured 9.73 expected 9.81", true},
+		{"measured 9.73, expected value 9.81", true},
+		{"error of 9.73 vs 9.81", true},
+		{"4.7k ohm ±5%", true},
+		{"100 +- 5", true},
+		{"$100 +/- 10%", true},
+		{"tolerance of 100 mm ± 0.5 mm", true},
+		{"is 102.3 within 100 ± 3%", true},
+
+		// Arithmetic and resistor networks are left alone
+		{"100 + 5", false},
+		{"100 +- 5 + 3", false},
+		{"resistors 4.7k and 10k in parallel", false},
+		{"is 10.0.0.1 in 10.0.0.0/8", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsToleranceExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsToleranceExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalTolerance(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"measured 9.73 expected 9.81", "absolute error -0.08, percent error 0.82%"},
+		{"measured $105 vs expected $100", "absolute error +$5.00, percent error 5%"},
+		{"error of 0.52 vs 0.5", "absolute error +0.02, percent error 4%"},
+
+		{"4.7k ohm ±5%", "4.465k to 4.935k ohm (±0.235k ohm)"},
+		{"4.7k ± 200", "4.5k to 4.9k (±0.2k)"},
+		{"100 +- 5", "95 to 105 (±5)"},
+		{"$100 +/- 10%", "$90.00 to $110.00 (±$10.00)"},
+		{"1,000 ± 2%", "980 to 1,020 (±20)"},
+		{"10 MHz ± 50 ppm", "9.9995 to 10.0005 MHz (±0.0005 MHz)"},
+		{"100 mm ± 0.5 mm", "99.5 to 100.5 mm (±0.5 mm)"},
+
+		{"is 102.3 within 100 ± 3%", "yes (100 ± 3% is 97 to 103)"},
+		{"is 104.3 within 100 ± 3", "no, 1.3 above (100 ± 3 is 97 to 103, ±3%)"},
+		{"is 4.4k within 4.7k ohm ±5%", "no, 0.065k ohm below (4.7k ohm ± 5% is 4.465k to 4.935k ohm)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalTolerance(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalTolerance(%q) error: %v", tt.expr, err)
+			}
+			if result.Text != tt.expected {
+				t.Errorf("EvalTolerance(%q) = %q, want %q", tt.expr, result.Text, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalToleranceErrorValue(t *testing.T) {
+	result, err := EvalTolerance("measured 9.73 expected 9.81")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 0.08 / 9.81 * 100; !result.HasValue || math.Abs(result.Value-want) > 1e-9 {
+		t.Errorf("value = %v, want %v", result.Value, want)
+	}
+
+	if _, err := EvalTolerance("measured 5 expected 0"); err == nil {
+		t.Error("a zero expected value should fail")
+	}
+}
@@ -0,0 +1,76 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"smartcalc/internal/utils"
+)
+
+// trendPattern matches "trend \1..\10", the summary of a range of lines
+var trendPattern = regexp.MustCompile(`(?i)^trend\s+(?:of\s+)?\\\d+\.\.\\\d+$`)
+
+// sparkBars are the bars of a sparkline, lowest first
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// IsTrendExpression checks if an expression summarizes a range of lines
+func IsTrendExpression(expr string) bool {
+	return trendPattern.MatchString(strings.TrimSpace(expr))
+}
+
+// Trend summarizes a series of values on one line: a sparkline, the min, max
+// and mean, and the change from the first value to the last. Its value is
+// the change.
+func Trend(values []float64, isCurrency bool) (utils.Result, error) {
+	if len(values) < 2 {
+		return utils.Result{}, fmt.Errorf("a trend needs at least 2 numbers, found %d", len(values))
+	}
+	lo, hi, sum := values[0], values[0], 0.0
+	for _, v := range values {
+		lo, hi, sum = math.Min(lo, v), math.Max(hi, v), sum+v
+	}
+	first, last := values[0], values[len(values)-1]
+	change := last - first
+
+	format := func(v float64) string {
+		return utils.FormatResult(isCurrency, roundTo(v, 10))
+	}
+	changeText := format(change)
+	if change > 0 {
+		changeText = "+" + changeText
+	}
+	if first != 0 {
+		pct := roundTo(change/math.Abs(first)*100, 1)
+		sign := ""
+		if pct > 0 {
+			sign = "+"
+		}
+		changeText += fmt.Sprintf(" (%s%s%%)", sign, formatResult(pct))
+	}
+	text := fmt.Sprintf("%s min %s, max %s, mean %s, change %s",
+		Sparkline(values), format(lo), format(hi), format(sum/float64(len(values))), changeText)
+	return utils.ValueResult(text, change, isCurrency), nil
+}
+
+// Sparkline draws values as a row of bars scaled from the lowest to the
+// highest: "▁▂▄▇". Equal values are drawn at mid height.
+func Sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	var sb strings.Builder
+	for _, v := range values {
+		i := len(sparkBars) / 2
+		if hi > lo {
+			i = int(math.Round((v - lo) / (hi - lo) * float64(len(sparkBars)-1)))
+		}
+		sb.WriteRune(sparkBars[i])
+	}
+	return sb.String()
+}
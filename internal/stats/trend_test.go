@@ -0,0 +1,59 @@
+package stats
+
+import (
+	"testing"
+)
+
+func TestIsTrendExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{`trend \1..\10`, true},
+		{`Trend of \10..\1`, true},
+		{`trend \1`, false},
+		{`trend 1..10`, false},
+		{`\1..\10`, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTrendExpression(tt.expr); got != tt.expected {
+			t.Errorf("IsTrendExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+		}
+	}
+}
+
+func TestTrend(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     []float64
+		isCurrency bool
+		expected   string
+		value      float64
+	}{
+		{"rising", []float64{10, 12, 15, 11, 20}, false, "▁▂▅▂█ min 10, max 20, mean 13.6, change +10 (+100%)", 10},
+		{"falling", []float64{8, 6, 4, 2}, false, "█▆▃▁ min 2, max 8, mean 5, change -6 (-75%)", -6},
+		{"flat", []float64{3, 3, 3}, false, "▅▅▅ min 3, max 3, mean 3, change 0 (0%)", 0},
+		{"from zero", []float64{0, 5}, false, "▁█ min 0, max 5, mean 2.5, change +5", 5},
+		{"currency", []float64{5, 8}, true, "▁█ min $5.00, max $8.00, mean $6.50, change +$3.00 (+60%)", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Trend(tt.values, tt.isCurrency)
+			if err != nil {
+				t.Fatalf("Trend(%v) error: %v", tt.values, err)
+			}
+			if r.Text != tt.expected {
+				t.Errorf("Trend(%v) = %q, want %q", tt.values, r.Text, tt.expected)
+			}
+			if r.Value != tt.value || r.IsCurrency != tt.isCurrency {
+				t.Errorf("Trend(%v) value = %v (currency %v), want %v", tt.values, r.Value, r.IsCurrency, tt.value)
+			}
+		})
+	}
+
+	if _, err := Trend([]float64{1}, false); err == nil {
+		t.Error("Trend of one value should fail")
+	}
+}
@@ -0,0 +1,224 @@
+package travel
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/units"
+	"smartcalc/internal/utils"
+)
+
+// Meters in a kilometer and in a mile
+const (
+	kmMeters   = 1000.0
+	mileMeters = 1609.344
+)
+
+// Parts of the travel expressions. A distance is a number with a length unit;
+// a duration is spelled out in hours, minutes and seconds ("1.5 hours",
+// "2 h 30 min"), so the "52:30" race times of paces are left to fitness; a
+// speed is in km/h, mph or m/s and a pace is a time per kilometer or mile,
+// "5:30/km", "5:30 min/km" or "8 min/mile".
+const (
+	distancePart = `(\d+(?:\.\d+)?)\s*(kilometers?|kilometres?|km|meters?|metres?|m|miles?|mi|yards?|yd|feet|foot|ft)`
+	durationPart = `((?:\d+(?:\.\d+)?\s*(?:hours?|hrs?|h|minutes?|mins?|seconds?|secs?|s)\b\s*(?:and\s+)?)+)`
+	speedPart    = `(\d+(?:\.\d+)?)\s*(km/h|kmh|kph|mph|m/s)`
+	pacePart     = `(\d+:\d{2}(?:\.\d+)?(?:\s*min)?|\d+(?:\.\d+)?\s*min)\s*(?:/\s*|per\s+)(kilometers?|kilometres?|km|miles?|mi)\b`
+)
+
+// speedPattern matches the speed of a trip: "120 km in 1.5 hours"
+var speedPattern = regexp.MustCompile(`^(?:(?:average\s+)?speed\s+(?:of|for)\s+)?` + distancePart + `\s+in\s+` + durationPart + `$`)
+
+// distancePattern matches the distance covered at a speed or pace: "60 mph
+// for 2.5 hours", "at 5:30/km for 45 min"
+var distancePattern = regexp.MustCompile(`^(?:how\s+far\s+)?(?:at\s+)?(?:` + speedPart + `|` + pacePart + `)\s+for\s+` + durationPart + `$`)
+
+// timePattern matches the time a distance takes at a speed: "300 km at 100
+// km/h". A distance at a pace is a finish time, which fitness shows as a race
+// clock.
+var timePattern = regexp.MustCompile(`^(?:how\s+long\s+(?:is\s+|for\s+)?)?` + distancePart + `\s+at\s+` + speedPart + `$`)
+
+// durationUnitPattern matches one part of a duration such as "2 h 30 min"
+var durationUnitPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*([a-z]+)`)
+
+// IsTravelExpression checks if an expression solves for the speed, distance
+// or time of a trip from the other two
+func IsTravelExpression(expr string) bool {
+	expr = utils.NormalizeExpr(expr)
+	return speedPattern.MatchString(expr) || distancePattern.MatchString(expr) || timePattern.MatchString(expr)
+}
+
+// EvalTravel computes the third of speed, distance and time from the other
+// two. Speeds and distances are shown in the units of the expression with
+// their metric or imperial equivalent; the value of the line is the first
+// number shown, or the time in hours.
+func EvalTravel(expr string) (utils.Result, error) {
+	expr = utils.NormalizeExpr(expr)
+	if m := speedPattern.FindStringSubmatch(expr); m != nil {
+		return evalSpeed(m[1], m[2], m[3])
+	}
+	if m := distancePattern.FindStringSubmatch(expr); m != nil {
+		return evalDistance(m[1], m[2], m[3], m[4], m[5])
+	}
+	if m := timePattern.FindStringSubmatch(expr); m != nil {
+		return evalTime(m[1], m[2], m[3], m[4])
+	}
+	return utils.Result{}, fmt.Errorf("invalid travel expression")
+}
+
+// evalSpeed computes the average speed of a distance covered in a time:
+// "80 km/h (49.71 mph)"
+func evalSpeed(value, unit, duration string) (utils.Result, error) {
+	meters, imperial, err := units.ParseDistance(value, unit)
+	if err != nil {
+		return utils.Result{}, err
+	}
+	secs := parseDuration(duration)
+	if secs <= 0 {
+		return utils.Result{}, fmt.Errorf("time must be more than zero")
+	}
+	return speedResult(meters/secs, imperial), nil
+}
+
+// evalDistance computes the distance covered at a speed or pace in a time:
+// "150 miles (241.4 km)"
+func evalDistance(speed, speedUnit, pace, paceUnit, duration string) (utils.Result, error) {
+	metersPerSec, imperial, err := parseRate(speed, speedUnit, pace, paceUnit)
+	if err != nil {
+		return utils.Result{}, err
+	}
+	return distanceResult(metersPerSec*parseDuration(duration), imperial), nil
+}
+
+// evalTime computes the time a distance takes at a speed: "3 hours"
+func evalTime(value, unit, speed, speedUnit string) (utils.Result, error) {
+	meters, _, err := units.ParseDistance(value, unit)
+	if err != nil {
+		return utils.Result{}, err
+	}
+	metersPerSec, _, err := parseRate(speed, speedUnit, "", "")
+	if err != nil {
+		return utils.Result{}, err
+	}
+	secs := meters / metersPerSec
+	return utils.ValueResult(formatDuration(secs), secs/3600, false), nil
+}
+
+// parseRate converts a speed in km/h, mph or m/s, or a pace per kilometer or
+// mile, to meters per second, reporting whether it is imperial
+func parseRate(speed, speedUnit, pace, paceUnit string) (metersPerSec float64, imperial bool, err error) {
+	if pace != "" {
+		secs, err := parsePace(pace)
+		if err != nil {
+			return 0, false, err
+		}
+		if strings.HasPrefix(paceUnit, "mi") {
+			return mileMeters / secs, true, nil
+		}
+		return kmMeters / secs, false, nil
+	}
+	v, _ := strconv.ParseFloat(speed, 64)
+	if v <= 0 {
+		return 0, false, fmt.Errorf("speed must be more than zero")
+	}
+	switch speedUnit {
+	case "mph":
+		return v * mileMeters / 3600, true, nil
+	case "m/s":
+		return v, false, nil
+	}
+	return v * kmMeters / 3600, false, nil
+}
+
+// parsePace converts a pace of "5:30", "5:30 min" or "8 min" to seconds
+func parsePace(pace string) (float64, error) {
+	pace = strings.TrimSpace(strings.TrimSuffix(pace, "min"))
+	total := 0.0
+	if mins, secs, ok := strings.Cut(pace, ":"); ok {
+		m, _ := strconv.ParseFloat(mins, 64)
+		s, _ := strconv.ParseFloat(secs, 64)
+		if s >= 60 {
+			return 0, fmt.Errorf("invalid pace %s", pace)
+		}
+		total = m*60 + s
+	} else {
+		m, _ := strconv.ParseFloat(pace, 64)
+		total = m * 60
+	}
+	if total <= 0 {
+		return 0, fmt.Errorf("pace must be more than zero")
+	}
+	return total, nil
+}
+
+// parseDuration converts "1.5 hours" or "2 h 30 min" to seconds
+func parseDuration(s string) float64 {
+	total := 0.0
+	for _, m := range durationUnitPattern.FindAllStringSubmatch(s, -1) {
+		value, _ := strconv.ParseFloat(m[1], 64)
+		switch {
+		case strings.HasPrefix(m[2], "h"):
+			total += value * 3600
+		case strings.HasPrefix(m[2], "m"):
+			total += value * 60
+		default:
+			total += value
+		}
+	}
+	return total
+}
+
+// speedResult shows a speed in km/h and mph, the unit of the expression first
+func speedResult(metersPerSec float64, imperial bool) utils.Result {
+	kmh := metersPerSec * 3600 / kmMeters
+	mph := metersPerSec * 3600 / mileMeters
+	if imperial {
+		return utils.ValueResult(fmt.Sprintf("%s mph (%s km/h)", formatNumber(mph), formatNumber(kmh)), mph, false)
+	}
+	return utils.ValueResult(fmt.Sprintf("%s km/h (%s mph)", formatNumber(kmh), formatNumber(mph)), kmh, false)
+}
+
+// distanceResult shows a distance in kilometers and miles, the unit of the
+// expression first
+func distanceResult(meters float64, imperial bool) utils.Result {
+	km := meters / kmMeters
+	miles := meters / mileMeters
+	milesText := formatNumber(miles) + " " + utils.Plural(miles, "mile", "miles")
+	kmText := formatNumber(km) + " km"
+	if imperial {
+		return utils.ValueResult(fmt.Sprintf("%s (%s)", milesText, kmText), miles, false)
+	}
+	return utils.ValueResult(fmt.Sprintf("%s (%s)", kmText, milesText), km, false)
+}
+
+// formatNumber rounds a speed or distance to two decimals
+func formatNumber(v float64) string {
+	return utils.FormatResult(false, math.Round(v*100)/100)
+}
+
+// formatDuration writes a travel time in words, to the minute from an hour
+// up and to the second below: "3 hours", "2 hours 30 minutes", "4 minutes 10
+// seconds"
+func formatDuration(secs float64) string {
+	total := int64(math.Round(secs))
+	if total >= 3600 {
+		total = int64(math.Round(secs/60)) * 60
+	}
+	h, m, s := total/3600, total%3600/60, total%60
+	var parts []string
+	for _, p := range []struct {
+		n  int64
+		id utils.MessageID
+	}{{h, utils.MsgHour}, {m, utils.MsgMinute}, {s, utils.MsgSecond}} {
+		if p.n > 0 {
+			parts = append(parts, utils.FormatCount(strconv.FormatInt(p.n, 10), p.id))
+		}
+	}
+	if len(parts) == 0 {
+		return utils.FormatCount("0", utils.MsgSecond)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,98 @@
+package travel
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIsTravelExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"120 km in 1.5 hours", true},
+		{"average speed of 26.2 miles in 3 h 30 min", true},
+		{"100 m in 9.58 s", true},
+		{"60 mph for 2.5 hours", true},
+		{"how far at 5:30/km for 2 hours", true},
+		{"9:30 min/mile for 45 min", true},
+		{"300 km at 100 km/h", true},
+		{"how long is 5 km at 20 km/h", true},
+
+		// Race times and finish times at a pace are paces
+		{"10 km in 52:30", false},
+		{"pace for 10 km in 1 h 45 min", false},
+		{"marathon at 5:30/km", false},
+		{"10 km at 5:00/km", false},
+		{"time for 100 km at 28 km/h", false},
+		// Unit and duration conversions
+		{"5 km in miles", false},
+		{"2 hours in minutes", false},
+		{"60 mph to kph", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsTravelExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsTravelExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalTravel(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+		value    float64
+	}{
+		{"120 km in 1.5 hours", "80 km/h (49.71 mph)", 80},
+		{"26.2 miles in 3 h 30 min", "7.49 mph (12.05 km/h)", 7.49},
+		{"100 m in 9.58 s", "37.58 km/h (23.35 mph)", 37.58},
+		{"60 mph for 2.5 hours", "150 miles (241.4 km)", 150},
+		{"10 m/s for 30 minutes", "18 km (11.18 miles)", 18},
+		{"5:30/km for 2 hours", "21.82 km (13.56 miles)", 21.82},
+		{"at 9:30 min/mile for 45 min", "4.74 miles (7.62 km)", 4.74},
+		{"6 min/km for 1 hour 30 minutes", "15 km (9.32 miles)", 15},
+		{"300 km at 100 km/h", "3 hours", 3},
+		{"100 km at 28 km/h", "3 hours 34 minutes", 3.57},
+		{"5 km at 20 km/h", "15 minutes", 0.25},
+		{"1 mile at 60 mph", "1 minute", 1.0 / 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalTravel(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalTravel(%q) error: %v", tt.expr, err)
+			}
+			if result.Text != tt.expected {
+				t.Errorf("EvalTravel(%q) = %q, want %q", tt.expr, result.Text, tt.expected)
+			}
+			if math.Abs(result.Value-tt.value) > 0.01 {
+				t.Errorf("EvalTravel(%q) value = %v, want %v", tt.expr, result.Value, tt.value)
+			}
+		})
+	}
+}
+
+func TestEvalTravelErrors(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"120 km in 0 hours", "time must be more than zero"},
+		{"300 km at 0 km/h", "speed must be more than zero"},
+		{"5:75/km for 1 hour", "invalid pace 5:75"},
+		{"0:00/km for 1 hour", "pace must be more than zero"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := EvalTravel(tt.expr)
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("EvalTravel(%q) error = %v, want %q", tt.expr, err, tt.expected)
+			}
+		})
+	}
+}
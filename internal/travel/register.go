@@ -0,0 +1,15 @@
+package travel
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// A trip that takes no time or goes nowhere is reported instead of left
+	// to unit conversions, which would read "120 km in 0 hours" as one
+	registry.Register(registry.Evaluator{
+		Name:     "travel",
+		Priority: registry.PriorityTravel,
+		Traits:   registry.ReportsErrors,
+		Detect:   IsTravelExpression,
+		Eval:     EvalTravel,
+	})
+}
@@ -0,0 +1,237 @@
+package uncertainty
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"smartcalc/internal/utils"
+)
+
+// numberPattern matches a number at the start of the rest of an expression
+var numberPattern = regexp.MustCompile(`^(?:\d{1,3}(?:,\d{3})+(?:\.\d+)?|\d*\.?\d+)(?:[eE][-+]?\d+)?`)
+
+// IsUncertaintyExpression checks if an expression does arithmetic with at
+// least one "12.3 ± 0.2" value: "(12.3 ± 0.2) * (4.56 ± 0.05)". A value on
+// its own, "100 +- 2%", is a tolerance band rather than a calculation. A
+// calculation that divides by zero is still claimed, to report it.
+func IsUncertaintyExpression(expr string) bool {
+	expr = normalize(expr)
+	if !strings.Contains(expr, "±") {
+		return false
+	}
+	p := &parser{src: expr}
+	_, err := p.parse()
+	var ae arithmeticError
+	return err == nil && p.ops > 0 || errors.As(err, &ae)
+}
+
+// EvalUncertainty evaluates arithmetic on values with uncertainties,
+// propagating them in quadrature: "(12.3 ± 0.2) * (4.56 ± 0.05)" is
+// "56.1 ± 1.1". Plain numbers are exact. The value of the line is the mean.
+func EvalUncertainty(expr string) (utils.Result, error) {
+	p := &parser{src: normalize(expr)}
+	v, err := p.parse()
+	if err != nil {
+		return utils.Result{}, err
+	}
+	return utils.ValueResult(v.Format(), v.Mean, false), nil
+}
+
+// normalize writes ± for its ASCII spellings and trims the expression
+func normalize(expr string) string {
+	return strings.TrimSpace(utils.NormalizePlusMinus(expr))
+}
+
+// arithmeticError is an operation that has no result, such as a division by
+// zero, in an expression that reads fine
+type arithmeticError struct{ err error }
+
+func (e arithmeticError) Error() string { return e.err.Error() }
+
+// parser is a recursive descent parser over an expression. ops counts the
+// operations it has read, so a lone value can be told from a calculation.
+type parser struct {
+	src string
+	pos int
+	ops int
+}
+
+// parse reads the whole expression
+func (p *parser) parse() (Value, error) {
+	v, err := p.sum()
+	if err != nil {
+		return Value{}, err
+	}
+	if p.skipSpace(); p.pos < len(p.src) {
+		return Value{}, fmt.Errorf("unexpected %q", p.src[p.pos:])
+	}
+	return v, nil
+}
+
+// sum reads terms joined by + and -
+func (p *parser) sum() (Value, error) {
+	v, err := p.product()
+	if err != nil {
+		return Value{}, err
+	}
+	for {
+		switch {
+		case p.accept("+"):
+			w, err := p.product()
+			if err != nil {
+				return Value{}, err
+			}
+			v = v.Add(w)
+		case p.accept("-"):
+			w, err := p.product()
+			if err != nil {
+				return Value{}, err
+			}
+			v = v.Sub(w)
+		default:
+			return v, nil
+		}
+		p.ops++
+	}
+}
+
+// product reads factors joined by *, × , / and ÷
+func (p *parser) product() (Value, error) {
+	v, err := p.unary()
+	if err != nil {
+		return Value{}, err
+	}
+	for {
+		switch {
+		case p.accept("*"), p.accept("×"):
+			w, err := p.unary()
+			if err != nil {
+				return Value{}, err
+			}
+			v = v.Mul(w)
+		case p.accept("/"), p.accept("÷"):
+			w, err := p.unary()
+			if err != nil {
+				return Value{}, err
+			}
+			if v, err = v.Div(w); err != nil {
+				return Value{}, arithmeticError{err}
+			}
+		default:
+			return v, nil
+		}
+		p.ops++
+	}
+}
+
+// unary reads a negated factor or a power
+func (p *parser) unary() (Value, error) {
+	if p.accept("-") {
+		v, err := p.unary()
+		return v.Neg(), err
+	}
+	return p.power()
+}
+
+// power reads a value raised to a power; ^ groups from the right
+func (p *parser) power() (Value, error) {
+	v, err := p.primary()
+	if err != nil {
+		return Value{}, err
+	}
+	if !p.accept("^") && !p.accept("**") {
+		return v, nil
+	}
+	exp, err := p.unary()
+	if err != nil {
+		return Value{}, err
+	}
+	p.ops++
+	if v, err = v.Pow(exp); err != nil {
+		return Value{}, arithmeticError{err}
+	}
+	return v, nil
+}
+
+// primary reads a number with an optional "± error", a square root or a
+// parenthesized expression
+func (p *parser) primary() (Value, error) {
+	switch {
+	case p.accept("("):
+		return p.group()
+	case p.accept("sqrt("):
+		v, err := p.group()
+		if err != nil {
+			return Value{}, err
+		}
+		p.ops++
+		if v, err = v.Pow(Exact(0.5)); err != nil {
+			return Value{}, arithmeticError{err}
+		}
+		return v, nil
+	}
+	mean, err := p.number()
+	if err != nil {
+		return Value{}, err
+	}
+	if !p.accept("±") {
+		return Exact(mean), nil
+	}
+	sigma, err := p.number()
+	if err != nil {
+		return Value{}, err
+	}
+	if p.accept("%") {
+		sigma = sigma / 100 * mean
+	}
+	if sigma < 0 {
+		sigma = -sigma
+	}
+	return Value{mean, sigma}, nil
+}
+
+// group reads the rest of a parenthesized expression
+func (p *parser) group() (Value, error) {
+	v, err := p.sum()
+	if err != nil {
+		return Value{}, err
+	}
+	if !p.accept(")") {
+		return Value{}, fmt.Errorf("missing )")
+	}
+	return v, nil
+}
+
+// number reads an unsigned number
+func (p *parser) number() (float64, error) {
+	p.skipSpace()
+	m := numberPattern.FindString(p.src[p.pos:])
+	if m == "" {
+		if p.pos == len(p.src) {
+			return 0, fmt.Errorf("missing number")
+		}
+		return 0, fmt.Errorf("unexpected %q", p.src[p.pos:])
+	}
+	p.pos += len(m)
+	return strconv.ParseFloat(strings.ReplaceAll(m, ",", ""), 64)
+}
+
+// accept consumes tok if the rest of the expression starts with it
+func (p *parser) accept(tok string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(strings.ToLower(p.src[p.pos:]), tok) {
+		p.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+// skipSpace moves past spaces and tabs
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
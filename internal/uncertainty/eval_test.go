@@ -0,0 +1,110 @@
+package uncertainty
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIsUncertaintyExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"(12.3 ± 0.2) * (4.56 ± 0.05)", true},
+		{"(12.3 +- 0.2) * (4.56 +/- 0.05)", true},
+		{"(100 +- 2%) * 3", true},
+		{"2 ^ (3 ± 0.1)", true},
+		{"sqrt(16 ± 0.4)", true},
+		{"(5 ± 1) / (0 ± 1)", true},
+
+		// A lone value is a tolerance band
+		{"100 +- 2%", false},
+		{"(12.3 ± 0.2)", false},
+		// No uncertain value, or not arithmetic
+		{"12.3 * 4.56", false},
+		{"4.7k ohm ±5%", false},
+		{"is 102.3 within 100 ± 3%", false},
+		{"(12.3 ± 0.2) * x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsUncertaintyExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsUncertaintyExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalUncertainty(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"(12.3 ± 0.2) * (4.56 ± 0.05)", "56.1 ± 1.1"},
+		{"(12.3 +- 0.2) * (4.56 +/- 0.05)", "56.1 ± 1.1"},
+		{"(10 ± 1) + (20 ± 2)", "30.0 ± 2.2"},
+		{"(10 ± 1) - (20 ± 2)", "-10.0 ± 2.2"},
+		{"(10 ± 0.3) / (4 ± 0.04)", "2.500 ± 0.079"},
+		{"(100 +- 2%) * 3", "300.0 ± 6.0"},
+		{"(10 ± 1)^2", "100 ± 20"},
+		{"2^(3 ± 0.1)", "8.00 ± 0.55"},
+		{"sqrt(16 ± 0.4)", "4.000 ± 0.050"},
+		{"-(1 ± 0.5) * 2", "-2.0 ± 1.0"},
+		{"1234.5 ± 12 + 1", "1,236 ± 12"},
+		{"(0.00123 ± 0.00004) * 2", "0.002460 ± 0.000080"},
+		// 0.996 rounds up to 1.0, which keeps its two figures
+		{"(9.87 ± 0.996) * 1", "9.9 ± 1.0"},
+		// Plain numbers are exact
+		{"(2 ± 0) * 3", "6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalUncertainty(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalUncertainty(%q) error: %v", tt.expr, err)
+			}
+			if result.Text != tt.expected {
+				t.Errorf("EvalUncertainty(%q) = %q, want %q", tt.expr, result.Text, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalUncertaintyErrors(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"(5 ± 1) / (0 ± 1)", "division by zero"},
+		{"sqrt(-4 ± 1)", "-4 ^ 0.5 is undefined"},
+		{"(-8 ± 1)^(1 ± 0.1)", "an uncertain exponent needs a positive base"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := EvalUncertainty(tt.expr)
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("EvalUncertainty(%q) error = %v, want %q", tt.expr, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValuePropagation(t *testing.T) {
+	a, b := Value{12.3, 0.2}, Value{4.56, 0.05}
+	product := a.Mul(b)
+	want := a.Mean * b.Mean * math.Hypot(a.Sigma/a.Mean, b.Sigma/b.Mean)
+	if math.Abs(product.Sigma-want) > 1e-12 {
+		t.Errorf("Mul sigma = %v, want %v", product.Sigma, want)
+	}
+
+	// An exact factor scales the uncertainty
+	if got := a.Mul(Exact(3)); math.Abs(got.Sigma-0.6) > 1e-12 {
+		t.Errorf("Mul by exact sigma = %v, want 0.6", got.Sigma)
+	}
+	if got := a.Add(Exact(1)); got.Sigma != a.Sigma {
+		t.Errorf("Add exact sigma = %v, want %v", got.Sigma, a.Sigma)
+	}
+}
@@ -0,0 +1,15 @@
+package uncertainty
+
+import "smartcalc/internal/registry"
+
+func init() {
+	// Shown as typed, since spacing would break "+/-"; a division by zero
+	// is reported instead of left to plain arithmetic
+	registry.Register(registry.Evaluator{
+		Name:     "uncertainty",
+		Priority: registry.PriorityUncertainty,
+		Traits:   registry.NoFormat | registry.ReportsErrors,
+		Detect:   IsUncertaintyExpression,
+		Eval:     EvalUncertainty,
+	})
+}
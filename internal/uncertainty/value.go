@@ -0,0 +1,103 @@
+package uncertainty
+
+import (
+	"fmt"
+	"math"
+
+	"smartcalc/internal/utils"
+)
+
+// Value is a measurement with its standard uncertainty, "12.3 ± 0.2". A plain
+// number is a Value with no uncertainty. The errors of the values combined
+// are taken to be independent, so they add in quadrature.
+type Value struct {
+	Mean  float64
+	Sigma float64
+}
+
+// Exact is a number without uncertainty
+func Exact(v float64) Value {
+	return Value{Mean: v}
+}
+
+// Add returns a + b: the uncertainties add in quadrature
+func (a Value) Add(b Value) Value {
+	return Value{a.Mean + b.Mean, math.Hypot(a.Sigma, b.Sigma)}
+}
+
+// Sub returns a - b: the uncertainties add in quadrature
+func (a Value) Sub(b Value) Value {
+	return Value{a.Mean - b.Mean, math.Hypot(a.Sigma, b.Sigma)}
+}
+
+// Mul returns a * b: the relative uncertainties add in quadrature
+func (a Value) Mul(b Value) Value {
+	return Value{a.Mean * b.Mean, math.Hypot(a.Sigma*b.Mean, b.Sigma*a.Mean)}
+}
+
+// Div returns a / b: the relative uncertainties add in quadrature
+func (a Value) Div(b Value) (Value, error) {
+	if b.Mean == 0 {
+		return Value{}, fmt.Errorf("division by zero")
+	}
+	mean := a.Mean / b.Mean
+	return Value{mean, math.Hypot(a.Sigma/b.Mean, b.Sigma*a.Mean/(b.Mean*b.Mean))}, nil
+}
+
+// Pow returns a ^ b. An exact exponent scales the relative uncertainty of the
+// base by the exponent; an uncertain one also needs a positive base.
+func (a Value) Pow(b Value) (Value, error) {
+	mean := math.Pow(a.Mean, b.Mean)
+	if math.IsNaN(mean) || math.IsInf(mean, 0) {
+		return Value{}, fmt.Errorf("%s ^ %s is undefined", Exact(a.Mean).Format(), Exact(b.Mean).Format())
+	}
+	// d(a^b)/da = b a^(b-1), d(a^b)/db = a^b ln a
+	dA := 0.0
+	if a.Sigma != 0 {
+		dA = b.Mean * math.Pow(a.Mean, b.Mean-1) * a.Sigma
+	}
+	dB := 0.0
+	if b.Sigma != 0 {
+		if a.Mean <= 0 {
+			return Value{}, fmt.Errorf("an uncertain exponent needs a positive base")
+		}
+		dB = mean * math.Log(a.Mean) * b.Sigma
+	}
+	if math.IsNaN(dA) || math.IsInf(dA, 0) {
+		return Value{}, fmt.Errorf("the uncertainty of %s ^ %s is undefined", Exact(a.Mean).Format(), Exact(b.Mean).Format())
+	}
+	return Value{mean, math.Hypot(dA, dB)}, nil
+}
+
+// Neg returns -a
+func (a Value) Neg() Value {
+	return Value{-a.Mean, a.Sigma}
+}
+
+// Format writes the value as "56.1 ± 1.1": the uncertainty rounded to two
+// significant figures and the mean to the same decimal place. A value
+// without uncertainty is written like any other result.
+func (a Value) Format() string {
+	if a.Sigma == 0 {
+		return utils.FormatResult(false, a.Mean)
+	}
+	decimals := 1 - int(math.Floor(math.Log10(a.Sigma)))
+	// 9.96 rounds to 10, which has its two figures one place further left
+	if roundTo(a.Sigma, decimals) >= math.Pow(10, float64(2-decimals)) {
+		decimals--
+	}
+	return fixed(a.Mean, decimals) + " ± " + fixed(a.Sigma, decimals)
+}
+
+// roundTo rounds v to decimals places; a negative count rounds to tens,
+// hundreds and so on
+func roundTo(v float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(v*scale) / scale
+}
+
+// fixed writes v rounded to decimals places, keeping the trailing zeros that
+// show its precision
+func fixed(v float64, decimals int) string {
+	return utils.FormatFixed(roundTo(v, decimals), decimals)
+}
@@ -2,9 +2,12 @@ package units
 
 import (
 	"fmt"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"smartcalc/internal/utils"
 )
 
 // Handler defines the interface for unit conversion handlers.
@@ -90,7 +93,7 @@ var lengthToMeters = map[string]float64{
 	"yd": 0.9144, "yard": 0.9144, "yards": 0.9144,
 	"ft": 0.3048, "foot": 0.3048, "feet": 0.3048,
 	"in": 0.0254, "inch": 0.0254, "inches": 0.0254,
-	"nm": 1852, "nautical mile": 1852, "nautical miles": 1852,
+	"nm": 1852, "nmi": 1852, "nautical mile": 1852, "nautical miles": 1852,
 }
 
 // Weight conversion factors to grams
@@ -105,6 +108,80 @@ var weightToGrams = map[string]float64{
 	"st": 6350.29, "stone": 6350.29, "stones": 6350.29,
 }
 
+// LengthInMeters converts a length in one of the units above, such as "ft"
+// or "centimeters", to meters
+func LengthInMeters(value float64, unit string) (float64, bool) {
+	f, ok := lengthToMeters[strings.ToLower(unit)]
+	return value * f, ok
+}
+
+// ParseDistance converts a distance typed as an amount and a unit, such as
+// "13.1" and "mi", to meters, reporting whether the unit is imperial. A "k"
+// is a kilometer, as in "5k".
+func ParseDistance(value, unit string) (meters float64, imperial bool, err error) {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid distance %s", value)
+	}
+	unit = strings.ToLower(unit)
+	if unit == "k" {
+		unit = "km"
+	}
+	meters, ok := LengthInMeters(v, unit)
+	if !ok {
+		return 0, false, fmt.Errorf("unknown distance unit %q", unit)
+	}
+	return meters, strings.HasPrefix(unit, "mi") || strings.HasPrefix(unit, "y") || strings.HasPrefix(unit, "f"), nil
+}
+
+// WeightInKilograms converts a weight in one of the units above, such as "lb"
+// or "stone", to kilograms
+func WeightInKilograms(value float64, unit string) (float64, bool) {
+	f, ok := weightToGrams[strings.ToLower(unit)]
+	return value * f / 1000, ok
+}
+
+// VolumeInLiters converts a volume in one of the units below, such as "gal"
+// or "cups", to liters
+func VolumeInLiters(value float64, unit string) (float64, bool) {
+	f, ok := volumeToLiters[strings.ToLower(unit)]
+	return value * f, ok
+}
+
+// DataInBytes converts an amount of data in one of the units below, such as
+// "GB" or "TiB", to bytes
+func DataInBytes(value float64, unit string) (float64, bool) {
+	f, ok := dataToBytes[strings.ToLower(unit)]
+	return value * f, ok
+}
+
+// Units of the SI (powers of 1000) and IEC (powers of 1024) byte scales
+var (
+	siByteUnits  = []string{"B", "KB", "MB", "GB", "TB", "PB", "EB"}
+	iecByteUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+)
+
+// FormatBytes shows an amount of data in SI and IEC units: "25.92 TB / 23.57 TiB"
+func FormatBytes(b float64) string {
+	return scaledBytes(b, 1000, siByteUnits) + " / " + scaledBytes(b, 1024, iecByteUnits)
+}
+
+// FormatBytesSI shows an amount of data in SI units only: "125 MB"
+func FormatBytesSI(b float64) string {
+	return scaledBytes(b, 1000, siByteUnits)
+}
+
+// scaledBytes shows bytes in the largest unit of a scale that keeps the
+// amount at least 1
+func scaledBytes(b, base float64, unitNames []string) string {
+	i := 0
+	for i < len(unitNames)-1 && b >= base {
+		b /= base
+		i++
+	}
+	return utils.FormatResult(false, math.Round(b*100)/100) + " " + unitNames[i]
+}
+
 // Volume conversion factors to liters
 var volumeToLiters = map[string]float64{
 	"l": 1, "liter": 1, "liters": 1, "litre": 1, "litres": 1,
@@ -118,6 +195,21 @@ var volumeToLiters = map[string]float64{
 	"tsp": 0.00492892, "teaspoon": 0.00492892, "teaspoons": 0.00492892,
 }
 
+// Power conversion factors to watts
+var powerToWatts = map[string]float64{
+	"w": 1, "watt": 1, "watts": 1,
+	"kw": 1000, "kilowatt": 1000, "kilowatts": 1000,
+	"megawatt": 1e6, "megawatts": 1e6,
+	"hp": 745.7, "horsepower": 745.7,
+}
+
+// PowerInWatts converts a power in one of the units above, such as "kW" or
+// "hp", to watts
+func PowerInWatts(value float64, unit string) (float64, bool) {
+	f, ok := powerToWatts[strings.ToLower(unit)]
+	return value * f, ok
+}
+
 // Data conversion factors to bytes
 // SI/Decimal units (base 1000): KB, MB, GB, TB, PB
 // IEC/Binary units (base 1024): KiB, MiB, GiB, TiB, PiB
@@ -145,7 +237,7 @@ var speedToMPS = map[string]float64{
 	"m/s": 1, "mps": 1, "meters per second": 1,
 	"km/h": 0.277778, "kph": 0.277778, "kmh": 0.277778, "kilometers per hour": 0.277778,
 	"mph": 0.44704, "miles per hour": 0.44704,
-	"knot": 0.514444, "knots": 0.514444, "kn": 0.514444,
+	"knot": 0.514444, "knots": 0.514444, "kn": 0.514444, "kt": 0.514444, "kts": 0.514444,
 	"ft/s": 0.3048, "fps": 0.3048, "feet per second": 0.3048,
 }
 
@@ -161,6 +253,13 @@ var areaToSqMeters = map[string]float64{
 	"sqin": 0.00064516, "in2": 0.00064516, "square inch": 0.00064516, "square inches": 0.00064516,
 }
 
+// AreaInSquareMeters converts an area in one of the units above, such as
+// "sq ft" or "acres", to square meters
+func AreaInSquareMeters(value float64, unit string) (float64, bool) {
+	f, ok := areaToSqMeters[strings.ToLower(unit)]
+	return value * f, ok
+}
+
 func handleLengthConversion(expr, exprLower string) (string, bool) {
 	re := regexp.MustCompile(`^([\d.]+)\s*([a-z]+(?:\s+[a-z]+)?)\s+(?:in|to)\s+([a-z]+(?:\s+[a-z]+)?)$`)
 	matches := re.FindStringSubmatch(exprLower)
@@ -384,7 +483,39 @@ func handleAreaConversion(expr, exprLower string) (string, bool) {
 	return formatResult(result, toUnit), true
 }
 
+// unitForms lists singular/plural pairs for spelled-out unit names so that
+// results read "1 mile" and "2 miles" regardless of which form was typed.
+var unitForms = [][2]string{
+	{"meter", "meters"}, {"metre", "metres"},
+	{"kilometer", "kilometers"}, {"kilometre", "kilometres"},
+	{"centimeter", "centimeters"}, {"centimetre", "centimetres"},
+	{"millimeter", "millimeters"}, {"millimetre", "millimetres"},
+	{"mile", "miles"}, {"nautical mile", "nautical miles"}, {"knot", "knots"},
+	{"yard", "yards"}, {"foot", "feet"}, {"inch", "inches"},
+	{"gram", "grams"}, {"kilogram", "kilograms"}, {"kilo", "kilos"}, {"milligram", "milligrams"},
+	{"lb", "lbs"}, {"pound", "pounds"}, {"ounce", "ounces"},
+	{"ton", "tons"}, {"short ton", "short tons"}, {"tonne", "tonnes"}, {"metric ton", "metric tons"},
+	{"stone", "stones"},
+	{"liter", "liters"}, {"litre", "litres"}, {"milliliter", "milliliters"}, {"millilitre", "millilitres"},
+	{"gallon", "gallons"}, {"quart", "quarts"}, {"pint", "pints"}, {"cup", "cups"},
+	{"fluid ounce", "fluid ounces"}, {"tablespoon", "tablespoons"}, {"teaspoon", "teaspoons"},
+	{"byte", "bytes"}, {"bit", "bits"},
+	{"acre", "acres"}, {"hectare", "hectares"},
+}
+
+// unitLabel returns the singular or plural form of unit to match value.
+// Abbreviations without a known plural form are returned unchanged.
+func unitLabel(value float64, unit string) string {
+	for _, forms := range unitForms {
+		if unit == forms[0] || unit == forms[1] {
+			return utils.Plural(value, forms[0], forms[1])
+		}
+	}
+	return unit
+}
+
 func formatResult(value float64, unit string) string {
+	unit = unitLabel(value, unit)
 	if value == float64(int64(value)) && value < 1e15 {
 		return fmt.Sprintf("%.0f %s", value, unit)
 	}
@@ -392,7 +523,7 @@ func formatResult(value float64, unit string) string {
 		return fmt.Sprintf("%.6g %s", value, unit)
 	}
 	if value == float64(int(value*100))/100 {
-		return fmt.Sprintf("%.2f %s", value, unit)
+		return fmt.Sprintf("%s %s", strconv.FormatFloat(value, 'f', -1, 64), unit)
 	}
 	return fmt.Sprintf("%.4f %s", value, unit)
 }
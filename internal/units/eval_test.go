@@ -1,6 +1,7 @@
 package units
 
 import (
+	"math"
 	"strings"
 	"testing"
 )
@@ -159,6 +160,8 @@ func TestEvalSpeedConversion(t *testing.T) {
 		{"60 mph to kph", "96.56"},
 		{"100 kph to mph", "62.13"},
 		{"10 m/s to kph", "36"},
+		{"120 kts to mph", "138.09"},
+		{"100 kt to km/h", "185.19"},
 	}
 
 	for _, tt := range tests {
@@ -200,6 +203,29 @@ func TestEvalAreaConversion(t *testing.T) {
 	}
 }
 
+func TestParseDistance(t *testing.T) {
+	tests := []struct {
+		value, unit string
+		meters      float64
+		imperial    bool
+	}{
+		{"10", "km", 10000, false},
+		{"5", "k", 5000, false},
+		{"13.1", "mi", 13.1 * 1609.344, true},
+		{"100", "yards", 91.44, true},
+	}
+
+	for _, tt := range tests {
+		meters, imperial, err := ParseDistance(tt.value, tt.unit)
+		if err != nil || math.Abs(meters-tt.meters) > 1e-9 || imperial != tt.imperial {
+			t.Errorf("ParseDistance(%q, %q) = %v, %v, %v, want %v, %v", tt.value, tt.unit, meters, imperial, err, tt.meters, tt.imperial)
+		}
+	}
+	if _, _, err := ParseDistance("3", "parsecs"); err == nil {
+		t.Error("ParseDistance with an unknown unit should fail")
+	}
+}
+
 func TestIsUnitExpression(t *testing.T) {
 	tests := []struct {
 		expr     string
@@ -0,0 +1,175 @@
+package units
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// dimension groups units that can be added together, with factors to a common base unit
+type dimension struct {
+	name    string
+	factors map[string]float64
+}
+
+// dimensions lists the measurable quantities that support arithmetic.
+// Temperature is excluded since its scales are offset, not proportional.
+var dimensions = []dimension{
+	{"length", lengthToMeters},
+	{"weight", weightToGrams},
+	{"volume", volumeToLiters},
+	{"data", dataToBytes},
+	{"speed", speedToMPS},
+	{"area", areaToSqMeters},
+}
+
+// quantity is a number with a unit, normalized to its dimension's base unit
+type quantity struct {
+	unit  string
+	dim   *dimension
+	value float64 // in the dimension's base unit
+}
+
+var quantityTermPattern = regexp.MustCompile(`^([\d.,]+)\s*([a-z/²]+(?:\s+[a-z]+)*)$`)
+
+// lookupUnit finds the dimension and base-unit factor for a unit name
+func lookupUnit(unit string) (*dimension, float64, bool) {
+	for i := range dimensions {
+		if f, ok := dimensions[i].factors[unit]; ok {
+			return &dimensions[i], f, true
+		}
+	}
+	return nil, 0, false
+}
+
+// IsUnit checks if a unit name is one unit arithmetic knows: "km", "kg",
+// "mph"
+func IsUnit(unit string) bool {
+	_, _, ok := lookupUnit(strings.ToLower(unit))
+	return ok
+}
+
+// parseQuantity parses a single term like "5 km" or "300m"
+func parseQuantity(term string) (quantity, bool) {
+	m := quantityTermPattern.FindStringSubmatch(strings.TrimSpace(term))
+	if m == nil {
+		return quantity{}, false
+	}
+	value, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+	if err != nil {
+		return quantity{}, false
+	}
+	unit := strings.TrimSpace(m[2])
+	dim, factor, ok := lookupUnit(unit)
+	if !ok {
+		return quantity{}, false
+	}
+	return quantity{unit: unit, dim: dim, value: value * factor}, true
+}
+
+// splitQuantityTerms splits "5 km + 300 m - 20 cm" into terms and operators.
+// A + or - only separates terms when it follows a unit, so signs and
+// exponents inside numbers are left alone.
+func splitQuantityTerms(expr string) ([]string, []byte) {
+	var terms []string
+	var ops []byte
+	start := 0
+	for i := 0; i < len(expr); i++ {
+		if expr[i] != '+' && expr[i] != '-' {
+			continue
+		}
+		prev := strings.TrimSpace(expr[start:i])
+		if prev == "" {
+			continue
+		}
+		last := prev[len(prev)-1]
+		if (last < 'a' || last > 'z') && !strings.HasSuffix(prev, "²") {
+			continue
+		}
+		terms = append(terms, prev)
+		ops = append(ops, expr[i])
+		start = i + 1
+	}
+	terms = append(terms, strings.TrimSpace(expr[start:]))
+	return terms, ops
+}
+
+// splitTargetUnit separates a trailing "in <unit>" or "to <unit>" when the
+// remainder names a known unit, so "5 in + 3 in" still reads as inches.
+func splitTargetUnit(exprLower string) (string, string) {
+	for _, sep := range []string{" in ", " to "} {
+		idx := strings.LastIndex(exprLower, sep)
+		if idx <= 0 {
+			continue
+		}
+		target := strings.TrimSpace(exprLower[idx+len(sep):])
+		if _, _, ok := lookupUnit(target); ok {
+			return strings.TrimSpace(exprLower[:idx]), target
+		}
+	}
+	return exprLower, ""
+}
+
+// parseQuantityExpression parses an addition/subtraction of quantities with an
+// optional target unit. ok is false when the expression isn't unit arithmetic.
+func parseQuantityExpression(expr string) (terms []quantity, ops []byte, target string, ok bool) {
+	body, target := splitTargetUnit(strings.ToLower(strings.TrimSpace(expr)))
+	rawTerms, ops := splitQuantityTerms(body)
+	if len(rawTerms) < 2 {
+		return nil, nil, "", false
+	}
+	for _, t := range rawTerms {
+		q, ok := parseQuantity(t)
+		if !ok {
+			return nil, nil, "", false
+		}
+		terms = append(terms, q)
+	}
+	return terms, ops, target, true
+}
+
+// IsQuantityExpression checks if an expression adds or subtracts quantities
+// with units, such as "5 km + 300 m" or "2 lbs - 100 g in oz".
+func IsQuantityExpression(expr string) bool {
+	_, _, _, ok := parseQuantityExpression(expr)
+	return ok
+}
+
+// EvalQuantity evaluates unit-aware arithmetic. The result is expressed in the
+// unit of the first operand unless a trailing "in <unit>" picks another one.
+// Mixing dimensions (km + kg) is an error.
+func EvalQuantity(expr string) (string, error) {
+	terms, ops, target, ok := parseQuantityExpression(expr)
+	if !ok {
+		return "", fmt.Errorf("unable to evaluate unit arithmetic: %s", expr)
+	}
+
+	first := terms[0]
+	total := first.value
+	for i, q := range terms[1:] {
+		if q.dim != first.dim {
+			return "", fmt.Errorf("incompatible units: %s (%s) and %s (%s)", first.unit, first.dim.name, q.unit, q.dim.name)
+		}
+		if ops[i] == '-' {
+			total -= q.value
+		} else {
+			total += q.value
+		}
+	}
+
+	outUnit := first.unit
+	if target != "" {
+		dim, _, _ := lookupUnit(target)
+		if dim != first.dim {
+			return "", fmt.Errorf("cannot express %s as %s (%s)", first.dim.name, target, dim.name)
+		}
+		outUnit = target
+	}
+
+	result := total / first.dim.factors[outUnit]
+	if first.dim.name == "data" {
+		return formatResult(result, strings.ToUpper(outUnit)), nil
+	}
+	return formatResult(result, outUnit), nil
+}
@@ -0,0 +1,77 @@
+package units
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvalQuantity(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"5 km + 300 m", "5.3 km"},
+		{"5km+300m", "5.3 km"},
+		{"5 km + 300 m in miles", "3.2933 miles"},
+		{"10 ft - 2 ft + 6 in", "8.5 ft"},
+		{"5 in + 3 in", "8 in"},
+		{"1 mile + 1 mile", "2 miles"},
+		{"1 kg + 500 g", "1.5 kg"},
+		{"2 lbs - 100 g in oz", "28.4726 oz"},
+		{"1 gb + 500 mb", "1.5 GB"},
+		{"1 liter + 250 ml in ml", "1250 ml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := EvalQuantity(tt.expr)
+			if err != nil {
+				t.Fatalf("EvalQuantity(%q) error: %v", tt.expr, err)
+			}
+			if result != tt.expected {
+				t.Errorf("EvalQuantity(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalQuantityErrors(t *testing.T) {
+	tests := []struct {
+		expr     string
+		contains string
+	}{
+		{"5 km + 2 kg", "incompatible units"},
+		{"5 km + 300 m in kg", "cannot express length"},
+		{"5 + 3", "unable to evaluate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := EvalQuantity(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("EvalQuantity(%q) error = %v, want to contain %q", tt.expr, err, tt.contains)
+			}
+		})
+	}
+}
+
+func TestIsQuantityExpression(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"5 km + 300 m", true},
+		{"5 km + 2 kg", true}, // recognized so the dimension error is reported
+		{"5 km in miles", false},
+		{"5 + 3", false},
+		{"5 apples + 3 pears", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := IsQuantityExpression(tt.expr); got != tt.expected {
+				t.Errorf("IsQuantityExpression(%q) = %v, want %v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
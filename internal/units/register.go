@@ -0,0 +1,21 @@
+package units
+
+import "smartcalc/internal/registry"
+
+func init() {
+	registry.Register(registry.Evaluator{
+		Name:     "units",
+		Priority: registry.PriorityUnits,
+		Detect:   IsUnitExpression,
+		Eval:     registry.TextEval(EvalUnits),
+	})
+	// Mixed dimensions (5 km + 3 kg) are reported rather than left to
+	// plain arithmetic
+	registry.Register(registry.Evaluator{
+		Name:     "quantity",
+		Priority: registry.PriorityQuantity,
+		Traits:   registry.ReportsErrors,
+		Detect:   IsQuantityExpression,
+		Eval:     registry.TextEval(EvalQuantity),
+	})
+}
@@ -0,0 +1,168 @@
+// Package userfuncs loads one-argument functions the user defines in a file
+// in the config directory, so they can be called from any sheet.
+package userfuncs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"smartcalc/internal/eval"
+)
+
+// FileName is the name of the definitions file in the config directory
+const FileName = "functions.txt"
+
+// definitionPattern matches "fahr(x) = x * 9/5 + 32"
+var definitionPattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*\(\s*([A-Za-z_][A-Za-z0-9_]*)\s*\)\s*=\s*(.+)$`)
+
+// definition is a parsed function with the line it was defined on
+type definition struct {
+	fn   eval.UserFunction
+	line int
+}
+
+// lineError is a problem with a line of the definitions file
+type lineError struct {
+	line int
+	msg  string
+}
+
+func (e lineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.line, e.msg)
+}
+
+// Load reads the definitions file at path and makes its functions callable.
+// A missing file defines no functions. See Apply for the file format.
+func Load(path string) ([]eval.UserFunction, []error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Apply("")
+	}
+	if err != nil {
+		eval.SetUserFunctions(nil)
+		return nil, []error{err}
+	}
+	return Apply(string(data))
+}
+
+// Apply parses definitions, one per line, and replaces the user-defined
+// functions with the valid ones. Blank lines and lines starting with "#" are
+// skipped. A definition is rejected if it cannot be parsed, redefines a
+// built-in function or an earlier definition, calls itself directly or
+// through other functions, or fails to evaluate with 1 as its argument.
+// Returns the functions defined and an error for each rejected line.
+func Apply(text string) ([]eval.UserFunction, []error) {
+	defs, errs := parse(text)
+	defs, cycleErrs := rejectRecursive(defs)
+	errs = append(errs, cycleErrs...)
+
+	fns := make([]eval.UserFunction, len(defs))
+	for i, d := range defs {
+		fns[i] = d.fn
+	}
+	eval.SetUserFunctions(fns)
+
+	// A function calling one that fails also fails, so one pass is enough
+	var valid []eval.UserFunction
+	for _, d := range defs {
+		if _, err := eval.EvalExpr(d.fn.Name+"(1)", nil); err != nil {
+			errs = append(errs, lineError{d.line, err.Error()})
+			continue
+		}
+		valid = append(valid, d.fn)
+	}
+	eval.SetUserFunctions(valid)
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].line < errs[j].line })
+	result := make([]error, len(errs))
+	for i, err := range errs {
+		result[i] = err
+	}
+	return eval.UserFunctions(), result
+}
+
+// parse reads the definitions and rejects malformed lines, built-in names and
+// duplicates
+func parse(text string) ([]definition, []lineError) {
+	var defs []definition
+	var errs []lineError
+	seen := make(map[string]bool)
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m := definitionPattern.FindStringSubmatch(line)
+		if m == nil {
+			errs = append(errs, lineError{i + 1, "expected a definition like fahr(x) = x * 9/5 + 32"})
+			continue
+		}
+		name, param := strings.ToLower(m[1]), strings.ToLower(m[2])
+		switch {
+		case eval.IsBuiltinFunctionName(name):
+			errs = append(errs, lineError{i + 1, name + " is a built-in function and cannot be redefined"})
+			continue
+		case seen[name]:
+			errs = append(errs, lineError{i + 1, name + " is already defined"})
+			continue
+		}
+		seen[name] = true
+		defs = append(defs, definition{
+			fn:   eval.UserFunction{Name: name, Param: param, Body: strings.TrimSpace(m[3])},
+			line: i + 1,
+		})
+	}
+	return defs, errs
+}
+
+// rejectRecursive removes the definitions that call themselves, directly or
+// through other user functions
+func rejectRecursive(defs []definition) ([]definition, []lineError) {
+	calls := make(map[string][]string, len(defs))
+	for _, d := range defs {
+		calls[d.fn.Name] = eval.ExprFunctionCalls(d.fn.Body)
+	}
+
+	var kept []definition
+	var errs []lineError
+	for _, d := range defs {
+		if path := findCycle(d.fn.Name, calls); path != nil {
+			if len(path) == 2 {
+				errs = append(errs, lineError{d.line, d.fn.Name + " is recursive: it calls itself"})
+			} else {
+				errs = append(errs, lineError{d.line, d.fn.Name + " is recursive: " + strings.Join(path, " → ")})
+			}
+			continue
+		}
+		kept = append(kept, d)
+	}
+	return kept, errs
+}
+
+// findCycle returns the chain of calls leading from name back to itself, or
+// nil when there is none
+func findCycle(name string, calls map[string][]string) []string {
+	visited := make(map[string]bool)
+	var walk func(from string, path []string) []string
+	walk = func(from string, path []string) []string {
+		for _, callee := range calls[from] {
+			if callee == name {
+				return append(path, callee)
+			}
+			if visited[callee] {
+				continue
+			}
+			visited[callee] = true
+			if found := walk(callee, append(path, callee)); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	return walk(name, []string{name})
+}
@@ -0,0 +1,93 @@
+package userfuncs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"smartcalc/internal/eval"
+)
+
+func TestApply(t *testing.T) {
+	defer eval.SetUserFunctions(nil)
+
+	text := `# temperature
+fahr(x) = x * 9/5 + 32
+sq(n) = n * n
+hyp(a) = sqrt(sq(a) + sq(a))
+
+f(x) = g(x)
+g(x) = f(x)
+sin(x) = 1
+bad(x) = y + 1
+fact(x) = x * fact(x - 1)
+useg(x) = g(x) + 1
+not a definition
+fahr(y) = 1`
+
+	fns, errs := Apply(text)
+
+	var names []string
+	for _, fn := range fns {
+		names = append(names, fn.Name)
+	}
+	if want := []string{"fahr", "hyp", "sq"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("defined %v, want %v", names, want)
+	}
+
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	want := []string{
+		"line 6: f is recursive: f → g → f",
+		"line 7: g is recursive: g → f → g",
+		"line 8: sin is a built-in function and cannot be redefined",
+		"line 9: bad: unknown variable: y",
+		"line 10: fact is recursive: it calls itself",
+		"line 11: useg: unknown function: g",
+		"line 12: expected a definition like fahr(x) = x * 9/5 + 32",
+		"line 13: fahr is already defined",
+	}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Errorf("errors:\n%q\nwant:\n%q", msgs, want)
+	}
+
+	if v, err := eval.EvalExpr("fahr(20)", nil); err != nil || v != 68 {
+		t.Errorf("fahr(20) = %v, %v; want 68", v, err)
+	}
+}
+
+func TestApplyReplaces(t *testing.T) {
+	defer eval.SetUserFunctions(nil)
+
+	Apply("double(x) = x * 2")
+	Apply("triple(x) = x * 3")
+	if eval.IsFunctionName("double") {
+		t.Error("double should be gone after reloading")
+	}
+	// Redefining a user function on reload is not a clash with the old one
+	if _, errs := Apply("triple(x) = 3 * x"); len(errs) != 0 {
+		t.Errorf("reloading triple: %v", errs)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	defer eval.SetUserFunctions(nil)
+	dir := t.TempDir()
+
+	fns, errs := Load(filepath.Join(dir, FileName))
+	if len(fns) != 0 || len(errs) != 0 {
+		t.Errorf("missing file: got %v, %v; want nothing", fns, errs)
+	}
+
+	path := filepath.Join(dir, FileName)
+	if err := os.WriteFile(path, []byte("kmh(mph) = mph * 1.609344\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fns, errs = Load(path)
+	if len(errs) != 0 || len(fns) != 1 || fns[0].String() != "kmh(mph) = mph * 1.609344" {
+		t.Errorf("Load = %v, %v", fns, errs)
+	}
+}
@@ -3,7 +3,9 @@ package utils
 import (
 	"fmt"
 	"math"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 func addThousandsSeparators(s string) string {
@@ -34,8 +36,68 @@ func addThousandsSeparators(s string) string {
 	return b.String()
 }
 
+// NumberFormat is how results are shown: the most decimal places of plain
+// numbers, the symbol currency amounts are written with and the decimal mark
+type NumberFormat struct {
+	Decimals     int    // 0 to MaxDecimals; trailing zeros are dropped
+	Symbol       string // "$", "€", or a code with a space such as "CHF "
+	DecimalComma bool   // 1.234,56 instead of 1,234.56
+	// ExactCurrency shows currency amounts with Decimals places instead of
+	// cents, as a line's precision hint asks
+	ExactCurrency bool
+	// WholeCurrency shows currency amounts without cents, for currencies
+	// such as the yen that have none
+	WholeCurrency bool
+}
+
+// MaxDecimals is the most decimal places a result can be shown with
+const MaxDecimals = 10
+
+// DefaultNumberFormat shows up to 10 decimal places and dollar amounts
+var DefaultNumberFormat = NumberFormat{Decimals: MaxDecimals, Symbol: "$"}
+
+var (
+	precisionMu sync.RWMutex
+	precision   = DefaultNumberFormat.Decimals
+)
+
+// SetPrecision sets the most decimal places results are shown with, from 0
+// to MaxDecimals. Currency amounts keep showing cents.
+func SetPrecision(decimals int) {
+	precisionMu.Lock()
+	defer precisionMu.Unlock()
+	precision = max(0, min(decimals, MaxDecimals))
+}
+
+// Precision returns the most decimal places results are shown with
+func Precision() int {
+	precisionMu.RLock()
+	defer precisionMu.RUnlock()
+	return precision
+}
+
+// CurrentNumberFormat returns DefaultNumberFormat with the precision set by
+// SetPrecision and the decimal mark set by SetDecimalComma
+func CurrentNumberFormat() NumberFormat {
+	f := DefaultNumberFormat
+	f.Decimals = Precision()
+	f.DecimalComma = DecimalComma()
+	return f
+}
+
 func formatNumberWithThousands(v float64) string {
-	s := strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.10f", v), "0"), ".")
+	return formatDecimals(v, DefaultNumberFormat.Decimals)
+}
+
+// formatDecimals rounds v to at most decimals places, with thousands separators
+func formatDecimals(v float64, decimals int) string {
+	s := fmt.Sprintf("%.*f", decimals, v)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		s = "0" // -0.4 rounded to a whole number
+	}
 	intPart := s
 	fracPart := ""
 	if dot := strings.IndexByte(s, '.'); dot >= 0 {
@@ -45,30 +107,173 @@ func formatNumberWithThousands(v float64) string {
 	return addThousandsSeparators(intPart) + fracPart
 }
 
+// FormatDecimal rounds v to at most places decimals, dropping trailing zeros,
+// without thousands separators: "56.1" for a BMI, "1.65" for a ratio
+func FormatDecimal(v float64, places int) string {
+	return strconv.FormatFloat(math.Round(v*math.Pow10(places))/math.Pow10(places), 'f', -1, 64)
+}
+
+// FormatAmount rounds v to at most places decimals and shows it as a result,
+// with thousands separators: "1,234.6" for 1234.56 at 1 place
+func FormatAmount(v float64, places int) string {
+	return FormatResult(false, math.Round(v*math.Pow10(places))/math.Pow10(places))
+}
+
 // FormatCurrency formats a float as currency with thousands separators (e.g., $1,234.56)
 func FormatCurrency(v float64) string {
+	return CurrentNumberFormat().Currency(v)
+}
+
+// Currency formats a float as an amount with the format's symbol (e.g., €1,234.56)
+func (f NumberFormat) Currency(v float64) string {
+	if f.ExactCurrency {
+		intPart, fracPart, hasFrac := strings.Cut(strconv.FormatFloat(math.Abs(v), 'f', f.Decimals, 64), ".")
+		out := addThousandsSeparators(intPart)
+		if hasFrac {
+			out += "." + fracPart
+		}
+		out = localize(out, f.DecimalComma)
+		if v < 0 {
+			out = "-" + out
+		}
+		return f.Symbol + out
+	}
 	abs := math.Abs(v)
+	if f.WholeCurrency {
+		out := localize(addThousandsSeparators(fmt.Sprintf("%.0f", abs)), f.DecimalComma)
+		if v < 0 && out != "0" {
+			out = "-" + out
+		}
+		return f.Symbol + out
+	}
 	whole := int64(abs)
 	frac := int64(math.Round((abs - float64(whole)) * 100))
 	if frac == 100 {
 		whole++
 		frac = 0
 	}
-	out := fmt.Sprintf("%s.%02d", addThousandsSeparators(fmt.Sprintf("%d", whole)), frac)
+	out := localize(fmt.Sprintf("%s.%02d", addThousandsSeparators(fmt.Sprintf("%d", whole)), frac), f.DecimalComma)
 	if v < 0 {
 		out = "-" + out
 	}
-	return "$" + out
+	return f.Symbol + out
 }
 
 func FormatResult(isCurrency bool, v float64) string {
+	return CurrentNumberFormat().Result(isCurrency, v)
+}
+
+// Result formats a result like FormatResult, with the format's decimal places
+// and currency symbol
+func (f NumberFormat) Result(isCurrency bool, v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "NaN"
+	}
+	if isCurrency {
+		return f.Currency(v)
+	}
+	if useScientific(v) {
+		return localize(formatScientific(v), f.DecimalComma)
+	}
+	return localize(formatDecimals(v, f.Decimals), f.DecimalComma)
+}
+
+// useScientific reports whether a number is too large or too small to read
+// in fixed notation (abs >= 1e12 or abs < 1e-4)
+func useScientific(v float64) bool {
+	abs := math.Abs(v)
+	return abs >= 1e12 || (abs != 0 && abs < 1e-4)
+}
+
+// formatMantissa formats a mantissa with up to 10 significant digits
+func formatMantissa(m float64) string {
+	return strconv.FormatFloat(m, 'g', 10, 64)
+}
+
+// FormatScientific formats a number in scientific notation (e.g., 1.204e24, 4.5e-7)
+func FormatScientific(v float64) string {
+	if v == 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return FormatResult(false, v)
+	}
+	return LocalizeNumber(formatScientific(v))
+}
+
+// formatScientific formats a number in scientific notation with a decimal point
+func formatScientific(v float64) string {
+	return compactExponent(strconv.FormatFloat(v, 'e', 9, 64)) // 10 significant digits
+}
+
+// compactExponent tidies Go's e-notation: "1.2040000e+24" -> "1.204e24"
+func compactExponent(s string) string {
+	mantissa, exp, _ := strings.Cut(s, "e")
+	if strings.Contains(mantissa, ".") {
+		mantissa = strings.TrimRight(strings.TrimRight(mantissa, "0"), ".")
+	}
+	e, _ := strconv.Atoi(exp)
+	return fmt.Sprintf("%se%d", mantissa, e)
+}
+
+// FormatEngineering formats a number in engineering notation, where the
+// exponent is a multiple of 3 (e.g., 450e-9, 12.5e6)
+func FormatEngineering(v float64) string {
+	if v == 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return FormatResult(false, v)
+	}
+	exp := int(math.Floor(math.Log10(math.Abs(v))/3)) * 3
+	mantissa := v / math.Pow(10, float64(exp))
+	// Rounding can push the mantissa to 1000 (999.99999999999 -> 1000)
+	if m, _ := strconv.ParseFloat(formatMantissa(mantissa), 64); math.Abs(m) >= 1000 {
+		exp += 3
+		mantissa = v / math.Pow(10, float64(exp))
+	}
+	if exp == 0 {
+		return LocalizeNumber(formatMantissa(mantissa))
+	}
+	return LocalizeNumber(fmt.Sprintf("%se%d", formatMantissa(mantissa), exp))
+}
+
+// FormatExact formats a value for reuse in another expression (copying with
+// resolved references). Unlike FormatResult it keeps 15 significant digits.
+func FormatExact(isCurrency bool, v float64) string {
 	if math.IsNaN(v) || math.IsInf(v, 0) {
 		return "NaN"
 	}
 	if isCurrency {
 		return FormatCurrency(v)
 	}
-	return formatNumberWithThousands(v)
+	s := strconv.FormatFloat(v, 'g', 15, 64)
+	if strings.Contains(s, "e") {
+		return LocalizeNumber(compactExponent(s))
+	}
+	intPart, fracPart, hasFrac := strings.Cut(s, ".")
+	if hasFrac {
+		return LocalizeNumber(addThousandsSeparators(intPart) + "." + fracPart)
+	}
+	return LocalizeNumber(addThousandsSeparators(intPart))
+}
+
+// FormatFixed formats a number with exactly decimals places, keeping trailing
+// zeros that show its precision, with thousands separators: 1234.5 with 2
+// places is "1,234.50"
+func FormatFixed(v float64, decimals int) string {
+	s := strconv.FormatFloat(v, 'f', max(decimals, 0), 64)
+	if strings.Trim(s, "-0.") == "" {
+		s = strings.TrimPrefix(s, "-")
+	}
+	intPart, fracPart, hasFrac := strings.Cut(s, ".")
+	if hasFrac {
+		return LocalizeNumber(addThousandsSeparators(intPart) + "." + fracPart)
+	}
+	return LocalizeNumber(addThousandsSeparators(intPart))
+}
+
+// Plural returns singular when n is exactly 1 (or -1), plural otherwise.
+// Example: fmt.Sprintf("%.0f %s", n, Plural(n, "day", "days"))
+func Plural(n float64, singular, plural string) string {
+	if math.Abs(n) == 1 {
+		return singular
+	}
+	return plural
 }
 
 // FormatBoolResult formats a comparison result as true/false
@@ -78,3 +283,29 @@ func FormatBoolResult(v float64) string {
 	}
 	return "false"
 }
+
+// FormatInBase formats an integer value with a 0x/0o/0b prefix for base 16, 8 or 2.
+// Returns ok=false for other bases and for values that are not whole numbers.
+func FormatInBase(v float64, base int) (string, bool) {
+	if v != math.Trunc(v) || math.IsInf(v, 0) || math.Abs(v) >= 1<<63 {
+		return "", false
+	}
+	prefix := ""
+	switch base {
+	case 16:
+		prefix = "0x"
+	case 8:
+		prefix = "0o"
+	case 2:
+		prefix = "0b"
+	default:
+		return "", false
+	}
+	n := int64(v)
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	return sign + prefix + strings.ToUpper(strconv.FormatInt(n, base)), true
+}
@@ -87,6 +87,12 @@ func TestFormatResult(t *testing.T) {
 		{"currency decimal", true, 1234.56, "$1,234.56"},
 		{"small number", false, 5, "5"},
 		{"small currency", true, 5, "$5.00"},
+		{"large number", false, 1.204e24, "1.204e24"},
+		{"below threshold", false, 999999999999, "999,999,999,999"},
+		{"tiny number", false, 0.00000045, "4.5e-7"},
+		{"small decimal", false, 0.0001, "0.0001"},
+		{"zero", false, 0, "0"},
+		{"large currency", true, 2e12, "$2,000,000,000,000.00"},
 	}
 
 	for _, tt := range tests {
@@ -98,3 +104,244 @@ func TestFormatResult(t *testing.T) {
 		})
 	}
 }
+
+func TestNumberFormatResult(t *testing.T) {
+	euros := NumberFormat{Decimals: 4, Symbol: "€"}
+	tests := []struct {
+		name       string
+		format     NumberFormat
+		isCurrency bool
+		value      float64
+		expected   string
+	}{
+		{"four places", euros, false, 1.0 / 3, "0.3333"},
+		{"trailing zeros dropped", euros, false, 1234.5, "1,234.5"},
+		{"currency keeps cents", euros, true, 1234.5678, "€1,234.57"},
+		{"whole numbers", NumberFormat{Decimals: 0, Symbol: "$"}, false, 2.7, "3"},
+		{"no negative zero", NumberFormat{Decimals: 0, Symbol: "$"}, false, -0.4, "0"},
+		{"code as symbol", NumberFormat{Decimals: 10, Symbol: "CHF "}, true, 12, "CHF 12.00"},
+		{"scientific unchanged", euros, false, 1.204e24, "1.204e24"},
+		{"exact currency", NumberFormat{Decimals: 4, Symbol: "$", ExactCurrency: true}, true, -10.0 / 3, "$-3.3333"},
+		{"exact whole currency", NumberFormat{Decimals: 0, Symbol: "€", ExactCurrency: true}, true, 1234.5678, "€1,235"},
+		{"currency without cents", NumberFormat{Decimals: 10, Symbol: "¥", WholeCurrency: true}, true, 1234.5678, "¥1,235"},
+		{"no negative zero yen", NumberFormat{Decimals: 10, Symbol: "¥", WholeCurrency: true}, true, -0.4, "¥0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.format.Result(tt.isCurrency, tt.value); got != tt.expected {
+				t.Errorf("%+v.Result(%v, %v) = %q, want %q", tt.format, tt.isCurrency, tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSetPrecision(t *testing.T) {
+	defer SetPrecision(MaxDecimals)
+
+	SetPrecision(3)
+	if got := FormatResult(false, 100.0/3); got != "33.333" {
+		t.Errorf("FormatResult() with precision 3 = %q, want 33.333", got)
+	}
+	if got := FormatResult(true, 100.0/3); got != "$33.33" {
+		t.Errorf("FormatResult() of currency with precision 3 = %q, want $33.33", got)
+	}
+	SetPrecision(42)
+	if got := Precision(); got != MaxDecimals {
+		t.Errorf("Precision() after SetPrecision(42) = %d, want %d", got, MaxDecimals)
+	}
+}
+
+func TestFormatScientificAndEngineering(t *testing.T) {
+	tests := []struct {
+		value float64
+		sci   string
+		eng   string
+	}{
+		{1.204e24, "1.204e24", "1.204e24"},
+		{0.00045, "4.5e-4", "450e-6"},
+		{1234567, "1.234567e6", "1.234567e6"},
+		{-0.00000045, "-4.5e-7", "-450e-9"},
+		{12.5, "1.25e1", "12.5"},
+		{999999.99999999999, "1e6", "1e6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sci, func(t *testing.T) {
+			if got := FormatScientific(tt.value); got != tt.sci {
+				t.Errorf("FormatScientific(%v) = %q, want %q", tt.value, got, tt.sci)
+			}
+			if got := FormatEngineering(tt.value); got != tt.eng {
+				t.Errorf("FormatEngineering(%v) = %q, want %q", tt.value, got, tt.eng)
+			}
+		})
+	}
+}
+
+func TestFormatExact(t *testing.T) {
+	tests := []struct {
+		isCurrency bool
+		value      float64
+		expected   string
+	}{
+		{false, 1.0 / 3, "0.333333333333333"},
+		{false, 1.2345678901234e24, "1.2345678901234e24"},
+		{false, 1234567.125, "1,234,567.125"},
+		{true, 1234.5, "$1,234.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := FormatExact(tt.isCurrency, tt.value); got != tt.expected {
+				t.Errorf("FormatExact(%v, %v) = %q, want %q", tt.isCurrency, tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatDecimal(t *testing.T) {
+	tests := []struct {
+		value    float64
+		places   int
+		expected string
+	}{
+		{24.6913, 1, "24.7"},
+		{1.5, 2, "1.5"},
+		{12345.678, 0, "12346"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatDecimal(tt.value, tt.places); got != tt.expected {
+			t.Errorf("FormatDecimal(%v, %d) = %q, want %q", tt.value, tt.places, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		value    float64
+		places   int
+		expected string
+	}{
+		{1234.56, 1, "1,234.6"},
+		{0.125, 2, "0.13"},
+		{12345.678, 0, "12,346"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatAmount(tt.value, tt.places); got != tt.expected {
+			t.Errorf("FormatAmount(%v, %d) = %q, want %q", tt.value, tt.places, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatFixed(t *testing.T) {
+	tests := []struct {
+		value    float64
+		decimals int
+		expected string
+	}{
+		{56.088, 1, "56.1"},
+		{1234.5, 2, "1,234.50"},
+		{0.1, 3, "0.100"},
+		{-0.04, 1, "0.0"},
+		{5612.3, 0, "5,612"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := FormatFixed(tt.value, tt.decimals); got != tt.expected {
+				t.Errorf("FormatFixed(%v, %d) = %q, want %q", tt.value, tt.decimals, got, tt.expected)
+			}
+		})
+	}
+}
+
+// setDecimalComma switches to decimal commas for the rest of a test
+func setDecimalComma(t *testing.T) {
+	t.Helper()
+	SetDecimalComma(true)
+	t.Cleanup(func() { SetDecimalComma(false) })
+}
+
+func TestFormattersDecimalComma(t *testing.T) {
+	setDecimalComma(t)
+	euros := NumberFormat{Decimals: 4, Symbol: "€", DecimalComma: true}
+	tests := []struct {
+		name     string
+		got      string
+		expected string
+	}{
+		{"FormatResult", FormatResult(false, 1234.56), "1.234,56"},
+		{"FormatResult whole", FormatResult(false, 1234567), "1.234.567"},
+		{"FormatResult currency", FormatResult(true, 1500), "$1.500,00"},
+		{"FormatResult scientific", FormatResult(false, 1.204e24), "1,204e24"},
+		{"FormatCurrency", FormatCurrency(1234.567), "$1.234,57"},
+		{"FormatCurrency negative", FormatCurrency(-0.5), "$-0,50"},
+		{"FormatScientific", FormatScientific(0.00045), "4,5e-4"},
+		{"FormatEngineering", FormatEngineering(12.5), "12,5"},
+		{"FormatEngineering exponent", FormatEngineering(0.00045), "450e-6"},
+		{"FormatExact", FormatExact(false, 1234567.125), "1.234.567,125"},
+		{"FormatExact currency", FormatExact(true, 1234.5), "$1.234,50"},
+		{"NumberFormat.Result", euros.Result(false, 1234.5), "1.234,5"},
+		{"NumberFormat.Result currency", euros.Result(true, 1234.5678), "€1.234,57"},
+		{"NumberFormat.Currency", euros.Currency(0.99), "€0,99"},
+		{"NumberFormat without comma", NumberFormat{Decimals: 2, Symbol: "$"}.Result(false, 1234.5), "1,234.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.expected {
+				t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatInBase(t *testing.T) {
+	tests := []struct {
+		value    float64
+		base     int
+		expected string
+		ok       bool
+	}{
+		{256, 16, "0x100", true},
+		{255, 16, "0xFF", true},
+		{30, 2, "0b11110", true},
+		{16, 8, "0o20", true},
+		{-5, 2, "-0b101", true},
+		{127.5, 16, "", false}, // fractional results stay decimal
+		{10, 10, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			result, ok := FormatInBase(tt.value, tt.base)
+			if result != tt.expected || ok != tt.ok {
+				t.Errorf("FormatInBase(%v, %d) = (%q, %v), want (%q, %v)", tt.value, tt.base, result, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestPlural(t *testing.T) {
+	tests := []struct {
+		n        float64
+		expected string
+	}{
+		{1, "day"},
+		{-1, "day"},
+		{0, "days"},
+		{2, "days"},
+		{1.5, "days"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			result := Plural(tt.n, "day", "days")
+			if result != tt.expected {
+				t.Errorf("Plural(%v) = %q, want %q", tt.n, result, tt.expected)
+			}
+		})
+	}
+}
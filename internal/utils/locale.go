@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	localeMu     sync.RWMutex
+	decimalComma bool
+)
+
+// SetDecimalComma switches how numbers are read and written: with a decimal
+// comma, 1.234,56, or with a decimal point, 1,234.56. Function arguments are
+// then separated by semicolons: "avg(1,5; 2,5)".
+func SetDecimalComma(on bool) {
+	localeMu.Lock()
+	defer localeMu.Unlock()
+	decimalComma = on
+}
+
+// DecimalComma reports whether numbers are written with a decimal comma
+func DecimalComma() bool {
+	localeMu.RLock()
+	defer localeMu.RUnlock()
+	return decimalComma
+}
+
+// decimalCommaLanguages write numbers with a decimal comma
+var decimalCommaLanguages = map[string]bool{
+	"az": true, "be": true, "bg": true, "ca": true, "cs": true, "da": true, "de": true,
+	"el": true, "es": true, "et": true, "eu": true, "fi": true, "fr": true, "gl": true,
+	"hr": true, "hu": true, "id": true, "is": true, "it": true, "kk": true, "lt": true,
+	"lv": true, "nb": true, "nl": true, "nn": true, "no": true, "pl": true, "pt": true,
+	"ro": true, "ru": true, "sk": true, "sl": true, "sr": true, "sv": true, "tr": true,
+	"uk": true, "vi": true,
+}
+
+// decimalPointRegions use a decimal point though their language mostly doesn't
+var decimalPointRegions = map[string]bool{
+	"de_CH": true, "de_LI": true, "it_CH": true, "es_MX": true, "es_US": true,
+}
+
+// DetectDecimalComma checks if an OS locale such as "de_DE.UTF-8" or "fr-FR"
+// writes numbers with a decimal comma
+func DetectDecimalComma(locale string) bool {
+	locale, _, _ = strings.Cut(locale, ".") // drop the encoding
+	locale, _, _ = strings.Cut(locale, "@") // and the modifier
+	locale = strings.ReplaceAll(locale, "-", "_")
+	lang, region, _ := strings.Cut(locale, "_")
+	lang = strings.ToLower(lang)
+	if decimalPointRegions[lang+"_"+strings.ToUpper(region)] {
+		return false
+	}
+	return decimalCommaLanguages[lang]
+}
+
+// commaNumberPattern matches a number written with a decimal comma: "1,5",
+// "1.234,56", "1.000". The first group of a thousands-separated number cannot
+// start with 0, so "0.500" is left to be read as a decimal point.
+var commaNumberPattern = regexp.MustCompile(`^([1-9]\d{0,2}(?:\.\d{3})+|\d+)(?:,(\d+))?([eE][+-]?\d+)?$`)
+
+// canonicalNumber rewrites a number written with a decimal comma the way
+// strconv reads it: "1.234,56" -> "1234.56"
+func canonicalNumber(s string) (string, bool) {
+	m := commaNumberPattern.FindStringSubmatch(s)
+	if m == nil {
+		return s, false
+	}
+	out := strings.ReplaceAll(m[1], ".", "")
+	if m[2] != "" {
+		out += "." + m[2]
+	}
+	return out + m[3], true
+}
+
+// ParseNumber reads a number with thousands separators: "1,234.56", or with
+// decimalComma "1.234,56". With a decimal comma a number that can only be
+// read with a decimal point, such as "0.5" or "1234.5", is still accepted.
+func ParseNumber(s string, decimalComma bool) (float64, error) {
+	if decimalComma {
+		if c, ok := canonicalNumber(s); ok {
+			s = c
+		} else if strings.Contains(s, ",") {
+			return 0, fmt.Errorf("invalid number %q", s)
+		}
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	if err != nil {
+		// Also a number too large for a float64, rather than infinity
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return v, nil
+}
+
+// numberRunPattern matches the digits, periods and commas a number is written with
+var numberRunPattern = regexp.MustCompile(`\d[\d.,]*`)
+
+// CanonicalNumbers rewrites an expression typed with decimal commas the way
+// the evaluators read it: "1.234,56 + 10%" -> "1234.56 + 10%" and
+// "avg(1,5; 2,5)" -> "avg(1.5, 2.5)". Digits that are not a number, such as
+// the date 15.06.2025 or the address 192.168.1.1, are left alone.
+func CanonicalNumbers(expr string) string {
+	expr = numberRunPattern.ReplaceAllStringFunc(expr, func(run string) string {
+		c, _ := canonicalNumber(run)
+		return c
+	})
+	return strings.ReplaceAll(expr, ";", ",")
+}
+
+// localize writes a formatted number with a decimal comma when comma is set:
+// "1,234.56" -> "1.234,56"
+func localize(s string, comma bool) string {
+	if !comma {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.':
+			return ','
+		case ',':
+			return '.'
+		}
+		return r
+	}, s)
+}
+
+// LocalizeNumber writes a number formatted with a decimal point, such as
+// strconv's "1234.5", with the current decimal mark
+func LocalizeNumber(s string) string {
+	return localize(s, DecimalComma())
+}
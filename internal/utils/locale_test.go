@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectDecimalComma(t *testing.T) {
+	tests := []struct {
+		locale   string
+		expected bool
+	}{
+		{"de_DE.UTF-8", true},
+		{"fr-FR", true},
+		{"pt_BR.utf8", true},
+		{"ru_RU.UTF-8@euro", true},
+		{"nl", true},
+		{"en_US.UTF-8", false},
+		{"en_GB", false},
+		{"de_CH.UTF-8", false},
+		{"es_MX", false},
+		{"ja_JP.UTF-8", false},
+		{"C", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			if got := DetectDecimalComma(tt.locale); got != tt.expected {
+				t.Errorf("DetectDecimalComma(%q) = %v, want %v", tt.locale, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		input        string
+		decimalComma bool
+		expected     float64
+		wantErr      bool
+	}{
+		{"1,234.56", false, 1234.56, false},
+		{"1234.5", false, 1234.5, false},
+		{"1,5", false, 15, false},
+		{"1.234,56", true, 1234.56, false},
+		{"1,5", true, 1.5, false},
+		{"1.000", true, 1000, false},
+		{"1" + strings.Repeat("0", 400), false, 0, true},
+		{"1.234.567", true, 1234567, false},
+		{"1,5e3", true, 1500, false},
+		{"0.5", true, 0.5, false},
+		{"1234.5", true, 1234.5, false},
+		{"1,2,3", true, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseNumber(tt.input, tt.decimalComma)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseNumber(%q, %v) = %v, want error", tt.input, tt.decimalComma, got)
+				}
+				return
+			}
+			if err != nil || got != tt.expected {
+				t.Errorf("ParseNumber(%q, %v) = %v, %v, want %v", tt.input, tt.decimalComma, got, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCanonicalNumbers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1.234,56 + 10%", "1234.56 + 10%"},
+		{"$1.500,00 - 5%", "$1500.00 - 5%"},
+		{"avg(1,5; 2,5; 4)", "avg(1.5, 2.5, 4)"},
+		{"1,5 km to m", "1.5 km to m"},
+		{"15.06.2025 + 3 days", "15.06.2025 + 3 days"},
+		{"geoip 192.168.1.1", "geoip 192.168.1.1"},
+		{"0.5 * 2", "0.5 * 2"},
+		{"\\1 + \\2", "\\1 + \\2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := CanonicalNumbers(tt.input); got != tt.expected {
+				t.Errorf("CanonicalNumbers(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLocalizeNumber(t *testing.T) {
+	if got := LocalizeNumber("1,234.5"); got != "1,234.5" {
+		t.Errorf("LocalizeNumber with a decimal point = %q, want %q", got, "1,234.5")
+	}
+	setDecimalComma(t)
+	if got := LocalizeNumber("1,234.5"); got != "1.234,5" {
+		t.Errorf("LocalizeNumber with a decimal comma = %q, want %q", got, "1.234,5")
+	}
+}
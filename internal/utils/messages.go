@@ -0,0 +1,328 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+)
+
+// MessageID names a piece of output text in the message catalog. Only
+// output is translated; expressions are always typed in English.
+type MessageID int
+
+// Duration and date words. The unit words are counted with CountWord; the
+// short units and phrases are fmt formats.
+const (
+	MsgYear   MessageID = iota // "1 year", "2 years"
+	MsgMonth                   // "1 month", "2 months"
+	MsgWeek                    // "1 week", "2 weeks"
+	MsgDay                     // "1 day", "2 days"
+	MsgHour                    // "1 hour", "2 hours"
+	MsgMinute                  // "1 minute", "2 minutes"
+	MsgSecond                  // "1 second", "2 seconds"
+	MsgMin                     // "5 min", the minutes of a detailed duration
+
+	MsgYearsShort   // "%dy"
+	MsgMonthsShort  // "%dmo"
+	MsgDaysShort    // "%dd"
+	MsgHoursShort   // "%dh"
+	MsgMinutesShort // "%dm"
+	MsgSecondsShort // "%ds"
+
+	MsgExpiresIn  // "expires in %s"
+	MsgExpired    // "expired"
+	MsgPassedAgo  // "already passed %s ago"
+	MsgNotYetIn   // "not yet, in %s"
+	numMessageIDs // keep last
+)
+
+// PluralForm is the grammatical number a count takes. English only tells
+// one from other; Ukrainian also has few ("2 дні") and many ("5 днів").
+type PluralForm int
+
+// Plural forms, by the names of the CLDR plural rules
+const (
+	PluralOne PluralForm = iota
+	PluralFew
+	PluralMany
+	PluralOther
+)
+
+// forms holds a message's word for each plural form. A message that isn't
+// counted only has other; an empty form falls back to other.
+type forms [4]string
+
+// form returns the word for f
+func (m forms) form(f PluralForm) string {
+	if m[f] != "" {
+		return m[f]
+	}
+	return m[PluralOther]
+}
+
+// text is a message that isn't counted
+func text(s string) forms {
+	return forms{PluralOther: s}
+}
+
+// language is a catalog entry: its plural rule and its messages
+type language struct {
+	name     string // the language's own name, for settings menus
+	plural   func(i uint64, v int) PluralForm
+	messages [numMessageIDs]forms
+}
+
+// oneOther is the rule of English and German: "1 day" but "0 days", "2 days"
+// and "1.5 days"
+func oneOther(i uint64, v int) PluralForm {
+	if i == 1 && v == 0 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+// catalog holds the messages of each output language by its ISO 639-1 code
+var catalog = map[string]language{
+	"en": {
+		name:   "English",
+		plural: oneOther,
+		messages: [numMessageIDs]forms{
+			MsgYear:         {PluralOne: "year", PluralOther: "years"},
+			MsgMonth:        {PluralOne: "month", PluralOther: "months"},
+			MsgWeek:         {PluralOne: "week", PluralOther: "weeks"},
+			MsgDay:          {PluralOne: "day", PluralOther: "days"},
+			MsgHour:         {PluralOne: "hour", PluralOther: "hours"},
+			MsgMinute:       {PluralOne: "minute", PluralOther: "minutes"},
+			MsgSecond:       {PluralOne: "second", PluralOther: "seconds"},
+			MsgMin:          text("min"),
+			MsgYearsShort:   text("%dy"),
+			MsgMonthsShort:  text("%dmo"),
+			MsgDaysShort:    text("%dd"),
+			MsgHoursShort:   text("%dh"),
+			MsgMinutesShort: text("%dm"),
+			MsgSecondsShort: text("%ds"),
+			MsgExpiresIn:    text("expires in %s"),
+			MsgExpired:      text("expired"),
+			MsgPassedAgo:    text("already passed %s ago"),
+			MsgNotYetIn:     text("not yet, in %s"),
+		},
+	},
+	"de": {
+		name:   "Deutsch",
+		plural: oneOther,
+		messages: [numMessageIDs]forms{
+			MsgYear:         {PluralOne: "Jahr", PluralOther: "Jahre"},
+			MsgMonth:        {PluralOne: "Monat", PluralOther: "Monate"},
+			MsgWeek:         {PluralOne: "Woche", PluralOther: "Wochen"},
+			MsgDay:          {PluralOne: "Tag", PluralOther: "Tage"},
+			MsgHour:         {PluralOne: "Stunde", PluralOther: "Stunden"},
+			MsgMinute:       {PluralOne: "Minute", PluralOther: "Minuten"},
+			MsgSecond:       {PluralOne: "Sekunde", PluralOther: "Sekunden"},
+			MsgMin:          text("Min."),
+			MsgYearsShort:   text("%d J."),
+			MsgMonthsShort:  text("%d Mon."),
+			MsgDaysShort:    text("%d T."),
+			MsgHoursShort:   text("%d Std."),
+			MsgMinutesShort: text("%d Min."),
+			MsgSecondsShort: text("%d Sek."),
+			MsgExpiresIn:    text("läuft ab in %s"),
+			MsgExpired:      text("abgelaufen"),
+			MsgPassedAgo:    text("bereits vor %s vergangen"),
+			MsgNotYetIn:     text("noch nicht, in %s"),
+		},
+	},
+	"fr": {
+		name: "Français",
+		// French counts 0 and 1.5 as singular: "0 jour", "1,5 jour"
+		plural: func(i uint64, v int) PluralForm {
+			if i <= 1 {
+				return PluralOne
+			}
+			return PluralOther
+		},
+		messages: [numMessageIDs]forms{
+			MsgYear:         {PluralOne: "an", PluralOther: "ans"},
+			MsgMonth:        text("mois"),
+			MsgWeek:         {PluralOne: "semaine", PluralOther: "semaines"},
+			MsgDay:          {PluralOne: "jour", PluralOther: "jours"},
+			MsgHour:         {PluralOne: "heure", PluralOther: "heures"},
+			MsgMinute:       {PluralOne: "minute", PluralOther: "minutes"},
+			MsgSecond:       {PluralOne: "seconde", PluralOther: "secondes"},
+			MsgMin:          text("min"),
+			MsgYearsShort:   text("%d a"),
+			MsgMonthsShort:  text("%d mois"),
+			MsgDaysShort:    text("%d j"),
+			MsgHoursShort:   text("%d h"),
+			MsgMinutesShort: text("%d min"),
+			MsgSecondsShort: text("%d s"),
+			MsgExpiresIn:    text("expire dans %s"),
+			MsgExpired:      text("expiré"),
+			MsgPassedAgo:    text("déjà passé il y a %s"),
+			MsgNotYetIn:     text("pas encore, dans %s"),
+		},
+	},
+	"es": {
+		name: "Español",
+		// Only exactly one is singular: "1 día" but "1,5 días"
+		plural: func(i uint64, v int) PluralForm {
+			if i == 1 && v == 0 {
+				return PluralOne
+			}
+			return PluralOther
+		},
+		messages: [numMessageIDs]forms{
+			MsgYear:         {PluralOne: "año", PluralOther: "años"},
+			MsgMonth:        {PluralOne: "mes", PluralOther: "meses"},
+			MsgWeek:         {PluralOne: "semana", PluralOther: "semanas"},
+			MsgDay:          {PluralOne: "día", PluralOther: "días"},
+			MsgHour:         {PluralOne: "hora", PluralOther: "horas"},
+			MsgMinute:       {PluralOne: "minuto", PluralOther: "minutos"},
+			MsgSecond:       {PluralOne: "segundo", PluralOther: "segundos"},
+			MsgMin:          text("min"),
+			MsgYearsShort:   text("%d a"),
+			MsgMonthsShort:  text("%d m"),
+			MsgDaysShort:    text("%d d"),
+			MsgHoursShort:   text("%d h"),
+			MsgMinutesShort: text("%d min"),
+			MsgSecondsShort: text("%d s"),
+			MsgExpiresIn:    text("caduca en %s"),
+			MsgExpired:      text("caducado"),
+			MsgPassedAgo:    text("ya pasó hace %s"),
+			MsgNotYetIn:     text("todavía no, en %s"),
+		},
+	},
+	"uk": {
+		name: "Українська",
+		// 1, 21, 31 день; 2-4, 22-24 дні; 0, 5-20, 25 днів; 1,5 дня
+		plural: func(i uint64, v int) PluralForm {
+			switch {
+			case v != 0:
+				return PluralOther
+			case i%10 == 1 && i%100 != 11:
+				return PluralOne
+			case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+				return PluralFew
+			}
+			return PluralMany
+		},
+		messages: [numMessageIDs]forms{
+			MsgYear:         {"рік", "роки", "років", "року"},
+			MsgMonth:        {"місяць", "місяці", "місяців", "місяця"},
+			MsgWeek:         {"тиждень", "тижні", "тижнів", "тижня"},
+			MsgDay:          {"день", "дні", "днів", "дня"},
+			MsgHour:         {"година", "години", "годин", "години"},
+			MsgMinute:       {"хвилина", "хвилини", "хвилин", "хвилини"},
+			MsgSecond:       {"секунда", "секунди", "секунд", "секунди"},
+			MsgMin:          text("хв"),
+			MsgYearsShort:   text("%d р."),
+			MsgMonthsShort:  text("%d міс."),
+			MsgDaysShort:    text("%d д."),
+			MsgHoursShort:   text("%d год"),
+			MsgMinutesShort: text("%d хв"),
+			MsgSecondsShort: text("%d с"),
+			MsgExpiresIn:    text("спливає через %s"),
+			MsgExpired:      text("прострочено"),
+			MsgPassedAgo:    text("вже минуло %s тому"),
+			MsgNotYetIn:     text("ще ні, через %s"),
+		},
+	},
+}
+
+// outputLanguage is the language output is written in
+var outputLanguage = "en"
+
+// SetLanguage picks the language output is written in by its ISO 639-1
+// code. A language without a catalog falls back to English.
+func SetLanguage(lang string) {
+	lang = strings.ToLower(lang)
+	if _, ok := catalog[lang]; !ok {
+		lang = "en"
+	}
+	localeMu.Lock()
+	defer localeMu.Unlock()
+	outputLanguage = lang
+}
+
+// Language returns the ISO 639-1 code of the language output is written in
+func Language() string {
+	localeMu.RLock()
+	defer localeMu.RUnlock()
+	return outputLanguage
+}
+
+// Languages lists the codes of the output languages with a catalog
+func Languages() []string {
+	codes := make([]string, 0, len(catalog))
+	for code := range catalog {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// LanguageName returns a language's name in the language itself:
+// "de" -> "Deutsch"
+func LanguageName(lang string) string {
+	return catalog[lang].name
+}
+
+// DetectLanguage picks the output language of an OS locale such as
+// "uk_UA.UTF-8" or "fr-CA": its language if it has a catalog, else English
+func DetectLanguage(locale string) string {
+	lang, _, _ := strings.Cut(strings.ReplaceAll(locale, "-", "_"), "_")
+	lang, _, _ = strings.Cut(lang, ".")
+	lang = strings.ToLower(lang)
+	if _, ok := catalog[lang]; ok {
+		return lang
+	}
+	return "en"
+}
+
+// PluralFormOf returns the plural form a number takes in lang, for the
+// number as shown without thousands separators: "1" is one in English,
+// "1.00" and "1,00" are other
+func PluralFormOf(lang, number string) PluralForm {
+	l, ok := catalog[lang]
+	if !ok {
+		l = catalog["en"]
+	}
+	number = strings.TrimPrefix(number, "-")
+	intPart, frac := number, ""
+	if i := strings.IndexAny(number, ".,"); i >= 0 {
+		intPart, frac = number[:i], number[i+1:]
+	}
+	var i uint64
+	for _, r := range intPart {
+		if r >= '0' && r <= '9' {
+			i = i*10 + uint64(r-'0')
+		}
+	}
+	return l.plural(i, len(frac))
+}
+
+// Message returns the text of a message that isn't counted, in the output
+// language
+func Message(id MessageID) string {
+	return message(Language(), id).form(PluralOther)
+}
+
+// CountWord returns the word a message takes after a number, in the output
+// language. The number is passed as shown, with its decimals:
+// CountWord("3", MsgDay) is "days", or "дні" in Ukrainian.
+func CountWord(number string, id MessageID) string {
+	lang := Language()
+	return message(lang, id).form(PluralFormOf(lang, number))
+}
+
+// FormatCount writes a number as shown followed by its counted word:
+// FormatCount("5", MsgDay) is "5 days"
+func FormatCount(number string, id MessageID) string {
+	return number + " " + CountWord(number, id)
+}
+
+// message looks a message up in lang, falling back to English
+func message(lang string, id MessageID) forms {
+	if m := catalog[lang].messages[id]; m[PluralOther] != "" {
+		return m
+	}
+	return catalog["en"].messages[id]
+}
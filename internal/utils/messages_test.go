@@ -0,0 +1,90 @@
+package utils
+
+import "testing"
+
+func TestCountWord(t *testing.T) {
+	tests := []struct {
+		lang   string
+		number string
+		want   string
+	}{
+		{"en", "1", "day"},
+		{"en", "0", "days"},
+		{"en", "2", "days"},
+		{"en", "1.00", "days"},
+		{"de", "1", "Tag"},
+		{"de", "21", "Tage"},
+		{"de", "1.5", "Tage"},
+		// French counts 0 and fractions below 2 as one
+		{"fr", "0", "jour"},
+		{"fr", "1", "jour"},
+		{"fr", "1,5", "jour"},
+		{"fr", "2", "jours"},
+		{"es", "1", "día"},
+		{"es", "0", "días"},
+		{"es", "1,5", "días"},
+		{"es", "2", "días"},
+		// Ukrainian tells one, few and many apart by the last digits, and
+		// fractions take the genitive singular
+		{"uk", "1", "день"},
+		{"uk", "21", "день"},
+		{"uk", "101", "день"},
+		{"uk", "11", "днів"},
+		{"uk", "2", "дні"},
+		{"uk", "4", "дні"},
+		{"uk", "24", "дні"},
+		{"uk", "12", "днів"},
+		{"uk", "14", "днів"},
+		{"uk", "0", "днів"},
+		{"uk", "5", "днів"},
+		{"uk", "111", "днів"},
+		{"uk", "1.5", "дня"},
+		{"uk", "-3", "дні"},
+		// A language without a catalog is written in English
+		{"ja", "2", "days"},
+	}
+
+	defer SetLanguage("en")
+	for _, tt := range tests {
+		t.Run(tt.lang+" "+tt.number, func(t *testing.T) {
+			SetLanguage(tt.lang)
+			if got := CountWord(tt.number, MsgDay); got != tt.want {
+				t.Errorf("CountWord(%q, MsgDay) in %s = %q, want %q", tt.number, tt.lang, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessagesComplete(t *testing.T) {
+	for _, lang := range Languages() {
+		for id := MessageID(0); id < numMessageIDs; id++ {
+			if catalog[lang].messages[id][PluralOther] == "" {
+				t.Errorf("%s has no text for message %d", lang, id)
+			}
+		}
+		if LanguageName(lang) == "" {
+			t.Errorf("%s has no name", lang)
+		}
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"uk_UA.UTF-8", "uk"},
+		{"de-AT", "de"},
+		{"fr_CA.utf8", "fr"},
+		{"es", "es"},
+		{"en_US.UTF-8", "en"},
+		{"ja_JP.UTF-8", "en"},
+		{"C", "en"},
+		{"", "en"},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.locale); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.locale, got, tt.want)
+		}
+	}
+}
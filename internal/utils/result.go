@@ -0,0 +1,20 @@
+package utils
+
+// Result is an evaluator's formatted output together with its primary numeric
+// value, if it has one, so later lines can reference it with \N.
+type Result struct {
+	Text       string
+	Value      float64
+	HasValue   bool
+	IsCurrency bool
+}
+
+// TextResult wraps output that has no referenceable value
+func TextResult(text string) Result {
+	return Result{Text: text}
+}
+
+// ValueResult wraps output whose primary value can be referenced
+func ValueResult(text string, value float64, isCurrency bool) Result {
+	return Result{Text: text, Value: value, HasValue: true, IsCurrency: isCurrency}
+}
@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// NormalizeExpr lower-cases an expression and collapses its spaces, so
+// patterns can match it with single spaces: "Dim  Weight 40 X 30" is
+// "dim weight 40 x 30"
+func NormalizeExpr(expr string) string {
+	return strings.Join(strings.Fields(strings.ToLower(expr)), " ")
+}
+
+// plusMinusPattern matches the "+-" and "+/-" spellings of ±
+var plusMinusPattern = regexp.MustCompile(`\+\s*/?\s*-`)
+
+// NormalizePlusMinus writes the ASCII spellings of ±, "+-" and "+/-", as
+// the sign, so tolerance bands and uncertain values accept the same ones
+func NormalizePlusMinus(expr string) string {
+	return plusMinusPattern.ReplaceAllString(expr, "±")
+}
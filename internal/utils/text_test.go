@@ -0,0 +1,37 @@
+package utils
+
+import "testing"
+
+func TestNormalizeExpr(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"Dim  Weight 40 X 30", "dim weight 40 x 30"},
+		{"  crosswind\trunway 27 ", "crosswind runway 27"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeExpr(tt.input); got != tt.expected {
+			t.Errorf("NormalizeExpr(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestNormalizePlusMinus(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"100 +- 2%", "100 ± 2%"},
+		{"12.3 +/- 0.2", "12.3 ± 0.2"},
+		{"4.7k ± 5%", "4.7k ± 5%"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizePlusMinus(tt.input); got != tt.expected {
+			t.Errorf("NormalizePlusMinus(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
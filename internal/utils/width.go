@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// DisplayWidth returns the number of columns s takes in the editor. East
+// Asian wide characters and emoji take two columns, combining marks none, and
+// everything else (including Ω, ° and box-drawing characters) one.
+func DisplayWidth(s string) int {
+	return uniseg.StringWidth(s)
+}
+
+// PadRight pads s with spaces on the right to width columns, for
+// left-aligned table cells. Unlike fmt's %-10s it counts columns, not bytes.
+func PadRight(s string, width int) string {
+	if pad := width - DisplayWidth(s); pad > 0 {
+		return s + strings.Repeat(" ", pad)
+	}
+	return s
+}
+
+// PadLeft pads s with spaces on the left to width columns, for right-aligned
+// table cells such as amounts
+func PadLeft(s string, width int) string {
+	if pad := width - DisplayWidth(s); pad > 0 {
+		return strings.Repeat(" ", pad) + s
+	}
+	return s
+}
+
+// AlignColumns lays rows out as " | " separated columns, each as wide as its
+// widest cell. Columns listed in rightAligned are padded on the left. Returns
+// one line per row.
+func AlignColumns(rows [][]string, rightAligned ...int) []string {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], DisplayWidth(cell))
+		}
+	}
+
+	right := make(map[int]bool, len(rightAligned))
+	for _, i := range rightAligned {
+		right[i] = true
+	}
+
+	lines := make([]string, len(rows))
+	for r, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			switch {
+			case right[i]:
+				cells[i] = PadLeft(cell, widths[i])
+			case i == len(row)-1:
+				cells[i] = cell // no trailing spaces
+			default:
+				cells[i] = PadRight(cell, widths[i])
+			}
+		}
+		lines[r] = strings.Join(cells, " | ")
+	}
+	return lines
+}
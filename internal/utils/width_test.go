@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"4.7 kΩ", 6},
+		{"20°C", 4},
+		{"└── ", 4},
+		{"─────", 5},
+		{"🔐", 2},
+		{"🔐 Root", 7},
+		{"中国", 4},
+		{"ｱｲｳ", 3}, // halfwidth katakana
+		{"é", 1},  // e + combining acute accent
+		{"€1,234.00", 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := DisplayWidth(tt.input); got != tt.expected {
+				t.Errorf("DisplayWidth(%q) = %d, want %d", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPad(t *testing.T) {
+	tests := []struct {
+		input string
+		width int
+		right string
+		left  string
+	}{
+		{"ab", 4, "ab  ", "  ab"},
+		{"Ω", 3, "Ω  ", "  Ω"},
+		{"中", 4, "中  ", "  中"},
+		{"🔐", 3, "🔐 ", " 🔐"},
+		{"toolong", 3, "toolong", "toolong"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := PadRight(tt.input, tt.width); got != tt.right {
+				t.Errorf("PadRight(%q, %d) = %q, want %q", tt.input, tt.width, got, tt.right)
+			}
+			if got := PadLeft(tt.input, tt.width); got != tt.left {
+				t.Errorf("PadLeft(%q, %d) = %q, want %q", tt.input, tt.width, got, tt.left)
+			}
+		})
+	}
+}
+
+func TestAlignColumns(t *testing.T) {
+	rows := [][]string{
+		{"Name", "Value", "Note"},
+		{"中国", "4.7 kΩ", "wide name"},
+		{"🔐 key", "$1", "emoji"},
+		{"plain", "20°C", ""},
+	}
+	got := AlignColumns(rows, 1)
+	want := []string{
+		"Name   |  Value | Note",
+		"中国   | 4.7 kΩ | wide name",
+		"🔐 key |     $1 | emoji",
+		"plain  |   20°C | ",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AlignColumns =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -2,7 +2,13 @@ package main
 
 import (
 	"embed"
+	"os"
+	"slices"
 	"smartcalc/internal/data"
+	"smartcalc/internal/datetime"
+	"smartcalc/internal/utils"
+	"strconv"
+	"strings"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/menu"
@@ -18,6 +24,10 @@ var assets embed.FS
 var version = "dev"
 
 func main() {
+	if runCLI(os.Args[1:]) {
+		return
+	}
+
 	app := NewApp()
 
 	appMenu := createAppMenu(app)
@@ -52,6 +62,80 @@ func createAppMenu(app *App) *menu.Menu {
 		runtime.EventsEmit(app.ctx, "menu:about")
 	})
 	appSubmenu.AddSeparator()
+	timezoneMenu := appSubmenu.AddSubmenu("Ambiguous Time Zones")
+	for _, choice := range []struct {
+		mode  datetime.AmbiguityMode
+		label string
+	}{
+		{datetime.AmbiguityShowAll, "List Every Candidate"},
+		{datetime.AmbiguityAssumeDefault, "Assume the Most Common"},
+		{datetime.AmbiguityRequireRegion, "Require a Region"},
+	} {
+		mode := choice.mode // capture for closure
+		timezoneMenu.AddRadio(choice.label, app.GetSettings().AmbiguousTimezones == string(mode), nil, func(_ *menu.CallbackData) {
+			app.SetAmbiguousTimezoneMode(string(mode))
+			runtime.EventsEmit(app.ctx, "settings:changed")
+		})
+	}
+	appSubmenu.AddCheckbox("Show Results as Fractions", app.GetSettings().Fractions, nil, func(cd *menu.CallbackData) {
+		app.SetFractionMode(cd.MenuItem.Checked)
+		runtime.EventsEmit(app.ctx, "settings:changed")
+	})
+	decimalMenu := appSubmenu.AddSubmenu("Decimal Mark")
+	for _, choice := range []struct{ mark, label string }{
+		{DecimalMarkAuto, "Automatic (from System Locale)"},
+		{DecimalMarkPeriod, "Period (1,234.56)"},
+		{DecimalMarkComma, "Comma (1.234,56)"},
+	} {
+		mark := choice.mark // capture for closure
+		decimalMenu.AddRadio(choice.label, app.GetSettings().DecimalMark == mark, nil, func(_ *menu.CallbackData) {
+			app.SetDecimalMark(mark)
+			runtime.EventsEmit(app.ctx, "settings:changed")
+		})
+	}
+	languageMenu := appSubmenu.AddSubmenu("Output Language")
+	languages := []struct{ code, label string }{{LanguageAuto, "Automatic (from System Locale)"}}
+	for _, code := range utils.Languages() {
+		languages = append(languages, struct{ code, label string }{code, utils.LanguageName(code)})
+	}
+	for _, choice := range languages {
+		code := choice.code // capture for closure
+		languageMenu.AddRadio(choice.label, app.GetSettings().Language == code, nil, func(_ *menu.CallbackData) {
+			app.SetLanguage(code)
+			runtime.EventsEmit(app.ctx, "settings:changed")
+		})
+	}
+	precisionMenu := appSubmenu.AddSubmenu("Decimal Places")
+	for _, decimals := range []int{0, 2, 4, 6, 8, 10} {
+		n := decimals // capture for closure
+		precisionMenu.AddRadio(strconv.Itoa(n), app.GetSettings().Precision == n, nil, func(_ *menu.CallbackData) {
+			app.SetPrecision(n)
+			runtime.EventsEmit(app.ctx, "settings:changed")
+		})
+	}
+	evaluatorsMenu := appSubmenu.AddSubmenu("Evaluators")
+	disabled := app.GetSettings().DisabledEvaluators
+	for _, name := range app.GetEvaluatorNames() {
+		n := name // capture for closure
+		evaluatorsMenu.AddCheckbox(n, !slices.Contains(disabled, n), nil, func(cd *menu.CallbackData) {
+			app.SetEvaluatorEnabled(n, cd.MenuItem.Checked)
+			runtime.EventsEmit(app.ctx, "settings:changed")
+		})
+	}
+	appSubmenu.AddText("Notes Folder...", nil, func(_ *menu.CallbackData) {
+		app.ChooseNotesDir()
+	})
+	appSubmenu.AddText("Reload My Functions", nil, func(_ *menu.CallbackData) {
+		if problems := app.ReloadUserFunctions(); len(problems) > 0 {
+			runtime.MessageDialog(app.ctx, runtime.MessageDialogOptions{
+				Type:    runtime.WarningDialog,
+				Title:   "My Functions",
+				Message: "Some definitions in " + app.GetUserFunctionsPath() + " were skipped:\n\n" + strings.Join(problems, "\n"),
+			})
+		}
+		runtime.EventsEmit(app.ctx, "settings:changed")
+	})
+	appSubmenu.AddSeparator()
 	appSubmenu.AddText("Quit SmartCalc", keys.CmdOrCtrl("q"), func(_ *menu.CallbackData) {
 		runtime.Quit(app.ctx)
 	})
@@ -65,6 +149,9 @@ func createAppMenu(app *App) *menu.Menu {
 	fileMenu.AddText("Open...", keys.CmdOrCtrl("o"), func(_ *menu.CallbackData) {
 		runtime.EventsEmit(app.ctx, "menu:open")
 	})
+	fileMenu.AddText("Find in Recent Files...", keys.CmdOrCtrl("p"), func(_ *menu.CallbackData) {
+		runtime.EventsEmit(app.ctx, "menu:quickOpen")
+	})
 	fileMenu.AddText("Save", keys.CmdOrCtrl("s"), func(_ *menu.CallbackData) {
 		runtime.EventsEmit(app.ctx, "menu:save")
 	})
@@ -72,6 +159,14 @@ func createAppMenu(app *App) *menu.Menu {
 		runtime.EventsEmit(app.ctx, "menu:saveAs")
 	})
 	fileMenu.AddSeparator()
+	exportMenu := fileMenu.AddSubmenu("Export")
+	exportMenu.AddText("Markdown...", nil, func(_ *menu.CallbackData) {
+		runtime.EventsEmit(app.ctx, "menu:export", "markdown")
+	})
+	exportMenu.AddText("HTML...", nil, func(_ *menu.CallbackData) {
+		runtime.EventsEmit(app.ctx, "menu:export", "html")
+	})
+	fileMenu.AddSeparator()
 
 	// Recent files submenu
 	recentMenu := fileMenu.AddSubmenu("Recent")
@@ -95,18 +190,36 @@ func createAppMenu(app *App) *menu.Menu {
 	editMenu.AddText("Copy", keys.CmdOrCtrl("c"), func(_ *menu.CallbackData) {
 		runtime.EventsEmit(app.ctx, "menu:copy")
 	})
+	editMenu.AddText("Copy As...", keys.CmdOrCtrl("C"), func(_ *menu.CallbackData) {
+		runtime.EventsEmit(app.ctx, "menu:copyAs")
+	})
 	editMenu.AddText("Paste", keys.CmdOrCtrl("v"), func(_ *menu.CallbackData) {
 		runtime.EventsEmit(app.ctx, "menu:paste")
 	})
+	editMenu.AddSeparator()
+	editMenu.AddText("Refresh Document", keys.CmdOrCtrl("r"), func(_ *menu.CallbackData) {
+		runtime.EventsEmit(app.ctx, "menu:refresh")
+	})
+
+	// Evaluate menu
+	evaluateMenu := appMenu.AddSubmenu("Evaluate")
+	evaluateMenu.AddText("Refresh Network Results", keys.CmdOrCtrl("R"), func(_ *menu.CallbackData) {
+		runtime.EventsEmit(app.ctx, "menu:refreshNetwork")
+	})
 
 	// Snippets menu - populated from data package
 	snippetsMenu := appMenu.AddSubmenu("Snippets")
 	for _, category := range data.GetSnippetCategories() {
 		categoryMenu := snippetsMenu.AddSubmenu(category.Name)
 		for _, s := range category.Snippets {
-			snippet := s.Content
+			snippet := s
 			categoryMenu.AddText(s.Name, nil, func(_ *menu.CallbackData) {
-				runtime.EventsEmit(app.ctx, "menu:snippet", snippet)
+				// A template asks for its placeholders before it is inserted
+				if template, ok := snippet.Template(); ok {
+					runtime.EventsEmit(app.ctx, "menu:template", template)
+					return
+				}
+				runtime.EventsEmit(app.ctx, "menu:snippet", snippet.Content)
 			})
 		}
 	}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// recoveryInterval is how often unsaved editor text is written to the
+// recovery file
+const recoveryInterval = 15 * time.Second
+
+// recoveryFileName is the recovery file in the config directory
+const recoveryFileName = "recovery.json"
+
+// RecoveredDocument is editor text that was not saved when the app last
+// quit, offered back to the editor on startup
+type RecoveredDocument struct {
+	Path     string `json:"path"` // the document the text was typed into; empty if untitled
+	Content  string `json:"content"`
+	Modified string `json:"modified,omitempty"` // when the text was written, "2006-01-02 15:04"
+}
+
+// recoveryBuffer holds the latest unsaved editor text and writes it to the
+// recovery file when it has changed since the last write
+type recoveryBuffer struct {
+	mu    sync.Mutex
+	path  string
+	doc   RecoveredDocument
+	dirty bool
+}
+
+func newRecoveryBuffer(path string) *recoveryBuffer {
+	return &recoveryBuffer{path: path}
+}
+
+// mark records the editor text of a document with unsaved changes
+func (r *recoveryBuffer) mark(path, content string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.doc = RecoveredDocument{Path: path, Content: content}
+	r.dirty = true
+}
+
+// flush writes the recorded text to the recovery file if it changed since
+// the last write
+func (r *recoveryBuffer) flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.dirty {
+		return nil
+	}
+	data, err := json.Marshal(r.doc)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(r.path, data); err != nil {
+		return err
+	}
+	r.dirty = false
+	return nil
+}
+
+// clear forgets the recorded text and removes the recovery file, after the
+// document is saved or closed on purpose
+func (r *recoveryBuffer) clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.doc = RecoveredDocument{}
+	r.dirty = false
+	os.Remove(r.path)
+}
+
+// run flushes the recorded text every interval until ctx is done
+func (r *recoveryBuffer) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.flush()
+		}
+	}
+}
+
+// load reads the recovery file and when it was written. ok is false when
+// there is no recovery file or it cannot be read.
+func (r *recoveryBuffer) load() (doc RecoveredDocument, written time.Time, ok bool) {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return doc, written, false
+	}
+	data, err := os.ReadFile(r.path)
+	if err != nil || json.Unmarshal(data, &doc) != nil {
+		return doc, written, false
+	}
+	doc.Modified = info.ModTime().Format("2006-01-02 15:04")
+	return doc, info.ModTime(), true
+}
+
+// pending returns the recovered text to offer back, if any. Text that is no
+// newer than the document it was typed into is removed, since that document
+// was saved after it.
+func (r *recoveryBuffer) pending() (RecoveredDocument, bool) {
+	doc, written, ok := r.load()
+	if !ok {
+		return doc, false
+	}
+	var saved time.Time
+	if doc.Path != "" {
+		if info, err := os.Stat(doc.Path); err == nil {
+			saved = info.ModTime()
+		}
+	}
+	if !shouldOfferRecovery(written, saved) {
+		r.clear()
+		return doc, false
+	}
+	return doc, true
+}
+
+// shouldOfferRecovery decides if recovered text written at recovered is
+// offered back: only when it is newer than the document it was typed into,
+// as saving that document after the text was written means nothing was lost.
+// saved is zero when the text was untitled or its document is gone.
+func shouldOfferRecovery(recovered, saved time.Time) bool {
+	if recovered.IsZero() {
+		return false
+	}
+	return saved.IsZero() || recovered.After(saved)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it over path, so a crash mid-write never leaves a half-written file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// MarkDirty records the editor text of a document with unsaved changes,
+// which is written to the recovery file within recoveryInterval
+func (a *App) MarkDirty(text string) {
+	a.recovery.mark(a.currentFile, text)
+}
+
+// CheckRecovery emits "recovery:available" with the text left in the
+// recovery file by a crash, and the path of the document it was typed into,
+// when it is newer than that document. The editor calls it once it has
+// loaded the last opened document, and answers with DiscardRecovery or by
+// restoring the text.
+func (a *App) CheckRecovery() {
+	if doc, ok := a.recovery.pending(); ok {
+		runtime.EventsEmit(a.ctx, "recovery:available", doc)
+	}
+}
+
+// DiscardRecovery removes the recovery file
+func (a *App) DiscardRecovery() {
+	a.recovery.clear()
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestShouldOfferRecovery(t *testing.T) {
+	base := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		recovered time.Time
+		saved     time.Time
+		expected  bool
+	}{
+		{"newer than the document", base.Add(time.Minute), base, true},
+		{"older than the document", base, base.Add(time.Minute), false},
+		{"same time as the document", base, base, false},
+		{"untitled document", base, time.Time{}, true},
+		{"no recovery file", time.Time{}, base, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldOfferRecovery(tt.recovered, tt.saved); got != tt.expected {
+				t.Errorf("shouldOfferRecovery(%v, %v) = %v, want %v", tt.recovered, tt.saved, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRecoveryBuffer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "smartcalc", recoveryFileName)
+	r := newRecoveryBuffer(path)
+
+	if err := r.flush(); err != nil {
+		t.Fatalf("flush with nothing marked: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("flush with nothing marked wrote %s", path)
+	}
+
+	r.mark("/notes/budget.txt", "rent = $1200 =")
+	if err := r.flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	doc, written, ok := r.load()
+	if !ok {
+		t.Fatal("load found no recovery file after flush")
+	}
+	if doc.Path != "/notes/budget.txt" || doc.Content != "rent = $1200 =" {
+		t.Errorf("load = %+v, want the marked document", doc)
+	}
+	if written.IsZero() || doc.Modified == "" {
+		t.Errorf("load gave no write time: %v, %q", written, doc.Modified)
+	}
+
+	// A flush with nothing new marked leaves the file as it is
+	old := written.Add(-time.Hour)
+	os.Chtimes(path, old, old)
+	r.flush()
+	if _, written, _ := r.load(); !written.Equal(old) {
+		t.Errorf("flush without changes rewrote the recovery file")
+	}
+
+	r.clear()
+	if _, _, ok := r.load(); ok {
+		t.Error("load found a recovery file after clear")
+	}
+	if err := r.flush(); err != nil {
+		t.Fatalf("flush after clear: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("flush after clear wrote the recovery file again")
+	}
+}
+
+func TestRecoveryBufferPending(t *testing.T) {
+	dir := t.TempDir()
+	budget := filepath.Join(dir, "budget.txt")
+	other := filepath.Join(dir, "other.txt")
+	for _, p := range []string{budget, other} {
+		if err := os.WriteFile(p, []byte("saved"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := newRecoveryBuffer(filepath.Join(dir, recoveryFileName))
+	now := time.Now()
+
+	// Text typed into budget.txt is compared with budget.txt, however
+	// recently another document was saved
+	r.mark(budget, "rent = $1200 =")
+	r.flush()
+	os.Chtimes(budget, now.Add(-time.Hour), now.Add(-time.Hour))
+	os.Chtimes(other, now.Add(time.Hour), now.Add(time.Hour))
+	doc, ok := r.pending()
+	if !ok || doc.Path != budget {
+		t.Fatalf("pending() = %+v, %v; want the text of %s", doc, ok, budget)
+	}
+
+	// Saving budget.txt after the text was written leaves nothing to recover
+	os.Chtimes(budget, now.Add(time.Hour), now.Add(time.Hour))
+	if _, ok := r.pending(); ok {
+		t.Error("pending() offered text older than its saved document")
+	}
+	if _, _, ok := r.load(); ok {
+		t.Error("pending() kept the recovery file of a saved document")
+	}
+
+	// Untitled text is always offered
+	r.mark("", "2 + 2 =")
+	r.flush()
+	if doc, ok := r.pending(); !ok || doc.Path != "" {
+		t.Errorf("pending() = %+v, %v; want the untitled text", doc, ok)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "recovery.json")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(path, []byte("new")); err != nil {
+		t.Fatalf("writeFileAtomic: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "new" {
+		t.Errorf("file holds %q, %v; want \"new\"", data, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("writeFileAtomic left %d files in the directory, want 1", len(entries))
+	}
+}